@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+	"github.com/frostime/my-sftp/shell"
+)
+
+// runBackup 实现 my-sftp backup 子命令
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	keep := fs.Int("keep", 0, "Keep only the newest `N` snapshots (0 = keep all)")
+	verify := fs.Bool("verify", false, "Compare size and mtime of every file in current against the local tree before snapshotting")
+	verifyReport := fs.String("verify-report", "", "Write the verification report to `file` (implies --verify)")
+	positional, err := parseSubcommandArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 2 || *keep < 0 {
+		fmt.Println("Usage: my-sftp backup [--keep N] [--verify] [--verify-report FILE] <local-dir> <destination>:<remote-dir>")
+		return exitUsage
+	}
+
+	destination, remoteBase, ok := config.SplitRemoteSpec(positional[1])
+	if !ok {
+		fmt.Printf("Error: backup target must be <destination>:<remote-dir>, got %s\n", positional[1])
+		return exitUsage
+	}
+
+	c, err := connect(destination, client.StartEager)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return connectExitCode(err)
+	}
+	defer c.Close()
+	c.Subscribe(shell.TransferEventPrinter(c))
+
+	result, err := c.Backup(positional[0], remoteBase, &client.BackupOptions{
+		Keep:         *keep,
+		ShowProgress: true,
+		Concurrency:  c.Concurrency(),
+		Verify:       *verify || *verifyReport != "",
+	})
+	if result != nil {
+		for _, skipped := range result.Skipped {
+			fmt.Printf("Skipped non-regular file: %s\n", skipped)
+		}
+		if result.Snapshot != "" {
+			fmt.Printf("✓ Snapshot %s: %d uploaded, %d unchanged, %d removed\n",
+				result.Snapshot, result.Uploaded, result.Unchanged, result.Removed)
+		}
+		for _, pruned := range result.Pruned {
+			fmt.Printf("Pruned old snapshot %s\n", pruned)
+		}
+	}
+	if err != nil {
+		var verr *client.VerifyError
+		if errors.As(err, &verr) {
+			writeBackupVerifyReport(verr, *verifyReport)
+		}
+		fmt.Printf("Backup failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeBackupVerifyReport 输出校验报告到文件或 stdout
+func writeBackupVerifyReport(verr *client.VerifyError, file string) {
+	if file == "" {
+		client.WriteVerifyReport(os.Stdout, verr)
+		return
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		fmt.Printf("Error: write verify report: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if err := client.WriteVerifyReport(f, verr); err != nil {
+		fmt.Printf("Error: write verify report: %v\n", err)
+		return
+	}
+	fmt.Printf("Verify report written to %s\n", file)
+}
+
+// parseSubcommandArgs 解析子命令参数，允许选项出现在位置参数之后
+func parseSubcommandArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/shell"
+)
+
+// runBatch 连接 destination 并执行 file 中的命令（- 表示标准输入），返回进程退出码：
+// 全部成功为 0，否则见 exitcode.go（有文件传输失败为 exitPartial，连接断开为 exitConnectionLost）
+func runBatch(destination, file string, continueOnError, execOnly bool) int {
+	batchMode = true
+
+	var in io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+		in = f
+	}
+	return runCommands(destination, execOnly, func(sh *shell.Shell) error {
+		return sh.RunBatch(in, continueOnError)
+	})
+}
+
+// runInline 连接 destination 并执行 -e 给出的以 ; 分隔的命令，退出码同 runBatch
+func runInline(destination, commands string, continueOnError, execOnly bool) int {
+	return runCommands(destination, execOnly, func(sh *shell.Shell) error {
+		return sh.RunInline(commands, continueOnError)
+	})
+}
+
+// runCommands 建立连接、应用 profile 后执行 run，返回进程退出码
+func runCommands(destination string, execOnly bool, run func(*shell.Shell) error) int {
+	mode := client.StartEager
+	if execOnly {
+		mode = client.StartExecOnly
+	}
+	c, err := connect(destination, mode)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return connectExitCode(err)
+	}
+	defer c.Close()
+
+	sh := shell.NewShell(c)
+	applyProfile(sh, destination)
+	sh.SetJSONOutput(jsonOutput)
+	err = run(sh)
+	if err != nil && jsonOutput {
+		fmt.Fprintf(os.Stderr, "Failed: %v\n", err)
+	} else if err != nil {
+		fmt.Printf("Failed: %v\n", err)
+	}
+	return finishCommands(sh, commandExitCode(c, sh, err))
+}
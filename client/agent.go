@@ -0,0 +1,63 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrNoAgent 没有找到正在运行的 SSH agent
+var ErrNoAgent = errors.New("no SSH agent running")
+
+// agentSource 一个可能存在的 SSH agent：Unix 上为 SSH_AUTH_SOCK，Windows 上还有 OpenSSH 的命名管道和 Pageant
+type agentSource struct {
+	name string
+	dial func() (io.ReadWriteCloser, error)
+}
+
+// AgentSigners 连接本机所有可用的 SSH agent（见 agentSources），返回列出其中密钥的函数和 agent 的名称。
+// 连接在进程结束前保持打开，重连时继续使用；每次调用时重新列出密钥，之后加入 agent 的密钥也能使用。
+// 返回的是函数而不是 ssh.AuthMethod：ssh 库对同一种认证方式只尝试第一个，agent 和密钥文件需合并为一个 PublicKeysCallback。
+// 一个 agent 都没有时返回 ErrNoAgent
+func AgentSigners() (func() ([]ssh.Signer, error), []string, error) {
+	var agents []agent.ExtendedAgent
+	var names []string
+	var errs []error
+	for _, source := range agentSources() {
+		conn, err := source.dial()
+		if err != nil {
+			if !errors.Is(err, ErrNoAgent) {
+				errs = append(errs, fmt.Errorf("%s: %w", source.name, err))
+			}
+			continue
+		}
+		agents = append(agents, agent.NewClient(conn))
+		names = append(names, source.name)
+	}
+	if len(agents) == 0 {
+		if len(errs) > 0 {
+			return nil, nil, errors.Join(errs...)
+		}
+		return nil, nil, ErrNoAgent
+	}
+	return func() ([]ssh.Signer, error) {
+		var signers []ssh.Signer
+		var failed []string
+		for i, a := range agents {
+			s, err := a.Signers()
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", names[i], err))
+				continue
+			}
+			signers = append(signers, s...)
+		}
+		if len(signers) == 0 && len(failed) > 0 {
+			return nil, fmt.Errorf("list agent keys: %s", strings.Join(failed, "; "))
+		}
+		return signers, nil
+	}, names, nil
+}
@@ -0,0 +1,76 @@
+//go:build !windows
+
+package client
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAgentSignersNoAgent(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if _, _, err := AgentSigners(); !errors.Is(err, ErrNoAgent) {
+		t.Fatalf("AgentSigners() error = %v, want ErrNoAgent", err)
+	}
+
+	t.Setenv("SSH_AUTH_SOCK", filepath.Join(t.TempDir(), "missing.sock"))
+	if _, _, err := AgentSigners(); err == nil || errors.Is(err, ErrNoAgent) {
+		t.Fatalf("AgentSigners() with a dead socket error = %v, want a dial error", err)
+	}
+}
+
+func TestAgentSignersAuthSock(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+
+	keyring := agent.NewKeyring()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	signers, names, err := AgentSigners()
+	if err != nil {
+		t.Fatalf("AgentSigners() error = %v", err)
+	}
+	if len(names) != 1 || names[0] != "SSH_AUTH_SOCK" {
+		t.Fatalf("names = %v, want [SSH_AUTH_SOCK]", names)
+	}
+	if got, err := signers(); err != nil || len(got) != 0 {
+		t.Fatalf("signers() on empty agent = %d keys, %v", len(got), err)
+	}
+
+	// 之后加入 agent 的密钥在下次列出时可用
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := signers()
+	if err != nil || len(got) != 1 {
+		t.Fatalf("signers() = %d keys, %v; want 1 key", len(got), err)
+	}
+	want, _ := ssh.NewPublicKey(key.Public())
+	if string(got[0].PublicKey().Marshal()) != string(want.Marshal()) {
+		t.Fatal("agent returned a different key")
+	}
+	if _, err := got[0].Sign(rand.Reader, []byte("data")); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package client
+
+import (
+	"io"
+	"net"
+	"os"
+)
+
+// agentSources Unix 上只有 SSH_AUTH_SOCK 指向的 agent
+func agentSources() []agentSource {
+	return []agentSource{{name: "SSH_AUTH_SOCK", dial: dialAuthSock}}
+}
+
+func dialAuthSock() (io.ReadWriteCloser, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, ErrNoAgent
+	}
+	return net.Dial("unix", socket)
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package client
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// openSSHAgentPipe Windows 自带的 OpenSSH agent 服务（ssh-agent）监听的命名管道
+const openSSHAgentPipe = `\\.\pipe\openssh-ssh-agent`
+
+// agentSources Windows 上依次尝试 SSH_AUTH_SOCK（命名管道或 Unix socket）、
+// OpenSSH agent 的命名管道和 PuTTY 的 Pageant，正在运行的都会使用
+func agentSources() []agentSource {
+	sources := []agentSource{}
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket != "" {
+		sources = append(sources, agentSource{name: "SSH_AUTH_SOCK", dial: func() (io.ReadWriteCloser, error) {
+			return dialAgentPath(socket)
+		}})
+	}
+	if !strings.EqualFold(socket, openSSHAgentPipe) {
+		sources = append(sources, agentSource{name: "OpenSSH agent", dial: func() (io.ReadWriteCloser, error) {
+			conn, err := dialAgentPath(openSSHAgentPipe)
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, ErrNoAgent // 服务未启动
+			}
+			return conn, err
+		}})
+	}
+	return append(sources, agentSource{name: "Pageant", dial: dialPageant})
+}
+
+// dialAgentPath 连接命名管道（\\.\pipe\...）或 Unix socket
+func dialAgentPath(path string) (io.ReadWriteCloser, error) {
+	if strings.HasPrefix(path, `\\.\pipe\`) {
+		return os.OpenFile(path, os.O_RDWR, 0)
+	}
+	return net.Dial("unix", path)
+}
@@ -0,0 +1,401 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// BackupCurrentDir 备份根目录下保存最新镜像的子目录
+	BackupCurrentDir = "current"
+	// BackupSnapshotLayout 快照目录名的时间格式（按字典序即时间序）
+	BackupSnapshotLayout = "2006-01-02_150405"
+
+	backupPartSuffix = ".my-sftp-part"
+)
+
+// BackupOptions 备份选项
+type BackupOptions struct {
+	Keep         int  // 保留的快照数量，0 表示全部保留
+	ShowProgress bool // 显示进度条
+	Concurrency  int  // 并发数
+	Verify       bool // 快照前比较 current 与本地的大小和 mtime，不一致时返回 *VerifyError
+}
+
+// BackupResult 一次备份的统计
+type BackupResult struct {
+	Snapshot  string   // 新快照的远程路径
+	Uploaded  int      // 新增或变化的文件数
+	Unchanged int      // 与上次相同、保持硬链接的文件数
+	Removed   int      // 本地已删除、从 current 中移除的条目数
+	Skipped   []string // 非普通文件（符号链接、设备等）
+	Pruned    []string // 按 Keep 删除的旧快照
+}
+
+// Snapshot 备份根目录下的一个时间点快照
+type Snapshot struct {
+	Name string
+	Path string
+	Time time.Time
+}
+
+// Backup 将本地目录以 rsync 风格的轮转快照推送到 remoteBase：
+//
+//	remoteBase/current             最新镜像
+//	remoteBase/2006-01-02_150405   每次备份后 current 的硬链接快照
+//
+// 变化的文件先写入临时文件再 rename 覆盖，不会修改旧快照共享的 inode；
+// 未变化的文件（大小和 mtime 相同）不上传，在快照间共享同一份数据。
+func (c *Client) Backup(localDir, remoteBase string, opts *BackupOptions) (*BackupResult, error) {
+	if opts == nil {
+		opts = &BackupOptions{ShowProgress: true, Concurrency: c.Concurrency()}
+	}
+	localDir = c.ResolveLocalPath(localDir)
+	remoteBase = c.ResolveRemotePath(remoteBase)
+	current := path.Join(remoteBase, BackupCurrentDir)
+
+	stat, err := os.Stat(localDir)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", localDir)
+	}
+
+	result := &BackupResult{}
+	localFiles, localDirs, err := scanBackupSource(localDir, result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.ensureRemoteDir(current); err != nil {
+		return nil, fmt.Errorf("create %s: %w", current, err)
+	}
+	remoteEntries, err := c.scanRemoteTree(current)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 上传新增/变化的文件到临时文件
+	var tasks []transferTask
+	for rel, info := range localFiles {
+		remoteFile := path.Join(current, rel)
+		if remote, ok := remoteEntries[rel]; ok {
+			if !remote.IsDir() && remote.Size() == info.Size() && remote.ModTime().Unix() == info.ModTime().Unix() {
+				result.Unchanged++
+				continue
+			}
+			if remote.IsDir() {
+				if err := c.sftpConn().RemoveAll(remoteFile); err != nil {
+					return nil, fmt.Errorf("replace directory %s: %w", remoteFile, err)
+				}
+				forgetRemoteSubtree(remoteEntries, rel)
+			}
+		}
+		tasks = append(tasks, transferTask{
+			localPath:  filepath.Join(localDir, filepath.FromSlash(rel)),
+			remotePath: remoteFile + backupPartSuffix,
+			isUpload:   true,
+			size:       info.Size(),
+		})
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].remotePath < tasks[j].remotePath })
+
+	for rel := range localDirs {
+		if remote, ok := remoteEntries[rel]; ok && !remote.IsDir() {
+			if err := c.sftpConn().Remove(path.Join(current, rel)); err != nil {
+				return nil, fmt.Errorf("replace file %s: %w", rel, err)
+			}
+			delete(remoteEntries, rel)
+		}
+	}
+	dirs := c.collectRemoteDirsForUpload(tasks)
+	for rel := range localDirs {
+		dirs = append(dirs, path.Join(current, rel))
+	}
+	if err := c.ensureRemoteDirsExist(dirs); err != nil {
+		return nil, fmt.Errorf("create remote dirs: %w", err)
+	}
+
+	if len(tasks) > 0 {
+		fmt.Printf("Uploading %d new or changed file(s), %d unchanged\n", len(tasks), result.Unchanged)
+		// 任一文件失败整个快照都会作废，不必继续传输其余文件
+		transferOpts := &TransferOptions{ShowProgress: opts.ShowProgress, Concurrency: opts.Concurrency, MaxDepth: -1, FailFast: true}
+		if _, err := c.executeTasks(tasks, transferOpts); err != nil {
+			for _, task := range tasks {
+				c.sftpConn().Remove(task.remotePath)
+			}
+			return nil, fmt.Errorf("backup aborted, no snapshot created: %w", err)
+		}
+	}
+
+	// 2. 临时文件就位并保留 mtime，供下次比较
+	for _, task := range tasks {
+		final := strings.TrimSuffix(task.remotePath, backupPartSuffix)
+		if err := c.replaceRemoteFile(task.remotePath, final); err != nil {
+			return nil, fmt.Errorf("install %s: %w", final, err)
+		}
+		mtime := localFiles[strings.TrimPrefix(final, current+"/")].ModTime()
+		if err := c.sftpConn().Chtimes(final, mtime, mtime); err != nil {
+			return nil, fmt.Errorf("set mtime %s: %w", final, err)
+		}
+	}
+	result.Uploaded = len(tasks)
+
+	// 3. 移除本地已删除的条目（旧快照中仍保留）
+	removed, err := c.removeStaleEntries(current, remoteEntries, localFiles, localDirs)
+	result.Removed = removed
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Verify {
+		if err := c.verifyTasks(backupVerifyTasks(localDir, current, localFiles), true, opts.Concurrency); err != nil {
+			return result, err
+		}
+	}
+
+	// 4. 硬链接快照
+	snapshot := path.Join(remoteBase, time.Now().Format(BackupSnapshotLayout))
+	if _, err := c.sftpConn().Stat(snapshot); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", snapshot)
+	}
+	if err := c.hardlinkTree(current, snapshot); err != nil {
+		return nil, fmt.Errorf("create snapshot: %w", err)
+	}
+	result.Snapshot = snapshot
+
+	// 5. 轮转
+	if opts.Keep > 0 {
+		pruned, err := c.pruneSnapshots(remoteBase, opts.Keep)
+		result.Pruned = pruned
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// ListSnapshots 列出 remoteBase 下的快照，按时间从旧到新排序
+func (c *Client) ListSnapshots(remoteBase string) ([]Snapshot, error) {
+	remoteBase = c.ResolveRemotePath(remoteBase)
+	entries, err := c.sftpConn().ReadDir(remoteBase)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		t, err := time.ParseInLocation(BackupSnapshotLayout, entry.Name(), time.Local)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{Name: entry.Name(), Path: path.Join(remoteBase, entry.Name()), Time: t})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots, nil
+}
+
+// snapshotQueryLayouts --at 支持的时间格式及其精度
+var snapshotQueryLayouts = []struct {
+	layout    string
+	precision time.Duration
+}{
+	{"2006-01-02", 24 * time.Hour},
+	{"2006-01-02 15:04", time.Minute},
+	{"2006-01-02T15:04", time.Minute},
+	{"2006-01-02 15:04:05", time.Second},
+	{"2006-01-02T15:04:05", time.Second},
+}
+
+// SelectSnapshot 按快照名或时间点选择快照：返回该时间点（含当天/当分钟）之前最新的快照
+// snapshots 需按时间从旧到新排序（见 ListSnapshots）
+func SelectSnapshot(snapshots []Snapshot, at string) (Snapshot, error) {
+	if len(snapshots) == 0 {
+		return Snapshot{}, fmt.Errorf("no snapshots found")
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Name == at {
+			return snapshot, nil
+		}
+	}
+
+	for _, q := range snapshotQueryLayouts {
+		t, err := time.ParseInLocation(q.layout, at, time.Local)
+		if err != nil {
+			continue
+		}
+		cutoff := t.Add(q.precision)
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			if snapshots[i].Time.Before(cutoff) {
+				return snapshots[i], nil
+			}
+		}
+		return Snapshot{}, fmt.Errorf("no snapshot at or before %s (oldest is %s)", at, snapshots[0].Name)
+	}
+	return Snapshot{}, fmt.Errorf("invalid --at value: %s (want a snapshot name or YYYY-MM-DD[ HH:MM[:SS]])", at)
+}
+
+// backupVerifyTasks 为所有本地文件生成校验任务（current 中的对应路径）
+func backupVerifyTasks(localDir, current string, localFiles map[string]os.FileInfo) []transferTask {
+	tasks := make([]transferTask, 0, len(localFiles))
+	for rel, info := range localFiles {
+		tasks = append(tasks, transferTask{
+			localPath:  filepath.Join(localDir, filepath.FromSlash(rel)),
+			remotePath: path.Join(current, rel),
+			isUpload:   true,
+			size:       info.Size(),
+		})
+	}
+	return tasks
+}
+
+// scanBackupSource 收集本地普通文件与目录（相对路径，斜杠分隔），特殊文件记入 result.Skipped
+func scanBackupSource(localDir string, result *BackupResult) (map[string]os.FileInfo, map[string]struct{}, error) {
+	entries, err := scanLocalTree(localDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, dirs, special := splitTreeEntries(entries)
+	for _, rel := range special {
+		result.Skipped = append(result.Skipped, filepath.Join(localDir, filepath.FromSlash(rel)))
+	}
+	return files, dirs, nil
+}
+
+// scanRemoteTree 递归收集远程目录下的条目（相对路径），不含根目录下的锁文件 LockFileName
+func (c *Client) scanRemoteTree(root string) (map[string]os.FileInfo, error) {
+	entries := make(map[string]os.FileInfo)
+	walker := c.sftpConn().Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", walker.Path(), err)
+		}
+		if walker.Path() == root {
+			continue
+		}
+		rel := strings.TrimPrefix(walker.Path(), root+"/")
+		if rel == LockFileName {
+			continue // 同步时持有的锁，不是目录内容
+		}
+		entries[rel] = walker.Stat()
+	}
+	return entries, nil
+}
+
+// forgetRemoteSubtree 从扫描结果中移除 rel 及其子项（已被替换的条目）
+func forgetRemoteSubtree(entries map[string]os.FileInfo, rel string) {
+	delete(entries, rel)
+	for p := range entries {
+		if strings.HasPrefix(p, rel+"/") {
+			delete(entries, p)
+		}
+	}
+}
+
+// removeStaleEntries 删除 current 中本地已不存在的条目
+func (c *Client) removeStaleEntries(current string, remoteEntries, localFiles map[string]os.FileInfo, localDirs map[string]struct{}) (int, error) {
+	var stale []string
+	for rel, info := range remoteEntries {
+		if strings.HasSuffix(rel, backupPartSuffix) {
+			stale = append(stale, rel)
+			continue
+		}
+		if info.IsDir() {
+			if _, ok := localDirs[rel]; !ok {
+				stale = append(stale, rel)
+			}
+		} else if _, ok := localFiles[rel]; !ok {
+			if _, isDir := localDirs[rel]; !isDir {
+				stale = append(stale, rel)
+			}
+		}
+	}
+	sort.Strings(stale)
+
+	removed := 0
+	var lastDir string
+	for _, rel := range stale {
+		if lastDir != "" && strings.HasPrefix(rel, lastDir+"/") {
+			continue
+		}
+		target := path.Join(current, rel)
+		if remoteEntries[rel].IsDir() {
+			lastDir = rel
+			if err := c.sftpConn().RemoveAll(target); err != nil {
+				return removed, fmt.Errorf("remove %s: %w", target, err)
+			}
+		} else if err := c.sftpConn().Remove(target); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("remove %s: %w", target, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// replaceRemoteFile 用 src 原子替换 dst（服务器不支持 posix-rename 时见 swapRename）
+func (c *Client) replaceRemoteFile(src, dst string) error {
+	if err := c.sftpConn().PosixRename(src, dst); err == nil {
+		return nil
+	}
+	return c.swapRename(src, dst)
+}
+
+// hardlinkTree 用硬链接复制目录树：优先远程 cp -al，失败时使用 hardlink@openssh.com 扩展
+func (c *Client) hardlinkTree(src, dst string) error {
+	var stderr bytes.Buffer
+	command := "cp -al -- " + shellJoin(src, dst)
+	if err := c.ExecuteRemote(command, nil, io.Discard, &stderr); err == nil {
+		return nil
+	}
+	// cp 可能已创建部分目录
+	if _, err := c.sftpConn().Stat(dst); err == nil {
+		if err := c.sftpConn().RemoveAll(dst); err != nil {
+			return err
+		}
+	}
+
+	walker := c.sftpConn().Walk(src)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+		target := path.Join(dst, strings.TrimPrefix(walker.Path(), src))
+		if walker.Stat().IsDir() {
+			if err := c.sftpConn().MkdirAll(target); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.sftpConn().Link(walker.Path(), target); err != nil {
+			return fmt.Errorf("hardlink %s: %w (remote cp failed: %s)", walker.Path(), err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
+
+// pruneSnapshots 只保留最新的 keep 个快照
+func (c *Client) pruneSnapshots(remoteBase string, keep int) ([]string, error) {
+	snapshots, err := c.ListSnapshots(remoteBase)
+	if err != nil {
+		return nil, err
+	}
+	var pruned []string
+	for len(snapshots) > keep {
+		if err := c.sftpConn().RemoveAll(snapshots[0].Path); err != nil {
+			return pruned, fmt.Errorf("prune %s: %w", snapshots[0].Path, err)
+		}
+		pruned = append(pruned, snapshots[0].Path)
+		snapshots = snapshots[1:]
+	}
+	return pruned, nil
+}
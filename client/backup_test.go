@@ -0,0 +1,111 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestScanBackupSource(t *testing.T) {
+	root := t.TempDir()
+	mustWrite := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite("a.txt", "a")
+	mustWrite("sub/deep/b.txt", "bb")
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	wantSkipped := 0
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("a.txt", filepath.Join(root, "link")); err != nil {
+			t.Fatal(err)
+		}
+		wantSkipped = 1
+	}
+
+	result := &BackupResult{}
+	files, dirs, err := scanBackupSource(root, result)
+	if err != nil {
+		t.Fatalf("scanBackupSource() error = %v", err)
+	}
+	if len(files) != 2 || files["a.txt"].Size() != 1 || files["sub/deep/b.txt"].Size() != 2 {
+		t.Fatalf("files = %v", files)
+	}
+	for _, dir := range []string{"sub", "sub/deep", "empty"} {
+		if _, ok := dirs[dir]; !ok {
+			t.Fatalf("dirs missing %q: %v", dir, dirs)
+		}
+	}
+	if len(result.Skipped) != wantSkipped {
+		t.Fatalf("Skipped = %v, want %d entries", result.Skipped, wantSkipped)
+	}
+}
+
+func TestForgetRemoteSubtree(t *testing.T) {
+	entries := map[string]os.FileInfo{
+		"sub":       fakeFileInfo{name: "sub", mode: os.ModeDir},
+		"sub/a":     fakeFileInfo{name: "a"},
+		"sub/x/b":   fakeFileInfo{name: "b"},
+		"subdir":    fakeFileInfo{name: "subdir", mode: os.ModeDir},
+		"other.txt": fakeFileInfo{name: "other.txt"},
+	}
+	forgetRemoteSubtree(entries, "sub")
+	if len(entries) != 2 {
+		t.Fatalf("entries = %v, want subdir and other.txt", entries)
+	}
+	if _, ok := entries["subdir"]; !ok {
+		t.Fatal("sibling with shared prefix was removed")
+	}
+}
+
+func TestSelectSnapshot(t *testing.T) {
+	var snapshots []Snapshot
+	for _, name := range []string{"2024-04-29_230000", "2024-04-30_080000", "2024-04-30_201500", "2024-05-02_090000"} {
+		ts, err := time.ParseInLocation(BackupSnapshotLayout, name, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, Snapshot{Name: name, Time: ts})
+	}
+
+	tests := []struct {
+		at      string
+		want    string
+		wantErr bool
+	}{
+		{at: "2024-04-30_080000", want: "2024-04-30_080000"},
+		{at: "2024-04-30", want: "2024-04-30_201500"},
+		{at: "2024-05-01", want: "2024-04-30_201500"},
+		{at: "2024-04-30 12:00", want: "2024-04-30_080000"},
+		{at: "2024-04-30T08:00", want: "2024-04-30_080000"},
+		{at: "2030-01-01", want: "2024-05-02_090000"},
+		{at: "2024-04-28", wantErr: true},
+		{at: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SelectSnapshot(snapshots, tt.at)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SelectSnapshot(%q) = %s, want error", tt.at, got.Name)
+			}
+			continue
+		}
+		if err != nil || got.Name != tt.want {
+			t.Errorf("SelectSnapshot(%q) = %s, %v; want %s", tt.at, got.Name, err, tt.want)
+		}
+	}
+
+	if _, err := SelectSnapshot(nil, "2024-04-30"); err == nil {
+		t.Error("SelectSnapshot(nil) expected error")
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Batch 一组可以单独取消、单独统计进度的传输（如 shell 的后台任务）。
+// 通过 UploadOptions/DownloadOptions/SyncOptions 的 Batch 字段关联，同一个 Batch 可用于多次调用；
+// CancelTransfers 同样会取消 Batch 中的传输。
+type Batch struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	files       atomic.Int64
+	doneFiles   atomic.Int64
+	failedFiles atomic.Int64
+	bytes       atomic.Int64
+	doneBytes   atomic.Int64
+}
+
+// BatchProgress Batch 的进度快照
+type BatchProgress struct {
+	Files       int   // 已计划的文件数
+	DoneFiles   int   // 已完成的文件数
+	FailedFiles int   // 失败的文件数
+	Bytes       int64 // 已计划的总字节数
+	DoneBytes   int64 // 已传输的字节数
+}
+
+// NewBatch 创建 Batch
+func NewBatch() *Batch {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Batch{ctx: ctx, cancel: cancel}
+}
+
+// Cancel 取消 Batch 中进行中和尚未开始的传输
+func (b *Batch) Cancel() {
+	b.cancel()
+}
+
+// Cancelled 报告 Batch 是否已被取消
+func (b *Batch) Cancelled() bool {
+	return b.ctx.Err() != nil
+}
+
+// Progress 返回当前进度
+func (b *Batch) Progress() BatchProgress {
+	return BatchProgress{
+		Files:       int(b.files.Load()),
+		DoneFiles:   int(b.doneFiles.Load()),
+		FailedFiles: int(b.failedFiles.Load()),
+		Bytes:       b.bytes.Load(),
+		DoneBytes:   b.doneBytes.Load(),
+	}
+}
+
+// context 返回同时受 Batch 和 parent（客户端的传输上下文）控制的上下文，用完后调用 stop
+func (b *Batch) context(parent context.Context) (ctx context.Context, stop func()) {
+	if b == nil {
+		return parent, func() {}
+	}
+	ctx, cancel := context.WithCancel(b.ctx)
+	stopAfter := context.AfterFunc(parent, cancel)
+	return ctx, func() {
+		stopAfter()
+		cancel()
+	}
+}
+
+// plan 记录即将执行的任务
+func (b *Batch) plan(files int, bytes int64) {
+	if b == nil {
+		return
+	}
+	b.files.Add(int64(files))
+	b.bytes.Add(bytes)
+}
+
+// finish 记录一个文件的结果
+func (b *Batch) finish(err error) {
+	if b == nil {
+		return
+	}
+	if err != nil {
+		b.failedFiles.Add(1)
+		return
+	}
+	b.doneFiles.Add(1)
+}
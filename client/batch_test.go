@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBatchContext(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	defer cancelParent()
+
+	b := NewBatch()
+	ctx, stop := b.context(parent)
+	defer stop()
+	b.Cancel()
+	if ctx.Err() == nil || !b.Cancelled() {
+		t.Fatal("Batch.Cancel() should cancel the batch context")
+	}
+	if parent.Err() != nil {
+		t.Fatal("Batch.Cancel() must not cancel other transfers")
+	}
+
+	// CancelTransfers（父上下文取消）同样取消批次中的传输
+	b = NewBatch()
+	ctx, stop = b.context(parent)
+	defer stop()
+	cancelParent()
+	<-ctx.Done()
+	if b.Cancelled() {
+		t.Fatal("parent cancellation should not mark the batch itself as cancelled")
+	}
+
+	var nilBatch *Batch
+	if ctx, _ := nilBatch.context(parent); ctx != parent {
+		t.Fatal("nil Batch should use the parent context unchanged")
+	}
+}
+
+func TestBatchProgress(t *testing.T) {
+	b := NewBatch()
+	b.plan(3, 300)
+	b.finish(nil)
+	b.finish(errors.New("boom"))
+	(&transferProgress{batch: b}).Add64(150)
+
+	got := b.Progress()
+	want := BatchProgress{Files: 3, DoneFiles: 1, FailedFiles: 1, Bytes: 300, DoneBytes: 150}
+	if got != want {
+		t.Fatalf("Progress() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,402 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileStamp 同步时比较的文件状态：大小和秒级 mtime
+type FileStamp struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"mtime"` // Unix 秒
+}
+
+func stampOf(info os.FileInfo) FileStamp {
+	return FileStamp{Size: info.Size(), ModTime: info.ModTime().Unix()}
+}
+
+// SyncStateEntry 一个文件上次同步完成时两端的状态
+type SyncStateEntry struct {
+	Local  FileStamp `json:"local"`
+	Remote FileStamp `json:"remote"`
+}
+
+// SyncState 双向同步的状态数据库：记录每个文件上次同步后两端的大小和 mtime，
+// 下次同步时据此判断是哪一端发生了变化
+type SyncState struct {
+	Host   string                    `json:"host"`
+	Local  string                    `json:"local"`
+	Remote string                    `json:"remote"`
+	Synced time.Time                 `json:"synced"`
+	Files  map[string]SyncStateEntry `json:"files"`
+}
+
+// syncStateFile 返回 host 上 local <-> remote 这一对目录的状态文件路径
+func syncStateFile(stateDir, host, local, remote string) string {
+	sum := sha256.Sum256([]byte(host + "\n" + local + "\n" + remote))
+	return filepath.Join(stateDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadSyncState 读取状态文件；文件不存在时返回空状态（首次同步）
+func loadSyncState(file string) (*SyncState, bool, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &SyncState{Files: make(map[string]SyncStateEntry)}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	state := &SyncState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, false, fmt.Errorf("parse sync state %s: %w", file, err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]SyncStateEntry)
+	}
+	return state, true, nil
+}
+
+// save 写入状态文件（先写临时文件再重命名）
+func (s *SyncState) save(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// BiSyncPlan 双向同步的计划（相对路径，/ 分隔，均已排序），由 PlanBiSync 生成、BiSync 执行
+type BiSyncPlan struct {
+	LocalDir, RemoteDir string
+	StateFile           string
+	FirstRun            bool // 还没有状态文件：两端都有且不同的文件全部视为冲突
+
+	Upload       []string // 只有本地变化（或新增）
+	Download     []string // 只有远程变化（或新增）
+	DeleteRemote []string // 本地已删除、远程未变化
+	DeleteLocal  []string // 远程已删除、本地未变化
+	Conflicts    []string // 两端都变化且结果不同，不做处理
+	Skipped      []string // 一端是文件另一端是目录，或特殊文件
+	Unchanged    int      // 包括两端都变化但结果相同的文件
+
+	state      *SyncState
+	local      map[string]os.FileInfo
+	remote     map[string]os.FileInfo
+	converged  []string // 两端都变化但结果相同，只更新状态
+	forget     []string // 两端都已删除
+	remoteMiss bool     // 远程目录不存在
+}
+
+// BiSyncResult 双向同步的统计
+type BiSyncResult struct {
+	Uploaded, Downloaded        int
+	DeletedRemote, DeletedLocal int
+	Unchanged                   int
+	Conflicts                   []string
+	Skipped                     []string
+}
+
+// planBiSync 按状态判断每个文件哪一端发生了变化：只有一端变化时把变化（包括删除）传到另一端，
+// 两端都变化时结果相同则视为已同步，否则记为冲突。没有状态记录的文件在两端都算作新增
+func planBiSync(local, remote map[string]os.FileInfo, state map[string]SyncStateEntry) *BiSyncPlan {
+	plan := &BiSyncPlan{}
+	paths := make(map[string]struct{}, len(local)+len(remote))
+	for rel := range local {
+		paths[rel] = struct{}{}
+	}
+	for rel := range remote {
+		paths[rel] = struct{}{}
+	}
+	for rel := range state {
+		paths[rel] = struct{}{}
+	}
+	sorted := make([]string, 0, len(paths))
+	for rel := range paths {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	for _, rel := range sorted {
+		l, lok := local[rel]
+		r, rok := remote[rel]
+		st, sok := state[rel]
+		lChanged := sideChanged(l, lok, st.Local, sok)
+		rChanged := sideChanged(r, rok, st.Remote, sok)
+		switch {
+		case !lChanged && !rChanged:
+			if lok && rok {
+				plan.Unchanged++
+			}
+		case !rChanged && lok:
+			plan.Upload = append(plan.Upload, rel)
+		case !rChanged:
+			if rok {
+				plan.DeleteRemote = append(plan.DeleteRemote, rel)
+			} else {
+				plan.forget = append(plan.forget, rel)
+			}
+		case !lChanged && rok:
+			plan.Download = append(plan.Download, rel)
+		case !lChanged:
+			if lok {
+				plan.DeleteLocal = append(plan.DeleteLocal, rel)
+			} else {
+				plan.forget = append(plan.forget, rel)
+			}
+		case !lok && !rok:
+			plan.forget = append(plan.forget, rel)
+		case lok && rok && stampOf(l) == stampOf(r):
+			plan.converged = append(plan.converged, rel)
+			plan.Unchanged++
+		default:
+			plan.Conflicts = append(plan.Conflicts, rel)
+		}
+	}
+	return plan
+}
+
+// sideChanged 报告一端的文件相对于上次同步是否变化：新增、删除或大小/mtime 不同
+func sideChanged(info os.FileInfo, exists bool, last FileStamp, known bool) bool {
+	if !known {
+		return exists
+	}
+	return !exists || stampOf(info) != last
+}
+
+// PlanBiSync 扫描两端并与状态比较，生成双向同步计划（不修改任何文件）。
+// stateDir 是保存状态文件的本地目录，每对 主机/本地目录/远程目录 一个文件
+func (c *Client) PlanBiSync(localDir, remoteDir, stateDir string) (*BiSyncPlan, error) {
+	localDir, remoteDir = c.ResolveLocalPath(localDir), c.ResolveRemotePath(remoteDir)
+	if stat, err := os.Stat(localDir); err != nil {
+		return nil, err
+	} else if !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", localDir)
+	}
+	localEntries, err := scanLocalTree(localDir)
+	if err != nil {
+		return nil, err
+	}
+	remoteEntries := make(map[string]os.FileInfo)
+	remoteMiss := false
+	if stat, err := c.sftpConn().Stat(remoteDir); err == nil {
+		if !stat.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", remoteDir)
+		}
+		if remoteEntries, err = c.scanRemoteTree(remoteDir); err != nil {
+			return nil, err
+		}
+	} else if errors.Is(err, fs.ErrNotExist) {
+		remoteMiss = true
+	} else {
+		return nil, err
+	}
+
+	stateFile := syncStateFile(stateDir, c.hostLabel(), localDir, remoteDir)
+	state, found, err := loadSyncState(stateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	localFiles, localDirs, localSpecial := splitTreeEntries(localEntries)
+	remoteFiles, remoteDirs, remoteSpecial := splitTreeEntries(remoteEntries)
+	// 一端整个为空而状态中有文件，多半是目录写错或未挂载，不能把“全部删除”传到另一端
+	if len(state.Files) > 0 && (len(localFiles) == 0) != (len(remoteFiles) == 0) {
+		empty := localDir
+		if len(remoteFiles) == 0 {
+			empty = remoteDir
+		}
+		return nil, fmt.Errorf("%s has no files but %d were synced last time; refusing to delete them on the other side (remove %s to start over)",
+			empty, len(state.Files), stateFile)
+	}
+
+	// 一端是文件另一端是目录（或目录下的内容）、特殊文件：跳过，状态保持不变
+	skipped := make(map[string]struct{})
+	for _, special := range append(localSpecial, remoteSpecial...) {
+		skipped[special] = struct{}{}
+	}
+	for rel := range localFiles {
+		if _, ok := remoteDirs[rel]; ok || underAnyDir(rel, remoteFiles) {
+			skipped[rel] = struct{}{}
+		}
+	}
+	for rel := range remoteFiles {
+		if _, ok := localDirs[rel]; ok || underAnyDir(rel, localFiles) {
+			skipped[rel] = struct{}{}
+		}
+	}
+	known := make(map[string]SyncStateEntry, len(state.Files))
+	for rel, entry := range state.Files {
+		known[rel] = entry
+	}
+	for rel := range skipped {
+		delete(localFiles, rel)
+		delete(remoteFiles, rel)
+		delete(known, rel)
+	}
+
+	plan := planBiSync(localFiles, remoteFiles, known)
+	plan.LocalDir, plan.RemoteDir, plan.StateFile = localDir, remoteDir, stateFile
+	plan.FirstRun = !found
+	plan.state, plan.local, plan.remote, plan.remoteMiss = state, localFiles, remoteFiles, remoteMiss
+	for rel := range skipped {
+		plan.Skipped = append(plan.Skipped, rel)
+	}
+	sort.Strings(plan.Skipped)
+	return plan, nil
+}
+
+// underAnyDir 报告 rel 的某个上级路径是否是 files 中的文件
+func underAnyDir(rel string, files map[string]os.FileInfo) bool {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if _, ok := files[dir]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// BiSync 执行 PlanBiSync 生成的计划并更新状态文件。只有确认完成的传输和删除才写入状态，
+// 失败的文件和冲突保持原来的记录，下次同步时重新判断。opts.Reverse 和 opts.Router 被忽略
+func (c *Client) BiSync(plan *BiSyncPlan, opts *SyncOptions) (*BiSyncResult, error) {
+	if opts == nil {
+		opts = &SyncOptions{ShowProgress: true, Concurrency: c.Concurrency()}
+	}
+	result := &BiSyncResult{Unchanged: plan.Unchanged, Conflicts: plan.Conflicts, Skipped: plan.Skipped}
+	state := plan.state
+	state.Host, state.Local, state.Remote = c.hostLabel(), plan.LocalDir, plan.RemoteDir
+
+	for _, rel := range plan.converged {
+		state.Files[rel] = SyncStateEntry{Local: stampOf(plan.local[rel]), Remote: stampOf(plan.remote[rel])}
+	}
+	for _, rel := range plan.forget {
+		delete(state.Files, rel)
+	}
+
+	var errs []error
+	if plan.remoteMiss && len(plan.Upload) > 0 {
+		if err := c.ensureRemoteDir(plan.RemoteDir); err != nil {
+			return result, fmt.Errorf("create %s: %w", plan.RemoteDir, err)
+		}
+	}
+	if total := len(plan.Upload) + len(plan.Download) + len(plan.DeleteRemote) + len(plan.DeleteLocal); total > 0 {
+		opts.logf("Syncing both ways: %d up, %d down, %d deletion(s), %d unchanged",
+			len(plan.Upload), len(plan.Download), len(plan.DeleteRemote)+len(plan.DeleteLocal), plan.Unchanged)
+	}
+
+	var uploads, downloads []transferTask
+	for _, rel := range plan.Upload {
+		uploads = append(uploads, c.bisyncTask(plan, rel, true))
+	}
+	for _, rel := range plan.Download {
+		downloads = append(downloads, c.bisyncTask(plan, rel, false))
+	}
+	completed := &completedTasks{}
+	transferOpts := &TransferOptions{ShowProgress: opts.ShowProgress, Concurrency: opts.Concurrency, MaxDepth: -1, Scanner: opts.Scanner, Batch: opts.Batch, completed: completed}
+	if len(uploads) > 0 {
+		if err := c.ensureRemoteDirsExist(c.collectRemoteDirsForUpload(uploads)); err != nil {
+			return result, fmt.Errorf("create remote dirs: %w", err)
+		}
+		if _, err := c.executeTasks(uploads, transferOpts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(downloads) > 0 {
+		if _, err := c.executeTasks(downloads, transferOpts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i, rel := range plan.Upload {
+		if stamp, ok := c.finishBiSyncTask(completed, uploads[i], plan.local[rel]); ok {
+			state.Files[rel] = SyncStateEntry{Local: stamp, Remote: stamp}
+			result.Uploaded++
+		}
+	}
+	for i, rel := range plan.Download {
+		if stamp, ok := c.finishBiSyncTask(completed, downloads[i], plan.remote[rel]); ok {
+			state.Files[rel] = SyncStateEntry{Local: stamp, Remote: stamp}
+			result.Downloaded++
+		}
+	}
+
+	// 删除前再确认文件仍是上次同步时的状态，扫描之后才发生的修改不会被删掉
+	for _, rel := range plan.DeleteRemote {
+		target := path.Join(plan.RemoteDir, rel)
+		info, err := c.sftpConn().Lstat(target)
+		if err == nil && stampOf(info) != state.Files[rel].Remote {
+			err = errors.New("changed since the scan, not deleted")
+		}
+		if err == nil {
+			err = c.sftpConn().Remove(target)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, &fileError{op: "delete", path: target, err: err})
+			continue
+		}
+		delete(state.Files, rel)
+		c.invalidateDirCache(path.Dir(target))
+		result.DeletedRemote++
+	}
+	for _, rel := range plan.DeleteLocal {
+		target := filepath.Join(plan.LocalDir, filepath.FromSlash(rel))
+		info, err := os.Lstat(target)
+		if err == nil && stampOf(info) != state.Files[rel].Local {
+			err = errors.New("changed since the scan, not deleted")
+		}
+		if err == nil {
+			err = os.Remove(target)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, &fileError{op: "delete", path: target, err: err})
+			continue
+		}
+		delete(state.Files, rel)
+		result.DeletedLocal++
+	}
+
+	state.Synced = time.Now().UTC().Truncate(time.Second)
+	if err := state.save(plan.StateFile); err != nil {
+		errs = append(errs, fmt.Errorf("save sync state: %w", err))
+	}
+	return result, joinFileErrors(errs)
+}
+
+func (c *Client) bisyncTask(plan *BiSyncPlan, rel string, upload bool) transferTask {
+	src := plan.remote[rel]
+	if upload {
+		src = plan.local[rel]
+	}
+	return transferTask{
+		localPath:  filepath.Join(plan.LocalDir, filepath.FromSlash(rel)),
+		remotePath: path.Join(plan.RemoteDir, rel),
+		isUpload:   upload,
+		size:       src.Size(),
+	}
+}
+
+// finishBiSyncTask 传输成功后把目标 mtime 设为源的 mtime，返回两端共同的状态；
+// 传输失败、被取消或设置 mtime 失败时 ok 为 false
+func (c *Client) finishBiSyncTask(completed *completedTasks, task transferTask, src os.FileInfo) (FileStamp, bool) {
+	if !completed.has(task) {
+		return FileStamp{}, false
+	}
+	if err := c.setTargetMtime(task, src.ModTime()); err != nil {
+		return FileStamp{}, false
+	}
+	return stampOf(src), true
+}
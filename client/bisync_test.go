@@ -0,0 +1,76 @@
+package client
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanBiSync(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	file := func(size int64, offset time.Duration) os.FileInfo {
+		return fakeFileInfo{size: size, modTime: mtime.Add(offset)}
+	}
+	base := FileStamp{Size: 3, ModTime: mtime.Unix()}
+	synced := SyncStateEntry{Local: base, Remote: base}
+	state := map[string]SyncStateEntry{
+		"same":          synced,
+		"local-edit":    synced,
+		"remote-edit":   synced,
+		"local-gone":    synced,
+		"remote-gone":   synced,
+		"both-edit":     synced,
+		"both-same":     synced,
+		"both-gone":     synced,
+		"gone-vs-edit":  synced,
+		"subsecond-rem": synced,
+	}
+	local := map[string]os.FileInfo{
+		"same":          file(3, 0),
+		"local-edit":    file(5, time.Minute),
+		"remote-edit":   file(3, 0),
+		"remote-gone":   file(3, 0),
+		"both-edit":     file(4, time.Minute),
+		"both-same":     file(6, time.Hour),
+		"gone-vs-edit":  file(3, 0),
+		"subsecond-rem": file(3, 0),
+		"new-local":     file(1, 0),
+		"new-both-diff": file(1, 0),
+	}
+	remote := map[string]os.FileInfo{
+		"same":          file(3, 0),
+		"local-edit":    file(3, 0),
+		"remote-edit":   file(7, time.Minute),
+		"local-gone":    file(3, 0),
+		"both-edit":     file(8, time.Minute),
+		"both-same":     file(6, time.Hour),
+		"subsecond-rem": file(3, 400*time.Millisecond),
+		"new-remote":    file(1, 0),
+		"new-both-diff": file(2, 0),
+	}
+	delete(local, "gone-vs-edit")
+	remote["gone-vs-edit"] = file(9, time.Minute)
+
+	plan := planBiSync(local, remote, state)
+	checks := []struct {
+		name      string
+		got, want []string
+	}{
+		{"Upload", plan.Upload, []string{"local-edit", "new-local"}},
+		{"Download", plan.Download, []string{"new-remote", "remote-edit"}},
+		{"DeleteRemote", plan.DeleteRemote, []string{"local-gone"}},
+		{"DeleteLocal", plan.DeleteLocal, []string{"remote-gone"}},
+		{"Conflicts", plan.Conflicts, []string{"both-edit", "gone-vs-edit", "new-both-diff"}},
+		{"converged", plan.converged, []string{"both-same"}},
+		{"forget", plan.forget, []string{"both-gone"}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if plan.Unchanged != 3 {
+		t.Errorf("Unchanged = %d, want 3 (same, subsecond-rem, both-same)", plan.Unchanged)
+	}
+}
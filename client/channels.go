@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// defaultMaxChannels 未设置 SFTPOptions.Channels 时前台传输最多使用的 SFTP 通道数（含主通道）。
+// OpenSSH 默认每个连接最多 10 个会话（MaxSessions），还要留给后台任务、扩展请求和 ! 命令
+const defaultMaxChannels = 4
+
+// channelPool 前台并发传输共用的一组 SFTP 通道：同一 SSH 连接上的每个通道有独立的窗口和请求队列，
+// 多个文件分散到不同通道上才能真正并行，而不是在一个通道里排队。
+// 槽 0 是主通道，其余按需打开；打开失败后不再尝试，只使用已有的通道
+type channelPool struct {
+	mu      sync.Mutex
+	clients []*sftp.Client // clients[0] 为主通道
+	users   []int          // 每个通道上进行中的传输数
+	size    int            // 最多使用的通道数
+	failed  bool           // 额外通道打开失败过
+}
+
+type channelKey struct{}
+
+// channelCount 前台传输最多使用的 SFTP 通道数：SFTPOptions.Channels，未设置时为 defaultMaxChannels。
+// 只有所有通道都在传输时才打开新通道，因此实际通道数不超过并发数
+func (c *Client) channelCount() int {
+	if c.sftpOpts.Channels > 0 {
+		return c.sftpOpts.Channels
+	}
+	return defaultMaxChannels
+}
+
+// acquireChannel 为一个前台传输任务选择进行中传输最少的通道（需要时打开新通道），
+// 返回带有该通道的 ctx（见 dataClient）和归还函数。后台任务使用自己的通道，原样返回
+func (c *Client) acquireChannel(ctx context.Context) (context.Context, func()) {
+	if background, _ := ctx.Value(backgroundKey{}).(bool); background {
+		return ctx, func() {}
+	}
+	p := &c.channels
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.clients) == 0 || p.clients[0] != c.sftpConn() {
+		// 首次使用，或重连后主通道已更换
+		p.clients, p.users, p.failed = []*sftp.Client{c.sftpConn()}, []int{0}, false
+		p.size = c.channelCount()
+	}
+
+	best := 0
+	for i, n := range p.users {
+		if n < p.users[best] {
+			best = i
+		}
+	}
+	if p.users[best] > 0 && len(p.clients) < p.size && !p.failed {
+		if sc, err := c.newSFTPClient(); err == nil {
+			p.clients = append(p.clients, sc)
+			p.users = append(p.users, 0)
+			best = len(p.clients) - 1
+		} else {
+			p.failed = true
+		}
+	}
+	p.users[best]++
+	sc := p.clients[best]
+	return context.WithValue(ctx, channelKey{}, sc), func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if best < len(p.users) && p.clients[best] == sc {
+			p.users[best]--
+		}
+	}
+}
+
+// closeChannels 关闭额外打开的通道（主通道由调用方关闭）
+func (c *Client) closeChannels() {
+	p := &c.channels
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, sc := range p.clients[min(1, len(p.clients)):] {
+		sc.Close()
+	}
+	p.clients, p.users = nil, nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+)
+
+// downloadChecksummed 下载文件并在写入的同时计算摘要；服务器端的校验命令与下载并行运行，
+// 结束时比较两个摘要，因此大文件不需要再完整读取一遍本地副本。
+// 服务器上没有 alg 所需的程序时两端都改用 sha256
+func (c *Client) downloadChecksummed(ctx context.Context, alg *HashAlgorithm, remotePath, localPath string, progress *transferProgress) error {
+	remotePath = c.ResolveRemotePath(remotePath)
+	alg = c.remoteHashAlgorithm(alg)
+
+	type digestResult struct {
+		sum string
+		err error
+	}
+	// 下载失败时取消服务器端的校验命令，不让它继续读完整个文件
+	hashCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	remote := make(chan digestResult, 1)
+	go func() {
+		sum, err := c.execHash(hashCtx, alg, remotePath)
+		remote <- digestResult{sum, err}
+	}()
+
+	h := alg.New()
+	if err := c.downloadFile(ctx, remotePath, localPath, progress, h); err != nil {
+		return err
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+
+	want := <-remote
+	if want.err != nil {
+		return fmt.Errorf("checksum not verified: remote %s failed: %w", alg.Name, want.err)
+	}
+	if got != want.sum {
+		return fmt.Errorf("checksum mismatch (%s): local %s, remote %s", alg.Name, got, want.sum)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+)
+
+// ParseFileMode 解析八进制权限，如 "755"、"0644"、"4755"（含 setuid/setgid/sticky 位）
+func ParseFileMode(s string) (os.FileMode, error) {
+	v, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || v > 0o7777 {
+		return 0, fmt.Errorf("invalid mode: %s (want octal, e.g. 755 or 0644)", s)
+	}
+	mode := os.FileMode(v & 0o777)
+	if v&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if v&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if v&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// Chmod 修改远程文件/目录的权限；recursive 时包括目录下的所有条目（跳过符号链接，与 chmod -R 一致）
+func (c *Client) Chmod(remotePath string, mode os.FileMode, recursive bool) error {
+	remotePath = c.ResolveRemotePath(remotePath)
+	defer c.invalidateDirCache(path.Dir(remotePath))
+	if !recursive {
+		return c.sftpConn().Chmod(remotePath, mode)
+	}
+
+	walker := c.sftpConn().Walk(remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+		info := walker.Stat()
+		if info.Mode()&os.ModeSymlink != 0 && walker.Path() != remotePath {
+			continue
+		}
+		if err := c.sftpConn().Chmod(walker.Path(), mode); err != nil {
+			return fmt.Errorf("chmod %s: %w", walker.Path(), err)
+		}
+		if info.IsDir() {
+			c.invalidateDirCache(walker.Path())
+		}
+	}
+	return nil
+}
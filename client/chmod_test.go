@@ -0,0 +1,27 @@
+package client
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseFileMode(t *testing.T) {
+	tests := map[string]os.FileMode{
+		"755":  0755,
+		"0644": 0644,
+		"4755": os.ModeSetuid | 0755,
+		"2775": os.ModeSetgid | 0775,
+		"1777": os.ModeSticky | 0777,
+	}
+	for input, want := range tests {
+		got, err := ParseFileMode(input)
+		if err != nil || got != want {
+			t.Errorf("ParseFileMode(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "abc", "789", "17777", "u+x"} {
+		if _, err := ParseFileMode(bad); err == nil {
+			t.Errorf("ParseFileMode(%q) expected error", bad)
+		}
+	}
+}
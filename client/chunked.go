@@ -0,0 +1,211 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// chunkDirSuffix 分块上传时存放分块文件的远程临时目录后缀
+const chunkDirSuffix = ".my-sftp-chunks"
+
+// maxAssembleCommand 服务器端拼接时单条命令的最大长度。分块很多时一条 cat 命令会超过
+// ARG_MAX，因此分批追加到同一个文件
+const maxAssembleCommand = 32 * 1024
+
+// UploadChunked 分块上传单个文件（带进度条），见 uploadChunkedWithProgress
+func (c *Client) UploadChunked(localPath, remotePath string, chunkSize int64) error {
+	localPath = c.ResolveLocalPath(localPath)
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	display := c.startProgress(stat.Size(), fmt.Sprintf("Uploading %s in chunks", filepath.Base(localPath)), false)
+	defer display.finish()
+	defer c.trackTransfer(true)()
+
+	return c.uploadChunkedWithProgress(c.transferContext(), localPath, remotePath, chunkSize, c.newTransferProgress(display.bar, nil))
+}
+
+// uploadChunkedWithProgress 将文件按 chunkSize 切分为独立的远程分块文件，
+// 再在服务器端用 cat 拼接并校验 SHA-256。每个分块使用独立的文件句柄，
+// 连接中断后重新执行同一命令会跳过已完整上传的分块。
+func (c *Client) uploadChunkedWithProgress(ctx context.Context, localPath, remotePath string, chunkSize int64, progress *transferProgress) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+	localPath = c.ResolveLocalPath(localPath)
+	remotePath = c.ResolveRemotePath(remotePath)
+
+	srcFile, err := os.Open(localPath)
+	if err != nil {
+		return &sourceError{fmt.Errorf("open local: %w", err)}
+	}
+	defer srcFile.Close()
+	stat, err := srcFile.Stat()
+	if err != nil {
+		return fmt.Errorf("stat local: %w", err)
+	}
+
+	if remoteStat, err := c.sftpConn().Stat(remotePath); err == nil && remoteStat.IsDir() {
+		remotePath = path.Join(remotePath, filepath.Base(localPath))
+	}
+	chunkDir := remotePath + chunkDirSuffix
+	if err := c.ensureRemoteDir(chunkDir); err != nil {
+		return fmt.Errorf("create chunk dir: %w", err)
+	}
+
+	chunkCount := int((stat.Size() + chunkSize - 1) / chunkSize)
+	if chunkCount == 0 {
+		chunkCount = 1
+	}
+	var chunkPaths []string
+	for i := 0; i < chunkCount; i++ {
+		offset := int64(i) * chunkSize
+		size := chunkSize
+		if offset+size > stat.Size() {
+			size = stat.Size() - offset
+		}
+		chunkPath := path.Join(chunkDir, fmt.Sprintf("part-%06d", i))
+		chunkPaths = append(chunkPaths, chunkPath)
+
+		// 已完整上传的分块直接跳过（最终校验会发现内容错误）
+		if existing, err := c.sftpConn().Stat(chunkPath); err == nil && existing.Size() == size {
+			progress.Add64(size)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.uploadChunk(ctx, io.NewSectionReader(srcFile, offset, size), chunkPath, progress); err != nil {
+			return fmt.Errorf("upload chunk %d/%d: %w", i+1, chunkCount, err)
+		}
+	}
+
+	localSum, err := c.localChecksum(defaultHashAlgorithm(), localPath)
+	if err != nil {
+		return fmt.Errorf("checksum local: %w", err)
+	}
+	if err := c.assembleChunks(chunkPaths, remotePath, hex.EncodeToString(localSum)); err != nil {
+		if errors.Is(err, errAssembledMismatch) {
+			// 否则下次执行时会跳过同样的坏分块，永远无法通过校验
+			c.removeBadChunks(srcFile, chunkPaths, chunkSize, chunkDir)
+		}
+		return err
+	}
+	return c.sftpConn().RemoveAll(chunkDir)
+}
+
+// removeBadChunks 逐块比较本地与远程的校验和，删除不一致的分块；无法逐块校验时删除整个分块目录
+func (c *Client) removeBadChunks(src *os.File, chunkPaths []string, chunkSize int64, chunkDir string) {
+	alg := defaultHashAlgorithm()
+	for i, chunkPath := range chunkPaths {
+		localSum, err := c.checksumReader(alg, io.NewSectionReader(src, int64(i)*chunkSize, chunkSize))
+		var remoteSum string
+		if err == nil {
+			remoteSum, err = c.remoteSHA256(chunkPath)
+		}
+		if err != nil {
+			c.sftpConn().RemoveAll(chunkDir)
+			return
+		}
+		if remoteSum != hex.EncodeToString(localSum) {
+			c.sftpConn().Remove(chunkPath)
+		}
+	}
+}
+
+func (c *Client) uploadChunk(ctx context.Context, r io.Reader, chunkPath string, progress *transferProgress) error {
+	dst, err := c.sftpConn().Create(chunkPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	buf := c.getBuffer()
+	defer c.putBuffer(buf)
+
+	var writer io.Writer = dst
+	if progress != nil {
+		writer = io.MultiWriter(dst, progress)
+	}
+	_, err = io.CopyBuffer(writer, &contextReader{ctx: ctx, r: r}, buf)
+	return err
+}
+
+// errAssembledMismatch 拼接后的文件与本地文件的校验和不一致
+var errAssembledMismatch = errors.New("checksum mismatch after assembly")
+
+// assembleChunks 在服务器端拼接分块到临时文件，校验后原子替换目标文件
+func (c *Client) assembleChunks(chunkPaths []string, remotePath, wantSum string) error {
+	assembled := remotePath + backupPartSuffix
+	for _, command := range assembleCommands(chunkPaths, assembled, maxAssembleCommand) {
+		var stderr bytes.Buffer
+		if err := c.ExecuteRemote(command, nil, io.Discard, &stderr); err != nil {
+			c.sftpConn().Remove(assembled)
+			return fmt.Errorf("assemble chunks on server: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	gotSum, err := c.remoteSHA256(assembled)
+	if err != nil {
+		return fmt.Errorf("checksum assembled file: %w", err)
+	}
+	if gotSum != wantSum {
+		c.sftpConn().Remove(assembled)
+		return fmt.Errorf("%w: local %s, remote %s", errAssembledMismatch, wantSum, gotSum)
+	}
+	return c.replaceRemoteFile(assembled, remotePath)
+}
+
+// assembleCommands 把分块拼接到 assembled 的 cat 命令，每条不超过 limit 字节（单个分块超长时独占一条）。
+// 第一条用 > 截断，其余用 >> 追加
+func assembleCommands(chunkPaths []string, assembled string, limit int) []string {
+	target := shellQuote(assembled)
+	var commands []string
+	var args []string
+	length := 0
+	flush := func() {
+		redirect := " >> "
+		if len(commands) == 0 {
+			redirect = " > "
+		}
+		commands = append(commands, "cat -- "+strings.Join(args, " ")+redirect+target)
+		args, length = nil, 0
+	}
+	// 固定部分："cat -- " 加重定向和目标
+	fixed := len("cat -- ") + len(" >> ") + len(target)
+	for _, p := range chunkPaths {
+		arg := shellQuote(p)
+		if len(args) > 0 && fixed+length+1+len(arg) > limit {
+			flush()
+		}
+		args = append(args, arg)
+		length += 1 + len(arg)
+	}
+	if len(args) > 0 || len(commands) == 0 {
+		flush()
+	}
+	return commands
+}
+
+// remoteSHA256 优先在服务器端计算校验和，不可用时通过 SFTP 读取计算
+func (c *Client) remoteSHA256(remotePath string) (string, error) {
+	alg := defaultHashAlgorithm()
+	if sum, err := c.execHash(context.Background(), alg, remotePath); err == nil {
+		return sum, nil
+	}
+	sum, err := c.remoteChecksum(alg, remotePath)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum), nil
+}
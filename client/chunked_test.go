@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssembleCommands(t *testing.T) {
+	got := assembleCommands([]string{"/d/part-000000", "/d/part-000001"}, "/f.part", maxAssembleCommand)
+	want := []string{"cat -- '/d/part-000000' '/d/part-000001' > '/f.part'"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("assembleCommands() = %q, want %q", got, want)
+	}
+
+	// 超过长度限制时分批，第一批截断，其余追加，顺序不变
+	var chunks []string
+	for i := range 1000 {
+		chunks = append(chunks, fmt.Sprintf("/data/upload.bin.my-sftp-chunks/part-%06d", i))
+	}
+	commands := assembleCommands(chunks, "/data/upload.bin.part", 4096)
+	if len(commands) < 2 {
+		t.Fatalf("assembleCommands() returned %d command(s), want several", len(commands))
+	}
+	var joined []string
+	for i, command := range commands {
+		if len(command) > 4096 {
+			t.Errorf("command %d is %d bytes", i, len(command))
+		}
+		redirect := " >> "
+		if i == 0 {
+			redirect = " > "
+		}
+		args, ok := strings.CutSuffix(command, redirect+"'/data/upload.bin.part'")
+		if !ok || !strings.HasPrefix(args, "cat -- ") {
+			t.Fatalf("command %d = %q", i, command)
+		}
+		joined = append(joined, strings.TrimPrefix(args, "cat -- "))
+	}
+	if got, want := strings.Join(joined, " "), shellJoin(chunks...); got != want {
+		t.Errorf("batched chunks differ from the original order")
+	}
+}
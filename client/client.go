@@ -1,9 +1,15 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/sftp"
@@ -12,9 +18,11 @@ import (
 )
 
 const (
-	// BufferSize 传输缓冲区大小 (512KB)
+	// BufferSize 默认的传输缓冲区大小 (512KB)，可用 WithBufferSize 修改
 	BufferSize = 512 * 1024
-	// MaxConcurrentTransfers 最大并发传输数
+	// MaxBufferSize 传输缓冲区大小的上限 (64MB)：每个并发传输各占一个缓冲区，再大也不会更快
+	MaxBufferSize = 64 << 20
+	// MaxConcurrentTransfers 默认的并发传输数，可用 WithConcurrency 修改
 	MaxConcurrentTransfers = 4
 	// DirCacheTimeout 目录列表缓存超时时间
 	DirCacheTimeout = 30 * time.Second
@@ -29,43 +37,184 @@ type dirCacheEntry struct {
 
 // Client SFTP 客户端封装
 type Client struct {
-	sshClient    *ssh.Client
-	sftpClient   *sftp.Client
-	workDir      string                    // 远程当前工作目录
-	localWorkDir string                    // 本地当前工作目录
-	dirCache     map[string]*dirCacheEntry // 目录列表缓存
-	cacheMu      sync.RWMutex              // 缓存锁
-	bufferPool   *sync.Pool                // 统一的 buffer pool，减少 GC 压力
-	remoteCaseSensitive bool               // true = case-sensitive (Linux default)
+	sshClient           atomic.Pointer[ssh.Client]  // 当前的 SSH 连接，重连时替换，经 sshConn 访问
+	sftpClient          atomic.Pointer[sftp.Client] // 主 SFTP 通道，重连时替换，经 sftpConn 访问
+	workDir             string                      // 远程当前工作目录，经 Getwd/setWorkDir 访问
+	wdMu                sync.RWMutex                // 保护 workDir
+	homeDir             string                      // 远程用户主目录（连接时的工作目录），未知时为空
+	localWorkDir        string                      // 本地当前工作目录
+	dirCache            map[string]*dirCacheEntry   // 目录列表缓存
+	cacheMu             sync.RWMutex                // 缓存锁
+	bufferPool          *sync.Pool                  // 统一的 buffer pool，减少 GC 压力
+	remoteCaseSensitive bool                        // true = case-sensitive (Linux default)
 	// dirLocks       [DirLockShards]sync.Mutex // 分片锁，用于目录创建的并发控制, 引入 singleflight 后也许不需要了
-	dirCreateGroup singleflight.Group // 确保同一目录只创建一次
+	dirCreateGroup  singleflight.Group // 确保同一目录只创建一次
+	activeUploads   atomic.Int32       // 进行中的上传批次数
+	activeDownloads atomic.Int32       // 进行中的下载批次数
+	cancelMu        sync.Mutex         // 保护 transferCtx / cancelTransfer
+	transferCtx     context.Context    // 传输取消上下文，CancelTransfers 后重建
+	cancelTransfer  context.CancelFunc
+	events          eventHub        // 事件订阅者，见 Subscribe
+	accounts        remoteAccounts  // 远程用户/组缓存，见 RemoteUsers
+	overwritePrompt OverwritePrompt // ask 覆盖策略的询问回调，见 SetOverwritePrompt
+	noSpacePrompt   NoSpacePrompt   // 上传时远程空间不足的询问回调，见 SetNoSpacePrompt
+	latency         latencyTracker  // 服务器 RTT 估计，见 RTT
+	hashTools       hashToolCache   // 服务器上可用的校验和程序，见 remoteHashAlgorithm
+	progressMode    ProgressMode    // 进度显示方式，见 SetProgressMode
+	progressEvery   ProgressEvery   // log 模式的打印频率
+	ready           chan struct{}   // SFTP 初始化完成（或不启动）时关闭，见 WaitReady
+	readyErr        error           // SFTP 初始化失败的原因，exec-only 模式下为 ErrExecOnly
+	trace           *sftpTrace      // SFTP 协议跟踪，见 WithSFTPTrace
+	sftpOpts        SFTPOptions     // SFTP 兼容性设置，见 WithSFTPOptions
+	rawMu           sync.Mutex      // 保护 raw
+	raw             *rawSFTP        // 扩展请求使用的通道，见 rawChannel
+	concurrency     int             // 选项未指定并发数时的并发传输数，见 WithConcurrency
+	bufferSize      atomic.Int64    // 传输缓冲区大小，见 WithBufferSize、SetBufferSize
+	closed          atomic.Bool     // Close 已被调用
+	lost            atomic.Bool     // SSH 连接在 Close 之前断开，见 ConnectionLost
+	lostMu          sync.Mutex      // 保护 lostErr、lostHandler
+	lostErr         error           // 连接断开的原因，见 ConnectionLostErr
+	lostHandler     ConnectionLostHandler
+	connGen         atomic.Int64 // 当前连接的编号，每次重连加一，见 watchConnection
+	redial          Dialer       // 重新建立 SSH 连接，nil 表示不能重连，见 WithReconnect
+
+	workers      *workerScheduler  // 前台/后台传输的 worker 分配
+	bgMu         sync.Mutex        // 保护 bgSFTP / bgOpened
+	bgSFTP       *sftp.Client      // 后台任务传输数据的通道，见 dataClient
+	bgOpened     bool              // 已尝试打开 bgSFTP
+	channels     channelPool       // 前台并发传输使用的 SFTP 通道，见 acquireChannel
+	limits       serverLimitsCache // 服务器报告的限制，见 ServerLimits
+	keepAlive    time.Duration     // 保活请求的间隔，见 WithKeepAlive
+	keepAliveMax int               // 连续多少次保活请求没有响应时断开连接
+}
+
+// StartMode 建立 SSH 连接后 SFTP 子系统的启动方式
+type StartMode int
+
+const (
+	// StartEager 返回前完成 SFTP 初始化（默认）
+	StartEager StartMode = iota
+	// StartLazy 在后台初始化 SFTP，调用方在使用 SFTP 前通过 WaitReady 等待
+	StartLazy
+	// StartExecOnly 不启动 SFTP，只能执行远程命令（ExecuteRemote）
+	StartExecOnly
+)
+
+// ErrExecOnly StartExecOnly 模式下 WaitReady 返回的错误
+var ErrExecOnly = errors.New("SFTP is disabled in exec-only mode")
+
+// ClientOption NewClient / NewClientConn 的可选配置
+type ClientOption func(*Client)
+
+// SFTPOptions 针对行为特殊的服务器的 SFTP 兼容性设置，零值为默认行为。
+// 客户端只支持 SFTP v3（各常见服务器都支持的版本）
+type SFTPOptions struct {
+	Subsystem    string // 子系统名称，默认 sftp；包含 / 时作为服务器端程序路径执行（如 /usr/lib/sftp-server）
+	MaxPacket    int    // 单个读写请求的最大数据字节数，默认 32768；服务器报告了更大的读写上限时放大到该上限（最多 256KB）
+	MaxRequests  int    // 每个文件的最大并发请求数，默认 64（数据包较大时减少，每个文件在途最多 8MB）
+	SerialReads  bool   // 关闭并发读取（部分服务器不能处理乱序的读请求）
+	SerialWrites bool   // 关闭并发写入
+	UseFstat     bool   // 下载时用 FSTAT 而不是 STAT 获取文件大小
+	Channels     int    // 并发传输最多使用的 SFTP 通道数（含主通道），默认 4；1 表示所有传输共用主通道
+}
+
+// WithSFTPOptions 设置 SFTP 兼容性选项，见 SFTPOptions
+func WithSFTPOptions(opts SFTPOptions) ClientOption {
+	return func(c *Client) {
+		c.sftpOpts = opts
+	}
+}
+
+// WithConcurrency 设置默认的并发传输数（传输选项的 Concurrency 为 0 时使用），n<1 时忽略
+func WithConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		if n >= 1 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithBufferSize 设置传输缓冲区大小，n<1 时忽略，超过 MaxBufferSize 时取上限
+func WithBufferSize(n int) ClientOption {
+	return func(c *Client) {
+		c.SetBufferSize(n)
+	}
+}
+
+// SetBufferSize 修改传输缓冲区大小，n<1 时忽略，超过 MaxBufferSize 时取上限；
+// 进行中的传输继续使用原来的缓冲区，之后的传输使用新大小
+func (c *Client) SetBufferSize(n int) {
+	if n >= 1 {
+		c.bufferSize.Store(int64(min(n, MaxBufferSize)))
+	}
+}
+
+// BufferSize 当前的传输缓冲区大小
+func (c *Client) BufferSize() int {
+	return int(c.bufferSize.Load())
 }
 
 // NewClient 创建 SFTP 客户端
-func NewClient(addr string, config *ssh.ClientConfig) (*Client, error) {
-	sshClient, err := ssh.Dial("tcp", addr, config)
+func NewClient(addr string, config *ssh.ClientConfig, mode StartMode, opts ...ClientOption) (*Client, error) {
+	sshClient, err := Dial(addr, config)
 	if err != nil {
-		return nil, fmt.Errorf("ssh dial: %w", err)
+		return nil, err
 	}
+	return newClient(sshClient, mode, opts)
+}
 
-	sftpClient, err := sftp.NewClient(sshClient,
-		// 部分服务器不支持; 就不启用了
-		// sftp.MaxPacket(128*1024),               // 128KB packet size
-		sftp.UseConcurrentWrites(true),         // 启用并发写入（上传优化）
-		sftp.UseConcurrentReads(true),          // 确保并发读取开启（下载优化）
-		sftp.MaxConcurrentRequestsPerFile(64), // 每个文件最大并发请求数
-	)
+// NewClientConn 在已建立的连接（如经 ControlMaster 转发的流）上完成 SSH 握手并创建 SFTP 客户端
+// addr 用于主机密钥校验；握手失败时关闭 conn
+func NewClientConn(conn net.Conn, addr string, config *ssh.ClientConfig, mode StartMode, opts ...ClientOption) (*Client, error) {
+	sshClient, err := Handshake(conn, addr, config)
 	if err != nil {
-		sshClient.Close()
-		return nil, fmt.Errorf("sftp client: %w", err)
+		return nil, err
 	}
+	return newClient(sshClient, mode, opts)
+}
 
-	// 获取初始工作目录
-	wd, err := sftpClient.Getwd()
+// Dial 建立到 addr 的 TCP 连接并完成 SSH 握手；config.Timeout 同时限制连接和握手，见 Handshake
+func Dial(addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.Dial("tcp", addr)
 	if err != nil {
-		wd = "/"
+		return nil, fmt.Errorf("ssh dial: %w", err)
 	}
+	return Handshake(conn, addr, config)
+}
 
+// Handshake 在 conn 上完成 SSH 握手，失败时关闭 conn。config.Timeout 大于 0 时，服务器在此时间内
+// 没有出示主机密钥即放弃（接受了 TCP 连接却不响应的主机不再无限等待）；之后的主机确认和密码输入不计时
+func Handshake(conn net.Conn, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	var timedOut atomic.Bool
+	if config.Timeout > 0 {
+		timer := time.AfterFunc(config.Timeout, func() {
+			timedOut.Store(true)
+			conn.Close()
+		})
+		defer timer.Stop()
+		timed := *config
+		check := config.HostKeyCallback
+		timed.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if !timer.Stop() {
+				return errors.New("timed out")
+			}
+			return check(hostname, remote, key)
+		}
+		config = &timed
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		if timedOut.Load() {
+			return nil, fmt.Errorf("ssh handshake: no response from %s within %s", addr, config.Timeout)
+		}
+		return nil, fmt.Errorf("ssh handshake: %w", err)
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+func newClient(sshClient *ssh.Client, mode StartMode, opts []ClientOption) (*Client, error) {
 	// 获取程序启动时的本地工作目录
 	localWd, err := os.Getwd()
 	if err != nil {
@@ -73,40 +222,336 @@ func NewClient(addr string, config *ssh.ClientConfig) (*Client, error) {
 	}
 
 	c := &Client{
-		sshClient:    sshClient,
-		sftpClient:   sftpClient,
-		workDir:      wd,
-		localWorkDir: localWd,
-		dirCache:     make(map[string]*dirCacheEntry),
-		bufferPool: &sync.Pool{
-			New: func() interface{} {
-				buf := make([]byte, BufferSize)
-				return &buf
-			},
+		localWorkDir:        localWd,
+		dirCache:            make(map[string]*dirCacheEntry),
+		ready:               make(chan struct{}),
+		remoteCaseSensitive: true,
+		concurrency:         MaxConcurrentTransfers,
+	}
+	c.sshClient.Store(sshClient)
+	c.bufferSize.Store(BufferSize)
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.workers = newWorkerScheduler(c.concurrency)
+	if budget := fileBudget(); budget > 0 {
+		c.workers.capacity = min(c.workers.capacity, budget)
+	}
+	c.bufferPool = &sync.Pool{
+		New: func() interface{} {
+			buf := make([]byte, c.bufferSize.Load())
+			return &buf
 		},
 	}
+	c.watchConnection(sshClient)
+
+	switch mode {
+	case StartExecOnly:
+		c.readyErr = ErrExecOnly
+		close(c.ready)
+	case StartLazy:
+		go c.startSFTP(false)
+	default:
+		if err := c.startSFTP(true); err != nil {
+			sshClient.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// watchConnection 在后台等待 sshClient 断开并标记为 ConnectionLost，需要时发送保活请求。
+// 重连后旧连接的关闭不再算作断开
+func (c *Client) watchConnection(sshClient *ssh.Client) {
+	gen := c.connGen.Add(1)
+	stale := func() bool { return c.closed.Load() || c.connGen.Load() != gen }
+	go func() {
+		err := sshClient.Wait()
+		if stale() {
+			return
+		}
+		if err == nil || errors.Is(err, io.EOF) {
+			err = errors.New("connection closed by the server")
+		}
+		c.markLost(err)
+	}()
+	if c.keepAlive > 0 {
+		go c.runKeepAlive(sshClient, stale)
+	}
+}
+
+// openMainSFTP 打开主 SFTP 通道。配置的数据包超过服务器的限制时会出现难以理解的失败，
+// 按服务器的限制重新打开；未配置时按服务器报告的上限放大数据包，同样需要重新打开
+func (c *Client) openMainSFTP(verbose bool) (*sftp.Client, error) {
+	sftpClient, err := c.newSFTPClient()
+	if err != nil {
+		return nil, err
+	}
+	c.sftpClient.Store(sftpClient)
+
+	// 放大数据包是连接 OpenSSH 时的常规情况，不提示；只在缩小了配置的数据包时提示
+	clamped := c.clampPacketSize()
+	if clamped || c.raisePacketSize() {
+		if clamped && verbose {
+			fmt.Printf("ℹ Server accepts at most %d bytes per read/write request, reducing the packet size\n", c.sftpOpts.MaxPacket)
+		}
+		if reopened, err := c.newSFTPClient(); err == nil {
+			sftpClient.Close()
+			sftpClient = reopened
+			c.sftpClient.Store(sftpClient)
+		}
+	}
+	return sftpClient, nil
+}
+
+// startSFTP 打开 SFTP 子系统并解析初始工作目录，完成后关闭 c.ready。
+// 初始目录与大小写探测互不依赖，并行进行以减少启动时的往返次数
+func (c *Client) startSFTP(verbose bool) error {
+	defer close(c.ready)
+
+	sftpClient, err := c.openMainSFTP(verbose)
+	if err != nil {
+		c.readyErr = fmt.Errorf("sftp client: %w", err)
+		return c.readyErr
+	}
 
-	c.remoteCaseSensitive = c.probeRemoteCaseSensitivity()
-	if c.remoteCaseSensitive {
-		fmt.Println("ℹ Remote filesystem: case-sensitive")
+	caseSensitive := make(chan bool, 1)
+	go func() { caseSensitive <- c.probeRemoteCaseSensitivity() }()
+
+	// 获取初始工作目录（同时作为第一个 RTT 样本）
+	start := time.Now()
+	wd, err := sftpClient.Getwd()
+	if err == nil {
+		c.latency.observe(time.Since(start))
+		c.homeDir = wd
 	} else {
-		fmt.Println("ℹ Remote filesystem: case-insensitive (case-variant filenames treated as same path)")
+		wd = "/"
 	}
+	c.setWorkDir(wd)
+	c.remoteCaseSensitive = <-caseSensitive
 
-	return c, nil
+	if verbose {
+		if c.remoteCaseSensitive {
+			fmt.Println("ℹ Remote filesystem: case-sensitive")
+		} else {
+			fmt.Println("ℹ Remote filesystem: case-insensitive (case-variant filenames treated as same path)")
+		}
+	}
+	return nil
+}
+
+// Ready 报告 SFTP 是否已可用（不阻塞）
+func (c *Client) Ready() bool {
+	select {
+	case <-c.ready:
+		return c.readyErr == nil
+	default:
+		return false
+	}
+}
+
+// WaitReady 等待后台的 SFTP 初始化完成；初始化失败或 exec-only 模式时返回错误。
+// StartLazy 模式下，除 ExecuteRemote、ActiveTransfers 等不涉及远程文件的方法外，
+// 其余方法都必须在 WaitReady 成功后调用
+func (c *Client) WaitReady() error {
+	<-c.ready
+	return c.readyErr
+}
+
+// sftpClientOptions 主通道与并行传输的额外通道共用的 SFTP 选项，见 SFTPOptions
+func (c *Client) sftpClientOptions() []sftp.ClientOption {
+	opts := []sftp.ClientOption{
+		sftp.UseConcurrentWrites(!c.sftpOpts.SerialWrites), // 默认启用并发写入（上传优化）
+		sftp.UseConcurrentReads(!c.sftpOpts.SerialReads),   // 默认启用并发读取（下载优化）
+		sftp.MaxConcurrentRequestsPerFile(c.requestsPerFile()),
+		sftp.UseFstat(c.sftpOpts.UseFstat),
+	}
+	// 部分服务器不支持大于 32KB 的数据包：未配置时只在服务器报告了更大的上限后放大（见 raisePacketSize）
+	if c.sftpOpts.MaxPacket > 0 {
+		opts = append(opts, sftp.MaxPacketUnchecked(c.sftpOpts.MaxPacket))
+	}
+	return opts
+}
+
+// newSFTPClient 在 sshClient 上打开一个 SFTP 通道（主通道和并行传输的额外通道）
+func (c *Client) newSFTPClient() (*sftp.Client, error) {
+	rd, wr, err := c.openSFTPChannel()
+	if err != nil {
+		return nil, err
+	}
+	sftpClient, err := sftp.NewClientPipe(rd, wr, c.sftpClientOptions()...)
+	if err != nil {
+		wr.Close()
+		return nil, err
+	}
+	return sftpClient, nil
+}
+
+// openSFTPChannel 启动 SFTP 子系统并返回其输出和输入；关闭输入时结束会话。
+// 启用 trace 时在收发两个方向上解析数据包
+func (c *Client) openSFTPChannel() (io.Reader, io.WriteCloser, error) {
+	session, err := c.sshConn().NewSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	// 与 OpenSSH sftp -s 相同：包含 / 时作为服务器端程序执行，否则作为子系统名称
+	subsystem := c.sftpOpts.Subsystem
+	if subsystem == "" {
+		subsystem = "sftp"
+	}
+	pw, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+	pr, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+	if strings.Contains(subsystem, "/") {
+		err = session.Start(subsystem)
+	} else {
+		err = session.RequestSubsystem(subsystem)
+	}
+	if err != nil {
+		session.Close()
+		return nil, nil, fmt.Errorf("start %s: %w", subsystem, err)
+	}
+
+	var rd io.Reader = pr
+	var wr io.WriteCloser = &sessionWriter{w: pw, session: session}
+	if c.trace != nil {
+		ch := c.trace.newChannel()
+		rd = &traceReader{r: pr, scan: packetScanner{emit: ch.received}}
+		wr = &traceWriter{w: wr, scan: packetScanner{emit: ch.sent}}
+	}
+	return rd, wr, nil
+}
+
+// sessionWriter SFTP 通道的 stdin；Close 时一并结束会话
+type sessionWriter struct {
+	w       io.WriteCloser
+	session *ssh.Session
+}
+
+func (w *sessionWriter) Write(p []byte) (int, error) { return w.w.Write(p) }
+
+func (w *sessionWriter) Close() error {
+	err := w.w.Close()
+	w.session.Close()
+	return err
 }
 
 // Close 关闭连接
 func (c *Client) Close() error {
-	if c.sftpClient != nil {
-		c.sftpClient.Close()
+	c.closed.Store(true)
+	if c.ready != nil {
+		<-c.ready // 等待后台的 SFTP 初始化结束，避免泄漏半初始化的通道
+	}
+	if c.raw != nil {
+		c.raw.Close()
 	}
-	if c.sshClient != nil {
-		return c.sshClient.Close()
+	c.bgMu.Lock()
+	if c.bgSFTP != nil {
+		c.bgSFTP.Close()
+	}
+	c.bgMu.Unlock()
+	c.closeChannels()
+	if c.sftpConn() != nil {
+		c.sftpConn().Close()
+	}
+	if c.sshConn() != nil {
+		return c.sshConn().Close()
 	}
 	return nil
 }
 
+// Concurrency 返回默认的并发传输数，见 WithConcurrency
+func (c *Client) Concurrency() int {
+	return c.concurrency
+}
+
+// ConnectionLost 报告 SSH 连接是否在 Close 之前断开（服务器关闭、网络中断）
+func (c *Client) ConnectionLost() bool {
+	return c.lost.Load()
+}
+
+// sshConn 返回当前的 SSH 连接。Reconnect 会替换连接，后台任务（分离的传输、rwatch、锁续期等）
+// 可能同时在使用，所以每次使用时重新获取，不要长期保存
+func (c *Client) sshConn() *ssh.Client {
+	return c.sshClient.Load()
+}
+
+// sftpConn 返回主 SFTP 通道，未初始化时为 nil；同 sshConn，重连后会被替换
+func (c *Client) sftpConn() *sftp.Client {
+	return c.sftpClient.Load()
+}
+
+// hostLabel 返回 user@address 形式的主机标识（快照和同步状态中记录）
+func (c *Client) hostLabel() string {
+	return c.sshConn().User() + "@" + c.sshConn().RemoteAddr().String()
+}
+
+// ActiveTransfers 返回进行中的上传/下载批次数（用于提示符指示器）
+func (c *Client) ActiveTransfers() (uploads, downloads int) {
+	return int(c.activeUploads.Load()), int(c.activeDownloads.Load())
+}
+
+// trackTransfer 记录一个进行中的传输批次，返回结束时调用的函数
+func (c *Client) trackTransfer(isUpload bool) func() {
+	counter := &c.activeDownloads
+	if isUpload {
+		counter = &c.activeUploads
+	}
+	counter.Add(1)
+	return func() { counter.Add(-1) }
+}
+
+// transferContext 返回当前传输批次使用的取消上下文
+func (c *Client) transferContext() context.Context {
+	c.cancelMu.Lock()
+	defer c.cancelMu.Unlock()
+	if c.transferCtx == nil {
+		c.transferCtx, c.cancelTransfer = context.WithCancel(context.Background())
+	}
+	return c.transferCtx
+}
+
+// CancelTransfers 取消所有进行中的传输；之后发起的传输不受影响
+func (c *Client) CancelTransfers() {
+	c.cancelMu.Lock()
+	defer c.cancelMu.Unlock()
+	if c.cancelTransfer != nil {
+		c.cancelTransfer()
+	}
+	c.transferCtx, c.cancelTransfer = context.WithCancel(context.Background())
+}
+
+// WaitTransfers 阻塞直到没有进行中的传输
+func (c *Client) WaitTransfers() {
+	for {
+		uploads, downloads := c.ActiveTransfers()
+		if uploads == 0 && downloads == 0 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// contextReader 在上下文取消后让读取立即失败，用于中断 io.Copy
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
 // getBuffer 安全地从 buffer pool 获取缓冲区
 func (c *Client) getBuffer() []byte {
 	buf := c.bufferPool.Get()
@@ -114,11 +559,14 @@ func (c *Client) getBuffer() []byte {
 		return *b
 	}
 	// 后备方案：如果类型断言失败，创建新的缓冲区
-	return make([]byte, BufferSize)
+	return make([]byte, c.bufferSize.Load())
 }
 
-// putBuffer 将缓冲区归还到 pool
+// putBuffer 将缓冲区归还到 pool；SetBufferSize 之后，旧大小的缓冲区不再放回
 func (c *Client) putBuffer(buf []byte) {
+	if size := c.bufferSize.Load(); size > 0 && int64(len(buf)) != size {
+		return
+	}
 	c.bufferPool.Put(&buf)
 }
 
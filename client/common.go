@@ -1,6 +1,10 @@
 package client
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,13 +13,23 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"golang.org/x/crypto/ssh"
 )
 
-// Getwd 获取远程当前工作目录
+// Getwd 获取远程当前工作目录；后台任务与 cd 可能并发访问，读写都经过 wdMu
 func (c *Client) Getwd() string {
+	c.wdMu.RLock()
+	defer c.wdMu.RUnlock()
 	return c.workDir
 }
 
+func (c *Client) setWorkDir(dir string) {
+	c.wdMu.Lock()
+	c.workDir = dir
+	c.wdMu.Unlock()
+}
+
 // GetLocalwd 获取本地当前工作目录
 func (c *Client) GetLocalwd() string {
 	return c.localWorkDir
@@ -62,36 +76,49 @@ func (c *Client) LocalMkdir(dir string) error {
 // Chdir 切换工作目录
 func (c *Client) Chdir(dir string) error {
 	targetPath := c.ResolveRemotePath(dir)
-	stat, err := c.sftpClient.Stat(targetPath)
+	stat, err := c.timedStat(targetPath)
 	if err != nil {
 		return fmt.Errorf("stat: %w", err)
 	}
 	if !stat.IsDir() {
 		return fmt.Errorf("not a directory: %s", targetPath)
 	}
-	c.workDir = targetPath
+	c.setWorkDir(targetPath)
 	// 切换目录后清除缓存
 	c.ClearDirCache()
 	return nil
 }
 
+// EnsureWorkDir 检查远程工作目录是否仍然存在（可能已在服务器端被删除或重命名）。
+// 不存在时切换到主目录（未知时为 /），返回失效的目录；目录仍然有效时返回 ""
+func (c *Client) EnsureWorkDir() (string, error) {
+	wd := c.Getwd()
+	info, err := c.sftpConn().Stat(wd)
+	if err == nil && info.IsDir() {
+		return "", nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	home := c.homeDir
+	if home == "" || home == wd {
+		home = "/"
+	}
+	c.setWorkDir(home)
+	return wd, nil
+}
+
 // List 列出目录内容
 func (c *Client) List(dir string) ([]os.FileInfo, error) {
 	targetPath := c.ResolveRemotePath(dir)
 
 	// 检查缓存
-	c.cacheMu.RLock()
-	if entry, exists := c.dirCache[targetPath]; exists {
-		// 检查是否过期
-		if time.Since(entry.cachedAt) < DirCacheTimeout {
-			c.cacheMu.RUnlock()
-			return entry.files, nil
-		}
+	if files, ok := c.cachedList(targetPath); ok {
+		return files, nil
 	}
-	c.cacheMu.RUnlock()
 
 	// 缓存未命中或已过期，读取目录
-	files, err := c.sftpClient.ReadDir(targetPath)
+	files, err := c.sftpConn().ReadDir(targetPath)
 	if err != nil {
 		return nil, err
 	}
@@ -107,10 +134,21 @@ func (c *Client) List(dir string) ([]os.FileInfo, error) {
 	return files, nil
 }
 
+// cachedList 返回未过期的目录缓存
+func (c *Client) cachedList(targetPath string) ([]os.FileInfo, bool) {
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+	entry, exists := c.dirCache[targetPath]
+	if !exists || time.Since(entry.cachedAt) >= DirCacheTimeout {
+		return nil, false
+	}
+	return entry.files, true
+}
+
 // Remove 删除文件或目录
 func (c *Client) Remove(remotePath string) error {
 	remotePath = c.ResolveRemotePath(remotePath)
-	stat, err := c.sftpClient.Stat(remotePath)
+	stat, err := c.sftpConn().Stat(remotePath)
 	if err != nil {
 		return err
 	}
@@ -120,7 +158,7 @@ func (c *Client) Remove(remotePath string) error {
 		// 递归删除目录
 		removeErr = c.removeDir(remotePath)
 	} else {
-		removeErr = c.sftpClient.Remove(remotePath)
+		removeErr = c.sftpConn().Remove(remotePath)
 	}
 
 	if removeErr == nil {
@@ -132,7 +170,7 @@ func (c *Client) Remove(remotePath string) error {
 
 // removeDir 递归删除目录
 func (c *Client) removeDir(dir string) error {
-	files, err := c.sftpClient.ReadDir(dir)
+	files, err := c.sftpConn().ReadDir(dir)
 	if err != nil {
 		return err
 	}
@@ -144,19 +182,19 @@ func (c *Client) removeDir(dir string) error {
 				return err
 			}
 		} else {
-			if err := c.sftpClient.Remove(fullPath); err != nil {
+			if err := c.sftpConn().Remove(fullPath); err != nil {
 				return err
 			}
 		}
 	}
 
-	return c.sftpClient.RemoveDirectory(dir)
+	return c.sftpConn().RemoveDirectory(dir)
 }
 
 // Mkdir 创建目录
 func (c *Client) Mkdir(dir string) error {
 	dir = c.ResolveRemotePath(dir)
-	err := c.sftpClient.Mkdir(dir)
+	err := c.sftpConn().Mkdir(dir)
 	if err == nil {
 		// 清除父目录缓存
 		c.invalidateDirCache(path.Dir(dir))
@@ -168,7 +206,7 @@ func (c *Client) Mkdir(dir string) error {
 func (c *Client) Rename(oldPath, newPath string) error {
 	oldPath = c.ResolveRemotePath(oldPath)
 	newPath = c.ResolveRemotePath(newPath)
-	err := c.sftpClient.Rename(oldPath, newPath)
+	err := c.sftpConn().Rename(oldPath, newPath)
 	if err == nil {
 		// 清除相关目录缓存
 		c.invalidateDirCache(path.Dir(oldPath))
@@ -177,23 +215,76 @@ func (c *Client) Rename(oldPath, newPath string) error {
 	return err
 }
 
+// Move 移动或重命名文件/目录；newPath 是已存在的文件时将其替换。
+// 服务器不支持 posix-rename 扩展时先把目标改名移开再重命名，失败时恢复目标
+func (c *Client) Move(oldPath, newPath string) error {
+	oldPath = c.ResolveRemotePath(oldPath)
+	newPath = c.ResolveRemotePath(newPath)
+	if oldPath == newPath {
+		return fmt.Errorf("'%s' and '%s' are the same file", oldPath, newPath)
+	}
+	var err error
+	if _, ok := c.sftpConn().HasExtension("posix-rename@openssh.com"); ok {
+		err = c.sftpConn().PosixRename(oldPath, newPath)
+	} else if _, err = c.sftpConn().Lstat(oldPath); err == nil {
+		err = c.swapRename(oldPath, newPath)
+	}
+	if err == nil {
+		c.invalidateDirCache(path.Dir(oldPath))
+		c.invalidateDirCache(path.Dir(newPath))
+	}
+	return err
+}
+
+// swapRename 不支持 posix-rename 时用 src 替换 dst：先把 dst 改名为临时名称，重命名成功后再删除它，
+// 失败时改回。不先删除 dst，即使两者其实是同一个文件（如大小写不敏感的服务器）也不会丢失数据
+func (c *Client) swapRename(src, dst string) error {
+	if _, err := c.sftpConn().Lstat(dst); os.IsNotExist(err) {
+		return c.sftpConn().Rename(src, dst)
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	aside := dst + ".old-" + hex.EncodeToString(suffix)
+	if err := c.sftpConn().Rename(dst, aside); err != nil {
+		return err
+	}
+	if err := c.sftpConn().Rename(src, dst); err != nil {
+		if restoreErr := c.sftpConn().Rename(aside, dst); restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("restore %s from %s: %w", dst, aside, restoreErr))
+		}
+		return err
+	}
+	if err := c.sftpConn().Remove(aside); err != nil {
+		return fmt.Errorf("remove replaced file %s: %w", aside, err)
+	}
+	return nil
+}
+
 // Stat 获取文件信息
 func (c *Client) Stat(remotePath string) (os.FileInfo, error) {
 	remotePath = c.ResolveRemotePath(remotePath)
-	return c.sftpClient.Stat(remotePath)
+	return c.timedStat(remotePath)
+}
+
+// OpenRemoteFile 以只读方式打开远程文件，读取受传输取消控制
+func (c *Client) OpenRemoteFile(remotePath string) (io.ReadCloser, error) {
+	f, err := c.sftpConn().Open(c.ResolveRemotePath(remotePath))
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&contextReader{ctx: c.transferContext(), r: f}, f}, nil
 }
 
 // ListCompletion 获取路径补全候选列表
-// 返回基于用户输入prefix的完整候选路径（保持prefix的格式：绝对/相对）
+// 返回基于用户输入prefix的完整候选路径（保持prefix的格式：绝对/相对）；目录列表与 ls 共用缓存
 func (c *Client) ListCompletion(prefix string) []string {
 	// 解析目录和部分文件名
-	resolvedPath := c.ResolveRemotePath(prefix)
-	dir, partial := path.Split(resolvedPath)
-	if dir == "" {
-		dir = c.workDir
-	}
+	dir, partial := c.completionDir(prefix)
 
-	files, err := c.sftpClient.ReadDir(dir)
+	files, err := c.List(dir)
 	if err != nil {
 		return nil
 	}
@@ -218,27 +309,55 @@ func (c *Client) ListCompletion(prefix string) []string {
 	return matches
 }
 
+// CompletionCached 报告补全 prefix 所需的目录列表是否已在缓存中（无需访问服务器）
+func (c *Client) CompletionCached(prefix string) bool {
+	dir, _ := c.completionDir(prefix)
+	_, ok := c.cachedList(c.ResolveRemotePath(dir))
+	return ok
+}
+
+// completionDir 把补全前缀拆分为要列出的远程目录和待匹配的部分文件名
+func (c *Client) completionDir(prefix string) (dir, partial string) {
+	dir, partial = path.Split(c.ResolveRemotePath(prefix))
+	if dir == "" {
+		dir = c.Getwd()
+	}
+	return dir, partial
+}
+
 // ResolveRemotePath 解析远程路径（相对路径转绝对路径）
 func (c *Client) ResolveRemotePath(p string) string {
 	if p == "" {
-		return c.workDir
-	}
-	if p == "~" {
-		// 获取远程用户主目录
-		if home, err := c.sftpClient.Getwd(); err == nil {
-			return home
-		}
-		return c.workDir
+		return c.Getwd()
 	}
-	if strings.HasPrefix(p, "~/") {
-		if home, err := c.sftpClient.Getwd(); err == nil {
-			return path.Clean(path.Join(home, p[2:]))
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		// 远程用户主目录
+		home, err := c.remoteHome()
+		if err != nil {
+			if p == "~" {
+				return c.Getwd()
+			}
+		} else {
+			return path.Clean(path.Join(home, p[1:]))
 		}
 	}
 	if path.IsAbs(p) {
 		return path.Clean(p)
 	}
-	return path.Clean(path.Join(c.workDir, p))
+	return path.Clean(path.Join(c.Getwd(), p))
+}
+
+// remoteHome 返回远程用户主目录：优先使用连接时记录的值，避免每次往返
+func (c *Client) remoteHome() (string, error) {
+	if c.homeDir != "" {
+		return c.homeDir, nil
+	}
+	return c.sftpConn().Getwd()
+}
+
+// HomeDir 返回远程用户主目录（连接时 SFTP 服务器报告的初始工作目录），未知时为空
+func (c *Client) HomeDir() string {
+	return c.homeDir
 }
 
 // ResolveLocalPath 解析本地路径（相对路径转绝对路径）
@@ -285,31 +404,17 @@ func (c *Client) invalidateDirCache(dir string) {
 	c.cacheMu.Unlock()
 }
 
-// FormatSize formats bytes into human-readable form (binary units, 1 decimal).
-func FormatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
-}
-
 // RemoveDir removes an empty remote directory.
 func (c *Client) RemoveDir(remotePath string) error {
 	remotePath = c.ResolveRemotePath(remotePath)
-	stat, err := c.sftpClient.Stat(remotePath)
+	stat, err := c.sftpConn().Stat(remotePath)
 	if err != nil {
 		return fmt.Errorf("stat: %w", err)
 	}
 	if !stat.IsDir() {
 		return fmt.Errorf("not a directory: %s", remotePath)
 	}
-	err = c.sftpClient.RemoveDirectory(remotePath)
+	err = c.sftpConn().RemoveDirectory(remotePath)
 	if err != nil {
 		return fmt.Errorf("rmdir: directory not empty: %s (use \"rm\" to remove recursively)", remotePath)
 	}
@@ -319,27 +424,25 @@ func (c *Client) RemoveDir(remotePath string) error {
 
 // probeRemoteCaseSensitivity detects whether the remote filesystem is case-sensitive.
 // It creates a temp file with mixed-case name, stats with opposite case, and cleans up.
+// Probe names are relative, i.e. inside the server's initial directory, so the probe
+// does not have to wait for Getwd.
 // Returns true if case-sensitive (default on failure).
 func (c *Client) probeRemoteCaseSensitivity() bool {
-	workDir, err := c.sftpClient.Getwd()
-	if err != nil {
-		workDir = "/"
-	}
 	suffix := fmt.Sprintf("%d", os.Getpid())
-	probeA := path.Join(workDir, "__my_sftp_case_probe_AaBb_"+suffix+"__")
-	probeB := path.Join(workDir, "__my_sftp_case_probe_aAbB_"+suffix+"__")
+	probeA := "__my_sftp_case_probe_AaBb_" + suffix + "__"
+	probeB := "__my_sftp_case_probe_aAbB_" + suffix + "__"
 
 	// Create temp file with mixed-case name
-	f, err := c.sftpClient.Create(probeA)
+	f, err := c.sftpConn().Create(probeA)
 	if err != nil {
 		log.Println("Warning: cannot probe remote case sensitivity (no write access), assuming case-sensitive")
 		return true
 	}
 	f.Close()
-	defer func() { _ = c.sftpClient.Remove(probeA) }()
+	defer func() { _ = c.sftpConn().Remove(probeA) }()
 
 	// Stat with opposite case
-	_, err = c.sftpClient.Stat(probeB)
+	_, err = c.sftpConn().Stat(probeB)
 	if err == nil {
 		// opposite-case stat succeeded → case-insensitive
 		return false
@@ -353,20 +456,50 @@ func (c *Client) probeRemoteCaseSensitivity() bool {
 	return true
 }
 
-// ExecuteRemote 在远程服务器执行命令（交互式）
+// RemoteExitError 远程命令以非零状态结束或被信号终止
+type RemoteExitError struct {
+	Status int    // 退出状态
+	Signal string // 终止命令的信号（如 KILL），正常退出时为空
+}
+
+func (e *RemoteExitError) Error() string {
+	if e.Signal != "" {
+		return fmt.Sprintf("remote command killed by signal %s", e.Signal)
+	}
+	return fmt.Sprintf("remote command exited with status %d", e.Status)
+}
+
+// ExecuteRemote 在远程服务器的当前工作目录执行命令（交互式）；
+// 命令以非零状态结束时返回 *RemoteExitError
 func (c *Client) ExecuteRemote(command string, stdin io.Reader, stdout, stderr io.Writer) error {
-	session, err := c.sshClient.NewSession()
+	return c.executeRemote(context.Background(), command, stdin, stdout, stderr)
+}
+
+// executeRemote 同 ExecuteRemote；ctx 取消时向远程命令发送 SIGTERM 并关闭会话，返回 ctx 的错误
+func (c *Client) executeRemote(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	session, err := c.sshConn().NewSession()
 	if err != nil {
 		return fmt.Errorf("create session: %w", err)
 	}
 	defer session.Close()
+	stop := context.AfterFunc(ctx, func() {
+		session.Signal(ssh.SIGTERM)
+		session.Close()
+	})
+	defer stop()
 
 	// 绑定 stdin/stdout/stderr 实现交互
 	session.Stdin = stdin
 	session.Stdout = stdout
 	session.Stderr = stderr
 
-	// 在当前工作目录执行命令
-	fullCommand := fmt.Sprintf("cd %s && %s", c.workDir, command)
-	return session.Run(fullCommand)
+	err = session.Run(remoteCommand(c.Getwd(), command))
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return &RemoteExitError{Status: exitErr.ExitStatus(), Signal: exitErr.Signal()}
+	}
+	return err
 }
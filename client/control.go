@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DialControlMaster 通过 OpenSSH ControlMaster socket 打开到 sshd 的数据流（ssh -W），
+// 省去新的 TCP 连接和跳板；SSH 握手与认证仍在返回的连接上由调用方完成。
+// master 不可用时返回错误，不会退回到由 ssh 自己建立的新连接。
+func DialControlMaster(socket, user, host string, port int) (net.Conn, error) {
+	base := []string{"-S", socket, "-o", "ControlMaster=no", "-o", "BatchMode=yes",
+		"-p", strconv.Itoa(port), "-l", user}
+
+	// -O check 只询问 master，socket 失效时直接失败
+	if out, err := exec.Command("ssh", append(base, "-O", "check", host)...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("control master %s not usable: %s", socket, strings.TrimSpace(string(out)))
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	target := addr
+	if local, ok := controlMasterSSHDAddr(base, host); ok {
+		target = local
+	}
+
+	return startCommandConn(exec.Command("ssh", append(base, "-W", target, host)...), addr)
+}
+
+// DialProxyJump 经由 ProxyJump 跳板主机打开到 host:port 的数据流：由 ssh 程序连接跳板（ssh -J ... -W），
+// 跳板的配置、认证和主机密钥校验都交给 ssh；目标主机的 SSH 握手与认证由调用方在返回的连接上完成
+func DialProxyJump(jump, host string, port int) (net.Conn, error) {
+	hops := strings.Split(jump, ",")
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	args := []string{"-W", addr}
+	if len(hops) > 1 {
+		args = append(args, "-J", strings.Join(hops[:len(hops)-1], ","))
+	}
+	// 与 -J 不同，ssh 的目标参数不接受 host:port，需要写成 ssh:// URI
+	last := hops[len(hops)-1]
+	if strings.Contains(last, ":") {
+		last = "ssh://" + last
+	}
+	args = append(args, last)
+	return startCommandConn(exec.Command("ssh", args...), addr)
+}
+
+// startCommandConn 启动 ssh -W 子进程，将其 stdin/stdout 作为连接；ssh 的错误信息直接输出到 stderr
+func startCommandConn(cmd *exec.Cmd, addr string) (net.Conn, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start ssh -W: %w", err)
+	}
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout, remote: addr}, nil
+}
+
+// controlMasterSSHDAddr 查询 master 所连接的 sshd 在服务器侧的地址（$SSH_CONNECTION 的后两项）。
+// -W 的目标由服务器解析，HostName:Port 在服务器上不一定可达（NAT 端口映射、仅对外的域名）。
+func controlMasterSSHDAddr(base []string, host string) (string, bool) {
+	out, err := exec.Command("ssh", append(base, "-T", host, "echo $SSH_CONNECTION")...).Output()
+	if err != nil {
+		return "", false
+	}
+	return parseSSHConnection(string(out))
+}
+
+// parseSSHConnection 解析 "client_ip client_port server_ip server_port"，返回 server_ip:server_port
+func parseSSHConnection(s string) (string, bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 4 || net.ParseIP(fields[2]) == nil {
+		return "", false
+	}
+	if port, err := strconv.Atoi(fields[3]); err != nil || port < 1 || port > 65535 {
+		return "", false
+	}
+	return net.JoinHostPort(fields[2], fields[3]), true
+}
+
+// commandConn 将子进程的 stdin/stdout 包装为 net.Conn
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	remote string // 用户可见的 host:port，供主机密钥校验使用
+}
+
+func (c *commandConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *commandConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *commandConn) Close() error {
+	c.stdin.Close()
+	if c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+	c.cmd.Wait()
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr("ssh -W") }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr(c.remote) }
+
+func (c *commandConn) SetDeadline(time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(time.Time) error { return nil }
+
+// commandAddr commandConn 的地址（仅用于显示）
+type commandAddr string
+
+func (a commandAddr) Network() string { return "ssh" }
+func (a commandAddr) String() string  { return string(a) }
@@ -0,0 +1,24 @@
+package client
+
+import "testing"
+
+func TestParseSSHConnection(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"203.0.113.7 51234 10.0.0.5 22\n", "10.0.0.5:22", true},
+		{"2001:db8::1 51234 2001:db8::2 2222", "[2001:db8::2]:2222", true},
+		{"$SSH_CONNECTION\n", "", false},
+		{"", "", false},
+		{"1.2.3.4 1 host 22", "", false},
+		{"1.2.3.4 1 5.6.7.8 70000", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSSHConnection(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseSSHConnection(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
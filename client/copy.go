@@ -0,0 +1,160 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// CopyMethod 远程复制实际使用的方式
+type CopyMethod int
+
+const (
+	CopyStream  CopyMethod = iota // 经由客户端读出再写回
+	CopyFileExt                   // copy-file 扩展：服务器端整文件复制
+	CopyDataExt                   // copy-data 扩展（OpenSSH 9.0+）：服务器端按句柄复制数据
+)
+
+func (m CopyMethod) String() string {
+	switch m {
+	case CopyFileExt:
+		return "copy-file"
+	case CopyDataExt:
+		return "copy-data"
+	}
+	return "streamed through client"
+}
+
+// CopyRemote 在服务器上把文件 src 复制为 dst（已存在时覆盖），并复制权限位；
+// preserve 时同时保留修改时间。优先使用 copy-file、copy-data 扩展，
+// 服务器都不支持时经由客户端传输数据。返回实际使用的方式
+func (c *Client) CopyRemote(src, dst string, preserve bool) (CopyMethod, error) {
+	src = c.ResolveRemotePath(src)
+	dst = c.ResolveRemotePath(dst)
+	info, err := c.sftpConn().Stat(src)
+	if err != nil {
+		return CopyStream, err
+	}
+	if info.IsDir() {
+		return CopyStream, fmt.Errorf("%s is a directory", src)
+	}
+	// 复制到源文件自身（包括经符号链接指向源文件）会先截断源文件
+	if c.canonicalRemotePath(src) == c.canonicalRemotePath(dst) {
+		return CopyStream, fmt.Errorf("'%s' and '%s' are the same file", src, dst)
+	}
+
+	method, err := c.copyServerSide(src, dst)
+	if errors.Is(err, errSFTPUnsupported) {
+		method, err = CopyStream, c.copyStream(src, dst, info.Size())
+	}
+	if err != nil {
+		return method, err
+	}
+	c.invalidateDirCache(path.Dir(dst))
+
+	if err := c.sftpConn().Chmod(dst, info.Mode().Perm()); err != nil {
+		return method, fmt.Errorf("chmod: %w", err)
+	}
+	if preserve {
+		if err := c.sftpConn().Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return method, fmt.Errorf("chtimes: %w", err)
+		}
+	}
+	return method, nil
+}
+
+// maxSymlinkHops canonicalRemotePath 最多解析的符号链接层数（同 Linux）
+const maxSymlinkHops = 40
+
+// canonicalRemotePath 返回 p（已解析的绝对路径）在服务器上的规范路径，用于判断两个路径是否为同一文件：
+// 先逐层解析 p 本身的符号链接，再由服务器的 realpath 解析所在目录（p 可以不存在）。
+// 有的服务器 realpath 只清理路径、不解析符号链接，所以末尾的链接自行解析
+func (c *Client) canonicalRemotePath(p string) string {
+	p = path.Clean(p)
+	for range maxSymlinkHops {
+		info, err := c.sftpConn().Lstat(p)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			break
+		}
+		target, err := c.sftpConn().ReadLink(p)
+		if err != nil {
+			break
+		}
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(p), target)
+		}
+		p = path.Clean(target)
+	}
+	if dir, err := c.sftpConn().RealPath(path.Dir(p)); err == nil {
+		return path.Join(dir, path.Base(p))
+	}
+	return p
+}
+
+// copyServerSide 用服务器支持的扩展复制；都不支持（或打不开额外通道）时返回 errSFTPUnsupported
+func (c *Client) copyServerSide(src, dst string) (CopyMethod, error) {
+	_, hasCopyFile := c.sftpConn().HasExtension("copy-file")
+	_, hasCopyData := c.sftpConn().HasExtension("copy-data")
+	if !hasCopyFile && !hasCopyData {
+		return CopyStream, errSFTPUnsupported
+	}
+	raw, err := c.rawChannel()
+	if err != nil {
+		return CopyStream, errSFTPUnsupported
+	}
+
+	if hasCopyFile {
+		// copy-file: string source, string destination, bool overwrite
+		err := raw.extended("copy-file", src, dst, true)
+		if !errors.Is(err, errSFTPUnsupported) || !hasCopyData {
+			return CopyFileExt, err
+		}
+	}
+	return CopyDataExt, c.copyData(raw, src, dst)
+}
+
+// copyData copy-data: string read-handle, uint64 read-offset, uint64 read-length（0 表示到文件末尾），
+// string write-handle, uint64 write-offset
+func (c *Client) copyData(raw *rawSFTP, src, dst string) error {
+	rh, err := raw.open(src, sshFxfRead)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer raw.close(rh)
+	wh, err := raw.open(dst, sshFxfWrite|sshFxfCreat|sshFxfTrunc)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	if err := raw.extended("copy-data", rh, uint64(0), uint64(0), wh, uint64(0)); err != nil {
+		raw.close(wh)
+		return err
+	}
+	return raw.close(wh)
+}
+
+// copyStream 经由客户端复制：边读边写，不落地到本地磁盘
+func (c *Client) copyStream(src, dst string, size int64) error {
+	in, err := c.sftpConn().Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := c.sftpConn().OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+
+	display := c.startProgress(size, fmt.Sprintf("Copying %s", path.Base(src)), true)
+	defer display.finish()
+	defer c.trackTransfer(true)()
+
+	buf := c.getBuffer()
+	defer c.putBuffer(buf)
+	_, err = io.CopyBuffer(io.MultiWriter(out, display.bar), &contextReader{ctx: c.transferContext(), r: in}, buf)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DeniedPolicy 递归传输时遇到无权限访问的目录或文件的处理方式
+type DeniedPolicy string
+
+const (
+	DeniedAbort DeniedPolicy = "abort" // 中止整个传输（默认）
+	DeniedSkip  DeniedPolicy = "skip"  // 跳过该路径，传输其余文件，结束时列出跳过的路径
+)
+
+// DeniedPolicies 所有可用策略，按帮助文本顺序
+var DeniedPolicies = []DeniedPolicy{DeniedAbort, DeniedSkip}
+
+// ParseDeniedPolicy 解析策略名称；空字符串视为 abort
+func ParseDeniedPolicy(s string) (DeniedPolicy, error) {
+	if s == "" {
+		return DeniedAbort, nil
+	}
+	for _, p := range DeniedPolicies {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid permission policy %q (want abort or skip)", s)
+}
+
+// DeniedSkips 按 DeniedSkip 策略跳过的路径，可被多个传输 worker 并发记录。
+// 传给 DownloadOptions/UploadOptions 的 Denied 为 nil 时即 DeniedAbort
+type DeniedSkips struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// skip 处理遍历或传输 p 时的错误：权限错误时记录 p 并返回 nil（跳过），其他错误原样返回。
+// d 为 nil 时总是返回 err
+func (d *DeniedSkips) skip(p string, err error) error {
+	if d == nil || err == nil || !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paths = append(d.paths, p)
+	return nil
+}
+
+// Paths 返回跳过的路径，按字母顺序
+func (d *DeniedSkips) Paths() []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	paths := slices.Clone(d.paths)
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+// Summary 跳过路径的汇总文本（每行一个路径），没有跳过时返回 ""
+func (d *DeniedSkips) Summary() string {
+	paths := d.Paths()
+	if len(paths) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Skipped %d path(s) (permission denied):", len(paths))
+	for _, p := range paths {
+		fmt.Fprintf(&b, "\n  - %s", p)
+	}
+	return b.String()
+}
+
+// sourceError 读取传输的源文件失败。传输中只有这类权限错误按 DeniedSkip 跳过，
+// 目标端（创建、写入）的权限错误仍然算失败，以免把写不进去的文件悄悄当作跳过
+type sourceError struct {
+	err error
+}
+
+func (e *sourceError) Error() string { return e.err.Error() }
+
+func (e *sourceError) Unwrap() error { return e.err }
+
+// isSourceError err 是否为读取源文件时的错误
+func isSourceError(err error) bool {
+	var se *sourceError
+	return errors.As(err, &se)
+}
+
+// remaining 返回 tasks 中源路径未被跳过的任务，用于传输后的校验
+func (d *DeniedSkips) remaining(tasks []transferTask) []transferTask {
+	paths := d.Paths()
+	if len(paths) == 0 {
+		return tasks
+	}
+	return slices.DeleteFunc(slices.Clone(tasks), func(t transferTask) bool {
+		_, found := slices.BinarySearch(paths, taskSourcePath(t))
+		return found
+	})
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDeniedSkips(t *testing.T) {
+	denied := fmt.Errorf("read remote dir /srv/private: %w", os.ErrPermission)
+	var abort *DeniedSkips
+	if err := abort.skip("/srv/private", denied); !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("nil DeniedSkips skip() = %v, want the error back", err)
+	}
+
+	d := &DeniedSkips{}
+	if err := d.skip("/srv/private", denied); err != nil {
+		t.Fatalf("skip(permission denied) = %v, want nil", err)
+	}
+	if err := d.skip("/srv/a.txt", os.ErrNotExist); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("skip(not exist) = %v, want the error back", err)
+	}
+	d.skip("/srv/b.txt", os.ErrPermission)
+	d.skip("/srv/b.txt", os.ErrPermission)
+	if got := d.Paths(); !slices.Equal(got, []string{"/srv/b.txt", "/srv/private"}) {
+		t.Fatalf("Paths() = %q", got)
+	}
+
+	tasks := []transferTask{{remotePath: "/srv/a.txt"}, {remotePath: "/srv/b.txt"}}
+	if got := d.remaining(tasks); len(got) != 1 || got[0].remotePath != "/srv/a.txt" {
+		t.Fatalf("remaining() = %+v", got)
+	}
+	if (&DeniedSkips{}).Summary() != "" {
+		t.Fatal("Summary() without skipped paths should be empty")
+	}
+	// 传输中只跳过读取源文件时的权限错误
+	if !isSourceError(fmt.Errorf("range 0-10: %w", &sourceError{os.ErrPermission})) {
+		t.Fatal("isSourceError(wrapped sourceError) = false")
+	}
+	if isSourceError(fmt.Errorf("create remote: %w", os.ErrPermission)) {
+		t.Fatal("isSourceError(destination error) = true")
+	}
+}
+
+func TestParseDeniedPolicy(t *testing.T) {
+	if p, err := ParseDeniedPolicy(""); err != nil || p != DeniedAbort {
+		t.Fatalf(`ParseDeniedPolicy("") = %q, %v`, p, err)
+	}
+	if p, err := ParseDeniedPolicy("skip"); err != nil || p != DeniedSkip {
+		t.Fatalf(`ParseDeniedPolicy("skip") = %q, %v`, p, err)
+	}
+	if _, err := ParseDeniedPolicy("ignore"); err == nil {
+		t.Fatal(`ParseDeniedPolicy("ignore") expected error`)
+	}
+}
+
+func TestCollectUploadTasksSkipsDeniedDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any directory")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	if err := os.MkdirAll(locked, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "locked/b.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	c := &Client{}
+	if _, _, err := c.collectUploadTasks(root, "/r", -1, 0, nil, "", nil); !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("abort policy error = %v, want permission denied", err)
+	}
+	d := &DeniedSkips{}
+	tasks, emptyDirs, err := c.collectUploadTasks(root, "/r", -1, 0, nil, "", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].remotePath != "/r/a.txt" || len(emptyDirs) != 0 {
+		t.Fatalf("tasks = %+v, emptyDirs = %q", tasks, emptyDirs)
+	}
+	if got := d.Paths(); !slices.Equal(got, []string{locked}) {
+		t.Fatalf("skipped = %q", got)
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DiskUsage 远程路径所在文件系统的容量（来自 statvfs@openssh.com 扩展）
+type DiskUsage struct {
+	Path       string // 解析后的远程路径
+	Total      uint64 // 总字节数
+	Used       uint64 // 已用字节数
+	Available  uint64 // 非 root 用户可用的字节数
+	Inodes     uint64 // inode 总数
+	InodesFree uint64 // 空闲的 inode 数
+}
+
+// UsePercent 与 df 相同：已用 / (已用 + 可用)，向上取整
+func (d *DiskUsage) UsePercent() int {
+	return ceilPercent(d.Used, d.Used+d.Available)
+}
+
+// InodesUsed 已用 inode 数
+func (d *DiskUsage) InodesUsed() uint64 {
+	if d.InodesFree > d.Inodes {
+		return 0
+	}
+	return d.Inodes - d.InodesFree
+}
+
+// InodesUsePercent inode 使用率，文件系统不报告 inode 时为 -1
+func (d *DiskUsage) InodesUsePercent() int {
+	if d.Inodes == 0 {
+		return -1
+	}
+	return ceilPercent(d.InodesUsed(), d.Inodes)
+}
+
+func ceilPercent(part, whole uint64) int {
+	if whole == 0 {
+		return 0
+	}
+	return int((part*100 + whole - 1) / whole)
+}
+
+// DiskUsage 查询 remotePath 所在文件系统的容量和 inode 数
+func (c *Client) DiskUsage(remotePath string) (*DiskUsage, error) {
+	remotePath = c.ResolveRemotePath(remotePath)
+	if _, ok := c.sftpConn().HasExtension("statvfs@openssh.com"); !ok {
+		return nil, errors.New("server does not support the statvfs@openssh.com extension (try '! df -h')")
+	}
+	vfs, err := c.sftpConn().StatVFS(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("statvfs %s: %w", remotePath, err)
+	}
+	free := vfs.Frsize * vfs.Bfree
+	return &DiskUsage{
+		Path:       remotePath,
+		Total:      vfs.TotalSpace(),
+		Used:       vfs.TotalSpace() - free,
+		Available:  vfs.Frsize * vfs.Bavail,
+		Inodes:     vfs.Files,
+		InodesFree: vfs.Ffree,
+	}, nil
+}
@@ -0,0 +1,17 @@
+package client
+
+import "testing"
+
+func TestDiskUsagePercent(t *testing.T) {
+	// 与 df 一样，保留给 root 的块不计入分母
+	usage := &DiskUsage{Total: 1000, Used: 601, Available: 300, Inodes: 200, InodesFree: 150}
+	if got := usage.UsePercent(); got != 67 {
+		t.Fatalf("UsePercent() = %d, want 67", got)
+	}
+	if got := usage.InodesUsePercent(); got != 25 {
+		t.Fatalf("InodesUsePercent() = %d, want 25", got)
+	}
+	if got := (&DiskUsage{}).InodesUsePercent(); got != -1 {
+		t.Fatalf("InodesUsePercent() without inodes = %d, want -1", got)
+	}
+}
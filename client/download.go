@@ -1,12 +1,15 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/schollz/progressbar/v3"
@@ -14,41 +17,64 @@ import (
 
 // Download 下载文件
 func (c *Client) Download(remotePath, localPath string) error {
+	return c.download(remotePath, localPath, nil)
+}
+
+// DownloadChecksum 下载文件，下载过程中增量计算摘要并与服务器端计算的摘要比较；
+// algorithm 为空时使用 DefaultHashAlgorithm
+func (c *Client) DownloadChecksum(remotePath, localPath, algorithm string) error {
+	alg, err := LookupHashAlgorithm(algorithm)
+	if err != nil {
+		return err
+	}
+	return c.download(remotePath, localPath, alg)
+}
+
+// download 下载单个文件并显示进度；checksum 非 nil 时用该算法校验
+func (c *Client) download(remotePath, localPath string, checksum *HashAlgorithm) error {
 	remotePath = c.ResolveRemotePath(remotePath)
 
 	// 获取文件信息以创建进度条
-	stat, err := c.sftpClient.Stat(remotePath)
+	stat, err := c.sftpConn().Stat(remotePath)
 	if err != nil {
 		return err
 	}
 
 	// 创建单文件进度条（显示文件名）
-	bar := progressbar.NewOptions64(stat.Size(),
-		progressbar.OptionSetDescription(fmt.Sprintf("Downloading %s (1/1 files)", path.Base(remotePath))),
-		progressbar.OptionShowBytes(true),
-		progressbar.OptionSetWidth(40),
-		progressbar.OptionSetPredictTime(true),
-	)
-	defer bar.Finish()
-	defer fmt.Println()
-
-	return c.DownloadWithProgress(remotePath, localPath, bar)
+	display := c.startProgress(stat.Size(), fmt.Sprintf("Downloading %s (1/1 files)", path.Base(remotePath)), false)
+	defer display.finish()
+	defer c.trackTransfer(false)()
+
+	progressEvent, done := c.singleFileEvents(transferTask{localPath: c.ResolveLocalPath(localPath), remotePath: remotePath, size: stat.Size()})
+	progress := c.newTransferProgress(display.bar, progressEvent)
+	if checksum != nil {
+		err = c.downloadChecksummed(c.transferContext(), checksum, remotePath, localPath, progress)
+	} else {
+		err = c.downloadFile(c.transferContext(), remotePath, localPath, progress, nil)
+	}
+	done(err)
+	return err
 }
 
 // DownloadWithProgress 下载文件（支持进度条）
 func (c *Client) DownloadWithProgress(remotePath, localPath string, globalBar *progressbar.ProgressBar) error {
+	return c.downloadFile(c.transferContext(), remotePath, localPath, c.newTransferProgress(globalBar, nil), nil)
+}
+
+// downloadFile 下载单个文件；digest 非 nil 时同时写入下载的内容
+func (c *Client) downloadFile(ctx context.Context, remotePath, localPath string, progress *transferProgress, digest io.Writer) error {
 	remotePath = c.ResolveRemotePath(remotePath)
 	localPath = c.ResolveLocalPath(localPath)
 
 	// 获取远程文件信息（确保文件存在）
-	_, err := c.sftpClient.Stat(remotePath)
+	_, err := c.sftpConn().Stat(remotePath)
 	if err != nil {
-		return fmt.Errorf("stat remote: %w", err)
+		return &sourceError{fmt.Errorf("stat remote: %w", err)}
 	}
 
-	srcFile, err := c.sftpClient.Open(remotePath)
+	srcFile, err := c.dataClient(ctx).Open(remotePath)
 	if err != nil {
-		return fmt.Errorf("open remote: %w", err)
+		return &sourceError{fmt.Errorf("open remote: %w", err)}
 	}
 	defer srcFile.Close()
 
@@ -71,12 +97,15 @@ func (c *Client) DownloadWithProgress(remotePath, localPath string, globalBar *p
 	defer c.putBuffer(buf)
 
 	// 使用缓冲和进度条
-	var writer io.Writer = dstFile
-	if globalBar != nil {
-		writer = io.MultiWriter(dstFile, globalBar)
+	writers := []io.Writer{dstFile}
+	if digest != nil {
+		writers = append(writers, digest)
+	}
+	if progress != nil {
+		writers = append(writers, progress)
 	}
 
-	_, err = io.CopyBuffer(writer, srcFile, buf)
+	_, err = io.CopyBuffer(io.MultiWriter(writers...), &contextReader{ctx: ctx, r: srcFile}, buf)
 	return err
 }
 
@@ -84,16 +113,47 @@ func (c *Client) DownloadWithProgress(remotePath, localPath string, globalBar *p
 type DownloadOptions struct {
 	Recursive    bool // 递归下载目录
 	ShowProgress bool // 显示进度条
-	Concurrency  int  // 并发数
+	Concurrency  int  // 并发数，0 表示客户端默认值（见 WithConcurrency）
 	Flatten      bool // 扁平化目标路径
 	MaxDepth     int  // 最大递归深度：-1=无限, 0=仅当前目录, 1=一层子目录...
+	// RecreateSpecial 重建符号链接和 FIFO，而不是跟随链接/跳过
+	RecreateSpecial bool
+	// Verify 传输后重新比较源与目标的大小，不一致时返回 *VerifyError
+	Verify bool
+	// Checksum 下载时增量计算摘要，结束时与服务器端计算的结果比较（不再重读本地文件）
+	Checksum bool
+	// Hash Checksum 使用的算法（见 LookupHashAlgorithm），空值等同于 DefaultHashAlgorithm
+	Hash string
+	// ParallelStreams 不小于 ParallelThreshold 的文件拆成多个字节范围并行传输，<2 表示不并行
+	ParallelStreams   int
+	ParallelThreshold int64
+	// Filter 递归下载时的 include/exclude 过滤，nil 表示不过滤
+	Filter *PathFilter
+	// Overwrite 目标已存在时的处理策略，空值等同于 OverwriteAlways
+	Overwrite OverwritePolicy
+	// Preserve 保留源文件的修改时间和权限位（-p）
+	Preserve bool
+	// Scanner 下载后扫描本地文件，nil 表示不扫描
+	Scanner *ContentScanner
+	// Batch 传输所属的批次（单独取消、统计进度），nil 表示普通传输
+	Batch *Batch
+	// FailFast 任一文件失败即中断整个传输，见 TransferOptions.FailFast
+	FailFast bool
+	// TaskTimeout 单个文件的传输时限，0 表示不限时
+	TaskTimeout time.Duration
+	// Order 文件的启动顺序，见 TransferOrder
+	Order TransferOrder
+	// Denied 非 nil 时跳过无权限读取的目录和文件并记录在其中（DeniedSkip），nil 时中止传输
+	Denied *DeniedSkips
+	// Literal source 是实际存在的路径（如 find 的结果），含 * ? [ 也不作为 glob 展开
+	Literal bool
 }
 
 // DownloadDir 递归下载整个目录
 // 使用统一的任务收集+执行模式，避免并发嵌套
 func (c *Client) DownloadDir(remoteDir, localDir string, opts *DownloadOptions) (int, error) {
 	resolvedDir := c.ResolveRemotePath(remoteDir)
-	stat, err := c.sftpClient.Stat(resolvedDir)
+	stat, err := c.sftpConn().Stat(resolvedDir)
 	if err != nil {
 		return 0, fmt.Errorf("stat remote dir: %w", err)
 	}
@@ -115,41 +175,26 @@ func (c *Client) DownloadDir(remoteDir, localDir string, opts *DownloadOptions)
 
 // DownloadSources 下载一个或多个远程 source（显式路径或 glob）
 func (c *Client) DownloadSources(remoteSources []string, localDir string, opts *DownloadOptions) (int, error) {
-	if len(remoteSources) == 0 {
-		return 0, fmt.Errorf("missing source path")
-	}
-
 	if opts == nil {
-		opts = &DownloadOptions{
-			ShowProgress: true,
-			Concurrency:  MaxConcurrentTransfers,
-			MaxDepth:     -1,
-		}
+		opts = c.defaultDownloadOptions()
 	}
 
 	localDir = c.ResolveLocalPath(localDir)
 
-	var tasks []transferTask
-	for _, source := range remoteSources {
-		sourceTasks, err := c.collectDownloadSourceTasks(source, localDir, opts, len(remoteSources))
-		if err != nil {
-			return 0, err
-		}
-		tasks = append(tasks, sourceTasks...)
+	tasks, err := c.planDownloadTasks(remoteSources, localDir, opts)
+	if err != nil {
+		return 0, err
 	}
-
-	if len(tasks) == 0 {
+	tasks, specials, skipped := c.splitSpecialTasks(tasks, opts.RecreateSpecial)
+	printSkippedSpecial(os.Stdout, skipped)
+	tasks, specials, err = c.applyOverwritePolicy(tasks, specials, opts.Overwrite)
+	if err != nil {
+		return 0, err
+	}
+	if len(tasks) == 0 && len(specials) == 0 {
 		return 0, nil
 	}
 
-	if opts.Flatten {
-		if err := c.applyFlattenMapping(tasks, localDir); err != nil {
-			return 0, err
-		}
-	}
-	if err := c.validateTargetCollisions(tasks); err != nil {
-		return 0, err
-	}
 	if err := os.MkdirAll(localDir, 0755); err != nil {
 		return 0, fmt.Errorf("create local dir: %w", err)
 	}
@@ -158,7 +203,7 @@ func (c *Client) DownloadSources(remoteSources []string, localDir string, opts *
 		return 0, err
 	}
 
-	fmt.Printf("Found %d file(s) to download\n", len(tasks))
+	fmt.Printf("Found %d file(s) to download\n", len(tasks)+len(specials))
 
 	// 使用统一执行引擎
 	transferOpts := &TransferOptions{
@@ -166,8 +211,69 @@ func (c *Client) DownloadSources(remoteSources []string, localDir string, opts *
 		ShowProgress: opts.ShowProgress,
 		Concurrency:  opts.Concurrency,
 		MaxDepth:     opts.MaxDepth,
+
+		ParallelStreams:   opts.ParallelStreams,
+		ParallelThreshold: opts.ParallelThreshold,
+		Preserve:          opts.Preserve,
+		Scanner:           opts.Scanner,
+		Batch:             opts.Batch,
+		FailFast:          opts.FailFast,
+		TaskTimeout:       opts.TaskTimeout,
+		Checksum:          opts.Checksum,
+		Hash:              opts.Hash,
+		Order:             opts.Order,
+		Denied:            opts.Denied,
+	}
+	count, err := c.executeTasks(tasks, transferOpts)
+	tasks = opts.Denied.remaining(tasks)
+	created, specialErrs := c.recreateSpecialTasks(specials)
+	count += created
+	if err != nil || len(specialErrs) > 0 {
+		return count, errors.Join(append([]error{err}, specialErrs...)...)
+	}
+	if opts.Verify {
+		return count, c.verifyTasks(tasks, false, opts.Concurrency)
+	}
+	return count, nil
+}
+
+// planDownloadTasks 解析 source 并生成下载任务（不触碰本地文件系统）
+// localDir 必须是已解析的本地路径
+func (c *Client) planDownloadTasks(remoteSources []string, localDir string, opts *DownloadOptions) ([]transferTask, error) {
+	if len(remoteSources) == 0 {
+		return nil, fmt.Errorf("missing source path")
+	}
+
+	var tasks []transferTask
+	for _, source := range remoteSources {
+		sourceTasks, err := c.collectDownloadSourceTasks(source, localDir, opts, len(remoteSources))
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, sourceTasks...)
+	}
+
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	if opts.Flatten {
+		if err := c.applyFlattenMapping(tasks, localDir); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.validateTargetCollisions(tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (c *Client) defaultDownloadOptions() *DownloadOptions {
+	return &DownloadOptions{
+		ShowProgress: true,
+		Concurrency:  c.Concurrency(),
+		MaxDepth:     -1,
 	}
-	return c.executeTasks(tasks, transferOpts)
 }
 
 // DownloadGlob 使用 glob 模式匹配下载远程文件
@@ -179,12 +285,12 @@ func (c *Client) collectDownloadSourceTasks(source, localDir string, opts *Downl
 	if sourceCount > 1 && !opts.Flatten && usesReservedPreservePrefix(source, false) {
 		return nil, fmt.Errorf("source path uses reserved preserve prefix: %s", source)
 	}
-	if strings.ContainsAny(source, "*?[]") {
+	if !opts.Literal && strings.ContainsAny(source, "*?[]") {
 		return c.collectDownloadGlobTasks(source, localDir, opts)
 	}
 
 	resolvedSource := c.ResolveRemotePath(source)
-	stat, err := c.sftpClient.Stat(resolvedSource)
+	stat, err := c.sftpConn().Stat(resolvedSource)
 	if err != nil {
 		return nil, err
 	}
@@ -197,7 +303,7 @@ func (c *Client) collectDownloadSourceTasks(source, localDir string, opts *Downl
 		if sourceCount > 1 {
 			dirRoot = filepath.Join(localDir, filepath.FromSlash(explicitRemoteFilePreservePath(source, resolvedSource)))
 		}
-		tasks, err := c.collectDownloadTasks(resolvedSource, dirRoot, opts.MaxDepth, 0)
+		tasks, err := c.collectDownloadTasks(resolvedSource, dirRoot, opts.MaxDepth, 0, opts.Filter, "", opts.Denied)
 		if err != nil {
 			return nil, fmt.Errorf("collect tasks for %s: %w", source, err)
 		}
@@ -214,20 +320,17 @@ func (c *Client) collectDownloadSourceTasks(source, localDir string, opts *Downl
 		remotePath: resolvedSource,
 		isUpload:   false,
 		size:       stat.Size(),
+		mode:       stat.Mode().Type(),
 	}}, nil
 }
 
 func (c *Client) collectDownloadGlobTasks(pattern, localDir string, opts *DownloadOptions) ([]transferTask, error) {
 	if opts == nil {
-		opts = &DownloadOptions{
-			ShowProgress: true,
-			Concurrency:  MaxConcurrentTransfers,
-			MaxDepth:     -1,
-		}
+		opts = c.defaultDownloadOptions()
 	}
 
 	// 解析 glob 模式的基路径
-	basePath := c.workDir
+	basePath := c.Getwd()
 	fullPattern := pattern
 	var globBase string
 	if !path.IsAbs(pattern) {
@@ -259,14 +362,18 @@ func (c *Client) collectDownloadGlobTasks(pattern, localDir string, opts *Downlo
 
 	entries := make([]transferSourceEntry, 0, len(matches))
 	for _, match := range matches {
-		stat, err := c.sftpClient.Stat(match)
+		stat, err := c.sftpConn().Stat(match)
 		if err != nil {
-			return nil, fmt.Errorf("stat match %s: %w", match, err)
+			if err := opts.Denied.skip(match, fmt.Errorf("stat match %s: %w", match, err)); err != nil {
+				return nil, err
+			}
+			continue
 		}
 		entries = append(entries, transferSourceEntry{
 			path:  match,
 			isDir: stat.IsDir(),
 			size:  stat.Size(),
+			mode:  stat.Mode().Type(),
 		})
 	}
 	entries = normalizeMatchedSourceEntries(entries, false, opts.Recursive)
@@ -280,7 +387,10 @@ func (c *Client) collectDownloadGlobTasks(pattern, localDir string, opts *Downlo
 			mapped := remoteRelativePath(globBaseAbs, match)
 			mapped = joinPreservePath(globBasePrefix, mapped)
 			localSubDir := filepath.Join(localDir, filepath.FromSlash(mapped))
-			subTasks, err := c.collectDownloadTasks(match, localSubDir, opts.MaxDepth, 0)
+			if opts.Filter.SkipDir(mapped) {
+				continue
+			}
+			subTasks, err := c.collectDownloadTasks(match, localSubDir, opts.MaxDepth, 0, opts.Filter, mapped, opts.Denied)
 			if err != nil {
 				return nil, fmt.Errorf("collect tasks for %s: %w", match, err)
 			}
@@ -288,12 +398,16 @@ func (c *Client) collectDownloadGlobTasks(pattern, localDir string, opts *Downlo
 		} else {
 			mapped := remoteRelativePath(globBaseAbs, match)
 			mapped = joinPreservePath(globBasePrefix, mapped)
+			if !opts.Filter.AllowFile(mapped) {
+				continue
+			}
 			localFile := filepath.Join(localDir, filepath.FromSlash(mapped))
 			tasks = append(tasks, transferTask{
 				localPath:  localFile,
 				remotePath: match,
 				isUpload:   false,
 				size:       entry.size,
+				mode:       entry.mode,
 			})
 		}
 	}
@@ -344,8 +458,9 @@ func remoteRelativePath(base, target string) string {
 	return path.Base(target)
 }
 
-// globRemote 在远程文件系统上执行 glob 匹配
-func (c *Client) globRemote(pattern string) ([]string, error) {
+// remoteGlobWalk 返回 glob 需要遍历的起始目录和最大深度：
+// 起始目录是第一个通配符之前的部分；模式包含 ** 时深度为 -1（不限），否则为剩余的路径段数
+func remoteGlobWalk(pattern string) (root string, maxDepth int) {
 	// 找到第一个包含通配符的路径段
 	parts := strings.Split(pattern, "/")
 	baseIdx := 0
@@ -357,19 +472,28 @@ func (c *Client) globRemote(pattern string) ([]string, error) {
 	}
 
 	// 基路径是通配符之前的部分
-	basePath := "/"
+	root = "/"
 	if baseIdx > 0 {
-		basePath = strings.Join(parts[:baseIdx], "/")
-		if basePath == "" {
-			basePath = "/"
+		root = strings.Join(parts[:baseIdx], "/")
+		if root == "" {
+			root = "/"
 		}
 	}
+	if strings.Contains(pattern, "**") {
+		return root, -1
+	}
+	return root, len(parts) - baseIdx
+}
+
+// globRemote 在远程文件系统上执行 glob 匹配
+func (c *Client) globRemote(pattern string) ([]string, error) {
+	basePath, maxDepth := remoteGlobWalk(pattern)
 
 	// 收集所有远程文件
 	var allFiles []string
-	var walk func(string) error
-	walk = func(dir string) error {
-		entries, err := c.sftpClient.ReadDir(dir)
+	var walk func(string, int) error
+	walk = func(dir string, depth int) error {
+		entries, err := c.sftpConn().ReadDir(dir)
 		if err != nil {
 			return nil // 忽略无法访问的目录
 		}
@@ -377,18 +501,16 @@ func (c *Client) globRemote(pattern string) ([]string, error) {
 		for _, entry := range entries {
 			fullPath := path.Join(dir, entry.Name())
 			allFiles = append(allFiles, fullPath)
-			if entry.IsDir() {
-				// 只有在模式包含 ** 时才递归
-				if strings.Contains(pattern, "**") {
-					walk(fullPath)
-				}
+			// 只在模式还有更深的路径段时递归
+			if entry.IsDir() && (maxDepth < 0 || depth < maxDepth) {
+				walk(fullPath, depth+1)
 			}
 		}
 		return nil
 	}
 
 	// 从基路径开始遍历
-	walk(basePath)
+	walk(basePath, 1)
 
 	// 使用 doublestar 进行匹配
 	var matches []string
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// errorExamples 合并显示的每组错误最多列出的文件数
+const errorExamples = 3
+
+// fileError 单个文件的操作失败，如 "upload ./a.txt: ..."
+type fileError struct {
+	op     string // upload、download、delete 等
+	path   string // 显示的路径
+	target string // 另一端的路径（可为空），错误信息中出现时同样视为文件名
+	err    error
+}
+
+func (e *fileError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.op, e.path, e.err)
+}
+
+func (e *fileError) Unwrap() error { return e.err }
+
+// cause 去掉文件路径后的错误原因，原因相同的文件错误合并显示
+func (e *fileError) cause() string {
+	msg := e.err.Error()
+	for _, p := range []string{e.path, e.target} {
+		if p != "" {
+			msg = strings.ReplaceAll(msg, p, "<file>")
+		}
+	}
+	return msg
+}
+
+// bulkError 多个文件的错误：原因相同的 fileError 合并为一组，显示数量和前几个文件，
+// 避免同一原因（如父目录无权限）打印成百上千行相同的信息。errors.Is/As 仍能找到每个错误
+type bulkError struct {
+	errs []error
+	msg  string // 合并后的信息，创建时生成一次
+}
+
+// joinFileErrors 同 errors.Join，但原因相同的文件错误合并显示
+func joinFileErrors(errs []error) error {
+	var kept []error
+	for _, err := range errs {
+		if err != nil {
+			kept = append(kept, err)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return &bulkError{errs: kept, msg: summarizeErrors(kept)}
+}
+
+func (e *bulkError) Unwrap() []error { return e.errs }
+
+func (e *bulkError) Error() string { return e.msg }
+
+// summarizeErrors 生成 bulkError 的信息；不修改 errs，分组和排序都在新的切片上进行
+func summarizeErrors(errs []error) string {
+	type group struct {
+		cause string
+		errs  []*fileError
+	}
+	var lines []any // string（单独显示的错误）或 *group，按首次出现的顺序
+	groups := make(map[string]*group)
+	for _, err := range errs {
+		fe, ok := err.(*fileError)
+		if !ok {
+			lines = append(lines, err.Error())
+			continue
+		}
+		key := fe.op + "\x00" + fe.cause()
+		g := groups[key]
+		if g == nil {
+			g = &group{cause: fe.cause()}
+			groups[key] = g
+			lines = append(lines, g)
+		}
+		g.errs = append(g.errs, fe)
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		g, ok := line.(*group)
+		if !ok {
+			b.WriteString(line.(string))
+			continue
+		}
+		if len(g.errs) == 1 {
+			b.WriteString(g.errs[0].Error())
+			continue
+		}
+		fmt.Fprintf(&b, "%s failed for %d files: %s", g.errs[0].op, len(g.errs), g.cause)
+		// 并发传输的失败顺序不固定，按路径排序后再取例子
+		slices.SortFunc(g.errs, func(a, b *fileError) int { return strings.Compare(a.path, b.path) })
+		for _, fe := range g.errs[:min(len(g.errs), errorExamples)] {
+			fmt.Fprintf(&b, "\n  %s", fe.path)
+		}
+		if more := len(g.errs) - errorExamples; more > 0 {
+			fmt.Fprintf(&b, "\n  ... and %d more", more)
+		}
+	}
+	return b.String()
+}
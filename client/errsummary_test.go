@@ -0,0 +1,52 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestJoinFileErrorsGroupsSameCause(t *testing.T) {
+	var errs []error
+	for i := 4; i >= 0; i-- {
+		target := fmt.Sprintf("/srv/www/f%d.txt", i)
+		errs = append(errs, &fileError{op: "upload", path: fmt.Sprintf("site/f%d.txt", i), target: target,
+			err: fmt.Errorf("create remote: %w", &fs.PathError{Op: "open", Path: target, Err: os.ErrPermission})})
+	}
+	errs = append(errs, &fileError{op: "upload", path: "site/big.iso", err: errors.New("connection lost")})
+	errs = append(errs, errors.New("transfer cancelled: 2 file(s) not started"))
+
+	err := joinFileErrors(errs)
+	want := `upload failed for 5 files: create remote: open <file>: permission denied
+  site/f0.txt
+  site/f1.txt
+  site/f2.txt
+  ... and 2 more
+upload site/big.iso: connection lost
+transfer cancelled: 2 file(s) not started`
+	if err.Error() != want {
+		t.Errorf("Error() =\n%s\nwant\n%s", err, want)
+	}
+	if !errors.Is(err, os.ErrPermission) {
+		t.Error("errors.Is(err, os.ErrPermission) = false")
+	}
+	// 显示时按路径排序，但不改变 Unwrap 返回的顺序
+	if first := err.(interface{ Unwrap() []error }).Unwrap()[0].(*fileError); first.path != "site/f4.txt" {
+		t.Errorf("Unwrap()[0] = %s, want the first error passed in", first.path)
+	}
+}
+
+func TestJoinFileErrorsSingle(t *testing.T) {
+	if joinFileErrors(nil) != nil || joinFileErrors([]error{nil}) != nil {
+		t.Fatal("joinFileErrors without errors != nil")
+	}
+	err := joinFileErrors([]error{
+		&fileError{op: "download", path: "/srv/a", err: errors.New("EOF")},
+		&fileError{op: "download", path: "/srv/b", err: errors.New("timeout")},
+	})
+	if want := "download /srv/a: EOF\ndownload /srv/b: timeout"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err, want)
+	}
+}
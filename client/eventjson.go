@@ -0,0 +1,93 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// eventJSONInterval 同一文件两次 progress 事件之间的最短间隔
+const eventJSONInterval = 250 * time.Millisecond
+
+// EventJSON NewEventJSONWriter 输出的一行 JSON
+type EventJSON struct {
+	Event      string `json:"event"`     // started、progress、retry、dir-created、error、completed
+	Direction  string `json:"direction"` // upload 或 download
+	Source     string `json:"source,omitempty"`
+	Target     string `json:"target"`
+	Bytes      int64  `json:"bytes"`
+	Total      int64  `json:"total"`
+	Index      int    `json:"index,omitempty"` // 文件在本次传输中的序号，从 1 开始
+	Count      int    `json:"count,omitempty"` // 本次传输的文件总数
+	Attempt    int    `json:"attempt,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Background bool   `json:"background,omitempty"` // 属于后台任务（&）
+	Time       string `json:"time"`                 // RFC 3339，毫秒精度
+}
+
+// WithEventJSON 把传输事件逐行写成 JSON 到 w（见 NewEventJSONWriter），用于 --progress-fd
+func WithEventJSON(w io.Writer) ClientOption {
+	return func(c *Client) {
+		c.Subscribe(NewEventJSONWriter(w))
+	}
+}
+
+// NewEventJSONWriter 返回把事件逐行写成 JSON 的回调（供 --progress-fd 等嵌入场景使用）。
+// 同一文件的 progress 事件至多每 250ms 一条，传输到最后一个字节时总会输出；
+// 写入失败（读端关闭）后不再输出，不影响传输
+func NewEventJSONWriter(w io.Writer) EventHandler {
+	return newEventJSONWriter(w, eventJSONInterval)
+}
+
+func newEventJSONWriter(w io.Writer, interval time.Duration) EventHandler {
+	var (
+		mu     sync.Mutex
+		enc    = json.NewEncoder(w)
+		last   = make(map[string]time.Time) // Target -> 上次输出 progress 的时间
+		broken bool
+	)
+	return func(ev Event) {
+		now := time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		if broken {
+			return
+		}
+		switch ev.Type {
+		case EventProgress:
+			if ev.Bytes < ev.Total && now.Sub(last[ev.Target]) < interval {
+				return
+			}
+			last[ev.Target] = now
+		case EventCompleted, EventError:
+			delete(last, ev.Target)
+		}
+		if err := enc.Encode(newEventJSON(ev, now)); err != nil {
+			broken = true
+		}
+	}
+}
+
+func newEventJSON(ev Event, now time.Time) EventJSON {
+	out := EventJSON{
+		Event:      ev.Type.String(),
+		Source:     ev.Source,
+		Target:     ev.Target,
+		Bytes:      ev.Bytes,
+		Total:      ev.Total,
+		Index:      ev.Index,
+		Count:      ev.Count,
+		Attempt:    ev.Attempt,
+		Background: ev.Batch != nil,
+		Time:       now.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+	}
+	out.Direction = "download"
+	if ev.IsUpload {
+		out.Direction = "upload"
+	}
+	if ev.Err != nil {
+		out.Error = ev.Err.Error()
+	}
+	return out
+}
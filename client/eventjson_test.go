@@ -0,0 +1,53 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventJSONWriterThrottlesProgress(t *testing.T) {
+	var buf bytes.Buffer
+	handler := newEventJSONWriter(&buf, time.Hour)
+
+	handler(Event{Type: EventTransferStarted, IsUpload: true, Source: "a.txt", Target: "/srv/a.txt", Total: 30, Index: 1, Count: 2})
+	handler(Event{Type: EventProgress, IsUpload: true, Target: "/srv/a.txt", Bytes: 10, Total: 30})
+	handler(Event{Type: EventProgress, IsUpload: true, Target: "/srv/a.txt", Bytes: 20, Total: 30}) // 间隔内，丢弃
+	handler(Event{Type: EventProgress, IsUpload: true, Target: "/srv/a.txt", Bytes: 30, Total: 30}) // 最后一个字节，总会输出
+	handler(Event{Type: EventCompleted, IsUpload: true, Target: "/srv/a.txt", Bytes: 30, Total: 30})
+	handler(Event{Type: EventError, Target: "b.txt", Err: errors.New("permission denied"), Batch: &Batch{}})
+
+	var got []EventJSON
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec EventJSON
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got = append(got, rec)
+	}
+
+	want := []struct {
+		event string
+		bytes int64
+	}{{"started", 0}, {"progress", 10}, {"progress", 30}, {"completed", 30}, {"error", 0}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Event != w.event || got[i].Bytes != w.bytes {
+			t.Errorf("line %d = %s/%d, want %s/%d", i, got[i].Event, got[i].Bytes, w.event, w.bytes)
+		}
+	}
+	if got[0].Direction != "upload" || got[0].Source != "a.txt" || got[0].Index != 1 || got[0].Count != 2 {
+		t.Errorf("started = %+v", got[0])
+	}
+	if last := got[4]; last.Direction != "download" || last.Error != "permission denied" || !last.Background {
+		t.Errorf("error = %+v", last)
+	}
+	if _, err := time.Parse(time.RFC3339, got[0].Time); err != nil {
+		t.Errorf("time %q: %v", got[0].Time, err)
+	}
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// EventType 客户端事件类型
+type EventType int
+
+const (
+	EventTransferStarted EventType = iota // 单个文件开始传输
+	EventProgress                         // 单个文件传输了更多字节
+	EventRetry                            // 传输失败后重试（由重试逻辑发出）
+	EventDirCreated                       // 创建了远程目录
+	EventError                            // 单个文件传输失败
+	EventCompleted                        // 单个文件传输完成
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventTransferStarted:
+		return "started"
+	case EventProgress:
+		return "progress"
+	case EventRetry:
+		return "retry"
+	case EventDirCreated:
+		return "dir-created"
+	case EventError:
+		return "error"
+	case EventCompleted:
+		return "completed"
+	}
+	return "unknown"
+}
+
+// Event 客户端发出的结构化事件，供 shell 显示或嵌入客户端的程序使用
+type Event struct {
+	Type     EventType
+	IsUpload bool
+	Source   string // 源路径（DirCreated 时为空）
+	Target   string // 目标路径
+	Bytes    int64  // 已传输字节数（Progress/Completed）
+	Total    int64  // 文件总大小
+	Index    int    // 文件在本批次中的序号，从 1 开始；单文件 Download/Upload 为 0
+	Count    int    // 本批次文件总数；单文件 Download/Upload 为 0
+	Attempt  int    // 第几次重试（Retry）
+	Err      error  // Error/Retry 的原因
+	Batch    *Batch // 所属批次（见 TransferOptions.Batch），普通传输为 nil
+}
+
+// EventHandler 事件回调；会在传输 goroutine 中并发调用，必须快速返回
+type EventHandler func(Event)
+
+// eventHub 管理事件订阅者
+type eventHub struct {
+	mu       sync.RWMutex
+	handlers map[int]EventHandler
+	nextID   int
+}
+
+// Subscribe 注册事件回调，返回取消订阅函数
+func (c *Client) Subscribe(handler EventHandler) (unsubscribe func()) {
+	h := &c.events
+	h.mu.Lock()
+	if h.handlers == nil {
+		h.handlers = make(map[int]EventHandler)
+	}
+	id := h.nextID
+	h.nextID++
+	h.handlers[id] = handler
+	h.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.handlers, id)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Events 以 channel 形式订阅事件。channel 满时丢弃 Progress 事件，其他事件阻塞等待；
+// 调用返回的 cancel 后停止投递并关闭 channel。
+func (c *Client) Events(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	done := make(chan struct{})
+	unsubscribe := c.Subscribe(func(ev Event) {
+		if ev.Type == EventProgress {
+			select {
+			case ch <- ev:
+			default:
+			}
+			return
+		}
+		select {
+		case ch <- ev:
+		case <-done:
+		}
+	})
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			close(done)
+			// 取消订阅会等待正在进行的投递结束，之后关闭 channel 是安全的
+			unsubscribe()
+			close(ch)
+		})
+	}
+}
+
+func (c *Client) hasSubscribers() bool {
+	c.events.mu.RLock()
+	defer c.events.mu.RUnlock()
+	return len(c.events.handlers) > 0
+}
+
+// emit 同步调用所有订阅者
+func (c *Client) emit(ev Event) {
+	c.events.mu.RLock()
+	defer c.events.mu.RUnlock()
+	for _, handler := range c.events.handlers {
+		handler(ev)
+	}
+}
+
+// taskEvent 根据传输任务构造事件
+func taskEvent(typ EventType, task transferTask, index, count int) Event {
+	return Event{
+		Type:     typ,
+		IsUpload: task.isUpload,
+		Source:   taskSourcePath(task),
+		Target:   taskTargetPath(task),
+		Total:    task.size,
+		Index:    index,
+		Count:    count,
+	}
+}
+
+// batchTaskEvent 构造属于 batch 的任务事件
+func batchTaskEvent(typ EventType, task transferTask, index, count int, batch *Batch) Event {
+	ev := taskEvent(typ, task, index, count)
+	ev.Batch = batch
+	return ev
+}
+
+// singleFileEvents 为不经过 executeTasks 的单文件传输（Download、Upload）发出 Started 事件，
+// 返回 Progress 事件模板和结束时发出 Completed 或 Error 事件的函数。
+// 这些事件的 Index、Count 为 0，表示不属于批量传输
+func (c *Client) singleFileEvents(task transferTask) (*Event, func(error)) {
+	c.emit(taskEvent(EventTransferStarted, task, 0, 0))
+	progressEvent := taskEvent(EventProgress, task, 0, 0)
+	return &progressEvent, func(err error) {
+		if err != nil {
+			ev := taskEvent(EventError, task, 0, 0)
+			ev.Err = err
+			c.emit(ev)
+			return
+		}
+		ev := taskEvent(EventCompleted, task, 0, 0)
+		ev.Bytes = task.size
+		c.emit(ev)
+	}
+}
+
+// transferProgress 单个文件的进度接收者：推进全局进度条并发出 Progress 事件。
+// nil 接收者是合法的（不显示也不发事件）。
+type transferProgress struct {
+	mu      sync.Mutex // 并行传输时多个 goroutine 同时写入
+	c       *Client
+	bar     *progressbar.ProgressBar
+	event   *Event // 为 nil 时不发出 Progress 事件
+	batch   *Batch // 非 nil 时累计批次的已传输字节数
+	written int64  // 本次尝试已记录的字节数，重试时由 rewind 撤销
+}
+
+// newTransferProgress 没有进度条、订阅者和批次时返回 nil
+func (c *Client) newTransferProgress(bar *progressbar.ProgressBar, event *Event) *transferProgress {
+	var batch *Batch
+	if event != nil {
+		batch = event.Batch
+	}
+	if event != nil && !c.hasSubscribers() {
+		event = nil
+	}
+	if bar == nil && event == nil && batch == nil {
+		return nil
+	}
+	return &transferProgress{c: c, bar: bar, event: event, batch: batch}
+}
+
+func (p *transferProgress) Write(b []byte) (int, error) {
+	p.Add64(int64(len(b)))
+	return len(b), nil
+}
+
+// Add64 记录 n 个字节的进度（跳过已上传分块时直接调用）
+func (p *transferProgress) Add64(n int64) {
+	if p == nil || n == 0 {
+		return
+	}
+	if p.bar != nil {
+		p.bar.Add64(n)
+	}
+	if p.batch != nil {
+		p.batch.doneBytes.Add(n)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written += n
+	if p.event != nil {
+		p.event.Bytes += n
+		ev := *p.event
+		ev.Type = EventProgress
+		p.c.emit(ev)
+	}
+}
+
+// rewind 撤销本次尝试记录的进度，文件从头重新传输前调用
+func (p *transferProgress) rewind() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bar != nil {
+		p.bar.Add64(-p.written)
+	}
+	if p.batch != nil {
+		p.batch.doneBytes.Add(-p.written)
+	}
+	if p.event != nil {
+		p.event.Bytes = 0
+	}
+	p.written = 0
+}
@@ -0,0 +1,75 @@
+package client
+
+import "testing"
+
+func TestSubscribeAndUnsubscribe(t *testing.T) {
+	c := &Client{}
+	var got []EventType
+	unsubscribe := c.Subscribe(func(ev Event) { got = append(got, ev.Type) })
+
+	c.emit(Event{Type: EventTransferStarted})
+	c.emit(Event{Type: EventCompleted})
+	unsubscribe()
+	unsubscribe()
+	c.emit(Event{Type: EventError})
+
+	if len(got) != 2 || got[0] != EventTransferStarted || got[1] != EventCompleted {
+		t.Fatalf("received %v, want [started completed]", got)
+	}
+	if c.hasSubscribers() {
+		t.Fatal("hasSubscribers() = true after unsubscribe")
+	}
+}
+
+func TestEventsChannelDropsProgressWhenFull(t *testing.T) {
+	c := &Client{}
+	ch, cancel := c.Events(1)
+
+	c.emit(Event{Type: EventProgress, Bytes: 1})
+	c.emit(Event{Type: EventProgress, Bytes: 2}) // 缓冲已满，丢弃
+	if ev := <-ch; ev.Bytes != 1 {
+		t.Fatalf("first event bytes = %d, want 1", ev.Bytes)
+	}
+
+	c.emit(Event{Type: EventCompleted})
+	done := make(chan struct{})
+	go func() {
+		c.emit(Event{Type: EventError}) // 缓冲已满，阻塞直到 cancel
+		close(done)
+	}()
+	if ev := <-ch; ev.Type != EventCompleted {
+		t.Fatalf("event = %v, want completed", ev.Type)
+	}
+	<-done
+	cancel()
+	for ev := range ch {
+		if ev.Type != EventError {
+			t.Fatalf("unexpected event after cancel: %v", ev.Type)
+		}
+	}
+}
+
+func TestTransferProgressEmitsCumulativeBytes(t *testing.T) {
+	c := &Client{}
+	task := transferTask{localPath: "/local/a.bin", remotePath: "/srv/a.bin", isUpload: true, size: 10}
+	ev := taskEvent(EventProgress, task, 1, 3)
+
+	if p := c.newTransferProgress(nil, &ev); p != nil {
+		t.Fatal("newTransferProgress() without bar or subscribers should be nil")
+	}
+
+	var got []Event
+	c.Subscribe(func(ev Event) { got = append(got, ev) })
+	p := c.newTransferProgress(nil, &ev)
+	p.Write(make([]byte, 4))
+	p.Add64(6)
+	p.Add64(0)
+
+	if len(got) != 2 {
+		t.Fatalf("got %d progress events, want 2", len(got))
+	}
+	last := got[1]
+	if last.Type != EventProgress || last.Bytes != 10 || last.Total != 10 || last.Index != 1 || last.Count != 3 || last.Target != "/srv/a.bin" {
+		t.Fatalf("last event = %+v", last)
+	}
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// ExportFormat 列表导出格式
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportJSON ExportFormat = "json"
+)
+
+// ParseExportFormat 解析导出格式名称
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return ExportCSV, nil
+	case "json":
+		return ExportJSON, nil
+	}
+	return "", fmt.Errorf("unsupported export format: %s (want csv or json)", name)
+}
+
+// ExportFormatForFile 根据文件扩展名推断导出格式，默认 CSV
+func ExportFormatForFile(file string) ExportFormat {
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		return ExportJSON
+	}
+	return ExportCSV
+}
+
+// ExportEntry 导出的一条远程元数据
+type ExportEntry struct {
+	Path    string    `json:"path"`
+	Type    string    `json:"type"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Mode    string    `json:"mode"`
+	UID     *uint32   `json:"uid,omitempty"`
+	GID     *uint32   `json:"gid,omitempty"`
+}
+
+var exportCSVHeader = []string{"path", "type", "size", "mtime", "mode", "uid", "gid"}
+
+// NewExportEntry 由远程路径和文件信息构造导出条目（ls/stat 的 JSON 输出也使用）
+func NewExportEntry(p string, info os.FileInfo) ExportEntry {
+	entry := ExportEntry{
+		Path:    p,
+		Type:    exportEntryType(info.Mode()),
+		Size:    info.Size(),
+		ModTime: info.ModTime().UTC(),
+		Mode:    info.Mode().String(),
+	}
+	if stat, ok := info.Sys().(*sftp.FileStat); ok {
+		uid, gid := stat.UID, stat.GID
+		entry.UID, entry.GID = &uid, &gid
+	}
+	return entry
+}
+
+func exportEntryType(mode os.FileMode) string {
+	if mode.IsDir() {
+		return "dir"
+	}
+	if kind := specialKind(mode.Type()); kind != "" {
+		return kind
+	}
+	return "file"
+}
+
+// listingWriter 流式写出导出条目，内存占用与条目数无关
+type listingWriter struct {
+	format ExportFormat
+	w      io.Writer
+	csv    *csv.Writer
+	count  int
+}
+
+func newListingWriter(w io.Writer, format ExportFormat) (*listingWriter, error) {
+	lw := &listingWriter{format: format, w: w}
+	switch format {
+	case ExportCSV:
+		lw.csv = csv.NewWriter(w)
+		if err := lw.csv.Write(exportCSVHeader); err != nil {
+			return nil, err
+		}
+	case ExportJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+	return lw, nil
+}
+
+func (lw *listingWriter) write(entry ExportEntry) error {
+	lw.count++
+	if lw.format == ExportCSV {
+		return lw.csv.Write([]string{
+			entry.Path,
+			entry.Type,
+			strconv.FormatInt(entry.Size, 10),
+			entry.ModTime.Format(time.RFC3339),
+			entry.Mode,
+			optionalID(entry.UID),
+			optionalID(entry.GID),
+		})
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if lw.count == 1 {
+		sep = "\n  "
+	}
+	if _, err := io.WriteString(lw.w, sep); err != nil {
+		return err
+	}
+	_, err = lw.w.Write(data)
+	return err
+}
+
+func (lw *listingWriter) close() error {
+	if lw.format == ExportCSV {
+		lw.csv.Flush()
+		return lw.csv.Error()
+	}
+	end := "\n]\n"
+	if lw.count == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(lw.w, end)
+	return err
+}
+
+func optionalID(id *uint32) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// ExportListing 将远程目录（recursive 时为整棵树）的元数据流式写入 w，返回条目数
+func (c *Client) ExportListing(remotePath string, recursive bool, format ExportFormat, w io.Writer) (int, error) {
+	remotePath = c.ResolveRemotePath(remotePath)
+	lw, err := newListingWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.walkListing(remotePath, recursive, lw.write); err != nil {
+		return lw.count, err
+	}
+	return lw.count, lw.close()
+}
+
+// walkListing 对已解析的远程目录 remotePath 下的每个条目（recursive 时为整棵树，不含根本身）调用 fn
+func (c *Client) walkListing(remotePath string, recursive bool, fn func(ExportEntry) error) error {
+	if !recursive {
+		entries, err := c.sftpConn().ReadDir(remotePath)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := fn(NewExportEntry(path.Join(remotePath, entry.Name()), entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	walker := c.sftpConn().Walk(remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return fmt.Errorf("walk %s: %w", walker.Path(), err)
+		}
+		if walker.Path() == remotePath {
+			continue
+		}
+		if err := fn(NewExportEntry(walker.Path(), walker.Stat())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+type statFileInfo struct {
+	fakeFileInfo
+	stat *sftp.FileStat
+}
+
+func (f statFileInfo) Sys() interface{} { return f.stat }
+
+func exportFixtures() []ExportEntry {
+	mtime := time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC)
+	return []ExportEntry{
+		NewExportEntry("/srv/a.txt", statFileInfo{
+			fakeFileInfo: fakeFileInfo{name: "a.txt", size: 42, mode: 0644, modTime: mtime},
+			stat:         &sftp.FileStat{UID: 1000, GID: 100},
+		}),
+		NewExportEntry("/srv/sub", fakeFileInfo{name: "sub", mode: os.ModeDir | 0755, modTime: mtime}),
+	}
+}
+
+func TestListingWriterCSV(t *testing.T) {
+	var buf bytes.Buffer
+	lw, err := newListingWriter(&buf, ExportCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range exportFixtures() {
+		if err := lw.write(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lw.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	want := [][]string{
+		exportCSVHeader,
+		{"/srv/a.txt", "file", "42", "2024-04-30T12:00:00Z", "-rw-r--r--", "1000", "100"},
+		{"/srv/sub", "dir", "0", "2024-04-30T12:00:00Z", "drwxr-xr-x", "", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %v", rows)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if rows[i][j] != want[i][j] {
+				t.Fatalf("row %d = %v, want %v", i, rows[i], want[i])
+			}
+		}
+	}
+}
+
+func TestListingWriterJSON(t *testing.T) {
+	for _, entries := range [][]ExportEntry{exportFixtures(), nil} {
+		var buf bytes.Buffer
+		lw, err := newListingWriter(&buf, ExportJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if err := lw.write(entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := lw.close(); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []ExportEntry
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+		}
+		if len(got) != len(entries) {
+			t.Fatalf("decoded %d entries, want %d", len(got), len(entries))
+		}
+		if len(got) > 0 && (got[0].Path != "/srv/a.txt" || *got[0].UID != 1000 || got[1].UID != nil) {
+			t.Fatalf("decoded = %+v", got)
+		}
+	}
+}
+
+func TestExportFormatForFile(t *testing.T) {
+	if ExportFormatForFile("listing.JSON") != ExportJSON || ExportFormatForFile("listing.csv") != ExportCSV || ExportFormatForFile("listing") != ExportCSV {
+		t.Fatal("unexpected format inference")
+	}
+	if _, err := ParseExportFormat("xml"); err == nil {
+		t.Fatal("ParseExportFormat(xml) expected error")
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+)
+
+// reservedFiles 按打开文件数上限限制并发时，为 SSH 连接、控制套接字、历史文件、日志和前台命令保留的描述符数
+const reservedFiles = 64
+
+// openFileLimit 本进程可打开的文件数（RLIMIT_NOFILE 的软限制），0 表示未知或不限
+var openFileLimit = sync.OnceValue(currentFileLimit)
+
+// fileLimitNotice 只提示一次并发被打开文件数上限缩小
+var fileLimitNotice sync.Once
+
+// fileBudget 传输可同时打开的本地文件数，0 表示不限
+func fileBudget() int {
+	limit := openFileLimit()
+	if limit == 0 {
+		return 0
+	}
+	return max(limit-reservedFiles, 1)
+}
+
+// clampToFileLimit 按打开文件数上限缩小同时传输的文件数和每个文件的并行流数（每个流打开一次本地文件），
+// 缩小时提示一次，避免传输中途随机出现 "too many open files"
+func clampToFileLimit(concurrency, streams int) (int, int) {
+	budget := fileBudget()
+	if budget == 0 {
+		return concurrency, streams
+	}
+	clampedConcurrency, clampedStreams := clampToBudget(budget, concurrency, streams)
+	if clampedConcurrency != concurrency || clampedStreams != streams {
+		fileLimitNotice.Do(func() {
+			parallel := ""
+			if clampedStreams > 1 {
+				parallel = fmt.Sprintf(" with %d streams each", clampedStreams)
+			}
+			fmt.Printf("ℹ Open file limit is %d (ulimit -n): transferring at most %d file(s) at once%s; raise the limit to allow more\n",
+				openFileLimit(), clampedConcurrency, parallel)
+		})
+	}
+	return clampedConcurrency, clampedStreams
+}
+
+// fileLimitHint 本地打开文件数耗尽导致的错误附加解决建议，其他错误原样返回
+func fileLimitHint(err error) error {
+	if err == nil || !isTooManyFiles(err) {
+		return err
+	}
+	return fmt.Errorf("%w (open file limit is %d: raise it with ulimit -n, or lower --concurrency/--parallel)", err, openFileLimit())
+}
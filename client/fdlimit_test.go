@@ -0,0 +1,47 @@
+//go:build !windows
+
+package client
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestClampToFileLimit(t *testing.T) {
+	saved := openFileLimit
+	defer func() { openFileLimit = saved }()
+
+	openFileLimit = func() int { return 0 }
+	if c, s := clampToFileLimit(500, 8); c != 500 || s != 8 {
+		t.Fatalf("unknown limit: clampToFileLimit(500, 8) = %d, %d", c, s)
+	}
+
+	openFileLimit = func() int { return reservedFiles + 32 }
+	if c, s := clampToFileLimit(4, 4); c != 4 || s != 4 {
+		t.Fatalf("within budget: clampToFileLimit(4, 4) = %d, %d", c, s)
+	}
+	if c, s := clampToFileLimit(100, 0); c != 32 || s != 0 {
+		t.Fatalf("clampToFileLimit(100, 0) = %d, %d, want 32, 0", c, s)
+	}
+	if c, s := clampToFileLimit(16, 8); c != 4 || s != 8 {
+		t.Fatalf("clampToFileLimit(16, 8) = %d, %d, want 4, 8", c, s)
+	}
+}
+
+func TestFileLimitHint(t *testing.T) {
+	if fileLimitHint(nil) != nil {
+		t.Fatal("fileLimitHint(nil) should be nil")
+	}
+	other := errors.New("boom")
+	if fileLimitHint(other) != other {
+		t.Fatal("unrelated errors should be returned unchanged")
+	}
+	emfile := &os.PathError{Op: "open", Path: "/tmp/a", Err: syscall.EMFILE}
+	err := fileLimitHint(emfile)
+	if !errors.Is(err, syscall.EMFILE) || !strings.Contains(err.Error(), "ulimit -n") {
+		t.Fatalf("fileLimitHint(EMFILE) = %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package client
+
+import (
+	"errors"
+	"syscall"
+)
+
+// currentFileLimit 读取 RLIMIT_NOFILE 的软限制（Go 启动时已尽量提高到硬限制）；不限时按 1<<20 计
+func currentFileLimit() int {
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+		return 0
+	}
+	return int(min(uint64(rlim.Cur), 1<<20))
+}
+
+// isTooManyFiles 判断 err 是否为进程或系统的打开文件数耗尽
+func isTooManyFiles(err error) bool {
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE)
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package client
+
+// currentFileLimit Windows 没有 RLIMIT_NOFILE，句柄数不做限制
+func currentFileLimit() int {
+	return 0
+}
+
+func isTooManyFiles(err error) bool {
+	return false
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// PathFilter 递归传输时的 include/exclude 过滤（doublestar 模式）。
+// 路径相对于源目录（glob 时相对于其静态前缀），使用 / 分隔：
+//   - 不含 / 的模式匹配任意层级的单个名称，如 "*.log"、"node_modules"
+//   - 含 / 的模式匹配完整相对路径，如 "node_modules/**"、"docs/*.md"
+//
+// 被 exclude 匹配的目录整个跳过；指定了 include 时只传输匹配任一 include 的文件。
+// nil 表示不过滤。
+type PathFilter struct {
+	include []string
+	exclude []string
+}
+
+// NewPathFilter 校验模式并创建过滤器；两者都为空时返回 nil
+func NewPathFilter(include, exclude []string) (*PathFilter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if pattern == "" || !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid filter pattern: %q", pattern)
+		}
+	}
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	return &PathFilter{include: include, exclude: exclude}, nil
+}
+
+// matchFilterPattern 按上面的规则判断 rel 是否匹配 pattern
+func matchFilterPattern(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.Contains(pattern, "/") {
+		ok, _ := doublestar.Match(pattern, rel)
+		return ok
+	}
+	for _, name := range strings.Split(rel, "/") {
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *PathFilter) excluded(rel string) bool {
+	for _, pattern := range f.exclude {
+		if matchFilterPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// SkipDir 判断相对路径为 rel 的目录是否整个跳过
+func (f *PathFilter) SkipDir(rel string) bool {
+	return f != nil && f.excluded(rel)
+}
+
+// AllowFile 判断相对路径为 rel 的文件是否传输
+func (f *PathFilter) AllowFile(rel string) bool {
+	if f == nil {
+		return true
+	}
+	if f.excluded(rel) {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if matchFilterPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinFilterPath 拼接过滤用的相对路径
+func joinFilterPath(relDir, name string) string {
+	if relDir == "" || relDir == "." {
+		return name
+	}
+	return path.Join(relDir, name)
+}
@@ -0,0 +1,71 @@
+package client
+
+import "testing"
+
+func TestPathFilter(t *testing.T) {
+	f, err := NewPathFilter(nil, []string{"node_modules/**", "*.log", ".git"})
+	if err != nil {
+		t.Fatalf("NewPathFilter() error = %v", err)
+	}
+	skipDirs := map[string]bool{
+		"node_modules":     true,
+		"node_modules/pkg": true,
+		"src/node_modules": false, // 含 / 的模式只匹配完整相对路径
+		".git":             true,
+		"lib/.git":         true, // 不含 / 的模式匹配任意层级
+		"src":              false,
+	}
+	for rel, want := range skipDirs {
+		if got := f.SkipDir(rel); got != want {
+			t.Errorf("SkipDir(%q) = %v, want %v", rel, got, want)
+		}
+	}
+	allowFiles := map[string]bool{
+		"main.go":          true,
+		"debug.log":        false,
+		"logs/app.log":     false,
+		"logs/app.log.txt": true,
+	}
+	for rel, want := range allowFiles {
+		if got := f.AllowFile(rel); got != want {
+			t.Errorf("AllowFile(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestPathFilterInclude(t *testing.T) {
+	f, err := NewPathFilter([]string{"*.go", "docs/**/*.md"}, []string{"*_test.go"})
+	if err != nil {
+		t.Fatalf("NewPathFilter() error = %v", err)
+	}
+	allowFiles := map[string]bool{
+		"main.go":           true,
+		"pkg/util.go":       true,
+		"pkg/util_test.go":  false, // exclude 优先于 include
+		"docs/guide/a.md":   true,
+		"README.md":         false,
+		"assets/logo.png":   false,
+		"docs/guide/a.html": false,
+	}
+	for rel, want := range allowFiles {
+		if got := f.AllowFile(rel); got != want {
+			t.Errorf("AllowFile(%q) = %v, want %v", rel, got, want)
+		}
+	}
+	if f.SkipDir("assets") {
+		t.Error("include patterns must not prune directories")
+	}
+}
+
+func TestNewPathFilter(t *testing.T) {
+	if f, err := NewPathFilter(nil, nil); f != nil || err != nil {
+		t.Fatalf("NewPathFilter(nil, nil) = %v, %v; want nil, nil", f, err)
+	}
+	if _, err := NewPathFilter(nil, []string{"[abc"}); err == nil {
+		t.Fatal("NewPathFilter() expected error for invalid pattern")
+	}
+	var nilFilter *PathFilter
+	if nilFilter.SkipDir("x") || !nilFilter.AllowFile("x") {
+		t.Fatal("nil filter must not filter anything")
+	}
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frostime/my-sftp/units"
+)
+
+// FindOptions find 的过滤条件，未设置的条件不过滤
+type FindOptions struct {
+	Name  string     // 文件名 glob（-name，path.Match 语法，只匹配最后一级）
+	Type  string     // "file"、"dir" 或 "symlink"（-type f/d/l）
+	Mtime *FindBound // 修改时间距今的整天数（-mtime）
+	Size  *FindBound // 字节数（-size）
+
+	// OnError 处理无法读取的目录；返回 nil 时跳过该目录继续查找。为 nil 时遇到错误即停止
+	OnError func(path string, err error) error
+}
+
+// FindBound -mtime/-size 的数值条件：+N 表示大于 N，-N 表示小于 N，N 表示等于 N
+type FindBound struct {
+	Cmp   int // 1 大于，-1 小于，0 等于
+	Value int64
+}
+
+func (b *FindBound) match(v int64) bool {
+	switch {
+	case b == nil:
+		return true
+	case b.Cmp > 0:
+		return v > b.Value
+	case b.Cmp < 0:
+		return v < b.Value
+	}
+	return v == b.Value
+}
+
+// ParseFindDays 解析 -mtime 的值，如 "7"、"+30"、"-1"
+func ParseFindDays(s string) (*FindBound, error) {
+	return parseFindBound(s, func(v string) (int64, error) {
+		return strconv.ParseInt(v, 10, 64)
+	})
+}
+
+// ParseFindSize 解析 -size 的值，如 "+100M"、"-4K"、"0"（单位同 units.ParseSize，无单位为字节）
+func ParseFindSize(s string) (*FindBound, error) {
+	return parseFindBound(s, units.ParseSize)
+}
+
+func parseFindBound(s string, parse func(string) (int64, error)) (*FindBound, error) {
+	bound := &FindBound{}
+	value := s
+	switch {
+	case strings.HasPrefix(s, "+"):
+		bound.Cmp, value = 1, s[1:]
+	case strings.HasPrefix(s, "-"):
+		bound.Cmp, value = -1, s[1:]
+	}
+	n, err := parse(value)
+	if err != nil || n < 0 || value == "" {
+		return nil, fmt.Errorf("invalid value: %s", s)
+	}
+	bound.Value = n
+	return bound, nil
+}
+
+// match 报告条目是否满足所有条件；now 用于计算 -mtime 的天数
+func (o *FindOptions) match(entry ExportEntry, now time.Time) bool {
+	if o.Type != "" && entry.Type != o.Type {
+		return false
+	}
+	if o.Name != "" {
+		if ok, _ := path.Match(o.Name, path.Base(entry.Path)); !ok {
+			return false
+		}
+	}
+	if !o.Size.match(entry.Size) {
+		return false
+	}
+	// 与 find 相同：距今时间按 24 小时向下取整
+	return o.Mtime.match(int64(now.Sub(entry.ModTime) / (24 * time.Hour)))
+}
+
+// Find 遍历 root（包括 root 本身），对每个满足条件的条目调用 fn；条目路径为远程绝对路径
+func (c *Client) Find(root string, opts *FindOptions, fn func(ExportEntry) error) error {
+	root = c.ResolveRemotePath(root)
+	if opts == nil {
+		opts = &FindOptions{}
+	}
+	if opts.Name != "" {
+		if _, err := path.Match(opts.Name, ""); err != nil {
+			return fmt.Errorf("invalid -name pattern %q: %w", opts.Name, err)
+		}
+	}
+
+	now := time.Now()
+	walker := c.sftpConn().Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if walker.Path() == root || opts.OnError == nil {
+				return fmt.Errorf("find %s: %w", walker.Path(), err)
+			}
+			if err := opts.OnError(walker.Path(), err); err != nil {
+				return err
+			}
+			continue
+		}
+		entry := NewExportEntry(walker.Path(), walker.Stat())
+		if opts.match(entry, now) {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportFind 将 Find 的结果按 ExportListing 的格式流式写入 w，返回条目数
+func (c *Client) ExportFind(root string, opts *FindOptions, format ExportFormat, w io.Writer) (int, error) {
+	lw, err := newListingWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.Find(root, opts, lw.write); err != nil {
+		return lw.count, err
+	}
+	return lw.count, lw.close()
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFindBound(t *testing.T) {
+	tests := []struct {
+		input string
+		parse func(string) (*FindBound, error)
+		want  FindBound
+	}{
+		{"7", ParseFindDays, FindBound{Cmp: 0, Value: 7}},
+		{"+30", ParseFindDays, FindBound{Cmp: 1, Value: 30}},
+		{"-1", ParseFindDays, FindBound{Cmp: -1, Value: 1}},
+		{"+100M", ParseFindSize, FindBound{Cmp: 1, Value: 100 << 20}},
+		{"-4K", ParseFindSize, FindBound{Cmp: -1, Value: 4 << 10}},
+	}
+	for _, tt := range tests {
+		got, err := tt.parse(tt.input)
+		if err != nil || *got != tt.want {
+			t.Errorf("parse(%q) = %+v, %v, want %+v", tt.input, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "+", "x", "--1"} {
+		if _, err := ParseFindDays(bad); err == nil {
+			t.Errorf("ParseFindDays(%q) expected error", bad)
+		}
+	}
+}
+
+func TestFindOptionsMatch(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	old := ExportEntry{Path: "/srv/logs/app-1.log.gz", Type: "file", Size: 5 << 20, ModTime: now.Add(-40 * 24 * time.Hour)}
+	fresh := ExportEntry{Path: "/srv/logs/app.log", Type: "file", Size: 200, ModTime: now.Add(-2 * time.Hour)}
+	dir := ExportEntry{Path: "/srv/logs/archive", Type: "dir", ModTime: now.Add(-3 * 24 * time.Hour)}
+
+	mtimeOver30, _ := ParseFindDays("+30")
+	mtimeExact3, _ := ParseFindDays("3")
+	sizeOver1M, _ := ParseFindSize("+1M")
+	tests := []struct {
+		name string
+		opts FindOptions
+		want []bool // old, fresh, dir
+	}{
+		{"no filter", FindOptions{}, []bool{true, true, true}},
+		{"name", FindOptions{Name: "*.gz"}, []bool{true, false, false}},
+		{"type", FindOptions{Type: "dir"}, []bool{false, false, true}},
+		{"mtime older", FindOptions{Mtime: mtimeOver30}, []bool{true, false, false}},
+		{"mtime exact days", FindOptions{Mtime: mtimeExact3}, []bool{false, false, true}},
+		{"size and type", FindOptions{Type: "file", Size: sizeOver1M}, []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		for i, entry := range []ExportEntry{old, fresh, dir} {
+			if got := tt.opts.match(entry, now); got != tt.want[i] {
+				t.Errorf("%s: match(%s) = %v, want %v", tt.name, entry.Path, got, tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
+)
+
+// DefaultHashAlgorithm 未指定算法时使用的校验和算法
+const DefaultHashAlgorithm = "sha256"
+
+// HashAlgorithm 校验和算法：本地计算用 New，服务器端计算用 RemoteCommand
+type HashAlgorithm struct {
+	Name string
+	New  func() hash.Hash
+	// RemoteCommand 服务器端计算摘要的命令，%[1]s 为转义后的路径；输出的第一个字段是十六进制摘要
+	RemoteCommand string
+	// RemoteTool RemoteCommand 依赖的程序，连接后首次使用时用 command -v 探测；空表示不探测
+	RemoteTool string
+	// DigestPrefix 服务器输出中摘要前可能带的前缀（如 xxhsum 的 "XXH3_"）
+	DigestPrefix string
+}
+
+var (
+	hashAlgorithmsMu sync.RWMutex
+	hashAlgorithms   = map[string]*HashAlgorithm{}
+)
+
+// RegisterHashAlgorithm 注册校验和算法，同名算法被替换
+func RegisterHashAlgorithm(alg HashAlgorithm) {
+	hashAlgorithmsMu.Lock()
+	defer hashAlgorithmsMu.Unlock()
+	hashAlgorithms[strings.ToLower(alg.Name)] = &alg
+}
+
+// LookupHashAlgorithm 按名称查找校验和算法，空名称返回 DefaultHashAlgorithm
+func LookupHashAlgorithm(name string) (*HashAlgorithm, error) {
+	if name == "" {
+		name = DefaultHashAlgorithm
+	}
+	hashAlgorithmsMu.RLock()
+	alg, ok := hashAlgorithms[strings.ToLower(name)]
+	hashAlgorithmsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown hash algorithm: %s (available: %s)", name, strings.Join(HashAlgorithmNames(), ", "))
+	}
+	return alg, nil
+}
+
+// HashAlgorithmNames 返回已注册的算法名称（已排序）
+func HashAlgorithmNames() []string {
+	hashAlgorithmsMu.RLock()
+	defer hashAlgorithmsMu.RUnlock()
+	names := make([]string, 0, len(hashAlgorithms))
+	for name := range hashAlgorithms {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func init() {
+	RegisterHashAlgorithm(HashAlgorithm{
+		Name:          "sha256",
+		New:           sha256.New,
+		RemoteCommand: "sha256sum %[1]s 2>/dev/null || shasum -a 256 %[1]s",
+	})
+	RegisterHashAlgorithm(HashAlgorithm{
+		Name:          "blake3",
+		New:           func() hash.Hash { return blake3.New() },
+		RemoteCommand: "b3sum %[1]s",
+		RemoteTool:    "b3sum",
+	})
+	RegisterHashAlgorithm(HashAlgorithm{
+		Name:          "xxh3",
+		New:           func() hash.Hash { return xxh3.New() },
+		RemoteCommand: "xxhsum -H3 %[1]s",
+		RemoteTool:    "xxhsum",
+		DigestPrefix:  "XXH3_",
+	})
+}
+
+// parseDigest 从服务器命令输出中取出摘要，检查长度是否符合算法
+func (alg *HashAlgorithm) parseDigest(output string) (string, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("unexpected %s output: %q", alg.Name, strings.TrimSpace(output))
+	}
+	sum := strings.ToLower(strings.TrimPrefix(fields[0], alg.DigestPrefix))
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != alg.New().Size()*2 {
+		return "", fmt.Errorf("unexpected %s output: %q", alg.Name, strings.TrimSpace(output))
+	}
+	return sum, nil
+}
+
+// hashToolCache 服务器上校验和程序是否可用，每个连接只探测一次
+type hashToolCache struct {
+	mu    sync.Mutex
+	tools map[string]bool
+}
+
+// remoteHashAlgorithm 返回服务器端可以计算的算法：alg 依赖的程序不存在时退回 sha256
+func (c *Client) remoteHashAlgorithm(alg *HashAlgorithm) *HashAlgorithm {
+	if alg.RemoteTool == "" {
+		return alg
+	}
+	c.hashTools.mu.Lock()
+	defer c.hashTools.mu.Unlock()
+	available, probed := c.hashTools.tools[alg.RemoteTool]
+	if !probed {
+		err := c.ExecuteRemote("command -v "+shellQuote(alg.RemoteTool), nil, &bytes.Buffer{}, &bytes.Buffer{})
+		available = err == nil
+		if c.hashTools.tools == nil {
+			c.hashTools.tools = make(map[string]bool)
+		}
+		c.hashTools.tools[alg.RemoteTool] = available
+		if !available {
+			fmt.Printf("ℹ %s not found on server, falling back to %s for remote checksums\n", alg.RemoteTool, DefaultHashAlgorithm)
+		}
+	}
+	if available {
+		return alg
+	}
+	return defaultHashAlgorithm()
+}
+
+// defaultHashAlgorithm 返回 DefaultHashAlgorithm（内置注册，总是存在）
+func defaultHashAlgorithm() *HashAlgorithm {
+	alg, _ := LookupHashAlgorithm(DefaultHashAlgorithm)
+	return alg
+}
+
+// execHash 在服务器端用 alg 计算文件摘要，返回小写十六进制；ctx 取消时结束远程命令
+func (c *Client) execHash(ctx context.Context, alg *HashAlgorithm, remotePath string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	command := fmt.Sprintf(alg.RemoteCommand, shellQuote(remotePath))
+	if err := c.executeRemote(ctx, command, nil, &stdout, &stderr); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return alg.parseDigest(stdout.String())
+}
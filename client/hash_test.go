@@ -0,0 +1,39 @@
+package client
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestLookupHashAlgorithm(t *testing.T) {
+	for name, want := range map[string]string{"": "sha256", "XXH3": "xxh3", "blake3": "blake3"} {
+		alg, err := LookupHashAlgorithm(name)
+		if err != nil || alg.Name != want {
+			t.Fatalf("LookupHashAlgorithm(%q) = %v, %v, want %s", name, alg, err, want)
+		}
+	}
+	if _, err := LookupHashAlgorithm("md5"); err == nil {
+		t.Fatalf("LookupHashAlgorithm(md5) expected error")
+	}
+}
+
+func TestHashAlgorithmParseDigest(t *testing.T) {
+	for _, name := range HashAlgorithmNames() {
+		alg, _ := LookupHashAlgorithm(name)
+		h := alg.New()
+		h.Write([]byte("abc"))
+		sum := hex.EncodeToString(h.Sum(nil))
+
+		output := sum + "  /srv/abc\n"
+		if alg.DigestPrefix != "" {
+			output = alg.DigestPrefix + output
+		}
+		got, err := alg.parseDigest(output)
+		if err != nil || got != sum {
+			t.Fatalf("%s parseDigest(%q) = %q, %v, want %q", name, output, got, err, sum)
+		}
+		if _, err := alg.parseDigest(sum[2:] + "  /srv/abc\n"); err == nil {
+			t.Fatalf("%s parseDigest accepted a truncated digest", name)
+		}
+	}
+}
@@ -0,0 +1,880 @@
+//go:build integration
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/frostime/my-sftp/demo"
+)
+
+// 集成测试连接 MY_SFTP_IT_ADDR 指定的 SFTP 服务器（./test-integration.sh 使用 testdata/integration 中的
+// OpenSSH 容器）；未设置时在进程内启动 demo 服务器，直接执行 go test -tags integration ./client/ 即可
+
+func TestMain(m *testing.M) {
+	if os.Getenv("MY_SFTP_IT_ADDR") != "" {
+		os.Exit(m.Run())
+	}
+	root, err := os.MkdirTemp("", "my-sftp-it-")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	srv, err := demo.Start(root)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	os.Setenv("MY_SFTP_IT_ADDR", srv.Addr())
+	os.Setenv("MY_SFTP_IT_PASSWORD", srv.Password())
+	code := m.Run()
+	srv.Close()
+	os.RemoveAll(root)
+	os.Exit(code)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// dialIntegration 以 mode 连接测试服务器
+func dialIntegration(t *testing.T, mode StartMode) *Client {
+	t.Helper()
+	password := envOr("MY_SFTP_IT_PASSWORD", "tester")
+	c, err := NewClient(envOr("MY_SFTP_IT_ADDR", "127.0.0.1:2223"), &ssh.ClientConfig{
+		User:            envOr("MY_SFTP_IT_USER", "tester"),
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         10 * time.Second,
+	}, mode)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// newIntegrationClient 连接测试服务器，并在远程创建本测试专用的临时目录
+func newIntegrationClient(t *testing.T) (*Client, string) {
+	t.Helper()
+	c := dialIntegration(t, StartEager)
+
+	remoteDir := path.Join(c.Getwd(), fmt.Sprintf("my-sftp-it-%d", time.Now().UnixNano()))
+	if err := c.Mkdir(remoteDir); err != nil {
+		t.Fatalf("mkdir %s: %v", remoteDir, err)
+	}
+	t.Cleanup(func() { removeRemoteTree(t, remoteDir) })
+	return c, remoteDir
+}
+
+// removeRemoteTree 递归删除测试目录及其中的全部内容。使用单独的连接，
+// 测试中关闭或重连了客户端时同样能清理；清理失败会使测试失败，避免在服务器上留下文件
+func removeRemoteTree(t *testing.T, dir string) {
+	t.Helper()
+	c := dialIntegration(t, StartEager)
+	if err := c.sftpConn().RemoveAll(dir); err != nil {
+		t.Errorf("remove %s: %v", dir, err)
+	}
+}
+
+// writeTree 在 root 下创建文件，files 为 相对路径 -> 内容
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertTree 检查 root 下的普通文件与 files 完全一致
+func assertTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	entries, err := scanLocalTree(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, _ := splitTreeEntries(entries)
+	if len(got) != len(files) {
+		t.Fatalf("%s has %d file(s), want %d: %v", root, len(got), len(files), got)
+	}
+	for rel, want := range files {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("read %s: %v", rel, err)
+		}
+		if string(data) != want {
+			t.Fatalf("%s = %q, want %q", rel, data, want)
+		}
+	}
+}
+
+func quietUpload() *UploadOptions {
+	return &UploadOptions{Recursive: true, Concurrency: 4, MaxDepth: -1}
+}
+
+func quietDownload() *DownloadOptions {
+	return &DownloadOptions{Recursive: true, Concurrency: 4, MaxDepth: -1}
+}
+
+func TestIntegrationDirRoundTrip(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	files := map[string]string{
+		"a.txt":           "alpha\n",
+		"sub/b.txt":       "beta\n",
+		"sub/deep/c.bin":  "\x00\x01\x02",
+		"with space.txt":  "spaced\n",
+		"sub/empty-ish.x": "",
+	}
+	src := t.TempDir()
+	writeTree(t, src, files)
+
+	if _, err := c.UploadDir(src, path.Join(remoteDir, "tree"), quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+	dst := t.TempDir()
+	if _, err := c.DownloadDir(path.Join(remoteDir, "tree"), dst, quietDownload()); err != nil {
+		t.Fatalf("DownloadDir() error = %v", err)
+	}
+	assertTree(t, dst, files)
+}
+
+func TestIntegrationGlobPreservesStructure(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{
+		"src/main.go":       "package main\n",
+		"src/pkg/util.go":   "package pkg\n",
+		"src/pkg/notes.txt": "skip me\n",
+	})
+
+	count, err := c.UploadSources([]string{filepath.Join(src, "src") + "/**/*.go"}, path.Join(remoteDir, "code"), quietUpload())
+	if err != nil {
+		t.Fatalf("UploadSources() error = %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("UploadSources() uploaded %d file(s), want 2", count)
+	}
+
+	dst := t.TempDir()
+	if _, err := c.DownloadSources([]string{path.Join(remoteDir, "code") + "/**/*.go"}, dst, quietDownload()); err != nil {
+		t.Fatalf("DownloadSources() error = %v", err)
+	}
+	assertTree(t, dst, map[string]string{"main.go": "package main\n", "pkg/util.go": "package pkg\n"})
+}
+
+func TestIntegrationLiteralSources(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a[1].txt": "bracket\n", "a1.txt": "plain\n"})
+	target := path.Join(remoteDir, "literal")
+	if _, err := c.UploadDir(src, target, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	// 作为 glob 时 a[1].txt 匹配的是 a1.txt；Literal 时下载的是名为 a[1].txt 的文件
+	dst := t.TempDir()
+	opts := quietDownload()
+	opts.Literal = true
+	if _, err := c.DownloadSources([]string{path.Join(target, "a[1].txt")}, dst, opts); err != nil {
+		t.Fatalf("DownloadSources() error = %v", err)
+	}
+	assertTree(t, dst, map[string]string{"a[1].txt": "bracket\n"})
+}
+
+func TestIntegrationSyncIsIncremental(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "one\n", "sub/b.txt": "two\n"})
+	target := path.Join(remoteDir, "site")
+	opts := &SyncOptions{Concurrency: 4}
+
+	result, err := c.Sync(src, target, opts)
+	if err != nil {
+		t.Fatalf("first Sync() error = %v", err)
+	}
+	if result.New != 2 {
+		t.Fatalf("first Sync() = %+v, want 2 new", result)
+	}
+
+	result, err = c.Sync(src, target, opts)
+	if err != nil {
+		t.Fatalf("second Sync() error = %v", err)
+	}
+	if result.New != 0 || result.Updated != 0 || result.Unchanged != 2 {
+		t.Fatalf("second Sync() = %+v, want everything unchanged", result)
+	}
+
+	writeTree(t, src, map[string]string{"a.txt": "one, edited\n"})
+	if result, err = c.Sync(src, target, opts); err != nil {
+		t.Fatalf("third Sync() error = %v", err)
+	}
+	if result.Updated != 1 || result.Unchanged != 1 {
+		t.Fatalf("third Sync() = %+v, want 1 updated", result)
+	}
+
+	back := t.TempDir()
+	if _, err := c.Sync(back, target, &SyncOptions{Reverse: true, Concurrency: 4}); err != nil {
+		t.Fatalf("reverse Sync() error = %v", err)
+	}
+	assertTree(t, back, map[string]string{"a.txt": "one, edited\n", "sub/b.txt": "two\n"})
+
+	// 未通过扫描的文件不设置 mtime，下次同步时重新传输（内容大小相同，只能靠 mtime 区分）
+	if runtime.GOOS == "windows" {
+		return
+	}
+	writeTree(t, src, map[string]string{"a.txt": "one, VIRUS!\n"})
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(src, "a.txt"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	rejecting := &SyncOptions{Concurrency: 4, Scanner: &ContentScanner{PreUpload: "! grep -q VIRUS"}}
+	if _, err := c.Sync(src, target, rejecting); err == nil {
+		t.Fatal("Sync() with a rejecting scanner succeeded")
+	}
+	result, err = c.Sync(src, target, opts)
+	if err != nil || result.Updated != 1 {
+		t.Fatalf("Sync() after a rejected file = %+v, %v; want 1 updated", result, err)
+	}
+}
+
+func TestIntegrationLargeFileTransfers(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	data := make([]byte, 3*1024*1024+17)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	src := t.TempDir()
+	local := filepath.Join(src, "big.bin")
+	if err := os.WriteFile(local, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts UploadOptions
+	}{
+		{"parallel", UploadOptions{Concurrency: 1, MaxDepth: -1, ParallelStreams: 4, ParallelThreshold: 1}},
+		{"chunked", UploadOptions{Concurrency: 1, MaxDepth: -1, ChunkSize: 1024 * 1024}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := path.Join(remoteDir, tc.name)
+			if _, err := c.UploadSources([]string{local}, dir, &tc.opts); err != nil {
+				t.Fatalf("UploadSources() error = %v", err)
+			}
+			dst := t.TempDir()
+			download := DownloadOptions{Concurrency: 1, MaxDepth: -1, ParallelStreams: 4, ParallelThreshold: 1}
+			if _, err := c.DownloadSources([]string{path.Join(dir, "big.bin")}, dst, &download); err != nil {
+				t.Fatalf("DownloadSources() error = %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(dst, "big.bin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("round-tripped %d bytes differ from the %d uploaded", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestIntegrationChunkedUploadDropsBadChunks(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	const chunkSize = 1024
+	data := make([]byte, 3*chunkSize)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	local := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(local, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 上次中断留下的分块：part-000001 大小正确但内容错误
+	remote := path.Join(remoteDir, "data.bin")
+	chunkDir := remote + chunkDirSuffix
+	stale := t.TempDir()
+	writeTree(t, stale, map[string]string{
+		"part-000000": string(data[:chunkSize]),
+		"part-000001": strings.Repeat("x", chunkSize),
+	})
+	if _, err := c.UploadDir(stale, chunkDir, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := c.uploadChunkedWithProgress(ctx, local, remote, chunkSize, nil); !errors.Is(err, errAssembledMismatch) {
+		t.Fatalf("first upload error = %v, want checksum mismatch", err)
+	}
+	if _, err := c.sftpConn().Stat(path.Join(chunkDir, "part-000001")); !os.IsNotExist(err) {
+		t.Fatalf("bad chunk kept: %v", err)
+	}
+	if _, err := c.sftpConn().Stat(path.Join(chunkDir, "part-000000")); err != nil {
+		t.Fatalf("good chunk removed: %v", err)
+	}
+
+	if err := c.uploadChunkedWithProgress(ctx, local, remote, chunkSize, nil); err != nil {
+		t.Fatalf("second upload error = %v", err)
+	}
+	sum, err := c.remoteSHA256(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.Sum256(data)
+	if sum != hex.EncodeToString(want[:]) {
+		t.Fatal("uploaded file differs from the local file")
+	}
+}
+
+func TestIntegrationChunkedUploadManyChunks(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	// 分块数足够多，拼接命令需要分成多批
+	const chunkSize = 1024
+	data := make([]byte, 2000*chunkSize+17)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	local := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(local, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := path.Join(remoteDir, "data.bin")
+	if err := c.uploadChunkedWithProgress(context.Background(), local, remote, chunkSize, nil); err != nil {
+		t.Fatalf("uploadChunkedWithProgress() error = %v", err)
+	}
+	sum, err := c.remoteSHA256(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.Sum256(data)
+	if sum != hex.EncodeToString(want[:]) {
+		t.Fatal("uploaded file differs from the local file")
+	}
+}
+
+func TestIntegrationMultipleChannels(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	files := map[string]string{}
+	for i := range 8 {
+		data := make([]byte, 1024*1024)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		files[fmt.Sprintf("f%d.bin", i)] = string(data)
+	}
+	writeTree(t, src, files)
+
+	if _, err := c.UploadDir(src, path.Join(remoteDir, "tree"), quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+	if n := len(c.channels.clients); n < 2 || n > defaultMaxChannels {
+		t.Fatalf("concurrent upload used %d SFTP channel(s), want 2..%d", n, defaultMaxChannels)
+	}
+	dst := t.TempDir()
+	if _, err := c.DownloadDir(path.Join(remoteDir, "tree"), dst, quietDownload()); err != nil {
+		t.Fatalf("DownloadDir() error = %v", err)
+	}
+	assertTree(t, dst, files)
+}
+
+func TestIntegrationParallelUsesChannelPool(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	c.sftpOpts.Channels = 2
+	data := make([]byte, 4*1024*1024)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	local := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(local, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 流数多于 SFTPChannels 时几段共用通道，不能另开通道
+	opts := UploadOptions{Concurrency: 1, MaxDepth: -1, ParallelStreams: 8, ParallelThreshold: 1}
+	if _, err := c.UploadSources([]string{local}, remoteDir, &opts); err != nil {
+		t.Fatalf("UploadSources() error = %v", err)
+	}
+	if n := len(c.channels.clients); n != 2 {
+		t.Fatalf("parallel upload used %d SFTP channel(s), want 2", n)
+	}
+	for i, n := range c.channels.users {
+		if n != 0 {
+			t.Errorf("channel %d still has %d user(s)", i, n)
+		}
+	}
+}
+
+func TestIntegrationRemoteLock(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	dir := path.Join(remoteDir, "site")
+	lock, err := c.LockRemoteDir(dir, "sync")
+	if err != nil {
+		t.Fatalf("LockRemoteDir() error = %v", err)
+	}
+	var locked *LockedError
+	if _, err := c.LockRemoteDir(dir, "stage push"); !errors.As(err, &locked) || locked.Lock.Command != "sync" {
+		t.Fatalf("second LockRemoteDir() error = %v, want LockedError held by sync", err)
+	}
+	entries, err := c.scanRemoteTree(dir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("scanRemoteTree() = %v, %v; the lock file should be hidden", entries, err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := c.Stat(path.Join(dir, LockFileName)); err == nil {
+		t.Fatal("lock file still exists after Release")
+	}
+
+	// 过期的锁可以接管，原持有者释放时不会删除新锁
+	stale, err := c.LockRemoteDir(dir, "sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := stale.lock
+	expired.Expires = time.Now().Add(-time.Minute)
+	f, err := c.sftpConn().OpenFile(stale.file, os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(expired)
+	f.Write(data)
+	f.Close()
+	fresh, err := c.LockRemoteDir(dir, "sync")
+	if err != nil || fresh.TakenOver == nil || fresh.TakenOver.ID != stale.lock.ID {
+		t.Fatalf("LockRemoteDir() over an expired lock = %+v, %v", fresh, err)
+	}
+	if err := stale.Release(); err == nil {
+		t.Fatal("Release() of a taken-over lock should fail")
+	}
+	if err := fresh.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+}
+
+func TestIntegrationRelay(t *testing.T) {
+	from, remoteDir := newIntegrationClient(t)
+	c := dialIntegration(t, StartEager)
+	files := map[string]string{"a.txt": "alpha\n", "sub/b.bin": strings.Repeat("b", 300*1024)}
+	src := t.TempDir()
+	writeTree(t, src, files)
+	if _, err := from.UploadDir(src, path.Join(remoteDir, "src"), quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	result, err := c.Relay(from, []string{path.Join(remoteDir, "src")}, path.Join(remoteDir, "copy"), &RelayOptions{Recursive: true})
+	if err != nil || result.Files != 2 {
+		t.Fatalf("Relay() = %+v, %v", result, err)
+	}
+	dst := t.TempDir()
+	if _, err := from.DownloadDir(path.Join(remoteDir, "copy", "src"), dst, quietDownload()); err != nil {
+		t.Fatalf("DownloadDir() error = %v", err)
+	}
+	assertTree(t, dst, files)
+
+	// 同一台服务器上复制到源目录自身时拒绝，源文件保持不变
+	if _, err := c.Relay(from, []string{path.Join(remoteDir, "src")}, remoteDir, &RelayOptions{Recursive: true}); err == nil || !strings.Contains(err.Error(), "same file") {
+		t.Fatalf("Relay() onto itself error = %v, want same file error", err)
+	}
+	dst = t.TempDir()
+	if _, err := from.DownloadDir(path.Join(remoteDir, "src"), dst, quietDownload()); err != nil {
+		t.Fatalf("DownloadDir() error = %v", err)
+	}
+	assertTree(t, dst, files)
+}
+
+// TestIntegrationCopyRemoteSameFile 经符号链接复制到源文件自身时拒绝，不能截断源文件
+func TestIntegrationCopyRemoteSameFile(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "alpha\n"})
+	target := path.Join(remoteDir, "cp")
+	if _, err := c.UploadDir(src, target, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+	file, link := path.Join(target, "a.txt"), path.Join(target, "link.txt")
+	if err := c.Symlink(file, link); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+
+	for _, pair := range [][2]string{{file, target + "/./a.txt"}, {link, file}, {file, link}} {
+		if _, err := c.CopyRemote(pair[0], pair[1], false); err == nil || !strings.Contains(err.Error(), "same file") {
+			t.Errorf("CopyRemote(%s, %s) error = %v, want same file error", pair[0], pair[1], err)
+		}
+	}
+	if info, err := c.Stat(file); err != nil || info.Size() != int64(len("alpha\n")) {
+		t.Fatalf("source after CopyRemote() = %v, %v", info, err)
+	}
+}
+
+func TestIntegrationPreserveAttributes(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"script.sh": "#!/bin/sh\n", "sub/secret.txt": "key\n"})
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	modes := map[string]os.FileMode{"script.sh": 0750, "sub/secret.txt": 0600}
+	for rel, mode := range modes {
+		p := filepath.Join(src, filepath.FromSlash(rel))
+		if err := os.Chmod(p, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target := path.Join(remoteDir, "keep")
+	opts := quietUpload()
+	opts.Preserve = true
+	if _, err := c.UploadDir(src, target, opts); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+	dst := t.TempDir()
+	dopts := quietDownload()
+	dopts.Preserve = true
+	if _, err := c.DownloadDir(target, dst, dopts); err != nil {
+		t.Fatalf("DownloadDir() error = %v", err)
+	}
+
+	for rel, mode := range modes {
+		remote, err := c.Stat(path.Join(target, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		local, err := os.Stat(filepath.Join(dst, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for side, info := range map[string]os.FileInfo{"remote": remote, "local": local} {
+			if info.Mode().Perm() != mode || !info.ModTime().Equal(mtime) {
+				t.Errorf("%s %s: mode %v mtime %v, want %v %v", side, rel, info.Mode().Perm(), info.ModTime().UTC(), mode, mtime)
+			}
+		}
+	}
+}
+
+func TestIntegrationDownloadChecksum(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	files := map[string]string{"disk.img": strings.Repeat("block", 200000), "sub/notes.txt": "hello\n"}
+	writeTree(t, src, files)
+	target := path.Join(remoteDir, "sums")
+	if _, err := c.UploadDir(src, target, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	dst := t.TempDir()
+	opts := quietDownload()
+	opts.Checksum = true
+	if _, err := c.DownloadDir(target, dst, opts); err != nil {
+		t.Fatalf("DownloadDir() with Checksum error = %v", err)
+	}
+	assertTree(t, dst, files)
+
+	single := filepath.Join(t.TempDir(), "disk.img")
+	if err := c.DownloadChecksum(path.Join(target, "disk.img"), single, ""); err != nil {
+		t.Fatalf("DownloadChecksum() error = %v", err)
+	}
+}
+
+func TestIntegrationChmodRecursive(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"bin/run.sh": "#!/bin/sh\n", "bin/lib/util.sh": "true\n"})
+	target := path.Join(remoteDir, "perm")
+	if _, err := c.UploadDir(src, target, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	if err := c.Chmod(path.Join(target, "bin"), 0750, true); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+	for _, rel := range []string{"bin", "bin/run.sh", "bin/lib", "bin/lib/util.sh"} {
+		info, err := c.Stat(path.Join(target, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0750 {
+			t.Errorf("%s mode = %v, want 0750", rel, info.Mode().Perm())
+		}
+	}
+}
+
+func TestIntegrationMoveReplacesFile(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"new.txt": "replacement", "old.txt": "old"})
+	target := path.Join(remoteDir, "mv")
+	if _, err := c.UploadDir(src, target, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	if err := c.Move(path.Join(target, "new.txt"), path.Join(target, "old.txt")); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if _, err := c.Stat(path.Join(target, "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("source still exists: %v", err)
+	}
+	info, err := c.Stat(path.Join(target, "old.txt"))
+	if err != nil || info.Size() != int64(len("replacement")) {
+		t.Fatalf("Stat(old.txt) = %v, %v", info, err)
+	}
+
+	// 源与目标相同（如 mv f .）时报错，不能删除源文件
+	if err := c.Move(path.Join(target, "old.txt"), target+"/./old.txt"); err == nil {
+		t.Fatal("Move() onto itself succeeded")
+	}
+	if _, err := c.Stat(path.Join(target, "old.txt")); err != nil {
+		t.Fatalf("Move() onto itself removed the file: %v", err)
+	}
+
+	// 不支持 posix-rename 时的替换：目标先改名移开，重命名失败时恢复
+	other := t.TempDir()
+	writeTree(t, other, map[string]string{"other.txt": "other"})
+	if _, err := c.UploadDir(other, target, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+	if err := c.swapRename(path.Join(target, "missing.txt"), path.Join(target, "old.txt")); err == nil {
+		t.Fatal("swapRename() of a missing source succeeded")
+	}
+	if info, err := c.Stat(path.Join(target, "old.txt")); err != nil || info.Size() != int64(len("replacement")) {
+		t.Fatalf("target not restored after failed swapRename(): %v, %v", info, err)
+	}
+	if err := c.swapRename(path.Join(target, "other.txt"), path.Join(target, "old.txt")); err != nil {
+		t.Fatalf("swapRename() error = %v", err)
+	}
+	entries, err := c.sftpConn().ReadDir(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if info, err := c.Stat(path.Join(target, "old.txt")); err != nil || info.Size() != int64(len("other")) || len(names) != 1 {
+		t.Fatalf("after swapRename(): old.txt = %v, %v; entries = %v", info, err, names)
+	}
+}
+
+func TestIntegrationPushStaged(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	files := []StagedFile{
+		{Local: filepath.Join(src, "a.txt"), Rel: "a.txt"},
+		{Local: filepath.Join(src, "sub", "b.txt"), Rel: "sub/b.txt"},
+	}
+
+	// 检查失败时不在服务器上创建目标目录
+	target := path.Join(remoteDir, "stage")
+	missing := append(slices.Clone(files), StagedFile{Local: filepath.Join(src, "missing.txt"), Rel: "missing.txt"})
+	if _, err := c.PushStaged(missing, target, false); err == nil {
+		t.Fatal("PushStaged() with a missing file succeeded")
+	}
+	if _, err := c.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("target created by a failed PushStaged(): %v", err)
+	}
+
+	result, err := c.PushStaged(files, target, false)
+	if err != nil || result.Created != 2 {
+		t.Fatalf("PushStaged() = %+v, %v", result, err)
+	}
+	dst := t.TempDir()
+	if _, err := c.DownloadDir(target, dst, quietDownload()); err != nil {
+		t.Fatalf("DownloadDir() error = %v", err)
+	}
+	assertTree(t, dst, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+}
+
+func TestIntegrationSymlink(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"shared/app.conf": "port=80\n"})
+	target := path.Join(remoteDir, "links")
+	if _, err := c.UploadDir(src, target, quietUpload()); err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	// 使用绝对目标：部分服务器（如 pkg/sftp 带工作目录时）会改写相对目标
+	link, linkTarget := path.Join(target, "app.conf"), path.Join(target, "shared/app.conf")
+	if err := c.Symlink(linkTarget, link); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	if got, err := c.ReadLink(link); err != nil || got != linkTarget {
+		t.Fatalf("ReadLink() = %q, %v", got, err)
+	}
+	if info, err := c.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("Lstat() = %v, %v, want a symlink", info, err)
+	}
+	if info, err := c.Stat(link); err != nil || info.Size() != int64(len("port=80\n")) {
+		t.Fatalf("Stat() through link = %v, %v", info, err)
+	}
+}
+
+func TestIntegrationDiskUsage(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	usage, err := c.DiskUsage(remoteDir)
+	if err != nil {
+		t.Fatalf("DiskUsage() error = %v", err)
+	}
+	if usage.Total == 0 || usage.Used+usage.Available > usage.Total {
+		t.Fatalf("DiskUsage() = %+v", usage)
+	}
+}
+
+func TestIntegrationLazyStart(t *testing.T) {
+	eager := dialIntegration(t, StartEager)
+	lazy := dialIntegration(t, StartLazy)
+	if err := lazy.WaitReady(); err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+	if !lazy.Ready() || lazy.Getwd() != eager.Getwd() || lazy.HomeDir() != eager.HomeDir() {
+		t.Fatalf("lazy client: ready=%v wd=%q home=%q, want wd %q", lazy.Ready(), lazy.Getwd(), lazy.HomeDir(), eager.Getwd())
+	}
+
+	execOnly := dialIntegration(t, StartExecOnly)
+	if err := execOnly.WaitReady(); !errors.Is(err, ErrExecOnly) {
+		t.Fatalf("exec-only WaitReady = %v, want ErrExecOnly", err)
+	}
+	var out bytes.Buffer
+	if err := execOnly.ExecuteRemote("echo ok", nil, &out, io.Discard); err != nil || strings.TrimSpace(out.String()) != "ok" {
+		t.Fatalf("exec-only ExecuteRemote = %q, %v", out.String(), err)
+	}
+}
+
+// TestIntegrationExecuteRemoteCancel ctx 取消时不等远程命令结束（下载失败时取消服务器端的校验）
+func TestIntegrationExecuteRemoteCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	c, _ := newIntegrationClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.executeRemote(ctx, "sleep 30", nil, io.Discard, io.Discard)
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 10*time.Second {
+		t.Fatalf("executeRemote() = %v after %v, want DeadlineExceeded right away", err, time.Since(start))
+	}
+}
+
+// TestIntegrationReconnect 重连替换连接时，其他 goroutine 可以继续使用客户端（go test -race）
+func TestIntegrationReconnect(t *testing.T) {
+	config := &ssh.ClientConfig{
+		User:            envOr("MY_SFTP_IT_USER", "tester"),
+		Auth:            []ssh.AuthMethod{ssh.Password(envOr("MY_SFTP_IT_PASSWORD", "tester"))},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         10 * time.Second,
+	}
+	addr := envOr("MY_SFTP_IT_ADDR", "127.0.0.1:2223")
+	c, err := NewClient(addr, config, StartEager, WithReconnect(func() (*ssh.Client, error) { return Dial(addr, config) }))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Close()
+	wd := c.Getwd()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				c.Stat(wd) // 重连期间的失败是预期的
+				c.Alive()
+			}
+		}
+	}()
+	for range 2 {
+		if err := c.Reconnect(nil); err != nil {
+			t.Fatalf("Reconnect() error = %v", err)
+		}
+	}
+	close(stop)
+	<-done
+	if _, err := c.Stat(wd); err != nil || c.Getwd() != wd {
+		t.Fatalf("after reconnect: Stat() = %v, Getwd() = %q, want %q", err, c.Getwd(), wd)
+	}
+}
+
+func TestIntegrationEnsureWorkDir(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	gone := path.Join(remoteDir, "gone")
+	if err := c.Mkdir(gone); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Chdir(gone); err != nil {
+		t.Fatal(err)
+	}
+	if lost, err := c.EnsureWorkDir(); err != nil || lost != "" {
+		t.Fatalf("EnsureWorkDir() with existing dir = %q, %v", lost, err)
+	}
+
+	// 模拟服务器端清理：目录被其他会话删除
+	if err := c.sftpConn().RemoveDirectory(gone); err != nil {
+		t.Fatal(err)
+	}
+	lost, err := c.EnsureWorkDir()
+	if err != nil || lost != gone {
+		t.Fatalf("EnsureWorkDir() = %q, %v; want %q", lost, err, gone)
+	}
+	if c.Getwd() != c.HomeDir() {
+		t.Fatalf("Getwd() = %q, want home %q", c.Getwd(), c.HomeDir())
+	}
+}
+
+// TestIntegrationChdirClearsCache 其他会话修改过的目录，cd 进入后列出的是最新内容
+func TestIntegrationChdirClearsCache(t *testing.T) {
+	c, remoteDir := newIntegrationClient(t)
+	if err := c.Chdir(remoteDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.List(""); err != nil {
+		t.Fatal(err)
+	}
+	// 另一个连接创建的目录不会使 c 的目录缓存失效
+	other := dialIntegration(t, StartEager)
+	if err := other.Mkdir(path.Join(remoteDir, "added")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Chdir(remoteDir); err != nil {
+		t.Fatal(err)
+	}
+	files, err := c.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(files, func(info os.FileInfo) bool { return info.Name() == "added" }) {
+		t.Fatalf("List() after Chdir() = %d entries, missing added", len(files))
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultServerAliveCountMax 连续多少次保活请求没有响应时断开连接（同 OpenSSH 的默认值）
+const defaultServerAliveCountMax = 3
+
+// WithKeepAlive 每隔 interval 发送一次保活请求（同 ssh 的 ServerAliveInterval），
+// 连续 countMax 次在下一次发送前没有响应时断开连接（ServerAliveCountMax，<=0 时为 3）；
+// interval<=0 时不发送
+func WithKeepAlive(interval time.Duration, countMax int) ClientOption {
+	return func(c *Client) {
+		c.keepAlive = interval
+		c.keepAliveMax = countMax
+		if c.keepAliveMax <= 0 {
+			c.keepAliveMax = defaultServerAliveCountMax
+		}
+	}
+}
+
+// ConnectionLostHandler 检测到 SSH 连接断开时调用（在后台 goroutine 中），err 为断开的原因
+type ConnectionLostHandler func(err error)
+
+// SetConnectionLostHandler 设置连接断开时的通知回调，如在提示符处提示用户
+func (c *Client) SetConnectionLostHandler(handler ConnectionLostHandler) {
+	c.lostMu.Lock()
+	defer c.lostMu.Unlock()
+	c.lostHandler = handler
+}
+
+// runKeepAlive 发送保活请求，直到连接关闭或服务器失去响应；失去响应时断开连接
+// stale 在 Client 关闭或已重连时返回 true
+func (c *Client) runKeepAlive(sshClient *ssh.Client, stale func() bool) {
+	err := keepAliveLoop(c.keepAlive, c.keepAliveMax, stale, func() error {
+		// 服务器拒绝该请求也算作响应
+		_, _, err := sshClient.SendRequest("keepalive@openssh.com", true, nil)
+		return err
+	})
+	if err != nil && !stale() {
+		c.setLostErr(err)
+		sshClient.Close() // Wait 返回后标记为 ConnectionLost
+	}
+}
+
+// keepAliveLoop 每隔 interval 调用一次 send，连续 countMax 次在下一次发送前没有返回时返回错误；
+// closed 返回 true 或 send 失败（连接已断开）时返回 nil
+func keepAliveLoop(interval time.Duration, countMax int, closed func() bool, send func() error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	missed := 0
+	for range ticker.C {
+		if closed() {
+			return nil
+		}
+		reply := make(chan error, 1)
+		go func() { reply <- send() }()
+		select {
+		case err := <-reply:
+			if err != nil {
+				return nil
+			}
+			missed = 0
+		case <-time.After(interval):
+			missed++
+			if missed >= countMax {
+				return fmt.Errorf("server not responding (%d keepalives unanswered in %s)", missed, time.Duration(missed)*interval)
+			}
+		}
+	}
+	return nil
+}
+
+// setLostErr 记录连接断开的原因；只保留第一个
+func (c *Client) setLostErr(err error) {
+	c.lostMu.Lock()
+	defer c.lostMu.Unlock()
+	if c.lostErr == nil {
+		c.lostErr = err
+	}
+}
+
+// markLost 标记连接已断开并通知 ConnectionLostHandler
+func (c *Client) markLost(err error) {
+	c.setLostErr(err)
+	c.lost.Store(true)
+	c.lostMu.Lock()
+	handler, err := c.lostHandler, c.lostErr
+	c.lostMu.Unlock()
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// ConnectionLostErr 返回连接断开的原因；连接未断开时返回 nil
+func (c *Client) ConnectionLostErr() error {
+	if !c.lost.Load() {
+		return nil
+	}
+	c.lostMu.Lock()
+	defer c.lostMu.Unlock()
+	return c.lostErr
+}
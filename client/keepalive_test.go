@@ -0,0 +1,35 @@
+package client
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeepAliveLoopUnresponsiveServer(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+	var sent atomic.Int32
+	err := keepAliveLoop(5*time.Millisecond, 2, func() bool { return false }, func() error {
+		sent.Add(1)
+		<-hang
+		return nil
+	})
+	if err == nil {
+		t.Fatal("keepAliveLoop() = nil, want an error after 2 unanswered keepalives")
+	}
+	if n := sent.Load(); n != 2 {
+		t.Errorf("sent %d keepalives, want 2", n)
+	}
+}
+
+func TestKeepAliveLoopStops(t *testing.T) {
+	// 连接已断开（send 失败）或已关闭时安静地退出
+	if err := keepAliveLoop(time.Millisecond, 3, func() bool { return false }, func() error { return errors.New("EOF") }); err != nil {
+		t.Errorf("keepAliveLoop() after send failure = %v, want nil", err)
+	}
+	if err := keepAliveLoop(time.Millisecond, 3, func() bool { return true }, func() error { return nil }); err != nil {
+		t.Errorf("keepAliveLoop() after close = %v, want nil", err)
+	}
+}
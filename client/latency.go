@@ -0,0 +1,58 @@
+package client
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// latencyTracker 服务器往返时间（RTT）的滑动估计，与 TCP 的 SRTT 一样按 1/8 的权重吸收新样本
+type latencyTracker struct {
+	mu      sync.Mutex
+	srtt    time.Duration
+	samples int
+}
+
+// observe 记录一次单往返请求的耗时
+func (t *latencyTracker) observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.samples == 0 {
+		t.srtt = d
+	} else {
+		t.srtt += (d - t.srtt) / 8
+	}
+	t.samples++
+}
+
+func (t *latencyTracker) estimate() (time.Duration, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.srtt, t.samples
+}
+
+// RTT 返回服务器往返时间的滑动估计及样本数；样本来自连接时的 Getwd、Stat、Chdir 和 MeasureRTT
+func (c *Client) RTT() (time.Duration, int) {
+	return c.latency.estimate()
+}
+
+// MeasureRTT 发送一个单往返请求（stat 工作目录）测量当前 RTT，并计入滑动估计
+func (c *Client) MeasureRTT() (time.Duration, error) {
+	start := time.Now()
+	if _, err := c.sftpConn().Stat(c.Getwd()); err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	c.latency.observe(rtt)
+	return rtt, nil
+}
+
+// timedStat 执行 Stat，成功时把耗时计入 RTT 估计
+func (c *Client) timedStat(remotePath string) (os.FileInfo, error) {
+	start := time.Now()
+	info, err := c.sftpConn().Stat(remotePath)
+	if err == nil {
+		c.latency.observe(time.Since(start))
+	}
+	return info, err
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	var tracker latencyTracker
+	if _, samples := tracker.estimate(); samples != 0 {
+		t.Fatalf("samples = %d, want 0", samples)
+	}
+
+	tracker.observe(400 * time.Millisecond)
+	if rtt, _ := tracker.estimate(); rtt != 400*time.Millisecond {
+		t.Fatalf("first sample: rtt = %v, want 400ms", rtt)
+	}
+
+	// 单个离群样本只移动 1/8
+	tracker.observe(1200 * time.Millisecond)
+	if rtt, samples := tracker.estimate(); rtt != 500*time.Millisecond || samples != 2 {
+		t.Fatalf("rtt = %v (%d samples), want 500ms (2 samples)", rtt, samples)
+	}
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultMaxPacket pkg/sftp 默认的读写请求数据长度，所有 SFTP v3 服务器都必须支持；
+// defaultMaxRequests 每个文件默认的最大并发请求数
+const (
+	defaultMaxPacket   = 32768
+	defaultMaxRequests = 64
+)
+
+// autoMaxPacket 未配置 SFTPMaxPacket 时，按服务器报告的读写上限放大数据包的上限（OpenSSH 报告 255KB）；
+// autoWindow 未配置 SFTPMaxRequests 时每个文件在途的数据量上限，放大数据包时相应减少请求数以限制内存
+const (
+	autoMaxPacket = 256 * 1024
+	autoWindow    = 8 * 1024 * 1024
+)
+
+// reservedHandles 按 MaxHandles 限制并发时，为 ls、cd 等前台命令保留的句柄数
+const reservedHandles = 4
+
+// ServerLimits 服务器通过 limits@openssh.com 扩展报告的限制，以及本会话实际使用的值
+type ServerLimits struct {
+	Reported   bool   // 服务器支持 limits@openssh.com；为 false 时以下四项均未知
+	MaxPacket  uint64 // 数据包的最大长度（含头部），0 表示未知
+	MaxRead    uint64 // 单个读请求最多返回的数据字节数，0 表示未知
+	MaxWrite   uint64 // 单个写请求最多接受的数据字节数，0 表示未知
+	MaxHandles uint64 // 最多同时打开的句柄数，0 表示不限或未知
+
+	PacketSize      int // 每个读写请求的数据字节数
+	RequestsPerFile int // 每个文件同时在途的请求数
+	Concurrency     int // 默认同时传输的文件数
+}
+
+// serverLimitsCache 每个连接只查询一次 limits@openssh.com
+type serverLimitsCache struct {
+	once   sync.Once
+	limits ServerLimits
+	err    error
+}
+
+// parseLimitsReply 解析 limits@openssh.com 的 EXTENDED_REPLY：四个 uint64
+func parseLimitsReply(payload traceBuf) (ServerLimits, error) {
+	var fields [4]uint64
+	for i := range fields {
+		v, ok := payload.uint64()
+		if !ok {
+			return ServerLimits{}, errors.New("malformed limits@openssh.com reply")
+		}
+		fields[i] = v
+	}
+	return ServerLimits{Reported: true, MaxPacket: fields[0], MaxRead: fields[1], MaxWrite: fields[2], MaxHandles: fields[3]}, nil
+}
+
+// maxData 读写请求都能接受的最大数据长度，0 表示未知
+func (l ServerLimits) maxData() int {
+	size := min(nonZero(l.MaxRead), nonZero(l.MaxWrite))
+	if size == nonZero(0) {
+		if l.MaxPacket <= 1024 {
+			return 0
+		}
+		size = l.MaxPacket - 1024 // 与 OpenSSH 相同，为数据包头部留出余量
+	}
+	if size > 1<<30 {
+		return 0
+	}
+	return int(size)
+}
+
+// nonZero 把 0（未知）当作无穷大，便于取最小值
+func nonZero(v uint64) uint64 {
+	if v == 0 {
+		return ^uint64(0)
+	}
+	return v
+}
+
+// clampTransfers 按 MaxHandles 限制同时传输的文件数和每个文件的并行流数：
+// 每个流占用一个句柄，并为前台命令保留 reservedHandles 个
+func (l ServerLimits) clampTransfers(concurrency, streams int) (int, int) {
+	if l.MaxHandles == 0 {
+		return concurrency, streams
+	}
+	return clampToBudget(max(int(min(l.MaxHandles, 1<<20))-reservedHandles, 1), concurrency, streams)
+}
+
+// clampToBudget 缩小同时传输的文件数和每个文件的并行流数，使两者之积不超过 budget（每个流占用一个句柄/描述符）
+func clampToBudget(budget, concurrency, streams int) (int, int) {
+	perFile := max(streams, 1)
+	if perFile > budget {
+		streams, perFile = budget, budget
+	}
+	if concurrency*perFile > budget {
+		concurrency = max(budget/perFile, 1)
+	}
+	return concurrency, streams
+}
+
+// queryServerLimits 通过 rawSFTP 通道发送 limits@openssh.com 请求；服务器未声明该扩展时返回 Reported 为 false 的结果
+func (c *Client) queryServerLimits() (ServerLimits, error) {
+	if _, ok := c.sftpConn().HasExtension("limits@openssh.com"); !ok {
+		return ServerLimits{}, nil
+	}
+	raw, err := c.rawChannel()
+	if err != nil {
+		return ServerLimits{}, err
+	}
+	typ, payload, err := raw.request(sshFxpExtended, "limits@openssh.com")
+	if err != nil {
+		return ServerLimits{}, err
+	}
+	if typ != sshFxpExtReply {
+		if err := raw.status(typ, payload); err != nil {
+			return ServerLimits{}, err
+		}
+		return ServerLimits{}, fmt.Errorf("unexpected %s reply to limits@openssh.com", tracePacketType(typ))
+	}
+	return parseLimitsReply(payload)
+}
+
+// reportedLimits 返回服务器报告的限制（首次调用时查询），查询失败视为未知
+func (c *Client) reportedLimits() ServerLimits {
+	c.limits.once.Do(func() {
+		c.limits.limits, c.limits.err = c.queryServerLimits()
+		if c.limits.err == nil {
+			c.workers.clamp(c.limits.limits)
+		}
+	})
+	return c.limits.limits
+}
+
+// ServerLimits 返回服务器的限制和本会话使用的值；查询失败时服务器的限制为未知，同时返回错误
+func (c *Client) ServerLimits() (ServerLimits, error) {
+	limits := c.reportedLimits()
+	limits.PacketSize = c.packetSize()
+	limits.RequestsPerFile = c.requestsPerFile()
+	limits.Concurrency, _ = limits.clampTransfers(c.Concurrency(), 1)
+	return limits, c.limits.err
+}
+
+// packetSize 本会话每个读写请求的数据字节数
+func (c *Client) packetSize() int {
+	if c.sftpOpts.MaxPacket > 0 {
+		return c.sftpOpts.MaxPacket
+	}
+	return defaultMaxPacket
+}
+
+// requestsPerFile 每个文件同时在途的请求数：未配置时为 defaultMaxRequests，数据包较大时按 autoWindow 减少
+func (c *Client) requestsPerFile() int {
+	if c.sftpOpts.MaxRequests > 0 {
+		return c.sftpOpts.MaxRequests
+	}
+	return min(defaultMaxRequests, max(autoWindow/c.packetSize(), 1))
+}
+
+// raisePacketSize 未配置 SFTPMaxPacket 且服务器报告了读写上限时，把数据包放大到该上限（最多 autoMaxPacket）并返回 true：
+// 高延迟链路上单个文件的吞吐量受每个 RTT 在途的数据量限制。只在 SFTP 初始化时调用
+func (c *Client) raisePacketSize() bool {
+	if c.sftpOpts.MaxPacket > 0 {
+		return false
+	}
+	size := min(c.reportedLimits().maxData(), autoMaxPacket)
+	if size <= defaultMaxPacket {
+		return false
+	}
+	c.sftpOpts.MaxPacket = size
+	return true
+}
+
+// clampPacketSize 配置的数据包大于服务器接受的大小时，缩小到服务器的限制并返回 true。
+// 只在 SFTP 初始化时调用：已打开的 SFTP 通道无法修改数据包大小
+func (c *Client) clampPacketSize() bool {
+	if c.sftpOpts.MaxPacket <= defaultMaxPacket {
+		return false
+	}
+	limit := c.reportedLimits().maxData()
+	if limit == 0 || c.sftpOpts.MaxPacket <= limit {
+		return false
+	}
+	c.sftpOpts.MaxPacket = max(limit, defaultMaxPacket)
+	return true
+}
+
+// clamp 按服务器的句柄上限缩小后台任务可用的 worker 数
+func (s *workerScheduler) clamp(limits ServerLimits) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capacity, _ = limits.clampTransfers(s.capacity, 1)
+}
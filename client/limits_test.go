@@ -0,0 +1,80 @@
+package client
+
+import "testing"
+
+func TestParseLimitsReply(t *testing.T) {
+	// OpenSSH sftp-server 的典型回复
+	payload := sftpPacket(0, uint64(256*1024), uint64(255*1024), uint64(255*1024), uint64(1019))[5:]
+	limits, err := parseLimitsReply(traceBuf(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ServerLimits{Reported: true, MaxPacket: 256 * 1024, MaxRead: 255 * 1024, MaxWrite: 255 * 1024, MaxHandles: 1019}
+	if limits != want {
+		t.Fatalf("limits = %+v, want %+v", limits, want)
+	}
+	if _, err := parseLimitsReply(traceBuf(payload[:20])); err == nil {
+		t.Fatal("truncated reply should fail")
+	}
+}
+
+func TestServerLimitsMaxData(t *testing.T) {
+	cases := []struct {
+		limits ServerLimits
+		want   int
+	}{
+		{ServerLimits{}, 0},
+		{ServerLimits{MaxRead: 65536, MaxWrite: 32768}, 32768},
+		{ServerLimits{MaxWrite: 40000}, 40000},
+		{ServerLimits{MaxPacket: 34816}, 33792},
+	}
+	for _, c := range cases {
+		if got := c.limits.maxData(); got != c.want {
+			t.Errorf("%+v.maxData() = %d, want %d", c.limits, got, c.want)
+		}
+	}
+}
+
+func TestServerLimitsClampTransfers(t *testing.T) {
+	cases := []struct {
+		handles                   uint64
+		concurrency, streams      int
+		wantConcurrency, wantStrm int
+	}{
+		{0, 32, 8, 32, 8},    // 未知：不限制
+		{1019, 16, 4, 16, 4}, // 远低于上限
+		{20, 32, 0, 16, 0},   // 保留 4 个句柄给前台命令
+		{20, 8, 4, 4, 4},     // 每个文件占用 4 个句柄
+		{6, 4, 8, 1, 2},      // 单个文件的并行流也被限制
+		{2, 4, 0, 1, 0},      // 至少保留一个传输
+	}
+	for _, c := range cases {
+		gotC, gotS := ServerLimits{MaxHandles: c.handles}.clampTransfers(c.concurrency, c.streams)
+		if gotC != c.wantConcurrency || gotS != c.wantStrm {
+			t.Errorf("handles %d: clampTransfers(%d, %d) = %d, %d, want %d, %d",
+				c.handles, c.concurrency, c.streams, gotC, gotS, c.wantConcurrency, c.wantStrm)
+		}
+	}
+}
+
+func TestRaisePacketSize(t *testing.T) {
+	cases := []struct {
+		limits                   ServerLimits
+		configured               int
+		wantPacket, wantRequests int
+	}{
+		{ServerLimits{}, 0, defaultMaxPacket, defaultMaxRequests},                                    // 未报告：保持默认
+		{ServerLimits{Reported: true, MaxRead: 255 * 1024, MaxWrite: 255 * 1024}, 0, 255 * 1024, 32}, // OpenSSH
+		{ServerLimits{Reported: true, MaxRead: 1 << 20, MaxWrite: 1 << 20}, 0, autoMaxPacket, 32},    // 最多 autoMaxPacket
+		{ServerLimits{Reported: true, MaxRead: 255 * 1024, MaxWrite: 255 * 1024}, 32768, 32768, 64},  // 已配置：不修改
+	}
+	for _, tc := range cases {
+		c := &Client{sftpOpts: SFTPOptions{MaxPacket: tc.configured}}
+		c.limits.once.Do(func() { c.limits.limits = tc.limits })
+		c.raisePacketSize()
+		if c.packetSize() != tc.wantPacket || c.requestsPerFile() != tc.wantRequests {
+			t.Errorf("%+v, configured %d: packet %d, %d requests; want %d, %d",
+				tc.limits, tc.configured, c.packetSize(), c.requestsPerFile(), tc.wantPacket, tc.wantRequests)
+		}
+	}
+}
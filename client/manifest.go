@@ -0,0 +1,132 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/frostime/my-sftp/units"
+)
+
+// ManifestEntry 描述传输计划中的单个文件（source → destination）
+type ManifestEntry struct {
+	Upload      bool   // true=上传, false=下载
+	Source      string // 已解析的源路径
+	Destination string // 已解析的目标路径
+	Size        int64  // 文件大小（字节）
+}
+
+// DownloadManifest 解析下载 source，返回将要传输的文件清单（不执行传输，不创建目录）
+func (c *Client) DownloadManifest(remoteSources []string, localDir string, opts *DownloadOptions) ([]ManifestEntry, error) {
+	if opts == nil {
+		opts = c.defaultDownloadOptions()
+	}
+	tasks, err := c.planDownloadTasks(remoteSources, c.ResolveLocalPath(localDir), opts)
+	if err != nil {
+		return nil, err
+	}
+	tasks, specials, _ := c.splitSpecialTasks(tasks, opts.RecreateSpecial)
+	return c.manifestWithOverwrite(append(tasks, specials...), opts.Overwrite)
+}
+
+// UploadManifest 解析上传 source，返回将要传输的文件清单（不执行传输，不创建目录）
+func (c *Client) UploadManifest(localSources []string, remoteDir string, opts *UploadOptions) ([]ManifestEntry, error) {
+	if opts == nil {
+		opts = c.defaultUploadOptions()
+	}
+	tasks, _, err := c.planUploadTasks(localSources, c.ResolveRemotePath(remoteDir), opts)
+	if err != nil {
+		return nil, err
+	}
+	tasks, specials, _ := c.splitSpecialTasks(tasks, opts.RecreateSpecial)
+	return c.manifestWithOverwrite(append(tasks, specials...), opts.Overwrite)
+}
+
+// manifestWithOverwrite 按覆盖策略去掉不会传输的文件；ask 策略无法预知回答，保留所有冲突
+func (c *Client) manifestWithOverwrite(tasks []transferTask, policy OverwritePolicy) ([]ManifestEntry, error) {
+	tasks, err := c.newOverwriteResolver(policy, false).filter(tasks)
+	if err != nil {
+		return nil, err
+	}
+	return manifestFromTasks(tasks), nil
+}
+
+func manifestFromTasks(tasks []transferTask) []ManifestEntry {
+	entries := make([]ManifestEntry, 0, len(tasks))
+	for _, task := range tasks {
+		entries = append(entries, ManifestEntry{
+			Upload:      task.isUpload,
+			Source:      taskSourcePath(task),
+			Destination: taskTargetPath(task),
+			Size:        task.size,
+		})
+	}
+	return entries
+}
+
+// SortManifest 按 source、destination 排序，保证输出稳定可 diff
+func SortManifest(entries []ManifestEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Source != entries[j].Source {
+			return entries[i].Source < entries[j].Source
+		}
+		return entries[i].Destination < entries[j].Destination
+	})
+}
+
+// WriteManifest 以稳定排序写出传输清单，upload 为传输方向（清单为空时也能正确标明）
+// 每行格式: <source> -> <destination>\t<size bytes>，首行为汇总注释
+func WriteManifest(w io.Writer, entries []ManifestEntry, upload bool) error {
+	sorted := make([]ManifestEntry, len(entries))
+	copy(sorted, entries)
+	SortManifest(sorted)
+
+	var totalBytes int64
+	for _, entry := range sorted {
+		totalBytes += entry.Size
+	}
+	direction := manifestDirection(upload)
+
+	if _, err := fmt.Fprintf(w, "# %s manifest: %d file(s), %d bytes (%s)\n",
+		direction, len(sorted), totalBytes, units.FormatSize(totalBytes)); err != nil {
+		return err
+	}
+	for _, entry := range sorted {
+		if _, err := fmt.Fprintf(w, "%s -> %s\t%d\n", entry.Source, entry.Destination, entry.Size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDryRun 以易读格式写出 --dry-run 的传输计划：每个文件的方向、路径和大小，最后一行为汇总；
+// upload 为传输方向
+func WriteDryRun(w io.Writer, entries []ManifestEntry, upload bool) error {
+	sorted := make([]ManifestEntry, len(entries))
+	copy(sorted, entries)
+	SortManifest(sorted)
+	if len(sorted) == 0 {
+		_, err := fmt.Fprintln(w, "[dry-run] nothing to transfer")
+		return err
+	}
+
+	direction := manifestDirection(upload)
+	var totalBytes int64
+	for _, entry := range sorted {
+		totalBytes += entry.Size
+		if _, err := fmt.Fprintf(w, "[dry-run] %s %s -> %s (%s)\n",
+			direction, entry.Source, entry.Destination, units.FormatSize(entry.Size)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "[dry-run] would %s %d file(s), %s (%d bytes); nothing was transferred\n",
+		direction, len(sorted), units.FormatSize(totalBytes), totalBytes)
+	return err
+}
+
+func manifestDirection(upload bool) string {
+	if upload {
+		return "upload"
+	}
+	return "download"
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteManifestIsSortedAndStable(t *testing.T) {
+	entries := []ManifestEntry{
+		{Upload: true, Source: "/work/b.txt", Destination: "/srv/b.txt", Size: 20},
+		{Upload: true, Source: "/work/a/z.txt", Destination: "/srv/a/z.txt", Size: 5},
+		{Upload: true, Source: "/work/a.txt", Destination: "/srv/a.txt", Size: 1},
+	}
+
+	var out strings.Builder
+	if err := WriteManifest(&out, entries, true); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+
+	want := "# upload manifest: 3 file(s), 26 bytes (26 B)\n" +
+		"/work/a.txt -> /srv/a.txt\t1\n" +
+		"/work/a/z.txt -> /srv/a/z.txt\t5\n" +
+		"/work/b.txt -> /srv/b.txt\t20\n"
+	if out.String() != want {
+		t.Fatalf("WriteManifest() =\n%s\nwant\n%s", out.String(), want)
+	}
+	if entries[0].Source != "/work/b.txt" {
+		t.Fatal("WriteManifest() must not reorder the caller's slice")
+	}
+}
+
+func TestWriteManifestEmpty(t *testing.T) {
+	// 空目录的清单同样按调用方给出的方向标明
+	for upload, direction := range map[bool]string{true: "upload", false: "download"} {
+		var out strings.Builder
+		if err := WriteManifest(&out, nil, upload); err != nil {
+			t.Fatalf("WriteManifest() error = %v", err)
+		}
+		want := "# " + direction + " manifest: 0 file(s), 0 bytes (0 B)\n"
+		if out.String() != want {
+			t.Errorf("WriteManifest(nil, %v) = %q, want %q", upload, out.String(), want)
+		}
+	}
+}
+
+func TestWriteDryRun(t *testing.T) {
+	entries := []ManifestEntry{
+		{Source: "/srv/b.log", Destination: "/work/b.log", Size: 2048},
+		{Source: "/srv/a.log", Destination: "/work/a.log", Size: 1},
+	}
+
+	var out strings.Builder
+	if err := WriteDryRun(&out, entries, false); err != nil {
+		t.Fatalf("WriteDryRun() error = %v", err)
+	}
+
+	want := "[dry-run] download /srv/a.log -> /work/a.log (1 B)\n" +
+		"[dry-run] download /srv/b.log -> /work/b.log (2.0 KB)\n" +
+		"[dry-run] would download 2 file(s), 2.0 KB (2049 bytes); nothing was transferred\n"
+	if out.String() != want {
+		t.Fatalf("WriteDryRun() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
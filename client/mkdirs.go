@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// mkdirWorkers 批量创建远程目录时同时在途的请求数，分散到 acquireChannel 分配的通道上
+const mkdirWorkers = 16
+
+// mkdirProgressMin 目录数达到该值时显示 "Preparing N directories…" 进度
+const mkdirProgressMin = 50
+
+// ensureRemoteDirsExist 确保所有远程目录（及其上级目录）存在。
+// 按深度逐层处理：同一层的目录互不依赖，并发创建；上级目录是本次新建的，不再检查是否已存在
+func (c *Client) ensureRemoteDirsExist(dirs []string) error {
+	levels := c.remoteDirLevels(dirs)
+	total := 0
+	for _, level := range levels {
+		total += len(level)
+	}
+	progress := c.startMkdirProgress(total)
+	defer progress.finish()
+
+	var mu sync.Mutex
+	created := make(map[string]bool) // 本次新建的目录
+	for _, level := range levels {
+		g := &errgroup.Group{}
+		g.SetLimit(mkdirWorkers)
+		for _, dir := range level {
+			g.Go(func() error {
+				mu.Lock()
+				parentNew := created[path.Dir(dir)]
+				mu.Unlock()
+				isNew, err := c.makeRemoteDir(dir, parentNew)
+				if err != nil {
+					return err
+				}
+				if isNew {
+					mu.Lock()
+					created[dir] = true
+					mu.Unlock()
+				}
+				progress.add()
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remoteDirLevels 解析、去重 dirs 并补全上级目录，按深度分组（浅的在前，组内按名称排序）
+func (c *Client) remoteDirLevels(dirs []string) [][]string {
+	set := make(map[string]struct{})
+	for _, dir := range dirs {
+		for dir = c.ResolveRemotePath(dir); dir != "/" && dir != "."; dir = path.Dir(dir) {
+			if _, ok := set[dir]; ok {
+				break
+			}
+			set[dir] = struct{}{}
+		}
+	}
+	byDepth := make(map[int][]string)
+	for dir := range set {
+		depth := strings.Count(dir, "/")
+		byDepth[depth] = append(byDepth[depth], dir)
+	}
+	depths := make([]int, 0, len(byDepth))
+	for depth := range byDepth {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	levels := make([][]string, 0, len(depths))
+	for _, depth := range depths {
+		sort.Strings(byDepth[depth])
+		levels = append(levels, byDepth[depth])
+	}
+	return levels
+}
+
+// makeRemoteDir 创建单个远程目录（上级目录已存在），返回是否新建。
+// parentNew 为 true 时上级目录刚刚创建，省去一次 Stat
+func (c *Client) makeRemoteDir(dir string, parentNew bool) (bool, error) {
+	ctx, release := c.acquireChannel(context.Background())
+	defer release()
+	sc := c.dataClient(ctx)
+	if !parentNew {
+		if stat, err := sc.Stat(dir); err == nil && stat.IsDir() {
+			return false, nil
+		}
+	}
+	if err := sc.Mkdir(dir); err != nil {
+		// 可能刚巧被别人（或另一个传输）创建了
+		if stat, statErr := sc.Stat(dir); statErr == nil && stat.IsDir() {
+			return false, nil
+		}
+		return false, err
+	}
+	c.invalidateDirCache(path.Dir(dir))
+	c.emit(Event{Type: EventDirCreated, IsUpload: true, Target: dir})
+	return true, nil
+}
+
+// mkdirProgress 批量创建目录的进度：进度条模式下原地刷新计数，log 模式下只打印一行；目录较少时为 nil
+type mkdirProgress struct {
+	total int
+	done  atomic.Int64
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func (c *Client) startMkdirProgress(total int) *mkdirProgress {
+	if total < mkdirProgressMin || c.progressMode == ProgressNone {
+		return nil
+	}
+	if c.progressMode == ProgressLog {
+		fmt.Printf("Preparing %d remote directories…\n", total)
+		return nil
+	}
+	p := &mkdirProgress{total: total, stop: make(chan struct{})}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			fmt.Printf("\r\033[KPreparing %d remote directories… %d/%d", p.total, p.done.Load(), p.total)
+			select {
+			case <-p.stop:
+				fmt.Print("\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return p
+}
+
+func (p *mkdirProgress) add() {
+	if p != nil {
+		p.done.Add(1)
+	}
+}
+
+func (p *mkdirProgress) finish() {
+	if p != nil {
+		close(p.stop)
+		p.wg.Wait()
+	}
+}
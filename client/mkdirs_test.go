@@ -0,0 +1,20 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoteDirLevels(t *testing.T) {
+	c := testClient(true)
+	got := c.remoteDirLevels([]string{"/srv/site/b/deep", "/srv/site/a", "/srv/site/b", "/srv/site/a"})
+	want := [][]string{
+		{"/srv"},
+		{"/srv/site"},
+		{"/srv/site/a", "/srv/site/b"},
+		{"/srv/site/b/deep"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("remoteDirLevels() = %v, want %v", got, want)
+	}
+}
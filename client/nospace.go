@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/sftp"
+
+	"github.com/frostime/my-sftp/units"
+)
+
+// SFTP 状态码：v3 只有笼统的 FAILURE，部分服务器使用 v5+ 的空间不足和配额状态码
+const (
+	sftpStatusFailure       = 4
+	sftpStatusNoSpace       = 14
+	sftpStatusQuotaExceeded = 15
+)
+
+// errNoSpaceAborted 空间不足且用户选择中止后，其余未完成的文件返回该错误，结束时合并为一条
+var errNoSpaceAborted = errors.New("not uploaded: remote filesystem is full")
+
+// NoSpaceInfo 上传因远程空间不足（磁盘满或配额用尽）暂停时的情况，传给 NoSpacePrompt
+type NoSpaceInfo struct {
+	Dir       string // 写入失败的远程目录
+	Err       error  // 服务器返回的错误
+	Quota     bool   // 服务器报告配额用尽；配额不反映在 statvfs 中
+	Usage     *DiskUsage
+	Remaining int64 // 尚未完成的文件（含失败的文件）的总字节数
+	Files     int   // 尚未完成的文件数
+}
+
+// Missing 还需释放的字节数：剩余字节数减去可用空间；可用空间未知或足够时为 0
+func (i NoSpaceInfo) Missing() uint64 {
+	if i.Usage == nil || i.Remaining < 0 || uint64(i.Remaining) <= i.Usage.Available {
+		return 0
+	}
+	return uint64(i.Remaining) - i.Usage.Available
+}
+
+// NoSpacePrompt 上传因空间不足暂停时调用，返回 true 表示已释放空间、继续传输
+type NoSpacePrompt func(NoSpaceInfo) bool
+
+// SetNoSpacePrompt 设置空间不足时的询问回调；未设置时（以及后台任务）直接中止上传
+func (c *Client) SetNoSpacePrompt(prompt NoSpacePrompt) {
+	c.noSpacePrompt = prompt
+}
+
+// remoteNoSpace 判断上传到 dir 失败的 err 是否由远程空间不足引起；size 为正在上传的文件大小
+func (c *Client) remoteNoSpace(err error, dir string, size int64) (quota, ok bool) {
+	msg := strings.ToLower(err.Error())
+	var status *sftp.StatusError
+	isStatus := errors.As(err, &status)
+	switch {
+	case isStatus && status.Code == sftpStatusQuotaExceeded, strings.Contains(msg, "quota exceeded"):
+		return true, true
+	case isStatus && status.Code == sftpStatusNoSpace, strings.Contains(msg, "no space left"), strings.Contains(msg, "disk full"):
+		return false, true
+	case !isStatus || status.Code != sftpStatusFailure:
+		return false, false
+	}
+	// OpenSSH 把 ENOSPC 报告为笼统的 FAILURE：文件放不下时视为空间不足
+	usage, statErr := c.DiskUsage(dir)
+	return false, statErr == nil && usage.Available < uint64(max(size, 1))
+}
+
+// spaceGate 一次上传中所有 worker 共享：第一个因空间不足失败的文件暂停队列并询问，
+// 其他失败的文件和尚未开始的文件等待同一个回答，而不是各自报错
+type spaceGate struct {
+	c          *Client
+	background bool
+	diskUsage  func(dir string) (*DiskUsage, error) // 询问时查询可用空间，即 c.DiskUsage
+
+	remainingBytes atomic.Int64
+	remainingFiles atomic.Int64
+
+	mu      sync.Mutex
+	resume  chan struct{} // 非 nil 时队列暂停，关闭时得到回答
+	aborted bool
+}
+
+// newSpaceGate 只用于上传；下载返回 nil
+func (c *Client) newSpaceGate(tasks []transferTask, background bool) *spaceGate {
+	if len(tasks) == 0 || !tasks[0].isUpload {
+		return nil
+	}
+	g := &spaceGate{c: c, background: background, diskUsage: c.DiskUsage}
+	for _, t := range tasks {
+		g.remainingBytes.Add(t.size)
+	}
+	g.remainingFiles.Store(int64(len(tasks)))
+	return g
+}
+
+// wait 在队列暂停期间阻塞，中止后返回 errNoSpaceAborted
+func (g *spaceGate) wait(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	resume, aborted := g.resume, g.aborted
+	g.mu.Unlock()
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		g.mu.Lock()
+		aborted = g.aborted
+		g.mu.Unlock()
+	}
+	if aborted {
+		return errNoSpaceAborted
+	}
+	return nil
+}
+
+// done 记录文件 t 已结束（成功或跳过），不再计入剩余量
+func (g *spaceGate) done(t transferTask) {
+	if g == nil {
+		return
+	}
+	g.remainingBytes.Add(-t.size)
+	g.remainingFiles.Add(-1)
+}
+
+// pause 处理任务 t 的错误：空间不足时暂停队列并等待回答，返回 true 表示应重试 t；
+// 返回 false 时 err 为最终错误（中止时非首个失败的文件为 errNoSpaceAborted）
+func (g *spaceGate) pause(ctx context.Context, t transferTask, err error) (bool, error) {
+	if g == nil || err == nil || ctx.Err() != nil {
+		return false, err
+	}
+	dir := path.Dir(t.remotePath)
+	quota, ok := g.c.remoteNoSpace(err, dir, t.size)
+	if !ok {
+		return false, err
+	}
+
+	g.mu.Lock()
+	if g.aborted {
+		g.mu.Unlock()
+		return false, errNoSpaceAborted
+	}
+	if resume := g.resume; resume != nil {
+		// 已有文件在询问：等待同一个回答
+		g.mu.Unlock()
+		if g.wait(ctx) != nil {
+			return false, errNoSpaceAborted
+		}
+		return true, nil
+	}
+	resume := make(chan struct{})
+	g.resume = resume
+	g.mu.Unlock()
+
+	info := NoSpaceInfo{Dir: dir, Err: err, Quota: quota,
+		Remaining: g.remainingBytes.Load(), Files: int(g.remainingFiles.Load())}
+	info.Usage, _ = g.diskUsage(dir)
+	retry := !g.background && g.c.noSpacePrompt != nil && g.c.noSpacePrompt(info)
+
+	g.mu.Lock()
+	g.aborted = !retry
+	g.resume = nil
+	close(resume)
+	g.mu.Unlock()
+	if !retry {
+		return false, fmt.Errorf("%w (%s)", err, info.describe())
+	}
+	return true, nil
+}
+
+// describe 空间不足的原因和缺少的空间，如 "remote disk full: 1.2 MB available, 340.0 MB more needed"
+func (i NoSpaceInfo) describe() string {
+	if i.Quota {
+		return "remote quota exceeded"
+	}
+	if i.Usage == nil {
+		return "remote disk full"
+	}
+	s := fmt.Sprintf("remote disk full: %s available", units.FormatSize(int64(i.Usage.Available)))
+	if missing := i.Missing(); missing > 0 {
+		s += fmt.Sprintf(", %s more needed", units.FormatSize(int64(missing)))
+	}
+	return s
+}
+
+// collapse 把中止后各文件的 errNoSpaceAborted 合并为一条错误
+func (g *spaceGate) collapse(errs []error) []error {
+	if g == nil {
+		return errs
+	}
+	kept := errs[:0]
+	aborted := 0
+	for _, err := range errs {
+		if errors.Is(err, errNoSpaceAborted) {
+			aborted++
+			continue
+		}
+		kept = append(kept, err)
+	}
+	if aborted > 0 {
+		kept = append(kept, fmt.Errorf("%d more file(s) %w", aborted, errNoSpaceAborted))
+	}
+	return kept
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRemoteNoSpace(t *testing.T) {
+	c := &Client{}
+	tests := []struct {
+		err       error
+		quota, ok bool
+	}{
+		{errors.New(`sftp: "write /srv/a: no space left on device" (SSH_FX_FAILURE)`), false, true},
+		{errors.New("Disk quota exceeded"), true, true},
+		{errors.New("connection lost"), false, false},
+	}
+	for _, tt := range tests {
+		quota, ok := c.remoteNoSpace(tt.err, "/srv", 1)
+		if quota != tt.quota || ok != tt.ok {
+			t.Errorf("remoteNoSpace(%q) = %v, %v, want %v, %v", tt.err, quota, ok, tt.quota, tt.ok)
+		}
+	}
+}
+
+func TestNoSpaceInfoMissing(t *testing.T) {
+	info := NoSpaceInfo{Remaining: 300, Usage: &DiskUsage{Available: 100}}
+	if got := info.Missing(); got != 200 {
+		t.Errorf("Missing() = %d, want 200", got)
+	}
+	info.Usage.Available = 500
+	if got := info.Missing(); got != 0 {
+		t.Errorf("Missing() with enough space = %d, want 0", got)
+	}
+	if got := (NoSpaceInfo{Remaining: 300}).Missing(); got != 0 {
+		t.Errorf("Missing() without statvfs = %d, want 0", got)
+	}
+}
+
+func TestSpaceGateResumeAndAbort(t *testing.T) {
+	full := errors.New("no space left on device")
+	tasks := []transferTask{
+		{remotePath: "/srv/a", isUpload: true, size: 10},
+		{remotePath: "/srv/b", isUpload: true, size: 20},
+		{remotePath: "/srv/c", isUpload: true, size: 30},
+	}
+	var asked []NoSpaceInfo
+	answer := true
+	c := &Client{noSpacePrompt: func(info NoSpaceInfo) bool {
+		asked = append(asked, info)
+		return answer
+	}}
+	g := c.newSpaceGate(tasks, false)
+	g.diskUsage = func(string) (*DiskUsage, error) { return &DiskUsage{Available: 5}, nil }
+	ctx := context.Background()
+
+	g.done(tasks[0])
+	if retry, err := g.pause(ctx, tasks[1], full); !retry || err != nil {
+		t.Fatalf("pause after resume = %v, %v, want retry", retry, err)
+	}
+	if len(asked) != 1 || asked[0].Files != 2 || asked[0].Remaining != 50 || asked[0].Dir != "/srv" || asked[0].Missing() != 45 {
+		t.Fatalf("asked %+v, want 2 files, 50 bytes in /srv, 45 bytes missing", asked)
+	}
+
+	answer = false
+	if retry, err := g.pause(ctx, tasks[1], full); retry || !errors.Is(err, full) || errors.Is(err, errNoSpaceAborted) {
+		t.Fatalf("pause after abort = %v, %v, want the server error", retry, err)
+	}
+	if retry, err := g.pause(ctx, tasks[2], full); retry || !errors.Is(err, errNoSpaceAborted) {
+		t.Fatalf("second failure after abort = %v, %v, want errNoSpaceAborted", retry, err)
+	}
+	if err := g.wait(ctx); !errors.Is(err, errNoSpaceAborted) {
+		t.Fatalf("wait after abort = %v, want errNoSpaceAborted", err)
+	}
+	if len(asked) != 2 {
+		t.Fatalf("asked %d times, want 2", len(asked))
+	}
+
+	errs := g.collapse([]error{full, errNoSpaceAborted, errNoSpaceAborted})
+	if len(errs) != 2 || errs[1].Error() != "2 more file(s) not uploaded: remote filesystem is full" {
+		t.Fatalf("collapse = %v", errs)
+	}
+}
+
+func TestSpaceGateBackgroundAborts(t *testing.T) {
+	c := &Client{noSpacePrompt: func(NoSpaceInfo) bool {
+		t.Fatal("background transfer must not prompt")
+		return true
+	}}
+	tasks := []transferTask{{remotePath: "/srv/a", isUpload: true, size: 1}}
+	g := c.newSpaceGate(tasks, true)
+	g.diskUsage = func(string) (*DiskUsage, error) { return nil, errors.New("unsupported") }
+	if retry, _ := g.pause(context.Background(), tasks[0], errors.New("Disk quota exceeded")); retry {
+		t.Fatal("background transfer resumed")
+	}
+	if c.newSpaceGate([]transferTask{{remotePath: "/srv/a"}}, false) != nil {
+		t.Fatal("download got a space gate")
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TransferOrder 批量传输中文件的启动顺序
+type TransferOrder string
+
+const (
+	OrderWalk    TransferOrder = "walk"    // 按收集顺序（目录遍历顺序，默认）
+	OrderByDir   TransferOrder = "dir"     // 按目标目录分组，同一目录的文件连续传输，远程文件系统缓存更友好
+	OrderBySize  TransferOrder = "size"    // 大文件优先，避免最后只剩一个大文件在传输
+	OrderShuffle TransferOrder = "shuffle" // 随机顺序
+)
+
+// TransferOrders 所有可用顺序，按帮助文本顺序
+var TransferOrders = []TransferOrder{OrderWalk, OrderByDir, OrderBySize, OrderShuffle}
+
+// ParseTransferOrder 解析顺序名称；空字符串视为 walk
+func ParseTransferOrder(s string) (TransferOrder, error) {
+	if s == "" {
+		return OrderWalk, nil
+	}
+	for _, o := range TransferOrders {
+		if string(o) == s {
+			return o, nil
+		}
+	}
+	names := make([]string, len(TransferOrders))
+	for i, o := range TransferOrders {
+		names[i] = string(o)
+	}
+	return "", fmt.Errorf("invalid transfer order %q (want one of: %s)", s, strings.Join(names, ", "))
+}
+
+// orderTasks 按 order 返回重新排列的任务；walk（或空）时原样返回，其他情况不修改 tasks
+func orderTasks(tasks []transferTask, order TransferOrder) []transferTask {
+	if order == "" || order == OrderWalk || len(tasks) < 2 {
+		return tasks
+	}
+	ordered := append([]transferTask(nil), tasks...)
+	switch order {
+	case OrderByDir:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			di, dj := taskDestDir(ordered[i]), taskDestDir(ordered[j])
+			if di != dj {
+				return di < dj
+			}
+			return taskDestPath(ordered[i]) < taskDestPath(ordered[j])
+		})
+	case OrderBySize:
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].size > ordered[j].size })
+	case OrderShuffle:
+		rand.Shuffle(len(ordered), func(i, j int) { ordered[i], ordered[j] = ordered[j], ordered[i] })
+	}
+	return ordered
+}
+
+// taskDestPath 返回任务的目标文件路径
+func taskDestPath(task transferTask) string {
+	if task.isUpload {
+		return task.remotePath
+	}
+	return task.localPath
+}
+
+// taskDestDir 返回任务目标文件所在的目录
+func taskDestDir(task transferTask) string {
+	if task.isUpload {
+		return path.Dir(task.remotePath)
+	}
+	return filepath.Dir(task.localPath)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestOrderTasks(t *testing.T) {
+	tasks := []transferTask{
+		{remotePath: "/r/b/2.txt", size: 10, isUpload: true},
+		{remotePath: "/r/a/1.txt", size: 300, isUpload: true},
+		{remotePath: "/r/b/1.txt", size: 20, isUpload: true},
+		{remotePath: "/r/a/2.txt", size: 5, isUpload: true},
+	}
+	paths := func(ts []transferTask) []string {
+		out := make([]string, len(ts))
+		for i, task := range ts {
+			out[i] = task.remotePath
+		}
+		return out
+	}
+
+	byDir := paths(orderTasks(tasks, OrderByDir))
+	want := []string{"/r/a/1.txt", "/r/a/2.txt", "/r/b/1.txt", "/r/b/2.txt"}
+	for i := range want {
+		if byDir[i] != want[i] {
+			t.Fatalf("dir order = %v, want %v", byDir, want)
+		}
+	}
+	if bySize := orderTasks(tasks, OrderBySize); bySize[0].size != 300 || bySize[3].size != 5 {
+		t.Fatalf("size order = %v", paths(bySize))
+	}
+	if shuffled := orderTasks(tasks, OrderShuffle); len(shuffled) != len(tasks) {
+		t.Fatalf("shuffle lost tasks: %v", paths(shuffled))
+	}
+	// 原切片不变
+	if tasks[0].remotePath != "/r/b/2.txt" {
+		t.Fatalf("orderTasks modified its input: %v", paths(tasks))
+	}
+}
+
+func TestParseTransferOrder(t *testing.T) {
+	if o, err := ParseTransferOrder(""); err != nil || o != OrderWalk {
+		t.Fatalf("ParseTransferOrder(\"\") = %q, %v", o, err)
+	}
+	if o, err := ParseTransferOrder("size"); err != nil || o != OrderBySize {
+		t.Fatalf("ParseTransferOrder(size) = %q, %v", o, err)
+	}
+	if _, err := ParseTransferOrder("random"); err == nil {
+		t.Fatal("ParseTransferOrder(random) expected error")
+	}
+}
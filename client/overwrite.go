@@ -0,0 +1,251 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OverwritePolicy 目标文件已存在时的处理策略
+type OverwritePolicy string
+
+const (
+	OverwriteAlways          OverwritePolicy = "always"            // 总是覆盖（默认）
+	OverwriteNever           OverwritePolicy = "never"             // 从不覆盖，跳过已存在的文件
+	OverwriteIfNewer         OverwritePolicy = "if-newer"          // 源文件修改时间比目标新时覆盖
+	OverwriteIfDifferentSize OverwritePolicy = "if-different-size" // 大小不同时覆盖
+	OverwriteAsk             OverwritePolicy = "ask"               // 逐个询问，见 SetOverwritePrompt
+)
+
+// OverwritePolicies 所有可用策略，按帮助文本顺序
+var OverwritePolicies = []OverwritePolicy{
+	OverwriteAlways, OverwriteNever, OverwriteIfNewer, OverwriteIfDifferentSize, OverwriteAsk,
+}
+
+// ParseOverwritePolicy 解析策略名称；空字符串视为 always
+func ParseOverwritePolicy(s string) (OverwritePolicy, error) {
+	if s == "" {
+		return OverwriteAlways, nil
+	}
+	for _, p := range OverwritePolicies {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	names := make([]string, len(OverwritePolicies))
+	for i, p := range OverwritePolicies {
+		names[i] = string(p)
+	}
+	return "", fmt.Errorf("invalid overwrite policy %q (want one of: %s)", s, strings.Join(names, ", "))
+}
+
+// OverwriteConflict 目标已存在时交给询问回调的信息
+type OverwriteConflict struct {
+	IsUpload    bool
+	Source      string
+	Destination string
+	SourceSize  int64
+	DestSize    int64
+	SourceMod   time.Time
+	DestMod     time.Time
+}
+
+// OverwriteAnswer 询问回调的回答
+type OverwriteAnswer int
+
+const (
+	OverwriteYes  OverwriteAnswer = iota // 覆盖这一个
+	OverwriteNo                          // 跳过这一个
+	OverwriteAll                         // 覆盖本批次剩余的所有冲突
+	OverwriteNone                        // 跳过本批次剩余的所有冲突
+	OverwriteQuit                        // 取消整个传输
+)
+
+// OverwritePrompt 询问是否覆盖已存在的目标文件；在传输开始前按顺序调用
+type OverwritePrompt func(OverwriteConflict) OverwriteAnswer
+
+// ErrTransferAborted 用户在覆盖询问中选择取消传输
+var ErrTransferAborted = errors.New("transfer aborted")
+
+// SetOverwritePrompt 设置 ask 策略使用的询问回调；未设置时 ask 等同于 never
+func (c *Client) SetOverwritePrompt(prompt OverwritePrompt) {
+	c.overwritePrompt = prompt
+}
+
+// overwriteResolver 按策略过滤目标已存在的任务，并缓存目标目录列表
+type overwriteResolver struct {
+	c           *Client
+	policy      OverwritePolicy
+	interactive bool            // false 时（清单/dry-run）ask 策略保留所有冲突
+	sticky      OverwriteAnswer // 回答 all/none 后对后续冲突生效，-1 表示未设置
+	listings    map[string]map[string]os.FileInfo
+	skipped     []string
+}
+
+func (c *Client) newOverwriteResolver(policy OverwritePolicy, interactive bool) *overwriteResolver {
+	return &overwriteResolver{
+		c:           c,
+		policy:      policy,
+		interactive: interactive,
+		sticky:      -1,
+		listings:    make(map[string]map[string]os.FileInfo),
+	}
+}
+
+// filter 返回需要传输的任务；被跳过的目标路径记录在 r.skipped
+func (r *overwriteResolver) filter(tasks []transferTask) ([]transferTask, error) {
+	if r.policy == "" || r.policy == OverwriteAlways {
+		return tasks, nil
+	}
+	kept := tasks[:0:0]
+	for _, task := range tasks {
+		overwrite, err := r.decide(task)
+		if err != nil {
+			return nil, err
+		}
+		if overwrite {
+			kept = append(kept, task)
+		} else {
+			r.skipped = append(r.skipped, taskTargetPath(task))
+		}
+	}
+	return kept, nil
+}
+
+func (r *overwriteResolver) decide(task transferTask) (bool, error) {
+	dest := r.lookupTarget(task)
+	if dest == nil || dest.IsDir() {
+		// 目标不存在；目标是目录时交给传输本身报错
+		return true, nil
+	}
+
+	switch r.policy {
+	case OverwriteNever:
+		return false, nil
+	case OverwriteIfDifferentSize:
+		return dest.Size() != task.size, nil
+	case OverwriteIfNewer:
+		src, err := r.c.statTaskSource(task)
+		if err != nil {
+			return false, fmt.Errorf("stat %s: %w", taskSourcePath(task), err)
+		}
+		return src.ModTime().After(dest.ModTime()), nil
+	}
+
+	// OverwriteAsk
+	if !r.interactive {
+		return true, nil
+	}
+	switch r.sticky {
+	case OverwriteAll:
+		return true, nil
+	case OverwriteNone:
+		return false, nil
+	}
+	if r.c.overwritePrompt == nil {
+		return false, nil
+	}
+	conflict := OverwriteConflict{
+		IsUpload:    task.isUpload,
+		Source:      taskSourcePath(task),
+		Destination: taskTargetPath(task),
+		SourceSize:  task.size,
+		DestSize:    dest.Size(),
+		DestMod:     dest.ModTime(),
+	}
+	if src, err := r.c.statTaskSource(task); err == nil {
+		conflict.SourceMod = src.ModTime()
+	}
+	switch answer := r.c.overwritePrompt(conflict); answer {
+	case OverwriteYes:
+		return true, nil
+	case OverwriteAll:
+		r.sticky = answer
+		return true, nil
+	case OverwriteNone:
+		r.sticky = answer
+		return false, nil
+	case OverwriteQuit:
+		return false, ErrTransferAborted
+	}
+	return false, nil
+}
+
+// lookupTarget 通过列出目标目录查找目标文件，每个目录只列一次；不存在时返回 nil
+func (r *overwriteResolver) lookupTarget(task transferTask) os.FileInfo {
+	dir, name := filepath.Split(task.localPath)
+	if task.isUpload {
+		dir, name = path.Split(task.remotePath)
+	}
+	entries, ok := r.listings[dir]
+	if !ok {
+		entries = make(map[string]os.FileInfo)
+		var infos []os.FileInfo
+		if task.isUpload {
+			infos, _ = r.c.sftpConn().ReadDir(dir)
+		} else {
+			dirEntries, _ := os.ReadDir(dir)
+			for _, entry := range dirEntries {
+				if info, err := entry.Info(); err == nil {
+					infos = append(infos, info)
+				}
+			}
+		}
+		for _, info := range infos {
+			entries[info.Name()] = info
+		}
+		r.listings[dir] = entries
+	}
+	return entries[name]
+}
+
+// printSkippedExisting 汇总输出因覆盖策略跳过的文件
+func printSkippedExisting(w io.Writer, skipped []string, policy OverwritePolicy) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Skipped %d existing file(s) (overwrite: %s):\n", len(skipped), policy)
+	for i, p := range skipped {
+		if i == maxListedSpecialFiles {
+			fmt.Fprintf(w, "  ... and %d more\n", len(skipped)-i)
+			break
+		}
+		fmt.Fprintf(w, "  - %s\n", p)
+	}
+}
+
+// applyOverwritePolicy 对普通任务和特殊文件任务应用同一个覆盖策略（all/none 回答对两者都生效）
+func (c *Client) applyOverwritePolicy(tasks, specials []transferTask, policy OverwritePolicy) ([]transferTask, []transferTask, error) {
+	r := c.newOverwriteResolver(policy, true)
+	tasks, err := r.filter(tasks)
+	if err != nil {
+		return nil, nil, err
+	}
+	specials, err = r.filter(specials)
+	if err != nil {
+		return nil, nil, err
+	}
+	printSkippedExisting(os.Stdout, r.skipped, policy)
+	return tasks, specials, nil
+}
+
+// AllowOverwrite 对单个文件应用覆盖策略（不经过批量传输的路径，如 --name），返回是否应继续传输
+func (c *Client) AllowOverwrite(localPath, remotePath string, isUpload bool, policy OverwritePolicy) (bool, error) {
+	task := transferTask{
+		localPath:  c.ResolveLocalPath(localPath),
+		remotePath: c.ResolveRemotePath(remotePath),
+		isUpload:   isUpload,
+	}
+	src, err := c.statTaskSource(task)
+	if err != nil {
+		return false, err
+	}
+	task.size = src.Size()
+	kept, _, err := c.applyOverwritePolicy([]transferTask{task}, nil, policy)
+	return len(kept) == 1, err
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseOverwritePolicy(t *testing.T) {
+	for _, p := range OverwritePolicies {
+		got, err := ParseOverwritePolicy(string(p))
+		if err != nil || got != p {
+			t.Errorf("ParseOverwritePolicy(%q) = %q, %v", p, got, err)
+		}
+	}
+	if got, err := ParseOverwritePolicy(""); err != nil || got != OverwriteAlways {
+		t.Errorf("ParseOverwritePolicy(\"\") = %q, %v, want always", got, err)
+	}
+	if _, err := ParseOverwritePolicy("newer"); err == nil {
+		t.Error("ParseOverwritePolicy(\"newer\") expected error")
+	}
+}
+
+func TestOverwriteResolverLocalTargets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "same.txt"), []byte("1234"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "diff.txt"), []byte("12"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tasks := []transferTask{
+		{localPath: filepath.Join(dir, "same.txt"), remotePath: "/r/same.txt", size: 4},
+		{localPath: filepath.Join(dir, "diff.txt"), remotePath: "/r/diff.txt", size: 4},
+		{localPath: filepath.Join(dir, "new.txt"), remotePath: "/r/new.txt", size: 4},
+	}
+	targets := func(tasks []transferTask) []string {
+		var names []string
+		for _, task := range tasks {
+			names = append(names, filepath.Base(task.localPath))
+		}
+		return names
+	}
+
+	c := &Client{}
+	tests := []struct {
+		policy      OverwritePolicy
+		interactive bool
+		want        []string
+	}{
+		{OverwriteAlways, true, []string{"same.txt", "diff.txt", "new.txt"}},
+		{OverwriteNever, true, []string{"new.txt"}},
+		{OverwriteIfDifferentSize, true, []string{"diff.txt", "new.txt"}},
+		// 没有询问回调时 ask 不覆盖；清单模式下保留所有冲突
+		{OverwriteAsk, true, []string{"new.txt"}},
+		{OverwriteAsk, false, []string{"same.txt", "diff.txt", "new.txt"}},
+	}
+	for _, tt := range tests {
+		r := c.newOverwriteResolver(tt.policy, tt.interactive)
+		got, err := r.filter(append([]transferTask(nil), tasks...))
+		if err != nil {
+			t.Fatalf("%s: filter() error = %v", tt.policy, err)
+		}
+		if !reflect.DeepEqual(targets(got), tt.want) {
+			t.Errorf("%s (interactive=%v): kept %v, want %v", tt.policy, tt.interactive, targets(got), tt.want)
+		}
+		if len(r.skipped)+len(got) != len(tasks) {
+			t.Errorf("%s: skipped %v does not account for every task", tt.policy, r.skipped)
+		}
+	}
+}
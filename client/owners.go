@@ -0,0 +1,179 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// remoteAccounts 远程用户/组名到 ID 的映射，每个会话只获取一次
+type remoteAccounts struct {
+	once   sync.Once
+	users  map[string]int
+	groups map[string]int
+	err    error
+}
+
+// parseAccountDB 解析 passwd/group 格式（name:x:id:...），返回名称到 ID 的映射
+func parseAccountDB(r io.Reader) map[string]int {
+	ids := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		if _, exists := ids[fields[0]]; !exists {
+			ids[fields[0]] = id
+		}
+	}
+	return ids
+}
+
+// remoteAccountDB 优先用 getent（包含 LDAP/NSS 账户），失败时读取 /etc 下的文件
+func (c *Client) remoteAccountDB(database string) (map[string]int, error) {
+	var out bytes.Buffer
+	if err := c.ExecuteRemote("getent "+database, nil, &out, io.Discard); err == nil && out.Len() > 0 {
+		return parseAccountDB(&out), nil
+	}
+	f, err := c.sftpConn().Open(path.Join("/etc", database))
+	if err != nil {
+		return nil, fmt.Errorf("read remote %s database: %w", database, err)
+	}
+	defer f.Close()
+	return parseAccountDB(f), nil
+}
+
+func (c *Client) loadRemoteAccounts() (*remoteAccounts, error) {
+	a := &c.accounts
+	a.once.Do(func() {
+		if a.users, a.err = c.remoteAccountDB("passwd"); a.err != nil {
+			return
+		}
+		a.groups, a.err = c.remoteAccountDB("group")
+	})
+	return a, a.err
+}
+
+func sortedNames(ids map[string]int) []string {
+	names := make([]string, 0, len(ids))
+	for name := range ids {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoteUsers 返回远程用户名列表（用于补全，失败时返回 nil）
+func (c *Client) RemoteUsers() []string {
+	a, err := c.loadRemoteAccounts()
+	if err != nil {
+		return nil
+	}
+	return sortedNames(a.users)
+}
+
+// RemoteGroups 返回远程组名列表（用于补全，失败时返回 nil）
+func (c *Client) RemoteGroups() []string {
+	a, err := c.loadRemoteAccounts()
+	if err != nil {
+		return nil
+	}
+	return sortedNames(a.groups)
+}
+
+// lookupAccountID 解析数字 ID 或账户名
+func lookupAccountID(name string, ids map[string]int, kind string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil && id >= 0 {
+		return id, nil
+	}
+	if id, ok := ids[name]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown remote %s: %s", kind, name)
+}
+
+// Chown 修改远程文件的所有者和/或组；owner 或 group 为空时保持不变。
+// 名称通过远程的 passwd/group 数据库解析，也可以直接使用数字 ID。
+func (c *Client) Chown(remotePath, owner, group string, recursive bool) error {
+	if owner == "" && group == "" {
+		return fmt.Errorf("missing owner or group")
+	}
+	// 账户数据库读取失败时仍允许使用数字 ID
+	var users, groups map[string]int
+	if a, err := c.loadRemoteAccounts(); err == nil {
+		users, groups = a.users, a.groups
+	}
+	uid, gid := -1, -1
+	var err error
+	if owner != "" {
+		if uid, err = lookupAccountID(owner, users, "user"); err != nil {
+			return err
+		}
+	}
+	if group != "" {
+		if gid, err = lookupAccountID(group, groups, "group"); err != nil {
+			return err
+		}
+	}
+
+	remotePath = c.ResolveRemotePath(remotePath)
+	defer c.invalidateDirCache(path.Dir(remotePath))
+	if !recursive {
+		return c.chownOne(remotePath, uid, gid)
+	}
+
+	walker := c.sftpConn().Walk(remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+		if err := c.chownOne(walker.Path(), uid, gid); err != nil {
+			return err
+		}
+		if walker.Stat().IsDir() {
+			c.invalidateDirCache(walker.Path())
+		}
+	}
+	return nil
+}
+
+// chownOne 修改单个路径；uid/gid 为 -1 时沿用当前值
+func (c *Client) chownOne(remotePath string, uid, gid int) error {
+	if uid < 0 || gid < 0 {
+		info, err := c.sftpConn().Lstat(remotePath)
+		if err != nil {
+			return err
+		}
+		stat, ok := info.Sys().(*sftp.FileStat)
+		if !ok {
+			return fmt.Errorf("%s: server did not report ownership", remotePath)
+		}
+		if uid < 0 {
+			uid = int(stat.UID)
+		}
+		if gid < 0 {
+			gid = int(stat.GID)
+		}
+	}
+	if err := c.sftpConn().Chown(remotePath, uid, gid); err != nil {
+		return fmt.Errorf("chown %s: %w", remotePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAccountDB(t *testing.T) {
+	db := parseAccountDB(strings.NewReader(`# comment
+root:x:0:0:root:/root:/bin/bash
+www-data:x:33:33:www-data:/var/www:/usr/sbin/nologin
+broken:x:notanumber:0::/:/bin/sh
+short:x
+
+root:x:1000:1000::/:/bin/sh
+`))
+	if len(db) != 2 || db["root"] != 0 || db["www-data"] != 33 {
+		t.Fatalf("parseAccountDB() = %v", db)
+	}
+
+	if id, err := lookupAccountID("www-data", db, "user"); err != nil || id != 33 {
+		t.Fatalf("lookupAccountID(www-data) = %d, %v", id, err)
+	}
+	if id, err := lookupAccountID("1001", nil, "user"); err != nil || id != 1001 {
+		t.Fatalf("lookupAccountID(1001) = %d, %v", id, err)
+	}
+	if _, err := lookupAccountID("nobody", db, "user"); err == nil {
+		t.Fatal("lookupAccountID(nobody) expected error")
+	}
+}
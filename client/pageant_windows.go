@@ -0,0 +1,135 @@
+//go:build windows
+
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Pageant 不使用管道或 socket：请求写入一块共享内存，再用 WM_COPYDATA 把共享内存的名字发给 Pageant 的窗口，
+// Pageant 处理完后把回复写回同一块内存
+const (
+	pageantMaxMessage = 8192       // 请求和回复的最大长度（含 4 字节长度前缀）
+	pageantCopyDataID = 0x804e50ba // WM_COPYDATA 的 dwData，表示 agent 请求
+	wmCopyData        = 0x004a
+)
+
+var (
+	user32          = windows.NewLazySystemDLL("user32.dll")
+	procFindWindowW = user32.NewProc("FindWindowW")
+	procSendMessage = user32.NewProc("SendMessageW")
+
+	pageantMu sync.Mutex // 同一时刻只发送一个请求（共享内存名按线程区分，进程内串行更简单）
+)
+
+type copyDataStruct struct {
+	dwData uintptr
+	cbData uint32
+	lpData uintptr
+}
+
+// pageantConn 把 agent 客户端的读写转换成 Pageant 请求：Write 收到完整的请求后发送，回复由 Read 读出
+type pageantConn struct {
+	request  []byte
+	response bytes.Reader
+}
+
+func dialPageant() (io.ReadWriteCloser, error) {
+	if findPageant() == 0 {
+		return nil, ErrNoAgent
+	}
+	return &pageantConn{}, nil
+}
+
+func (c *pageantConn) Write(p []byte) (int, error) {
+	c.request = append(c.request, p...)
+	if len(c.request) < 4 || len(c.request)-4 < int(binary.BigEndian.Uint32(c.request)) {
+		return len(p), nil
+	}
+	response, err := pageantQuery(c.request)
+	c.request = c.request[:0]
+	if err != nil {
+		return 0, err
+	}
+	c.response.Reset(response)
+	return len(p), nil
+}
+
+func (c *pageantConn) Read(p []byte) (int, error) {
+	return c.response.Read(p)
+}
+
+func (c *pageantConn) Close() error {
+	return nil
+}
+
+func findPageant() uintptr {
+	name, _ := windows.UTF16PtrFromString("Pageant")
+	hwnd, _, _ := procFindWindowW.Call(uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(name)))
+	return hwnd
+}
+
+// pageantQuery 发送一个完整的 agent 请求（含长度前缀），返回完整的回复
+func pageantQuery(request []byte) ([]byte, error) {
+	if len(request) > pageantMaxMessage {
+		return nil, fmt.Errorf("pageant: request too large (%d bytes)", len(request))
+	}
+	pageantMu.Lock()
+	defer pageantMu.Unlock()
+	hwnd := findPageant()
+	if hwnd == 0 {
+		return nil, errors.New("pageant: not running")
+	}
+
+	// 共享内存只允许当前用户访问；较新的 Pageant 还会检查它的所有者是否为同一用户
+	sa, err := pageantSecurityAttributes()
+	if err != nil {
+		return nil, fmt.Errorf("pageant: %w", err)
+	}
+	mapName := fmt.Sprintf("PageantRequest%08x", windows.GetCurrentThreadId())
+	name, _ := windows.UTF16PtrFromString(mapName)
+	mapping, err := windows.CreateFileMapping(windows.InvalidHandle, sa, windows.PAGE_READWRITE, 0, pageantMaxMessage, name)
+	if err != nil {
+		return nil, fmt.Errorf("pageant: create shared memory: %w", err)
+	}
+	defer windows.CloseHandle(mapping)
+	addr, err := windows.MapViewOfFile(mapping, windows.FILE_MAP_WRITE, 0, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("pageant: map shared memory: %w", err)
+	}
+	defer windows.UnmapViewOfFile(addr)
+	view := unsafe.Slice((*byte)(unsafe.Add(nil, addr)), pageantMaxMessage)
+	copy(view, request)
+
+	cName := append([]byte(mapName), 0)
+	data := copyDataStruct{dwData: pageantCopyDataID, cbData: uint32(len(cName)), lpData: uintptr(unsafe.Pointer(&cName[0]))}
+	if ret, _, _ := procSendMessage.Call(hwnd, wmCopyData, 0, uintptr(unsafe.Pointer(&data))); ret == 0 {
+		return nil, errors.New("pageant: request refused")
+	}
+	size := binary.BigEndian.Uint32(view)
+	if size > pageantMaxMessage-4 {
+		return nil, fmt.Errorf("pageant: invalid response length %d", size)
+	}
+	return bytes.Clone(view[:4+size]), nil
+}
+
+func pageantSecurityAttributes() (*windows.SecurityAttributes, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	sid := user.User.Sid.String()
+	sd, err := windows.SecurityDescriptorFromString("O:" + sid + "D:P(A;;GA;;;" + sid + ")")
+	if err != nil {
+		return nil, err
+	}
+	return &windows.SecurityAttributes{Length: uint32(unsafe.Sizeof(windows.SecurityAttributes{})), SecurityDescriptor: sd}, nil
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/frostime/my-sftp/ttystate"
+)
+
+// runTaskPool 以 opts.Concurrency 的并发度执行 n 个任务，run 收到的 ctx 在以下情况被取消：
+// 外部 ctx 取消、FailFast 模式下任一任务失败、单个任务超过 TaskTimeout。
+// 设置了 opts.workers 时每个任务启动前还要向调度器申请 worker（等待时间不计入 TaskTimeout）。
+// 返回实际启动的任务数和全部错误；未启动的任务汇总为一条错误。
+// FailFast 模式下因其他任务失败而被中断的任务不单独报告错误
+func runTaskPool(ctx context.Context, n int, opts *TransferOptions, run func(ctx context.Context, i int) error) (int, []error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = MaxConcurrentTransfers
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	g := &errgroup.Group{}
+	runCtx := ctx
+	if opts.FailFast {
+		g, runCtx = errgroup.WithContext(ctx)
+	}
+	g.SetLimit(concurrency)
+
+	var mu sync.Mutex
+	var errs []error
+	var started atomic.Int32
+	for i := 0; i < n && runCtx.Err() == nil; i++ {
+		g.Go(func() error {
+			defer ttystate.Guard()
+			// 等待空位期间可能已被取消
+			if runCtx.Err() != nil {
+				return nil
+			}
+			if opts.workers.acquire(runCtx, opts.Batch != nil) != nil {
+				return nil
+			}
+			defer opts.workers.release(opts.Batch != nil)
+			started.Add(1)
+
+			err := runWithTimeout(runCtx, opts.TaskTimeout, func(ctx context.Context) error { return run(ctx, i) })
+			if err == nil {
+				return nil
+			}
+			if opts.FailFast && ctx.Err() == nil && runCtx.Err() != nil && errors.Is(err, context.Canceled) {
+				return nil
+			}
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			return err
+		})
+	}
+	g.Wait()
+
+	count := int(started.Load())
+	if notStarted := n - count; notStarted > 0 {
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("transfer cancelled: %d file(s) not started", notStarted))
+		} else {
+			errs = append(errs, fmt.Errorf("stopped after first error (fail-fast): %d file(s) not started", notStarted))
+		}
+	}
+	return count, errs
+}
+
+// runWithTimeout 在 timeout 内执行 fn；timeout <= 0 表示不限时
+func runWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	taskCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(taskCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(taskCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunTaskPoolCollectsAllErrors(t *testing.T) {
+	var ran atomic.Int32
+	started, errs := runTaskPool(context.Background(), 6, &TransferOptions{Concurrency: 2}, func(ctx context.Context, i int) error {
+		ran.Add(1)
+		if i%2 == 0 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if started != 6 || ran.Load() != 6 {
+		t.Fatalf("started = %d, ran = %d, want 6", started, ran.Load())
+	}
+	if len(errs) != 3 {
+		t.Fatalf("errs = %v, want 3 errors", errs)
+	}
+}
+
+func TestRunTaskPoolFailFast(t *testing.T) {
+	opts := &TransferOptions{Concurrency: 2, FailFast: true}
+	started, errs := runTaskPool(context.Background(), 10, opts, func(ctx context.Context, i int) error {
+		if i == 0 {
+			return errors.New("boom")
+		}
+		// 其余任务一直运行到被取消
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if started >= 10 {
+		t.Fatalf("started = %d, want remaining tasks skipped", started)
+	}
+	// 只报告真正的失败和未启动的任务，被中断的任务不单独报告
+	if len(errs) != 2 || errs[0].Error() != "boom" || !strings.Contains(errs[1].Error(), "fail-fast") {
+		t.Fatalf("errs = %v", errs)
+	}
+}
+
+func TestRunTaskPoolCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started, errs := runTaskPool(ctx, 5, &TransferOptions{Concurrency: 1}, func(ctx context.Context, i int) error {
+		cancel()
+		return ctx.Err()
+	})
+	if started != 1 {
+		t.Fatalf("started = %d, want 1", started)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], context.Canceled) || !strings.Contains(errs[1].Error(), "transfer cancelled: 4 file(s)") {
+		t.Fatalf("errs = %v", errs)
+	}
+}
+
+func TestRunTaskPoolTimeout(t *testing.T) {
+	opts := &TransferOptions{Concurrency: 2, TaskTimeout: 20 * time.Millisecond}
+	_, errs := runTaskPool(context.Background(), 2, opts, func(ctx context.Context, i int) error {
+		if i == 1 {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) || !strings.Contains(errs[0].Error(), "timed out after 20ms") {
+		t.Fatalf("errs = %v", errs)
+	}
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"fmt"
+	"os"
+)
+
+// preserveAttributes 将源文件的修改时间和权限位复制到传输目标（scp/sftp -p 语义）
+func (c *Client) preserveAttributes(task transferTask) error {
+	src, err := c.statTaskSource(task)
+	if err != nil {
+		return fmt.Errorf("stat source: %w", err)
+	}
+	perm := src.Mode().Perm()
+	if task.isUpload {
+		err = c.sftpConn().Chmod(task.remotePath, perm)
+	} else {
+		err = os.Chmod(task.localPath, perm)
+	}
+	if err != nil {
+		return fmt.Errorf("chmod: %w", err)
+	}
+	if err := c.setTargetMtime(task, src.ModTime()); err != nil {
+		return fmt.Errorf("chtimes: %w", err)
+	}
+	return nil
+}
+
+// PreserveAttributes 对单个已传输的文件保留源的修改时间和权限位（用于不经过批量传输的路径，如 --name）
+func (c *Client) PreserveAttributes(localPath, remotePath string, isUpload bool) error {
+	return c.preserveAttributes(transferTask{
+		localPath:  c.ResolveLocalPath(localPath),
+		remotePath: c.ResolveRemotePath(remotePath),
+		isUpload:   isUpload,
+	})
+}
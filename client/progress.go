@@ -0,0 +1,194 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+
+	"github.com/frostime/my-sftp/units"
+)
+
+// ProgressMode 传输进度的显示方式
+type ProgressMode int
+
+const (
+	// ProgressBars 用回车符原地刷新的进度条（默认，适合终端）
+	ProgressBars ProgressMode = iota
+	// ProgressLog 定期打印单行状态，不含控制字符（适合管道、批处理日志）
+	ProgressLog
+	// ProgressNone 不显示进度（进度由 --progress-fd 等事件订阅者输出）
+	ProgressNone
+)
+
+// DefaultProgressEvery log 模式默认每 5 秒打印一次状态
+var DefaultProgressEvery = ProgressEvery{Interval: 5 * time.Second}
+
+// progressPollInterval log 模式检查进度的间隔
+const progressPollInterval = 200 * time.Millisecond
+
+// ProgressEvery log 模式的打印频率：每隔 Interval 或每前进 Percent 个百分点打印一行
+type ProgressEvery struct {
+	Interval time.Duration
+	Percent  int
+}
+
+func (e ProgressEvery) String() string {
+	if e.Percent > 0 {
+		return fmt.Sprintf("%d%%", e.Percent)
+	}
+	return e.Interval.String()
+}
+
+// ParseProgressEvery 解析打印频率："5s"、"1m" 等时长，或 "10%" 这样的百分比
+func ParseProgressEvery(s string) (ProgressEvery, error) {
+	s = strings.TrimSpace(s)
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		n, err := strconv.Atoi(pct)
+		if err != nil || n <= 0 || n > 100 {
+			return ProgressEvery{}, fmt.Errorf("invalid percent %q (want 1%%-100%%)", s)
+		}
+		return ProgressEvery{Percent: n}, nil
+	}
+	d, err := units.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return ProgressEvery{}, fmt.Errorf("invalid interval %q (e.g. 5s, 1m or 10%%)", s)
+	}
+	return ProgressEvery{Interval: d}, nil
+}
+
+// SetProgressMode 设置进度显示方式；every 只在 ProgressLog 模式下使用
+func (c *Client) SetProgressMode(mode ProgressMode, every ProgressEvery) {
+	c.progressMode = mode
+	c.progressEvery = every
+}
+
+// ClearLine 返回打印前清除进度条所在行的前缀；log 和 none 模式下没有进度条，返回空串
+func (c *Client) ClearLine() string {
+	if c.progressMode != ProgressBars {
+		return ""
+	}
+	return "\r\033[K"
+}
+
+// progressDisplay 一次传输的进度显示。log 模式下进度条本身不输出，
+// 由后台 goroutine 读取其状态定期打印单行日志
+type progressDisplay struct {
+	bar     *progressbar.ProgressBar
+	done    chan struct{}
+	stopped chan struct{}
+	silent  bool // none 模式：进度条不输出，结束时也不换行
+}
+
+// startProgress 按当前显示方式创建进度条
+func (c *Client) startProgress(total int64, description string, clearOnFinish bool) *progressDisplay {
+	opts := []progressbar.Option{
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionUseIECUnits(units.CurrentStyle() == units.Binary), // 与 units.FormatSize 的换算一致
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionSetPredictTime(true),
+	}
+	if c.progressMode == ProgressNone {
+		opts = append(opts, progressbar.OptionSetWriter(io.Discard))
+		return &progressDisplay{bar: progressbar.NewOptions64(total, opts...), silent: true}
+	}
+	if c.progressMode != ProgressLog {
+		if clearOnFinish {
+			opts = append(opts, progressbar.OptionClearOnFinish())
+		}
+		return &progressDisplay{bar: progressbar.NewOptions64(total, opts...)}
+	}
+
+	opts = append(opts, progressbar.OptionSetWriter(io.Discard))
+	p := &progressDisplay{
+		bar:     progressbar.NewOptions64(total, opts...),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	every := c.progressEvery
+	if every.Interval <= 0 && every.Percent <= 0 {
+		every = DefaultProgressEvery
+	}
+	go p.log(every)
+	return p
+}
+
+// finish 结束进度显示；log 模式下等待日志 goroutine 退出，避免其输出夹在完成信息之后
+func (p *progressDisplay) finish() {
+	p.bar.Finish()
+	if p.silent {
+		return
+	}
+	if p.done == nil {
+		fmt.Println() // 换行
+		return
+	}
+	close(p.done)
+	<-p.stopped
+}
+
+func (p *progressDisplay) log(every ProgressEvery) {
+	defer close(p.stopped)
+	poll := progressPollInterval
+	if every.Interval > 0 && every.Interval < poll {
+		poll = every.Interval
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	last := time.Now()
+	lastPercent := 0
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+		state := p.bar.State()
+		percent := progressPercent(state)
+		if every.Interval > 0 && time.Since(last) < every.Interval {
+			continue
+		}
+		if every.Percent > 0 && percent < lastPercent+every.Percent {
+			continue
+		}
+		fmt.Println(formatProgressLine(state))
+		last = time.Now()
+		if every.Percent > 0 {
+			lastPercent = percent - percent%every.Percent
+		}
+	}
+}
+
+// progressPercent 返回 0-100 的整数百分比（总量未知时为 0）
+func progressPercent(state progressbar.State) int {
+	if state.Max <= 0 || math.IsNaN(state.CurrentPercent) {
+		return 0
+	}
+	return int(state.CurrentPercent * 100)
+}
+
+// formatProgressLine 生成 log 模式的状态行，如
+// "Downloading a.iso (1/1 files): 37% (45.2 MB/120.0 MB), 12.3 MB/s, ETA 10s"
+func formatProgressLine(state progressbar.State) string {
+	var b strings.Builder
+	b.WriteString(state.Description)
+	b.WriteString(": ")
+	if state.Max > 0 {
+		fmt.Fprintf(&b, "%d%% (%s/%s)", progressPercent(state), units.FormatSize(state.CurrentNum), units.FormatSize(state.Max))
+	} else {
+		b.WriteString(units.FormatSize(state.CurrentNum))
+	}
+	if state.SecondsSince > 0 && state.KBsPerSecond > 0 && !math.IsInf(state.KBsPerSecond, 0) {
+		fmt.Fprintf(&b, ", %s/s", units.FormatSize(int64(state.KBsPerSecond*1024)))
+	}
+	if state.SecondsLeft > 0 {
+		fmt.Fprintf(&b, ", ETA %s", (time.Duration(state.SecondsLeft * float64(time.Second))).Round(time.Second))
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+func TestParseProgressEvery(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ProgressEvery
+		wantErr bool
+	}{
+		{in: "5s", want: ProgressEvery{Interval: 5 * time.Second}},
+		{in: "1m", want: ProgressEvery{Interval: time.Minute}},
+		{in: "10%", want: ProgressEvery{Percent: 10}},
+		{in: "0%", wantErr: true},
+		{in: "150%", wantErr: true},
+		{in: "0s", wantErr: true},
+		{in: "often", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseProgressEvery(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseProgressEvery(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseProgressEvery(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatProgressLine(t *testing.T) {
+	state := progressbar.State{
+		Max:            4 << 20,
+		CurrentNum:     1 << 20,
+		CurrentPercent: 0.25,
+		SecondsSince:   2,
+		SecondsLeft:    6,
+		KBsPerSecond:   512,
+		Description:    "Downloading a.iso (1/1 files)",
+	}
+	want := "Downloading a.iso (1/1 files): 25% (1.0 MB/4.0 MB), 512.0 KB/s, ETA 6s"
+	if got := formatProgressLine(state); got != want {
+		t.Errorf("formatProgressLine() = %q, want %q", got, want)
+	}
+
+	// 尚未开始：没有速率和剩余时间
+	empty := progressbar.State{Max: 0, Description: "Uploading x"}
+	if got := formatProgressLine(empty); got != "Uploading x: 0 B" {
+		t.Errorf("formatProgressLine(empty) = %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectLink 本地目录与远程目录的映射（project 模式）：映射内的文件在两端保持相同的相对位置
+type ProjectLink struct {
+	Local  string // 本地根目录（已解析的绝对路径）
+	Remote string // 远程根目录（已解析的绝对路径）
+}
+
+// LinkProject 解析 localDir 和 remoteDir 并创建映射。本地目录必须存在；
+// 远程目录可以尚不存在（第一次 put/sync 时创建），但不能是文件。
+// 远程路径相对于 SFTP 的工作目录解析，所以先等待 SFTP 就绪；exec-only 模式下返回 ErrExecOnly
+func (c *Client) LinkProject(localDir, remoteDir string) (*ProjectLink, error) {
+	if err := c.WaitReady(); err != nil {
+		return nil, err
+	}
+	link := &ProjectLink{
+		Local:  c.ResolveLocalPath(localDir),
+		Remote: c.ResolveRemotePath(remoteDir),
+	}
+	info, err := os.Stat(link.Local)
+	if err != nil {
+		return nil, fmt.Errorf("local project dir: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("local project dir is not a directory: %s", link.Local)
+	}
+	if info, err := c.sftpConn().Stat(link.Remote); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("remote project dir is not a directory: %s", link.Remote)
+	}
+	return link, nil
+}
+
+// ProjectUploadTarget 返回 put source 在映射下的远程目标目录：文件对应其所在目录，
+// 目录（-r）对应其自身。glob 传输会在目标下保留模式的目录前缀（src/*.py -> <目标>/src/），
+// 所以 glob 对应本地工作目录，且只支持不以 .. 开头的相对模式。source 不在本地根目录内时返回 false
+func (c *Client) ProjectUploadTarget(link *ProjectLink, source string) (string, bool) {
+	resolved := c.ResolveLocalPath(source)
+	anchor := filepath.Dir(resolved)
+	if strings.ContainsAny(source, "*?[]") {
+		if !relativeGlob(filepath.ToSlash(source)) {
+			return "", false
+		}
+		anchor = c.localWorkDir
+	} else if info, err := os.Stat(resolved); err == nil && info.IsDir() {
+		anchor = resolved
+	}
+	rel, err := filepath.Rel(link.Local, anchor)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return path.Join(link.Remote, filepath.ToSlash(rel)), true
+}
+
+// ProjectDownloadTarget 返回 get source 在映射下的本地目标目录，规则同 ProjectUploadTarget
+func (c *Client) ProjectDownloadTarget(link *ProjectLink, source string) (string, bool) {
+	resolved := c.ResolveRemotePath(source)
+	anchor := path.Dir(resolved)
+	if strings.ContainsAny(source, "*?[]") {
+		if !relativeGlob(source) {
+			return "", false
+		}
+		anchor = c.Getwd()
+	} else if info, err := c.Stat(resolved); err == nil && info.IsDir() {
+		anchor = resolved
+	}
+	root := path.Clean(link.Remote)
+	switch {
+	case anchor == root:
+		return link.Local, true
+	case strings.HasPrefix(anchor, strings.TrimSuffix(root, "/")+"/"):
+		rel := strings.TrimPrefix(anchor, strings.TrimSuffix(root, "/")+"/")
+		return filepath.Join(link.Local, filepath.FromSlash(rel)), true
+	}
+	return "", false
+}
+
+// relativeGlob 判断 glob 模式是否为不以 .. 或 ~ 开头的相对路径（/ 分隔）
+func relativeGlob(pattern string) bool {
+	if path.IsAbs(pattern) || filepath.IsAbs(pattern) || strings.HasPrefix(pattern, "~") {
+		return false
+	}
+	cleaned := path.Clean(pattern)
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
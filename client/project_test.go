@@ -0,0 +1,21 @@
+package client
+
+import "testing"
+
+func TestRelativeGlob(t *testing.T) {
+	tests := map[string]bool{
+		"*.py":          true,
+		"src/**/*.go":   true,
+		"./src/*.py":    true,
+		"src/../*.py":   true,
+		"../*.py":       false,
+		"..":            false,
+		"/srv/www/*.js": false,
+		"~/logs/*.log":  false,
+	}
+	for pattern, want := range tests {
+		if got := relativeGlob(pattern); got != want {
+			t.Errorf("relativeGlob(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
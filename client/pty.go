@@ -0,0 +1,85 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/frostime/my-sftp/ttystate"
+	"golang.org/x/crypto/ssh"
+	terminal "golang.org/x/term"
+)
+
+// InteractiveShell 在现有连接上打开带 PTY 的交互式登录 shell，起始目录为远程工作目录。
+// 本地终端在会话期间处于 raw 模式，窗口大小变化会同步到远程；远程 shell 退出后返回，
+// 退出状态非零时返回 *RemoteExitError。远程输出写到 out；tty 是真正的终端，
+// 用于检查终端和窗口大小（会话录制时 out 是管道）
+func (c *Client) InteractiveShell(in, tty *os.File, out io.Writer) error {
+	inFd, outFd := int(in.Fd()), int(tty.Fd())
+	if !terminal.IsTerminal(inFd) || !terminal.IsTerminal(outFd) {
+		return errors.New("interactive shell requires a terminal")
+	}
+	width, height, err := terminal.GetSize(outFd)
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	session, err := c.sshConn().NewSession()
+	if err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+	defer session.Close()
+
+	term := os.Getenv("TERM")
+	if term == "" {
+		term = "xterm-256color"
+	}
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(term, height, width, modes); err != nil {
+		return fmt.Errorf("request pty: %w", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	session.Stdout = out
+	session.Stderr = out
+
+	// 在远程工作目录启动用户的登录 shell；exec-only 模式下工作目录为空，直接使用登录目录
+	if err := session.Start(remoteCommand(c.Getwd(), `exec "${SHELL:-/bin/sh}" -l`)); err != nil {
+		return fmt.Errorf("start shell: %w", err)
+	}
+
+	restore, err := ttystate.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("raw mode: %w", err)
+	}
+	defer restore()
+
+	done := make(chan struct{})
+	defer close(done)
+	go watchWindowSize(outFd, session, done)
+	go func() {
+		defer ttystate.Guard()
+		copyTerminalInput(in, stdin, done)
+		stdin.Close()
+	}()
+
+	err = session.Wait()
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return &RemoteExitError{Status: exitErr.ExitStatus(), Signal: exitErr.Signal()}
+	}
+	var missing *ssh.ExitMissingError
+	if errors.As(err, &missing) {
+		// 连接断开或服务器未报告退出状态
+		return errors.New("remote shell ended without an exit status")
+	}
+	return err
+}
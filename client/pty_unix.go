@@ -0,0 +1,64 @@
+//go:build !windows
+
+package client
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sys/unix"
+	terminal "golang.org/x/term"
+)
+
+// watchWindowSize 收到 SIGWINCH 时把终端大小发送给远程会话，直到 done 关闭
+func watchWindowSize(fd int, session *ssh.Session, done <-chan struct{}) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case <-done:
+			return
+		case <-sig:
+			if width, height, err := terminal.GetSize(fd); err == nil {
+				session.WindowChange(height, width)
+			}
+		}
+	}
+}
+
+// copyTerminalInput 把终端输入转发给远程会话，直到 done 关闭或出错。
+// 读取前先 poll，会话结束后不会留下阻塞在 stdin 上的读取（否则会吞掉回到提示符后的第一次按键）
+func copyTerminalInput(in *os.File, w io.Writer, done <-chan struct{}) {
+	fds := []unix.PollFd{{Fd: int32(in.Fd()), Events: unix.POLLIN}}
+	buf := make([]byte, 4096)
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		n, err := unix.Poll(fds, 100)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+		n, err = in.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
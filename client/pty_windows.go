@@ -0,0 +1,37 @@
+//go:build windows
+
+package client
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	terminal "golang.org/x/term"
+)
+
+// watchWindowSize Windows 没有 SIGWINCH，定期检查终端大小，变化时发送给远程会话
+func watchWindowSize(fd int, session *ssh.Session, done <-chan struct{}) {
+	width, height, _ := terminal.GetSize(fd)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			w, h, err := terminal.GetSize(fd)
+			if err == nil && (w != width || h != height) {
+				width, height = w, h
+				session.WindowChange(height, width)
+			}
+		}
+	}
+}
+
+// copyTerminalInput 把终端输入转发给远程会话。Windows 控制台无法 poll，
+// 会话结束后的那次读取会在下一次按键时返回并被丢弃
+func copyTerminalInput(in *os.File, w io.Writer, done <-chan struct{}) {
+	io.Copy(w, in)
+}
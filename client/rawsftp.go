@@ -0,0 +1,177 @@
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// errSFTPUnsupported 服务器对请求返回 OP_UNSUPPORTED
+var errSFTPUnsupported = errors.New("operation not supported by server")
+
+// SFTP v3 打开文件的 pflags
+const (
+	sshFxfRead  = 0x01
+	sshFxfWrite = 0x02
+	sshFxfCreat = 0x08
+	sshFxfTrunc = 0x10
+)
+
+// rawSFTP 最小的 SFTP v3 通道，只用于 pkg/sftp 没有提供的扩展请求（copy-data、copy-file）。
+// 请求串行发送，一次只有一个请求在途
+type rawSFTP struct {
+	mu     sync.Mutex
+	r      io.Reader
+	w      io.WriteCloser
+	nextID uint32
+}
+
+// rawChannel 返回复用的 rawSFTP 通道，第一次调用时打开
+func (c *Client) rawChannel() (*rawSFTP, error) {
+	c.rawMu.Lock()
+	defer c.rawMu.Unlock()
+	if c.raw != nil {
+		return c.raw, nil
+	}
+	rd, wr, err := c.openSFTPChannel()
+	if err != nil {
+		return nil, err
+	}
+	raw := &rawSFTP{r: rd, w: wr}
+	if _, err := wr.Write(sftpPacket(sshFxpInit, uint32(3))); err != nil {
+		wr.Close()
+		return nil, err
+	}
+	typ, _, err := raw.readPacket()
+	if err == nil && typ != sshFxpVersion {
+		err = fmt.Errorf("unexpected %s in reply to INIT", tracePacketType(typ))
+	}
+	if err != nil {
+		wr.Close()
+		return nil, err
+	}
+	c.raw = raw
+	return raw, nil
+}
+
+// request 发送一个请求并等待响应，返回响应类型和 ID 之后的内容
+func (s *rawSFTP) request(typ byte, fields ...any) (byte, traceBuf, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := s.nextID
+	if _, err := s.w.Write(sftpPacket(typ, append([]any{id}, fields...)...)); err != nil {
+		return 0, nil, err
+	}
+	respType, payload, err := s.readPacket()
+	if err != nil {
+		return 0, nil, err
+	}
+	if respID, ok := payload.uint32(); !ok || respID != id {
+		return 0, nil, fmt.Errorf("unexpected reply id %d (want %d)", respID, id)
+	}
+	return respType, payload, nil
+}
+
+func (s *rawSFTP) readPacket() (byte, traceBuf, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	pkt := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(s.r, pkt); err != nil {
+		return 0, nil, err
+	}
+	if len(pkt) == 0 {
+		return 0, nil, errors.New("empty SFTP packet")
+	}
+	return pkt[0], traceBuf(pkt[1:]), nil
+}
+
+// status 把 STATUS 响应转换为错误，OK 时返回 nil
+func (s *rawSFTP) status(typ byte, payload traceBuf) error {
+	if typ != sshFxpStatus {
+		return fmt.Errorf("unexpected %s reply", tracePacketType(typ))
+	}
+	code, _ := payload.uint32()
+	msg, _ := payload.string()
+	switch code {
+	case 0:
+		return nil
+	case 2:
+		return fmt.Errorf("%s: %w", msg, os.ErrNotExist)
+	case 3:
+		return fmt.Errorf("%s: %w", msg, os.ErrPermission)
+	case 8:
+		return errSFTPUnsupported
+	}
+	if msg == "" {
+		msg = traceStatus(code)
+	}
+	return errors.New(msg)
+}
+
+// open 打开远程文件，返回句柄
+func (s *rawSFTP) open(path string, pflags uint32) (string, error) {
+	typ, payload, err := s.request(sshFxpOpen, path, pflags, uint32(0))
+	if err != nil {
+		return "", err
+	}
+	if typ != sshFxpHandle {
+		return "", s.status(typ, payload)
+	}
+	handle, ok := payload.string()
+	if !ok {
+		return "", errors.New("malformed HANDLE reply")
+	}
+	return handle, nil
+}
+
+func (s *rawSFTP) close(handle string) error {
+	typ, payload, err := s.request(sshFxpClose, handle)
+	if err != nil {
+		return err
+	}
+	return s.status(typ, payload)
+}
+
+// extended 发送 EXTENDED 请求，期望 STATUS 响应
+func (s *rawSFTP) extended(name string, fields ...any) error {
+	typ, payload, err := s.request(sshFxpExtended, append([]any{name}, fields...)...)
+	if err != nil {
+		return err
+	}
+	return s.status(typ, payload)
+}
+
+func (s *rawSFTP) Close() error {
+	return s.w.Close()
+}
+
+// sftpPacket 按 SFTP 线格式拼装数据包：uint32/uint64 为大端整数，string 为长度 + 内容，bool 为 1 字节
+func sftpPacket(typ byte, fields ...any) []byte {
+	payload := []byte{typ}
+	for _, f := range fields {
+		switch v := f.(type) {
+		case uint32:
+			payload = binary.BigEndian.AppendUint32(payload, v)
+		case uint64:
+			payload = binary.BigEndian.AppendUint64(payload, v)
+		case string:
+			payload = binary.BigEndian.AppendUint32(payload, uint32(len(v)))
+			payload = append(payload, v...)
+		case bool:
+			b := byte(0)
+			if v {
+				b = 1
+			}
+			payload = append(payload, b)
+		default:
+			panic(fmt.Sprintf("sftpPacket: unsupported field type %T", f))
+		}
+	}
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(payload))), payload...)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// fakeRawServer 读取一个请求，检查内容后回复 reply
+func fakeRawServer(t *testing.T, want []byte, reply func(id uint32) []byte) *rawSFTP {
+	t.Helper()
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	go func() {
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(reqR, got); err != nil {
+			return
+		}
+		if string(got) != string(want) {
+			t.Errorf("request = %x, want %x", got, want)
+		}
+		respW.Write(reply(1))
+	}()
+	return &rawSFTP{r: respR, w: reqW}
+}
+
+func TestRawSFTPExtended(t *testing.T) {
+	want := sftpPacket(sshFxpExtended, uint32(1), "copy-data", "h1", uint64(0), uint64(0), "h2", uint64(0))
+	raw := fakeRawServer(t, want, func(id uint32) []byte {
+		return sftpPacket(sshFxpStatus, id, uint32(0), "", "")
+	})
+	if err := raw.extended("copy-data", "h1", uint64(0), uint64(0), "h2", uint64(0)); err != nil {
+		t.Fatalf("extended() error = %v", err)
+	}
+}
+
+func TestRawSFTPStatusErrors(t *testing.T) {
+	tests := []struct {
+		code uint32
+		want error
+	}{
+		{code: 2, want: os.ErrNotExist},
+		{code: 3, want: os.ErrPermission},
+		{code: 8, want: errSFTPUnsupported},
+	}
+	for _, tt := range tests {
+		want := sftpPacket(sshFxpExtended, uint32(1), "copy-file", "/a", "/b", true)
+		raw := fakeRawServer(t, want, func(id uint32) []byte {
+			return sftpPacket(sshFxpStatus, id, tt.code, "nope", "")
+		})
+		if err := raw.extended("copy-file", "/a", "/b", true); !errors.Is(err, tt.want) {
+			t.Errorf("status %d: err = %v, want %v", tt.code, err, tt.want)
+		}
+	}
+}
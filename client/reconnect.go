@@ -0,0 +1,121 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Dialer 用与首次连接相同的地址、认证方式和主机密钥校验重新建立 SSH 连接
+type Dialer func() (*ssh.Client, error)
+
+// reconnectDelays 重连失败后的等待时间，依次重试
+var reconnectDelays = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+
+// ErrNoReconnect 没有设置 WithReconnect，连接断开后不能重连
+var ErrNoReconnect = errors.New("reconnect not available")
+
+// WithReconnect 允许连接断开后用 dial 重新连接，见 Reconnect
+func WithReconnect(dial Dialer) ClientOption {
+	return func(c *Client) {
+		c.redial = dial
+	}
+}
+
+// CanReconnect 报告连接断开后能否重连
+func (c *Client) CanReconnect() bool {
+	return c.redial != nil
+}
+
+// Reconnect 连接断开后重新建立 SSH 连接和 SFTP 会话，并恢复远程工作目录（本地工作目录不受影响）。
+// 失败时按 reconnectDelays 等待后重试；attempt 在每次尝试前调用，可为 nil。
+// 调用时不能有进行中的传输
+func (c *Client) Reconnect(attempt func(n int)) error {
+	if c.redial == nil {
+		return ErrNoReconnect
+	}
+	c.dropConnection()
+
+	var sshClient *ssh.Client
+	var err error
+	for n := 0; ; n++ {
+		if attempt != nil {
+			attempt(n + 1)
+		}
+		if sshClient, err = c.redial(); err == nil {
+			break
+		}
+		if n == len(reconnectDelays) {
+			return fmt.Errorf("reconnect failed after %d attempts: %w", n+1, err)
+		}
+		time.Sleep(reconnectDelays[n])
+	}
+
+	c.sshClient.Store(sshClient)
+	c.lostMu.Lock()
+	c.lostErr = nil
+	c.lostMu.Unlock()
+	c.lost.Store(false)
+	c.watchConnection(sshClient)
+
+	if errors.Is(c.readyErr, ErrExecOnly) {
+		return nil
+	}
+	if _, err := c.openMainSFTP(false); err != nil {
+		sshClient.Close()
+		return fmt.Errorf("reconnect: sftp client: %w", err)
+	}
+	c.ClearDirCache()
+	// 远程工作目录可能在断开期间被删除，此时回到主目录
+	if lost, err := c.EnsureWorkDir(); err == nil && lost != "" {
+		fmt.Printf("⚠ Remote working directory %s no longer exists; switched to %s\n", lost, c.Getwd())
+	}
+	return nil
+}
+
+// aliveTimeout Alive 等待服务器响应的时间
+const aliveTimeout = 5 * time.Second
+
+// Alive 发送一个保活请求，检查 SSH 连接是否仍然可用（用于判断命令失败是否由断线引起）
+func (c *Client) Alive() bool {
+	if c.lost.Load() {
+		return false
+	}
+	sshClient := c.sshConn()
+	reply := make(chan error, 1)
+	go func() {
+		_, _, err := sshClient.SendRequest("keepalive@openssh.com", true, nil)
+		reply <- err
+	}()
+	select {
+	case err := <-reply:
+		return err == nil
+	case <-time.After(aliveTimeout):
+		return false
+	}
+}
+
+// dropConnection 关闭已断开的连接上的所有通道，重连后按需重新打开
+func (c *Client) dropConnection() {
+	c.rawMu.Lock()
+	if c.raw != nil {
+		c.raw.Close()
+		c.raw = nil
+	}
+	c.rawMu.Unlock()
+	c.bgMu.Lock()
+	if c.bgSFTP != nil {
+		c.bgSFTP.Close()
+	}
+	c.bgSFTP, c.bgOpened = nil, false
+	c.bgMu.Unlock()
+	c.closeChannels()
+	if c.sftpConn() != nil {
+		c.sftpConn().Close()
+	}
+	// 先让旧连接的 watcher 失效，关闭旧连接不再算作断开
+	c.connGen.Add(1)
+	c.sshConn().Close()
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestReconnectWithoutDialer(t *testing.T) {
+	c := &Client{}
+	if c.CanReconnect() {
+		t.Fatal("CanReconnect() = true without WithReconnect")
+	}
+	if err := c.Reconnect(nil); !errors.Is(err, ErrNoReconnect) {
+		t.Fatalf("Reconnect() = %v, want ErrNoReconnect", err)
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	// 接受连接却从不响应的服务器
+	conn, server := net.Pipe()
+	defer server.Close()
+	go io.Copy(io.Discard, server)
+
+	config := &ssh.ClientConfig{User: "u", HostKeyCallback: ssh.InsecureIgnoreHostKey(), Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	_, err := Handshake(conn, "example.com:22", config)
+	if err == nil || !strings.Contains(err.Error(), "no response from example.com:22 within 50ms") {
+		t.Fatalf("Handshake() = %v, want a timeout error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Handshake() took %s", elapsed)
+	}
+}
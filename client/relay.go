@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// RelayOptions 服务器之间传输（Relay）的选项
+type RelayOptions struct {
+	Recursive    bool // 递归复制目录
+	ShowProgress bool // 显示进度条
+	Concurrency  int  // 并发数，0 表示客户端默认值
+	Preserve     bool // 保留源文件的修改时间和权限位（-p）
+	FailFast     bool // 任一文件失败即中断，见 TransferOptions.FailFast
+}
+
+// RelayResult 服务器之间传输的结果
+type RelayResult struct {
+	Files   int      // 成功复制的文件数
+	Bytes   int64    // 成功复制的字节数
+	Skipped []string // 跳过的特殊文件（符号链接、FIFO 等，源服务器上的路径）
+}
+
+// relayTask 从源服务器的 src 复制到目标服务器的 dst
+type relayTask struct {
+	src, dst string
+	size     int64
+	mode     os.FileMode
+	mtime    time.Time
+}
+
+// Relay 把源服务器 from 上的 sources（路径或 glob）经本机流式复制到本客户端所在服务器的 target，
+// 不在本地保存副本。多个 source、glob、-r 或 target 是已存在的目录、以 / 结尾时复制到 target 目录下，
+// 否则单个文件复制为 target 本身。文件分散到两端的多个 SFTP 通道上并发传输
+func (c *Client) Relay(from *Client, sources []string, target string, opts *RelayOptions) (*RelayResult, error) {
+	if opts == nil {
+		opts = &RelayOptions{ShowProgress: true}
+	}
+	tasks, dirs, skipped, err := c.collectRelayTasks(from, sources, target, opts.Recursive)
+	if err != nil {
+		return nil, err
+	}
+	result := &RelayResult{Skipped: skipped}
+	for _, t := range tasks {
+		dirs = append(dirs, path.Dir(t.dst))
+	}
+	if err := c.ensureRemoteDirsExist(dirs); err != nil {
+		return result, fmt.Errorf("create remote dirs: %w", err)
+	}
+	if len(tasks) == 0 {
+		return result, nil
+	}
+	defer c.trackTransfer(true)()
+	defer from.trackTransfer(false)()
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = c.Concurrency()
+	}
+	// 两端的句柄上限都要满足
+	concurrency, _ = c.reportedLimits().clampTransfers(concurrency, 1)
+	concurrency, _ = from.reportedLimits().clampTransfers(concurrency, 1)
+
+	totalBytes := int64(0)
+	for _, t := range tasks {
+		totalBytes += t.size
+	}
+	var display *progressDisplay
+	var bar *progressbar.ProgressBar
+	if opts.ShowProgress {
+		display = c.startProgress(totalBytes, fmt.Sprintf("Copying (0/%d files)", len(tasks)), true)
+		bar = display.bar
+	}
+
+	var files atomic.Int32
+	var copied atomic.Int64
+	poolOpts := &TransferOptions{Concurrency: concurrency, FailFast: opts.FailFast}
+	_, errs := runTaskPool(c.transferContext(), len(tasks), poolOpts, func(ctx context.Context, i int) error {
+		t, index := tasks[i], i+1
+		c.emit(relayEvent(EventTransferStarted, t, index, len(tasks)))
+		progressEvent := relayEvent(EventProgress, t, index, len(tasks))
+		if bar != nil {
+			bar.Describe(fmt.Sprintf("Copying %s (%d/%d files)", path.Base(t.src), files.Load(), len(tasks)))
+		}
+		if err := c.relayFile(ctx, from, t, opts.Preserve, c.newTransferProgress(bar, &progressEvent)); err != nil {
+			ev := relayEvent(EventError, t, index, len(tasks))
+			ev.Err = err
+			c.emit(ev)
+			return &fileError{op: "copy", path: t.src, target: t.dst, err: err}
+		}
+		count := files.Add(1)
+		copied.Add(t.size)
+		ev := relayEvent(EventCompleted, t, index, len(tasks))
+		ev.Bytes = t.size
+		c.emit(ev)
+		if bar != nil {
+			bar.Describe(fmt.Sprintf("Copying (%d/%d files)", count, len(tasks)))
+		}
+		return nil
+	})
+	if display != nil {
+		display.finish()
+	}
+
+	result.Files, result.Bytes = int(files.Load()), copied.Load()
+	if len(errs) > 0 {
+		return result, joinFileErrors(errs)
+	}
+	return result, nil
+}
+
+// collectRelayTasks 在源服务器上展开 sources，返回文件任务、需要在目标端创建的目录（含空目录）和跳过的特殊文件
+func (c *Client) collectRelayTasks(from *Client, sources []string, target string, recursive bool) ([]relayTask, []string, []string, error) {
+	toDir := len(sources) > 1 || recursive || slices.ContainsFunc(sources, isRemoteGlob) || strings.HasSuffix(target, "/")
+	target = c.ResolveRemotePath(target)
+	if !toDir {
+		if info, err := c.sftpConn().Stat(target); err == nil && info.IsDir() {
+			toDir = true
+		}
+	}
+
+	var paths []string
+	for _, source := range sources {
+		resolved := from.ResolveRemotePath(source)
+		if !isRemoteGlob(source) {
+			paths = append(paths, resolved)
+			continue
+		}
+		matches, err := from.sftpConn().Glob(resolved)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("glob %s: %w", source, err)
+		}
+		if len(matches) == 0 {
+			return nil, nil, nil, fmt.Errorf("no match: %s", source)
+		}
+		paths = append(paths, matches...)
+	}
+
+	sameServer := c.sshConn().RemoteAddr().String() == from.sshConn().RemoteAddr().String()
+	var tasks []relayTask
+	var dirs, skipped []string
+	for _, src := range paths {
+		info, err := from.sftpConn().Stat(src)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("stat %s: %w", src, err)
+		}
+		dst := target
+		if toDir {
+			dst = path.Join(target, path.Base(src))
+		}
+		// 同一台服务器上复制到源文件（或源目录）自身会先截断源文件；目录下的文件与之对应，只需比较一次
+		if sameServer && from.canonicalRemotePath(src) == from.canonicalRemotePath(dst) {
+			return nil, nil, nil, fmt.Errorf("%s and %s are the same file", src, dst)
+		}
+		switch {
+		case info.Mode().IsRegular():
+			tasks = append(tasks, newRelayTask(src, dst, info))
+		case !info.IsDir():
+			skipped = append(skipped, src)
+		case !recursive:
+			return nil, nil, nil, fmt.Errorf("%s is a directory (use -r)", src)
+		default:
+			entries, err := from.scanRemoteTree(src)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			dirs = append(dirs, dst)
+			rels := make([]string, 0, len(entries))
+			for rel := range entries {
+				rels = append(rels, rel)
+			}
+			slices.Sort(rels)
+			for _, rel := range rels {
+				entry := entries[rel]
+				switch {
+				case entry.IsDir():
+					dirs = append(dirs, path.Join(dst, rel))
+				case entry.Mode().IsRegular():
+					tasks = append(tasks, newRelayTask(path.Join(src, rel), path.Join(dst, rel), entry))
+				default:
+					skipped = append(skipped, path.Join(src, rel))
+				}
+			}
+		}
+	}
+	return tasks, dirs, skipped, nil
+}
+
+func newRelayTask(src, dst string, info os.FileInfo) relayTask {
+	return relayTask{src: src, dst: dst, size: info.Size(), mode: info.Mode(), mtime: info.ModTime()}
+}
+
+func isRemoteGlob(source string) bool {
+	return strings.ContainsAny(source, "*?[")
+}
+
+// relayFile 复制单个文件：两端各占用一个 SFTP 通道，数据只经过内存缓冲区。
+// 先写入 dst.part，成功后再替换 dst，失败时不会留下截断的目标文件
+func (c *Client) relayFile(ctx context.Context, from *Client, t relayTask, preserve bool, progress *transferProgress) error {
+	srcCtx, releaseSrc := from.acquireChannel(ctx)
+	defer releaseSrc()
+	dstCtx, releaseDst := c.acquireChannel(ctx)
+	defer releaseDst()
+
+	srcFile, err := from.dataClient(srcCtx).Open(t.src)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer srcFile.Close()
+	part := t.dst + ".part"
+	dstFile, err := c.dataClient(dstCtx).Create(part)
+	if err != nil {
+		return fmt.Errorf("create target: %w", err)
+	}
+
+	buf := c.getBuffer()
+	defer c.putBuffer(buf)
+	var writer io.Writer = dstFile
+	if progress != nil {
+		writer = io.MultiWriter(dstFile, progress)
+	}
+	_, err = io.CopyBuffer(writer, &contextReader{ctx: ctx, r: srcFile}, buf)
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && preserve {
+		if err = c.sftpConn().Chtimes(part, t.mtime, t.mtime); err != nil {
+			err = fmt.Errorf("preserve mtime: %w", err)
+		} else if err = c.sftpConn().Chmod(part, t.mode.Perm()); err != nil {
+			err = fmt.Errorf("preserve mode: %w", err)
+		}
+	}
+	if err == nil {
+		err = c.replaceRemoteFile(part, t.dst)
+	}
+	if err != nil {
+		c.sftpConn().Remove(part)
+	}
+	return err
+}
+
+// relayEvent 服务器之间传输的事件：Source 为源服务器上的路径，数据写入本客户端的服务器，视为上传
+func relayEvent(typ EventType, t relayTask, index, count int) Event {
+	return Event{Type: typ, IsUpload: true, Source: t.src, Target: t.dst, Total: t.size, Index: index, Count: count}
+}
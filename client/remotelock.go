@@ -0,0 +1,216 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path"
+	"sync"
+	"time"
+)
+
+// LockFileName 远程目录的咨询锁文件名：多人对同一远程目录执行 sync、stage push 时，
+// 同一时刻只有一个人在写入，避免两次部署的文件交错
+const LockFileName = ".my-sftp.lock"
+
+// lockTTL 锁的有效期：持有者每 lockTTL/4 刷新一次，超过有效期未刷新（进程崩溃、断线）的锁视为过期，可以接管
+var lockTTL = 2 * time.Minute
+
+// RemoteLock 锁文件的内容
+type RemoteLock struct {
+	ID       string    `json:"id"`      // 每次加锁随机生成，释放和接管时用于确认锁没有换人
+	Owner    string    `json:"owner"`   // 本地 用户@主机
+	PID      int       `json:"pid"`     // 本地进程号
+	Command  string    `json:"command"` // 持有锁的命令，如 sync
+	Acquired time.Time `json:"acquired"`
+	Expires  time.Time `json:"expires"`
+}
+
+// LockedError 远程目录已被其他人锁定（锁未过期）
+type LockedError struct {
+	Dir  string
+	Lock RemoteLock
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is locked by %s (pid %d, %s) since %s, expires %s; if that is a leftover, wait for it to expire or remove %s",
+		e.Dir, e.Lock.Owner, e.Lock.PID, e.Lock.Command, e.Lock.Acquired.Local().Format("2006-01-02 15:04"),
+		e.Lock.Expires.Local().Format("2006-01-02 15:04"), path.Join(e.Dir, LockFileName))
+}
+
+// RemoteDirLock 已获取的远程目录锁，持有期间在后台刷新有效期，用完调用 Release
+type RemoteDirLock struct {
+	c         *Client
+	file      string
+	lock      RemoteLock
+	ttl       time.Duration
+	TakenOver *RemoteLock // 接管的过期锁，nil 表示目录原本未锁定
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// LockRemoteDir 在远程目录 dir 中创建锁文件（目录不存在时先创建）。目录已被锁定时返回 *LockedError；
+// 锁已过期时接管它（见 RemoteDirLock.TakenOver）。锁只是约定：不加锁的客户端仍然可以写入
+func (c *Client) LockRemoteDir(dir, command string) (*RemoteDirLock, error) {
+	dir = c.ResolveRemotePath(dir)
+	if err := c.ensureRemoteDir(dir); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+	ttl := lockTTL
+	lock, err := newRemoteLock(command, ttl)
+	if err != nil {
+		return nil, err
+	}
+	l := &RemoteDirLock{c: c, file: path.Join(dir, LockFileName), lock: lock, ttl: ttl, stop: make(chan struct{}), done: make(chan struct{})}
+
+	// 锁过期时接管一次；接管后仍然创建失败说明有人同时抢到了锁
+	for attempt := 0; ; attempt++ {
+		created, err := l.create()
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			break
+		}
+		held, err := c.readRemoteLock(l.file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", l.file, err)
+		}
+		if attempt > 0 || time.Now().Before(held.Expires) || !c.takeOverRemoteLock(l.file, held) {
+			return nil, &LockedError{Dir: dir, Lock: *held}
+		}
+		l.TakenOver = held
+	}
+	go l.refresh()
+	return l, nil
+}
+
+func newRemoteLock(command string, ttl time.Duration) (RemoteLock, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return RemoteLock{}, err
+	}
+	owner := "unknown"
+	if u, err := user.Current(); err == nil {
+		owner = u.Username
+	}
+	host, _ := os.Hostname()
+	now := time.Now().UTC().Truncate(time.Second)
+	return RemoteLock{
+		ID:       hex.EncodeToString(id),
+		Owner:    owner + "@" + host,
+		PID:      os.Getpid(),
+		Command:  command,
+		Acquired: now,
+		Expires:  now.Add(ttl),
+	}, nil
+}
+
+// create 以独占方式创建锁文件；文件已存在时返回 false
+func (l *RemoteDirLock) create() (bool, error) {
+	f, err := l.c.sftpConn().OpenFile(l.file, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		// SFTP v3 没有"文件已存在"的错误码，需要再检查一次
+		if _, statErr := l.c.sftpConn().Lstat(l.file); statErr == nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("create %s: %w", l.file, err)
+	}
+	return true, l.write(f)
+}
+
+func (l *RemoteDirLock) write(f io.WriteCloser) error {
+	data, _ := json.MarshalIndent(l.lock, "", "  ")
+	_, err := f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", l.file, err)
+	}
+	return nil
+}
+
+// readRemoteLock 读取锁文件。内容无法解析时（例如对方刚创建、还没写入）按文件的修改时间估计有效期
+func (c *Client) readRemoteLock(file string) (*RemoteLock, error) {
+	f, err := c.sftpConn().Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lock RemoteLock
+	if data, err := io.ReadAll(io.LimitReader(f, 64*1024)); err != nil {
+		return nil, err
+	} else if json.Unmarshal(data, &lock) == nil && lock.ID != "" {
+		return &lock, nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteLock{Owner: "unknown", Command: "unknown", Acquired: info.ModTime(), Expires: info.ModTime().Add(lockTTL)}, nil
+}
+
+// takeOverRemoteLock 移走过期的锁 stale。先改名再确认改走的仍是 stale，
+// 避免两个客户端同时接管时，后一个删掉前一个刚创建的新锁
+func (c *Client) takeOverRemoteLock(file string, stale *RemoteLock) bool {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	moved := file + ".stale-" + hex.EncodeToString(suffix)
+	if err := c.sftpConn().Rename(file, moved); err != nil {
+		return false
+	}
+	if got, err := c.readRemoteLock(moved); err != nil || got.ID != stale.ID {
+		c.sftpConn().Rename(moved, file)
+		return false
+	}
+	c.sftpConn().Remove(moved)
+	return true
+}
+
+// refresh 定期延长锁的有效期，直到 Release；锁被别人接管（ID 不同）后停止
+func (l *RemoteDirLock) refresh() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.ttl / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		if held, err := l.c.readRemoteLock(l.file); err == nil && held.ID != l.lock.ID {
+			return
+		}
+		l.lock.Expires = time.Now().UTC().Truncate(time.Second).Add(l.ttl)
+		if f, err := l.c.sftpConn().OpenFile(l.file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC); err == nil {
+			l.write(f)
+		}
+	}
+}
+
+// Release 停止刷新并删除锁文件（锁已被别人接管时保留对方的锁）
+func (l *RemoteDirLock) Release() error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	held, err := l.c.readRemoteLock(l.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("release lock: %w", err)
+	}
+	if held.ID != l.lock.ID {
+		return fmt.Errorf("release lock: %s was taken over by %s", l.file, held.Owner)
+	}
+	if err := l.c.sftpConn().Remove(l.file); err != nil {
+		return fmt.Errorf("release lock: %w", err)
+	}
+	return nil
+}
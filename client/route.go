@@ -0,0 +1,111 @@
+package client
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// RouteRule 按文件名或 MIME 类型把上传的文件放到目标根目录下的另一个子目录
+type RouteRule struct {
+	// Match 任一匹配即生效：doublestar 文件名模式（不含 / 时只匹配文件名，含 / 时匹配相对路径），
+	// 或 "mime:<type>"，如 "mime:image/*"、"mime:text/css"
+	Match []string
+	// Dir 目标子目录，相对于传输的目标根目录；以 / 开头时为远程绝对路径
+	Dir string
+}
+
+// Router 按规则顺序为上传文件选择目标目录，第一条匹配的规则生效；nil 表示不路由
+type Router struct {
+	rules []RouteRule
+}
+
+// NewRouter 校验规则并创建 Router；没有规则时返回 nil
+func NewRouter(rules []RouteRule) (*Router, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	for _, rule := range rules {
+		if len(rule.Match) == 0 || rule.Dir == "" {
+			return nil, fmt.Errorf("route rule needs at least one pattern and a directory")
+		}
+		for _, pattern := range rule.Match {
+			if typ, ok := strings.CutPrefix(pattern, "mime:"); ok {
+				if _, err := path.Match(typ, ""); err != nil || !strings.Contains(typ, "/") {
+					return nil, fmt.Errorf("invalid MIME pattern: %q", pattern)
+				}
+				continue
+			}
+			if !doublestar.ValidatePattern(pattern) {
+				return nil, fmt.Errorf("invalid route pattern: %q", pattern)
+			}
+		}
+	}
+	return &Router{rules: rules}, nil
+}
+
+// Route 返回相对路径为 rel（/ 分隔）的本地文件在 root 下的新目标路径；没有规则匹配时 ok 为 false。
+// 路由后的文件直接放在规则目录中（只保留文件名）
+func (r *Router) Route(root, rel, localPath string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	var mimeType string
+	mimeKnown := false
+	for _, rule := range r.rules {
+		for _, pattern := range rule.Match {
+			matched := false
+			if typ, ok := strings.CutPrefix(pattern, "mime:"); ok {
+				if !mimeKnown {
+					mimeType, mimeKnown = detectMIMEType(localPath), true
+				}
+				matched, _ = path.Match(typ, mimeType)
+			} else if strings.Contains(pattern, "/") {
+				matched, _ = doublestar.Match(strings.TrimPrefix(pattern, "/"), rel)
+			} else {
+				matched, _ = doublestar.Match(pattern, path.Base(rel))
+			}
+			if matched {
+				dir := rule.Dir
+				if !path.IsAbs(dir) {
+					dir = path.Join(root, dir)
+				}
+				return path.Join(dir, path.Base(rel)), true
+			}
+		}
+	}
+	return "", false
+}
+
+// detectMIMEType 先按扩展名判断 MIME 类型，未知时读取文件开头嗅探；不含参数（如 charset）
+func detectMIMEType(localPath string) string {
+	typ := mime.TypeByExtension(path.Ext(strings.ReplaceAll(localPath, "\\", "/")))
+	if typ == "" {
+		if f, err := os.Open(localPath); err == nil {
+			buf := make([]byte, 512)
+			n, _ := f.Read(buf)
+			f.Close()
+			typ = http.DetectContentType(buf[:n])
+		}
+	}
+	typ, _, _ = strings.Cut(typ, ";")
+	return strings.TrimSpace(typ)
+}
+
+// applyRoutes 对上传任务应用路由规则；root 为已解析的远程目标根目录
+func (r *Router) applyRoutes(tasks []transferTask, root string) {
+	if r == nil {
+		return
+	}
+	for i, task := range tasks {
+		rel := strings.TrimPrefix(task.remotePath, strings.TrimSuffix(root, "/")+"/")
+		if routed, ok := r.Route(root, rel, task.localPath); ok {
+			tasks[i].remotePath = routed
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRouterRoute(t *testing.T) {
+	dir := t.TempDir()
+	// 没有扩展名的 PNG 只能通过内容嗅探识别
+	noExt := filepath.Join(dir, "logo")
+	if err := os.WriteFile(noExt, []byte("\x89PNG\r\n\x1a\n0000"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	router, err := NewRouter([]RouteRule{
+		{Match: []string{"*.css", "*.js"}, Dir: "assets/static"},
+		{Match: []string{"mime:image/*"}, Dir: "assets/img"},
+		{Match: []string{"docs/**/*.pdf"}, Dir: "/srv/downloads"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		rel, local string
+		want       string
+		ok         bool
+	}{
+		{"css/site.css", "site.css", "/www/assets/static/site.css", true},
+		{"photos/cat.JPG", "cat.JPG", "/www/assets/img/cat.JPG", true},
+		{"img/logo", noExt, "/www/assets/img/logo", true},
+		{"docs/2024/report.pdf", "report.pdf", "/srv/downloads/report.pdf", true},
+		{"report.pdf", "report.pdf", "", false},
+		{"index.html", "index.html", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := router.Route("/www", tt.rel, tt.local)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Route(%q) = %q, %v, want %q, %v", tt.rel, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	for _, rules := range [][]RouteRule{
+		{{Match: []string{"*.css"}}},
+		{{Match: []string{"mime:image"}, Dir: "img"}},
+		{{Match: []string{"[bad"}, Dir: "x"}},
+	} {
+		if _, err := NewRouter(rules); err == nil {
+			t.Errorf("NewRouter(%+v) expected error", rules)
+		}
+	}
+}
+
+func TestRouteSyncTree(t *testing.T) {
+	router, _ := NewRouter([]RouteRule{{Match: []string{"*.css"}, Dir: "static"}})
+	files := map[string]os.FileInfo{
+		"index.html":      fakeFileInfo{name: "index.html"},
+		"styles/main.css": fakeFileInfo{name: "main.css"},
+	}
+	dirs := map[string]struct{}{"styles": {}, "empty": {}}
+
+	routed, routedDirs, rels, err := routeSyncTree(router, "/src", files, dirs)
+	if err != nil {
+		t.Fatalf("routeSyncTree() error = %v", err)
+	}
+	if _, ok := routed["static/main.css"]; !ok || rels["static/main.css"] != "styles/main.css" {
+		t.Fatalf("routeSyncTree() files = %v, rels = %v", routed, rels)
+	}
+	if _, ok := routedDirs["styles"]; ok {
+		t.Error("a directory whose files were all routed away must not be created")
+	}
+	for _, dir := range []string{"static", "empty"} {
+		if _, ok := routedDirs[dir]; !ok {
+			t.Errorf("routeSyncTree() dirs = %v, missing %s", routedDirs, dir)
+		}
+	}
+
+	files["other/main.css"] = fakeFileInfo{name: "main.css"}
+	if _, _, _, err := routeSyncTree(router, "/src", files, dirs); err == nil {
+		t.Error("routeSyncTree() expected a conflict for two files routed to the same target")
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/frostime/my-sftp/record"
+	terminal "golang.org/x/term"
+)
+
+// maxScanOutput 扫描失败时错误信息中保留的输出长度
+const maxScanOutput = 512
+
+// ContentScanner 在上传前/下载后对本地文件运行的扫描命令（如 "clamdscan --no-summary -"）。
+// 命令通过系统 shell 执行，文件内容从 stdin 传入，路径在环境变量 MY_SFTP_SCAN_FILE 中；
+// 退出码非零表示未通过。
+type ContentScanner struct {
+	PreUpload    string // 上传前运行的命令，空表示不扫描
+	PostDownload string // 下载后运行的命令，空表示不扫描
+	// FlagOnly 未通过时只打印警告：仍然上传，下载的文件也保留。默认阻止上传并删除下载的文件
+	FlagOnly bool
+}
+
+// ScanError 文件未通过扫描
+type ScanError struct {
+	Path    string
+	Command string
+	Output  string // 扫描命令的输出（截断）
+	Err     error  // 通常是 *exec.ExitError
+}
+
+func (e *ScanError) Error() string {
+	msg := fmt.Sprintf("%s rejected by scanner %q: %v", e.Path, e.Command, e.Err)
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *ScanError) Unwrap() error { return e.Err }
+
+// Scan 用 command 扫描本地文件，未通过时返回 *ScanError
+func (s *ContentScanner) Scan(command, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = f
+	cmd.Env = append(os.Environ(), "MY_SFTP_SCAN_FILE="+localPath)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(out.String())
+		if len(output) > maxScanOutput {
+			output = output[:maxScanOutput] + "..."
+		}
+		return &ScanError{Path: localPath, Command: command, Output: output, Err: err}
+	}
+	return nil
+}
+
+// CheckUpload 上传前扫描；FlagOnly 时未通过只警告。s 为 nil 时不扫描
+func (s *ContentScanner) CheckUpload(localPath string) error {
+	if s == nil || s.PreUpload == "" {
+		return nil
+	}
+	return s.verdict(s.Scan(s.PreUpload, localPath), "uploading anyway")
+}
+
+// CheckDownload 下载后扫描；未通过且不是 FlagOnly 时删除下载的文件。s 为 nil 时不扫描
+func (s *ContentScanner) CheckDownload(localPath string) error {
+	if s == nil || s.PostDownload == "" {
+		return nil
+	}
+	err := s.Scan(s.PostDownload, localPath)
+	var scanErr *ScanError
+	if errors.As(err, &scanErr) && !s.FlagOnly {
+		if rmErr := os.Remove(localPath); rmErr != nil {
+			return errors.Join(err, fmt.Errorf("remove rejected file: %w", rmErr))
+		}
+		return fmt.Errorf("%w (file removed)", err)
+	}
+	return s.verdict(err, "file kept")
+}
+
+// verdict FlagOnly 模式下把扫描未通过转为警告；扫描命令本身无法运行（如文件打不开）仍然返回错误
+func (s *ContentScanner) verdict(err error, flagged string) error {
+	var scanErr *ScanError
+	if errors.As(err, &scanErr) && s.FlagOnly {
+		prefix := ""
+		if terminal.IsTerminal(int(record.Terminal().Fd())) {
+			prefix = "\r\033[K" // 清除进度条所在行；输出到文件或管道时不写控制字符
+		}
+		fmt.Printf("%s⚠ %v (%s)\n", prefix, err, flagged)
+		return nil
+	}
+	return err
+}
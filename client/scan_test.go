@@ -0,0 +1,59 @@
+package client
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestContentScanner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scanner command uses sh syntax")
+	}
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.txt")
+	infected := filepath.Join(dir, "infected.txt")
+	if err := os.WriteFile(clean, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 扫描命令同时读取 stdin 和 MY_SFTP_SCAN_FILE
+	const command = `! grep -q VIRUS && test -f "$MY_SFTP_SCAN_FILE"`
+
+	s := &ContentScanner{PreUpload: command, PostDownload: command}
+	if err := s.CheckUpload(clean); err != nil {
+		t.Fatalf("CheckUpload(clean) error = %v", err)
+	}
+
+	writeInfected := func() {
+		if err := os.WriteFile(infected, []byte("a VIRUS here\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeInfected()
+	var scanErr *ScanError
+	if err := s.CheckUpload(infected); !errors.As(err, &scanErr) {
+		t.Fatalf("CheckUpload(infected) = %v, want *ScanError", err)
+	}
+	if err := s.CheckDownload(infected); !errors.As(err, &scanErr) {
+		t.Fatalf("CheckDownload(infected) = %v, want *ScanError", err)
+	}
+	if _, err := os.Stat(infected); !os.IsNotExist(err) {
+		t.Fatal("CheckDownload must remove a rejected file")
+	}
+
+	writeInfected()
+	s.FlagOnly = true
+	if err := s.CheckDownload(infected); err != nil {
+		t.Fatalf("FlagOnly CheckDownload(infected) error = %v", err)
+	}
+	if _, err := os.Stat(infected); err != nil {
+		t.Fatal("FlagOnly CheckDownload must keep the file")
+	}
+
+	var none *ContentScanner
+	if err := none.CheckUpload(infected); err != nil {
+		t.Fatalf("nil scanner CheckUpload() error = %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// workerScheduler 在前台命令和后台任务（Batch 非 nil）之间分配传输 worker。
+// 前台传输从不等待，只受自身并发数限制；后台任务合计最多占用 capacity-1 个 worker，
+// 且前台正在传输时只使用剩余的空位，因此大批量的后台镜像不会让前台命令排队
+type workerScheduler struct {
+	mu         sync.Mutex
+	capacity   int
+	foreground int
+	background int
+	waiting    []chan struct{} // 等待空位的后台任务，按先后顺序
+}
+
+func newWorkerScheduler(capacity int) *workerScheduler {
+	return &workerScheduler{capacity: max(capacity, 1)}
+}
+
+// backgroundFits 报告能否再启动一个后台任务，调用方持有 mu
+func (s *workerScheduler) backgroundFits() bool {
+	limit := max(s.capacity-1, 1)
+	return s.background < limit && s.foreground+s.background < s.capacity
+}
+
+// acquire 占用一个 worker；后台任务在没有空位时等待，ctx 取消时返回其错误。nil 调度器不做限制
+func (s *workerScheduler) acquire(ctx context.Context, background bool) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !background {
+		s.foreground++
+		s.mu.Unlock()
+		return nil
+	}
+	if len(s.waiting) == 0 && s.backgroundFits() {
+		s.background++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	s.waiting = append(s.waiting, ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if i := slices.Index(s.waiting, ready); i >= 0 {
+			s.waiting = slices.Delete(s.waiting, i, i+1)
+			s.mu.Unlock()
+			return ctx.Err()
+		}
+		// 取消的同时已分到空位，归还
+		s.mu.Unlock()
+		s.release(true)
+		return ctx.Err()
+	}
+}
+
+// release 归还 acquire 占用的 worker，并按顺序唤醒能够启动的后台任务
+func (s *workerScheduler) release(background bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if background {
+		s.background--
+	} else {
+		s.foreground--
+	}
+	for len(s.waiting) > 0 && s.backgroundFits() {
+		s.background++
+		close(s.waiting[0])
+		s.waiting = s.waiting[1:]
+	}
+}
+
+type backgroundKey struct{}
+
+// withBackground 标记 ctx 属于后台任务，传输数据时使用单独的通道（见 dataClient）
+func withBackground(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, true)
+}
+
+// dataClient 返回传输文件内容使用的 SFTP 客户端：前台并发传输使用 acquireChannel 分配的通道；
+// 后台任务使用单独的通道，避免 ls 等前台请求排在大量读写请求之后；该通道无法打开时（只尝试一次）使用主通道
+func (c *Client) dataClient(ctx context.Context) *sftp.Client {
+	if sc, ok := ctx.Value(channelKey{}).(*sftp.Client); ok {
+		return sc
+	}
+	if background, _ := ctx.Value(backgroundKey{}).(bool); !background {
+		return c.sftpConn()
+	}
+	c.bgMu.Lock()
+	defer c.bgMu.Unlock()
+	if !c.bgOpened {
+		c.bgOpened = true
+		if sc, err := c.newSFTPClient(); err == nil {
+			c.bgSFTP = sc
+		}
+	}
+	if c.bgSFTP == nil {
+		return c.sftpConn()
+	}
+	return c.bgSFTP
+}
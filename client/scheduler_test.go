@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWorkerSchedulerReservesForeground(t *testing.T) {
+	s := newWorkerScheduler(3)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := s.acquire(ctx, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 后台最多占用 capacity-1 个 worker
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := s.acquire(waitCtx, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third background acquire = %v, want it to wait", err)
+	}
+	// 前台从不等待
+	for i := 0; i < 3; i++ {
+		if err := s.acquire(ctx, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 前台占着 worker 时，后台释放的空位不会被重新分给后台
+	s.release(true)
+	waiter := make(chan error, 1)
+	go func() { waiter <- s.acquire(ctx, true) }()
+	select {
+	case err := <-waiter:
+		t.Fatalf("background acquired while foreground busy: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	for i := 0; i < 3; i++ {
+		s.release(false)
+	}
+	select {
+	case err := <-waiter:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("background waiter not woken after foreground finished")
+	}
+	if s.background != 2 || len(s.waiting) != 0 {
+		t.Fatalf("background = %d, waiting = %d", s.background, len(s.waiting))
+	}
+}
+
+func TestWorkerSchedulerSingleWorker(t *testing.T) {
+	// capacity 为 1 时后台仍能运行，前台也不受影响
+	s := newWorkerScheduler(1)
+	if err := s.acquire(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.acquire(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	var nilScheduler *workerScheduler
+	if err := nilScheduler.acquire(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	nilScheduler.release(true)
+}
@@ -0,0 +1,27 @@
+package client
+
+import "strings"
+
+// shellQuote 用单引号包裹参数，供远程 POSIX shell 安全使用：
+// 单引号内除 ' 外没有特殊字符，' 写作 '"'"'（结束引号、双引号包裹的 '、重新开始引号）
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// shellJoin 引用每个参数并以空格连接，得到一条可直接执行的远程命令
+func shellJoin(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// remoteCommand 返回在 workDir 中执行 command 的命令行；workDir 为空（exec-only 模式）时在登录目录执行。
+// command 本身是 shell 语法，由调用方负责引用其中的路径
+func remoteCommand(workDir, command string) string {
+	if workDir == "" {
+		return command
+	}
+	return "cd " + shellQuote(workDir) + " && " + command
+}
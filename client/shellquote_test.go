@@ -0,0 +1,98 @@
+package client
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// hostileNames 在 shell 中有特殊含义的文件名
+var hostileNames = []string{
+	"plain",
+	"with space",
+	"semi;colon",
+	"it's",
+	`dq"uote`,
+	"$(touch pwned)",
+	"`touch pwned`",
+	"$HOME",
+	"glob*?[a]",
+	"back\\slash",
+	"new\nline",
+	"-rf",
+	"'';&&|| > out <in",
+	"",
+}
+
+func requirePOSIXShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+}
+
+func TestShellQuoteRoundTrip(t *testing.T) {
+	requirePOSIXShell(t)
+	dir := t.TempDir()
+	for _, name := range hostileNames {
+		cmd := exec.Command("sh", "-c", "printf '%s' "+shellQuote(name))
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", name, err)
+		}
+		if string(out) != name {
+			t.Errorf("shellQuote(%q) round trip = %q", name, out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+		t.Fatal("command substitution was executed")
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	requirePOSIXShell(t)
+	out, err := exec.Command("sh", "-c", `for a in `+shellJoin(hostileNames...)+`; do printf '%s\0' "$a"; done`).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if strings.Join(got, "|") != strings.Join(hostileNames, "|") {
+		t.Fatalf("shellJoin() args = %q", got)
+	}
+}
+
+func TestRemoteCommandHostileWorkDir(t *testing.T) {
+	requirePOSIXShell(t)
+	base := t.TempDir()
+	for _, name := range hostileNames {
+		if name == "" || strings.ContainsAny(name, "/") {
+			continue
+		}
+		dir := filepath.Join(base, name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command("sh", "-c", remoteCommand(dir, "pwd"))
+		cmd.Dir = base
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("remoteCommand(%q): %v", dir, err)
+		}
+		if got := strings.TrimSuffix(string(out), "\n"); got != dir {
+			t.Errorf("remoteCommand(%q) ran in %q", dir, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(base, "pwned")); err == nil {
+		t.Fatal("command substitution was executed")
+	}
+	if got := remoteCommand("", "ls"); got != "ls" {
+		t.Errorf("remoteCommand(\"\", ls) = %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxListedSpecialFiles 跳过提示中最多列出的路径数
+const maxListedSpecialFiles = 5
+
+// skippedSpecial 一个被跳过的特殊文件及原因
+type skippedSpecial struct {
+	path string
+	kind string
+}
+
+// specialKind 根据类型位返回特殊文件类型名称；普通文件返回 ""
+func specialKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode&os.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	case mode&os.ModeIrregular != 0:
+		return "irregular file"
+	}
+	return ""
+}
+
+// splitSpecialTasks 将任务分为普通传输、需要重建的特殊文件（recreate 时的 symlink/fifo）和跳过的特殊文件。
+// 不重建时符号链接按目标处理：指向普通文件则传输目标内容，否则跳过。
+func (c *Client) splitSpecialTasks(tasks []transferTask, recreate bool) (regular, special []transferTask, skipped []skippedSpecial) {
+	for _, task := range tasks {
+		kind := specialKind(task.mode)
+		switch {
+		case kind == "":
+			regular = append(regular, task)
+		case recreate && (kind == "symlink" || kind == "fifo"):
+			special = append(special, task)
+		case kind == "symlink":
+			target, err := c.statTaskSource(task)
+			switch {
+			case err != nil:
+				skipped = append(skipped, skippedSpecial{path: taskSourcePath(task), kind: "broken symlink"})
+			case target.IsDir():
+				skipped = append(skipped, skippedSpecial{path: taskSourcePath(task), kind: "symlink to directory"})
+			case specialKind(target.Mode().Type()) != "":
+				skipped = append(skipped, skippedSpecial{path: taskSourcePath(task), kind: specialKind(target.Mode().Type())})
+			default:
+				task.mode = 0
+				task.size = target.Size()
+				regular = append(regular, task)
+			}
+		default:
+			skipped = append(skipped, skippedSpecial{path: taskSourcePath(task), kind: kind})
+		}
+	}
+	return regular, special, skipped
+}
+
+// statTaskSource 跟随符号链接获取源文件信息
+func (c *Client) statTaskSource(task transferTask) (os.FileInfo, error) {
+	if task.isUpload {
+		return os.Stat(task.localPath)
+	}
+	return c.sftpConn().Stat(task.remotePath)
+}
+
+// printSkippedSpecial 汇总输出跳过的特殊文件
+func printSkippedSpecial(w io.Writer, skipped []skippedSpecial) {
+	if len(skipped) == 0 {
+		return
+	}
+	counts := make(map[string]int)
+	for _, s := range skipped {
+		counts[s.kind]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind, n := range counts {
+		kinds = append(kinds, fmt.Sprintf("%d %s", n, kind))
+	}
+	sort.Strings(kinds)
+
+	fmt.Fprintf(w, "Skipped %d special file(s) (%s):\n", len(skipped), strings.Join(kinds, ", "))
+	for i, s := range skipped {
+		if i == maxListedSpecialFiles {
+			fmt.Fprintf(w, "  ... and %d more\n", len(skipped)-i)
+			break
+		}
+		fmt.Fprintf(w, "  - %s (%s)\n", s.path, s.kind)
+	}
+}
+
+// recreateSpecialTasks 在目标端重建符号链接与 FIFO，返回成功数量
+func (c *Client) recreateSpecialTasks(tasks []transferTask) (int, []error) {
+	created := 0
+	var errs []error
+	for _, task := range tasks {
+		if err := c.recreateSpecial(task); err != nil {
+			errs = append(errs, fmt.Errorf("recreate %s %s: %w", specialKind(task.mode), taskSourcePath(task), err))
+			continue
+		}
+		fmt.Printf("✓ %s (%s)\n", taskTargetPath(task), specialKind(task.mode))
+		created++
+	}
+	return created, errs
+}
+
+func (c *Client) recreateSpecial(task transferTask) error {
+	if task.isUpload {
+		if err := c.ensureRemoteDir(path.Dir(task.remotePath)); err != nil {
+			return err
+		}
+		if _, err := c.sftpConn().Lstat(task.remotePath); err == nil {
+			if err := c.sftpConn().Remove(task.remotePath); err != nil {
+				return err
+			}
+		}
+		if task.mode&os.ModeSymlink != 0 {
+			target, err := os.Readlink(task.localPath)
+			if err != nil {
+				return err
+			}
+			return c.sftpConn().Symlink(filepath.ToSlash(target), task.remotePath)
+		}
+		return c.ExecuteRemote("mkfifo -- "+shellQuote(task.remotePath), nil, io.Discard, io.Discard)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(task.localPath), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(task.localPath); err == nil {
+		if err := os.Remove(task.localPath); err != nil {
+			return err
+		}
+	}
+	if task.mode&os.ModeSymlink != 0 {
+		target, err := c.sftpConn().ReadLink(task.remotePath)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(filepath.FromSlash(target), task.localPath)
+	}
+	return mkfifo(task.localPath)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSpecialKind(t *testing.T) {
+	tests := []struct {
+		mode os.FileMode
+		want string
+	}{
+		{0, ""},
+		{os.ModeSymlink, "symlink"},
+		{os.ModeNamedPipe, "fifo"},
+		{os.ModeSocket, "socket"},
+		{os.ModeDevice, "device"},
+		{os.ModeDevice | os.ModeCharDevice, "device"},
+		{os.ModeIrregular, "irregular file"},
+	}
+	for _, tt := range tests {
+		if got := specialKind(tt.mode); got != tt.want {
+			t.Errorf("specialKind(%v) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSpecialTasksUpload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileLink := filepath.Join(dir, "file-link")
+	dirLink := filepath.Join(dir, "dir-link")
+	brokenLink := filepath.Join(dir, "broken")
+	for target, link := range map[string]string{file: fileLink, dir: dirLink, filepath.Join(dir, "missing"): brokenLink} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tasks := []transferTask{
+		{localPath: file, isUpload: true, size: 5},
+		{localPath: fileLink, isUpload: true, size: 8, mode: os.ModeSymlink},
+		{localPath: dirLink, isUpload: true, mode: os.ModeSymlink},
+		{localPath: brokenLink, isUpload: true, mode: os.ModeSymlink},
+		{localPath: filepath.Join(dir, "pipe"), isUpload: true, mode: os.ModeNamedPipe},
+		{localPath: filepath.Join(dir, "sock"), isUpload: true, mode: os.ModeSocket},
+	}
+
+	c := &Client{}
+	regular, special, skipped := c.splitSpecialTasks(tasks, false)
+	if len(regular) != 2 || regular[1].localPath != fileLink || regular[1].mode != 0 || regular[1].size != 5 {
+		t.Fatalf("regular = %+v, want file and followed file-link", regular)
+	}
+	if len(special) != 0 {
+		t.Fatalf("special = %+v, want none without recreate", special)
+	}
+	var kinds []string
+	for _, s := range skipped {
+		kinds = append(kinds, s.kind)
+	}
+	if got := strings.Join(kinds, ","); got != "symlink to directory,broken symlink,fifo,socket" {
+		t.Fatalf("skipped kinds = %s", got)
+	}
+
+	regular, special, skipped = c.splitSpecialTasks(tasks, true)
+	if len(regular) != 1 || len(special) != 4 || len(skipped) != 1 || skipped[0].kind != "socket" {
+		t.Fatalf("recreate split = %d regular, %d special, %+v skipped", len(regular), len(special), skipped)
+	}
+}
+
+func TestPrintSkippedSpecial(t *testing.T) {
+	var skipped []skippedSpecial
+	for i := 0; i < maxListedSpecialFiles+2; i++ {
+		skipped = append(skipped, skippedSpecial{path: filepath.Join("run", "s"+string(rune('a'+i))), kind: "socket"})
+	}
+	skipped = append(skipped, skippedSpecial{path: "dev/null", kind: "device"})
+
+	var buf bytes.Buffer
+	printSkippedSpecial(&buf, skipped)
+	out := buf.String()
+	if !strings.HasPrefix(out, "Skipped 8 special file(s) (1 device, 7 socket):\n") {
+		t.Fatalf("unexpected header: %q", out)
+	}
+	if !strings.Contains(out, "... and 3 more") {
+		t.Fatalf("missing truncation line: %q", out)
+	}
+
+	buf.Reset()
+	printSkippedSpecial(&buf, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("printSkippedSpecial(nil) wrote %q", buf.String())
+	}
+}
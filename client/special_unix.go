@@ -0,0 +1,9 @@
+//go:build !windows
+
+package client
+
+import "syscall"
+
+func mkfifo(p string) error {
+	return syscall.Mkfifo(p, 0644)
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package client
+
+import "fmt"
+
+func mkfifo(p string) error {
+	return fmt.Errorf("FIFOs are not supported on Windows")
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// stageDirPrefix stage push 在目标目录下使用的临时目录名前缀
+const stageDirPrefix = ".my-sftp-stage-"
+
+// StagedFile 暂存区中的一个本地文件
+type StagedFile struct {
+	Local string // 本地绝对路径
+	Rel   string // 相对于推送目标目录的路径（/ 分隔）
+}
+
+// StagePushResult stage push 的结果
+type StagePushResult struct {
+	Replaced int // 替换的已有文件数
+	Created  int // 新建的文件数
+}
+
+// stageSwap 替换阶段已完成的一个文件，用于失败时回滚
+type stageSwap struct {
+	target string
+	backup string // 原文件的备份位置，空表示目标原本不存在
+}
+
+// PushStaged 把 files 作为一个整体推送到 remoteDir：先全部上传到 remoteDir 下的临时目录，
+// 全部成功后再逐个把文件换到目标位置（原文件先备份到临时目录）。
+// 上传失败时目标目录不受影响；替换中途失败时把已替换的文件恢复原状
+func (c *Client) PushStaged(files []StagedFile, remoteDir string, showProgress bool) (*StagePushResult, error) {
+	if len(files) == 0 {
+		return nil, errors.New("nothing staged")
+	}
+	root := c.ResolveRemotePath(remoteDir)
+	stageDir := path.Join(root, stageDirPrefix+time.Now().Format("20060102-150405"))
+	newDir, oldDir := path.Join(stageDir, "new"), path.Join(stageDir, "old")
+
+	// 1. 检查全部文件后再在服务器上创建目录，检查失败时服务器上什么都不变
+	tasks := make([]transferTask, 0, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f.Local)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("not a regular file: %s", f.Local)
+		}
+		if target, err := c.sftpConn().Stat(path.Join(root, f.Rel)); err == nil && target.IsDir() {
+			return nil, fmt.Errorf("%s is a directory on the server", path.Join(root, f.Rel))
+		}
+		tasks = append(tasks, transferTask{
+			localPath:  f.Local,
+			remotePath: path.Join(newDir, f.Rel),
+			isUpload:   true,
+			size:       info.Size(),
+		})
+	}
+
+	// 2. 上传到临时目录
+	if err := c.ensureRemoteDir(root); err != nil {
+		return nil, fmt.Errorf("create %s: %w", root, err)
+	}
+	if err := c.ensureRemoteDirsExist(c.collectRemoteDirsForUpload(tasks)); err != nil {
+		c.sftpConn().RemoveAll(stageDir)
+		return nil, fmt.Errorf("create staging dirs: %w", err)
+	}
+	fmt.Printf("Uploading %d staged file(s) to %s\n", len(tasks), stageDir)
+	_, err := c.executeTasks(tasks, &TransferOptions{
+		ShowProgress: showProgress,
+		Concurrency:  c.Concurrency(),
+		MaxDepth:     -1,
+		FailFast:     true,
+	})
+	if err != nil {
+		c.sftpConn().RemoveAll(stageDir)
+		return nil, fmt.Errorf("upload failed, nothing was changed in %s: %w", root, err)
+	}
+
+	// 3. 替换
+	result := &StagePushResult{}
+	var done []stageSwap
+	for _, f := range files {
+		swap, err := c.swapStaged(path.Join(newDir, f.Rel), path.Join(root, f.Rel), path.Join(oldDir, f.Rel))
+		if err != nil {
+			if rbErr := c.rollbackStaged(done); rbErr != nil {
+				return nil, fmt.Errorf("replace %s: %w; rollback failed, previous versions are kept in %s: %v",
+					path.Join(root, f.Rel), err, oldDir, rbErr)
+			}
+			c.sftpConn().RemoveAll(stageDir)
+			return nil, fmt.Errorf("replace %s: %w (all changes rolled back)", path.Join(root, f.Rel), err)
+		}
+		done = append(done, swap)
+		if swap.backup != "" {
+			result.Replaced++
+		} else {
+			result.Created++
+		}
+	}
+
+	for _, swap := range done {
+		c.invalidateDirCache(path.Dir(swap.target))
+	}
+	if err := c.sftpConn().RemoveAll(stageDir); err != nil {
+		fmt.Printf("Warning: remove %s: %v\n", stageDir, err)
+	}
+	c.invalidateDirCache(root)
+	return result, nil
+}
+
+// swapStaged 把 staged 移到 target；target 已存在时先备份到 backup。
+// 服务器支持 hardlink 扩展时用硬链接备份，替换本身是一次 rename，target 不会短暂消失
+func (c *Client) swapStaged(staged, target, backup string) (stageSwap, error) {
+	swap := stageSwap{target: target}
+	if err := c.ensureRemoteDir(path.Dir(target)); err != nil {
+		return swap, err
+	}
+	if _, err := c.sftpConn().Lstat(target); err != nil {
+		if !os.IsNotExist(err) {
+			return swap, err
+		}
+		return swap, c.sftpConn().Rename(staged, target)
+	}
+
+	if err := c.ensureRemoteDir(path.Dir(backup)); err != nil {
+		return swap, err
+	}
+	if err := c.sftpConn().Link(target, backup); err == nil {
+		swap.backup = backup
+		return swap, c.replaceRemoteFile(staged, target)
+	}
+	if err := c.sftpConn().Rename(target, backup); err != nil {
+		return swap, err
+	}
+	swap.backup = backup
+	if err := c.sftpConn().Rename(staged, target); err != nil {
+		// 放回原文件，本次替换不计入 done
+		c.sftpConn().Rename(backup, target)
+		return swap, err
+	}
+	return swap, nil
+}
+
+// rollbackStaged 按相反顺序撤销已完成的替换
+func (c *Client) rollbackStaged(done []stageSwap) error {
+	var errs []error
+	for i := len(done) - 1; i >= 0; i-- {
+		swap := done[i]
+		var err error
+		if swap.backup != "" {
+			err = c.replaceRemoteFile(swap.backup, swap.target)
+		} else {
+			err = c.sftpConn().Remove(swap.target)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", swap.target, err))
+		}
+		c.invalidateDirCache(path.Dir(swap.target))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"os"
+	"path"
+)
+
+// Symlink 在 link 处创建指向 target 的符号链接。target 原样保存（相对路径相对于链接所在目录解析，与 ln -s 一致）
+func (c *Client) Symlink(target, link string) error {
+	link = c.ResolveRemotePath(link)
+	if err := c.sftpConn().Symlink(target, link); err != nil {
+		return err
+	}
+	c.invalidateDirCache(path.Dir(link))
+	return nil
+}
+
+// ReadLink 返回符号链接保存的目标路径
+func (c *Client) ReadLink(link string) (string, error) {
+	return c.sftpConn().ReadLink(c.ResolveRemotePath(link))
+}
+
+// Lstat 获取文件信息，不跟随符号链接
+func (c *Client) Lstat(remotePath string) (os.FileInfo, error) {
+	return c.sftpConn().Lstat(c.ResolveRemotePath(remotePath))
+}
@@ -0,0 +1,352 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SyncOptions 同步选项
+type SyncOptions struct {
+	Reverse      bool // false: 本地 -> 远程；true: 远程 -> 本地
+	ShowProgress bool // 显示进度条
+	Concurrency  int  // 并发数
+	// Scanner 上传前/下载后扫描本地文件，nil 表示不扫描
+	Scanner *ContentScanner
+	// Router 上传方向按文件名/MIME 类型改变目标子目录，反向同步时忽略
+	Router *Router
+	// Batch 传输所属的批次（单独取消、统计进度），nil 表示普通传输
+	Batch *Batch
+	// Log 说明信息（如 "Syncing 3 new ..."）的输出位置，nil 时为标准输出；后台任务写入任务日志
+	Log io.Writer
+}
+
+// logf 向 Log 输出一行说明信息
+func (o *SyncOptions) logf(format string, a ...any) {
+	w := o.Log
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintf(w, format+"\n", a...)
+}
+
+// SyncResult 一次同步的统计
+type SyncResult struct {
+	New       int      // 目标端不存在、新传输的文件数
+	Updated   int      // 大小或 mtime 不同、重新传输的文件数
+	Unchanged int      // 大小和 mtime 相同、跳过的文件数
+	Conflicts []string // 类型冲突（文件/目录互相替换）或特殊文件，未处理
+}
+
+// syncPlan 比较源与目标后得到的同步计划（相对路径，斜杠分隔）
+type syncPlan struct {
+	newFiles  []string
+	updated   []string
+	unchanged int
+	conflicts []string
+	blocked   []string // 目标端是文件的源目录，其下内容不处理
+}
+
+// planSync 按大小和秒级 mtime 比较源文件与目标条目。
+// 源目录在目标端是文件（或反之）时记为冲突，冲突目录下的文件不处理。
+func planSync(srcFiles map[string]os.FileInfo, srcDirs map[string]struct{}, dst map[string]os.FileInfo) syncPlan {
+	var plan syncPlan
+	var blocked []string
+	for rel := range srcDirs {
+		if info, ok := dst[rel]; ok && !info.IsDir() {
+			blocked = append(blocked, rel)
+		}
+	}
+	sort.Strings(blocked)
+	plan.blocked = blocked
+	plan.conflicts = append(plan.conflicts, blocked...)
+
+	rels := make([]string, 0, len(srcFiles))
+	for rel := range srcFiles {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		if underAny(rel, blocked) {
+			continue
+		}
+		src := srcFiles[rel]
+		info, ok := dst[rel]
+		switch {
+		case !ok:
+			plan.newFiles = append(plan.newFiles, rel)
+		case !info.Mode().IsRegular():
+			plan.conflicts = append(plan.conflicts, rel)
+		case info.Size() == src.Size() && info.ModTime().Unix() == src.ModTime().Unix():
+			plan.unchanged++
+		default:
+			plan.updated = append(plan.updated, rel)
+		}
+	}
+	return plan
+}
+
+func underAny(rel string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// syncJob 同步前的扫描与比较结果，尚未修改任何文件
+type syncJob struct {
+	localDir, remoteDir string
+	reverse             bool
+	dstExists           bool
+	srcFiles            map[string]os.FileInfo
+	missingDirs         []string // 目标端需要创建的目录（相对路径）
+	rels                []string // 与 tasks 一一对应的相对路径
+	tasks               []transferTask
+	result              *SyncResult
+}
+
+// prepareSync 扫描源和目标并生成传输任务；目标目录不存在时视为空
+// router 只用于上传方向，源文件按路由后的目标相对路径与目标端比较
+func (c *Client) prepareSync(localDir, remoteDir string, reverse bool, router *Router) (*syncJob, error) {
+	job := &syncJob{
+		localDir:  c.ResolveLocalPath(localDir),
+		remoteDir: c.ResolveRemotePath(remoteDir),
+		reverse:   reverse,
+	}
+
+	srcDir, dstDir := job.localDir, job.remoteDir
+	srcStat, dstStat := os.Stat, c.sftpConn().Stat
+	scanSrc, scanDst := scanLocalTree, c.scanRemoteTree
+	if reverse {
+		srcDir, dstDir = dstDir, srcDir
+		srcStat, dstStat = dstStat, srcStat
+		scanSrc, scanDst = scanDst, scanSrc
+	}
+
+	if stat, err := srcStat(srcDir); err != nil {
+		return nil, err
+	} else if !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", srcDir)
+	}
+	srcEntries, err := scanSrc(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	dstEntries := make(map[string]os.FileInfo)
+	if stat, err := dstStat(dstDir); err == nil {
+		if !stat.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", dstDir)
+		}
+		job.dstExists = true
+		if dstEntries, err = scanDst(dstDir); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	srcFiles, srcDirs, special := splitTreeEntries(srcEntries)
+	// srcRels 目标相对路径 -> 源相对路径（只有路由时不同）
+	srcRels := make(map[string]string, len(srcFiles))
+	for rel := range srcFiles {
+		srcRels[rel] = rel
+	}
+	if router != nil && !reverse {
+		srcFiles, srcDirs, srcRels, err = routeSyncTree(router, job.localDir, srcFiles, srcDirs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	plan := planSync(srcFiles, srcDirs, dstEntries)
+	job.srcFiles = srcFiles
+	job.result = &SyncResult{
+		New:       len(plan.newFiles),
+		Updated:   len(plan.updated),
+		Unchanged: plan.unchanged,
+		Conflicts: append(plan.conflicts, special...),
+	}
+
+	job.rels = append(plan.newFiles, plan.updated...)
+	for _, rel := range job.rels {
+		job.tasks = append(job.tasks, transferTask{
+			localPath:  filepath.Join(job.localDir, filepath.FromSlash(srcRels[rel])),
+			remotePath: path.Join(job.remoteDir, rel),
+			isUpload:   !reverse,
+			size:       srcFiles[rel].Size(),
+		})
+	}
+	for rel := range srcDirs {
+		if _, ok := dstEntries[rel]; !ok && !underAny(rel, plan.blocked) {
+			job.missingDirs = append(job.missingDirs, rel)
+		}
+	}
+	sort.Strings(job.missingDirs)
+	return job, nil
+}
+
+// routeSyncTree 把源文件重新按路由后的目标相对路径登记；被路由走的文件不再使原目录出现在目标端，
+// 原本就为空的目录仍然保留。两个源文件路由到同一目标时报错
+func routeSyncTree(router *Router, localDir string, files map[string]os.FileInfo, dirs map[string]struct{}) (map[string]os.FileInfo, map[string]struct{}, map[string]string, error) {
+	routedFiles := make(map[string]os.FileInfo, len(files))
+	rels := make(map[string]string, len(files))
+	routedDirs := make(map[string]struct{})
+	nonEmpty := make(map[string]struct{})
+	for rel, info := range files {
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			nonEmpty[dir] = struct{}{}
+		}
+		target := rel
+		if routed, ok := router.Route("", rel, filepath.Join(localDir, filepath.FromSlash(rel))); ok {
+			if path.IsAbs(routed) || routed == ".." || strings.HasPrefix(routed, "../") {
+				return nil, nil, nil, fmt.Errorf("sync routes must stay inside the target directory: %s -> %s", rel, routed)
+			}
+			target = routed
+		}
+		if other, exists := rels[target]; exists {
+			return nil, nil, nil, fmt.Errorf("route conflict: %s and %s both map to %s", other, rel, target)
+		}
+		routedFiles[target] = info
+		rels[target] = rel
+		for dir := path.Dir(target); dir != "."; dir = path.Dir(dir) {
+			routedDirs[dir] = struct{}{}
+		}
+	}
+	for dir := range dirs {
+		if _, ok := nonEmpty[dir]; !ok {
+			routedDirs[dir] = struct{}{}
+		}
+	}
+	return routedFiles, routedDirs, rels, nil
+}
+
+// SyncManifest 返回 Sync 将要传输的文件清单和统计（不修改任何文件）
+func (c *Client) SyncManifest(localDir, remoteDir string, reverse bool, router *Router) ([]ManifestEntry, *SyncResult, error) {
+	job, err := c.prepareSync(localDir, remoteDir, reverse, router)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifestFromTasks(job.tasks), job.result, nil
+}
+
+// Sync 增量同步目录树：只传输目标端不存在或大小/mtime 不同的文件，
+// 传输后把目标文件的 mtime 设置为源文件的 mtime，供下次比较。不删除目标端多余的文件。
+func (c *Client) Sync(localDir, remoteDir string, opts *SyncOptions) (*SyncResult, error) {
+	if opts == nil {
+		opts = &SyncOptions{ShowProgress: true, Concurrency: c.Concurrency()}
+	}
+	job, err := c.prepareSync(localDir, remoteDir, opts.Reverse, opts.Router)
+	if err != nil {
+		return nil, err
+	}
+	result, tasks := job.result, job.tasks
+
+	// 目标端创建所有源目录（包括空目录）
+	if opts.Reverse {
+		dirs := append([]string{""}, job.missingDirs...)
+		for _, rel := range dirs {
+			if err := os.MkdirAll(filepath.Join(job.localDir, filepath.FromSlash(rel)), 0755); err != nil {
+				return result, fmt.Errorf("create local dir: %w", err)
+			}
+		}
+	} else {
+		if !job.dstExists {
+			if err := c.ensureRemoteDir(job.remoteDir); err != nil {
+				return result, fmt.Errorf("create %s: %w", job.remoteDir, err)
+			}
+		}
+		dirs := c.collectRemoteDirsForUpload(tasks)
+		for _, rel := range job.missingDirs {
+			dirs = append(dirs, path.Join(job.remoteDir, rel))
+		}
+		if err := c.ensureRemoteDirsExist(dirs); err != nil {
+			return result, fmt.Errorf("create remote dirs: %w", err)
+		}
+	}
+
+	if len(tasks) == 0 {
+		return result, nil
+	}
+	opts.logf("Syncing %d new and %d changed file(s), %d unchanged", result.New, result.Updated, result.Unchanged)
+	completed := &completedTasks{}
+	transferOpts := &TransferOptions{ShowProgress: opts.ShowProgress, Concurrency: opts.Concurrency, MaxDepth: -1, Scanner: opts.Scanner, Batch: opts.Batch, completed: completed}
+	_, transferErr := c.executeTasks(tasks, transferOpts)
+
+	// 目标文件 mtime 与源一致，下次同步时才能判定为未变化；失败或取消的文件保持原样，下次重新传输
+	var errs []error
+	for i, task := range tasks {
+		if !completed.has(task) {
+			continue
+		}
+		mtime := job.srcFiles[job.rels[i]].ModTime()
+		if err := c.setTargetMtime(task, mtime); err != nil {
+			errs = append(errs, fmt.Errorf("set mtime %s: %w", taskTargetPath(task), err))
+		}
+	}
+	if transferErr != nil || len(errs) > 0 {
+		return result, errors.Join(append([]error{transferErr}, errs...)...)
+	}
+	return result, nil
+}
+
+// setTargetMtime 设置传输目标的访问/修改时间
+func (c *Client) setTargetMtime(task transferTask, mtime time.Time) error {
+	if task.isUpload {
+		return c.sftpConn().Chtimes(task.remotePath, mtime, mtime)
+	}
+	return os.Chtimes(task.localPath, mtime, mtime)
+}
+
+// scanLocalTree 递归收集本地目录下的条目（相对路径，斜杠分隔；不跟随符号链接）
+func scanLocalTree(root string) (map[string]os.FileInfo, error) {
+	entries := make(map[string]os.FileInfo)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries[filepath.ToSlash(rel)] = info
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", root, err)
+	}
+	return entries, nil
+}
+
+// splitTreeEntries 将扫描结果分为普通文件、目录和特殊文件（特殊文件按路径排序）
+func splitTreeEntries(entries map[string]os.FileInfo) (files map[string]os.FileInfo, dirs map[string]struct{}, special []string) {
+	files = make(map[string]os.FileInfo)
+	dirs = make(map[string]struct{})
+	for rel, info := range entries {
+		switch {
+		case info.IsDir():
+			dirs[rel] = struct{}{}
+		case info.Mode().IsRegular():
+			files[rel] = info
+		default:
+			special = append(special, rel)
+		}
+	}
+	sort.Strings(special)
+	return files, dirs, special
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanSync(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	src := map[string]os.FileInfo{
+		"same.txt":        fakeFileInfo{name: "same.txt", size: 3, modTime: mtime},
+		"touched.txt":     fakeFileInfo{name: "touched.txt", size: 3, modTime: mtime.Add(time.Second)},
+		"grown.txt":       fakeFileInfo{name: "grown.txt", size: 4, modTime: mtime},
+		"new.txt":         fakeFileInfo{name: "new.txt", size: 1, modTime: mtime},
+		"dir/file.txt":    fakeFileInfo{name: "file.txt", size: 1, modTime: mtime},
+		"blocked/inner":   fakeFileInfo{name: "inner", size: 1, modTime: mtime},
+		"isdir-there.txt": fakeFileInfo{name: "isdir-there.txt", size: 1, modTime: mtime},
+	}
+	srcDirs := map[string]struct{}{"dir": {}, "blocked": {}}
+	dst := map[string]os.FileInfo{
+		"same.txt":        fakeFileInfo{name: "same.txt", size: 3, modTime: mtime.Add(300 * time.Millisecond)},
+		"touched.txt":     fakeFileInfo{name: "touched.txt", size: 3, modTime: mtime},
+		"grown.txt":       fakeFileInfo{name: "grown.txt", size: 3, modTime: mtime},
+		"blocked":         fakeFileInfo{name: "blocked", size: 1, modTime: mtime},
+		"isdir-there.txt": fakeFileInfo{name: "isdir-there.txt", mode: os.ModeDir | 0755, modTime: mtime},
+	}
+
+	plan := planSync(src, srcDirs, dst)
+	if want := []string{"dir/file.txt", "new.txt"}; !reflect.DeepEqual(plan.newFiles, want) {
+		t.Fatalf("newFiles = %v, want %v", plan.newFiles, want)
+	}
+	if want := []string{"grown.txt", "touched.txt"}; !reflect.DeepEqual(plan.updated, want) {
+		t.Fatalf("updated = %v, want %v", plan.updated, want)
+	}
+	if plan.unchanged != 1 {
+		t.Fatalf("unchanged = %d, want 1", plan.unchanged)
+	}
+	if want := []string{"blocked", "isdir-there.txt"}; !reflect.DeepEqual(plan.conflicts, want) {
+		t.Fatalf("conflicts = %v, want %v", plan.conflicts, want)
+	}
+}
@@ -0,0 +1,364 @@
+package client
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// WithSFTPTrace 把每个 SFTP 请求和响应以 JSON 行（见 TraceRecord）写入 w，用于排查第三方服务器的协议问题。
+// 包括并行传输额外打开的通道；w 需在客户端关闭后由调用方关闭
+func WithSFTPTrace(w io.Writer) ClientOption {
+	return func(c *Client) {
+		c.trace = &sftpTrace{enc: json.NewEncoder(w)}
+	}
+}
+
+// TraceRecord --trace-sftp 输出的一行。请求（dir=send）与响应（dir=recv）各占一行，
+// 响应行带上对应请求的类型、路径和往返耗时
+type TraceRecord struct {
+	Time      string  `json:"time"`
+	Channel   int     `json:"channel"`             // SFTP 通道序号，0 为主通道
+	Dir       string  `json:"dir"`                 // send 或 recv
+	Type      string  `json:"type"`                // 数据包类型，如 OPEN、STATUS
+	ID        *uint32 `json:"id,omitempty"`        // 请求 ID（INIT/VERSION 没有）
+	Request   string  `json:"request,omitempty"`   // 响应对应的请求类型
+	Extension string  `json:"extension,omitempty"` // EXTENDED 请求的扩展名
+	Path      string  `json:"path,omitempty"`      // 请求的路径；句柄操作为打开句柄时的路径
+	Target    string  `json:"target,omitempty"`    // RENAME/SYMLINK/hardlink 的第二个路径
+	Offset    *uint64 `json:"offset,omitempty"`    // READ/WRITE 的偏移
+	Length    int     `json:"length,omitempty"`    // READ 请求的长度、WRITE/DATA 的数据字节数
+	Count     int     `json:"count,omitempty"`     // NAME 响应的条目数
+	Version   int     `json:"version,omitempty"`   // INIT/VERSION 的协议版本
+	Status    string  `json:"status,omitempty"`    // STATUS 响应的状态码，如 NO_SUCH_FILE
+	Message   string  `json:"message,omitempty"`   // STATUS 响应的错误信息
+	LatencyMS float64 `json:"latency_ms,omitempty"`
+}
+
+// maxTraceHead 每个数据包最多保留用于解析的字节数（含 4 字节长度）；WRITE/DATA 的数据部分不需要
+const maxTraceHead = 8192
+
+// sftpTrace 所有通道共用的输出
+type sftpTrace struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	channels int
+}
+
+func (t *sftpTrace) write(rec *TraceRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.enc.Encode(rec)
+}
+
+func (t *sftpTrace) newChannel() *traceChannel {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := &traceChannel{
+		trace:   t,
+		id:      t.channels,
+		pending: make(map[uint32]tracedRequest),
+		handles: make(map[string]string),
+	}
+	t.channels++
+	return ch
+}
+
+// traceChannel 一个 SFTP 通道的状态：未完成的请求和已打开句柄对应的路径
+type traceChannel struct {
+	trace   *sftpTrace
+	id      int
+	mu      sync.Mutex
+	pending map[uint32]tracedRequest
+	handles map[string]string
+}
+
+type tracedRequest struct {
+	typ   string
+	path  string
+	start time.Time
+}
+
+// sent 记录客户端发出的请求
+func (ch *traceChannel) sent(pkt []byte) {
+	rec, ok := ch.decode(pkt, "send")
+	if !ok {
+		return
+	}
+	if rec.ID != nil {
+		ch.mu.Lock()
+		ch.pending[*rec.ID] = tracedRequest{typ: rec.Type, path: rec.Path, start: time.Now()}
+		ch.mu.Unlock()
+	}
+	ch.trace.write(rec)
+}
+
+// received 记录服务器返回的响应，并与请求配对计算耗时
+func (ch *traceChannel) received(pkt []byte) {
+	rec, ok := ch.decode(pkt, "recv")
+	if !ok {
+		return
+	}
+	ch.trace.write(rec)
+}
+
+// decode 解析数据包头部；pkt 可能只是数据包的前 maxTraceHead 字节
+func (ch *traceChannel) decode(pkt []byte, dir string) (*TraceRecord, bool) {
+	if len(pkt) == 0 {
+		return nil, false
+	}
+	rec := &TraceRecord{
+		Time:    time.Now().Format(time.RFC3339Nano),
+		Channel: ch.id,
+		Dir:     dir,
+		Type:    tracePacketType(pkt[0]),
+	}
+	buf := traceBuf(pkt[1:])
+	if pkt[0] == sshFxpInit || pkt[0] == sshFxpVersion {
+		version, _ := buf.uint32()
+		rec.Version = int(version)
+		return rec, true
+	}
+	id, ok := buf.uint32()
+	if !ok {
+		return rec, true
+	}
+	rec.ID = &id
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if dir == "send" {
+		ch.decodeRequest(rec, pkt[0], buf)
+		return rec, true
+	}
+
+	if req, ok := ch.pending[id]; ok {
+		delete(ch.pending, id)
+		rec.Request = req.typ
+		rec.Path = req.path
+		rec.LatencyMS = float64(time.Since(req.start).Microseconds()) / 1000
+		if pkt[0] == sshFxpHandle && (req.typ == "OPEN" || req.typ == "OPENDIR") {
+			if handle, ok := buf.string(); ok {
+				ch.handles[handle] = req.path
+			}
+		}
+	}
+	switch pkt[0] {
+	case sshFxpStatus:
+		code, _ := buf.uint32()
+		rec.Status = traceStatus(code)
+		rec.Message, _ = buf.string()
+	case sshFxpData:
+		n, _ := buf.uint32()
+		rec.Length = int(n)
+	case sshFxpName:
+		n, _ := buf.uint32()
+		rec.Count = int(n)
+	}
+	return rec, true
+}
+
+// decodeRequest 解析请求的路径、句柄和偏移；调用方持有 ch.mu
+func (ch *traceChannel) decodeRequest(rec *TraceRecord, typ byte, buf traceBuf) {
+	switch typ {
+	case sshFxpOpen, sshFxpLstat, sshFxpStat, sshFxpSetstat, sshFxpOpendir,
+		sshFxpRemove, sshFxpMkdir, sshFxpRmdir, sshFxpRealpath, sshFxpReadlink:
+		rec.Path, _ = buf.string()
+	case sshFxpRename, sshFxpSymlink:
+		rec.Path, _ = buf.string()
+		rec.Target, _ = buf.string()
+	case sshFxpClose:
+		// 句柄关闭后可能被服务器复用
+		var handle string
+		handle, rec.Path = ch.handlePath(&buf)
+		delete(ch.handles, handle)
+	case sshFxpReaddir, sshFxpFstat, sshFxpFsetstat:
+		_, rec.Path = ch.handlePath(&buf)
+	case sshFxpRead, sshFxpWrite:
+		_, rec.Path = ch.handlePath(&buf)
+		offset, _ := buf.uint64()
+		n, _ := buf.uint32()
+		rec.Offset = &offset
+		rec.Length = int(n)
+	case sshFxpExtended:
+		rec.Extension, _ = buf.string()
+		switch rec.Extension {
+		case "posix-rename@openssh.com", "hardlink@openssh.com":
+			rec.Path, _ = buf.string()
+			rec.Target, _ = buf.string()
+		case "statvfs@openssh.com":
+			rec.Path, _ = buf.string()
+		case "fstatvfs@openssh.com", "fsync@openssh.com":
+			_, rec.Path = ch.handlePath(&buf)
+		}
+	}
+}
+
+// handlePath 读取句柄并返回它和打开它时的路径；未知句柄的路径显示为 handle:<hex>
+func (ch *traceChannel) handlePath(buf *traceBuf) (handle, path string) {
+	handle, ok := buf.string()
+	if !ok {
+		return "", ""
+	}
+	if path, ok := ch.handles[handle]; ok {
+		return handle, path
+	}
+	return handle, "handle:" + hex.EncodeToString([]byte(handle))
+}
+
+// SFTP v3 数据包类型（draft-ietf-secsh-filexfer-02）
+const (
+	sshFxpInit     = 1
+	sshFxpVersion  = 2
+	sshFxpOpen     = 3
+	sshFxpClose    = 4
+	sshFxpRead     = 5
+	sshFxpWrite    = 6
+	sshFxpLstat    = 7
+	sshFxpFstat    = 8
+	sshFxpSetstat  = 9
+	sshFxpFsetstat = 10
+	sshFxpOpendir  = 11
+	sshFxpReaddir  = 12
+	sshFxpRemove   = 13
+	sshFxpMkdir    = 14
+	sshFxpRmdir    = 15
+	sshFxpRealpath = 16
+	sshFxpStat     = 17
+	sshFxpRename   = 18
+	sshFxpReadlink = 19
+	sshFxpSymlink  = 20
+	sshFxpStatus   = 101
+	sshFxpHandle   = 102
+	sshFxpData     = 103
+	sshFxpName     = 104
+	sshFxpAttrs    = 105
+	sshFxpExtended = 200
+	sshFxpExtReply = 201
+)
+
+var tracePacketNames = map[byte]string{
+	sshFxpInit: "INIT", sshFxpVersion: "VERSION", sshFxpOpen: "OPEN", sshFxpClose: "CLOSE",
+	sshFxpRead: "READ", sshFxpWrite: "WRITE", sshFxpLstat: "LSTAT", sshFxpFstat: "FSTAT",
+	sshFxpSetstat: "SETSTAT", sshFxpFsetstat: "FSETSTAT", sshFxpOpendir: "OPENDIR", sshFxpReaddir: "READDIR",
+	sshFxpRemove: "REMOVE", sshFxpMkdir: "MKDIR", sshFxpRmdir: "RMDIR", sshFxpRealpath: "REALPATH",
+	sshFxpStat: "STAT", sshFxpRename: "RENAME", sshFxpReadlink: "READLINK", sshFxpSymlink: "SYMLINK",
+	sshFxpStatus: "STATUS", sshFxpHandle: "HANDLE", sshFxpData: "DATA", sshFxpName: "NAME",
+	sshFxpAttrs: "ATTRS", sshFxpExtended: "EXTENDED", sshFxpExtReply: "EXTENDED_REPLY",
+}
+
+func tracePacketType(typ byte) string {
+	if name, ok := tracePacketNames[typ]; ok {
+		return name
+	}
+	return fmt.Sprintf("type(%d)", typ)
+}
+
+var traceStatusNames = []string{
+	"OK", "EOF", "NO_SUCH_FILE", "PERMISSION_DENIED", "FAILURE",
+	"BAD_MESSAGE", "NO_CONNECTION", "CONNECTION_LOST", "OP_UNSUPPORTED",
+}
+
+func traceStatus(code uint32) string {
+	if int(code) < len(traceStatusNames) {
+		return traceStatusNames[code]
+	}
+	return fmt.Sprintf("status(%d)", code)
+}
+
+// traceBuf 按 SFTP 线格式读取字段；数据不足时返回 false
+type traceBuf []byte
+
+func (b *traceBuf) uint32() (uint32, bool) {
+	if len(*b) < 4 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint32(*b)
+	*b = (*b)[4:]
+	return v, true
+}
+
+func (b *traceBuf) uint64() (uint64, bool) {
+	if len(*b) < 8 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint64(*b)
+	*b = (*b)[8:]
+	return v, true
+}
+
+func (b *traceBuf) string() (string, bool) {
+	n, ok := b.uint32()
+	if !ok || uint32(len(*b)) < n {
+		return "", false
+	}
+	s := string((*b)[:n])
+	*b = (*b)[n:]
+	return s, true
+}
+
+// packetScanner 从字节流中切分 SFTP 数据包（4 字节长度 + 载荷），
+// 每个数据包只保留前 maxTraceHead 字节交给 emit
+type packetScanner struct {
+	head []byte // 当前数据包已保留的部分（含长度字段）
+	size int    // 当前数据包总长度（含长度字段），0 表示长度尚未读全
+	seen int    // 当前数据包已经过的字节数
+	emit func(pkt []byte)
+}
+
+func (s *packetScanner) feed(p []byte) {
+	for len(p) > 0 {
+		if s.size == 0 {
+			n := min(4-len(s.head), len(p))
+			s.head = append(s.head, p[:n]...)
+			s.seen += n
+			p = p[n:]
+			if len(s.head) < 4 {
+				return
+			}
+			s.size = 4 + int(binary.BigEndian.Uint32(s.head))
+		}
+		n := min(s.size-s.seen, len(p))
+		if room := maxTraceHead - len(s.head); room > 0 {
+			s.head = append(s.head, p[:min(n, room)]...)
+		}
+		s.seen += n
+		p = p[n:]
+		if s.seen == s.size {
+			s.emit(s.head[4:])
+			s.head, s.size, s.seen = s.head[:0], 0, 0
+		}
+	}
+}
+
+// traceReader / traceWriter 包装 SFTP 子系统的 stdout / stdin
+type traceReader struct {
+	r    io.Reader
+	scan packetScanner
+}
+
+func (r *traceReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.scan.feed(p[:n])
+	return n, err
+}
+
+type traceWriter struct {
+	w    io.WriteCloser
+	mu   sync.Mutex // 保证解析顺序与发送顺序一致
+	scan packetScanner
+}
+
+func (w *traceWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// 先记录再发送，保证响应到达时请求已登记
+	w.scan.feed(p)
+	return w.w.Write(p)
+}
+
+func (w *traceWriter) Close() error { return w.w.Close() }
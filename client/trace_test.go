@@ -0,0 +1,55 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPacketScannerSplitsStream(t *testing.T) {
+	var types []byte
+	s := packetScanner{emit: func(pkt []byte) { types = append(types, pkt[0]) }}
+	stream := append(sftpPacket(sshFxpStat, uint32(1), "/a"), sftpPacket(sshFxpWrite, uint32(2), "h", uint64(0), strings.Repeat("x", 3*maxTraceHead))...)
+	// 逐字节输入，数据包边界与 Write 调用边界无关
+	for i := range stream {
+		s.feed(stream[i : i+1])
+	}
+	if !bytes.Equal(types, []byte{sshFxpStat, sshFxpWrite}) {
+		t.Fatalf("types = %v", types)
+	}
+	if len(s.head) != 0 || s.size != 0 {
+		t.Fatalf("scanner not reset: size=%d head=%d", s.size, len(s.head))
+	}
+}
+
+func TestTraceChannelPairsResponses(t *testing.T) {
+	var out bytes.Buffer
+	ch := (&sftpTrace{enc: json.NewEncoder(&out)}).newChannel()
+	send := packetScanner{emit: ch.sent}
+	recv := packetScanner{emit: ch.received}
+
+	send.feed(sftpPacket(sshFxpOpen, uint32(1), "/srv/a.txt", uint32(1), uint32(0)))
+	recv.feed(sftpPacket(sshFxpHandle, uint32(1), "h1"))
+	send.feed(sftpPacket(sshFxpRead, uint32(2), "h1", uint64(4096), uint32(32768)))
+	recv.feed(sftpPacket(sshFxpStatus, uint32(2), uint32(1), "EOF", ""))
+
+	var recs []TraceRecord
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var rec TraceRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 4 {
+		t.Fatalf("got %d records", len(recs))
+	}
+	read, status := recs[2], recs[3]
+	if read.Type != "READ" || read.Path != "/srv/a.txt" || *read.Offset != 4096 || read.Length != 32768 {
+		t.Errorf("read = %+v", read)
+	}
+	if status.Request != "READ" || status.Path != "/srv/a.txt" || status.Status != "EOF" || status.Message != "EOF" {
+		t.Errorf("status = %+v", status)
+	}
+}
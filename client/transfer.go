@@ -1,8 +1,9 @@
 package client
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -12,8 +13,13 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/pkg/sftp"
 	"github.com/schollz/progressbar/v3"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/frostime/my-sftp/ttystate"
 )
 
 const (
@@ -23,25 +29,81 @@ const (
 
 // transferTask 表示单个传输任务
 type transferTask struct {
-	localPath  string // 本地文件路径
-	remotePath string // 远程文件路径
-	isUpload   bool   // true=上传, false=下载
-	size       int64  // 文件大小，用于进度显示
+	localPath  string      // 本地文件路径
+	remotePath string      // 远程文件路径
+	isUpload   bool        // true=上传, false=下载
+	size       int64       // 文件大小，用于进度显示
+	mode       os.FileMode // 源文件类型位（os.ModeType），0 表示普通文件
 }
 
 type transferSourceEntry struct {
 	path  string
 	isDir bool
 	size  int64
+	mode  os.FileMode
 }
 
 // TransferOptions 统一的传输选项
 type TransferOptions struct {
-	Recursive    bool // 递归处理目录
-	ShowProgress bool // 显示进度条
-	Concurrency  int  // 并发数
-	MaxDepth     int  // 最大递归深度：-1=无限, 0=仅当前目录, 1=一层子目录...
-}
+	Recursive    bool  // 递归处理目录
+	ShowProgress bool  // 显示进度条
+	Concurrency  int   // 并发数，0 表示客户端默认值（见 WithConcurrency）
+	MaxDepth     int   // 最大递归深度：-1=无限, 0=仅当前目录, 1=一层子目录...
+	ChunkSize    int64 // 大于该值的文件分块上传，0 表示不分块
+	// ParallelStreams 大文件按字节范围切分后的并行流数，<2 表示不并行
+	ParallelStreams int
+	// ParallelThreshold 启用并行流的文件大小下限，0 表示 DefaultParallelThreshold
+	ParallelThreshold int64
+	// Preserve 传输完成后将源文件的修改时间和权限位复制到目标（-p）
+	Preserve bool
+	// Scanner 上传前/下载后扫描本地文件，nil 表示不扫描
+	Scanner *ContentScanner
+	// Batch 传输所属的批次（单独取消、统计进度），nil 表示普通传输
+	Batch *Batch
+	// Checksum 下载时增量计算摘要并与服务器端的摘要比较；使用单个流，忽略 ParallelStreams
+	Checksum bool
+	// Hash Checksum 使用的算法名称，空值等同于 DefaultHashAlgorithm；服务器缺少对应程序时退回 sha256
+	Hash string
+	// FailFast 任一文件失败即中断进行中的传输、不再启动剩余文件；默认传输全部文件并汇总错误
+	FailFast bool
+	// TaskTimeout 单个文件的传输时限，0 表示不限时
+	TaskTimeout time.Duration
+	// Order 文件的启动顺序，空值等同于 OrderWalk
+	Order TransferOrder
+	// Denied 非 nil 时权限不足而失败的文件记为跳过而不是错误，见 DeniedSkips
+	Denied *DeniedSkips
+
+	workers   *workerScheduler // 由 executeTasks 设置为客户端的调度器
+	completed *completedTasks  // 非 nil 时记录成功完成的任务（同步据此更新 mtime 和状态）
+}
+
+// completedTasks executeTasks 中成功完成的任务，传输并发进行，需要加锁
+type completedTasks struct {
+	mu    sync.Mutex
+	tasks map[transferTask]bool
+}
+
+func (s *completedTasks) add(t transferTask) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tasks == nil {
+		s.tasks = make(map[transferTask]bool)
+	}
+	s.tasks[t] = true
+}
+
+// has 报告 t 是否已成功完成；失败、取消和按 DeniedSkip 跳过的任务都不算
+func (s *completedTasks) has(t transferTask) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tasks[t]
+}
+
+// DefaultParallelThreshold 默认启用多流并行传输的文件大小下限 (64MB)
+const DefaultParallelThreshold = 64 * 1024 * 1024
 
 func flattenCollisionError(base string) error {
 	return fmt.Errorf("duplicate basename in --flatten mode: %s\nHint: remove --flatten or narrow source set", base)
@@ -423,26 +485,24 @@ func DefaultTransferOptions() *TransferOptions {
 }
 
 // executeTasks 统一的任务执行引擎
-// 这是所有并发传输的唯一入口点，避免并发嵌套问题
+// 这是所有并发传输的唯一入口点，避免并发嵌套问题；取消、FailFast 和 TaskTimeout 由 runTaskPool 处理
 func (c *Client) executeTasks(tasks []transferTask, opts *TransferOptions) (int, error) {
 	if len(tasks) == 0 {
 		return 0, nil
 	}
-
-	concurrency := opts.Concurrency
-	if concurrency <= 0 {
-		concurrency = MaxConcurrentTransfers
-	}
-	if concurrency > len(tasks) {
-		concurrency = len(tasks)
+	defer c.trackTransfer(tasks[0].isUpload)()
+	tasks = orderTasks(tasks, opts.Order)
+	scheduled := *opts
+	if scheduled.Concurrency <= 0 {
+		scheduled.Concurrency = c.Concurrency()
 	}
+	// 并发数和并行流数超过服务器的句柄上限时，部分文件会以笼统的 "failure" 状态失败
+	scheduled.Concurrency, scheduled.ParallelStreams = c.reportedLimits().clampTransfers(scheduled.Concurrency, scheduled.ParallelStreams)
+	scheduled.Concurrency, scheduled.ParallelStreams = clampToFileLimit(scheduled.Concurrency, scheduled.ParallelStreams)
+	scheduled.workers = c.workers
+	opts = &scheduled
 
-	// 并发控制信号量
-	sem := make(chan struct{}, concurrency)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var errs []error
-	var successCount int32 = 0
+	var successCount atomic.Int32
 
 	// 计算总字节数和文件数
 	totalBytes := int64(0)
@@ -455,88 +515,293 @@ func (c *Client) executeTasks(tasks []transferTask, opts *TransferOptions) (int,
 	var globalBar *progressbar.ProgressBar
 	var completedFiles *atomic.Int32
 
+	var display *progressDisplay
 	if opts.ShowProgress {
-		globalBar = progressbar.NewOptions64(totalBytes,
-			progressbar.OptionSetDescription(fmt.Sprintf("Transferring (0/%d files)", totalFiles)),
-			progressbar.OptionShowBytes(true),
-			progressbar.OptionSetWidth(40),
-			progressbar.OptionSetPredictTime(true),
-			progressbar.OptionClearOnFinish(),
-		)
+		display = c.startProgress(totalBytes, fmt.Sprintf("Transferring (0/%d files)", totalFiles), true)
+		globalBar = display.bar
 		completedFiles = &atomic.Int32{}
 	}
 
-	for _, task := range tasks {
-		wg.Add(1)
-		sem <- struct{}{} // 获取信号量
-
-		go func(t transferTask) {
-			defer wg.Done()
-			defer func() { <-sem }() // 释放信号量
-
-			// panic 保护
-			defer func() {
-				if r := recover(); r != nil {
-					mu.Lock()
-					errs = append(errs, fmt.Errorf("panic during transfer %s: %v\nstack: %s",
-						t.localPath, r, debug.Stack()))
-					mu.Unlock()
-				}
-			}()
-
-			// 显示当前正在传输的文件（多文件模式）
-			if globalBar != nil {
-				fileName := filepath.Base(t.localPath)
-				if !t.isUpload {
-					fileName = path.Base(t.remotePath)
-				}
-				count := completedFiles.Load()
-				globalBar.Describe(fmt.Sprintf("Transferring %s (%d/%d files)", fileName, count, totalFiles))
+	opts.Batch.plan(totalFiles, totalBytes)
+	ctx, stop := opts.Batch.context(c.transferContext())
+	defer stop()
+	if opts.Batch != nil {
+		ctx = withBackground(ctx)
+	}
+	space := c.newSpaceGate(tasks, opts.Batch != nil)
+	_, errs := runTaskPool(ctx, totalFiles, opts, func(ctx context.Context, i int) (err error) {
+		t, index := tasks[i], i+1
+
+		// panic 保护
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic during transfer %s: %v\nstack: %s", t.localPath, r, debug.Stack())
+			}
+		}()
+
+		// 远程空间不足时等待用户释放空间
+		if err := space.wait(ctx); err != nil {
+			return err
+		}
+		ctx, release := c.acquireChannel(ctx)
+		defer release()
+
+		// 显示当前正在传输的文件（多文件模式）
+		if globalBar != nil {
+			fileName := filepath.Base(t.localPath)
+			if !t.isUpload {
+				fileName = path.Base(t.remotePath)
+			}
+			count := completedFiles.Load()
+			globalBar.Describe(fmt.Sprintf("Transferring %s (%d/%d files)", fileName, count, totalFiles))
+		}
+
+		c.emit(batchTaskEvent(EventTransferStarted, t, index, totalFiles, opts.Batch))
+		progressEvent := batchTaskEvent(EventProgress, t, index, totalFiles, opts.Batch)
+		progress := c.newTransferProgress(globalBar, &progressEvent)
+
+		err = c.runTask(ctx, t, opts, progress)
+		for attempt := 1; err != nil; attempt++ {
+			retry, final := space.pause(ctx, t, err)
+			if !retry {
+				err = final
+				break
 			}
+			// 释放空间后从头重新上传该文件
+			progress.rewind()
+			ev := batchTaskEvent(EventRetry, t, index, totalFiles, opts.Batch)
+			ev.Attempt, ev.Err = attempt, err
+			c.emit(ev)
+			err = c.runTask(ctx, t, opts, progress)
+		}
+		if err != nil && isSourceError(err) && opts.Denied.skip(taskSourcePath(t), err) == nil {
+			// 无权限读取的源文件按 DeniedSkip 跳过，不算失败也不算完成
+			opts.Batch.finish(nil)
+			space.done(t)
+			return nil
+		}
+		opts.Batch.finish(err)
 
-			var err error
+		if err != nil {
+			err = fileLimitHint(err)
+			ev := batchTaskEvent(EventError, t, index, totalFiles, opts.Batch)
+			ev.Err = err
+			c.emit(ev)
 			if t.isUpload {
-				err = c.UploadWithProgress(t.localPath, t.remotePath, globalBar)
-			} else {
-				err = c.DownloadWithProgress(t.remotePath, t.localPath, globalBar)
+				return &fileError{op: "upload", path: t.localPath, target: t.remotePath, err: err}
 			}
+			return &fileError{op: "download", path: t.remotePath, target: t.localPath, err: err}
+		}
 
-			if err != nil {
-				mu.Lock()
-				if t.isUpload {
-					errs = append(errs, fmt.Errorf("upload %s: %w", t.localPath, err))
-				} else {
-					errs = append(errs, fmt.Errorf("download %s: %w", t.remotePath, err))
-				}
-				mu.Unlock()
-			} else {
-				atomic.AddInt32(&successCount, 1)
-				// 文件完成后打印确认信息并更新计数
-				if globalBar != nil && completedFiles != nil {
-					count := completedFiles.Add(1)
-					fileName := filepath.Base(t.localPath)
-					if !t.isUpload {
-						fileName = path.Base(t.remotePath)
-					}
-					// 打印完成信息
-					fmt.Printf("\r\033[K✓ %s (%s)\n", fileName, FormatSize(t.size))
-					globalBar.Describe(fmt.Sprintf("Transferring (%d/%d files)", count, totalFiles))
-				}
+		successCount.Add(1)
+		opts.completed.add(t)
+		space.done(t)
+		ev := batchTaskEvent(EventCompleted, t, index, totalFiles, opts.Batch)
+		ev.Bytes = t.size
+		c.emit(ev)
+		// 文件完成后更新计数（完成信息由事件订阅者显示）
+		if globalBar != nil && completedFiles != nil {
+			count := completedFiles.Add(1)
+			globalBar.Describe(fmt.Sprintf("Transferring (%d/%d files)", count, totalFiles))
+		}
+		return nil
+	})
+
+	if display != nil {
+		display.finish()
+	}
+
+	if errs = space.collapse(errs); len(errs) > 0 {
+		return int(successCount.Load()), joinFileErrors(errs)
+	}
+	return int(successCount.Load()), nil
+}
+
+// runTask 传输单个文件，包括上传前扫描、属性保留和下载后扫描
+func (c *Client) runTask(ctx context.Context, t transferTask, opts *TransferOptions, progress *transferProgress) error {
+	var err error
+	if t.isUpload {
+		if err := opts.Scanner.CheckUpload(t.localPath); err != nil {
+			return err
+		}
+	}
+
+	if t.isUpload && opts.ChunkSize > 0 && t.size > opts.ChunkSize {
+		err = c.uploadChunkedWithProgress(ctx, t.localPath, t.remotePath, opts.ChunkSize, progress)
+	} else if !t.isUpload && opts.Checksum {
+		var alg *HashAlgorithm
+		if alg, err = LookupHashAlgorithm(opts.Hash); err == nil {
+			err = c.downloadChecksummed(ctx, alg, t.remotePath, t.localPath, progress)
+		}
+	} else if opts.useParallel(t.size) {
+		err = c.transferParallel(ctx, t, opts.ParallelStreams, progress)
+	} else if t.isUpload {
+		err = c.uploadFile(ctx, t.localPath, t.remotePath, progress)
+	} else {
+		err = c.downloadFile(ctx, t.remotePath, t.localPath, progress, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Preserve {
+		if err := c.preserveAttributes(t); err != nil {
+			return fmt.Errorf("preserve attributes: %w", err)
+		}
+	}
+	if !t.isUpload {
+		return opts.Scanner.CheckDownload(t.localPath)
+	}
+	return nil
+}
+
+// useParallel 判断文件是否使用多流并行传输
+func (opts *TransferOptions) useParallel(size int64) bool {
+	if opts.ParallelStreams < 2 {
+		return false
+	}
+	threshold := opts.ParallelThreshold
+	if threshold <= 0 {
+		threshold = DefaultParallelThreshold
+	}
+	return size >= threshold
+}
+
+// byteRange 并行传输中一个流负责的区间 [offset, offset+length)
+type byteRange struct {
+	offset int64
+	length int64
+}
+
+// splitByteRanges 将 size 字节均分为最多 streams 段
+func splitByteRanges(size int64, streams int) []byteRange {
+	if streams < 1 {
+		streams = 1
+	}
+	if size <= 0 {
+		return []byteRange{{0, 0}}
+	}
+	if int64(streams) > size {
+		streams = int(size)
+	}
+	step := (size + int64(streams) - 1) / int64(streams)
+	ranges := make([]byteRange, 0, streams)
+	for offset := int64(0); offset < size; offset += step {
+		length := step
+		if offset+length > size {
+			length = size - offset
+		}
+		ranges = append(ranges, byteRange{offset: offset, length: length})
+	}
+	return ranges
+}
+
+// transferParallel 将单个大文件按字节范围切分，每段由独立 goroutine 传输并直接写入目标文件的对应偏移，
+// 因此无需额外拼接。单个 SFTP 通道受 SSH 窗口限制，在高延迟链路上多通道可以显著提高吞吐。
+// 第一段使用任务已分配的通道，其余各段通过 acquireChannel 从通道池分配，
+// 总通道数不超过 SFTPChannels；流数多于通道数时几段共用一个通道
+func (c *Client) transferParallel(ctx context.Context, task transferTask, streams int, progress *transferProgress) error {
+	ranges := splitByteRanges(task.size, streams)
+
+	// 先创建（截断）目标文件，各个流再以写模式打开
+	if task.isUpload {
+		dst, err := c.sftpConn().Create(task.remotePath)
+		if err != nil {
+			return fmt.Errorf("create remote: %w", err)
+		}
+		dst.Close()
+	} else {
+		dst, err := os.Create(task.localPath)
+		if err != nil {
+			return fmt.Errorf("create local: %w", err)
+		}
+		dst.Close()
+	}
+
+	// 任一区间失败即取消其余区间：文件已不完整，继续传输没有意义
+	g, ctx := errgroup.WithContext(ctx)
+	for i, r := range ranges {
+		g.Go(func() error {
+			defer ttystate.Guard()
+			rctx := ctx
+			if i > 0 {
+				var release func()
+				rctx, release = c.acquireChannel(ctx)
+				defer release()
+			}
+			if err := c.transferRange(rctx, c.dataClient(rctx), task, r, progress); err != nil {
+				return fmt.Errorf("range %d-%d: %w", r.offset, r.offset+r.length, err)
 			}
-		}(task)
+			return nil
+		})
 	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	var info os.FileInfo
+	var err error
+	if task.isUpload {
+		info, err = c.sftpConn().Stat(task.remotePath)
+	} else {
+		info, err = os.Stat(task.localPath)
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() != task.size {
+		return fmt.Errorf("size mismatch after parallel transfer: got %d, want %d", info.Size(), task.size)
+	}
+	return nil
+}
 
-	wg.Wait()
+// transferRange 通过 sc 传输 task 的一个字节区间
+func (c *Client) transferRange(ctx context.Context, sc *sftp.Client, task transferTask, r byteRange, progress *transferProgress) error {
+	buf := c.getBuffer()
+	defer c.putBuffer(buf)
 
-	if globalBar != nil {
-		globalBar.Finish()
-		fmt.Println() // 换行
+	var src io.Reader
+	var dst io.Writer
+	if task.isUpload {
+		local, err := os.Open(task.localPath)
+		if err != nil {
+			return &sourceError{err}
+		}
+		defer local.Close()
+		remote, err := sc.OpenFile(task.remotePath, os.O_WRONLY)
+		if err != nil {
+			return err
+		}
+		defer remote.Close()
+		if _, err := remote.Seek(r.offset, io.SeekStart); err != nil {
+			return err
+		}
+		src, dst = io.NewSectionReader(local, r.offset, r.length), remote
+	} else {
+		remote, err := sc.Open(task.remotePath)
+		if err != nil {
+			return &sourceError{err}
+		}
+		defer remote.Close()
+		if _, err := remote.Seek(r.offset, io.SeekStart); err != nil {
+			return err
+		}
+		local, err := os.OpenFile(task.localPath, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer local.Close()
+		src, dst = io.LimitReader(remote, r.length), io.NewOffsetWriter(local, r.offset)
 	}
 
-	if len(errs) > 0 {
-		return int(successCount), errors.Join(errs...)
+	if progress != nil {
+		dst = io.MultiWriter(dst, progress)
 	}
-	return int(successCount), nil
+	n, err := io.CopyBuffer(dst, &contextReader{ctx: ctx, r: src}, buf)
+	if err == nil && n != r.length {
+		err = fmt.Errorf("short transfer: %d of %d bytes", n, r.length)
+	}
+	return err
 }
 
 // collectDownloadTasks 收集下载任务（不执行传输）
@@ -544,36 +809,42 @@ func (c *Client) executeTasks(tasks []transferTask, opts *TransferOptions) (int,
 // localDir: 本地目录路径
 // maxDepth: 最大递归深度，-1表示无限
 // currentDepth: 当前深度（内部使用）
-func (c *Client) collectDownloadTasks(remoteDir, localDir string, maxDepth, currentDepth int) ([]transferTask, error) {
+// denied: 非 nil 时跳过无权限读取的目录（见 DeniedSkips）
+func (c *Client) collectDownloadTasks(remoteDir, localDir string, maxDepth, currentDepth int, filter *PathFilter, relDir string, denied *DeniedSkips) ([]transferTask, error) {
 	var tasks []transferTask
 
-	entries, err := c.sftpClient.ReadDir(remoteDir)
+	entries, err := c.sftpConn().ReadDir(remoteDir)
 	if err != nil {
-		return nil, fmt.Errorf("read remote dir %s: %w", remoteDir, err)
+		return nil, denied.skip(remoteDir, fmt.Errorf("read remote dir %s: %w", remoteDir, err))
 	}
 
 	for _, entry := range entries {
 		remotePath := path.Join(remoteDir, entry.Name())
 		localPath := filepath.Join(localDir, entry.Name())
+		rel := joinFilterPath(relDir, entry.Name())
 
 		if entry.IsDir() {
 			// 检查深度限制
 			if maxDepth >= 0 && currentDepth >= maxDepth {
 				continue // 超过深度限制，跳过此目录
 			}
+			if filter.SkipDir(rel) {
+				continue
+			}
 
 			// 递归收集子目录任务
-			subTasks, err := c.collectDownloadTasks(remotePath, localPath, maxDepth, currentDepth+1)
+			subTasks, err := c.collectDownloadTasks(remotePath, localPath, maxDepth, currentDepth+1, filter, rel, denied)
 			if err != nil {
 				return nil, err
 			}
 			tasks = append(tasks, subTasks...)
-		} else {
+		} else if filter.AllowFile(rel) {
 			tasks = append(tasks, transferTask{
 				localPath:  localPath,
 				remotePath: remotePath,
 				isUpload:   false,
 				size:       entry.Size(),
+				mode:       entry.Mode().Type(),
 			})
 		}
 	}
@@ -586,33 +857,39 @@ func (c *Client) collectDownloadTasks(remoteDir, localDir string, maxDepth, curr
 // remoteDir: 远程目录路径
 // maxDepth: 最大递归深度，-1表示无限
 // currentDepth: 当前深度（内部使用）
-func (c *Client) collectUploadTasks(localDir, remoteDir string, maxDepth, currentDepth int) ([]transferTask, []string, error) {
+// filter/relDir: include/exclude 过滤器及 localDir 相对于过滤根目录的路径
+// denied: 非 nil 时跳过无权限读取的目录（见 DeniedSkips），不在远程创建对应的空目录
+func (c *Client) collectUploadTasks(localDir, remoteDir string, maxDepth, currentDepth int, filter *PathFilter, relDir string, denied *DeniedSkips) ([]transferTask, []string, error) {
 	var tasks []transferTask
 	var emptyDirs []string
 
 	entries, err := os.ReadDir(localDir)
 	if err != nil {
-		return nil, nil, fmt.Errorf("read local dir %s: %w", localDir, err)
+		return nil, nil, denied.skip(localDir, fmt.Errorf("read local dir %s: %w", localDir, err))
 	}
 
 	for _, entry := range entries {
 		localPath := filepath.Join(localDir, entry.Name())
 		remotePath := path.Join(remoteDir, entry.Name())
+		rel := joinFilterPath(relDir, entry.Name())
 
 		if entry.IsDir() {
 			// 检查深度限制
 			if maxDepth >= 0 && currentDepth >= maxDepth {
 				continue // 超过深度限制，跳过此目录
 			}
+			if filter.SkipDir(rel) {
+				continue
+			}
 
 			// 递归收集子目录任务
-			subTasks, subEmptyDirs, err := c.collectUploadTasks(localPath, remotePath, maxDepth, currentDepth+1)
+			subTasks, subEmptyDirs, err := c.collectUploadTasks(localPath, remotePath, maxDepth, currentDepth+1, filter, rel, denied)
 			if err != nil {
 				return nil, nil, err
 			}
 			tasks = append(tasks, subTasks...)
 			emptyDirs = append(emptyDirs, subEmptyDirs...)
-		} else {
+		} else if filter.AllowFile(rel) {
 			info, err := entry.Info()
 			if err != nil {
 				continue // 跳过无法获取信息的文件
@@ -622,6 +899,7 @@ func (c *Client) collectUploadTasks(localDir, remoteDir string, maxDepth, curren
 				remotePath: remotePath,
 				isUpload:   true,
 				size:       info.Size(),
+				mode:       info.Mode().Type(),
 			})
 		}
 	}
@@ -664,14 +942,3 @@ func (c *Client) collectRemoteDirsForUpload(tasks []transferTask) []string {
 	})
 	return dirs
 }
-
-// ensureRemoteDirsExist 确保所有远程目录存在
-// 使用 singleflight 避免重复创建
-func (c *Client) ensureRemoteDirsExist(dirs []string) error {
-	for _, dir := range dirs {
-		if err := c.ensureRemoteDir(dir); err != nil {
-			return err
-		}
-	}
-	return nil
-}
@@ -1,12 +1,17 @@
 package client
 
 import (
+	"fmt"
+	"math/rand"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
+
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 func TestExplicitRemoteFilePreservePath(t *testing.T) {
@@ -506,3 +511,124 @@ func TestUsesReservedPreservePrefix(t *testing.T) {
 		t.Fatal("did not expect parent-relative source to count as reserved prefix")
 	}
 }
+
+func TestSampleSpotCheckTasks(t *testing.T) {
+	tasks := make([]transferTask, 10)
+	for i := range tasks {
+		tasks[i] = transferTask{localPath: fmt.Sprintf("f%02d", i), isUpload: true}
+	}
+	rng := rand.New(rand.NewSource(1))
+
+	if got := sampleSpotCheckTasks(tasks, 0, rng); len(got) != 0 {
+		t.Fatalf("0%% sample = %d tasks, want 0", len(got))
+	}
+	if got := sampleSpotCheckTasks(tasks, 1, rng); len(got) != 1 {
+		t.Fatalf("1%% sample = %d tasks, want at least 1", len(got))
+	}
+	if got := sampleSpotCheckTasks(tasks, 100, rng); len(got) != len(tasks) {
+		t.Fatalf("100%% sample = %d tasks, want %d", len(got), len(tasks))
+	}
+
+	got := sampleSpotCheckTasks(tasks, 25, rng)
+	if len(got) != 3 {
+		t.Fatalf("25%% sample = %d tasks, want 3", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i-1].localPath >= got[i].localPath {
+			t.Fatalf("sample not in task order: %v", got)
+		}
+	}
+}
+
+func TestSplitByteRanges(t *testing.T) {
+	tests := []struct {
+		size    int64
+		streams int
+		want    []byteRange
+	}{
+		{size: 10, streams: 3, want: []byteRange{{0, 4}, {4, 4}, {8, 2}}},
+		{size: 8, streams: 4, want: []byteRange{{0, 2}, {2, 2}, {4, 2}, {6, 2}}},
+		{size: 2, streams: 4, want: []byteRange{{0, 1}, {1, 1}}},
+		{size: 0, streams: 4, want: []byteRange{{0, 0}}},
+	}
+	for _, tt := range tests {
+		got := splitByteRanges(tt.size, tt.streams)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Fatalf("splitByteRanges(%d, %d) = %v, want %v", tt.size, tt.streams, got, tt.want)
+		}
+	}
+}
+
+func TestTransferOptionsUseParallel(t *testing.T) {
+	opts := &TransferOptions{ParallelStreams: 4}
+	if opts.useParallel(DefaultParallelThreshold-1) || !opts.useParallel(DefaultParallelThreshold) {
+		t.Fatal("default threshold not applied")
+	}
+	opts.ParallelThreshold = 1024
+	if !opts.useParallel(1024) || opts.useParallel(1023) {
+		t.Fatal("custom threshold not applied")
+	}
+	if (&TransferOptions{ParallelStreams: 1}).useParallel(1 << 40) {
+		t.Fatal("single stream must not use parallel engine")
+	}
+}
+
+func TestLocalGlobBaseKeepsRoot(t *testing.T) {
+	tests := map[string]string{
+		"src/**/*.go":      "src",
+		"/tmp/src/**/*.go": "/tmp/src",
+		"/*.txt":           "/",
+		"*.txt":            ".",
+	}
+	for pattern, want := range tests {
+		if got := localGlobBase(filepath.FromSlash(pattern)); got != filepath.FromSlash(want) {
+			t.Errorf("localGlobBase(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestRemoteGlobWalk(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		wantRoot  string
+		wantDepth int
+	}{
+		{"/var/log/*.log", "/var/log", 1},
+		{"/var/log/*/app.log", "/var/log", 2},
+		{"/srv/**/*.go", "/srv", -1},
+		{"/*.txt", "/", 1},
+	}
+	for _, tt := range tests {
+		root, depth := remoteGlobWalk(tt.pattern)
+		if root != tt.wantRoot || depth != tt.wantDepth {
+			t.Errorf("remoteGlobWalk(%q) = (%q, %d), want (%q, %d)", tt.pattern, root, depth, tt.wantRoot, tt.wantDepth)
+		}
+	}
+}
+
+// FuzzRemoteGlobWalk 检查 globRemote 的遍历范围覆盖所有能匹配模式的路径
+func FuzzRemoteGlobWalk(f *testing.F) {
+	for _, seed := range []string{"/var/log/*.log", "/a/*/b/*.txt", "/srv/**/*.go", "/*", "/a/b?/c", "/x/[ab]*/y"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, pattern string) {
+		// 过长的模式会让 doublestar 的回溯匹配变慢，不增加覆盖
+		if len(pattern) > 64 || strings.Count(pattern, "*") > 8 || !strings.HasPrefix(pattern, "/") || !strings.ContainsAny(pattern, "*?[]") || !doublestar.ValidatePattern(pattern) {
+			return
+		}
+		root, maxDepth := remoteGlobWalk(pattern)
+
+		// 用具体名字替换通配符，得到一个（可能）匹配模式的路径
+		sample := strings.NewReplacer("**", "d1/d2", "*", "x", "?", "y").Replace(pattern)
+		if ok, _ := doublestar.Match(pattern, sample); !ok || path.Clean(sample) != sample {
+			return
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(sample, root), "/")
+		if root != "/" && !strings.HasPrefix(sample, root+"/") || rel == "" {
+			t.Fatalf("remoteGlobWalk(%q) root %q does not contain matching path %q", pattern, root, sample)
+		}
+		if depth := strings.Count(rel, "/") + 1; maxDepth >= 0 && depth > maxDepth {
+			t.Fatalf("remoteGlobWalk(%q) depth %d cannot reach matching path %q (depth %d)", pattern, maxDepth, sample, depth)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frostime/my-sftp/units"
+)
+
+// TreeSnapshot 远程目录树的元数据快照，用于比较两次部署之间服务器上发生的变化。
+// Entries 的 Path 是相对于 Root 的路径（/ 分隔）
+type TreeSnapshot struct {
+	Host    string        `json:"host"`
+	Root    string        `json:"root"`
+	Created time.Time     `json:"created"`
+	Entries []ExportEntry `json:"entries"`
+}
+
+// ChangeKind 快照比较中一个路径的变化类型
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// TreeChange 两个快照之间一个路径的变化
+type TreeChange struct {
+	Kind    ChangeKind
+	Path    string       // 相对于快照根目录
+	Old     *ExportEntry // Added 时为 nil
+	New     *ExportEntry // Removed 时为 nil
+	Details []string     // Modified 时变化的属性，如 "size 10 B -> 12 B"
+}
+
+// TakeTreeSnapshot 递归记录远程目录下所有条目的类型、大小、mtime、权限和属主
+func (c *Client) TakeTreeSnapshot(remoteDir string) (*TreeSnapshot, error) {
+	root := c.ResolveRemotePath(remoteDir)
+	info, err := c.sftpConn().Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+
+	snap := &TreeSnapshot{
+		Host:    c.hostLabel(),
+		Root:    root,
+		Created: time.Now().UTC().Truncate(time.Second),
+	}
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	err = c.walkListing(root, true, func(entry ExportEntry) error {
+		entry.Path = strings.TrimPrefix(entry.Path, prefix)
+		snap.Entries = append(snap.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snap.Entries, func(i, j int) bool { return snap.Entries[i].Path < snap.Entries[j].Path })
+	return snap, nil
+}
+
+// Save 将快照写入本地 JSON 文件（先写临时文件再重命名）
+func (s *TreeSnapshot) Save(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// LoadTreeSnapshot 读取 Save 写出的快照文件
+func LoadTreeSnapshot(file string) (*TreeSnapshot, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	snap := &TreeSnapshot{}
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, fmt.Errorf("parse snapshot %s: %w", file, err)
+	}
+	return snap, nil
+}
+
+// DiffTreeSnapshots 比较两个快照，按路径排序返回新增、删除和修改的条目。
+// 目录的 mtime 随子项增删而变化，因此目录只比较类型、权限和属主
+func DiffTreeSnapshots(before, after *TreeSnapshot) []TreeChange {
+	old := make(map[string]*ExportEntry, len(before.Entries))
+	for i := range before.Entries {
+		old[before.Entries[i].Path] = &before.Entries[i]
+	}
+
+	var changes []TreeChange
+	seen := make(map[string]struct{}, len(after.Entries))
+	for i := range after.Entries {
+		cur := &after.Entries[i]
+		seen[cur.Path] = struct{}{}
+		prev, ok := old[cur.Path]
+		if !ok {
+			changes = append(changes, TreeChange{Kind: ChangeAdded, Path: cur.Path, New: cur})
+			continue
+		}
+		if details := entryChanges(prev, cur); len(details) > 0 {
+			changes = append(changes, TreeChange{Kind: ChangeModified, Path: cur.Path, Old: prev, New: cur, Details: details})
+		}
+	}
+	for i := range before.Entries {
+		prev := &before.Entries[i]
+		if _, ok := seen[prev.Path]; !ok {
+			changes = append(changes, TreeChange{Kind: ChangeRemoved, Path: prev.Path, Old: prev})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// entryChanges 返回同一路径两次记录之间变化的属性
+func entryChanges(old, cur *ExportEntry) []string {
+	if old.Type != cur.Type {
+		return []string{fmt.Sprintf("type %s -> %s", old.Type, cur.Type)}
+	}
+	var details []string
+	if cur.Type != "dir" {
+		if old.Size != cur.Size {
+			details = append(details, fmt.Sprintf("size %s -> %s", units.FormatSize(old.Size), units.FormatSize(cur.Size)))
+		}
+		if !old.ModTime.Equal(cur.ModTime) {
+			details = append(details, fmt.Sprintf("mtime %s -> %s",
+				old.ModTime.Local().Format("2006-01-02 15:04:05"), cur.ModTime.Local().Format("2006-01-02 15:04:05")))
+		}
+	}
+	if old.Mode != cur.Mode {
+		details = append(details, fmt.Sprintf("mode %s -> %s", old.Mode, cur.Mode))
+	}
+	if optionalID(old.UID) != optionalID(cur.UID) || optionalID(old.GID) != optionalID(cur.GID) {
+		details = append(details, fmt.Sprintf("owner %s:%s -> %s:%s",
+			optionalID(old.UID), optionalID(old.GID), optionalID(cur.UID), optionalID(cur.GID)))
+	}
+	return details
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffTreeSnapshots(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	before := &TreeSnapshot{Entries: []ExportEntry{
+		{Path: "app", Type: "dir", ModTime: t0, Mode: "drwxr-xr-x"},
+		{Path: "app/main.js", Type: "file", Size: 10, ModTime: t0, Mode: "-rw-r--r--"},
+		{Path: "app/old.css", Type: "file", Size: 5, ModTime: t0, Mode: "-rw-r--r--"},
+		{Path: "run.sh", Type: "file", Size: 20, ModTime: t0, Mode: "-rw-r--r--"},
+		{Path: "same.txt", Type: "file", Size: 1, ModTime: t0, Mode: "-rw-r--r--"},
+	}}
+	after := &TreeSnapshot{Entries: []ExportEntry{
+		// 目录的 mtime 变化不算修改
+		{Path: "app", Type: "dir", ModTime: t1, Mode: "drwxr-xr-x"},
+		{Path: "app/main.js", Type: "file", Size: 12, ModTime: t1, Mode: "-rw-r--r--"},
+		{Path: "app/new.js", Type: "file", Size: 3, ModTime: t1, Mode: "-rw-r--r--"},
+		{Path: "run.sh", Type: "file", Size: 20, ModTime: t0, Mode: "-rwxr-xr-x"},
+		{Path: "same.txt", Type: "file", Size: 1, ModTime: t0, Mode: "-rw-r--r--"},
+	}}
+
+	changes := DiffTreeSnapshots(before, after)
+	var got []string
+	for _, change := range changes {
+		got = append(got, string(change.Kind)+" "+change.Path)
+	}
+	want := []string{"modified app/main.js", "added app/new.js", "removed app/old.css", "modified run.sh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffTreeSnapshots() = %v, want %v", got, want)
+	}
+	if d := changes[0].Details; len(d) != 2 || d[0] != "size 10 B -> 12 B" {
+		t.Errorf("main.js details = %v", d)
+	}
+	if d := changes[3].Details; len(d) != 1 || d[0] != "mode -rw-r--r-- -> -rwxr-xr-x" {
+		t.Errorf("run.sh details = %v", d)
+	}
+}
+
+func TestTreeSnapshotSaveLoad(t *testing.T) {
+	uid := uint32(1000)
+	snap := &TreeSnapshot{
+		Host:    "deploy@example.com:22",
+		Root:    "/srv/www",
+		Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Entries: []ExportEntry{{Path: "index.html", Type: "file", Size: 42, ModTime: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Mode: "-rw-r--r--", UID: &uid, GID: &uid}},
+	}
+	file := filepath.Join(t.TempDir(), "snapshots", "v1.json")
+	if err := snap.Save(file); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadTreeSnapshot(file)
+	if err != nil {
+		t.Fatalf("LoadTreeSnapshot() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, snap) {
+		t.Fatalf("LoadTreeSnapshot() = %+v, want %+v", loaded, snap)
+	}
+}
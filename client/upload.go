@@ -1,12 +1,15 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/schollz/progressbar/v3"
@@ -23,37 +26,39 @@ func (c *Client) Upload(localPath, remotePath string) error {
 	}
 
 	// 创建单文件进度条（显示文件名）
-	bar := progressbar.NewOptions64(stat.Size(),
-		progressbar.OptionSetDescription(fmt.Sprintf("Uploading %s (1/1 files)", filepath.Base(localPath))),
-		progressbar.OptionShowBytes(true),
-		progressbar.OptionSetWidth(40),
-		progressbar.OptionSetPredictTime(true),
-	)
-	defer bar.Finish()
-	defer fmt.Println()
-
-	return c.UploadWithProgress(localPath, remotePath, bar)
+	display := c.startProgress(stat.Size(), fmt.Sprintf("Uploading %s (1/1 files)", filepath.Base(localPath)), false)
+	defer display.finish()
+	defer c.trackTransfer(true)()
+
+	progressEvent, done := c.singleFileEvents(transferTask{localPath: localPath, remotePath: c.ResolveRemotePath(remotePath), isUpload: true, size: stat.Size()})
+	err = c.uploadFile(c.transferContext(), localPath, remotePath, c.newTransferProgress(display.bar, progressEvent))
+	done(err)
+	return err
 }
 
 // UploadWithProgress 上传文件（支持进度条）
 func (c *Client) UploadWithProgress(localPath, remotePath string, globalBar *progressbar.ProgressBar) error {
+	return c.uploadFile(c.transferContext(), localPath, remotePath, c.newTransferProgress(globalBar, nil))
+}
+
+func (c *Client) uploadFile(ctx context.Context, localPath, remotePath string, progress *transferProgress) error {
 	localPath = c.ResolveLocalPath(localPath)
 	remotePath = c.ResolveRemotePath(remotePath)
 
 	// 获取本地文件信息（确保文件存在）
 	_, err := os.Stat(localPath)
 	if err != nil {
-		return fmt.Errorf("stat local: %w", err)
+		return &sourceError{fmt.Errorf("stat local: %w", err)}
 	}
 
 	srcFile, err := os.Open(localPath)
 	if err != nil {
-		return fmt.Errorf("open local: %w", err)
+		return &sourceError{fmt.Errorf("open local: %w", err)}
 	}
 	defer srcFile.Close()
 
 	// 如果远程路径是目录，使用本地文件名
-	if remoteStat, err := c.sftpClient.Stat(remotePath); err == nil && remoteStat.IsDir() {
+	if remoteStat, err := c.sftpConn().Stat(remotePath); err == nil && remoteStat.IsDir() {
 		remotePath = path.Join(remotePath, filepath.Base(localPath))
 	}
 	parent := path.Dir(remotePath)
@@ -63,7 +68,7 @@ func (c *Client) UploadWithProgress(localPath, remotePath string, globalBar *pro
 		}
 	}
 
-	dstFile, err := c.sftpClient.Create(remotePath)
+	dstFile, err := c.dataClient(ctx).Create(remotePath)
 	if err != nil {
 		return fmt.Errorf("create remote: %w", err)
 	}
@@ -75,11 +80,11 @@ func (c *Client) UploadWithProgress(localPath, remotePath string, globalBar *pro
 
 	// 使用缓冲和进度条
 	var writer io.Writer = dstFile
-	if globalBar != nil {
-		writer = io.MultiWriter(dstFile, globalBar)
+	if progress != nil {
+		writer = io.MultiWriter(dstFile, progress)
 	}
 
-	_, err = io.CopyBuffer(writer, srcFile, buf)
+	_, err = io.CopyBuffer(writer, &contextReader{ctx: ctx, r: srcFile}, buf)
 	return err
 }
 
@@ -87,9 +92,42 @@ func (c *Client) UploadWithProgress(localPath, remotePath string, globalBar *pro
 type UploadOptions struct {
 	Recursive    bool // 递归上传目录
 	ShowProgress bool // 显示进度条
-	Concurrency  int  // 并发数
+	Concurrency  int  // 并发数，0 表示客户端默认值（见 WithConcurrency）
 	Flatten      bool // 扁平化目标路径
 	MaxDepth     int  // 最大递归深度：-1=无限, 0=仅当前目录, 1=一层子目录...
+	// SpotCheckPercent 上传成功后随机重新下载该百分比的文件校验内容，0 表示不校验
+	SpotCheckPercent float64
+	// SpotCheckHash 抽查使用的算法名称（两端都在本地计算），空值等同于 DefaultHashAlgorithm
+	SpotCheckHash string
+	// ChunkSize 大于该值的文件分块上传并在服务器端拼接，0 表示不分块
+	ChunkSize int64
+	// RecreateSpecial 重建符号链接和 FIFO，而不是跟随链接/跳过
+	RecreateSpecial bool
+	// Verify 传输后重新比较源与目标的大小，不一致时返回 *VerifyError
+	Verify bool
+	// ParallelStreams 不小于 ParallelThreshold 的文件拆成多个字节范围并行传输，<2 表示不并行
+	ParallelStreams   int
+	ParallelThreshold int64
+	// Filter 递归上传时的 include/exclude 过滤，nil 表示不过滤
+	Filter *PathFilter
+	// Overwrite 目标已存在时的处理策略，空值等同于 OverwriteAlways
+	Overwrite OverwritePolicy
+	// Preserve 保留源文件的修改时间和权限位（-p）
+	Preserve bool
+	// Scanner 上传前扫描本地文件，nil 表示不扫描
+	Scanner *ContentScanner
+	// Router 按文件名/MIME 类型改变目标子目录（.sftp-settings 的 route 规则），nil 表示不路由
+	Router *Router
+	// Batch 传输所属的批次（单独取消、统计进度），nil 表示普通传输
+	Batch *Batch
+	// FailFast 任一文件失败即中断整个传输，见 TransferOptions.FailFast
+	FailFast bool
+	// TaskTimeout 单个文件的传输时限，0 表示不限时
+	TaskTimeout time.Duration
+	// Order 文件的启动顺序，见 TransferOrder
+	Order TransferOrder
+	// Denied 非 nil 时跳过无权限读取的目录和文件并记录在其中（DeniedSkip），nil 时中止传输
+	Denied *DeniedSkips
 }
 
 // UploadGlob 使用 glob 模式匹配上传文件
@@ -99,32 +137,24 @@ func (c *Client) UploadGlob(pattern, remotePath string, opts *UploadOptions) (in
 
 // UploadSources 上传一个或多个本地 source（显式路径或 glob）
 func (c *Client) UploadSources(localSources []string, remoteDir string, opts *UploadOptions) (int, error) {
-	if len(localSources) == 0 {
-		return 0, fmt.Errorf("missing source path")
-	}
-
 	if opts == nil {
-		opts = &UploadOptions{
-			ShowProgress: true,
-			Concurrency:  MaxConcurrentTransfers,
-			MaxDepth:     -1,
-		}
+		opts = c.defaultUploadOptions()
 	}
 
 	remoteDir = c.ResolveRemotePath(remoteDir)
 
-	var tasks []transferTask
-	var allEmptyDirs []string
-	for _, source := range localSources {
-		sourceTasks, sourceEmptyDirs, err := c.collectUploadSourceTasks(source, remoteDir, opts, len(localSources))
-		if err != nil {
-			return 0, err
-		}
-		tasks = append(tasks, sourceTasks...)
-		allEmptyDirs = append(allEmptyDirs, sourceEmptyDirs...)
+	tasks, allEmptyDirs, err := c.planUploadTasks(localSources, remoteDir, opts)
+	if err != nil {
+		return 0, err
+	}
+	tasks, specials, skipped := c.splitSpecialTasks(tasks, opts.RecreateSpecial)
+	printSkippedSpecial(os.Stdout, skipped)
+	tasks, specials, err = c.applyOverwritePolicy(tasks, specials, opts.Overwrite)
+	if err != nil {
+		return 0, err
 	}
 
-	if len(tasks) == 0 && len(allEmptyDirs) > 0 {
+	if len(tasks) == 0 && len(specials) == 0 && len(allEmptyDirs) > 0 {
 		for _, dir := range allEmptyDirs {
 			if err := c.ensureRemoteDir(dir); err != nil {
 				return 0, err
@@ -134,20 +164,10 @@ func (c *Client) UploadSources(localSources []string, remoteDir string, opts *Up
 		return 0, nil
 	}
 
-	if len(tasks) == 0 {
-		return 0, fmt.Errorf("no files found in directory")
-	}
-
-	if opts.Flatten {
-		if err := c.applyFlattenMapping(tasks, remoteDir); err != nil {
-			return 0, err
-		}
-	}
-	if err := c.validateTargetCollisions(tasks); err != nil {
-		return 0, err
+	if len(tasks) == 0 && len(specials) == 0 {
+		return 0, nil
 	}
-
-	fmt.Printf("Found %d file(s) to upload\n", len(tasks))
+	fmt.Printf("Found %d file(s) to upload\n", len(tasks)+len(specials))
 
 	// 确保所有远程目录存在
 	dirs := c.collectRemoteDirsForUpload(tasks)
@@ -161,8 +181,83 @@ func (c *Client) UploadSources(localSources []string, remoteDir string, opts *Up
 		ShowProgress: opts.ShowProgress,
 		Concurrency:  opts.Concurrency,
 		MaxDepth:     opts.MaxDepth,
+		ChunkSize:    opts.ChunkSize,
+
+		ParallelStreams:   opts.ParallelStreams,
+		ParallelThreshold: opts.ParallelThreshold,
+		Preserve:          opts.Preserve,
+		Scanner:           opts.Scanner,
+		Batch:             opts.Batch,
+		FailFast:          opts.FailFast,
+		TaskTimeout:       opts.TaskTimeout,
+		Order:             opts.Order,
+		Denied:            opts.Denied,
+	}
+	count, err := c.executeTasks(tasks, transferOpts)
+	tasks = opts.Denied.remaining(tasks)
+	created, specialErrs := c.recreateSpecialTasks(specials)
+	count += created
+	if err != nil || len(specialErrs) > 0 {
+		return count, errors.Join(append([]error{err}, specialErrs...)...)
+	}
+	if opts.Verify {
+		if err := c.verifyTasks(tasks, false, opts.Concurrency); err != nil {
+			return count, err
+		}
+	}
+	if opts.SpotCheckPercent <= 0 {
+		return count, nil
+	}
+	alg, err := LookupHashAlgorithm(opts.SpotCheckHash)
+	if err != nil {
+		return count, err
+	}
+	return count, c.spotCheckUploads(tasks, opts.SpotCheckPercent, alg, opts.Concurrency)
+}
+
+// planUploadTasks 解析 source 并生成上传任务（不触碰远程文件系统）
+// remoteDir 必须是已解析的远程路径；没有文件时返回需要创建的空目录
+func (c *Client) planUploadTasks(localSources []string, remoteDir string, opts *UploadOptions) ([]transferTask, []string, error) {
+	if len(localSources) == 0 {
+		return nil, nil, fmt.Errorf("missing source path")
+	}
+
+	var tasks []transferTask
+	var allEmptyDirs []string
+	for _, source := range localSources {
+		sourceTasks, sourceEmptyDirs, err := c.collectUploadSourceTasks(source, remoteDir, opts, len(localSources))
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, sourceTasks...)
+		allEmptyDirs = append(allEmptyDirs, sourceEmptyDirs...)
+	}
+
+	if len(tasks) == 0 {
+		if len(allEmptyDirs) > 0 {
+			return nil, allEmptyDirs, nil
+		}
+		return nil, nil, fmt.Errorf("no files found in directory")
+	}
+
+	if opts.Flatten {
+		if err := c.applyFlattenMapping(tasks, remoteDir); err != nil {
+			return nil, nil, err
+		}
+	}
+	opts.Router.applyRoutes(tasks, remoteDir)
+	if err := c.validateTargetCollisions(tasks); err != nil {
+		return nil, nil, err
+	}
+	return tasks, allEmptyDirs, nil
+}
+
+func (c *Client) defaultUploadOptions() *UploadOptions {
+	return &UploadOptions{
+		ShowProgress: true,
+		Concurrency:  c.Concurrency(),
+		MaxDepth:     -1,
 	}
-	return c.executeTasks(tasks, transferOpts)
 }
 
 func (c *Client) collectUploadSourceTasks(source, remoteDir string, opts *UploadOptions, sourceCount int) ([]transferTask, []string, error) {
@@ -187,7 +282,7 @@ func (c *Client) collectUploadSourceTasks(source, remoteDir string, opts *Upload
 		if sourceCount > 1 {
 			dirRoot = path.Join(remoteDir, explicitLocalFilePreservePath(source, resolvedSource))
 		}
-		tasks, emptyDirs, err := c.collectUploadTasks(resolvedSource, dirRoot, opts.MaxDepth, 0)
+		tasks, emptyDirs, err := c.collectUploadTasks(resolvedSource, dirRoot, opts.MaxDepth, 0, opts.Filter, "", opts.Denied)
 		if err != nil {
 			return nil, nil, fmt.Errorf("collect tasks for %s: %w", source, err)
 		}
@@ -204,16 +299,13 @@ func (c *Client) collectUploadSourceTasks(source, remoteDir string, opts *Upload
 		remotePath: remoteFile,
 		isUpload:   true,
 		size:       stat.Size(),
+		mode:       stat.Mode().Type(),
 	}}, nil, nil
 }
 
 func (c *Client) collectUploadGlobTasks(pattern, remotePath string, opts *UploadOptions) ([]transferTask, []string, error) {
 	if opts == nil {
-		opts = &UploadOptions{
-			ShowProgress: true,
-			Concurrency:  MaxConcurrentTransfers,
-			MaxDepth:     -1,
-		}
+		opts = c.defaultUploadOptions()
 	}
 
 	// 解析 glob 模式
@@ -251,12 +343,16 @@ func (c *Client) collectUploadGlobTasks(pattern, remotePath string, opts *Upload
 	for _, match := range matches {
 		stat, err := os.Stat(match)
 		if err != nil {
-			return nil, nil, fmt.Errorf("stat match %s: %w", match, err)
+			if err := opts.Denied.skip(match, fmt.Errorf("stat match %s: %w", match, err)); err != nil {
+				return nil, nil, err
+			}
+			continue
 		}
 		entries = append(entries, transferSourceEntry{
 			path:  match,
 			isDir: stat.IsDir(),
 			size:  stat.Size(),
+			mode:  stat.Mode().Type(),
 		})
 	}
 	entries = normalizeMatchedSourceEntries(entries, true, opts.Recursive)
@@ -274,8 +370,11 @@ func (c *Client) collectUploadGlobTasks(pattern, remotePath string, opts *Upload
 				return nil, nil, fmt.Errorf("relative path for %s: %w", match, relErr)
 			}
 			mappedSlash := joinPreservePath(globBasePrefix, filepath.ToSlash(mapped))
+			if opts.Filter.SkipDir(mappedSlash) {
+				continue
+			}
 			remoteSubDir := path.Join(remotePath, mappedSlash)
-			subTasks, subEmptyDirs, err := c.collectUploadTasks(match, remoteSubDir, opts.MaxDepth, 0)
+			subTasks, subEmptyDirs, err := c.collectUploadTasks(match, remoteSubDir, opts.MaxDepth, 0, opts.Filter, mappedSlash, opts.Denied)
 			if err != nil {
 				return nil, nil, fmt.Errorf("collect tasks for %s: %w", match, err)
 			}
@@ -287,12 +386,16 @@ func (c *Client) collectUploadGlobTasks(pattern, remotePath string, opts *Upload
 				return nil, nil, fmt.Errorf("relative path for %s: %w", match, relErr)
 			}
 			mappedSlash := joinPreservePath(globBasePrefix, filepath.ToSlash(mapped))
+			if !opts.Filter.AllowFile(mappedSlash) {
+				continue
+			}
 			remoteFile := path.Join(remotePath, mappedSlash)
 			tasks = append(tasks, transferTask{
 				localPath:  match,
 				remotePath: remoteFile,
 				isUpload:   true,
 				size:       entry.size,
+				mode:       entry.mode,
 			})
 		}
 	}
@@ -324,14 +427,14 @@ func (c *Client) ensureRemoteDir(dir string) error {
 	dir = c.ResolveRemotePath(dir)
 
 	// 快速路径：目录已存在
-	if stat, err := c.sftpClient.Stat(dir); err == nil && stat.IsDir() {
+	if stat, err := c.sftpConn().Stat(dir); err == nil && stat.IsDir() {
 		return nil
 	}
 
 	// 使用 singleflight 确保同一目录只创建一次
 	_, err, _ := c.dirCreateGroup.Do(dir, func() (interface{}, error) {
 		// double check
-		if stat, err := c.sftpClient.Stat(dir); err == nil && stat.IsDir() {
+		if stat, err := c.sftpConn().Stat(dir); err == nil && stat.IsDir() {
 			return nil, nil
 		}
 
@@ -347,19 +450,20 @@ func (c *Client) ensureRemoteDir(dir string) error {
 		// mu := c.getDirLock(dir)
 		// mu.Lock()
 		// defer mu.Unlock()
-		// if stat, err := c.sftpClient.Stat(dir); err == nil && stat.IsDir() {
+		// if stat, err := c.sftpConn().Stat(dir); err == nil && stat.IsDir() {
 		// 	return nil, nil
 		// }
 
-		if err := c.sftpClient.Mkdir(dir); err != nil {
+		if err := c.sftpConn().Mkdir(dir); err != nil {
 			// 最后一次检查（防止服务器端刚巧被别人创建了）
-			if stat, statErr := c.sftpClient.Stat(dir); statErr == nil && stat.IsDir() {
+			if stat, statErr := c.sftpConn().Stat(dir); statErr == nil && stat.IsDir() {
 				return nil, nil
 			}
 			return nil, err
 		}
 
 		c.invalidateDirCache(parent)
+		c.emit(Event{Type: EventDirCreated, IsUpload: true, Target: dir})
 		return nil, nil
 	})
 
@@ -383,5 +487,10 @@ func localGlobBase(pattern string) string {
 	if len(base) == 0 {
 		return filepath.Dir(cleaned)
 	}
-	return filepath.Clean(filepath.Join(base...))
+	joined := filepath.Join(base...)
+	// filepath.Join 会丢弃开头的空元素，绝对路径需要补回根分隔符
+	if strings.HasPrefix(cleaned, string(filepath.Separator)) {
+		joined = string(filepath.Separator) + joined
+	}
+	return filepath.Clean(joined)
 }
@@ -0,0 +1,225 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// sampleSpotCheckTasks 随机抽取 percent% 的任务（至少 1 个），保持原顺序
+func sampleSpotCheckTasks(tasks []transferTask, percent float64, rng *rand.Rand) []transferTask {
+	if len(tasks) == 0 || percent <= 0 {
+		return nil
+	}
+	n := int(math.Ceil(float64(len(tasks)) * percent / 100))
+	if n < 1 {
+		n = 1
+	}
+	if n >= len(tasks) {
+		return append([]transferTask(nil), tasks...)
+	}
+
+	picked := rng.Perm(len(tasks))[:n]
+	selected := make([]bool, len(tasks))
+	for _, i := range picked {
+		selected[i] = true
+	}
+	sample := make([]transferTask, 0, n)
+	for i, task := range tasks {
+		if selected[i] {
+			sample = append(sample, task)
+		}
+	}
+	return sample
+}
+
+// spotCheckUploads 重新下载抽样的已上传文件并与本地文件比较摘要（两端都在本地计算）
+func (c *Client) spotCheckUploads(tasks []transferTask, percent float64, alg *HashAlgorithm, concurrency int) error {
+	sample := sampleSpotCheckTasks(tasks, percent, rand.New(rand.NewSource(rand.Int63())))
+	if len(sample) == 0 {
+		return nil
+	}
+	if concurrency <= 0 {
+		concurrency = c.Concurrency()
+	}
+
+	fmt.Printf("Spot-checking %d of %d uploaded file(s) (%g%%, %s)...\n", len(sample), len(tasks), percent, alg.Name)
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	var mu sync.Mutex
+	var errs []error
+	for _, task := range sample {
+		g.Go(func() error {
+			if err := c.compareUploadedFile(alg, task.localPath, task.remotePath); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("spot-check failed for %d of %d sampled file(s): %w", len(errs), len(sample), errors.Join(errs...))
+	}
+	fmt.Printf("✓ Spot-check passed: %d file(s) match\n", len(sample))
+	return nil
+}
+
+// compareUploadedFile 比较本地文件与远程文件内容
+func (c *Client) compareUploadedFile(alg *HashAlgorithm, localPath, remotePath string) error {
+	localSum, err := c.localChecksum(alg, localPath)
+	if err != nil {
+		return fmt.Errorf("checksum %s: %w", localPath, err)
+	}
+	remoteSum, err := c.remoteChecksum(alg, remotePath)
+	if err != nil {
+		return fmt.Errorf("re-download %s: %w", remotePath, err)
+	}
+	if !bytes.Equal(localSum, remoteSum) {
+		return fmt.Errorf("checksum mismatch: %s != %s", localPath, remotePath)
+	}
+	return nil
+}
+
+func (c *Client) localChecksum(alg *HashAlgorithm, localPath string) ([]byte, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return c.checksumReader(alg, f)
+}
+
+func (c *Client) remoteChecksum(alg *HashAlgorithm, remotePath string) ([]byte, error) {
+	f, err := c.sftpConn().Open(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return c.checksumReader(alg, f)
+}
+
+func (c *Client) checksumReader(alg *HashAlgorithm, r io.Reader) ([]byte, error) {
+	buf := c.getBuffer()
+	defer c.putBuffer(buf)
+
+	h := alg.New()
+	if _, err := io.CopyBuffer(h, &contextReader{ctx: c.transferContext(), r: r}, buf); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// VerifyMismatch 传输后目标与源仍不一致的文件
+type VerifyMismatch struct {
+	Source      string
+	Destination string
+	Reason      string
+}
+
+// VerifyError 校验发现不一致时返回，包含全部不一致项
+type VerifyError struct {
+	Checked    int
+	Mismatches []VerifyMismatch
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("verification failed: %d of %d file(s) differ at destination", len(e.Mismatches), e.Checked)
+}
+
+// verifyTasks 传输后校验：重新读取源和目标的元数据，比较大小（compareMtime 时还比较秒级 mtime），
+// 用于发现被截断的写入或被服务器篡改的时间戳。
+func (c *Client) verifyTasks(tasks []transferTask, compareMtime bool, concurrency int) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	if concurrency <= 0 {
+		concurrency = c.Concurrency()
+	}
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	var mu sync.Mutex
+	var mismatches []VerifyMismatch
+	for _, task := range tasks {
+		g.Go(func() error {
+			if reason := c.compareTransferred(task, compareMtime); reason != "" {
+				mu.Lock()
+				mismatches = append(mismatches, VerifyMismatch{Source: taskSourcePath(task), Destination: taskTargetPath(task), Reason: reason})
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	if len(mismatches) > 0 {
+		sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Destination < mismatches[j].Destination })
+		return &VerifyError{Checked: len(tasks), Mismatches: mismatches}
+	}
+	checked := "size"
+	if compareMtime {
+		checked = "size and mtime"
+	}
+	fmt.Printf("✓ Verified %d file(s): destination %s match\n", len(tasks), checked)
+	return nil
+}
+
+// VerifyFile 校验单个已传输文件的大小，不一致时返回 *VerifyError
+func (c *Client) VerifyFile(localPath, remotePath string, isUpload bool) error {
+	task := transferTask{
+		localPath:  c.ResolveLocalPath(localPath),
+		remotePath: c.ResolveRemotePath(remotePath),
+		isUpload:   isUpload,
+	}
+	return c.verifyTasks([]transferTask{task}, false, 1)
+}
+
+// compareTransferred 返回不一致的原因；一致时返回 ""
+func (c *Client) compareTransferred(task transferTask, compareMtime bool) string {
+	var src, dst os.FileInfo
+	var srcErr, dstErr error
+	if task.isUpload {
+		src, srcErr = os.Stat(task.localPath)
+		dst, dstErr = c.sftpConn().Stat(task.remotePath)
+	} else {
+		src, srcErr = c.sftpConn().Stat(task.remotePath)
+		dst, dstErr = os.Stat(task.localPath)
+	}
+	switch {
+	case srcErr != nil:
+		return fmt.Sprintf("source unreadable: %v", srcErr)
+	case dstErr != nil:
+		return fmt.Sprintf("destination missing: %v", dstErr)
+	case src.Size() != dst.Size():
+		return fmt.Sprintf("size %d != %d", src.Size(), dst.Size())
+	case compareMtime && src.ModTime().Unix() != dst.ModTime().Unix():
+		return fmt.Sprintf("mtime %s != %s", src.ModTime().Format(time.RFC3339), dst.ModTime().Format(time.RFC3339))
+	}
+	return ""
+}
+
+// WriteVerifyReport 输出校验报告：每行 destination<TAB>reason<TAB>source
+func WriteVerifyReport(w io.Writer, verr *VerifyError) error {
+	if _, err := fmt.Fprintf(w, "# verify report: %d of %d file(s) differ\n", len(verr.Mismatches), verr.Checked); err != nil {
+		return err
+	}
+	for _, m := range verr.Mismatches {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", m.Destination, m.Reason, m.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteVerifyReport(t *testing.T) {
+	verr := &VerifyError{
+		Checked: 10,
+		Mismatches: []VerifyMismatch{
+			{Source: "/local/a.log", Destination: "/srv/a.log", Reason: "size 10 != 4"},
+			{Source: "/local/b.log", Destination: "/srv/b.log", Reason: "mtime 2024-04-30T12:00:00Z != 2024-04-30T12:00:02Z"},
+		},
+	}
+	if got, want := verr.Error(), "verification failed: 2 of 10 file(s) differ at destination"; got != want {
+		t.Fatalf("Error() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteVerifyReport(&buf, verr); err != nil {
+		t.Fatal(err)
+	}
+	want := "# verify report: 2 of 10 file(s) differ\n" +
+		"/srv/a.log\tsize 10 != 4\t/local/a.log\n" +
+		"/srv/b.log\tmtime 2024-04-30T12:00:00Z != 2024-04-30T12:00:02Z\t/local/b.log\n"
+	if buf.String() != want {
+		t.Fatalf("report = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// WatchEventKind 远程变化类型
+type WatchEventKind string
+
+const (
+	WatchCreated  WatchEventKind = "created"
+	WatchModified WatchEventKind = "modified"
+	WatchRemoved  WatchEventKind = "removed"
+)
+
+// WatchEvent 描述一次轮询检测到的远程变化
+type WatchEvent struct {
+	Kind WatchEventKind
+	Path string      // 远程绝对路径
+	Info os.FileInfo // removed 事件为 nil
+}
+
+// watchState 单个路径在某次轮询时的元数据
+type watchState struct {
+	info os.FileInfo
+}
+
+func (s watchState) sameAs(other watchState) bool {
+	return s.info.Size() == other.info.Size() &&
+		s.info.ModTime().Equal(other.info.ModTime()) &&
+		s.info.Mode() == other.info.Mode()
+}
+
+// WatchRemote 按 interval 轮询远程文件或目录（目录只看直接子项）的 size/mtime，
+// 每检测到变化调用 onEvent，直到 ctx 结束。路径暂不存在时会等待其出现。
+func (c *Client) WatchRemote(ctx context.Context, remotePath string, interval time.Duration, onEvent func(WatchEvent)) error {
+	remotePath = c.ResolveRemotePath(remotePath)
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	previous, err := c.pollWatchState(remotePath)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := c.pollWatchState(remotePath)
+		if err != nil {
+			return err
+		}
+		for _, event := range diffWatchStates(previous, current) {
+			onEvent(event)
+		}
+		previous = current
+	}
+}
+
+// pollWatchState 读取路径当前状态；不存在时返回空状态
+func (c *Client) pollWatchState(remotePath string) (map[string]watchState, error) {
+	states := make(map[string]watchState)
+	stat, err := c.sftpConn().Stat(remotePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return states, nil
+		}
+		return nil, err
+	}
+
+	if !stat.IsDir() {
+		states[remotePath] = watchState{info: stat}
+		return states, nil
+	}
+
+	entries, err := c.sftpConn().ReadDir(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		states[path.Join(remotePath, entry.Name())] = watchState{info: entry}
+	}
+	c.invalidateDirCache(remotePath)
+	return states, nil
+}
+
+// diffWatchStates 比较两次轮询结果，按路径排序返回变化事件
+func diffWatchStates(previous, current map[string]watchState) []WatchEvent {
+	var events []WatchEvent
+	for p, cur := range current {
+		prev, existed := previous[p]
+		switch {
+		case !existed:
+			events = append(events, WatchEvent{Kind: WatchCreated, Path: p, Info: cur.info})
+		case !prev.sameAs(cur):
+			events = append(events, WatchEvent{Kind: WatchModified, Path: p, Info: cur.info})
+		}
+	}
+	for p := range previous {
+		if _, exists := current[p]; !exists {
+			events = append(events, WatchEvent{Kind: WatchRemoved, Path: p})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
+
+// WaitForPath 轮询直到远程路径出现（gone=true 时为消失），ctx 结束时返回 ctx.Err()
+func (c *Client) WaitForPath(ctx context.Context, remotePath string, gone bool, interval time.Duration) error {
+	remotePath = c.ResolveRemotePath(remotePath)
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := c.sftpConn().Stat(remotePath)
+		switch {
+		case err == nil && !gone:
+			return nil
+		case err != nil && os.IsNotExist(err):
+			if gone {
+				return nil
+			}
+		case err != nil:
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
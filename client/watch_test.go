@@ -0,0 +1,56 @@
+package client
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+type fakeFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (f fakeFileInfo) Name() string       { return f.name }
+func (f fakeFileInfo) Size() int64        { return f.size }
+func (f fakeFileInfo) Mode() os.FileMode  { return f.mode }
+func (f fakeFileInfo) ModTime() time.Time { return f.modTime }
+func (f fakeFileInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fakeFileInfo) Sys() interface{}   { return nil }
+
+func TestDiffWatchStates(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	previous := map[string]watchState{
+		"/out/a.log": {info: fakeFileInfo{name: "a.log", size: 10, modTime: t0}},
+		"/out/b.log": {info: fakeFileInfo{name: "b.log", size: 10, modTime: t0}},
+		"/out/c.log": {info: fakeFileInfo{name: "c.log", size: 10, modTime: t0}},
+	}
+	current := map[string]watchState{
+		"/out/a.log": {info: fakeFileInfo{name: "a.log", size: 10, modTime: t0}},
+		"/out/b.log": {info: fakeFileInfo{name: "b.log", size: 20, modTime: t0.Add(time.Second)}},
+		"/out/d.log": {info: fakeFileInfo{name: "d.log", size: 1, modTime: t0}},
+	}
+
+	events := diffWatchStates(previous, current)
+	want := []struct {
+		kind WatchEventKind
+		path string
+	}{
+		{WatchModified, "/out/b.log"},
+		{WatchRemoved, "/out/c.log"},
+		{WatchCreated, "/out/d.log"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("diffWatchStates() = %#v", events)
+	}
+	for i := range want {
+		if events[i].Kind != want[i].kind || events[i].Path != want[i].path {
+			t.Fatalf("event[%d] = %s %s, want %s %s", i, events[i].Kind, events[i].Path, want[i].kind, want[i].path)
+		}
+	}
+	if events[1].Info != nil {
+		t.Fatal("removed event must not carry file info")
+	}
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WcResult 单个远程文件的行数/字节数
+type WcResult struct {
+	Path  string
+	Lines int64 // 未统计行数时为 -1
+	Bytes int64
+}
+
+// Wc 统计远程文件（支持 glob）的字节数；countLines 时同时统计行数。
+// 行数优先在服务器上执行 wc，无法执行命令时通过 SFTP 流式读取统计。
+func (c *Client) Wc(patterns []string, countLines bool) ([]WcResult, error) {
+	var results []WcResult
+	for _, pattern := range patterns {
+		files, err := c.ExpandRemoteFiles(pattern)
+		if err != nil {
+			return results, err
+		}
+		for _, file := range files {
+			result := WcResult{Path: file, Lines: -1}
+			if countLines {
+				result.Lines, result.Bytes, err = c.countRemote(file)
+			} else if stat, statErr := c.sftpConn().Stat(file); statErr == nil {
+				result.Bytes = stat.Size()
+			} else {
+				err = statErr
+			}
+			if err != nil {
+				return results, fmt.Errorf("wc %s: %w", file, err)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// ExpandRemoteFiles 展开 glob 并只保留普通文件（按路径排序）；显式路径为目录时报错
+func (c *Client) ExpandRemoteFiles(pattern string) ([]string, error) {
+	resolved := c.ResolveRemotePath(pattern)
+	if !strings.ContainsAny(pattern, "*?[]") {
+		stat, err := c.sftpConn().Stat(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		if stat.IsDir() {
+			return nil, fmt.Errorf("%s: is a directory", pattern)
+		}
+		return []string{resolved}, nil
+	}
+
+	matches, err := c.globRemote(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("glob pattern: %w", err)
+	}
+	var files []string
+	for _, match := range matches {
+		if stat, err := c.sftpConn().Stat(match); err == nil && stat.Mode().IsRegular() {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match pattern: %s", pattern)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// countRemote 返回远程文件的行数和字节数
+func (c *Client) countRemote(remotePath string) (lines, size int64, err error) {
+	var out bytes.Buffer
+	if c.ExecuteRemote("wc -l -c < "+shellQuote(remotePath), nil, &out, io.Discard) == nil {
+		if lines, size, ok := parseWcOutput(out.String()); ok {
+			return lines, size, nil
+		}
+	}
+
+	f, err := c.sftpConn().Open(remotePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	return c.countLines(f)
+}
+
+// parseWcOutput 解析 `wc -l -c` 的输出（行数在前，字节数在后）
+func parseWcOutput(out string) (lines, size int64, ok bool) {
+	fields := strings.Fields(out)
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+	lines, err1 := strconv.ParseInt(fields[0], 10, 64)
+	size, err2 := strconv.ParseInt(fields[1], 10, 64)
+	return lines, size, err1 == nil && err2 == nil
+}
+
+// countLines 流式统计换行符个数和字节数
+func (c *Client) countLines(r io.Reader) (lines, size int64, err error) {
+	buf := c.getBuffer()
+	defer c.putBuffer(buf)
+
+	r = &contextReader{ctx: c.transferContext(), r: r}
+	for {
+		n, err := r.Read(buf)
+		lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		size += int64(n)
+		if err == io.EOF {
+			return lines, size, nil
+		}
+		if err != nil {
+			return lines, size, err
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseWcOutput(t *testing.T) {
+	tests := []struct {
+		out         string
+		lines, size int64
+		ok          bool
+	}{
+		{"      3      12\n", 3, 12, true},
+		{"3 12", 3, 12, true},
+		{"12\n", 0, 0, false},
+		{"wc: error\n", 0, 0, false},
+	}
+	for _, tt := range tests {
+		lines, size, ok := parseWcOutput(tt.out)
+		if ok != tt.ok || (ok && (lines != tt.lines || size != tt.size)) {
+			t.Fatalf("parseWcOutput(%q) = %d, %d, %v", tt.out, lines, size, ok)
+		}
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	c := &Client{bufferPool: &sync.Pool{New: func() interface{} {
+		buf := make([]byte, 4)
+		return &buf
+	}}}
+	lines, size, err := c.countLines(strings.NewReader("one\ntwo\nthree"))
+	if err != nil || lines != 2 || size != 13 {
+		t.Fatalf("countLines() = %d, %d, %v", lines, size, err)
+	}
+}
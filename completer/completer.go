@@ -1,23 +1,34 @@
 package completer
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/chzyer/readline"
+
+	"github.com/frostime/my-sftp/lexer"
 )
 
 // ClientInterface 定义 SFTP 客户端必需的接口
 type ClientInterface interface {
 	ListCompletion(prefix string) []string
 	GetLocalwd() string
+	RemoteUsers() []string  // 远程用户名（会话内缓存）
+	RemoteGroups() []string // 远程组名（会话内缓存）
 }
 
+// MaxCandidates 一次显示的候选项上限：目录有上万个条目时，把全部候选交给 readline 显示会卡住终端
+const MaxCandidates = 200
+
 // Completer 自动补全器
 type Completer struct {
 	client  ClientInterface
-	cmdList []string // 命令列表
+	cmdList []string  // 命令列表
+	out     io.Writer // 候选项被截断时的提示输出位置，nil 表示不提示
 }
 
 // NewCompleter 创建补全器
@@ -28,88 +39,310 @@ func NewCompleter(client ClientInterface) *Completer {
 			"help", "exit", "quit", "q",
 			"ls", "ll", "dir",
 			"cd", "pwd",
-			"get", "download",
+			"get", "download", "sync",
 			"put", "upload",
 			"rm", "del", "delete",
 			"mkdir", "md",
 			"rmdir", "rd",
-			"rename", "mv",
-			"stat", "info",
+			"rename", "mv", "cp",
+			"stat", "info", "ln", "readlink", "df", "find",
+			"chown", "chgrp", "chmod", "wc",
+			"cat", "grep", "head", "tail", "sort", "uniq",
+			"rwatch", "wait-for", "overwrite",
+			"jobs", "status", "cancel", "fg", "snapshot", "timing", "prompt", "set", "project", "stage", "exec", "shell",
 			// 本地命令
 			"lpwd", "lcd", "lls", "ldir", "lmkdir",
 		},
 	}
 }
 
+// SetOutput 设置提示输出位置（readline 的 Stdout，不打断输入行）
+func (c *Completer) SetOutput(w io.Writer) {
+	c.out = w
+}
+
 // Do 执行自动补全
-// readline 会用返回的候选项替换最后 length 个字符
+// readline 会用返回的候选项替换最后 length 个字符。候选项超过 MaxCandidates 时，
+// 全部候选项有公共前缀则只补全该前缀；否则只返回排序后的 MaxCandidates 个，并提示还有多少个未显示
 func (c *Completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
+	newLine, length = c.complete(line, pos)
+	if len(newLine) <= MaxCandidates {
+		return newLine, length
+	}
+	slices.SortFunc(newLine, func(a, b []rune) int { return strings.Compare(string(a), string(b)) })
+	// 排序后首尾两项的公共前缀即全部候选项的公共前缀；必须在截断前计算，
+	// 否则 readline 会插入只属于前一部分候选项的前缀
+	first, last := newLine[0], newLine[len(newLine)-1]
+	if prefix := commonPrefix(first, last); len(prefix) > 0 {
+		return [][]rune{prefix}, length
+	}
+	if c.out != nil {
+		fmt.Fprintf(c.out, "ℹ %d matches, showing %d (+%d more); type more characters to narrow\n",
+			len(newLine), MaxCandidates, len(newLine)-MaxCandidates)
+	}
+	// 保留最后一项，截断后的候选项同样没有公共前缀
+	return append(newLine[:MaxCandidates-1:MaxCandidates-1], last), length
+}
+
+func commonPrefix(a, b []rune) []rune {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+// complete 计算全部候选项
+// 单词边界与引号状态由 lexer 判定，候选后缀按当前引号状态转义，保证补全结果能被命令解析器原样读回
+func (c *Completer) complete(line []rune, pos int) (newLine [][]rune, length int) {
 	text := string(line[:pos])
-	fields := strings.Fields(text)
+	tokens, openQuote := lexer.Lex(text)
+	atBoundary := lexer.AtWordBoundary(text, tokens)
 
 	// 空输入：补全命令
-	if len(fields) == 0 {
+	if len(tokens) == 0 {
 		return c.completeCommand(""), 0
 	}
 
 	// 只有命令且未输入空格，补全命令
-	if len(fields) == 1 && !strings.HasSuffix(text, " ") {
-		return c.completeCommand(fields[0]), len(fields[0])
+	if len(tokens) == 1 && !atBoundary {
+		return c.completeCommand(tokens[0].Value), tokens[0].RawLen(text)
+	}
+
+	fields := make([]string, len(tokens))
+	for i, tok := range tokens {
+		fields[i] = tok.Value
 	}
 
 	// 获取当前正在输入的参数（可能为空）
 	var currentArg string
-	if strings.HasSuffix(text, " ") {
+	rawLen := 0
+	if atBoundary {
 		// 刚输入完空格，开始新参数
-		currentArg = ""
+		openQuote = 0
 	} else {
 		// 正在输入参数
-		currentArg = fields[len(fields)-1]
+		last := tokens[len(tokens)-1]
+		currentArg = last.Value
+		rawLen = last.RawLen(text)
 	}
 
 	cmd := fields[0]
-	hasTrailingSpace := strings.HasSuffix(text, " ")
 	optExpectValue := ""
-	if hasTrailingSpace {
+	if atBoundary {
 		if len(fields) > 1 {
 			prev := fields[len(fields)-1]
-			if prev == "-d" || prev == "--dir" || prev == "--name" {
+			if prev == "-d" || prev == "--dir" || prev == "--name" || prev == "--overwrite" {
 				optExpectValue = prev
 			}
 		}
 	} else {
 		if len(fields) > 2 {
 			prev := fields[len(fields)-2]
-			if prev == "-d" || prev == "--dir" || prev == "--name" {
+			if prev == "-d" || prev == "--dir" || prev == "--name" || prev == "--overwrite" {
 				optExpectValue = prev
 			}
 		}
 	}
 
+	remote := func() ([][]rune, int) {
+		return escapeCandidates(c.completeRemotePath(currentArg), openQuote), rawLen
+	}
+	local := func() ([][]rune, int) {
+		return escapeCandidates(c.completeLocalPath(currentArg), openQuote), rawLen
+	}
+
 	switch cmd {
-	case "cd", "ls", "ll", "dir", "rm", "del", "delete", "rmdir", "rd", "stat", "info":
+	case "cd", "ls", "ll", "dir", "rm", "del", "delete", "rmdir", "rd", "stat", "info", "rwatch", "wait-for", "wc", "ln", "readlink", "df",
+		"cat", "grep", "head", "tail", "sort", "uniq", "exec":
 		// 远程路径补全
-		return c.completeRemotePath(currentArg), len(currentArg)
+		return remote()
 	case "lcd", "lls", "ldir", "lmkdir":
 		// 本地路径补全
-		return c.completeLocalPath(currentArg), len(currentArg)
+		return local()
+	case "chown", "chgrp":
+		// 第一个非选项参数是用户/组，之后是远程路径
+		done := fields[1:]
+		if !atBoundary {
+			done = fields[1 : len(fields)-1]
+		}
+		positional := 0
+		for _, field := range done {
+			if !strings.HasPrefix(field, "-") {
+				positional++
+			}
+		}
+		if positional > 0 || strings.HasPrefix(currentArg, "-") {
+			return remote()
+		}
+		return escapeCandidates(c.completeOwnership(cmd, currentArg), openQuote), rawLen
+	case "chmod":
+		// 第一个非选项参数是权限，之后是远程路径
+		done := fields[1:]
+		if !atBoundary {
+			done = fields[1 : len(fields)-1]
+		}
+		for _, field := range done {
+			if !strings.HasPrefix(field, "-") {
+				return remote()
+			}
+		}
+		return nil, 0
+	case "rename", "mv", "cp":
+		// 第一个路径是源，之后优先补全目录（目标），没有匹配的目录时补全所有路径（多个源）
+		if strings.HasPrefix(currentArg, "-") {
+			return nil, 0
+		}
+		done := fields[1:]
+		if !atBoundary {
+			done = fields[1 : len(fields)-1]
+		}
+		for _, field := range done {
+			if !strings.HasPrefix(field, "-") {
+				return escapeCandidates(c.completeRemoteDir(currentArg), openQuote), rawLen
+			}
+		}
+		return remote()
+	case "find":
+		prev := ""
+		if n := len(fields) - 1; atBoundary {
+			prev = fields[n]
+		} else if n > 1 {
+			prev = fields[n-1]
+		}
+		switch prev {
+		case "-d", "--dir":
+			return local()
+		case "-type":
+			return escapeCandidates(completeFromList([]string{"f", "d", "l"}, currentArg), openQuote), rawLen
+		case "--format":
+			return escapeCandidates(completeFromList([]string{"csv", "json"}, currentArg), openQuote), rawLen
+		case "-name", "-mtime", "-size", "--export":
+			return nil, 0
+		}
+		return remote()
+	case "project":
+		// project [show|unlink] | project link <local-dir> <remote-dir>
+		argIndex := len(fields) - 1
+		if !atBoundary {
+			argIndex--
+		}
+		switch {
+		case argIndex == 0:
+			return escapeCandidates(completeFromList(projectCommands, currentArg), openQuote), rawLen
+		case fields[1] != "link":
+			return nil, 0
+		case argIndex == 1:
+			return local()
+		case argIndex == 2:
+			return escapeCandidates(c.completeRemoteDir(currentArg), openQuote), rawLen
+		}
+		return nil, 0
+	case "stage":
+		// stage add <local>... | stage rm <local>... | stage push [remote-dir] | stage status|clear
+		argIndex := len(fields) - 1
+		if !atBoundary {
+			argIndex--
+		}
+		switch {
+		case argIndex == 0:
+			return escapeCandidates(completeFromList(stageCommands, currentArg), openQuote), rawLen
+		case fields[1] == "add" || fields[1] == "rm":
+			return local()
+		case fields[1] == "push" && argIndex == 1:
+			return escapeCandidates(c.completeRemoteDir(currentArg), openQuote), rawLen
+		}
+		return nil, 0
+	case "prompt":
+		// prompt [home on|off] [depth N] [local on|off] [color NAME]
+		argIndex := len(fields) - 1
+		if !atBoundary {
+			argIndex--
+		}
+		if argIndex%2 == 0 {
+			return escapeCandidates(completeFromList(promptSettings, currentArg), openQuote), rawLen
+		}
+		if key := fields[argIndex]; key == "home" || key == "local" {
+			return escapeCandidates(completeFromList([]string{"on", "off"}, currentArg), openQuote), rawLen
+		}
+		if fields[argIndex] == "color" {
+			return escapeCandidates(completeFromList(promptColorNames, currentArg), openQuote), rawLen
+		}
+		return nil, 0
+	case "set":
+		// set output json|text / set hash ALGO / set backslash convert|keep / set units binary|decimal / set buffer SIZE
+		argIndex := len(fields) - 1
+		if !atBoundary {
+			argIndex--
+		}
+		switch argIndex {
+		case 0:
+			return escapeCandidates(completeFromList([]string{"output", "hash", "backslash", "units", "buffer"}, currentArg), openQuote), rawLen
+		case 1:
+			switch fields[1] {
+			case "hash":
+				return escapeCandidates(completeFromList(hashAlgorithms, currentArg), openQuote), rawLen
+			case "backslash":
+				return escapeCandidates(completeFromList([]string{"convert", "keep"}, currentArg), openQuote), rawLen
+			case "units":
+				return escapeCandidates(completeFromList([]string{"binary", "decimal"}, currentArg), openQuote), rawLen
+			case "buffer":
+				return nil, 0
+			}
+			return escapeCandidates(completeFromList([]string{"json", "text"}, currentArg), openQuote), rawLen
+		}
+		return nil, 0
+	case "overwrite":
+		return escapeCandidates(completeOverwritePolicy(currentArg), openQuote), rawLen
+	case "snapshot":
+		// snapshot <save|diff|list> <name> [remote-dir]
+		argIndex := len(fields) - 1
+		if !atBoundary {
+			argIndex--
+		}
+		switch argIndex {
+		case 0:
+			return escapeCandidates(completeFromList(snapshotCommands, currentArg), openQuote), rawLen
+		case 1:
+			return nil, 0
+		}
+		return remote()
 	case "get", "download":
 		switch optExpectValue {
 		case "-d", "--dir":
-			return c.completeLocalPath(currentArg), len(currentArg)
+			return local()
 		case "--name":
 			return nil, 0
+		case "--overwrite":
+			return escapeCandidates(completeOverwritePolicy(currentArg), openQuote), rawLen
 		default:
-			return c.completeRemotePath(currentArg), len(currentArg)
+			return remote()
 		}
+	case "sync":
+		// sync <local-dir> <remote-dir>
+		positional := 0
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				positional++
+			}
+		}
+		if !atBoundary {
+			positional--
+		}
+		if positional == 0 {
+			return local()
+		}
+		return remote()
 	case "put", "upload":
 		switch optExpectValue {
 		case "-d", "--dir":
-			return c.completeRemotePath(currentArg), len(currentArg)
+			return remote()
 		case "--name":
 			return nil, 0
+		case "--overwrite":
+			return escapeCandidates(completeOverwritePolicy(currentArg), openQuote), rawLen
 		default:
-			return c.completeLocalPath(currentArg), len(currentArg)
+			return local()
 		}
 	default:
 		return nil, 0
@@ -123,6 +356,14 @@ func (c *Completer) ToReadline() readline.AutoCompleter {
 
 // ========================== Internal Helpers ==========================
 
+// escapeCandidates quotes completion suffixes for the quote state of the word being completed.
+func escapeCandidates(suffixes [][]rune, openQuote rune) [][]rune {
+	for i, suffix := range suffixes {
+		suffixes[i] = []rune(lexer.Escape(string(suffix), openQuote))
+	}
+	return suffixes
+}
+
 // completeFromCandidates computes completion suffixes from a list of candidates.
 func completeFromCandidates(candidates []string, prefix string) [][]rune {
 	if len(candidates) == 0 {
@@ -160,12 +401,86 @@ func (c *Completer) completeCommand(prefix string) [][]rune {
 	return completeFromCandidates(candidates, prefix)
 }
 
+// overwritePolicies --overwrite 与 overwrite 命令可用的策略（与 client.OverwritePolicies 一致）
+var overwritePolicies = []string{"always", "never", "if-newer", "if-different-size", "ask"}
+
+// completeOverwritePolicy 补全覆盖策略名称
+func completeOverwritePolicy(prefix string) [][]rune {
+	return completeFromList(overwritePolicies, prefix)
+}
+
+// hashAlgorithms set hash 可用的算法（与 client.HashAlgorithmNames 的内置算法一致）
+var hashAlgorithms = []string{"blake3", "sha256", "xxh3"}
+
+// snapshotCommands snapshot 的子命令
+var snapshotCommands = []string{"save", "diff", "list"}
+
+// projectCommands project 的子命令
+var projectCommands = []string{"show", "link", "unlink"}
+
+// stageCommands stage 的子命令
+var stageCommands = []string{"add", "status", "rm", "clear", "push"}
+
+// promptSettings prompt 命令的设置项
+var promptSettings = []string{"home", "depth", "local", "color"}
+
+// promptColorNames prompt color 的颜色名（同 shell.ParsePromptColor）
+var promptColorNames = []string{"red", "green", "yellow", "blue", "magenta", "cyan", "white", "default"}
+
+// completeFromList 从固定的单词列表中补全
+func completeFromList(words []string, prefix string) [][]rune {
+	var candidates []string
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			candidates = append(candidates, word)
+		}
+	}
+	return completeFromCandidates(candidates, prefix)
+}
+
 // completeRemotePath 补全远程路径
 func (c *Completer) completeRemotePath(prefix string) [][]rune {
 	candidates := c.client.ListCompletion(prefix)
 	return completeFromCandidates(candidates, prefix)
 }
 
+// completeRemoteDir 补全远程目录；没有匹配的目录时退回到所有路径
+func (c *Completer) completeRemoteDir(prefix string) [][]rune {
+	candidates := c.client.ListCompletion(prefix)
+	var dirs []string
+	for _, candidate := range candidates {
+		if strings.HasSuffix(candidate, "/") {
+			dirs = append(dirs, candidate)
+		}
+	}
+	if len(dirs) > 0 {
+		candidates = dirs
+	}
+	return completeFromCandidates(candidates, prefix)
+}
+
+// completeOwnership 补全 chown 的 user[:group] 或 chgrp 的 group
+func (c *Completer) completeOwnership(cmd, prefix string) [][]rune {
+	names := c.client.RemoteGroups
+	userPart := ""
+	partial := prefix
+	if cmd == "chown" {
+		if user, group, ok := strings.Cut(prefix, ":"); ok {
+			userPart, partial = user+":", group
+		} else {
+			names = c.client.RemoteUsers
+		}
+	}
+
+	var candidates []string
+	for _, name := range names() {
+		if strings.HasPrefix(name, partial) {
+			candidates = append(candidates, userPart+name)
+		}
+	}
+	return completeFromCandidates(candidates, prefix)
+}
+
 // completeLocalPath 补全本地路径
 func (c *Completer) completeLocalPath(prefix string) [][]rune {
 	// 解析目录和文件名部分
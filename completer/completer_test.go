@@ -0,0 +1,177 @@
+package completer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeClient struct{}
+
+func (fakeClient) ListCompletion(prefix string) []string {
+	var matches []string
+	for _, name := range []string{"data/", "docs/", "notes.txt", "dump.sql"} {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+func (fakeClient) GetLocalwd() string     { return "." }
+func (fakeClient) RemoteUsers() []string  { return []string{"root", "www-data"} }
+func (fakeClient) RemoteGroups() []string { return []string{"staff", "sudo"} }
+
+func completeLine(line string) []string {
+	candidates, _ := NewCompleter(fakeClient{}).Do([]rune(line), len(line))
+	out := make([]string, len(candidates))
+	for i, c := range candidates {
+		out[i] = string(c)
+	}
+	return out
+}
+
+func TestCompleteOwnership(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"chown ww", []string{"w-data"}},
+		{"chown -R ro", []string{"ot"}},
+		{"chown root:st", []string{"aff"}},
+		{"chown root:s", []string{"taff", "udo"}},
+		{"chgrp su", []string{"do"}},
+		{"chown root da", []string{"ta/"}},
+	}
+	for _, tt := range tests {
+		got := completeLine(tt.line)
+		if len(got) != len(tt.want) {
+			t.Fatalf("complete %q = %q, want %q", tt.line, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("complete %q = %q, want %q", tt.line, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestCompleteMove(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"mv d", []string{"ata/", "ocs/", "ump.sql"}},
+		{"mv notes.txt d", []string{"ata/", "ocs/"}},
+		{"mv -i notes.txt ", []string{"d"}},
+		{"mv data/ n", []string{"otes.txt"}},
+		{"mv -", nil},
+	}
+	for _, tt := range tests {
+		got := completeLine(tt.line)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Fatalf("complete %q = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteProject(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"project l", []string{"ink"}},
+		{"project link . d", []string{"ata/", "ocs/"}},
+		{"project unlink ", nil},
+	}
+	for _, tt := range tests {
+		got := completeLine(tt.line)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Fatalf("complete %q = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCompletePromptSettings(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"prompt h", []string{"ome"}},
+		{"prompt home o", []string{"n", "ff"}},
+		{"prompt home on l", []string{"ocal"}},
+		{"prompt depth ", nil},
+		{"prompt color ma", []string{"genta"}},
+	}
+	for _, tt := range tests {
+		got := completeLine(tt.line)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Fatalf("complete %q = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+type bigClient struct{ fakeClient }
+
+func (bigClient) ListCompletion(prefix string) []string {
+	var matches []string
+	for i := 999; i >= 0; i-- {
+		if name := fmt.Sprintf("f%03d.log", i); strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+func TestCompleteCapsCandidates(t *testing.T) {
+	var out strings.Builder
+	c := NewCompleter(bigClient{})
+	c.SetOutput(&out)
+	candidates, _ := c.Do([]rune("cat f"), len("cat f"))
+	if len(candidates) != MaxCandidates {
+		t.Fatalf("got %d candidates, want %d", len(candidates), MaxCandidates)
+	}
+	if first, last := string(candidates[0]), string(candidates[len(candidates)-1]); first != "000.log" || last != "999.log" {
+		t.Fatalf("candidates = %q ... %q, want sorted 000.log ... 999.log", first, last)
+	}
+	if !strings.Contains(out.String(), "+800 more") {
+		t.Fatalf("notice = %q", out.String())
+	}
+
+	// 不超过上限时返回全部候选，不提示
+	out.Reset()
+	candidates, _ = c.Do([]rune("cat f1"), len("cat f1"))
+	if len(candidates) != 100 || out.Len() != 0 {
+		t.Fatalf("candidates = %d, notice %q", len(candidates), out.String())
+	}
+}
+
+type prefixClient struct{ fakeClient }
+
+func (prefixClient) ListCompletion(prefix string) []string {
+	matches := []string{"report-final.pdf"}
+	for i := range 300 {
+		matches = append(matches, fmt.Sprintf("report-0%03d.csv", i))
+	}
+	return matches
+}
+
+func TestCompleteCapsCandidatesKeepsCommonPrefix(t *testing.T) {
+	c := NewCompleter(prefixClient{})
+	candidates, _ := c.Do([]rune("cat r"), len("cat r"))
+	if len(candidates) != 1 || string(candidates[0]) != "eport-" {
+		t.Fatalf("candidates = %d, want only the common prefix", len(candidates))
+	}
+
+	// 排序后的前 200 项都以 report-0 开头，但 report-final.pdf 不是：不能补全 0
+	candidates, _ = c.Do([]rune("cat report-"), len("cat report-"))
+	if len(candidates) != MaxCandidates {
+		t.Fatalf("got %d candidates, want %d", len(candidates), MaxCandidates)
+	}
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		prefix = commonPrefix(prefix, candidate)
+	}
+	if len(prefix) != 0 {
+		t.Fatalf("truncated candidates share the prefix %q", string(prefix))
+	}
+}
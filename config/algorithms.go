@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// algorithmSet 一类可配置的算法：supported 为 golang.org/x/crypto/ssh 实现的全部算法，
+// defaults 为库默认使用的列表（按优先顺序，与库保持一致）
+type algorithmSet struct {
+	option    string // ssh_config 关键字
+	query     string // -Q 的参数，同 ssh -Q
+	supported []string
+	defaults  []string
+}
+
+var algorithmSets = []algorithmSet{
+	{
+		option: "Ciphers",
+		query:  "cipher",
+		supported: []string{
+			"aes128-gcm@openssh.com", "aes256-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+			"aes128-ctr", "aes192-ctr", "aes256-ctr",
+			"aes128-cbc", "3des-cbc", "arcfour256", "arcfour128", "arcfour",
+		},
+		defaults: []string{
+			"aes128-gcm@openssh.com", "aes256-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+			"aes128-ctr", "aes192-ctr", "aes256-ctr",
+		},
+	},
+	{
+		option: "MACs",
+		query:  "mac",
+		supported: []string{
+			"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com", "hmac-sha2-256", "hmac-sha2-512",
+			"hmac-sha1", "hmac-sha1-96",
+		},
+		defaults: []string{
+			"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com", "hmac-sha2-256", "hmac-sha2-512",
+			"hmac-sha1", "hmac-sha1-96",
+		},
+	},
+	{
+		option: "KexAlgorithms",
+		query:  "kex",
+		supported: []string{
+			"curve25519-sha256", "curve25519-sha256@libssh.org",
+			"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+			"diffie-hellman-group14-sha256", "diffie-hellman-group16-sha512", "diffie-hellman-group14-sha1",
+			"diffie-hellman-group-exchange-sha256", "diffie-hellman-group-exchange-sha1", "diffie-hellman-group1-sha1",
+		},
+		defaults: []string{
+			"curve25519-sha256", "curve25519-sha256@libssh.org",
+			"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+			"diffie-hellman-group14-sha256", "diffie-hellman-group14-sha1",
+		},
+	},
+	{
+		option:    "HostKeyAlgorithms",
+		query:     "key",
+		supported: hostKeyAlgorithms,
+		defaults:  hostKeyAlgorithms,
+	},
+}
+
+// hostKeyAlgorithms 支持的主机密钥算法，默认全部使用
+var hostKeyAlgorithms = []string{
+	"rsa-sha2-256-cert-v01@openssh.com", "rsa-sha2-512-cert-v01@openssh.com", "ssh-rsa-cert-v01@openssh.com",
+	"ssh-dss-cert-v01@openssh.com", "ecdsa-sha2-nistp256-cert-v01@openssh.com", "ecdsa-sha2-nistp384-cert-v01@openssh.com",
+	"ecdsa-sha2-nistp521-cert-v01@openssh.com", "ssh-ed25519-cert-v01@openssh.com",
+	"ecdsa-sha2-nistp256", "ecdsa-sha2-nistp384", "ecdsa-sha2-nistp521",
+	"rsa-sha2-256", "rsa-sha2-512", "ssh-rsa", "ssh-dss", "ssh-ed25519",
+}
+
+func lookupAlgorithmSet(option string) *algorithmSet {
+	for i := range algorithmSets {
+		if strings.EqualFold(algorithmSets[i].option, option) || algorithmSets[i].query == strings.ToLower(option) {
+			return &algorithmSets[i]
+		}
+	}
+	return nil
+}
+
+// AlgorithmQueries -Q 接受的名称
+func AlgorithmQueries() []string {
+	names := make([]string, len(algorithmSets))
+	for i, set := range algorithmSets {
+		names[i] = set.query
+	}
+	return names
+}
+
+// SupportedAlgorithms 返回 name（cipher、mac、kex、key 或对应的 ssh_config 关键字）类别中支持的全部算法
+func SupportedAlgorithms(name string) ([]string, error) {
+	set := lookupAlgorithmSet(name)
+	if set == nil {
+		return nil, fmt.Errorf("unknown algorithm type %q (want %s)", name, strings.Join(AlgorithmQueries(), ", "))
+	}
+	return slices.Clone(set.supported), nil
+}
+
+// ResolveAlgorithms 按 ssh_config 的写法解析 option（Ciphers、MACs、KexAlgorithms、HostKeyAlgorithms）的值：
+// "a,b" 替换默认列表，"+a,b" 把默认列表中没有的追加到最后，"^a,b" 放到默认列表之前，"-a,b" 从默认列表中去掉（可用 * 通配）。
+// 替换列表中本程序不支持的算法被跳过（共用的 ssh_config 常列出 OpenSSH 才有的算法），一个都不支持时报错；
+// + 和 ^ 是明确要求加入的算法，不支持时报错。spec 为空时返回 nil，即使用库的默认值
+func ResolveAlgorithms(option, spec string) ([]string, error) {
+	set := lookupAlgorithmSet(option)
+	if set == nil {
+		return nil, fmt.Errorf("unknown algorithm option %q", option)
+	}
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	op, list := spec[0], spec[1:]
+	if !strings.ContainsRune("+-^", rune(op)) {
+		op, list = 0, spec
+	}
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("empty %s list", set.option)
+	}
+
+	if op == '-' {
+		result := slices.DeleteFunc(slices.Clone(set.defaults), func(alg string) bool {
+			return slices.ContainsFunc(names, func(pattern string) bool {
+				matched, _ := path.Match(pattern, alg)
+				return matched
+			})
+		})
+		if len(result) == 0 {
+			return nil, fmt.Errorf("%s %q removes every algorithm", set.option, spec)
+		}
+		return result, nil
+	}
+
+	var picked []string
+	for _, name := range names {
+		if slices.Contains(set.supported, name) {
+			if !slices.Contains(picked, name) {
+				picked = append(picked, name)
+			}
+		} else if op != 0 {
+			return nil, fmt.Errorf("unsupported %s algorithm %q (see my-sftp -Q %s)", set.option, name, set.query)
+		}
+	}
+	if len(picked) == 0 {
+		return nil, fmt.Errorf("no supported algorithm in %s %q (see my-sftp -Q %s)", set.option, spec, set.query)
+	}
+	switch op {
+	case '+':
+		added := slices.DeleteFunc(picked, func(alg string) bool { return slices.Contains(set.defaults, alg) })
+		return append(slices.Clone(set.defaults), added...), nil
+	case '^':
+		rest := slices.DeleteFunc(slices.Clone(set.defaults), func(alg string) bool { return slices.Contains(picked, alg) })
+		return append(picked, rest...), nil
+	}
+	return picked, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveAlgorithms(t *testing.T) {
+	tests := []struct {
+		option, spec string
+		want         []string
+	}{
+		{"Ciphers", "", nil},
+		{"Ciphers", "aes256-ctr, aes128-ctr", []string{"aes256-ctr", "aes128-ctr"}},
+		{"ciphers", "aes256-gcm@openssh.com,aes256-ctr,aes256-gcm@openssh.com", []string{"aes256-gcm@openssh.com", "aes256-ctr"}},
+		// OpenSSH-only algorithms in a shared config are skipped
+		{"KexAlgorithms", "sntrup761x25519-sha512@openssh.com,curve25519-sha256", []string{"curve25519-sha256"}},
+		{"Ciphers", "+aes128-cbc,aes128-ctr", []string{
+			"aes128-gcm@openssh.com", "aes256-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+			"aes128-ctr", "aes192-ctr", "aes256-ctr", "aes128-cbc",
+		}},
+		{"MACs", "^hmac-sha2-512", []string{
+			"hmac-sha2-512", "hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com", "hmac-sha2-256",
+			"hmac-sha1", "hmac-sha1-96",
+		}},
+		{"MACs", "-hmac-sha1*", []string{
+			"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com", "hmac-sha2-256", "hmac-sha2-512",
+		}},
+		{"HostKeyAlgorithms", "ssh-ed25519", []string{"ssh-ed25519"}},
+	}
+	for _, tt := range tests {
+		got, err := ResolveAlgorithms(tt.option, tt.spec)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ResolveAlgorithms(%s, %q) = %v, %v; want %v", tt.option, tt.spec, got, err, tt.want)
+		}
+	}
+
+	for _, tt := range [][2]string{
+		{"Ciphers", "+nope"},
+		{"Ciphers", "nope,other"},
+		{"Ciphers", "-*"},
+		{"MACs", ","},
+		{"PubkeyAcceptedAlgorithms", "ssh-ed25519"},
+	} {
+		if got, err := ResolveAlgorithms(tt[0], tt[1]); err == nil {
+			t.Errorf("ResolveAlgorithms(%s, %q) = %v, want error", tt[0], tt[1], got)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kevinburke/ssh_config"
+	"golang.org/x/crypto/scrypt"
+)
+
+// bundleFormat 配置包的格式标识，导入时据此识别文件
+const bundleFormat = "my-sftp-config"
+
+// bundleVersion 配置包的格式版本
+const bundleVersion = 1
+
+// scrypt 参数（同 scrypt 论文推荐的交互式参数）
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	bundleKeyLen = 32
+)
+
+// 导入时接受的 scrypt 参数上限：参数来自不可信的文件，过大时派生密钥会耗尽内存和 CPU
+const (
+	scryptMaxN      = 1 << 20
+	scryptMaxMemory = 256 << 20 // scrypt 使用约 128·N·r 字节内存
+	scryptMaxP      = 4
+)
+
+// bundleCommandKeys 会在本机执行命令的 profile 设置，导入前需要用户确认
+var bundleCommandKeys = []string{"PreUploadScan", "PostDownloadScan"}
+
+// ErrBundlePassphrase 加密的配置包需要密码，或密码错误
+var ErrBundlePassphrase = errors.New("wrong passphrase or corrupted bundle")
+
+// Bundle 用于在机器之间共享的 my-sftp 配置（profile 配置文件）：
+// my-sftp config export 写出，my-sftp config import 安装
+type Bundle struct {
+	Format    string           `json:"format"`
+	Version   int              `json:"version"`
+	Exported  time.Time        `json:"exported"`
+	Profiles  string           `json:"profiles,omitempty"`  // profile 配置文件的内容；加密时为空
+	Encrypted *encryptedBundle `json:"encrypted,omitempty"` // 加密后的 Profiles
+}
+
+// encryptedBundle 用 scrypt 从密码派生密钥、AES-256-GCM 加密的内容
+type encryptedBundle struct {
+	KDF    string `json:"kdf"`
+	Salt   []byte `json:"salt"`
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	Cipher string `json:"cipher"`
+	Nonce  []byte `json:"nonce"`
+	Data   []byte `json:"data"`
+}
+
+// ProfileConfigPath 返回 profile 配置文件路径（$MY_SFTP_CONFIG 或 <用户配置目录>/my-sftp/config）
+func ProfileConfigPath() string {
+	return findProfilePath()
+}
+
+// ExportBundle 读取 profile 配置文件生成配置包；passphrase 非空时加密
+func ExportBundle(passphrase string) ([]byte, error) {
+	configPath := findProfilePath()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("read profile config: %w", err)
+	}
+	bundle := Bundle{Format: bundleFormat, Version: bundleVersion, Exported: time.Now().UTC().Truncate(time.Second)}
+	if passphrase == "" {
+		bundle.Profiles = string(data)
+	} else if bundle.Encrypted, err = encryptBundle(data, passphrase); err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// ParseBundle 解析配置包；加密时调用 passphrase 读取密码，并检查其中的 profile 都能解析
+func ParseBundle(data []byte, passphrase func() (string, error)) (*Bundle, error) {
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil || bundle.Format != bundleFormat {
+		return nil, fmt.Errorf("not a my-sftp config bundle")
+	}
+	if bundle.Version > bundleVersion {
+		return nil, fmt.Errorf("bundle version %d is newer than this my-sftp supports (%d)", bundle.Version, bundleVersion)
+	}
+	if bundle.Encrypted != nil {
+		pass, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		plain, err := bundle.Encrypted.decrypt(pass)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Profiles, bundle.Encrypted = string(plain), nil
+	}
+	if err := validateProfiles(bundle.Profiles); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// validateProfiles 检查配置文本中每个 Host 块的 profile 设置都有效，避免导入后连接时才报错
+func validateProfiles(text string) error {
+	cfg, err := ssh_config.Decode(bytes.NewReader([]byte(text)))
+	if err != nil {
+		return fmt.Errorf("parse profiles: %w", err)
+	}
+	for _, host := range cfg.Hosts {
+		for _, pattern := range host.Patterns {
+			if _, err := resolveProfile(cfg, pattern.String()); err != nil {
+				return fmt.Errorf("profiles: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Commands 配置包中会在本机执行的命令，每项形如 "Host web: PreUploadScan clamdscan -"
+func (b *Bundle) Commands() []string {
+	cfg, err := ssh_config.Decode(bytes.NewReader([]byte(b.Profiles)))
+	if err != nil {
+		return nil
+	}
+	var commands []string
+	for _, host := range cfg.Hosts {
+		var patterns []string
+		for _, pattern := range host.Patterns {
+			patterns = append(patterns, pattern.String())
+		}
+		for _, node := range host.Nodes {
+			kv, ok := node.(*ssh_config.KV)
+			if !ok {
+				continue
+			}
+			for _, key := range bundleCommandKeys {
+				if strings.EqualFold(kv.Key, key) {
+					commands = append(commands, fmt.Sprintf("Host %s: %s %s", strings.Join(patterns, " "), key, kv.Value))
+				}
+			}
+		}
+	}
+	return commands
+}
+
+// Install 把配置包中的 profile 写入配置文件。已有内容不同的配置文件时，force 为 false 返回错误，
+// 为 true 时先把原文件保存为 .old。返回写入的路径
+func (b *Bundle) Install(force bool) (string, error) {
+	configPath := findProfilePath()
+	current, err := os.ReadFile(configPath)
+	switch {
+	case err == nil && string(current) == b.Profiles:
+		return configPath, nil
+	case err == nil && !force:
+		return "", fmt.Errorf("%s already exists and differs from the bundle (use --force to replace it; the old file is kept as %s.old)", configPath, filepath.Base(configPath))
+	case err == nil:
+		if err := os.WriteFile(configPath+".old", current, 0600); err != nil {
+			return "", err
+		}
+	case !os.IsNotExist(err):
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return "", err
+	}
+	return configPath, os.WriteFile(configPath, []byte(b.Profiles), 0600)
+}
+
+func encryptBundle(plain []byte, passphrase string) (*encryptedBundle, error) {
+	e := &encryptedBundle{KDF: "scrypt", N: scryptN, R: scryptR, P: scryptP, Cipher: "aes-256-gcm", Salt: make([]byte, 16)}
+	if _, err := rand.Read(e.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := e.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	e.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(e.Nonce); err != nil {
+		return nil, err
+	}
+	e.Data = aead.Seal(nil, e.Nonce, plain, nil)
+	return e, nil
+}
+
+func (e *encryptedBundle) decrypt(passphrase string) ([]byte, error) {
+	if e.KDF != "scrypt" || e.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported bundle encryption %s/%s", e.KDF, e.Cipher)
+	}
+	if e.N <= 1 || e.N > scryptMaxN || e.R <= 0 || e.R > scryptMaxMemory/(128*e.N) || e.P <= 0 || e.P > scryptMaxP {
+		return nil, fmt.Errorf("unsupported scrypt parameters N=%d r=%d p=%d", e.N, e.R, e.P)
+	}
+	aead, err := e.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, e.Nonce, e.Data, nil)
+	if err != nil {
+		return nil, ErrBundlePassphrase
+	}
+	return plain, nil
+}
+
+// aead 由密码和 scrypt 参数派生 AES-256-GCM
+func (e *encryptedBundle) aead(passphrase string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), e.Salt, e.N, e.R, e.P, bundleKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Nonce) != 0 && len(e.Nonce) != gcm.NonceSize() {
+		return nil, ErrBundlePassphrase
+	}
+	return gcm, nil
+}
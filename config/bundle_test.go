@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const bundleProfiles = `Host prod-*
+    PromptColor red
+    Confirm rm rename put
+`
+
+func TestBundleRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MY_SFTP_CONFIG", filepath.Join(dir, "config"))
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(bundleProfiles), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pass := range []string{"", "s3cret"} {
+		data, err := ExportBundle(pass)
+		if err != nil {
+			t.Fatalf("ExportBundle(%q) error = %v", pass, err)
+		}
+		if pass != "" && strings.Contains(string(data), "PromptColor") {
+			t.Fatal("encrypted bundle contains the profiles in plain text")
+		}
+		bundle, err := ParseBundle(data, func() (string, error) { return pass, nil })
+		if err != nil {
+			t.Fatalf("ParseBundle(%q) error = %v", pass, err)
+		}
+		if bundle.Profiles != bundleProfiles {
+			t.Fatalf("Profiles = %q, want %q", bundle.Profiles, bundleProfiles)
+		}
+	}
+
+	data, _ := ExportBundle("s3cret")
+	if _, err := ParseBundle(data, func() (string, error) { return "wrong", nil }); !errors.Is(err, ErrBundlePassphrase) {
+		t.Fatalf("ParseBundle with wrong passphrase = %v, want ErrBundlePassphrase", err)
+	}
+}
+
+func TestBundleRejectsInvalidProfiles(t *testing.T) {
+	data := []byte(`{"format": "my-sftp-config", "version": 1, "profiles": "Host web\n    PromptDepth -1\n"}`)
+	if _, err := ParseBundle(data, nil); err == nil || !strings.Contains(err.Error(), "PromptDepth") {
+		t.Fatalf("ParseBundle() = %v, want an invalid PromptDepth error", err)
+	}
+	if _, err := ParseBundle([]byte(`{"hosts": []}`), nil); err == nil {
+		t.Fatal("ParseBundle() accepted a file that is not a bundle")
+	}
+}
+
+func TestBundleInstall(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "my-sftp", "config")
+	t.Setenv("MY_SFTP_CONFIG", configPath)
+	bundle := &Bundle{Profiles: bundleProfiles}
+
+	if _, err := bundle.Install(false); err != nil {
+		t.Fatalf("Install() into an empty directory error = %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte("Host old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bundle.Install(false); err == nil {
+		t.Fatal("Install() replaced a different config without --force")
+	}
+	if _, err := bundle.Install(true); err != nil {
+		t.Fatalf("Install(force) error = %v", err)
+	}
+	if old, _ := os.ReadFile(configPath + ".old"); string(old) != "Host old\n" {
+		t.Fatalf("config.old = %q, want the previous config", old)
+	}
+	if got, _ := os.ReadFile(configPath); string(got) != bundleProfiles {
+		t.Fatalf("config = %q, want the bundle", got)
+	}
+}
+
+func TestBundleRejectsExpensiveScrypt(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MY_SFTP_CONFIG", filepath.Join(dir, "config"))
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(bundleProfiles), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ExportBundle("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, params := range []string{`"n": 1073741824`, `"r": 1048576`, `"p": 1000`, `"n": 0`} {
+		key := strings.Split(params, ":")[0]
+		tampered := regexp.MustCompile(key+`: \d+`).ReplaceAllString(string(data), params)
+		if _, err := ParseBundle([]byte(tampered), func() (string, error) { return "s3cret", nil }); err == nil || !strings.Contains(err.Error(), "scrypt parameters") {
+			t.Errorf("ParseBundle() with %s = %v, want an unsupported parameters error", params, err)
+		}
+	}
+}
+
+func TestBundleCommands(t *testing.T) {
+	bundle := &Bundle{Profiles: bundleProfiles + "\nHost web db\n    PostDownloadScan clamdscan -\n\nHost *\n    preuploadscan ./check.sh\n"}
+	want := []string{"Host web db: PostDownloadScan clamdscan -", "Host *: PreUploadScan ./check.sh"}
+	if got := bundle.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Commands() = %q, want %q", got, want)
+	}
+	if got := (&Bundle{Profiles: bundleProfiles}).Commands(); len(got) != 0 {
+		t.Fatalf("Commands() = %q, want none", got)
+	}
+}
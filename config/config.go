@@ -1,58 +1,109 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-
-	"github.com/kevinburke/ssh_config"
+	"time"
 )
 
 // SSHConfig 封装 SSH 配置信息
 type SSHConfig struct {
-	Host         string
-	Port         int
-	User         string
-	IdentityFile string
+	Host string
+	Port int
+	User string
+	// IdentityFiles 按顺序尝试的私钥文件（SSH config 中可有多个 IdentityFile），空表示使用默认密钥
+	IdentityFiles  []string
+	IdentitiesOnly bool // 只使用 IdentityFiles 中的密钥：agent 中的其他密钥不尝试
+
+	StrictHostKeyChecking HostKeyPolicy // 未知主机和密钥变化的处理方式，空值同 ask
+	ConnectTimeout        time.Duration // 建立连接的超时，0 表示不限
+	ProxyJump             string        // 跳板主机，逗号分隔多级；空值表示直连
+	ServerAliveInterval   time.Duration // 发送保活请求的间隔，0 表示不发送
+	ServerAliveCountMax   int           // 连续多少次保活请求没有响应时断开连接，0 表示默认的 3
+	HashKnownHosts        bool          // 新加入 known_hosts 的主机名写成哈希（|1|...），不泄露主机列表
+	// Ciphers 等为 ssh_config 写法的算法列表（见 ResolveAlgorithms），空值表示库的默认值
+	Ciphers           string
+	MACs              string
+	KexAlgorithms     string
+	HostKeyAlgorithms string
 }
 
 // LoadSSHConfig 从 SSH config 文件加载配置
-// alias 是主机别名，如 "eegsys"
-func LoadSSHConfig(alias string) (*SSHConfig, error) {
+// alias 是主机别名，如 "eegsys"；user 为命令行上给出的用户名（可为空），用于 Match user
+func LoadSSHConfig(alias, user string) (*SSHConfig, error) {
+	cfg, err := decodeSSHConfig()
+	if err != nil {
+		return nil, err
+	}
+	return resolveHost(cfg, alias, user), nil
+}
+
+// LoadDestination 解析 user@host[:port]，并同 ssh 一样应用 SSH config 中匹配 host 的段（如 Host *.example.com 或 Match），
+// destination 中的用户和端口优先。没有 SSH config 文件时只使用 destination
+func LoadDestination(dest string) (*SSHConfig, error) {
+	conf, err := ParseDestination(dest)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decodeSSHConfig()
+	if errors.Is(err, ErrSSHConfigNotFound) {
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resolved := resolveHost(cfg, conf.Host, conf.User)
+	resolved.User = conf.User
+	if _, _, err := net.SplitHostPort(dest[strings.LastIndex(dest, "@")+1:]); err == nil {
+		resolved.Port = conf.Port
+	}
+	return resolved, nil
+}
+
+// ErrSSHConfigNotFound 找不到 SSH config 文件
+var ErrSSHConfigNotFound = errors.New("SSH config file not found")
+
+// decodeSSHConfig 查找并解析 SSH config 文件
+func decodeSSHConfig() (*sshConfigFile, error) {
 	// 查找 SSH config 文件位置
 	configPath := findSSHConfigPath()
 	if configPath == "" {
-		return nil, fmt.Errorf("SSH config file not found")
+		return nil, ErrSSHConfigNotFound
 	}
 
-	// 打开并解析配置文件
-	f, err := os.Open(configPath)
+	// 读取配置文件（展开 Include）并解析
+	data, err := readSSHConfig(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("open config: %w", err)
 	}
-	defer f.Close()
 
-	cfg, err := ssh_config.Decode(f)
+	cfg, err := parseSSHConfig(data)
 	if err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	return cfg, nil
+}
 
-	// 提取配置项
+// resolveHost 提取别名对应的配置项（Host 通配符和 Match 见 lookup）
+func resolveHost(cfg *sshConfigFile, alias, user string) *SSHConfig {
 	conf := &SSHConfig{}
+	options := cfg.lookup(alias, user)
 
 	// HostName
-	hostname, err := cfg.Get(alias, "HostName")
-	if err != nil || hostname == "" {
+	hostname := strings.ReplaceAll(options.get("HostName"), "%h", alias)
+	if hostname == "" {
 		// 如果没有 HostName，使用别名本身
 		hostname = alias
 	}
 	conf.Host = hostname
 
 	// Port
-	portStr, _ := cfg.Get(alias, "Port")
+	portStr := options.get("Port")
 	if portStr != "" {
 		if port, err := strconv.Atoi(portStr); err == nil {
 			conf.Port = port
@@ -63,21 +114,24 @@ func LoadSSHConfig(alias string) (*SSHConfig, error) {
 	}
 
 	// User
-	user, _ := cfg.Get(alias, "User")
-	conf.User = user
-
-	// IdentityFile
-	identityFile, _ := cfg.Get(alias, "IdentityFile")
-	if identityFile != "" {
-		// 展开 ~ 为用户主目录
-		if identityFile[0] == '~' {
-			home, _ := os.UserHomeDir()
-			identityFile = filepath.Join(home, identityFile[1:])
+	conf.User = options.get("User")
+
+	// IdentityFile 可以出现多次，按出现的顺序尝试（同 ssh）
+	for _, identityFile := range options.getAll("IdentityFile") {
+		if identityFile != "" {
+			conf.IdentityFiles = append(conf.IdentityFiles, expandHome(identityFile))
 		}
-		conf.IdentityFile = identityFile
 	}
 
-	return conf, nil
+	// 其余 -o 也支持的选项；无效的值被忽略
+	for _, key := range []string{"StrictHostKeyChecking", "ConnectTimeout", "ProxyJump", "ServerAliveInterval", "ServerAliveCountMax", "HashKnownHosts",
+		"IdentitiesOnly", "Ciphers", "MACs", "KexAlgorithms", "HostKeyAlgorithms"} {
+		if value := options.get(key); value != "" {
+			conf.ApplyOption(key, value)
+		}
+	}
+
+	return conf
 }
 
 // findSSHConfigPath 查找 SSH config 文件路径
@@ -125,7 +179,7 @@ func (c *SSHConfig) Merge(host string, port int, user string, keyFile string) {
 		c.User = user
 	}
 	if keyFile != "" {
-		c.IdentityFile = keyFile
+		c.IdentityFiles = []string{keyFile}
 	}
 }
 
@@ -156,10 +210,10 @@ func ParseDestination(dest string) (*SSHConfig, error) {
 		Port: 22, // 默认端口
 	}
 
-	// 分割 user@host[:port]
-	parts := strings.SplitN(dest, "@", 2)
-	config.User = parts[0]
-	hostPart := parts[1]
+	// 分割 user@host[:port]；用户名本身可能包含 @（如 me@corp.com@host），以最后一个 @ 为准
+	at := strings.LastIndex(dest, "@")
+	config.User = dest[:at]
+	hostPart := dest[at+1:]
 
 	// 使用 net.SplitHostPort 正确处理 IPv6 地址
 	host, portStr, err := net.SplitHostPort(hostPart)
@@ -174,13 +228,16 @@ func ParseDestination(dest string) (*SSHConfig, error) {
 	} else {
 		// 成功分离出主机和端口
 		config.Host = host
-		if port, err := strconv.Atoi(portStr); err == nil {
+		if port, err := strconv.Atoi(portStr); err == nil && port >= 1 && port <= 65535 {
 			config.Port = port
 		} else {
 			return nil, fmt.Errorf("invalid port number: %s", portStr)
 		}
 	}
 
+	if config.Host == "" || strings.ContainsAny(config.Host, "[] \t") {
+		return nil, fmt.Errorf("invalid host: %q", hostPart)
+	}
 	return config, nil
 }
 
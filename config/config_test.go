@@ -0,0 +1,169 @@
+package config
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDestination(t *testing.T) {
+	tests := []struct {
+		dest    string
+		want    SSHConfig
+		wantErr bool
+	}{
+		{dest: "user@192.168.1.100", want: SSHConfig{User: "user", Host: "192.168.1.100", Port: 22}},
+		{dest: "user@example.com:2222", want: SSHConfig{User: "user", Host: "example.com", Port: 2222}},
+		{dest: "user@[2001:db8::1]:22", want: SSHConfig{User: "user", Host: "2001:db8::1", Port: 22}},
+		{dest: "user@[2001:db8::1]", want: SSHConfig{User: "user", Host: "2001:db8::1", Port: 22}},
+		{dest: "me@corp.com@host", want: SSHConfig{User: "me@corp.com", Host: "host", Port: 22}},
+		{dest: "host", wantErr: true},
+		{dest: "user@", wantErr: true},
+		{dest: "user@host:0", wantErr: true},
+		{dest: "user@host:70000", wantErr: true},
+		{dest: "user@host:-1", wantErr: true},
+		{dest: "user@[::1", wantErr: true},
+		{dest: "user@ho st", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDestination(tt.dest)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDestination(%q) = %+v, want error", tt.dest, *got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("ParseDestination(%q) = %+v, %v; want %+v", tt.dest, got, err, tt.want)
+		}
+	}
+}
+
+func FuzzParseDestination(f *testing.F) {
+	for _, seed := range []string{"user@host", "user@host:2222", "user@[::1]:22", "user@[fe80::1%eth0]", "a@b@c", "@", "user@[", "用户@主机:22"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, dest string) {
+		conf, err := ParseDestination(dest)
+		if err != nil {
+			return
+		}
+		if conf.Host == "" || strings.ContainsAny(conf.Host, "@[] \t") {
+			t.Fatalf("ParseDestination(%q) host = %q", dest, conf.Host)
+		}
+		if conf.Port < 1 || conf.Port > 65535 {
+			t.Fatalf("ParseDestination(%q) port = %d", dest, conf.Port)
+		}
+		// 重新格式化后解析结果不变
+		again := conf.User + "@" + net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port))
+		conf2, err := ParseDestination(again)
+		if err != nil || !reflect.DeepEqual(*conf2, *conf) {
+			t.Fatalf("ParseDestination(%q) = %+v, but %q parses to %+v, %v", dest, *conf, again, conf2, err)
+		}
+	})
+}
+
+func TestParseSSHOption(t *testing.T) {
+	tests := []struct {
+		arg        string
+		key, value string
+		wantErr    bool
+	}{
+		{arg: "Port=2222", key: "Port", value: "2222"},
+		{arg: "port 2222", key: "Port", value: "2222"},
+		{arg: "stricthostkeychecking=accept-new", key: "StrictHostKeyChecking", value: "accept-new"},
+		{arg: "ProxyJump=bastion,jump2", key: "ProxyJump", value: "bastion,jump2"},
+		{arg: "ConnectTimeout = 10", key: "ConnectTimeout", value: "10"},
+		{arg: "Port=0", wantErr: true},
+		{arg: "StrictHostKeyChecking=maybe", wantErr: true},
+		{arg: "ServerAliveInterval=-5", wantErr: true},
+		{arg: "ServerAliveCountMax=0", wantErr: true},
+		{arg: "Compression=yes", wantErr: true},
+		{arg: "MACs=hmac-md5", wantErr: true},
+		{arg: "ForwardAgent=yes", wantErr: true},
+		{arg: "Port", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, value, err := ParseSSHOption(tt.arg)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSSHOption(%q) = %q, %q, want error", tt.arg, key, value)
+			}
+			continue
+		}
+		if err != nil || key != tt.key || value != tt.value {
+			t.Errorf("ParseSSHOption(%q) = %q, %q, %v; want %q, %q", tt.arg, key, value, err, tt.key, tt.value)
+		}
+	}
+}
+
+func TestParseHostKeyPolicy(t *testing.T) {
+	tests := map[string]HostKeyPolicy{
+		"yes": HostKeyStrict, "Accept-New": HostKeyAcceptNew, "no": HostKeyOff, "off": HostKeyOff, "ask": HostKeyAsk,
+	}
+	for value, want := range tests {
+		if got, err := ParseHostKeyPolicy(value); err != nil || got != want {
+			t.Errorf("ParseHostKeyPolicy(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseHostKeyPolicy("strict"); err == nil {
+		t.Error(`ParseHostKeyPolicy("strict") expected error`)
+	}
+}
+
+func TestApplyOption(t *testing.T) {
+	conf := SSHConfig{Host: "example.com", Port: 22, User: "root", ProxyJump: "bastion"}
+	for _, kv := range [][2]string{
+		{"port", "2200"}, {"User", "deploy"}, {"StrictHostKeyChecking", "off"},
+		{"ConnectTimeout", "10"}, {"ServerAliveInterval", "1m"}, {"ServerAliveCountMax", "5"}, {"ProxyJump", "none"}, {"HashKnownHosts", "yes"},
+		{"Ciphers", "+aes128-cbc"}, {"kexalgorithms", "-diffie-hellman-*"},
+		{"IdentityFile", "/keys/a"}, {"IdentityFile", "/keys/b"}, {"identitiesonly", "yes"},
+	} {
+		if err := conf.ApplyOption(kv[0], kv[1]); err != nil {
+			t.Fatalf("ApplyOption(%s, %s): %v", kv[0], kv[1], err)
+		}
+	}
+	want := SSHConfig{Host: "example.com", Port: 2200, User: "deploy", StrictHostKeyChecking: HostKeyOff,
+		ConnectTimeout: 10 * time.Second, ServerAliveInterval: time.Minute, ServerAliveCountMax: 5, HashKnownHosts: true,
+		Ciphers: "+aes128-cbc", KexAlgorithms: "-diffie-hellman-*", IdentityFiles: []string{"/keys/b"}, IdentitiesOnly: true}
+	if !reflect.DeepEqual(conf, want) {
+		t.Fatalf("conf = %+v, want %+v", conf, want)
+	}
+}
+
+func TestResolveHostIdentityFiles(t *testing.T) {
+	cfg, err := parseSSHConfig([]byte(`
+Host web
+    HostName web.example.com
+    IdentityFile /keys/web_ed25519
+    IdentityFile /keys/web_rsa.ppk
+    IdentitiesOnly yes
+
+Host db
+    HostName db.example.com
+
+Host *
+    IdentityFile /keys/shared
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 所有匹配的 Host 段中的 IdentityFile 按出现顺序累加（同 ssh）
+	web := resolveHost(cfg, "web", "")
+	if want := []string{"/keys/web_ed25519", "/keys/web_rsa.ppk", "/keys/shared"}; !reflect.DeepEqual(web.IdentityFiles, want) || !web.IdentitiesOnly {
+		t.Errorf("web: IdentityFiles = %v, IdentitiesOnly = %v; want %v, true", web.IdentityFiles, web.IdentitiesOnly, want)
+	}
+	db := resolveHost(cfg, "db", "")
+	if want := []string{"/keys/shared"}; !reflect.DeepEqual(db.IdentityFiles, want) || db.IdentitiesOnly {
+		t.Errorf("db: IdentityFiles = %v, IdentitiesOnly = %v; want %v, false", db.IdentityFiles, db.IdentitiesOnly, want)
+	}
+
+	// -i 替换 SSH config 中的全部密钥
+	web.Merge("", 0, "", "/tmp/other")
+	if want := []string{"/tmp/other"}; !reflect.DeepEqual(web.IdentityFiles, want) {
+		t.Errorf("after Merge: IdentityFiles = %v, want %v", web.IdentityFiles, want)
+	}
+}
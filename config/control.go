@@ -0,0 +1,105 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// controlTokens ControlPath 中 % 转义对应的值（见 ssh_config(5) TOKENS）
+type controlTokens struct {
+	alias     string // %n 命令行上给出的主机名或别名
+	host      string // %h 解析后的 HostName
+	port      int    // %p
+	user      string // %r 远程用户名
+	proxyJump string // %j
+}
+
+// ControlSocket 返回 ssh_config 为 alias 配置的 ControlPath 展开后的路径；
+// 未配置、配置为 none 或路径上不是 socket 文件时返回 ""
+// conf 是已解析的连接配置（HostName/Port/User），用于展开 %h/%p/%r/%C
+func ControlSocket(alias string, conf *SSHConfig) string {
+	cfg, err := decodeSSHConfig()
+	if err != nil {
+		return ""
+	}
+	options := cfg.lookup(alias, conf.User)
+	pattern := options.get("ControlPath")
+	if pattern == "" || strings.EqualFold(pattern, "none") {
+		return ""
+	}
+	proxyJump := options.get("ProxyJump")
+	socket := expandControlPath(pattern, controlTokens{
+		alias:     alias,
+		host:      conf.Host,
+		port:      conf.Port,
+		user:      conf.User,
+		proxyJump: proxyJump,
+	})
+	info, err := os.Stat(socket)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return ""
+	}
+	return socket
+}
+
+// expandControlPath 展开 ControlPath 中的 ~ 和 % 转义；不认识的转义原样保留
+func expandControlPath(pattern string, t controlTokens) string {
+	home, _ := os.UserHomeDir()
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		pattern = filepath.Join(home, pattern[1:])
+	}
+
+	localHost, _ := os.Hostname()
+	shortHost, _, _ := strings.Cut(localHost, ".")
+	port := strconv.Itoa(t.port)
+	var localUser, uid string
+	if u, err := user.Current(); err == nil {
+		localUser, uid = u.Username, u.Uid
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i+1 == len(pattern) {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case '%':
+			b.WriteByte('%')
+		case 'C':
+			// %C = SHA1(%l%h%p%r%j)，与 OpenSSH 一致
+			sum := sha1.Sum([]byte(localHost + t.host + port + t.user + t.proxyJump))
+			b.WriteString(hex.EncodeToString(sum[:]))
+		case 'd':
+			b.WriteString(home)
+		case 'h':
+			b.WriteString(t.host)
+		case 'i':
+			b.WriteString(uid)
+		case 'j':
+			b.WriteString(t.proxyJump)
+		case 'L':
+			b.WriteString(shortHost)
+		case 'l':
+			b.WriteString(localHost)
+		case 'n':
+			b.WriteString(t.alias)
+		case 'p':
+			b.WriteString(port)
+		case 'r':
+			b.WriteString(t.user)
+		case 'u':
+			b.WriteString(localUser)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandControlPath(t *testing.T) {
+	tokens := controlTokens{alias: "prod", host: "10.0.0.5", port: 2222, user: "admin"}
+	home, _ := os.UserHomeDir()
+	local, _ := os.Hostname()
+	sum := sha1.Sum([]byte(local + "10.0.0.5" + "2222" + "admin"))
+
+	tests := []struct {
+		pattern, want string
+	}{
+		{"/tmp/ssh-%r@%h:%p", "/tmp/ssh-admin@10.0.0.5:2222"},
+		{"~/.ssh/cm-%n", filepath.Join(home, ".ssh/cm-prod")},
+		{"/run/cm/%C", "/run/cm/" + hex.EncodeToString(sum[:])},
+		{"/tmp/100%%-%x", "/tmp/100%-%x"},
+		{"/tmp/trailing%", "/tmp/trailing%"},
+	}
+	for _, tt := range tests {
+		if got := expandControlPath(tt.pattern, tokens); got != tt.want {
+			t.Errorf("expandControlPath(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kevinburke/ssh_config"
+)
+
+// HostEntry SSH config 或 profile 中的一个主机别名及其解析后的配置
+type HostEntry struct {
+	Alias string
+	SSHConfig
+}
+
+// ListSSHHosts 列出 SSH config 中所有具体的 Host 别名（跳过通配符和否定模式），按出现顺序
+func ListSSHHosts() ([]HostEntry, error) {
+	cfg, err := decodeSSHConfig()
+	if err != nil {
+		return nil, err
+	}
+	return listHosts(cfg), nil
+}
+
+// ListHosts 列出 SSH config 中的主机别名，之后是只在 profile 中出现的别名。
+// 后者同样按 SSH config 解析（同 my-sftp <alias> 连接时），没有匹配的段时 HostName 就是别名本身
+func ListHosts() ([]HostEntry, error) {
+	cfg, err := decodeSSHConfig()
+	if errors.Is(err, ErrSSHConfigNotFound) {
+		cfg, err = parseSSHConfig(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := listProfileAliases()
+	if err != nil {
+		return nil, err
+	}
+	return mergeProfileHosts(cfg, aliases), nil
+}
+
+// mergeProfileHosts 在 SSH config 的主机之后加上 aliases 中没有出现过的别名
+func mergeProfileHosts(cfg *sshConfigFile, aliases []string) []HostEntry {
+	entries := listHosts(cfg)
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		seen[entry.Alias] = true
+	}
+	for _, alias := range aliases {
+		if !seen[alias] {
+			seen[alias] = true
+			entries = append(entries, HostEntry{Alias: alias, SSHConfig: *resolveHost(cfg, alias, "")})
+		}
+	}
+	return entries
+}
+
+// listProfileAliases 列出 profile 配置文件中的具体 Host 别名；配置文件不存在时返回空
+func listProfileAliases() ([]string, error) {
+	configPath := findProfilePath()
+	f, err := os.Open(configPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open profile config: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := ssh_config.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", configPath, err)
+	}
+	var aliases []string
+	for _, host := range cfg.Hosts {
+		aliases = append(aliases, concreteAliases(host)...)
+	}
+	return aliases, nil
+}
+
+func listHosts(cfg *sshConfigFile) []HostEntry {
+	var entries []HostEntry
+	seen := make(map[string]bool)
+	for i, host := range cfg.cfg.Hosts {
+		if _, isMatch := cfg.matches[i]; isMatch {
+			continue
+		}
+		for _, alias := range concreteAliases(host) {
+			if seen[alias] {
+				continue
+			}
+			seen[alias] = true
+			entries = append(entries, HostEntry{Alias: alias, SSHConfig: *resolveHost(cfg, alias, "")})
+		}
+	}
+	return entries
+}
+
+// concreteAliases 返回 Host 行中的具体别名，跳过通配符和否定模式
+func concreteAliases(host *ssh_config.Host) []string {
+	var aliases []string
+	for _, pattern := range host.Patterns {
+		alias := pattern.String()
+		if alias == "" || strings.ContainsAny(alias, "*?") {
+			continue
+		}
+		// 否定模式（!alias）不会匹配自身
+		if !host.Matches(alias) {
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
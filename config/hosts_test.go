@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListHosts(t *testing.T) {
+	cfg, err := parseSSHConfig([]byte(`
+Host web web-alt
+    HostName 10.0.0.5
+    User deploy
+
+Host db
+    HostName db.internal
+    Port 2222
+
+Host *.example.com !bastion
+    User admin
+
+Host web
+    Port 2200
+
+Host *
+    User fallback
+`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	hosts := listHosts(cfg)
+	want := []HostEntry{
+		{Alias: "web", SSHConfig: SSHConfig{Host: "10.0.0.5", Port: 2200, User: "deploy"}},
+		{Alias: "web-alt", SSHConfig: SSHConfig{Host: "10.0.0.5", Port: 22, User: "deploy"}},
+		{Alias: "db", SSHConfig: SSHConfig{Host: "db.internal", Port: 2222, User: "fallback"}},
+	}
+	if len(hosts) != len(want) {
+		t.Fatalf("listHosts() = %+v, want %+v", hosts, want)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(hosts[i], want[i]) {
+			t.Fatalf("listHosts()[%d] = %+v, want %+v", i, hosts[i], want[i])
+		}
+	}
+}
+
+func TestMergeProfileHosts(t *testing.T) {
+	cfg, err := parseSSHConfig([]byte(`
+Host web
+    HostName 10.0.0.5
+
+Host *
+    User fallback
+`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	// 只在 profile 中出现的别名排在后面，同样应用 Host * 的设置
+	hosts := mergeProfileHosts(cfg, []string{"web", "staging"})
+	want := []HostEntry{
+		{Alias: "web", SSHConfig: SSHConfig{Host: "10.0.0.5", Port: 22, User: "fallback"}},
+		{Alias: "staging", SSHConfig: SSHConfig{Host: "staging", Port: 22, User: "fallback"}},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Fatalf("mergeProfileHosts() = %+v, want %+v", hosts, want)
+	}
+}
+
+func TestListProfileAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("Host staging web\n    UploadDir /srv\n\nHost *.prod !db\n    Banner PRODUCTION\n\nHost *\n    AutoLsOnCd yes\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MY_SFTP_CONFIG", path)
+	aliases, err := listProfileAliases()
+	if want := []string{"staging", "web"}; err != nil || !reflect.DeepEqual(aliases, want) {
+		t.Fatalf("listProfileAliases() = %v, %v; want %v", aliases, err, want)
+	}
+
+	t.Setenv("MY_SFTP_CONFIG", filepath.Join(t.TempDir(), "missing"))
+	if aliases, err := listProfileAliases(); err != nil || aliases != nil {
+		t.Fatalf("listProfileAliases() without profile = %v, %v", aliases, err)
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxIncludeDepth Include 的最大嵌套层数（同 OpenSSH）
+const maxIncludeDepth = 16
+
+// readSSHConfig 读取 SSH config 文件，把其中的 Include 指令替换为被包含文件的内容。
+// 规则同 OpenSSH：可以有多个路径和通配符，~ 展开为主目录，相对路径相对于 ~/.ssh，
+// 匹配的文件按名称顺序读取，没有匹配的文件时忽略。
+// kevinburke/ssh_config 自带的 Include 不展开 ~，也无法列出被包含文件中的 Host，所以在解析前自行展开
+func readSSHConfig(path string) ([]byte, error) {
+	data, _, err := expandIncludes(path, 0)
+	return data, err
+}
+
+// expandIncludes 返回展开后的内容，以及其中是否出现了 Host 或 Match（会改变之后的行所属的段）
+func expandIncludes(path string, depth int) ([]byte, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if !bytes.Contains(bytes.ToLower(data), []byte("include")) {
+		return data, hasBlockHeader(data), nil
+	}
+
+	var out bytes.Buffer
+	header := "" // 当前所在段的 Host/Match 行，空表示还在全局部分
+	var cond []string
+	sawHeader := false
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		keyword, args := splitConfigLine(line)
+		switch strings.ToLower(keyword) {
+		case "host", "match":
+			header, sawHeader = strings.TrimSpace(line), true
+			cond = blockCondition(keyword, args)
+		case "include":
+			if depth >= maxIncludeDepth {
+				return nil, false, fmt.Errorf("%s: Include nested too deeply", path)
+			}
+			included, changed, err := includeFiles(args, depth+1)
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: %w", path, err)
+			}
+			if changed && len(cond) > 0 {
+				included = restrictBlocks(included, cond)
+			}
+			out.Write(included)
+			// 被包含的文件切换了段时，之后的行回到 Include 所在的段
+			if changed {
+				if header == "" {
+					out.WriteString("\nHost *\n")
+				} else {
+					out.WriteString("\n" + header + "\n")
+				}
+			}
+			continue
+		}
+		out.WriteString(line)
+	}
+	return out.Bytes(), sawHeader, nil
+}
+
+// blockCondition 把 Host/Match 行转换为等价的 Match 条件；总是生效的段（Host *、Match all）返回空
+func blockCondition(keyword string, args []string) []string {
+	if strings.EqualFold(keyword, "host") {
+		if slices.Equal(args, []string{"*"}) {
+			return nil
+		}
+		return []string{"originalhost", strings.Join(args, ",")}
+	}
+	if len(args) == 1 && strings.EqualFold(args[0], "all") {
+		return nil
+	}
+	return args
+}
+
+// restrictBlocks 段内的 Include 只在该段生效时才有作用（同 OpenSSH）：被包含的内容中的每个 Host/Match 段
+// 都改写为加上 cond 条件的 Match 段，否则其中的 Host 段在 Include 所在的段不生效时也会被应用
+func restrictBlocks(data []byte, cond []string) []byte {
+	var out bytes.Buffer
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		keyword, args := splitConfigLine(line)
+		if !strings.EqualFold(keyword, "host") && !strings.EqualFold(keyword, "match") {
+			out.WriteString(line)
+			continue
+		}
+		criteria := append([]string(nil), cond...)
+		criteria = append(criteria, blockCondition(keyword, args)...)
+		for i, arg := range criteria {
+			if strings.ContainsAny(arg, " \t") {
+				criteria[i] = `"` + arg + `"`
+			}
+		}
+		out.WriteString("Match " + strings.Join(criteria, " ") + "\n")
+	}
+	return out.Bytes()
+}
+
+// includeFiles 读取一条 Include 指令的全部文件并依次展开
+func includeFiles(patterns []string, depth int) ([]byte, bool, error) {
+	var out bytes.Buffer
+	changed := false
+	for _, pattern := range patterns {
+		pattern = expandHome(pattern)
+		if !filepath.IsAbs(pattern) {
+			home, _ := os.UserHomeDir()
+			pattern = filepath.Join(home, ".ssh", pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, false, fmt.Errorf("Include %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || info.IsDir() {
+				continue
+			}
+			data, sawHeader, err := expandIncludes(match, depth)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			out.Write(data)
+			if len(data) > 0 && data[len(data)-1] != '\n' {
+				out.WriteByte('\n')
+			}
+			changed = changed || sawHeader
+		}
+	}
+	return out.Bytes(), changed, nil
+}
+
+// splitConfigLine 拆分一行为关键字和参数（"Key value ..." 或 "Key=value"），注释和空行返回空关键字
+func splitConfigLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", nil
+	}
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return line, nil
+	}
+	keyword, rest := line[:end], strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+	return keyword, splitConfigArgs(rest)
+}
+
+// splitConfigArgs 按空白拆分参数，双引号中的空白不拆分（如 Match exec "test -e x"），# 开头的参数及之后为注释
+func splitConfigArgs(s string) []string {
+	var args []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" || s[0] == '#' {
+			return args
+		}
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return append(args, s[1:])
+			}
+			args, s = append(args, s[1:end+1]), s[end+2:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			return append(args, s)
+		}
+		args, s = append(args, s[:end]), s[end:]
+	}
+}
+
+func hasBlockHeader(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		keyword, _ := splitConfigLine(line)
+		if strings.EqualFold(keyword, "host") || strings.EqualFold(keyword, "match") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSSHConfigInclude(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	sshDir := filepath.Join(home, ".ssh")
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(sshDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config", `
+Include config.d/*.conf ~/.ssh/extra missing/*.conf
+
+Host web
+    Include web-options
+    Port 2200
+
+Host *
+    User global
+    IdentityFile ~/.ssh/shared
+`)
+	write("config.d/10-db.conf", "Host db\n    HostName db.internal\n    User dbadmin\n")
+	write("config.d/20-cache.conf", "Host cache\n    HostName cache.internal\n")
+	write("config.d/ignored.txt", "Host ignored\n")
+	write("extra", "Host jump\n    HostName jump.example.com\n    Include nested\n")
+	write("nested", "Port 2222\n")
+	write("web-options", "HostName web.internal\n")
+
+	data, err := readSSHConfig(filepath.Join(sshDir, "config"))
+	if err != nil {
+		t.Fatalf("readSSHConfig() error = %v", err)
+	}
+	cfg, err := parseSSHConfig(data)
+	if err != nil {
+		t.Fatalf("parseSSHConfig() error = %v\n%s", err, data)
+	}
+
+	var aliases []string
+	for _, entry := range listHosts(cfg) {
+		aliases = append(aliases, entry.Alias)
+	}
+	if want := []string{"db", "cache", "jump", "web"}; !reflect.DeepEqual(aliases, want) {
+		t.Fatalf("aliases = %v, want %v\n%s", aliases, want, data)
+	}
+
+	tests := []struct {
+		alias string
+		want  SSHConfig
+	}{
+		{alias: "db", want: SSHConfig{Host: "db.internal", Port: 22, User: "dbadmin"}},
+		{alias: "jump", want: SSHConfig{Host: "jump.example.com", Port: 2222, User: "global"}},
+		// 段内的 Include 之后，后续的行仍属于该段
+		{alias: "web", want: SSHConfig{Host: "web.internal", Port: 2200, User: "global"}},
+	}
+	for _, tt := range tests {
+		tt.want.IdentityFiles = []string{filepath.Join(sshDir, "shared")}
+		if got := resolveHost(cfg, tt.alias, ""); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("resolveHost(%q) = %+v, want %+v", tt.alias, *got, tt.want)
+		}
+	}
+}
+
+func TestReadSSHConfigIncludeInBlock(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"config": `
+Host web
+    Include web-extra
+
+Match user root
+    Include root-extra
+
+Host db
+    HostName db.internal
+`,
+		"web-extra":  "Port 2200\nHost db\n    User webonly\nHost *\n    IdentityFile /keys/web\n",
+		"root-extra": "Host db cache\n    Port 22022\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(sshDir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := readSSHConfig(filepath.Join(sshDir, "config"))
+	if err != nil {
+		t.Fatalf("readSSHConfig() error = %v", err)
+	}
+	cfg, err := parseSSHConfig(data)
+	if err != nil {
+		t.Fatalf("parseSSHConfig() error = %v\n%s", err, data)
+	}
+
+	// 被包含的 Host 段只在 Include 所在的段生效时才应用
+	tests := []struct {
+		alias, user string
+		want        SSHConfig
+	}{
+		{alias: "web", user: "admin", want: SSHConfig{Host: "web", Port: 2200, IdentityFiles: []string{"/keys/web"}}},
+		{alias: "db", user: "admin", want: SSHConfig{Host: "db.internal", Port: 22}},
+		{alias: "db", user: "root", want: SSHConfig{Host: "db.internal", Port: 22022}},
+	}
+	for _, tt := range tests {
+		if got := resolveHost(cfg, tt.alias, tt.user); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("resolveHost(%q, %q) = %+v, want %+v\n%s", tt.alias, tt.user, *got, tt.want, data)
+		}
+	}
+}
+
+func TestReadSSHConfigIncludeLoop(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte("Include "+path+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSSHConfig(path); err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+		t.Fatalf("readSSHConfig() error = %v, want nesting error", err)
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"fmt"
+	"os/user"
+	"strings"
+	"sync"
+
+	"github.com/kevinburke/ssh_config"
+)
+
+// sshConfigFile 解析后的 SSH config。kevinburke/ssh_config 不支持 Match：解析前把每个 Match 行换成 Host *，
+// 条件记在 matches 中（键为 cfg.Hosts 中对应段的下标），查询时按 OpenSSH 的规则逐段求值
+type sshConfigFile struct {
+	cfg     *ssh_config.Config
+	matches map[int][]matchCriterion
+}
+
+// matchCriterion Match 行中的一个条件，如 host *.example.com 或 !user root
+type matchCriterion struct {
+	name     string // all、canonical、final、host、originalhost、user、localuser、exec、localnetwork、tagged
+	negate   bool
+	patterns string // 逗号分隔的模式列表（同 OpenSSH，可用 * ? 和 ! 否定）
+}
+
+// matchArgs 需要参数的 Match 条件
+var matchArgs = map[string]bool{
+	"host": true, "originalhost": true, "user": true, "localuser": true, "exec": true, "localnetwork": true, "tagged": true,
+}
+
+// parseSSHConfig 解析 SSH config 的内容（Include 已展开，见 readSSHConfig）
+func parseSSHConfig(data []byte) (*sshConfigFile, error) {
+	file := &sshConfigFile{matches: make(map[int][]matchCriterion)}
+	var out strings.Builder
+	block := 0 // cfg.Hosts[0] 为文件开头的全局部分
+	for n, line := range strings.SplitAfter(string(data), "\n") {
+		keyword, args := splitConfigLine(line)
+		switch strings.ToLower(keyword) {
+		case "host":
+			block++
+		case "match":
+			block++
+			criteria, err := parseMatch(args)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			file.matches[block] = criteria
+			out.WriteString("Host *\n")
+			continue
+		}
+		out.WriteString(line)
+	}
+	cfg, err := ssh_config.DecodeBytes([]byte(out.String()))
+	if err != nil {
+		return nil, err
+	}
+	file.cfg = cfg
+	return file, nil
+}
+
+func parseMatch(args []string) ([]matchCriterion, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Match needs at least one criterion")
+	}
+	var criteria []matchCriterion
+	for i := 0; i < len(args); i++ {
+		c := matchCriterion{name: strings.ToLower(args[i])}
+		if strings.HasPrefix(c.name, "!") {
+			c.negate, c.name = true, c.name[1:]
+		}
+		switch {
+		case c.name == "all" || c.name == "canonical" || c.name == "final":
+		case matchArgs[c.name]:
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("Match %s needs an argument", c.name)
+			}
+			i++
+			c.patterns = args[i]
+		default:
+			return nil, fmt.Errorf("unsupported Match criterion %q", args[i])
+		}
+		criteria = append(criteria, c)
+	}
+	return criteria, nil
+}
+
+// hostOptions 一个主机的全部选项，键为小写的关键字，值按出现顺序
+type hostOptions map[string][]string
+
+// get 返回第一次出现的值（同 ssh，先出现的优先）
+func (o hostOptions) get(key string) string {
+	if values := o[strings.ToLower(key)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// getAll 返回全部值，用于 IdentityFile 等可以出现多次的选项
+func (o hostOptions) getAll(key string) []string {
+	return o[strings.ToLower(key)]
+}
+
+// lookup 按 OpenSSH 的规则收集 alias 的选项：依次检查每个段，Host 段按 alias 匹配，
+// Match 段按此前已得到的 HostName 和 User 求值。user 为命令行上给出的用户名，可以为空
+func (f *sshConfigFile) lookup(alias, user string) hostOptions {
+	options := hostOptions{}
+	for i, host := range f.cfg.Hosts {
+		if criteria, ok := f.matches[i]; ok {
+			if !f.evalMatch(criteria, alias, user, options) {
+				continue
+			}
+		} else if !host.Matches(alias) {
+			continue
+		}
+		for _, node := range host.Nodes {
+			if kv, ok := node.(*ssh_config.KV); ok {
+				key := strings.ToLower(kv.Key)
+				options[key] = append(options[key], kv.Value)
+			}
+		}
+	}
+	return options
+}
+
+// unsupportedMatch 不支持的 Match 条件：my-sftp 不执行 SSH config 中的命令，也没有 canonicalization
+var unsupportedMatch = map[string]bool{"exec": true, "localnetwork": true, "tagged": true, "canonical": true}
+
+// unsupportedMatchNotice 只提示一次忽略了含不支持条件的 Match 段
+var unsupportedMatchNotice sync.Once
+
+// evalMatch 所有条件都满足时 Match 段生效。含不支持的条件（包括否定形式，如 !exec）时整段不生效，
+// 否则无法求值的条件会被当作已满足或不满足，应用本不该应用的设置
+func (f *sshConfigFile) evalMatch(criteria []matchCriterion, alias, remoteUser string, options hostOptions) bool {
+	for _, c := range criteria {
+		if unsupportedMatch[c.name] {
+			unsupportedMatchNotice.Do(func() {
+				fmt.Printf("Warning: SSH config: Match %s is not supported, ignoring Match blocks that use it\n", c.name)
+			})
+			return false
+		}
+	}
+	host := alias
+	if hostname := options.get("HostName"); hostname != "" {
+		host = strings.ReplaceAll(hostname, "%h", alias)
+	}
+	if remoteUser == "" {
+		remoteUser = options.get("User")
+	}
+	if remoteUser == "" {
+		remoteUser = localUsername()
+	}
+	for _, c := range criteria {
+		var ok bool
+		switch c.name {
+		case "all", "final":
+			ok = true
+		case "host":
+			ok = matchPatternList(strings.ToLower(host), c.patterns)
+		case "originalhost":
+			ok = matchPatternList(strings.ToLower(alias), c.patterns)
+		case "user":
+			ok = matchPatternList(remoteUser, c.patterns)
+		case "localuser":
+			ok = matchPatternList(localUsername(), c.patterns)
+		}
+		if ok == c.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPatternList s 是否匹配逗号分隔的模式列表：匹配任一否定模式（!pattern）时不匹配
+func matchPatternList(s, list string) bool {
+	var patterns []*ssh_config.Pattern
+	for _, p := range strings.Split(list, ",") {
+		if pattern, err := ssh_config.NewPattern(strings.TrimSpace(p)); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return (&ssh_config.Host{Patterns: patterns}).Matches(s)
+}
+
+// localUsername 本地用户名；Windows 上去掉域名部分
+func localUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	if _, name, ok := strings.Cut(u.Username, `\`); ok {
+		return name
+	}
+	return u.Username
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const matchTestConfig = `
+Host bastion
+    HostName bastion.example.com
+    User jump
+
+Host web1
+    HostName 10.1.0.11
+
+Match originalhost web1 user deploy
+    IdentityFile /keys/deploy
+
+Match host 10.1.*,!10.1.0.99
+    ProxyJump bastion
+    Port 2200
+
+Host *.prod.example.com !db.prod.example.com
+    User deploy
+    IdentityFile /keys/prod
+
+Match user root
+    Port 22022
+
+Match exec "test -e /tmp/vpn"
+    ProxyJump none
+
+Match all
+    IdentityFile /keys/default
+`
+
+func TestMatchAndWildcardHosts(t *testing.T) {
+	cfg, err := parseSSHConfig([]byte(matchTestConfig))
+	if err != nil {
+		t.Fatalf("parseSSHConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		alias string
+		user  string // 命令行上的用户名
+		want  SSHConfig
+	}{
+		{
+			// Match host 按已得到的 HostName 求值；Host 通配符不匹配 10.1.0.11
+			name: "match resolved host", alias: "web1", user: "admin",
+			want: SSHConfig{Host: "10.1.0.11", Port: 2200, ProxyJump: "bastion", IdentityFiles: []string{"/keys/default"}},
+		},
+		{
+			name: "match command line user", alias: "web1", user: "deploy",
+			want: SSHConfig{Host: "10.1.0.11", Port: 2200, ProxyJump: "bastion", IdentityFiles: []string{"/keys/deploy", "/keys/default"}},
+		},
+		{
+			// Host 通配符段中的 User 在之后的 Match user 中可见
+			name: "wildcard host", alias: "api.prod.example.com",
+			want: SSHConfig{Host: "api.prod.example.com", Port: 22, User: "deploy", IdentityFiles: []string{"/keys/prod", "/keys/default"}},
+		},
+		{
+			name: "negated wildcard", alias: "db.prod.example.com", user: "root",
+			want: SSHConfig{Host: "db.prod.example.com", Port: 22022, IdentityFiles: []string{"/keys/default"}},
+		},
+		{
+			name: "negated match host", alias: "10.1.0.99", user: "admin",
+			want: SSHConfig{Host: "10.1.0.99", Port: 22, IdentityFiles: []string{"/keys/default"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveHost(cfg, tt.alias, tt.user); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("resolveHost(%q, %q) = %+v, want %+v", tt.alias, tt.user, *got, tt.want)
+			}
+		})
+	}
+
+	// Match 段不是别名
+	var aliases []string
+	for _, entry := range listHosts(cfg) {
+		aliases = append(aliases, entry.Alias)
+	}
+	if want := []string{"bastion", "web1"}; !reflect.DeepEqual(aliases, want) {
+		t.Errorf("aliases = %v, want %v", aliases, want)
+	}
+}
+
+func TestUnsupportedMatchIgnored(t *testing.T) {
+	cfg, err := parseSSHConfig([]byte(`
+Match !exec "test -e /tmp/vpn" host *
+    Port 2222
+
+Match !localnetwork 10.0.0.0/8
+    User remote
+
+Match !canonical
+    ProxyJump bastion
+`))
+	if err != nil {
+		t.Fatalf("parseSSHConfig() error = %v", err)
+	}
+	// 否定的不支持条件同样使整段不生效
+	want := SSHConfig{Host: "web", Port: 22}
+	if got := resolveHost(cfg, "web", "admin"); !reflect.DeepEqual(*got, want) {
+		t.Errorf("resolveHost() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestParseMatchErrors(t *testing.T) {
+	for _, text := range []string{"Match\n", "Match host\n", "Match address 10.0.0.1\n"} {
+		if _, err := parseSSHConfig([]byte(text)); err == nil {
+			t.Errorf("parseSSHConfig(%q) succeeded, want error", strings.TrimSpace(text))
+		}
+	}
+}
+
+func TestLoadDestination(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(matchTestConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SSH_CONFIG", path)
+
+	// user@host 同样应用匹配的段，destination 中的用户和端口优先
+	got, err := LoadDestination("admin@api.prod.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SSHConfig{Host: "api.prod.example.com", Port: 22, User: "admin", IdentityFiles: []string{"/keys/prod", "/keys/default"}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("LoadDestination() = %+v, want %+v", *got, want)
+	}
+
+	got, err = LoadDestination("root@web1:2022")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = SSHConfig{Host: "10.1.0.11", Port: 2022, User: "root", ProxyJump: "bastion", IdentityFiles: []string{"/keys/default"}}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("LoadDestination() = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frostime/my-sftp/units"
+)
+
+// sshOptionNames 支持的 ssh_config 关键字（-o 和 SSH config 文件），按规范大小写
+var sshOptionNames = []string{
+	"Port", "User", "IdentityFile", "IdentitiesOnly", "StrictHostKeyChecking", "ConnectTimeout", "ProxyJump", "ServerAliveInterval",
+	"ServerAliveCountMax", "HashKnownHosts", "Ciphers", "MACs", "KexAlgorithms", "HostKeyAlgorithms",
+}
+
+// HostKeyPolicy StrictHostKeyChecking 的取值：遇到未知主机或主机密钥变化时的处理方式
+type HostKeyPolicy string
+
+const (
+	HostKeyAsk       HostKeyPolicy = "ask"        // 询问是否信任未知主机（默认），密钥变化时拒绝连接
+	HostKeyStrict    HostKeyPolicy = "yes"        // 拒绝未知主机和密钥变化，只连接 known_hosts 中的主机
+	HostKeyAcceptNew HostKeyPolicy = "accept-new" // 不询问直接把未知主机加入 known_hosts，密钥变化时拒绝连接
+	HostKeyOff       HostKeyPolicy = "no"         // 不安全：加入未知主机，密钥变化时警告并禁用密码和 agent 认证
+)
+
+// ParseHostKeyPolicy 解析 StrictHostKeyChecking 的值（同 ssh，off 等同于 no），不区分大小写
+func ParseHostKeyPolicy(value string) (HostKeyPolicy, error) {
+	switch strings.ToLower(value) {
+	case "ask":
+		return HostKeyAsk, nil
+	case "yes":
+		return HostKeyStrict, nil
+	case "accept-new":
+		return HostKeyAcceptNew, nil
+	case "no", "off":
+		return HostKeyOff, nil
+	}
+	return "", fmt.Errorf("invalid StrictHostKeyChecking %q (want yes, no, accept-new or ask)", value)
+}
+
+// ParseSSHOption 解析 -o 的参数 Key=Value（同 ssh，也接受 "Key Value"），返回规范大小写的关键字和值
+func ParseSSHOption(s string) (key, value string, err error) {
+	key, value, ok := strings.Cut(strings.TrimSpace(s), "=")
+	if !ok {
+		key, value, ok = strings.Cut(key, " ")
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid option %q (want Key=Value)", s)
+	}
+	for _, name := range sshOptionNames {
+		if strings.EqualFold(key, name) {
+			return name, value, (&SSHConfig{}).ApplyOption(name, value)
+		}
+	}
+	return "", "", fmt.Errorf("unsupported option %q (supported: %s)", key, strings.Join(sshOptionNames, ", "))
+}
+
+// ApplyOption 按 ssh_config 关键字设置对应的字段，key 不区分大小写
+func (c *SSHConfig) ApplyOption(key, value string) error {
+	switch strings.ToLower(key) {
+	case "port":
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid Port %q", value)
+		}
+		c.Port = port
+	case "user":
+		if value == "" {
+			return fmt.Errorf("User must not be empty")
+		}
+		c.User = value
+	case "identityfile":
+		if value == "" {
+			return fmt.Errorf("IdentityFile must not be empty")
+		}
+		c.IdentityFiles = []string{expandHome(value)} // 同 -i，替换 SSH config 中的密钥
+	case "stricthostkeychecking":
+		policy, err := ParseHostKeyPolicy(value)
+		if err != nil {
+			return err
+		}
+		c.StrictHostKeyChecking = policy
+	case "connecttimeout":
+		d, err := parseSSHTime(value)
+		if err != nil {
+			return fmt.Errorf("invalid ConnectTimeout %q", value)
+		}
+		c.ConnectTimeout = d
+	case "proxyjump":
+		if strings.EqualFold(value, "none") {
+			value = ""
+		}
+		c.ProxyJump = value
+	case "serveraliveinterval":
+		d, err := parseSSHTime(value)
+		if err != nil {
+			return fmt.Errorf("invalid ServerAliveInterval %q", value)
+		}
+		c.ServerAliveInterval = d
+	case "serveralivecountmax":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid ServerAliveCountMax %q (want a positive number)", value)
+		}
+		c.ServerAliveCountMax = n
+	case "hashknownhosts":
+		switch strings.ToLower(value) {
+		case "yes":
+			c.HashKnownHosts = true
+		case "no":
+			c.HashKnownHosts = false
+		default:
+			return fmt.Errorf("invalid HashKnownHosts %q (want yes or no)", value)
+		}
+	case "identitiesonly":
+		switch strings.ToLower(value) {
+		case "yes":
+			c.IdentitiesOnly = true
+		case "no":
+			c.IdentitiesOnly = false
+		default:
+			return fmt.Errorf("invalid IdentitiesOnly %q (want yes or no)", value)
+		}
+	case "ciphers", "macs", "kexalgorithms", "hostkeyalgorithms":
+		if _, err := ResolveAlgorithms(key, value); err != nil {
+			return err
+		}
+		switch strings.ToLower(key) {
+		case "ciphers":
+			c.Ciphers = value
+		case "macs":
+			c.MACs = value
+		case "kexalgorithms":
+			c.KexAlgorithms = value
+		default:
+			c.HostKeyAlgorithms = value
+		}
+	default:
+		return fmt.Errorf("unsupported option %q", key)
+	}
+	return nil
+}
+
+// parseSSHTime 解析 ssh_config 的时间值：纯数字为秒，也接受 30s、5m 等（见 units.ParseDuration）；none 为 0
+func parseSSHTime(value string) (time.Duration, error) {
+	if strings.EqualFold(value, "none") {
+		return 0, nil
+	}
+	d, err := units.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return d, nil
+}
+
+// expandHome 展开开头的 ~ 为用户主目录
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, _ := os.UserHomeDir()
+		p = filepath.Join(home, p[1:])
+	}
+	return p
+}
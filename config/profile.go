@@ -0,0 +1,333 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kevinburke/ssh_config"
+
+	"github.com/frostime/my-sftp/lexer"
+	"github.com/frostime/my-sftp/units"
+)
+
+// Profile my-sftp 自己的按主机配置，保存在 ~/.config/my-sftp/config（ssh_config 语法）：
+//
+//	Host myserver
+//	    DownloadDir ~/Downloads/%h
+//	    UploadDir /srv/project
+//	    HostKeyFingerprint SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8
+//	    PreUploadScan clamdscan --no-summary -
+//	    PostDownloadScan clamdscan --no-summary -
+//	    ScanFailure flag
+//	    AutoLsOnCd 20
+//	    PromptHome yes
+//	    PromptDepth 3
+//	    PromptLocal yes
+//	    PromptColor red
+//	    Banner PRODUCTION
+//	    Confirm rm rename put
+//	    ProjectLink ~/code/site /srv/www/site
+//	    SFTPSubsystem /usr/lib/sftp-server
+//	    SFTPMaxPacket 16384
+//	    SFTPMaxRequests 8
+//	    SFTPConcurrentReads no
+//	    SFTPConcurrentWrites no
+//	    SFTPUseFstat yes
+//	    SFTPChannels 2
+//	    Concurrency 8
+//	    BufferSize 1M
+//	    SizeUnits decimal
+//	    Overwrite if-newer
+//	    Color no
+//	    ConvertBackslashes yes
+//	    HistoryFile ~/.local/state/my-sftp/history
+//
+// Host * 块中的设置作为所有主机的默认值（同 ssh_config，先出现的值优先，所以 Host * 放在最后）
+type Profile struct {
+	DownloadDir string // 不带 -d 的 get 的本地目标目录
+	UploadDir   string // 不带 -d 的 put 的远程目标目录
+	// HostKeyFingerprints 固定的主机密钥指纹（SHA256:...），非空时服务器密钥必须匹配其中之一，
+	// 且仍然会检查 known_hosts
+	HostKeyFingerprints []string
+	// PreUploadScan/PostDownloadScan 上传前/下载后扫描本地文件的命令（文件内容从 stdin 传入）
+	PreUploadScan    string
+	PostDownloadScan string
+	// ScanFlagOnly ScanFailure 为 flag：扫描未通过时只警告（默认 block：阻止上传、删除下载的文件）
+	ScanFlagOnly bool
+	// AutoLsOnCd cd 成功后自动显示的目录条目数，0 表示不显示（AutoLsOnCd yes 为 DefaultAutoLsEntries）
+	AutoLsOnCd int
+	// PromptHome 提示符中把主目录显示为 ~；PromptDepth 大于 0 时只显示路径的最后几级；
+	// PromptLocal 在提示符上方单独一行显示本地工作目录
+	PromptHome  bool
+	PromptDepth int
+	PromptLocal bool
+	// PromptColor 提示符路径的颜色名（如生产环境用 red），由 Shell 校验；
+	// Banner 进入交互式 Shell 时显示的醒目横幅（如 PRODUCTION），空表示不显示
+	PromptColor string
+	Banner      string
+	// Confirm 执行前需要确认（y/N）的命令，如生产环境的 rm rename put，由 Shell 校验
+	Confirm []string
+	// ProjectLocal/ProjectRemote 连接后自动建立的 project 映射（ProjectLink <local-dir> <remote-dir>）
+	ProjectLocal  string
+	ProjectRemote string
+	// SFTPSubsystem 等：行为特殊的服务器的 SFTP 兼容性设置（见 client.SFTPOptions），
+	// SFTPConcurrentReads/SFTPConcurrentWrites no 对应 SFTPSerialReads/SFTPSerialWrites
+	SFTPSubsystem    string
+	SFTPMaxPacket    int
+	SFTPMaxRequests  int
+	SFTPSerialReads  bool
+	SFTPSerialWrites bool
+	SFTPUseFstat     bool
+	SFTPChannels     int
+	// Concurrency 默认的并发传输数，0 表示内置默认值
+	Concurrency int
+	// BufferSize 传输缓冲区大小（如 1M），Overwrite get/put 默认的覆盖策略；
+	// 为空表示内置默认值，由使用方解析和校验
+	BufferSize string
+	Overwrite  string
+	// SizeUnits 显示大小的单位（同 set units），默认 binary
+	SizeUnits units.Style
+	// NoColor Color no：提示符不使用颜色
+	NoColor bool
+	// ConvertBackslashes 远程路径参数中的 \ 当作 /（同 set backslash convert）
+	ConvertBackslashes bool
+	// HistoryFile 交互式命令历史文件，none 表示不保存，空表示系统临时目录下的 my-sftp-history
+	HistoryFile string
+	// Path 配置文件路径（用于错误提示）
+	Path string
+}
+
+// DefaultAutoLsEntries AutoLsOnCd yes 显示的条目数
+const DefaultAutoLsEntries = 20
+
+// LoadProfile 读取 alias 对应的 profile；配置文件不存在时返回空 profile
+func LoadProfile(alias string) (*Profile, error) {
+	configPath := findProfilePath()
+	f, err := os.Open(configPath)
+	if os.IsNotExist(err) {
+		return &Profile{Path: configPath}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open profile config: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := ssh_config.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", configPath, err)
+	}
+	profile, err := resolveProfile(cfg, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
+	}
+	profile.Path = configPath
+	return profile, nil
+}
+
+// findProfilePath 返回 profile 配置文件路径：$MY_SFTP_CONFIG 或 <用户配置目录>/my-sftp/config
+func findProfilePath() string {
+	if configPath := os.Getenv("MY_SFTP_CONFIG"); configPath != "" {
+		return configPath
+	}
+	return filepath.Join(appConfigDir(), "config")
+}
+
+// appConfigDir 返回 my-sftp 的配置目录：<用户配置目录>/my-sftp
+func appConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "my-sftp")
+}
+
+func resolveProfile(cfg *ssh_config.Config, alias string) (*Profile, error) {
+	var err error
+	profile := &Profile{}
+	if dir, _ := cfg.Get(alias, "DownloadDir"); dir != "" {
+		profile.DownloadDir = expandProfilePath(dir, alias, true)
+	}
+	if dir, _ := cfg.Get(alias, "UploadDir"); dir != "" {
+		profile.UploadDir = expandProfilePath(dir, alias, false)
+	}
+	profile.PreUploadScan, _ = cfg.Get(alias, "PreUploadScan")
+	profile.PostDownloadScan, _ = cfg.Get(alias, "PostDownloadScan")
+	switch failure, _ := cfg.Get(alias, "ScanFailure"); strings.ToLower(failure) {
+	case "", "block":
+	case "flag":
+		profile.ScanFlagOnly = true
+	default:
+		return nil, fmt.Errorf("invalid ScanFailure %q for %s (want block or flag)", failure, alias)
+	}
+	if profile.AutoLsOnCd, err = parseAutoLs(cfg, alias); err != nil {
+		return nil, err
+	}
+	if profile.PromptHome, err = parseYesNo(cfg, alias, "PromptHome"); err != nil {
+		return nil, err
+	}
+	if profile.PromptLocal, err = parseYesNo(cfg, alias, "PromptLocal"); err != nil {
+		return nil, err
+	}
+	if depth, _ := cfg.Get(alias, "PromptDepth"); depth != "" {
+		if profile.PromptDepth, err = strconv.Atoi(depth); err != nil || profile.PromptDepth < 0 {
+			return nil, fmt.Errorf("invalid PromptDepth %q for %s (want a number of path components, 0 for all)", depth, alias)
+		}
+	}
+	profile.PromptColor, _ = cfg.Get(alias, "PromptColor")
+	if banner, _ := cfg.Get(alias, "Banner"); banner != "" {
+		// 允许用引号包住含空格的横幅
+		profile.Banner = strings.Trim(banner, `"'`)
+	}
+	if link, _ := cfg.Get(alias, "ProjectLink"); link != "" {
+		dirs := lexer.Split(link)
+		if len(dirs) != 2 {
+			return nil, fmt.Errorf("invalid ProjectLink %q for %s (want <local-dir> <remote-dir>)", link, alias)
+		}
+		profile.ProjectLocal = expandProfilePath(dirs[0], alias, true)
+		profile.ProjectRemote = expandProfilePath(dirs[1], alias, false)
+	}
+	if err := parseSFTPOptions(cfg, alias, profile); err != nil {
+		return nil, err
+	}
+	if err := parseDefaults(cfg, alias, profile); err != nil {
+		return nil, err
+	}
+	// Confirm 可以重复出现，也可以在一行中用空格或逗号分隔多个命令
+	confirm, _ := cfg.GetAll(alias, "Confirm")
+	for _, value := range confirm {
+		profile.Confirm = append(profile.Confirm, strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })...)
+	}
+	// HostKeyFingerprint 可以重复出现，也可以在一行中用空格或逗号分隔多个（密钥轮换期间）
+	values, _ := cfg.GetAll(alias, "HostKeyFingerprint")
+	for _, value := range values {
+		for _, fp := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			if !strings.HasPrefix(fp, "SHA256:") || len(fp) == len("SHA256:") {
+				return nil, fmt.Errorf("invalid HostKeyFingerprint %q for %s (want SHA256:<base64>)", fp, alias)
+			}
+			profile.HostKeyFingerprints = append(profile.HostKeyFingerprints, strings.TrimRight(fp, "="))
+		}
+	}
+	return profile, nil
+}
+
+// parseAutoLs 解析 AutoLsOnCd：yes/no 或显示的条目数
+func parseAutoLs(cfg *ssh_config.Config, alias string) (int, error) {
+	value, _ := cfg.Get(alias, "AutoLsOnCd")
+	switch strings.ToLower(value) {
+	case "", "no", "false":
+		return 0, nil
+	case "yes", "true":
+		return DefaultAutoLsEntries, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid AutoLsOnCd %q for %s (want yes, no or a number of entries)", value, alias)
+	}
+	return n, nil
+}
+
+// parseSFTPOptions 解析 SFTP 兼容性设置
+func parseSFTPOptions(cfg *ssh_config.Config, alias string, profile *Profile) error {
+	var err error
+	profile.SFTPSubsystem, _ = cfg.Get(alias, "SFTPSubsystem")
+	for key, field := range map[string]*int{
+		"SFTPMaxPacket": &profile.SFTPMaxPacket, "SFTPMaxRequests": &profile.SFTPMaxRequests, "SFTPChannels": &profile.SFTPChannels,
+	} {
+		value, _ := cfg.Get(alias, key)
+		if value == "" {
+			continue
+		}
+		if *field, err = strconv.Atoi(value); err != nil || *field < 1 {
+			return fmt.Errorf("invalid %s %q for %s (want a positive number)", key, value, alias)
+		}
+	}
+	concurrentReads, err := parseYesNoDefault(cfg, alias, "SFTPConcurrentReads", true)
+	if err != nil {
+		return err
+	}
+	concurrentWrites, err := parseYesNoDefault(cfg, alias, "SFTPConcurrentWrites", true)
+	if err != nil {
+		return err
+	}
+	profile.SFTPSerialReads, profile.SFTPSerialWrites = !concurrentReads, !concurrentWrites
+	profile.SFTPUseFstat, err = parseYesNo(cfg, alias, "SFTPUseFstat")
+	return err
+}
+
+// parseDefaults 解析传输和界面的默认设置
+func parseDefaults(cfg *ssh_config.Config, alias string, profile *Profile) error {
+	var err error
+	if value, _ := cfg.Get(alias, "Concurrency"); value != "" {
+		if profile.Concurrency, err = strconv.Atoi(value); err != nil || profile.Concurrency < 1 {
+			return fmt.Errorf("invalid Concurrency %q for %s (want a positive number)", value, alias)
+		}
+	}
+	profile.BufferSize, _ = cfg.Get(alias, "BufferSize")
+	profile.Overwrite, _ = cfg.Get(alias, "Overwrite")
+	if value, _ := cfg.Get(alias, "SizeUnits"); value != "" {
+		if profile.SizeUnits, err = units.ParseStyle(value); err != nil {
+			return fmt.Errorf("invalid SizeUnits %q for %s (want binary or decimal)", value, alias)
+		}
+	}
+	color, err := parseYesNoDefault(cfg, alias, "Color", true)
+	if err != nil {
+		return err
+	}
+	profile.NoColor = !color
+	if profile.ConvertBackslashes, err = parseYesNo(cfg, alias, "ConvertBackslashes"); err != nil {
+		return err
+	}
+	if history, _ := cfg.Get(alias, "HistoryFile"); history != "" && !strings.EqualFold(history, "none") {
+		profile.HistoryFile = expandProfilePath(history, alias, true)
+	} else {
+		profile.HistoryFile = strings.ToLower(history)
+	}
+	return nil
+}
+
+// parseYesNo 解析 yes/no 选项，未设置时为 false
+func parseYesNo(cfg *ssh_config.Config, alias, key string) (bool, error) {
+	return parseYesNoDefault(cfg, alias, key, false)
+}
+
+// parseYesNoDefault 解析 yes/no 选项，未设置时为 def
+func parseYesNoDefault(cfg *ssh_config.Config, alias, key string, def bool) (bool, error) {
+	value, _ := cfg.Get(alias, key)
+	switch strings.ToLower(value) {
+	case "":
+		return def, nil
+	case "no", "false":
+		return false, nil
+	case "yes", "true":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid %s %q for %s (want yes or no)", key, value, alias)
+}
+
+// MatchesFingerprint 判断 SHA256 指纹是否在固定列表中；没有固定指纹时总是返回 true
+func (p *Profile) MatchesFingerprint(fingerprint string) bool {
+	if len(p.HostKeyFingerprints) == 0 {
+		return true
+	}
+	fingerprint = strings.TrimRight(fingerprint, "=")
+	for _, pinned := range p.HostKeyFingerprints {
+		if pinned == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// expandProfilePath 将 %h 替换为主机别名；本地路径额外展开开头的 ~
+func expandProfilePath(p, alias string, local bool) string {
+	p = strings.ReplaceAll(p, "%h", alias)
+	if local && (p == "~" || strings.HasPrefix(p, "~/")) {
+		home, _ := os.UserHomeDir()
+		p = filepath.Join(home, p[1:])
+	}
+	return p
+}
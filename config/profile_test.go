@@ -0,0 +1,262 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kevinburke/ssh_config"
+
+	"github.com/frostime/my-sftp/units"
+)
+
+func decodeProfileConfig(t *testing.T, text string) *ssh_config.Config {
+	t.Helper()
+	cfg, err := ssh_config.Decode(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return cfg
+}
+
+func TestResolveProfile(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host web
+    UploadDir /srv/%h/current
+
+Host *
+    DownloadDir ~/Downloads/%h
+`)
+	home, _ := os.UserHomeDir()
+
+	got, err := resolveProfile(cfg, "web")
+	if err != nil {
+		t.Fatalf("resolveProfile(web) error = %v", err)
+	}
+	want := &Profile{DownloadDir: filepath.Join(home, "Downloads", "web"), UploadDir: "/srv/web/current"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("resolveProfile(web) = %+v, want %+v", *got, *want)
+	}
+
+	got, _ = resolveProfile(cfg, "db")
+	if got.UploadDir != "" || got.DownloadDir != filepath.Join(home, "Downloads", "db") {
+		t.Fatalf("resolveProfile(db) = %+v", *got)
+	}
+}
+
+func TestResolveProfileFingerprints(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host web
+    HostKeyFingerprint SHA256:oldKeyAAAA=
+    HostKeyFingerprint SHA256:newKeyBBBB, SHA256:spareCCCC
+
+Host bad
+    HostKeyFingerprint MD5:aa:bb
+`)
+	profile, err := resolveProfile(cfg, "web")
+	if err != nil {
+		t.Fatalf("resolveProfile(web) error = %v", err)
+	}
+	want := []string{"SHA256:oldKeyAAAA", "SHA256:newKeyBBBB", "SHA256:spareCCCC"}
+	if !reflect.DeepEqual(profile.HostKeyFingerprints, want) {
+		t.Fatalf("HostKeyFingerprints = %v, want %v", profile.HostKeyFingerprints, want)
+	}
+	if !profile.MatchesFingerprint("SHA256:newKeyBBBB") || !profile.MatchesFingerprint("SHA256:oldKeyAAAA") {
+		t.Fatal("MatchesFingerprint() rejected a pinned fingerprint")
+	}
+	if profile.MatchesFingerprint("SHA256:otherDDDD") {
+		t.Fatal("MatchesFingerprint() accepted an unpinned fingerprint")
+	}
+
+	if _, err := resolveProfile(cfg, "bad"); err == nil {
+		t.Fatal("resolveProfile(bad) expected error for non-SHA256 fingerprint")
+	}
+	if unpinned, _ := resolveProfile(cfg, "other"); !unpinned.MatchesFingerprint("SHA256:anything") {
+		t.Fatal("a profile without pins must accept any fingerprint")
+	}
+}
+
+func TestResolveProfileScanners(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host partner
+    PreUploadScan clamdscan --no-summary -
+    ScanFailure flag
+
+Host bad
+    ScanFailure warn
+
+Host *
+    PostDownloadScan /opt/scan.sh "$MY_SFTP_SCAN_FILE"
+`)
+	got, err := resolveProfile(cfg, "partner")
+	if err != nil {
+		t.Fatalf("resolveProfile(partner) error = %v", err)
+	}
+	want := &Profile{
+		PreUploadScan:    "clamdscan --no-summary -",
+		PostDownloadScan: `/opt/scan.sh "$MY_SFTP_SCAN_FILE"`,
+		ScanFlagOnly:     true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("resolveProfile(partner) = %+v, want %+v", *got, *want)
+	}
+	if _, err := resolveProfile(cfg, "bad"); err == nil {
+		t.Fatal("resolveProfile(bad) expected error for unknown ScanFailure")
+	}
+}
+
+func TestResolveProfileAutoLs(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host web
+    AutoLsOnCd yes
+
+Host logs
+    AutoLsOnCd 5
+
+Host bad
+    AutoLsOnCd some
+`)
+	for alias, want := range map[string]int{"web": DefaultAutoLsEntries, "logs": 5, "db": 0} {
+		got, err := resolveProfile(cfg, alias)
+		if err != nil || got.AutoLsOnCd != want {
+			t.Fatalf("resolveProfile(%s).AutoLsOnCd = %v, %v, want %d", alias, got, err, want)
+		}
+	}
+	if _, err := resolveProfile(cfg, "bad"); err == nil {
+		t.Fatal("resolveProfile(bad) expected error for invalid AutoLsOnCd")
+	}
+}
+
+func TestResolveProfilePrompt(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host web
+    PromptHome yes
+    PromptDepth 2
+    PromptLocal yes
+    PromptColor red
+    Banner "PRODUCTION - web"
+    Confirm rm, rename
+    Confirm put
+
+Host bad
+    PromptDepth -1
+
+Host worse
+    PromptHome maybe
+`)
+	got, err := resolveProfile(cfg, "web")
+	if err != nil {
+		t.Fatalf("resolveProfile(web) error = %v", err)
+	}
+	if !got.PromptHome || got.PromptDepth != 2 || !got.PromptLocal || got.PromptColor != "red" || got.Banner != "PRODUCTION - web" ||
+		!slices.Equal(got.Confirm, []string{"rm", "rename", "put"}) {
+		t.Fatalf("resolveProfile(web) = %+v", *got)
+	}
+	for _, alias := range []string{"bad", "worse"} {
+		if _, err := resolveProfile(cfg, alias); err == nil {
+			t.Fatalf("resolveProfile(%s) expected error", alias)
+		}
+	}
+}
+
+func TestResolveProfileProjectLink(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host web
+    ProjectLink "/home/me/my site" /srv/www/%h
+
+Host bad
+    ProjectLink /home/me/site
+`)
+	got, err := resolveProfile(cfg, "web")
+	if err != nil {
+		t.Fatalf("resolveProfile(web) error = %v", err)
+	}
+	if got.ProjectLocal != "/home/me/my site" || got.ProjectRemote != "/srv/www/web" {
+		t.Fatalf("resolveProfile(web) = %q <-> %q", got.ProjectLocal, got.ProjectRemote)
+	}
+	if _, err := resolveProfile(cfg, "bad"); err == nil {
+		t.Fatal("resolveProfile(bad) expected error")
+	}
+}
+
+func TestResolveProfileSFTPOptions(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host gateway
+    SFTPSubsystem /usr/lib/sftp-server
+    SFTPMaxPacket 16384
+    SFTPConcurrentReads no
+    SFTPUseFstat yes
+    SFTPChannels 2
+
+Host bad
+    SFTPMaxRequests 0
+`)
+	got, err := resolveProfile(cfg, "gateway")
+	if err != nil {
+		t.Fatalf("resolveProfile(gateway) error = %v", err)
+	}
+	if got.SFTPSubsystem != "/usr/lib/sftp-server" || got.SFTPMaxPacket != 16384 || got.SFTPMaxRequests != 0 ||
+		!got.SFTPSerialReads || got.SFTPSerialWrites || !got.SFTPUseFstat || got.SFTPChannels != 2 {
+		t.Fatalf("resolveProfile(gateway) = %+v", got)
+	}
+	if _, err := resolveProfile(cfg, "bad"); err == nil {
+		t.Fatal("resolveProfile(bad) expected error")
+	}
+}
+
+func TestResolveProfileDefaults(t *testing.T) {
+	cfg := decodeProfileConfig(t, `
+Host fast
+    Concurrency 16
+    HistoryFile none
+
+Host bad
+    Concurrency many
+
+Host badunits
+    SizeUnits metric
+
+Host *
+    Concurrency 2
+    BufferSize 1M
+    SizeUnits decimal
+    Overwrite if-newer
+    Color no
+    ConvertBackslashes yes
+    HistoryFile /var/tmp/%h-history
+`)
+	got, err := resolveProfile(cfg, "fast")
+	if err != nil {
+		t.Fatalf("resolveProfile(fast) error = %v", err)
+	}
+	if got.Concurrency != 16 || got.BufferSize != "1M" || got.SizeUnits != units.Decimal || got.Overwrite != "if-newer" || !got.NoColor || !got.ConvertBackslashes || got.HistoryFile != "none" {
+		t.Fatalf("resolveProfile(fast) = %+v", got)
+	}
+	got, err = resolveProfile(cfg, "other")
+	if err != nil {
+		t.Fatalf("resolveProfile(other) error = %v", err)
+	}
+	if got.Concurrency != 2 || got.HistoryFile != "/var/tmp/other-history" {
+		t.Fatalf("resolveProfile(other) = %+v", got)
+	}
+	for _, alias := range []string{"bad", "badunits"} {
+		if _, err := resolveProfile(cfg, alias); err == nil {
+			t.Fatalf("resolveProfile(%s) expected error", alias)
+		}
+	}
+}
+
+func TestLoadProfileMissingFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing")
+	t.Setenv("MY_SFTP_CONFIG", configPath)
+	profile, err := LoadProfile("web")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	if !reflect.DeepEqual(profile, &Profile{Path: configPath}) {
+		t.Fatalf("LoadProfile() = %+v, want empty profile", *profile)
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import "strings"
+
+// SplitRemoteSpec 解析 scp 风格的远程路径 destination:path
+// destination 可以是 SSH config 别名、user@host、user@host:port 或 user@[ipv6]:port，
+// 例如 "backup:/srv"、"user@host:2222:/srv"、"user@[::1]:data"。
+// 不含冒号、或形如 Windows 盘符（C:\x）时返回 ok=false，表示本地路径。
+func SplitRemoteSpec(spec string) (destination, remotePath string, ok bool) {
+	hostStart := strings.LastIndex(spec[:userPartEnd(spec)], "@") + 1
+
+	i := hostStart
+	if strings.HasPrefix(spec[i:], "[") {
+		end := strings.Index(spec[i:], "]")
+		if end < 0 {
+			return "", "", false
+		}
+		i += end + 1
+		if i >= len(spec) || spec[i] != ':' {
+			return "", "", false
+		}
+	} else {
+		colon := strings.Index(spec[i:], ":")
+		if colon < 0 {
+			return "", "", false
+		}
+		i += colon
+	}
+
+	host := strings.Trim(spec[hostStart:i], "[]")
+	if host == "" || strings.ContainsAny(host, `/\`) {
+		return "", "", false
+	}
+	if hostStart == 0 && len(host) == 1 {
+		// Windows 盘符
+		return "", "", false
+	}
+
+	// host:port:path 形式
+	rest := spec[i+1:]
+	if digits := leadingDigits(rest); digits > 0 && digits < len(rest) && rest[digits] == ':' {
+		i += 1 + digits
+		rest = spec[i+1:]
+	}
+	return spec[:i], rest, true
+}
+
+// userPartEnd 返回第一个冒号或路径分隔符的位置（没有则为 len），用于忽略路径中的 @
+func userPartEnd(s string) int {
+	if i := strings.IndexAny(s, `:/\`); i >= 0 {
+		return i
+	}
+	return len(s)
+}
+
+func leadingDigits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestSplitRemoteSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		wantDest string
+		wantPath string
+		wantOK   bool
+	}{
+		{spec: "backup:/srv/data", wantDest: "backup", wantPath: "/srv/data", wantOK: true},
+		{spec: "user@host:backups/", wantDest: "user@host", wantPath: "backups/", wantOK: true},
+		{spec: "user@host:", wantDest: "user@host", wantPath: "", wantOK: true},
+		{spec: "user@host:2222:/srv", wantDest: "user@host:2222", wantPath: "/srv", wantOK: true},
+		{spec: "user@host:2222", wantDest: "user@host", wantPath: "2222", wantOK: true},
+		{spec: "user@[2001:db8::1]:/srv", wantDest: "user@[2001:db8::1]", wantPath: "/srv", wantOK: true},
+		{spec: "user@[2001:db8::1]:22:/srv", wantDest: "user@[2001:db8::1]:22", wantPath: "/srv", wantOK: true},
+		{spec: "host:/mail/a@b", wantDest: "host", wantPath: "/mail/a@b", wantOK: true},
+		{spec: "./local/dir", wantOK: false},
+		{spec: "dir/with:colon", wantOK: false},
+		{spec: `C:\Users\me`, wantOK: false},
+		{spec: "C:/Users/me", wantOK: false},
+		{spec: "user@[::1]", wantOK: false},
+		{spec: ":/srv", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		dest, remotePath, ok := SplitRemoteSpec(tt.spec)
+		if ok != tt.wantOK || dest != tt.wantDest || remotePath != tt.wantPath {
+			t.Errorf("SplitRemoteSpec(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.spec, dest, remotePath, ok, tt.wantDest, tt.wantPath, tt.wantOK)
+		}
+	}
+}
+
+func FuzzSplitRemoteSpec(f *testing.F) {
+	for _, seed := range []string{"backup:/srv", "user@host:2222:/srv", "user@[::1]:data", `C:\x`, "host:/mail/a@b", "[", "@:"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, spec string) {
+		dest, remotePath, ok := SplitRemoteSpec(spec)
+		if !ok {
+			return
+		}
+		if dest == "" || dest+":"+remotePath != spec {
+			t.Fatalf("SplitRemoteSpec(%q) = (%q, %q), which does not rebuild the spec", spec, dest, remotePath)
+		}
+	})
+}
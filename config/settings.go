@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/frostime/my-sftp/lexer"
+)
+
+// ProjectSettingsFile 项目级设置文件名，从本地工作目录向上查找
+const ProjectSettingsFile = ".sftp-settings"
+
+// ProjectSettings 项目级设置（.sftp-settings）。每行一条指令，# 开头为注释，引号规则与 shell 相同：
+//
+//	# 上传时按类型放到不同子目录
+//	route *.png *.jpg *.gif mime:image/* -> assets/img
+//	route *.css *.js -> assets/static
+type ProjectSettings struct {
+	Path   string  // 设置文件路径
+	Routes []Route // 按出现顺序，第一条匹配的生效
+}
+
+// Route 一条 route 指令：匹配任一模式的文件上传到 Dir
+type Route struct {
+	Match []string // 文件名 glob，或 mime:<type>
+	Dir   string   // 相对于目标根目录的子目录，或远程绝对路径
+}
+
+// LoadProjectSettings 从 dir 开始逐级向上查找 .sftp-settings 并解析；找不到时返回 nil
+func LoadProjectSettings(dir string) (*ProjectSettings, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		settingsPath := filepath.Join(dir, ProjectSettingsFile)
+		f, err := os.Open(settingsPath)
+		if err == nil {
+			defer f.Close()
+			settings, err := parseProjectSettings(bufio.NewScanner(f))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", settingsPath, err)
+			}
+			settings.Path = settingsPath
+			return settings, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("open %s: %w", settingsPath, err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func parseProjectSettings(scanner *bufio.Scanner) (*ProjectSettings, error) {
+	settings := &ProjectSettings{}
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words := lexer.Split(line)
+		switch strings.ToLower(words[0]) {
+		case "route":
+			route, err := parseRoute(words[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			settings.Routes = append(settings.Routes, route)
+		default:
+			return nil, fmt.Errorf("line %d: unknown directive %q", lineNo, words[0])
+		}
+	}
+	return settings, scanner.Err()
+}
+
+// parseRoute 解析 route 的参数：<pattern>... -> <dir>
+func parseRoute(args []string) (Route, error) {
+	for i, arg := range args {
+		if arg != "->" {
+			continue
+		}
+		if i == 0 || i != len(args)-2 || args[i+1] == "" {
+			break
+		}
+		return Route{Match: args[:i], Dir: args[i+1]}, nil
+	}
+	return Route{}, fmt.Errorf("usage: route <pattern|mime:type>... -> <dir>")
+}
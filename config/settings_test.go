@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProjectSettings(t *testing.T) {
+	settings, err := parseProjectSettings(bufio.NewScanner(strings.NewReader(`
+# web deploy layout
+route *.png *.jpg mime:image/* -> assets/img
+ROUTE "*.css" *.js -> "assets/static files"
+`)))
+	if err != nil {
+		t.Fatalf("parseProjectSettings() error = %v", err)
+	}
+	want := []Route{
+		{Match: []string{"*.png", "*.jpg", "mime:image/*"}, Dir: "assets/img"},
+		{Match: []string{"*.css", "*.js"}, Dir: "assets/static files"},
+	}
+	if !reflect.DeepEqual(settings.Routes, want) {
+		t.Fatalf("Routes = %+v, want %+v", settings.Routes, want)
+	}
+
+	for _, bad := range []string{
+		"route *.png assets/img",
+		"route -> assets/img",
+		"route *.png -> a b",
+		"include other.conf",
+	} {
+		if _, err := parseProjectSettings(bufio.NewScanner(strings.NewReader(bad))); err == nil {
+			t.Errorf("parseProjectSettings(%q) expected error", bad)
+		}
+	}
+}
+
+func TestLoadProjectSettingsSearchesParents(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "site", "src")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if settings, err := LoadProjectSettings(nested); err != nil || settings != nil {
+		t.Fatalf("LoadProjectSettings() without file = %+v, %v", settings, err)
+	}
+
+	settingsPath := filepath.Join(root, "site", ProjectSettingsFile)
+	if err := os.WriteFile(settingsPath, []byte("route *.css -> static\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := LoadProjectSettings(nested)
+	if err != nil || settings == nil {
+		t.Fatalf("LoadProjectSettings() = %+v, %v", settings, err)
+	}
+	if settings.Path != settingsPath || len(settings.Routes) != 1 {
+		t.Fatalf("LoadProjectSettings() = %+v, want the file in site/", settings)
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// snapshotExt 快照文件扩展名
+const snapshotExt = ".json"
+
+// SnapshotDir 返回保存远程目录快照的本地目录：<用户配置目录>/my-sftp/snapshots
+func SnapshotDir() string {
+	return filepath.Join(appConfigDir(), "snapshots")
+}
+
+// SyncStateDir 返回保存双向同步状态的本地目录：<用户配置目录>/my-sftp/sync-state
+func SyncStateDir() string {
+	return filepath.Join(appConfigDir(), "sync-state")
+}
+
+// SnapshotPath 返回名为 name 的快照文件路径；name 不能包含路径分隔符
+func SnapshotPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid snapshot name: %q", name)
+	}
+	return filepath.Join(SnapshotDir(), name+snapshotExt), nil
+}
+
+// SnapshotNames 列出已保存的快照名称（按名称排序）
+func SnapshotNames() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(SnapshotDir(), "*"+snapshotExt))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = strings.TrimSuffix(filepath.Base(match), snapshotExt)
+	}
+	return names, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotPathAndNames(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	for _, bad := range []string{"", "..", "a/b", `a\b`} {
+		if _, err := SnapshotPath(bad); err == nil {
+			t.Errorf("SnapshotPath(%q) expected error", bad)
+		}
+	}
+
+	if names, err := SnapshotNames(); err != nil || len(names) != 0 {
+		t.Fatalf("SnapshotNames() without snapshots = %v, %v", names, err)
+	}
+	for _, name := range []string{"release-2", "release-1"} {
+		file, err := SnapshotPath(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := SnapshotNames()
+	if err != nil || !reflect.DeepEqual(names, []string{"release-1", "release-2"}) {
+		t.Fatalf("SnapshotNames() = %v, %v", names, err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+
+	terminal "golang.org/x/term"
+
+	"github.com/frostime/my-sftp/config"
+	"github.com/frostime/my-sftp/ttystate"
+)
+
+// bundlePassphraseEnv 配置包的密码，非交互式导出/导入时使用
+const bundlePassphraseEnv = "MY_SFTP_BUNDLE_PASSPHRASE"
+
+const configUsage = `Usage: my-sftp config export [--encrypt] <bundle.json|->
+       my-sftp config import [--force] [--allow-commands] <bundle.json|->`
+
+// runConfig 实现 my-sftp config 子命令：导出/导入 profile 配置，便于团队共享
+func runConfig(args []string) int {
+	if len(args) == 0 {
+		fmt.Println(configUsage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "export":
+		return runConfigExport(args[1:])
+	case "import":
+		return runConfigImport(args[1:])
+	}
+	fmt.Println(configUsage)
+	return exitUsage
+}
+
+func runConfigExport(args []string) int {
+	fs := flag.NewFlagSet("config export", flag.ContinueOnError)
+	encrypt := fs.Bool("encrypt", false, "Encrypt the bundle with a passphrase (prompted, or $"+bundlePassphraseEnv+")")
+	positional, err := parseSubcommandArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Println(configUsage)
+		return exitUsage
+	}
+
+	passphrase := ""
+	if *encrypt {
+		if passphrase, err = readBundlePassphrase(true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return exitFailed
+		}
+	}
+	data, err := config.ExportBundle(passphrase)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return exitFailed
+	}
+	if positional[0] == "-" {
+		os.Stdout.Write(data)
+		return exitOK
+	}
+	if err := os.WriteFile(positional[0], data, 0600); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return exitFailed
+	}
+	fmt.Printf("✓ Exported %s to %s\n", config.ProfileConfigPath(), positional[0])
+	return exitOK
+}
+
+func runConfigImport(args []string) int {
+	fs := flag.NewFlagSet("config import", flag.ContinueOnError)
+	force := fs.Bool("force", false, "Replace an existing, different profile config (kept as config.old)")
+	allowCommands := fs.Bool("allow-commands", false, "Install scan commands (PreUploadScan/PostDownloadScan) from the bundle without asking")
+	positional, err := parseSubcommandArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fmt.Println(configUsage)
+		return exitUsage
+	}
+
+	var data []byte
+	if positional[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(positional[0])
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return exitFailed
+	}
+	bundle, err := config.ParseBundle(data, func() (string, error) { return readBundlePassphrase(false) })
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", positional[0], err)
+		return exitFailed
+	}
+	if commands := bundle.Commands(); len(commands) > 0 && !*allowCommands {
+		if err := confirmBundleCommands(commands, positional[0] == "-"); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return exitFailed
+		}
+	}
+	path, err := bundle.Install(*force)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return exitFailed
+	}
+	fmt.Printf("✓ Imported profiles exported %s into %s\n", bundle.Exported.Local().Format("2006-01-02 15:04"), path)
+	return exitOK
+}
+
+// confirmBundleCommands 列出配置包中会在本机执行的命令并要求确认；
+// 配置包从 stdin 读取或不在终端中运行时无法确认，需要 --allow-commands
+func confirmBundleCommands(commands []string, fromStdin bool) error {
+	fmt.Println("The bundle sets commands that my-sftp will run on this machine:")
+	for _, command := range commands {
+		fmt.Printf("  %s\n", command)
+	}
+	if fromStdin || !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("review the commands above and pass --allow-commands to install them")
+	}
+	fmt.Print("Install these commands? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return errors.New("import cancelled")
+	}
+	return nil
+}
+
+// readBundlePassphrase 从 $MY_SFTP_BUNDLE_PASSPHRASE 或终端读取配置包密码；confirm 时要求输入两次
+func readBundlePassphrase(confirm bool) (string, error) {
+	if pass := os.Getenv(bundlePassphraseEnv); pass != "" {
+		return pass, nil
+	}
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("the bundle is encrypted: set %s or run in a terminal", bundlePassphraseEnv)
+	}
+	fmt.Print("Bundle passphrase: ")
+	pass, err := ttystate.ReadPassword(int(syscall.Stdin))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	if len(pass) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	if confirm {
+		fmt.Print("Repeat passphrase: ")
+		again, err := ttystate.ReadPassword(int(syscall.Stdin))
+		fmt.Println()
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(pass) {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return string(pass), nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+// connectRetries 由 --retry 指定：首次连接失败（主机不可达、超时等）后重试的次数
+var connectRetries int
+
+// maxConnectRetryDelay 重试间隔的上限
+const maxConnectRetryDelay = 30 * time.Second
+
+// retryConnect 调用 dial 建立连接，网络原因失败时按指数退避（1s、2s、4s……最多 30s）重试 connectRetries 次；
+// 认证失败和主机密钥问题不重试
+func retryConnect(dial func() (*client.Client, error)) (*client.Client, error) {
+	c, err := dial()
+	for attempt := 1; err != nil && attempt <= connectRetries && connectExitCode(err) == exitConnect; attempt++ {
+		delay := connectRetryDelay(attempt)
+		fmt.Printf("ℹ %v; retrying in %s (%d/%d)\n", err, delay, attempt, connectRetries)
+		time.Sleep(delay)
+		c, err = dial()
+	}
+	return c, err
+}
+
+// connectRetryDelay 第 attempt 次重试前的等待时间
+func connectRetryDelay(attempt int) time.Duration {
+	delay := time.Second << min(attempt-1, 5)
+	return min(delay, maxConnectRetryDelay)
+}
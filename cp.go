@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/shell"
+)
+
+// runCopy 执行一次性传输 my-sftp cp [options] <source>... <target> 后退出，
+// source 和 target 中至少一侧是 destination:path 形式的远程路径；两侧都是时在两台服务器之间传输
+func runCopy(args []string) int {
+	cmd, err := shell.ParseCopyCommand(args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println("Usage: my-sftp cp [get/put options] <local-path>... <destination>:<remote-path>")
+		fmt.Println("       my-sftp cp [get/put options] <destination>:<remote-path>... <local-path>")
+		fmt.Println("       my-sftp cp [-r] [-p] [--fail-fast] <source-host>:<remote-path>... <destination>:<remote-path>")
+		return exitUsage
+	}
+	if cmd.Source != "" {
+		return runRelay(cmd)
+	}
+
+	c, err := connect(cmd.Destination, client.StartEager)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return connectExitCode(err)
+	}
+	defer c.Close()
+
+	sh := shell.NewShell(c)
+	sh.SetJSONOutput(jsonOutput)
+	err = sh.RunCopy(cmd)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+	return finishCommands(sh, commandExitCode(c, sh, err))
+}
+
+// runRelay 连接源主机和目标主机，把文件经本机从一台服务器复制到另一台，本地不保存副本
+func runRelay(cmd *shell.CopyCommand) int {
+	from, err := connect(cmd.Source, client.StartEager)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", cmd.Source, err)
+		return connectExitCode(err)
+	}
+	defer from.Close()
+	c, err := connect(cmd.Destination, client.StartEager)
+	if err != nil {
+		fmt.Printf("Error: %s: %v\n", cmd.Destination, err)
+		return connectExitCode(err)
+	}
+	defer c.Close()
+
+	sh := shell.NewShell(c)
+	sh.SetJSONOutput(jsonOutput)
+	err = sh.RunRelay(from, cmd)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+	return finishCommands(sh, commandExitCode(c, sh, err))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+	"github.com/frostime/my-sftp/demo"
+)
+
+// demoDestination my-sftp demo 使用的 destination，connect 遇到它时连接 demoServer
+const demoDestination = "demo"
+
+// demoServer my-sftp demo 启动的内置服务器
+var demoServer *demo.Server
+
+// runDemo 执行 my-sftp demo [--dir DIR] [--keep]：在进程内启动 SFTP 服务器并连接到它，
+// 新用户可以放心尝试所有命令，开发者不需要真实服务器即可复现问题。
+// 默认在临时目录中生成示例文件，远程一侧为其中的 remote/，本地工作目录切换到 local/，退出时删除；
+// --dir 直接使用已有目录作为远程一侧，本地工作目录不变。全局选项（-e、-b、--json 等）也可以写在 demo 之后
+func runDemo(args []string, run func(destination string) int) int {
+	fs := flag.NewFlagSet("demo", flag.ContinueOnError)
+	dir := fs.String("dir", "", "Serve an existing `directory` instead of a fresh sample tree")
+	keep := fs.Bool("keep", false, "Keep the sample tree after exit")
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	positional, err := parseSubcommandArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) > 0 {
+		fmt.Printf("Error: unexpected argument %q\n", positional[0])
+		fmt.Println("Usage: my-sftp demo [--dir DIR] [--keep] [-e <commands> | -b <file>]")
+		return exitUsage
+	}
+
+	root := *dir
+	if root == "" {
+		sandbox, err := newDemoSandbox()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return exitFailed
+		}
+		if *keep {
+			defer fmt.Printf("ℹ Demo files kept in %s\n", sandbox)
+		} else {
+			defer os.RemoveAll(sandbox)
+		}
+		root = filepath.Join(sandbox, "remote")
+		if err := os.Chdir(filepath.Join(sandbox, "local")); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return exitFailed
+		}
+	} else if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		fmt.Printf("Error: --dir %s is not a directory\n", root)
+		return exitUsage
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return exitFailed
+	}
+
+	demoServer, err = demo.Start(root)
+	if err != nil {
+		fmt.Printf("Error: demo server: %v\n", err)
+		return exitFailed
+	}
+	defer demoServer.Close()
+	wd, _ := os.Getwd()
+	fmt.Printf("ℹ Demo server on %s\n", demoServer.Addr())
+	fmt.Printf("ℹ Remote side: %s\n", root)
+	fmt.Printf("ℹ Local side:  %s\n", wd)
+	return run(demoDestination)
+}
+
+// newDemoSandbox 创建临时目录，remote/ 中为示例文件，local/ 为空的本地工作目录
+func newDemoSandbox() (string, error) {
+	sandbox, err := os.MkdirTemp("", "my-sftp-demo-")
+	if err != nil {
+		return "", err
+	}
+	if err := demo.Seed(filepath.Join(sandbox, "remote")); err != nil {
+		os.RemoveAll(sandbox)
+		return "", fmt.Errorf("create sample files: %w", err)
+	}
+	if err := os.Mkdir(filepath.Join(sandbox, "local"), 0o755); err != nil {
+		os.RemoveAll(sandbox)
+		return "", err
+	}
+	return sandbox, nil
+}
+
+// connectDemo 连接 demoServer：以当前用户名和服务器生成的密码登录，主机密钥固定为服务器生成的密钥，不读取 SSH config 和 profile
+func connectDemo(mode client.StartMode) (*client.Client, error) {
+	name := "demo"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	sshClientConfig := &ssh.ClientConfig{
+		User:            name,
+		Auth:            []ssh.AuthMethod{ssh.Password(demoServer.Password())},
+		HostKeyCallback: ssh.FixedHostKey(demoServer.HostKey()),
+		Timeout:         10 * time.Second,
+	}
+	opts := append(slices.Clone(clientOptions), transferDefaults(&config.Profile{})...)
+	fmt.Printf("[my-sftp %s]Connecting to the demo server...\n", Version)
+	c, err := client.NewClient(demoServer.Addr(), sshClientConfig, mode, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	c.SetProgressMode(progressMode, progressEvery)
+	return c, nil
+}
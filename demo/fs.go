@@ -0,0 +1,266 @@
+package demo
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// rootFS SFTP 请求的处理器：路径与本机路径相同（exec 请求中的路径因此也有效），
+// 但只允许访问 root 之内的文件，经符号链接指向 root 以外的路径同样拒绝
+type rootFS struct {
+	root string // 已解析符号链接的绝对路径
+}
+
+func newRootFS(root string) (*rootFS, error) {
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	return &rootFS{root: resolved}, nil
+}
+
+func (fs *rootFS) handlers() sftp.Handlers {
+	return sftp.Handlers{FileGet: fs, FilePut: fs, FileCmd: fs, FileList: fs}
+}
+
+// startDirectory SFTP 会话的工作目录（SFTP 路径形式）
+func (fs *rootFS) startDirectory() string {
+	return "/" + strings.TrimPrefix(filepath.ToSlash(fs.root), "/")
+}
+
+// localPath 把 SFTP 路径转换为本机路径（Windows 上 /C:/x 对应 C:\x）
+func localPath(p string) string {
+	if runtime.GOOS == "windows" && len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+// ancestor name 是否为 root 的上级目录：允许查看其属性，客户端逐级创建目录时需要
+func (fs *rootFS) ancestor(name string) bool {
+	rel, err := filepath.Rel(name, fs.root)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// within name 是否为 root 或 root 之内的路径
+func (fs *rootFS) within(name string) bool {
+	rel, err := filepath.Rel(fs.root, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// resolve 返回 SFTP 路径 p 对应的本机路径。p 所在的目录必须在 root 之内；
+// follow 时 p 本身是符号链接的话，其指向的文件也必须在 root 之内（悬空的链接同样拒绝）
+func (fs *rootFS) resolve(p string, follow bool) (string, error) {
+	name := localPath(p)
+	if !fs.within(name) {
+		return "", sftp.ErrSSHFxPermissionDenied
+	}
+	if name == fs.root {
+		return name, nil
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(name)); err == nil && !fs.within(dir) {
+		return "", sftp.ErrSSHFxPermissionDenied
+	}
+	if info, err := os.Lstat(name); follow && err == nil && info.Mode()&os.ModeSymlink != 0 {
+		target, err := filepath.EvalSymlinks(name)
+		if err != nil || !fs.within(target) {
+			return "", sftp.ErrSSHFxPermissionDenied
+		}
+	}
+	return name, nil
+}
+
+func (fs *rootFS) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	name, err := fs.resolve(r.Filepath, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+func (fs *rootFS) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	return fs.OpenFile(r)
+}
+
+// OpenFile 按请求的标志打开文件；不使用 O_APPEND，否则与 WriteAt 冲突
+func (fs *rootFS) OpenFile(r *sftp.Request) (sftp.WriterAtReaderAt, error) {
+	name, err := fs.resolve(r.Filepath, true)
+	if err != nil {
+		return nil, err
+	}
+	pflags := r.Pflags()
+	flag := os.O_RDONLY
+	switch {
+	case pflags.Read && pflags.Write:
+		flag = os.O_RDWR
+	case pflags.Write:
+		flag = os.O_WRONLY
+	}
+	if pflags.Creat {
+		flag |= os.O_CREATE
+	}
+	if pflags.Trunc {
+		flag |= os.O_TRUNC
+	}
+	if pflags.Excl {
+		flag |= os.O_EXCL
+	}
+	return os.OpenFile(name, flag, 0o644)
+}
+
+func (fs *rootFS) Filecmd(r *sftp.Request) error {
+	follow := r.Method == "Setstat"
+	name, err := fs.resolve(r.Filepath, follow)
+	if r.Method == "Symlink" {
+		// Symlink 的 Filepath 是链接指向的目标，Target 是链接本身
+		name, err = fs.resolve(r.Target, false)
+	}
+	if err != nil {
+		return err
+	}
+	switch r.Method {
+	case "Setstat":
+		return fs.setstat(name, r)
+	case "Rename", "PosixRename":
+		target, err := fs.resolve(r.Target, false)
+		if err != nil {
+			return err
+		}
+		return os.Rename(name, target)
+	case "Link":
+		target, err := fs.resolve(r.Target, false)
+		if err != nil {
+			return err
+		}
+		return os.Link(name, target)
+	case "Symlink":
+		target := localPath(r.Filepath)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(name), target)
+		}
+		if !fs.within(filepath.Clean(target)) {
+			return sftp.ErrSSHFxPermissionDenied
+		}
+		return os.Symlink(localPath(r.Filepath), name)
+	case "Rmdir":
+		if info, err := os.Lstat(name); err == nil && !info.IsDir() {
+			return &os.PathError{Op: "rmdir", Path: r.Filepath, Err: os.ErrInvalid}
+		}
+		return os.Remove(name)
+	case "Remove":
+		return os.Remove(name)
+	case "Mkdir":
+		return os.Mkdir(name, 0o755)
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+func (fs *rootFS) PosixRename(r *sftp.Request) error {
+	return fs.Filecmd(r)
+}
+
+func (fs *rootFS) StatVFS(r *sftp.Request) (*sftp.StatVFS, error) {
+	name, err := fs.resolve(r.Filepath, true)
+	if err != nil {
+		return nil, err
+	}
+	return statVFS(name)
+}
+
+// setstat 修改大小、权限和时间（不修改属主）
+func (fs *rootFS) setstat(name string, r *sftp.Request) error {
+	flags, attrs := r.AttrFlags(), r.Attributes()
+	if flags.Size {
+		if err := os.Truncate(name, int64(attrs.Size)); err != nil {
+			return err
+		}
+	}
+	if flags.Permissions {
+		if err := os.Chmod(name, attrs.FileMode().Perm()); err != nil {
+			return err
+		}
+	}
+	if flags.Acmodtime {
+		if err := os.Chtimes(name, time.Unix(int64(attrs.Atime), 0), time.Unix(int64(attrs.Mtime), 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *rootFS) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		name, err := fs.resolve(r.Filepath, true)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		infos := make([]os.FileInfo, 0, len(entries))
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				infos = append(infos, info)
+			}
+		}
+		return listerAt(infos), nil
+	case "Stat":
+		name, err := fs.resolve(r.Filepath, true)
+		if fs.ancestor(localPath(r.Filepath)) {
+			name, err = localPath(r.Filepath), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+func (fs *rootFS) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	name, err := fs.resolve(r.Filepath, false)
+	if fs.ancestor(localPath(r.Filepath)) {
+		name, err = localPath(r.Filepath), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(name)
+	if err != nil {
+		return nil, err
+	}
+	return listerAt{info}, nil
+}
+
+func (fs *rootFS) Readlink(p string) (string, error) {
+	name, err := fs.resolve(p, false)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(name)
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(infos []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(infos, l[offset:])
+	if n < len(infos) {
+		return n, io.EOF
+	}
+	return n, nil
+}
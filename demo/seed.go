@@ -0,0 +1,64 @@
+package demo
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BigFileSize Seed 生成的 data/big.bin 的大小，足以看到进度条
+const BigFileSize = 8 << 20
+
+// Seed 在 dir 中生成示例文件：文本、嵌套目录、日志和一个较大的二进制文件，修改时间分散在过去几天
+func Seed(dir string) error {
+	var log strings.Builder
+	start := time.Now().Add(-time.Hour)
+	for i := range 200 {
+		level := []string{"INFO", "INFO", "INFO", "WARN", "ERROR"}[i%5]
+		fmt.Fprintf(&log, "%s %-5s request %d handled\n", start.Add(time.Duration(i)*15*time.Second).Format(time.RFC3339), level, i)
+	}
+	files := map[string]string{
+		"README.txt": "This is the my-sftp demo server. Everything here is a throwaway copy,\n" +
+			"so try anything: get, put, rm, sync, find, grep ...\n",
+		"docs/guide.md":         "# Guide\n\nSee `help` in the shell for every command.\n",
+		"docs/notes.txt":        "things to try: ll docs, find . -size +1M, grep ERROR logs/app.log, get -r docs, put guide.md -d uploads\n",
+		"docs/archive/2023.txt": "old notes\n",
+		"logs/app.log":          log.String(),
+		"logs/error.log":        "ERROR disk almost full\n",
+		"site/index.html":       "<!doctype html>\n<title>demo</title>\n<h1>Hello from my-sftp</h1>\n",
+		"site/css/style.css":    "body { font-family: sans-serif; }\n",
+		"site/js/app.js":        "console.log('demo');\n",
+	}
+	age := 0
+	for rel, content := range files {
+		if err := writeFile(dir, rel, []byte(content), age); err != nil {
+			return err
+		}
+		age++
+	}
+
+	big := make([]byte, BigFileSize)
+	if _, err := rand.Read(big); err != nil {
+		return err
+	}
+	if err := writeFile(dir, "data/big.bin", big, 0); err != nil {
+		return err
+	}
+	return os.MkdirAll(filepath.Join(dir, "uploads"), 0o755)
+}
+
+// writeFile 写入 dir 下的 rel，修改时间为 ageDays 天前
+func writeFile(dir, rel string, data []byte, ageDays int) error {
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return err
+	}
+	mtime := time.Now().Add(-time.Duration(ageDays) * 24 * time.Hour)
+	return os.Chtimes(p, mtime, mtime)
+}
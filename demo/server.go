@@ -0,0 +1,229 @@
+// Package demo 提供进程内的 SSH/SFTP 服务器，供 my-sftp demo 和集成测试使用，
+// 不需要真实的服务器或 Docker 即可尝试所有命令、复现问题
+package demo
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Server 只监听 127.0.0.1 随机端口的 SSH 服务器：接受任意用户名，密码为每次启动随机生成的 Password()。
+// SFTP 会话从 Root 目录开始，只能访问 Root 之内的文件；exec 请求（! 命令、校验和等）在 Root 中
+// 用本机的 shell 以当前用户的权限执行，不受此限制，所以密码只交给本进程中的客户端
+type Server struct {
+	Root string
+
+	password string
+	fs       *rootFS
+	listener net.Listener
+	config   *ssh.ServerConfig
+	hostKey  ssh.PublicKey
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Start 在 root 上启动服务器，每次使用新生成的主机密钥和密码
+func Start(root string) (*Server, error) {
+	fs, err := newRootFS(root)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate password: %w", err)
+	}
+	password := hex.EncodeToString(secret)
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate host key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("generate host key: %w", err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, given []byte) (*ssh.Permissions, error) {
+			if subtle.ConstantTimeCompare(given, []byte(password)) != 1 {
+				return nil, errors.New("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	s := &Server{
+		Root:     root,
+		password: password,
+		fs:       fs,
+		listener: listener,
+		config:   config,
+		hostKey:  signer.PublicKey(),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr 服务器的监听地址（host:port）
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Password 登录密码（每次启动随机生成）
+func (s *Server) Password() string {
+	return s.password
+}
+
+// HostKey 服务器的主机密钥，客户端用 ssh.FixedHostKey 校验
+func (s *Server) HostKey() ssh.PublicKey {
+	return s.hostKey
+}
+
+// Close 停止监听并断开所有连接
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// handleConn 处理一个 SSH 连接：只接受 session 通道，全局请求（如 keepalive）一律回复失败
+func (s *Server) handleConn(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		ch, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(ch, requests)
+	}
+}
+
+// handleSession 处理 session 通道上的 subsystem sftp 和 exec 请求；不支持 pty 和交互式 shell
+func (s *Server) handleSession(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range requests {
+		switch req.Type {
+		case "subsystem":
+			var payload struct{ Name string }
+			if ssh.Unmarshal(req.Payload, &payload) != nil || payload.Name != "sftp" {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			go ssh.DiscardRequests(requests)
+			server := sftp.NewRequestServer(ch, s.fs.handlers(), sftp.WithStartDirectory(s.fs.startDirectory()))
+			server.Serve()
+			server.Close()
+			return
+		case "exec":
+			var payload struct{ Command string }
+			if ssh.Unmarshal(req.Payload, &payload) != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			go ssh.DiscardRequests(requests)
+			s.exec(ch, payload.Command)
+			return
+		case "env":
+			req.Reply(true, nil)
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// exec 在 Root 中执行命令并回传退出码
+func (s *Server) exec(ch ssh.Channel, command string) {
+	cmd := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	}
+	cmd.Dir = s.Root
+	cmd.Stdout = ch
+	cmd.Stderr = ch.Stderr()
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		go func() {
+			io.Copy(stdin, ch)
+			stdin.Close()
+		}()
+	}
+
+	status := uint32(0)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			status = uint32(exitErr.ExitCode())
+		} else {
+			fmt.Fprintf(ch.Stderr(), "%v\n", err)
+			status = 127
+		}
+	}
+	ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+}
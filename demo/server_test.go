@@ -0,0 +1,100 @@
+package demo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	if err := Seed(root); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := Start(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	if _, err := ssh.Dial("tcp", srv.Addr(), &ssh.ClientConfig{
+		User:            "anyone",
+		Auth:            []ssh.AuthMethod{ssh.Password("anything")},
+		HostKeyCallback: ssh.FixedHostKey(srv.HostKey()),
+	}); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	conn, err := ssh.Dial("tcp", srv.Addr(), &ssh.ClientConfig{
+		User:            "anyone",
+		Auth:            []ssh.AuthMethod{ssh.Password(srv.Password())},
+		HostKeyCallback: ssh.FixedHostKey(srv.HostKey()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sc, err := sftp.NewClient(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	root, _ = filepath.EvalSymlinks(root)
+	if wd, err := sc.Getwd(); err != nil || wd != filepath.ToSlash(root) {
+		t.Fatalf("Getwd() = %q, %v; want %q", wd, err, root)
+	}
+	if info, err := sc.Stat("data/big.bin"); err != nil || info.Size() != BigFileSize {
+		t.Fatalf("Stat(data/big.bin) = %v, %v", info, err)
+	}
+	f, err := sc.Create("uploads/hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("hello"))
+	f.Close()
+	if data, err := os.ReadFile(filepath.Join(root, "uploads", "hello.txt")); err != nil || string(data) != "hello" {
+		t.Fatalf("uploaded file = %q, %v", data, err)
+	}
+
+	// SFTP 只能访问 Root 之内的文件，包括经符号链接访问
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{filepath.ToSlash(filepath.Join(outside, "secret")), "../" + filepath.Base(outside), "escape/secret", "escape"} {
+		if _, err := sc.Stat(p); !errors.Is(err, os.ErrPermission) {
+			t.Errorf("Stat(%s) error = %v, want permission denied", p, err)
+		}
+	}
+	if _, err := sc.Create("escape/new"); err == nil {
+		t.Error("Create(escape/new) succeeded")
+	}
+	if err := sc.Symlink(filepath.ToSlash(outside), "escape2"); err == nil {
+		t.Error("Symlink() to a path outside Root succeeded")
+	}
+
+	session, err := conn.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := session.Output("cat uploads/hello.txt")
+	if err != nil || strings.TrimSpace(string(out)) != "hello" {
+		t.Fatalf("exec output = %q, %v", out, err)
+	}
+	session, err = conn.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exitErr *ssh.ExitError
+	if err := session.Run("exit 3"); !errors.As(err, &exitErr) || exitErr.ExitStatus() != 3 {
+		t.Fatalf("exit status = %v, want 3", err)
+	}
+}
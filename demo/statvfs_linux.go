@@ -0,0 +1,27 @@
+package demo
+
+import (
+	"syscall"
+
+	"github.com/pkg/sftp"
+)
+
+// statVFS 文件系统容量（statvfs@openssh.com）
+func statVFS(name string) (*sftp.StatVFS, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(name, &stat); err != nil {
+		return nil, err
+	}
+	return &sftp.StatVFS{
+		Bsize:   uint64(stat.Bsize),
+		Frsize:  uint64(stat.Frsize),
+		Blocks:  stat.Blocks,
+		Bfree:   stat.Bfree,
+		Bavail:  stat.Bavail,
+		Files:   stat.Files,
+		Ffree:   stat.Ffree,
+		Favail:  stat.Ffree,
+		Flag:    uint64(stat.Flags),
+		Namemax: uint64(stat.Namelen),
+	}, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package demo
+
+import "github.com/pkg/sftp"
+
+// statVFS 只在 Linux 上支持
+func statVFS(string) (*sftp.StatVFS, error) {
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
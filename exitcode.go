@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/shell"
+)
+
+// 进程退出码，脚本可以据此区分失败原因
+const (
+	exitOK             = 0
+	exitFailed         = 1   // 命令失败
+	exitUsage          = 2   // 参数错误
+	exitPartial        = 3   // 有文件传输失败（其余文件可能已成功）
+	exitAuth           = 4   // 认证失败
+	exitHostKey        = 5   // 主机密钥不匹配、未固定或未被信任
+	exitConnectionLost = 6   // 会话中连接断开
+	exitConnect        = 255 // 无法建立连接（同 ssh）
+)
+
+// errorReportPath 由 --error-report 指定：-b、-e 和 cp 结束后写入失败的命令和文件
+var errorReportPath string
+
+// hostKeyError 主机密钥校验失败，connect 据此返回 exitHostKey
+type hostKeyError struct {
+	err error
+}
+
+func (e *hostKeyError) Error() string { return e.err.Error() }
+func (e *hostKeyError) Unwrap() error { return e.err }
+
+// connectExitCode 按 connect 返回的错误选择退出码
+func connectExitCode(err error) int {
+	var hkErr *hostKeyError
+	switch {
+	case errors.As(err, &hkErr):
+		return exitHostKey
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return exitAuth
+	}
+	return exitConnect
+}
+
+// commandExitCode 命令执行失败（err 非 nil）时的退出码：连接断开优先，其次是文件传输失败
+func commandExitCode(c *client.Client, sh *shell.Shell, err error) int {
+	switch {
+	case c.ConnectionLost():
+		return exitConnectionLost
+	case err == nil:
+		return exitOK
+	case sh.FailedFiles() > 0:
+		return exitPartial
+	}
+	return exitFailed
+}
+
+// finishCommands 写入 --error-report（如有）并返回 code
+func finishCommands(sh *shell.Shell, code int) int {
+	if errorReportPath == "" {
+		return code
+	}
+	if err := sh.WriteErrorReport(errorReportPath, code); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+	return code
+}
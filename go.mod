@@ -10,14 +10,17 @@ require (
 	github.com/kevinburke/ssh_config v1.2.0
 	github.com/pkg/sftp v1.13.6
 	github.com/schollz/progressbar/v3 v3.18.0
+	github.com/zeebo/blake3 v0.2.4
+	github.com/zeebo/xxh3 v1.1.0
 	golang.org/x/crypto v0.17.0
 	golang.org/x/sync v0.18.0
+	golang.org/x/sys v0.30.0
 	golang.org/x/term v0.28.0
 )
 
 require (
+	github.com/klauspost/cpuid/v2 v2.2.10 // indirect
 	github.com/kr/fs v0.1.0 // indirect
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.29.0 // indirect
 )
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	terminal "golang.org/x/term"
+)
+
+// updateHostKeys 由 --update-hostkeys 开启：主机密钥不匹配、但新密钥与 HostKeyFingerprint 固定的指纹一致时，
+// 不询问直接用新密钥替换 known_hosts 中的旧记录
+var updateHostKeys bool
+
+// offerHostKeyUpdate 主机密钥不匹配时提供替换 known_hosts 中旧记录的机会：
+// 新密钥已由固定的指纹确认（pinned）且指定了 --update-hostkeys 时直接替换，
+// 否则只在交互式运行时、用户输入新密钥的指纹确认后替换。
+// 返回 true 表示已替换，可以继续连接；mismatch 为不替换时返回的错误
+func offerHostKeyUpdate(path, hostname string, remote net.Addr, key ssh.PublicKey, stale []knownhosts.KnownKey, hashHost, pinned bool, mismatch error) (bool, error) {
+	fingerprint := ssh.FingerprintSHA256(key)
+	if updateHostKeys && pinned {
+		fmt.Printf("Warning: HOST KEY CHANGED for %s (old key %s); replacing it with %s, which matches the pinned fingerprint\n",
+			hostname, knownKeyFingerprints(stale), fingerprint)
+	} else {
+		if batchMode || !terminal.IsTerminal(int(os.Stdin.Fd())) {
+			if updateHostKeys {
+				// 没有固定的指纹时，新密钥必须由用户确认，不能无人值守地替换
+				return false, fmt.Errorf("%w (--update-hostkeys replaces the key without asking only when it matches a HostKeyFingerprint in the profile; "+
+					"otherwise connect interactively to confirm the new fingerprint)", mismatch)
+			}
+			return false, mismatch
+		}
+		fmt.Printf("\n@ WARNING: %v\n", mismatch)
+		fmt.Println("If the new key was verified with the server's administrator, type its fingerprint to replace")
+		fmt.Println("the old entry in known_hosts. Press Enter to abort.")
+		fmt.Print("Fingerprint: ")
+		text, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return false, mismatch
+		}
+		if text != fingerprint && "SHA256:"+text != fingerprint {
+			return false, fmt.Errorf("host key verification failed: the fingerprint typed does not match the remote key %s", fingerprint)
+		}
+	}
+
+	hosts := []string{knownhosts.Normalize(hostname)}
+	if remote != nil {
+		hosts = append(hosts, knownhosts.Normalize(remote.String()))
+	}
+	if err := removeKnownHosts(hosts, stale); err != nil {
+		return false, fmt.Errorf("update known_hosts: %w", err)
+	}
+	return true, appendToKnownHosts(path, hostname, key, hashHost)
+}
+
+// removeKnownHosts 从 known_hosts 中删除 stale 所在行里的 hosts：该行只有这些主机时删除整行，
+// 与其他主机共用时只去掉这些主机名。修改前的文件保存为 .old（同 ssh-keygen -R）
+func removeKnownHosts(hosts []string, stale []knownhosts.KnownKey) error {
+	lines := make(map[string][]int) // 文件 -> 行号
+	for _, k := range stale {
+		lines[k.Filename] = append(lines[k.Filename], k.Line)
+	}
+	for file, numbers := range lines {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		content := strings.SplitAfter(string(data), "\n")
+		for _, n := range numbers {
+			if n < 1 || n > len(content) {
+				return fmt.Errorf("%s:%d: line not found", file, n)
+			}
+			line, ok := removeHostsFromLine(content[n-1], hosts)
+			if !ok {
+				return fmt.Errorf("%s:%d: entry does not name the host directly (wildcard pattern?); edit it by hand", file, n)
+			}
+			content[n-1] = line
+		}
+		if err := os.WriteFile(file+".old", data, 0600); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, []byte(strings.Join(content, "")), 0600); err != nil {
+			return err
+		}
+		fmt.Printf("Removed the old key for %s from %s (original saved as %s.old)\n", hosts[0], file, file)
+	}
+	return nil
+}
+
+// removeHostsFromLine 从一行 known_hosts 中去掉 hosts（包括散列形式的主机名），
+// 没有剩余主机时返回空串；该行不含 hosts 中的任何一个时返回 false
+func removeHostsFromLine(line string, hosts []string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return line, false
+	}
+	patterns := strings.Split(fields[0], ",")
+	kept := slices.DeleteFunc(slices.Clone(patterns), func(p string) bool {
+		return slices.ContainsFunc(hosts, func(host string) bool { return knownHostMatches(p, host) })
+	})
+	switch {
+	case len(kept) == len(patterns):
+		return line, false
+	case len(kept) == 0:
+		return "", true
+	}
+	return strings.Replace(line, fields[0], strings.Join(kept, ","), 1), true
+}
+
+// knownHostMatches 判断 known_hosts 的一个主机字段是否就是 host（明文或 |1|salt|hash 散列形式）
+func knownHostMatches(pattern, host string) bool {
+	if !strings.HasPrefix(pattern, "|1|") {
+		return pattern == host
+	}
+	// 用字段中的盐值重新散列 host 后比较（HMAC-SHA1，同 ssh-keygen -H）
+	parts := strings.Split(pattern, "|")
+	if len(parts) != 4 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(host))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)) == parts[3]
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestRemoveHostsFromLine(t *testing.T) {
+	key := newTestHostKey(t)
+	keyText := key.Type() + " " + base64.StdEncoding.EncodeToString(key.Marshal())
+	hashed := knownhosts.HashHostname("example.com")
+	hashedPort := knownhosts.HashHostname("[example.com]:2222")
+
+	tests := []struct {
+		name  string
+		line  string
+		hosts []string
+		want  string
+		ok    bool
+	}{
+		{name: "plain", line: "example.com " + keyText + "\n", hosts: []string{"example.com"}, want: "", ok: true},
+		{name: "hashed", line: hashed + " " + keyText + "\n", hosts: []string{"example.com"}, want: "", ok: true},
+		{name: "hashed other host", line: hashed + " " + keyText + "\n", hosts: []string{"example.org"}, want: hashed + " " + keyText + "\n"},
+		{name: "port", line: "[example.com]:2222 " + keyText + "\n", hosts: []string{"[example.com]:2222"}, want: "", ok: true},
+		{name: "port does not match default port", line: "[example.com]:2222 " + keyText + "\n", hosts: []string{"example.com"},
+			want: "[example.com]:2222 " + keyText + "\n"},
+		{name: "hashed port", line: hashedPort + " " + keyText + "\n", hosts: []string{"[example.com]:2222"}, want: "", ok: true},
+		{
+			// 只有这些主机时删除整行
+			name: "comma list of the hosts", line: "example.com,10.0.0.5 " + keyText + "\n", hosts: []string{"example.com", "10.0.0.5"},
+			want: "", ok: true,
+		},
+		{
+			// 与其他主机共用时保留其他主机，行的其余部分不变
+			name: "comma list with other hosts", line: "web,example.com,10.0.0.5 " + keyText + " comment\n", hosts: []string{"example.com"},
+			want: "web,10.0.0.5 " + keyText + " comment\n", ok: true,
+		},
+		{
+			name: "comma list with hashed and plain", line: hashed + ",web " + keyText + "\n", hosts: []string{"example.com"},
+			want: "web " + keyText + "\n", ok: true,
+		},
+		{name: "wildcard", line: "*.com " + keyText + "\n", hosts: []string{"example.com"}, want: "*.com " + keyText + "\n"},
+		{name: "prefix is not a match", line: "example.com.evil " + keyText + "\n", hosts: []string{"example.com"},
+			want: "example.com.evil " + keyText + "\n"},
+		{name: "comment", line: "# example.com " + keyText + "\n", hosts: []string{"example.com"}, want: "# example.com " + keyText + "\n"},
+		{name: "too few fields", line: "example.com\n", hosts: []string{"example.com"}, want: "example.com\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := removeHostsFromLine(tt.line, tt.hosts)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("removeHostsFromLine(%q) = %q, %v; want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRemoveKnownHosts(t *testing.T) {
+	key := newTestHostKey(t)
+	keyText := key.Type() + " " + base64.StdEncoding.EncodeToString(key.Marshal())
+	otherText := newTestHostKey(t).Type() + " " + base64.StdEncoding.EncodeToString(newTestHostKey(t).Marshal())
+
+	// 不相关的行（注释、空行、多余的空白、其他主机）必须原样保留
+	lines := []string{
+		"# managed by hand\n",
+		"other.example.com\t" + otherText + "  trailing comment\n",
+		"example.com,10.0.0.5 " + keyText + "\n",
+		"\n",
+		knownhosts.HashHostname("example.com") + " " + keyText + "\n",
+		"web,example.com " + keyText + "\n",
+		"@cert-authority *.example.com " + otherText + "\n",
+		"last.example.com " + otherText, // 没有结尾的换行
+	}
+	original := strings.Join(lines, "")
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := []knownhosts.KnownKey{
+		{Filename: path, Line: 3, Key: key},
+		{Filename: path, Line: 5, Key: key},
+		{Filename: path, Line: 6, Key: key},
+	}
+	if err := removeKnownHosts([]string{"example.com", "10.0.0.5"}, stale); err != nil {
+		t.Fatalf("removeKnownHosts() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := lines[0] + lines[1] + lines[3] + "web " + keyText + "\n" + lines[6] + lines[7]
+	if string(data) != want {
+		t.Errorf("known_hosts =\n%q\nwant\n%q", data, want)
+	}
+	backup, err := os.ReadFile(path + ".old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != original {
+		t.Errorf("known_hosts.old =\n%q\nwant the original\n%q", backup, original)
+	}
+}
+
+func TestRemoveKnownHostsRefusesPattern(t *testing.T) {
+	key := newTestHostKey(t)
+	original := "*.example.com " + key.Type() + " " + base64.StdEncoding.EncodeToString(key.Marshal()) + "\n"
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 通配符记录需要手工修改：不修改文件，也不写 .old
+	err := removeKnownHosts([]string{"web.example.com"}, []knownhosts.KnownKey{{Filename: path, Line: 1, Key: key}})
+	if err == nil || !strings.Contains(err.Error(), "edit it by hand") {
+		t.Fatalf("removeKnownHosts() error = %v, want wildcard error", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("known_hosts changed to %q", data)
+	}
+	if _, err := os.Stat(path + ".old"); !os.IsNotExist(err) {
+		t.Errorf("known_hosts.old written: %v", err)
+	}
+
+	err = removeKnownHosts([]string{"web.example.com"}, []knownhosts.KnownKey{{Filename: path, Line: 5, Key: key}})
+	if err == nil || !strings.Contains(err.Error(), "line not found") {
+		t.Fatalf("removeKnownHosts() with missing line error = %v", err)
+	}
+}
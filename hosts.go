@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frostime/my-sftp/config"
+)
+
+// hostCheckTimeout --check 时每个主机的 TCP 连接超时
+const hostCheckTimeout = 3 * time.Second
+
+// runHosts 实现 my-sftp hosts 子命令：列出 SSH config 和 profile 中的主机别名
+func runHosts(args []string) int {
+	fs := flag.NewFlagSet("hosts", flag.ContinueOnError)
+	check := fs.Bool("check", false, "Test TCP reachability of every host")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 0 {
+		fmt.Println("Usage: my-sftp hosts [--check]")
+		return exitUsage
+	}
+
+	hosts, err := config.ListHosts()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if len(hosts) == 0 {
+		fmt.Println("No Host aliases found in SSH config or profiles")
+		return 0
+	}
+
+	var status []string
+	if *check {
+		status = checkHosts(hosts)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "ALIAS\tHOSTNAME\tUSER\tPORT"
+	if *check {
+		header += "\tSTATUS"
+	}
+	fmt.Fprintln(w, header)
+	for i, host := range hosts {
+		user := host.User
+		if user == "" {
+			user = "-"
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%d", host.Alias, host.Host, user, host.Port)
+		if *check {
+			line += "\t" + status[i]
+		}
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+	return 0
+}
+
+// checkHosts 并发测试所有主机的 TCP 端口是否可连接，返回与 hosts 对应的状态
+func checkHosts(hosts []config.HostEntry) []string {
+	status := make([]string, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addr := net.JoinHostPort(host.Host, strconv.Itoa(host.Port))
+			start := time.Now()
+			conn, err := net.DialTimeout("tcp", addr, hostCheckTimeout)
+			if err != nil {
+				status[i] = "unreachable"
+				return
+			}
+			conn.Close()
+			status[i] = fmt.Sprintf("ok (%s)", time.Since(start).Round(time.Millisecond))
+		}()
+	}
+	wg.Wait()
+	return status
+}
@@ -0,0 +1,216 @@
+// Package lexer 把交互式命令行拆分为单词。
+//
+// Shell（解析命令）和补全器（找出光标所在的单词、转义插入的候选）共用同一套规则，
+// 所以 Tab 补全写入命令行的内容，解析时读回的值不变。
+//
+// 引号规则（与 POSIX 相近，为方便 Windows 有一处不同）：
+//
+//   - 引号之外的空格和制表符分隔单词。
+//   - 单引号：直到下一个单引号之前的所有字符都按字面处理。
+//   - 双引号：字符按字面处理，但 \" 表示 "，\\ 表示 \；其他反斜杠原样保留。
+//   - 引号之外的反斜杠是普通字符，C:\Users\file.txt 这样的 Windows 路径可以直接粘贴，
+//     不需要加引号（POSIX shell 会把它当作转义）。
+//   - 相邻的带引号和不带引号的部分组成一个单词：a"b c"d 是一个单词 "ab cd"。
+//   - 一对空的双引号或单引号是一个空单词。
+//   - 双引号未关闭时，位于行尾的 \" 读作字面的反斜杠加上关闭的引号，
+//     所以 "C:\Program Files\" 得到 C:\Program Files\，而不是未结束的单词。
+//   - 未关闭的引号延续到行尾；Lex 会报告它，调用方（补全器）可以接着补全带引号的单词。
+//   - 引号之外的 ; 分隔命令（见 SplitCommands），所以 Quote 会给含 ; 的单词加上引号。
+package lexer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Token 命令行中的一个单词
+type Token struct {
+	Value string // 去掉引号和转义后的单词
+	Start int    // 第一个原始字符的字节偏移
+	End   int    // 最后一个原始字符之后的字节偏移
+}
+
+// Raw 返回单词在 line 中的原始文本
+func (t Token) Raw(line string) string {
+	return line[t.Start:t.End]
+}
+
+// RawLen 返回单词在 line 中占用的字符（rune）数
+func (t Token) RawLen(line string) int {
+	return utf8.RuneCountInString(line[t.Start:t.End])
+}
+
+// Split 把 line 拆分为单词，未关闭的引号视为在行尾关闭
+func Split(line string) []string {
+	tokens, _ := Lex(line)
+	if len(tokens) == 0 {
+		return nil
+	}
+	words := make([]string, len(tokens))
+	for i, tok := range tokens {
+		words[i] = tok.Value
+	}
+	return words
+}
+
+// Lex 把 line 拆分为单词，并返回行尾仍未关闭的引号字符（没有时为 0）
+func Lex(line string) ([]Token, rune) {
+	var tokens []Token
+	var current strings.Builder
+	inToken := false
+	start := 0
+	quote := rune(0)
+
+	flush := func(end int) {
+		if inToken {
+			tokens = append(tokens, Token{Value: current.String(), Start: start, End: end})
+		}
+		current.Reset()
+		inToken = false
+	}
+	begin := func(i int) {
+		if !inToken {
+			inToken = true
+			start = i
+		}
+	}
+
+	for i := 0; i < len(line); {
+		// 按原始字节复制，不是有效 UTF-8 的文件名也能原样保留
+		r, size := utf8.DecodeRuneInString(line[i:])
+
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteString(line[i : i+size])
+			}
+
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				next := byte(0)
+				if i+1 < len(line) {
+					next = line[i+1]
+				}
+				if next == '\\' || (next == '"' && i+2 < len(line)) {
+					current.WriteByte(next)
+					i += 2
+					continue
+				}
+				// 单独的反斜杠，以及行尾的 \"，按字面处理
+				current.WriteString(line[i : i+size])
+			default:
+				current.WriteString(line[i : i+size])
+			}
+
+		case r == ' ' || r == '\t':
+			flush(i)
+
+		case r == '"' || r == '\'':
+			begin(i)
+			quote = r
+
+		default:
+			begin(i)
+			current.WriteString(line[i : i+size])
+		}
+
+		i += size
+	}
+
+	flush(len(line))
+	return tokens, quote
+}
+
+// SplitCommands 按引号之外的分号拆分 line（引号规则同 Lex），返回去掉首尾空白的非空命令。
+// 命令保持原始形式（保留引号），每条都可以再作为单独的命令行解析
+func SplitCommands(line string) []string {
+	var commands []string
+	add := func(cmd string) {
+		if cmd = strings.TrimSpace(cmd); cmd != "" {
+			commands = append(commands, cmd)
+		}
+	}
+
+	start := 0
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\' && i+1 < len(line) && (line[i+1] == '\\' || (line[i+1] == '"' && i+2 < len(line))):
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ';':
+			add(line[start:i])
+			start = i + 1
+		}
+	}
+	add(line[start:])
+	return commands
+}
+
+// AtWordBoundary 光标在行尾时是否开始一个新单词：行为空，或以引号之外的空白结尾
+func AtWordBoundary(line string, tokens []Token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	return tokens[len(tokens)-1].End < len(line)
+}
+
+// Escape 编码 s，使其追加到当前处于 openQuote 引号中（0 表示没有引号）的单词之后，
+// 单词的值恰好增加 s。用于插入补全的后缀而不破坏已有的引号
+func Escape(s string, openQuote rune) string {
+	if s == "" {
+		return ""
+	}
+	switch openQuote {
+	case '\'':
+		// 单引号中不能出现单引号：先关闭，在双引号中加入单引号，再重新打开
+		return strings.ReplaceAll(s, "'", `'"'"'`)
+	case '"':
+		// 行尾的 \" 读作字面的反斜杠（见包文档），所以结尾的双引号改为放在单引号中：
+		// 先关闭，加入 '"'，再重新打开
+		if strings.HasSuffix(s, `"`) {
+			return escapeDoubleQuoted(s[:len(s)-1]) + `"'"'"`
+		}
+		return escapeDoubleQuoted(s)
+	default:
+		if !needsQuoting(s) {
+			return s
+		}
+		return `"` + escapeDoubleQuoted(s) + `"`
+	}
+}
+
+// Quote 把 s 编码为一个单词，Split 读回的值不变
+func Quote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return Escape(s, 0)
+}
+
+// needsQuoting 报告 s 是否必须放在引号中：含空白、引号，或者含会被 SplitCommands 拆开的 ;
+func needsQuoting(s string) bool {
+	return strings.ContainsAny(s, " \t\"';")
+}
+
+func escapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,229 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "empty", line: "", want: nil},
+		{name: "only spaces", line: "  \t ", want: nil},
+		{name: "simple words", line: "get a.txt  b.txt", want: []string{"get", "a.txt", "b.txt"}},
+		{name: "tabs separate", line: "ls\t/tmp", want: []string{"ls", "/tmp"}},
+		{name: "double quoted spaces", line: `put "my folder/file.txt"`, want: []string{"put", "my folder/file.txt"}},
+		{name: "single quoted spaces", line: `put 'my folder/file.txt'`, want: []string{"put", "my folder/file.txt"}},
+		{name: "adjacent segments join", line: `put a"b c"d`, want: []string{"put", "ab cd"}},
+		{name: "single inside double", line: `put "it's here"`, want: []string{"put", "it's here"}},
+		{name: "double inside single", line: `put 'say "hi"'`, want: []string{"put", `say "hi"`}},
+		{name: "empty double quotes", line: `rename "" x`, want: []string{"rename", "", "x"}},
+		{name: "empty single quotes", line: `rename '' x`, want: []string{"rename", "", "x"}},
+		{name: "windows path unquoted", line: `put C:\Users\file.txt`, want: []string{"put", `C:\Users\file.txt`}},
+		{name: "trailing backslash unquoted", line: `put C:\path\`, want: []string{"put", `C:\path\`}},
+		{name: "escaped quote in double", line: `put "hello \"world\""`, want: []string{"put", `hello "world"`}},
+		{name: "escaped backslash in double", line: `put "path\\file"`, want: []string{"put", `path\file`}},
+		{name: "other backslash in double is literal", line: `put "a\b\ c"`, want: []string{"put", `a\b\ c`}},
+		{name: "backslash in single is literal", line: `put 'a\"b'`, want: []string{"put", `a\"b`}},
+		{name: "trailing escaped quote closes", line: `put "C:\Program Files\"`, want: []string{"put", `C:\Program Files\`}},
+		{name: "trailing double backslash", line: `put "C:\dir\\"`, want: []string{"put", `C:\dir\`}},
+		{name: "unterminated double", line: `put "my fol`, want: []string{"put", "my fol"}},
+		{name: "unterminated single", line: `put 'my fol`, want: []string{"put", "my fol"}},
+		{name: "lone trailing backslash in double", line: `put "abc\`, want: []string{"put", `abc\`}},
+		{name: "unicode", line: `get "报告 2024/数据.csv" ファイル`, want: []string{"get", "报告 2024/数据.csv", "ファイル"}},
+		{name: "emoji", line: `put 🚀.txt`, want: []string{"put", "🚀.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Split(%q) = %#v, want %#v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitCommands(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "", want: nil},
+		{line: " ; ;", want: nil},
+		{line: "cd /var/log; get -r app ./logs", want: []string{"cd /var/log", "get -r app ./logs"}},
+		{line: `put 'a;b.txt'; ls`, want: []string{`put 'a;b.txt'`, "ls"}},
+		{line: `put "a;\"b"; ls "c;d"`, want: []string{`put "a;\"b"`, `ls "c;d"`}},
+		{line: `put C:\dir\;pwd`, want: []string{`put C:\dir\`, "pwd"}},
+		{line: `put "C:\dir\\";pwd`, want: []string{`put "C:\dir\\"`, "pwd"}},
+		{line: `put "C:\dir\";pwd`, want: []string{`put "C:\dir\";pwd`}},
+		{line: `put "unterminated; ls`, want: []string{`put "unterminated; ls`}},
+	}
+	for _, tt := range tests {
+		if got := SplitCommands(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("SplitCommands(%q) = %#v, want %#v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLexReportsOpenQuote(t *testing.T) {
+	tests := []struct {
+		line string
+		want rune
+	}{
+		{line: `get "a b`, want: '"'},
+		{line: `get 'a b`, want: '\''},
+		{line: `get "a b"`, want: 0},
+		{line: `get "a \"`, want: 0},
+		{line: `get "a \" b`, want: '"'},
+	}
+	for _, tt := range tests {
+		if _, got := Lex(tt.line); got != tt.want {
+			t.Fatalf("Lex(%q) open quote = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLexTokenOffsets(t *testing.T) {
+	line := `get "my dir"/x  报告.txt`
+	tokens, _ := Lex(line)
+	if len(tokens) != 3 {
+		t.Fatalf("Lex(%q) = %#v", line, tokens)
+	}
+	if raw := tokens[1].Raw(line); raw != `"my dir"/x` {
+		t.Fatalf("raw token = %q", raw)
+	}
+	if n := tokens[2].RawLen(line); n != 6 {
+		t.Fatalf("RawLen = %d, want 6 runes", n)
+	}
+}
+
+func TestAtWordBoundary(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "", want: true},
+		{line: "get", want: false},
+		{line: "get ", want: true},
+		{line: `get "a `, want: false},
+		{line: `get ""`, want: false},
+		{line: `get "" `, want: true},
+	}
+	for _, tt := range tests {
+		tokens, _ := Lex(tt.line)
+		if got := AtWordBoundary(tt.line, tokens); got != tt.want {
+			t.Fatalf("AtWordBoundary(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	names := []string{
+		"plain.txt",
+		"my folder/file.txt",
+		"tab\tname",
+		`it's.txt`,
+		`say "hi".txt`,
+		`C:\Users\me\`,
+		`back\slash "and" 'quotes'`,
+		"报告 2024.csv",
+		"a;b.txt",
+		`semi;"quoted".txt`,
+		"trailing;",
+		"",
+	}
+	for _, name := range names {
+		line := "get " + Quote(name) + " -d out"
+		got := Split(line)
+		want := []string{"get", name, "-d", "out"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Split(%q) = %#v, want %#v", line, got, want)
+		}
+		// 作为 -e 的一条命令时，单词中的 ; 不能拆开命令
+		if got := SplitCommands(line + "; ls"); !reflect.DeepEqual(got, []string{line, "ls"}) {
+			t.Fatalf("SplitCommands(%q) = %#v", line+"; ls", got)
+		}
+	}
+}
+
+func TestEscapeContinuesOpenWord(t *testing.T) {
+	tests := []struct {
+		typed  string
+		suffix string
+		want   string
+	}{
+		{typed: `get my`, suffix: " folder/", want: "my folder/"},
+		{typed: `get "my`, suffix: " folder/", want: "my folder/"},
+		{typed: `get 'my`, suffix: " folder/", want: "my folder/"},
+		{typed: `get 'it`, suffix: "'s.txt", want: "it's.txt"},
+		{typed: `get "a`, suffix: `\b`, want: `a\b`},
+		{typed: `get C:\Us`, suffix: `ers\`, want: `C:\Users\`},
+	}
+	for _, tt := range tests {
+		tokens, quote := Lex(tt.typed)
+		line := tt.typed + Escape(tt.suffix, quote)
+		got := Split(line)
+		if len(got) != 2 || got[1] != tt.want {
+			t.Fatalf("typed %q + suffix %q -> line %q parsed %#v, want word %q (tokens %v)", tt.typed, tt.suffix, line, got, tt.want, tokens)
+		}
+	}
+}
+
+func TestEscapeRoundTrip(t *testing.T) {
+	words := []string{`a\`, `\`, `\\`, `"`, `\"`, `a"`, `a\"`, `x y"`, `"\`, `'`, `\'`, `a'\`, `C:\Program Files\`}
+	for _, word := range words {
+		for _, quote := range []rune{0, '"', '\''} {
+			open := ""
+			if quote != 0 {
+				open = string(quote)
+			}
+			// 引号在行尾仍未关闭（刚插入了补全的后缀），以及引号关闭后还跟着另一个单词
+			for _, line := range []string{open + Escape(word, quote), open + Escape(word, quote) + open + " next"} {
+				tokens, _ := Lex(line)
+				if len(tokens) == 0 || tokens[0].Value != word {
+					t.Errorf("Lex(%q) = %+v, want first word %q (open quote %q)", line, tokens, word, quote)
+				}
+			}
+		}
+	}
+}
+
+func FuzzLex(f *testing.F) {
+	for _, seed := range []string{"", `get "my fol`, `put 'a\"b'`, `put "C:\Program Files\"`, "a\"b c\"d", "报告\t🚀 '", "\xff\xfe\"\\"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		tokens, quote := Lex(line)
+		if quote != 0 && quote != '"' && quote != '\'' {
+			t.Fatalf("Lex(%q) open quote = %q", line, quote)
+		}
+		prevEnd := 0
+		for _, tok := range tokens {
+			if tok.Start < prevEnd || tok.End <= tok.Start || tok.End > len(line) {
+				t.Fatalf("Lex(%q) token %+v out of order or out of bounds", line, tok)
+			}
+			prevEnd = tok.End
+		}
+		if AtWordBoundary(line, tokens) && quote != 0 {
+			t.Fatalf("Lex(%q): word boundary reported inside an open quote", line)
+		}
+	})
+}
+
+func FuzzQuoteRoundTrip(f *testing.F) {
+	for _, seed := range []string{"", "plain", `it's "x"`, `C:\dir\`, "tab\tname", "\\\"", "报告 🚀"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, word string) {
+		line := "get " + Quote(word) + " -d out"
+		got := Split(line)
+		want := []string{"get", word, "-d", "out"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Split(%q) = %#v, want %#v", line, got, want)
+		}
+	})
+}
@@ -2,14 +2,21 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/x509"
 	"encoding/base64"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 
 	"golang.org/x/crypto/ssh"
@@ -18,7 +25,11 @@ import (
 
 	"github.com/frostime/my-sftp/client"
 	"github.com/frostime/my-sftp/config"
+	"github.com/frostime/my-sftp/ppk"
+	"github.com/frostime/my-sftp/record"
 	"github.com/frostime/my-sftp/shell"
+	"github.com/frostime/my-sftp/ttystate"
+	"github.com/frostime/my-sftp/units"
 )
 
 var (
@@ -27,10 +38,90 @@ var (
 	Date    = "unknown"
 )
 
+// progressMode / progressEvery 由 --progress、--progress-every 决定，connect 时应用到客户端
+var (
+	progressMode  = client.ProgressBars
+	progressEvery = client.DefaultProgressEvery
+)
+
+// clientOptions 由 --trace-sftp 等参数决定，connect 时传给客户端；
+// sftpSubsystem 来自 -s，优先于 profile 中的 SFTPSubsystem
+var (
+	clientOptions []client.ClientOption
+	sftpSubsystem string
+)
+
+// batchMode 由 -b 开启：连接时不询问密码和未知主机密钥
+var batchMode bool
+
+// jsonOutput 由 --json 开启：ls、stat、df 和传输摘要输出 JSON
+var jsonOutput bool
+
+// concurrency / bufferSize / noColor 来自 --concurrency、--buffer-size、--no-color，
+// 非零时覆盖 profile 中的 Concurrency、BufferSize、Color
+var (
+	concurrency int
+	bufferSize  int64
+	noColor     bool
+)
+
+// sshPort / sshUser / identityFile 来自 -p、-l、-i（同 OpenSSH），优先于 destination 和 SSH config
+var (
+	sshPort      int
+	sshUser      string
+	identityFile string
+)
+
+// sshOptions 来自 -o Key=Value（可重复），按顺序覆盖 destination 和 SSH config 中的值
+var sshOptions sshOptionList
+
+// sshOptionList 实现 flag.Value：每个 -o 在解析参数时即检查
+type sshOptionList []string
+
+func (l *sshOptionList) String() string { return strings.Join(*l, " ") }
+
+func (l *sshOptionList) Set(s string) error {
+	if _, _, err := config.ParseSSHOption(s); err != nil {
+		return err
+	}
+	*l = append(*l, s)
+	return nil
+}
+
 func main() {
 	showVersion := flag.Bool("version", false, "Show version and exit")
+	recordPath := flag.String("record", "", "Record the session to an asciinema v2 `file` (.cast)")
+	progressStyle := flag.String("progress", "auto", "Transfer progress `style`: auto, bar, log or none (auto logs lines when stdout is not a terminal)")
+	execOnly := flag.Bool("exec-only", false, "Skip the SFTP subsystem; only remote commands (! <command>) and local commands are available")
+	progressFD := flag.String("progress-fd", "", "Write transfer events as JSON lines to file descriptor `fd` (e.g. 3), or to a file or named pipe path; progress bars are then off unless --progress is given")
+	progressStep := flag.String("progress-every", client.DefaultProgressEvery.String(), "In log style, print a status line every `interval` (e.g. 5s) or percent step (e.g. 10%)")
+	flag.StringVar(&sftpSubsystem, "s", "", "SFTP `subsystem` name, or the path of the server's sftp-server program (overrides SFTPSubsystem in the profile)")
+	tracePath := flag.String("trace-sftp", "", "Log every SFTP request and response as JSON lines to `file` (for debugging servers)")
+	batchFile := flag.String("b", "", "Batch mode: run commands from `file` (- for stdin) and exit; stops at the first failed command, never prompts")
+	inline := flag.String("e", "", "Run the `commands` (separated by ;) and exit, e.g. -e \"cd /var/log; get -r app -d ./logs\"")
+	continueOnError := flag.Bool("B", false, "With -b or -e, continue after failed commands (exit status 1 if any failed)")
+	flag.BoolVar(&jsonOutput, "json", false, "Print ls, stat, df and transfer summaries as JSON (same as set output json in the shell)")
+	flag.IntVar(&concurrency, "concurrency", 0, "Transfer `N` files at a time (default 4, or Concurrency in the profile)")
+	bufferSizeFlag := flag.String("buffer-size", "", "Transfer buffer `size`, e.g. 1M (default 512K, or BufferSize in the profile)")
+	flag.BoolVar(&noColor, "no-color", os.Getenv("NO_COLOR") != "", "Do not color the prompt (also set by NO_COLOR or Color no in the profile)")
+	flag.IntVar(&sshPort, "p", 0, "Connect to `port` on the server (overrides :port and Port in the SSH config)")
+	flag.IntVar(&sshPort, "port", 0, "Same as -p")
+	flag.StringVar(&sshUser, "l", "", "Log in as `user` (overrides user@ and User in the SSH config)")
+	flag.StringVar(&sshUser, "user", "", "Same as -l")
+	flag.StringVar(&identityFile, "i", "", "Authenticate with the private key in `file` (overrides IdentityFile in the SSH config)")
+	flag.StringVar(&identityFile, "identity", "", "Same as -i")
+	flag.Var(&sshOptions, "o", "Override an SSH config `Key=Value` (repeatable): Port, User, IdentityFile, IdentitiesOnly, StrictHostKeyChecking, ConnectTimeout, ProxyJump, ServerAliveInterval, ServerAliveCountMax, HashKnownHosts, Ciphers, MACs, KexAlgorithms, HostKeyAlgorithms")
+	queryAlgorithms := flag.String("Q", "", "List the supported algorithms of `type` cipher, mac, kex or key and exit (same as ssh -Q)")
+	flag.IntVar(&connectRetries, "retry", 0, "Retry the initial connection up to `N` times with exponential backoff when the host is unreachable or times out")
+	flag.BoolVar(&noReconnect, "no-reconnect", false, "Exit when the connection drops instead of reconnecting and retrying the interrupted command")
+	flag.BoolVar(&updateHostKeys, "update-hostkeys", false, "When the host key has changed and the new key matches a pinned HostKeyFingerprint, replace the old known_hosts entry without asking")
+	flag.StringVar(&errorReportPath, "error-report", "", "With -b, -e or cp, write the failed commands and files as JSON to `file` when done")
 	flag.Parse()
 
+	// 被终止或 panic 时恢复终端（密码提示关闭了回显、readline 和远程 shell 使用 raw 模式）
+	ttystate.Save()
+	defer ttystate.Guard()
+
 	// 支持 my-sftp --version
 	if *showVersion {
 		fmt.Printf("my-sftp version: %s\n", Version)
@@ -40,136 +131,772 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *queryAlgorithms != "" {
+		algorithms, err := config.SupportedAlgorithms(*queryAlgorithms)
+		if err != nil {
+			fmt.Printf("Error: -Q: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		fmt.Println(strings.Join(algorithms, "\n"))
+		os.Exit(exitOK)
+	}
+
+	if *progressFD != "" {
+		events, err := openProgressFD(*progressFD)
+		if err != nil {
+			fmt.Printf("Error: --progress-fd: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		clientOptions = append(clientOptions, client.WithEventJSON(events))
+		if *progressStyle == "auto" {
+			*progressStyle = "none"
+		}
+	}
+	if err := setupProgress(*progressStyle, *progressStep); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if concurrency < 0 {
+		fmt.Printf("Error: invalid --concurrency %d\n", concurrency)
+		os.Exit(exitUsage)
+	}
+	if connectRetries < 0 {
+		fmt.Printf("Error: invalid --retry %d\n", connectRetries)
+		os.Exit(exitUsage)
+	}
+	if sshPort < 0 || sshPort > 65535 {
+		fmt.Printf("Error: invalid port %d\n", sshPort)
+		os.Exit(exitUsage)
+	}
+	if *bufferSizeFlag != "" {
+		size, err := units.ParseSize(*bufferSizeFlag)
+		if err != nil || size <= 0 || size > client.MaxBufferSize {
+			fmt.Printf("Error: invalid --buffer-size %q (at most 64M)\n", *bufferSizeFlag)
+			os.Exit(exitUsage)
+		}
+		bufferSize = size
+	}
+	if *tracePath != "" {
+		// 每条记录直接写入文件，进程退出时无需刷新
+		traceFile, err := os.Create(*tracePath)
+		if err != nil {
+			fmt.Printf("Error: --trace-sftp: %v\n", err)
+			os.Exit(1)
+		}
+		clientOptions = append(clientOptions, client.WithSFTPTrace(traceFile))
+	}
+
 	// 获取位置参数作为 destination
 	args := flag.Args()
 	if len(args) == 0 {
-		fmt.Println("Usage: my-sftp [--version] <destination>")
-		fmt.Println("")
-		fmt.Println("Examples:")
-		fmt.Println("  my-sftp myserver           # Use SSH config alias")
-		fmt.Println("  my-sftp user@host          # Connect to host")
-		fmt.Println("  my-sftp user@host:2222     # Connect to host with custom port")
-		os.Exit(1)
+		printUsage()
+		os.Exit(exitUsage)
+	}
+
+	// run 按 -b、-e 或交互式会话连接 destination，返回退出码
+	run := func(destination string) int {
+		switch {
+		case *batchFile != "" && *inline != "":
+			fmt.Println("Error: -b and -e cannot be used together")
+			return exitUsage
+		case *batchFile != "":
+			return runBatch(destination, *batchFile, *continueOnError, *execOnly)
+		case *inline != "":
+			return runInline(destination, *inline, *continueOnError, *execOnly)
+		}
+		return runSession(destination, *recordPath, *execOnly)
+	}
+
+	// 子命令
+	switch args[0] {
+	case "demo":
+		os.Exit(runDemo(args[1:], run))
+	case "replay":
+		os.Exit(runReplay(args[1:]))
+	case "backup":
+		os.Exit(runBackup(args[1:]))
+	case "restore":
+		os.Exit(runRestore(args[1:]))
+	case "hosts":
+		os.Exit(runHosts(args[1:]))
+	case "cp":
+		os.Exit(runCopy(args[1:]))
+	case "config":
+		os.Exit(runConfig(args[1:]))
+	}
+
+	// 选项也可以写在 destination 之后：my-sftp host -e "..."
+	if extra, _ := parseSubcommandArgs(flag.CommandLine, args[1:]); len(extra) > 0 {
+		fmt.Printf("Error: unexpected argument %q\n", extra[0])
+		printUsage()
+		os.Exit(exitUsage)
+	}
+
+	os.Exit(run(args[0]))
+}
+
+func printUsage() {
+	fmt.Println("Usage: my-sftp [--version] [--record <file.cast>] [--exec-only] [--progress auto|bar|log|none] [--progress-every 5s|10%] [--progress-fd FD] [-s <subsystem>] [--trace-sftp <file>] [-b <file> | -e <commands>] [-B] [--json] [--error-report <file>] [--concurrency N] [--buffer-size SIZE] [--no-color] [-Q cipher|mac|kex|key] [-p port] [-l user] [-i identity_file] [-o Key=Value]... [--update-hostkeys] [--retry N] [--no-reconnect] <destination>")
+	fmt.Println("       my-sftp replay [--speed N] [--idle D] <file.cast>")
+	fmt.Println("       my-sftp backup [--keep N] [--verify] <local-dir> <destination>:<remote-dir>")
+	fmt.Println("       my-sftp restore [--list] [--at DATE|NAME] [--to DIR] <destination>:<backup-dir> [path|pattern...]")
+	fmt.Println("       my-sftp hosts [--check]")
+	fmt.Println("       my-sftp cp [-r] [-p] [get/put options] <source>... <target>   (one or both sides are <destination>:<path>)")
+	fmt.Println("       my-sftp config export [--encrypt] <bundle.json> | config import [--force] <bundle.json>")
+	fmt.Println("       my-sftp demo [--dir DIR] [--keep] [-e <commands> | -b <file>]   (built-in sandbox server, no real server needed)")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  my-sftp myserver           # Use SSH config alias")
+	fmt.Println("  my-sftp user@host          # Connect to host")
+	fmt.Println("  my-sftp user@host:2222     # Connect to host with custom port")
+	fmt.Println("  my-sftp -p 2222 -l deploy -i ~/.ssh/deploy_ed25519 host   # Port, user and key given as options (like ssh)")
+	fmt.Println("  my-sftp -o ProxyJump=bastion -o ConnectTimeout=10 myserver   # Override SSH config values for one run")
+	fmt.Println("  my-sftp -o ConnectTimeout=10 --retry 3 -b nightly.txt myserver   # Fail fast on unreachable hosts, retrying a few times")
+	fmt.Println("  my-sftp -o KexAlgorithms=+diffie-hellman-group1-sha1 -o Ciphers=+aes128-cbc oldbox   # Legacy server")
+	fmt.Println("  my-sftp --record demo.cast myserver   # Record the session (asciinema v2)")
+	fmt.Println("  my-sftp --exec-only myserver          # Remote commands only, no SFTP subsystem")
+	fmt.Println("  my-sftp -s /usr/lib/openssh/sftp-server myserver   # Start a specific sftp-server instead of the sftp subsystem")
+	fmt.Println("  my-sftp --trace-sftp trace.jsonl myserver   # Log SFTP packets to debug a misbehaving server")
+	fmt.Println("  my-sftp -b nightly.txt myserver        # Run commands from a file, stop at the first error")
+	fmt.Println("  my-sftp -e \"cd /var/log; get -r app -d ./logs\" myserver   # Run commands given inline, then exit")
+	fmt.Println("  my-sftp --json -e \"ls /srv/app; df /srv\" myserver   # Machine-readable output for scripts")
+	fmt.Println("  my-sftp replay demo.cast   # Replay a recorded session")
+	fmt.Println("  my-sftp demo               # Try every command against a throwaway local sandbox")
+	fmt.Println("  my-sftp --progress log --progress-every 10% myserver < cmds.txt > sftp.log   # Batch run with plain progress lines")
+	fmt.Println("  my-sftp --progress-fd 3 -e \"put -r ./site -d /srv/www\" myserver 3>events.jsonl   # JSON progress events on fd 3, stdout stays clean")
+	fmt.Println("  my-sftp backup ./docs myserver:/backups/docs --keep 7   # Rotating hardlink snapshots")
+	fmt.Println("  my-sftp restore myserver:/backups/docs --at 2024-04-30 --to ./restore")
+	fmt.Println("  my-sftp hosts --check      # List SSH config aliases and test reachability")
+	fmt.Println("  my-sftp config export --encrypt team.json   # Share host profiles; teammates run config import team.json")
+	fmt.Println("  my-sftp cp ./app.tar.gz myserver:/srv/releases/   # One transfer, then exit")
+	fmt.Println("  my-sftp cp -r myserver:/var/log/app ./logs")
+	fmt.Println("  my-sftp cp -r -p web1:/srv/www/site web2:/srv/www/   # Server to server, streamed through this machine")
+	fmt.Println("")
+	fmt.Println("Exit status: 0 success, 1 command failed, 2 usage error, 3 some files failed to transfer,")
+	fmt.Println("             4 authentication failed, 5 host key mismatch or untrusted, 6 connection lost, 255 cannot connect")
+}
+
+// setupProgress 解析进度显示参数；auto 在标准输出不是终端（管道、重定向）时改为逐行打印状态
+func setupProgress(style, every string) error {
+	switch style {
+	case "auto":
+		if !terminal.IsTerminal(int(record.Terminal().Fd())) {
+			progressMode = client.ProgressLog
+		}
+	case "bar":
+		progressMode = client.ProgressBars
+	case "log":
+		progressMode = client.ProgressLog
+	case "none":
+		progressMode = client.ProgressNone
+	default:
+		return fmt.Errorf("invalid --progress %q (want auto, bar, log or none)", style)
+	}
+	step, err := client.ParseProgressEvery(every)
+	if err != nil {
+		return fmt.Errorf("invalid --progress-every: %w", err)
+	}
+	progressEvery = step
+	return nil
+}
+
+// openProgressFD 打开 --progress-fd 的目标：数字为调用方已打开的文件描述符（如 3>events.jsonl），
+// 否则为文件或命名管道的路径（Windows 上如 \\.\pipe\name）
+func openProgressFD(spec string) (*os.File, error) {
+	fd, err := strconv.Atoi(spec)
+	if err != nil {
+		return os.OpenFile(spec, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	}
+	if fd <= 1 {
+		return nil, fmt.Errorf("descriptor %d is stdin or stdout; use 2 or a descriptor above 2", fd)
+	}
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd %d", fd))
+	if f == nil {
+		return nil, fmt.Errorf("invalid descriptor %d", fd)
+	}
+	if _, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("descriptor %d is not open", fd)
+	}
+	return f, nil
+}
+
+// runSession 连接 destination 并运行交互式 Shell，返回进程退出码。
+// SFTP 子系统在后台启动，不等它就绪即显示提示符；execOnly 时不启动 SFTP
+func runSession(destination, recordPath string, execOnly bool) int {
+	// ==================== 会话录制 ====================
+	if recordPath != "" {
+		stop, err := startRecording(recordPath, destination)
+		if err != nil {
+			fmt.Printf("Recording error: %v\n", err)
+			return 1
+		}
+		defer stop()
+	}
+
+	mode := client.StartLazy
+	if execOnly {
+		mode = client.StartExecOnly
+	}
+	c, err := connect(destination, mode)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return connectExitCode(err)
+	}
+	defer c.Close()
+
+	fmt.Println("✓ Connected successfully!")
+	if execOnly {
+		fmt.Println("ℹ Exec-only mode: SFTP is not started; run remote commands with ! <command>")
+	}
+	fmt.Println("Type 'help' for available commands, 'exit' to quit.")
+	fmt.Println()
+
+	// ==================== 启动交互式 Shell ====================
+	sh := shell.NewShell(c)
+	applyProfile(sh, destination)
+	sh.SetJSONOutput(jsonOutput)
+	if err := sh.Run(); err != nil {
+		fmt.Printf("Shell error: %v\n", err)
+		return exitFailed
+	}
+	if c.ConnectionLost() {
+		return exitConnectionLost
+	}
+	return exitOK
+}
+
+// applyProfile 把 destination 的主机 profile 中与 Shell 相关的设置应用到 sh
+func applyProfile(sh *shell.Shell, destination string) {
+	profile, err := config.LoadProfile(profileAlias(destination))
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	} else if profile.DownloadDir != "" || profile.UploadDir != "" {
+		sh.SetLandingDirs(profile.DownloadDir, profile.UploadDir)
+		fmt.Printf("ℹ Landing dirs: get -> %s, put -> %s\n", orCwd(profile.DownloadDir), orCwd(profile.UploadDir))
+	}
+	if err == nil && (profile.PreUploadScan != "" || profile.PostDownloadScan != "") {
+		sh.SetScanner(&client.ContentScanner{
+			PreUpload:    profile.PreUploadScan,
+			PostDownload: profile.PostDownloadScan,
+			FlagOnly:     profile.ScanFlagOnly,
+		})
+		fmt.Printf("ℹ Content scanning enabled (uploads: %s, downloads: %s)\n", orNone(profile.PreUploadScan), orNone(profile.PostDownloadScan))
+	}
+	if err == nil {
+		style := shell.PromptStyle{
+			AbbrevHome: profile.PromptHome,
+			Depth:      profile.PromptDepth,
+			ShowLocal:  profile.PromptLocal,
+		}
+		if color, colorErr := shell.ParsePromptColor(profile.PromptColor); colorErr != nil {
+			fmt.Printf("Warning: PromptColor: %v\n", colorErr)
+		} else {
+			style.Color = color
+		}
+		sh.SetPromptStyle(style)
+		sh.SetBanner(profile.Banner)
+	}
+	if err == nil && profile.ProjectLocal != "" {
+		if linkErr := sh.SetProject(profile.ProjectLocal, profile.ProjectRemote); linkErr != nil {
+			fmt.Printf("Warning: ProjectLink: %v\n", linkErr)
+		} else {
+			fmt.Printf("ℹ Project: %s <-> %s\n", profile.ProjectLocal, profile.ProjectRemote)
+		}
+	}
+	if err == nil && profile.AutoLsOnCd > 0 {
+		sh.SetAutoLs(profile.AutoLsOnCd)
+	}
+	if err == nil && profile.Overwrite != "" {
+		if policy, policyErr := client.ParseOverwritePolicy(profile.Overwrite); policyErr != nil {
+			fmt.Printf("Warning: Overwrite: %v\n", policyErr)
+		} else {
+			sh.SetOverwrite(policy)
+		}
+	}
+	if err == nil && len(profile.Confirm) > 0 {
+		if confirmErr := sh.SetConfirmCommands(profile.Confirm); confirmErr != nil {
+			fmt.Printf("Warning: Confirm: %v\n", confirmErr)
+		}
+		if confirm := sh.ConfirmCommands(); len(confirm) > 0 {
+			fmt.Printf("ℹ Confirmation required for: %s\n", strings.Join(confirm, ", "))
+		}
+	}
+	sh.SetColor(!noColor && (err != nil || !profile.NoColor))
+	if err == nil && profile.ConvertBackslashes {
+		sh.SetConvertBackslashes(true)
 	}
+	if err == nil && profile.HistoryFile != "" {
+		history := profile.HistoryFile
+		if history == "none" {
+			history = ""
+		}
+		if historyErr := sh.SetHistoryFile(history); historyErr != nil {
+			fmt.Printf("Warning: HistoryFile: %v\n", historyErr)
+		}
+	}
+}
 
-	destination := args[0]
+// profileAlias 返回查找 profile 使用的主机名：SSH config 别名本身，或 user@host[:port] 中的 host
+func profileAlias(destination string) string {
+	if sshConfig, err := config.ParseDestination(destination); err == nil {
+		return sshConfig.Host
+	}
+	return destination
+}
+
+func orNone(command string) string {
+	if command == "" {
+		return "off"
+	}
+	return command
+}
+
+func orCwd(dir string) string {
+	if dir == "" {
+		return "(current dir)"
+	}
+	return dir
+}
+
+// connect 解析 destination（user@host[:port] 或 SSH config 别名）并建立 SFTP 连接，mode 见 client.StartMode；
+// my-sftp demo 中连接内置的演示服务器
+func connect(destination string, mode client.StartMode) (*client.Client, error) {
+	if demoServer != nil && destination == demoDestination {
+		return connectDemo(mode)
+	}
 
 	// ==================== 解析 SSH 配置 ====================
 
-	// 尝试解析 destination
 	var sshConfig *config.SSHConfig
 	var err error
 
 	// 1. 解析目标地址
 	if strings.Contains(destination, "@") {
-		sshConfig, err = config.ParseDestination(destination)
+		// 同 ssh，SSH config 中匹配该主机的段（Host 通配符、Match）同样生效
+		if _, err := config.ParseDestination(destination); err != nil {
+			return nil, fmt.Errorf("invalid destination: %w", err)
+		}
+		sshConfig, err = config.LoadDestination(destination)
 		if err != nil {
-			fmt.Printf("Invalid destination: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("config error: %w", err)
 		}
 	} else {
-		// 作为 SSH config 别名处理
-		sshConfig, err = config.LoadSSHConfig(destination)
+		// 作为 SSH config 别名处理；没有 SSH config 文件时作为主机名（用户名可由 -l 给出）
+		sshConfig, err = config.LoadSSHConfig(destination, sshUser)
+		if errors.Is(err, config.ErrSSHConfigNotFound) {
+			sshConfig, err = &config.SSHConfig{Host: destination, Port: 22}, nil
+		}
 		if err != nil {
-			fmt.Printf("Config error: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("config error: %w", err)
 		}
 	}
+	for _, option := range sshOptions {
+		key, value, _ := config.ParseSSHOption(option) // 已在解析参数时检查
+		sshConfig.ApplyOption(key, value)
+	}
+	sshConfig.Merge("", sshPort, sshUser, expandIdentityPath(identityFile))
 
 	// 验证配置
 	if err := sshConfig.Validate(); err != nil {
-		fmt.Printf("Invalid config: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
-	// 2. 准备认证方法 (Key + Password)
+	// 2. 准备认证方法 (Key + Agent + Password)
 	var authMethods []ssh.AuthMethod
-	var keyFiles []string
-	if sshConfig.IdentityFile != "" {
-		keyFiles = append(keyFiles, sshConfig.IdentityFile)
-	} else {
+	keyFiles := sshConfig.IdentityFiles
+	explicitKeys := len(keyFiles) > 0
+	if !explicitKeys {
 		keyFiles = config.FindDefaultKeys()
 	}
 
-	// 尝试加载所有可用的密钥
+	// SSH agent（Unix 的 SSH_AUTH_SOCK，Windows 的 OpenSSH agent 和 Pageant）
+	agentSigners, _, err := client.AgentSigners()
+	if err != nil && !errors.Is(err, client.ErrNoAgent) {
+		fmt.Printf("Warning: SSH agent not usable: %v\n", err)
+	}
+
+	// 尝试加载所有可用的密钥（OpenSSH 格式或 PuTTY 的 .ppk）；-i 指定的密钥无法使用时给出提示（同 ssh）
+	// 设有密码的密钥已在 agent 中时由 agent 签名；否则只在明确指定（-i 或 IdentityFile）时询问密码
+	type identity struct {
+		signer ssh.Signer    // nil 表示使用 agent 中公钥相同的密钥
+		key    ssh.PublicKey // 公钥
+	}
+	var identities []identity
+	var keyChanged atomic.Bool // 接受了变化的主机密钥（StrictHostKeyChecking=no），见 createHostKeyCallback
 	for _, keyFile := range keyFiles {
-		if authMethod, err := loadPrivateKey(keyFile); err == nil {
-			authMethods = append(authMethods, authMethod)
+		signer, err := loadPrivateKey(keyFile, nil)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) && agentHasKey(agentSigners, missing.PublicKey) {
+			identities = append(identities, identity{key: missing.PublicKey})
+			continue
 		}
+		// 能得到公钥时等服务器接受该密钥后再询问密码（同 ssh），否则现在询问
+		if errors.As(err, &missing) && explicitKeys && !batchMode {
+			if pub := encryptedKeyPublic(keyFile, missing); pub != nil {
+				identities = append(identities, identity{signer: &lazySigner{path: keyFile, pub: pub}, key: pub})
+				continue
+			}
+			fmt.Printf("Enter passphrase for key '%s': ", keyFile)
+			passphrase, readErr := ttystate.ReadPassword(int(syscall.Stdin))
+			fmt.Println()
+			if readErr != nil {
+				return nil, readErr
+			}
+			signer, err = loadPrivateKey(keyFile, passphrase)
+		}
+		if err == nil {
+			identities = append(identities, identity{signer: signer, key: signer.PublicKey()})
+		} else if identityFile != "" {
+			fmt.Printf("Warning: identity file %s not accessible: %v\n", keyFile, err)
+		}
+	}
+
+	// 明确指定的密钥按配置的顺序优先，其次是 agent 中的其他密钥（IdentitiesOnly yes 时不使用）；
+	// 未指定时同 ssh，先 agent 中的密钥，再是默认位置的密钥
+	if len(identities) > 0 || agentSigners != nil {
+		authMethods = append(authMethods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			var fromAgent []ssh.Signer
+			if agentSigners != nil && !keyChanged.Load() {
+				var err error
+				if fromAgent, err = agentSigners(); err != nil {
+					fmt.Printf("Warning: %v\n", err)
+				}
+			}
+			var keys, others []ssh.Signer
+			for _, id := range identities {
+				if id.signer != nil {
+					keys = append(keys, id.signer)
+				} else if i := slices.IndexFunc(fromAgent, func(s ssh.Signer) bool { return sameKey(s.PublicKey(), id.key) }); i >= 0 {
+					keys = append(keys, fromAgent[i])
+				}
+			}
+			for _, s := range fromAgent {
+				if !slices.ContainsFunc(identities, func(id identity) bool { return sameKey(s.PublicKey(), id.key) }) {
+					others = append(others, s)
+				}
+			}
+			if sshConfig.IdentitiesOnly {
+				return keys, nil
+			}
+			if explicitKeys {
+				return append(keys, others...), nil
+			}
+			return append(others, keys...), nil
+		}))
 	}
 
-	// Fallback: 使用密码验证
+	// Fallback: 使用密码验证（批处理模式下不询问）
+	// 输入的密码保存在内存中，自动重连时不再询问
+	var password string
 	passwordCallback := ssh.PasswordCallback(func() (string, error) {
+		if keyChanged.Load() {
+			return "", errors.New("password authentication is disabled because the host key has changed")
+		}
+		if password != "" {
+			return password, nil
+		}
 		fmt.Printf("%s@%s's password: ", sshConfig.User, sshConfig.Host)
-		pw, err := terminal.ReadPassword(int(syscall.Stdin))
+		pw, err := ttystate.ReadPassword(int(syscall.Stdin))
 		fmt.Println()
 		if err != nil {
 			return "", err
 		}
-		return string(pw), nil
+		password = string(pw)
+		return password, nil
 	})
-	authMethods = append(authMethods, passwordCallback)
+	if !batchMode {
+		authMethods = append(authMethods, passwordCallback)
+	}
 
 	// 3. 创建安全的 HostKeyCallback
 	// 查找 known_hosts 文件路径
 	homeDir, _ := os.UserHomeDir()
 	knownHostsPath := filepath.Join(homeDir, ".ssh", "known_hosts")
 
-	// 创建回调函数
-	hostKeyCallback, err := createHostKeyCallback(knownHostsPath)
+	// profile 中可以固定主机密钥指纹，读取失败时拒绝连接而不是跳过检查
+	profile, err := config.LoadProfile(profileAlias(destination))
 	if err != nil {
-		fmt.Printf("Failed to initialize host key verification: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("profile error: %w", err)
+	}
+
+	// 创建回调函数；校验失败的错误标记为 hostKeyError，以便返回对应的退出码
+	checkHostKey, err := createHostKeyCallback(knownHostsPath, profile, sshConfig.StrictHostKeyChecking, sshConfig.HashKnownHosts, &keyChanged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize host key verification: %w", err)
+	}
+	hostKeyCallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := checkHostKey(hostname, remote, key); err != nil {
+			return &hostKeyError{err}
+		}
+		return nil
 	}
 
 	// 4. 构建 ClientConfig
+	opts := append([]client.ClientOption{client.WithSFTPOptions(sftpOptions(profile))}, clientOptions...)
+	opts = append(opts, transferDefaults(profile)...)
+	units.SetStyle(profile.SizeUnits)
+	if sshConfig.ServerAliveInterval > 0 {
+		opts = append(opts, client.WithKeepAlive(sshConfig.ServerAliveInterval, sshConfig.ServerAliveCountMax))
+	}
+
 	sshClientConfig := &ssh.ClientConfig{
 		User:            sshConfig.User,
 		Auth:            authMethods,
 		HostKeyCallback: hostKeyCallback,
 		// HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout: sshConfig.ConnectTimeout,
+	}
+	if err := setAlgorithms(sshClientConfig, sshConfig); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
 	addr := fmt.Sprintf("%s:%d", sshConfig.Host, sshConfig.Port)
+	if !noReconnect {
+		opts = append(opts, client.WithReconnect(redialer(destination, sshConfig, addr, sshClientConfig)))
+	}
 
 	fmt.Printf("[my-sftp %s]Connecting to %s@%s...\n", Version, sshConfig.User, addr)
 
-	// ==================== 创建 SSH 连接 ====================
+	c, err := retryConnect(func() (*client.Client, error) {
+		return dialClient(destination, sshConfig, addr, sshClientConfig, mode, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.SetProgressMode(progressMode, progressEvery)
+	return c, nil
+}
+
+// setAlgorithms 把 Ciphers、MACs、KexAlgorithms、HostKeyAlgorithms 设置到 sshClientConfig，未设置的保持库的默认值
+func setAlgorithms(sshClientConfig *ssh.ClientConfig, sshConfig *config.SSHConfig) error {
+	for _, setting := range []struct {
+		option string
+		spec   string
+		target *[]string
+	}{
+		{"Ciphers", sshConfig.Ciphers, &sshClientConfig.Ciphers},
+		{"MACs", sshConfig.MACs, &sshClientConfig.MACs},
+		{"KexAlgorithms", sshConfig.KexAlgorithms, &sshClientConfig.KeyExchanges},
+		{"HostKeyAlgorithms", sshConfig.HostKeyAlgorithms, &sshClientConfig.HostKeyAlgorithms},
+	} {
+		algorithms, err := config.ResolveAlgorithms(setting.option, setting.spec)
+		if err != nil {
+			return err
+		}
+		if algorithms != nil {
+			*setting.target = algorithms
+		}
+	}
+	return nil
+}
+
+// dialClient 建立 SSH 连接并创建客户端：经由 ControlMaster（正在运行时）、ProxyJump 或直连
+func dialClient(destination string, sshConfig *config.SSHConfig, addr string, sshClientConfig *ssh.ClientConfig, mode client.StartMode, opts []client.ClientOption) (*client.Client, error) {
+	// ssh_config 配置了 ControlPath 且 master 正在运行时，经由它转发，省去新的 TCP 连接
+	if socket := config.ControlSocket(profileAlias(destination), sshConfig); socket != "" {
+		conn, err := client.DialControlMaster(socket, sshConfig.User, sshConfig.Host, sshConfig.Port)
+		if err == nil {
+			fmt.Printf("ℹ Using ControlMaster %s\n", socket)
+			c, err := client.NewClientConn(conn, addr, sshClientConfig, mode, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("connection failed: %w", err)
+			}
+			return c, nil
+		}
+		fmt.Printf("ℹ %v; connecting directly\n", err)
+	}
 
-	c, err := client.NewClient(addr, sshClientConfig)
+	// ProxyJump 经由跳板主机转发（由 ssh 程序连接跳板）
+	if sshConfig.ProxyJump != "" {
+		fmt.Printf("ℹ Connecting via ProxyJump %s\n", sshConfig.ProxyJump)
+		conn, err := client.DialProxyJump(sshConfig.ProxyJump, sshConfig.Host, sshConfig.Port)
+		if err != nil {
+			return nil, fmt.Errorf("proxy jump: %w", err)
+		}
+		c, err := client.NewClientConn(conn, addr, sshClientConfig, mode, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("connection failed: %w", err)
+		}
+		return c, nil
+	}
+
+	c, err := client.NewClient(addr, sshClientConfig, mode, opts...)
 	if err != nil {
 		// 这里的错误可能包含 Host Key 验证失败的信息
-		fmt.Printf("Connection failed: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("connection failed: %w", err)
 	}
-	defer c.Close()
+	return c, nil
+}
 
-	fmt.Println("✓ Connected successfully!")
-	fmt.Println("Type 'help' for available commands, 'exit' to quit.")
-	fmt.Println()
+// transferDefaults 由 profile 和 --concurrency、--buffer-size 得到并发数和缓冲区大小，参数优先
+func transferDefaults(profile *config.Profile) []client.ClientOption {
+	n := profile.Concurrency
+	if concurrency > 0 {
+		n = concurrency
+	}
+	size := bufferSize
+	if size == 0 && profile.BufferSize != "" {
+		parsed, err := units.ParseSize(profile.BufferSize)
+		if err != nil || parsed <= 0 || parsed > client.MaxBufferSize {
+			fmt.Printf("Warning: invalid BufferSize %q in %s (at most 64M), using the default\n", profile.BufferSize, profile.Path)
+		} else {
+			size = parsed
+		}
+	}
+	return []client.ClientOption{client.WithConcurrency(n), client.WithBufferSize(int(size))}
+}
 
-	// ==================== 启动交互式 Shell ====================
-	sh := shell.NewShell(c)
-	if err := sh.Run(); err != nil {
-		fmt.Printf("Shell error: %v\n", err)
-		os.Exit(1)
+// sftpOptions 由 profile 和 -s 得到 SFTP 兼容性设置
+func sftpOptions(profile *config.Profile) client.SFTPOptions {
+	opts := client.SFTPOptions{
+		Subsystem:    profile.SFTPSubsystem,
+		MaxPacket:    profile.SFTPMaxPacket,
+		MaxRequests:  profile.SFTPMaxRequests,
+		SerialReads:  profile.SFTPSerialReads,
+		SerialWrites: profile.SFTPSerialWrites,
+		UseFstat:     profile.SFTPUseFstat,
+		Channels:     profile.SFTPChannels,
+	}
+	if sftpSubsystem != "" {
+		opts.Subsystem = sftpSubsystem
 	}
+	return opts
 }
 
-func loadPrivateKey(keyPath string) (ssh.AuthMethod, error) {
+// expandIdentityPath 展开 -i 参数开头的 ~（如 -i=~/.ssh/key 时 shell 不会展开）
+func expandIdentityPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, _ := os.UserHomeDir()
+		p = filepath.Join(home, p[1:])
+	}
+	return p
+}
+
+// loadPrivateKey 读取 OpenSSH（及 PEM）格式或 PuTTY .ppk 格式的私钥；
+// passphrase 为 nil 时不解密，密钥设有密码则返回 *ssh.PassphraseMissingError
+func loadPrivateKey(keyPath string, passphrase []byte) (ssh.Signer, error) {
 	key, err := os.ReadFile(keyPath)
 	if err != nil {
 		return nil, err
 	}
-	signer, err := ssh.ParsePrivateKey(key)
+	var raw any
+	switch {
+	case ppk.IsPPK(key) && passphrase == nil:
+		raw, err = ppk.ParseRawPrivateKey(key)
+	case ppk.IsPPK(key):
+		raw, err = ppk.ParseRawPrivateKeyWithPassphrase(key, passphrase)
+	case passphrase == nil:
+		raw, err = ssh.ParseRawPrivateKey(key)
+	default:
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase(key, passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(raw)
+}
+
+// encryptedKeyPublic 设有密码的密钥的公钥：OpenSSH 新格式和 .ppk 在文件中保存了公钥，
+// 旧的 PEM 格式读取旁边的 .pub 文件（同 ssh）；都没有时返回 nil
+func encryptedKeyPublic(keyPath string, missing *ssh.PassphraseMissingError) ssh.PublicKey {
+	if missing.PublicKey != nil {
+		return missing.PublicKey
+	}
+	data, err := os.ReadFile(keyPath + ".pub")
+	if err != nil {
+		return nil
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil
+	}
+	return pub
+}
+
+// maxPassphraseAttempts 询问密钥密码的次数（同 ssh 的 NumberOfPasswordPrompts 默认值）
+const maxPassphraseAttempts = 3
+
+// lazySigner 设有密码的密钥：服务器接受了其公钥、需要签名时才询问密码并解密，
+// 服务器不接受的密钥不会询问。解密后的密钥保存在内存中，自动重连时不再询问
+type lazySigner struct {
+	path   string
+	pub    ssh.PublicKey
+	mu     sync.Mutex
+	signer ssh.AlgorithmSigner
+}
+
+func (s *lazySigner) PublicKey() ssh.PublicKey {
+	return s.pub
+}
+
+func (s *lazySigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	signer, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(rand, data)
+}
+
+func (s *lazySigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	signer, err := s.load()
 	if err != nil {
 		return nil, err
 	}
-	return ssh.PublicKeys(signer), nil
+	return signer.SignWithAlgorithm(rand, data, algorithm)
+}
+
+// load 第一次签名时询问密码并解密；密码错误时重新询问，最多 maxPassphraseAttempts 次
+func (s *lazySigner) load() (ssh.AlgorithmSigner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.signer != nil {
+		return s.signer, nil
+	}
+	prompt := fmt.Sprintf("Enter passphrase for key '%s': ", s.path)
+	for range maxPassphraseAttempts {
+		fmt.Print(prompt)
+		passphrase, err := ttystate.ReadPassword(int(syscall.Stdin))
+		fmt.Println()
+		if err != nil {
+			return nil, err
+		}
+		signer, err := loadPrivateKey(s.path, passphrase)
+		if errors.Is(err, x509.IncorrectPasswordError) {
+			prompt = fmt.Sprintf("Bad passphrase, try again for '%s': ", s.path)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("identity file %s: %w", s.path, err)
+		}
+		algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+		if !ok {
+			return nil, fmt.Errorf("identity file %s: key type %s cannot sign with a chosen algorithm", s.path, signer.PublicKey().Type())
+		}
+		s.signer = algorithmSigner
+		return s.signer, nil
+	}
+	return nil, fmt.Errorf("identity file %s: incorrect passphrase", s.path)
+}
+
+// agentHasKey agent 中是否已有公钥为 pub 的密钥
+func agentHasKey(agentSigners func() ([]ssh.Signer, error), pub ssh.PublicKey) bool {
+	if agentSigners == nil || pub == nil {
+		return false
+	}
+	signers, _ := agentSigners()
+	return slices.ContainsFunc(signers, func(s ssh.Signer) bool { return sameKey(s.PublicKey(), pub) })
+}
+
+func sameKey(a, b ssh.PublicKey) bool {
+	return a != nil && b != nil && bytes.Equal(a.Marshal(), b.Marshal())
 }
 
 // createHostKeyCallback 创建一个支持交互式确认的主机密钥回调
-func createHostKeyCallback(path string) (ssh.HostKeyCallback, error) {
+// profile 固定了指纹时，密钥必须先匹配指纹，再按 known_hosts 检查
+// policy 为 StrictHostKeyChecking（ssh config 或 -o），见 config.HostKeyPolicy；
+// hashHosts 为 HashKnownHosts：新加入的主机名写成哈希。读取时哈希和明文条目都支持；
+// StrictHostKeyChecking=no 时接受了变化的密钥则设置 keyChanged，之后的认证不再使用密码和 agent
+func createHostKeyCallback(path string, profile *config.Profile, policy config.HostKeyPolicy, hashHosts bool, keyChanged *atomic.Bool) (ssh.HostKeyCallback, error) {
 	// 确保文件存在，不存在则创建
 	if err := ensureFileExists(path); err != nil {
 		return nil, err
@@ -183,7 +910,16 @@ func createHostKeyCallback(path string) (ssh.HostKeyCallback, error) {
 	}
 
 	// 返回一个包装函数，处理 "未知主机" 的情况
+	pinned := len(profile.HostKeyFingerprints) > 0
 	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		// 0. 检查固定指纹
+		fingerprint := ssh.FingerprintSHA256(key)
+		if !profile.MatchesFingerprint(fingerprint) {
+			return fmt.Errorf("HOST KEY NOT PINNED for %s! Remote key: %s, pinned: %s. "+
+				"If the host key was rotated, add the new fingerprint as HostKeyFingerprint in %s",
+				hostname, fingerprint, strings.Join(profile.HostKeyFingerprints, ", "), profile.Path)
+		}
+
 		// 1. 调用基础回调进行检查
 		err := callback(hostname, remote, key)
 
@@ -199,14 +935,45 @@ func createHostKeyCallback(path string) (ssh.HostKeyCallback, error) {
 		var keyErr *knownhosts.KeyError
 		if errors.As(err, &keyErr) {
 			// 情况 A: 这是一个已知的 Host，但 Key 不一样！(MITM 攻击风险)
+			// StrictHostKeyChecking=no 时只警告，不更新 known_hosts
 			if len(keyErr.Want) > 0 {
-				return fmt.Errorf("HOST KEY MISMATCH for %s! Possible MITM attack. Remote key: %s, Known key: %v",
-					hostname, ssh.FingerprintSHA256(key), keyErr.Want)
+				// 同 ssh：继续连接，但不再发送密码，也不使用 agent 中的密钥
+				if policy == config.HostKeyOff {
+					fmt.Printf("Warning: HOST KEY MISMATCH for %s (remote key %s, known key %s); "+
+						"connecting anyway because StrictHostKeyChecking=no, "+
+						"with password and agent authentication disabled to avoid man-in-the-middle attacks\n",
+						hostname, fingerprint, knownKeyFingerprints(keyErr.Want))
+					keyChanged.Store(true)
+					return nil
+				}
+				mismatch := fmt.Errorf("HOST KEY MISMATCH for %s! Possible MITM attack. Remote key: %s, Known key: %s",
+					hostname, fingerprint, knownKeyFingerprints(keyErr.Want))
+				if pinned {
+					// 指纹匹配但 known_hosts 里是旧密钥：多半是密钥轮换
+					mismatch = fmt.Errorf("HOST KEY MISMATCH for %s: remote key %s matches a pinned fingerprint, but known_hosts has %s. "+
+						"If the key was rotated, remove the stale entry with: ssh-keygen -R %s (or connect with --update-hostkeys)",
+						hostname, fingerprint, knownKeyFingerprints(keyErr.Want), knownhosts.Normalize(hostname))
+				}
+				// 确认新密钥后替换 known_hosts 中的旧记录
+				if updated, err := offerHostKeyUpdate(path, hostname, remote, key, keyErr.Want, hashHosts, pinned, mismatch); !updated {
+					return err
+				}
+				return nil
 			}
 
 			// 情况 B: 这是一个未知的主机 (keyErr.Want 为空)
-			// 我们需要询问用户是否信任它
-			return askUserToTrustHost(path, hostname, remote, key)
+			// 指纹已通过带外渠道确认时直接信任，否则询问用户
+			// StrictHostKeyChecking：yes 拒绝未知主机，accept-new/no 直接信任
+			switch {
+			case pinned:
+				fmt.Printf("✓ Host key %s matches the pinned fingerprint\n", fingerprint)
+				return appendToKnownHosts(path, hostname, key, hashHosts)
+			case policy == config.HostKeyStrict:
+				return fmt.Errorf("host key verification failed: unknown host %s with StrictHostKeyChecking=yes (key %s)", hostname, fingerprint)
+			case policy == config.HostKeyAcceptNew || policy == config.HostKeyOff:
+				return appendToKnownHosts(path, hostname, key, hashHosts)
+			}
+			return askUserToTrustHost(path, hostname, key, hashHosts)
 		}
 
 		// 其他系统错误
@@ -214,10 +981,22 @@ func createHostKeyCallback(path string) (ssh.HostKeyCallback, error) {
 	}, nil
 }
 
+// knownKeyFingerprints 把 known_hosts 中记录的密钥格式化为 "SHA256:... (文件:行号)"
+func knownKeyFingerprints(keys []knownhosts.KnownKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s (%s:%d)", ssh.FingerprintSHA256(k.Key), k.Filename, k.Line)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // askUserToTrustHost 询问用户是否信任主机，如果信任则写入文件
-func askUserToTrustHost(path string, hostname string, remote net.Addr, key ssh.PublicKey) error {
+func askUserToTrustHost(path string, hostname string, key ssh.PublicKey, hashHost bool) error {
 	fmt.Printf("\nThe authenticity of host '%s' can't be established.\n", hostname)
 	fmt.Printf("%s key fingerprint is %s.\n", key.Type(), ssh.FingerprintSHA256(key))
+	if batchMode {
+		return fmt.Errorf("host key verification failed: unknown host in batch mode (connect once interactively or pin HostKeyFingerprint)")
+	}
 	fmt.Print("Are you sure you want to continue connecting (yes/no)? ")
 
 	reader := bufio.NewReader(os.Stdin)
@@ -229,11 +1008,11 @@ func askUserToTrustHost(path string, hostname string, remote net.Addr, key ssh.P
 	}
 
 	// 用户同意，追加到 known_hosts 文件
-	return appendToKnownHosts(path, hostname, remote, key)
+	return appendToKnownHosts(path, hostname, key, hashHost)
 }
 
-// appendToKnownHosts 将新主机追加到 known_hosts 文件
-func appendToKnownHosts(path string, hostname string, remote net.Addr, key ssh.PublicKey) error {
+// appendToKnownHosts 将新主机追加到 known_hosts 文件；hashHost 时主机名写成 |1|salt|hash（同 ssh-keygen -H）
+func appendToKnownHosts(path string, hostname string, key ssh.PublicKey, hashHost bool) error {
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to open known_hosts: %w", err)
@@ -244,6 +1023,9 @@ func appendToKnownHosts(path string, hostname string, remote net.Addr, key ssh.P
 	// ssh 规范：如果端口不是22，hostname 格式通常是 [host]:port
 	// knownhosts.Normalize 帮助我们标准化这个格式
 	normalizedHost := knownhosts.Normalize(hostname)
+	if hashHost {
+		normalizedHost = knownhosts.HashHostname(normalizedHost)
+	}
 
 	// 序列化公钥
 	keyBytes := key.Marshal()
@@ -0,0 +1,405 @@
+// Package ppk 解析 PuTTY 的私钥文件（.ppk，格式版本 2 和 3），
+// 支持 RSA、DSA、ECDSA（nistp256/384/521）和 Ed25519 密钥，未加密或以 aes256-cbc 加密均可
+package ppk
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/ssh"
+)
+
+const headerPrefix = "PuTTY-User-Key-File-"
+
+// IsPPK data 是否为 PuTTY 私钥文件
+func IsPPK(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, "\ufeff \t\r\n"), []byte(headerPrefix))
+}
+
+// file 解析后的 .ppk 文件
+type file struct {
+	version    int
+	algorithm  string
+	encryption string
+	comment    string
+	public     []byte
+	private    []byte // 加密时为密文
+	mac        []byte
+	kdf        string // 版本 3 加密时的 Argon2 变体
+	memory     uint32
+	passes     uint32
+	threads    uint8
+	salt       []byte
+}
+
+// ParseRawPrivateKey 解析未加密的 .ppk 文件，返回值类型同 ssh.ParseRawPrivateKey。
+// 文件已加密时返回 *ssh.PassphraseMissingError
+func ParseRawPrivateKey(data []byte) (any, error) {
+	f, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.encryption != "none" {
+		pub, _ := ssh.ParsePublicKey(f.public)
+		return nil, &ssh.PassphraseMissingError{PublicKey: pub}
+	}
+	return f.decode(nil)
+}
+
+// ParseRawPrivateKeyWithPassphrase 用 passphrase 解密并解析 .ppk 文件；密码错误时返回 x509.IncorrectPasswordError
+func ParseRawPrivateKeyWithPassphrase(data, passphrase []byte) (any, error) {
+	f, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	return f.decode(passphrase)
+}
+
+func parseFile(data []byte) (*file, error) {
+	f := &file{}
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimLeft(data, "\ufeff \t\r\n")))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	// next 读取下一行 "Key: value"，key 必须与 want 相同
+	next := func(want string) (string, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", fmt.Errorf("ppk: missing %s", want)
+		}
+		key, value, ok := strings.Cut(strings.TrimRight(scanner.Text(), "\r"), ": ")
+		if !ok || key != want {
+			return "", fmt.Errorf("ppk: expected %s, got %q", want, scanner.Text())
+		}
+		return value, nil
+	}
+	// lines 读取 "Key: N" 和随后 N 行 base64
+	lines := func(want string) ([]byte, error) {
+		value, err := next(want)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > 1024 {
+			return nil, fmt.Errorf("ppk: invalid %s %q", want, value)
+		}
+		var encoded strings.Builder
+		for range n {
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("ppk: truncated %s", want)
+			}
+			encoded.WriteString(strings.TrimSpace(scanner.Text()))
+		}
+		return base64.StdEncoding.DecodeString(encoded.String())
+	}
+
+	if !scanner.Scan() {
+		return nil, errors.New("ppk: empty file")
+	}
+	key, algorithm, _ := strings.Cut(strings.TrimRight(scanner.Text(), "\r"), ": ")
+	switch key {
+	case headerPrefix + "2":
+		f.version = 2
+	case headerPrefix + "3":
+		f.version = 3
+	default:
+		if strings.HasPrefix(key, headerPrefix) {
+			return nil, fmt.Errorf("ppk: unsupported format version %q (re-save the key with a current PuTTYgen)", strings.TrimPrefix(key, headerPrefix))
+		}
+		return nil, errors.New("ppk: not a PuTTY private key file")
+	}
+	f.algorithm = algorithm
+
+	var err error
+	if f.encryption, err = next("Encryption"); err != nil {
+		return nil, err
+	}
+	if f.encryption != "none" && f.encryption != "aes256-cbc" {
+		return nil, fmt.Errorf("ppk: unsupported encryption %q", f.encryption)
+	}
+	if f.comment, err = next("Comment"); err != nil {
+		return nil, err
+	}
+	if f.public, err = lines("Public-Lines"); err != nil {
+		return nil, err
+	}
+	if f.version == 3 && f.encryption != "none" {
+		if err := f.parseKDF(next); err != nil {
+			return nil, err
+		}
+	}
+	if f.private, err = lines("Private-Lines"); err != nil {
+		return nil, err
+	}
+	mac, err := next("Private-MAC")
+	if err != nil {
+		return nil, err
+	}
+	if f.mac, err = hex.DecodeString(mac); err != nil {
+		return nil, fmt.Errorf("ppk: invalid Private-MAC: %w", err)
+	}
+	return f, nil
+}
+
+// parseKDF 读取版本 3 的 Argon2 参数
+func (f *file) parseKDF(next func(string) (string, error)) error {
+	var err error
+	if f.kdf, err = next("Key-Derivation"); err != nil {
+		return err
+	}
+	number := func(name string, max uint64) (uint64, error) {
+		value, err := next(name)
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || n == 0 || n > max {
+			return 0, fmt.Errorf("ppk: invalid %s %q", name, value)
+		}
+		return n, nil
+	}
+	memory, err := number("Argon2-Memory", 1<<22) // 最多 4GiB
+	if err != nil {
+		return err
+	}
+	passes, err := number("Argon2-Passes", 1<<16)
+	if err != nil {
+		return err
+	}
+	threads, err := number("Argon2-Parallelism", 255)
+	if err != nil {
+		return err
+	}
+	f.memory, f.passes, f.threads = uint32(memory), uint32(passes), uint8(threads)
+	salt, err := next("Argon2-Salt")
+	if err != nil {
+		return err
+	}
+	if f.salt, err = hex.DecodeString(salt); err != nil {
+		return fmt.Errorf("ppk: invalid Argon2-Salt: %w", err)
+	}
+	return nil
+}
+
+// keys 按文件版本由 passphrase 得到加密密钥、IV 和 MAC 的 HMAC 密钥与哈希
+func (f *file) keys(passphrase []byte) (key, iv, macKey []byte, newHash func() hash.Hash, err error) {
+	if f.version == 2 {
+		// 版本 2：密钥为 SHA1(0 || passphrase) || SHA1(1 || passphrase) 的前 32 字节，IV 全为 0；
+		// MAC 密钥为 SHA1("putty-private-key-file-mac-key" || passphrase)，未加密时 passphrase 为空
+		if f.encryption != "none" {
+			for i := range uint32(2) {
+				h := sha1.New()
+				binary.Write(h, binary.BigEndian, i)
+				h.Write(passphrase)
+				key = h.Sum(key)
+			}
+			key, iv = key[:32], make([]byte, aes.BlockSize)
+		} else {
+			passphrase = nil
+		}
+		h := sha1.New()
+		h.Write([]byte("putty-private-key-file-mac-key"))
+		h.Write(passphrase)
+		return key, iv, h.Sum(nil), sha1.New, nil
+	}
+
+	// 版本 3：Argon2 依次导出 32 字节密钥、16 字节 IV 和 32 字节 MAC 密钥；未加密时 MAC 密钥为空
+	if f.encryption == "none" {
+		return nil, nil, nil, sha256.New, nil
+	}
+	var derived []byte
+	switch f.kdf {
+	case "Argon2id":
+		derived = argon2.IDKey(passphrase, f.salt, f.passes, f.memory, f.threads, 80)
+	case "Argon2i":
+		derived = argon2.Key(passphrase, f.salt, f.passes, f.memory, f.threads, 80)
+	default:
+		// Argon2d 不在 golang.org/x/crypto 中
+		return nil, nil, nil, nil, fmt.Errorf("ppk: unsupported key derivation %q (re-save the key with Argon2id)", f.kdf)
+	}
+	return derived[:32], derived[32:48], derived[48:], sha256.New, nil
+}
+
+func (f *file) decode(passphrase []byte) (any, error) {
+	key, iv, macKey, newHash, err := f.keys(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	private := f.private
+	if f.encryption != "none" {
+		if len(private) == 0 || len(private)%aes.BlockSize != 0 {
+			return nil, errors.New("ppk: invalid encrypted private key length")
+		}
+		block, _ := aes.NewCipher(key)
+		private = make([]byte, len(f.private))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(private, f.private)
+	}
+
+	mac := hmac.New(newHash, macKey)
+	for _, field := range [][]byte{[]byte(f.algorithm), []byte(f.encryption), []byte(f.comment), f.public, private} {
+		writeString(mac, field)
+	}
+	if !hmac.Equal(mac.Sum(nil), f.mac) {
+		if f.encryption != "none" {
+			return nil, x509.IncorrectPasswordError
+		}
+		return nil, errors.New("ppk: MAC mismatch, the file is corrupted")
+	}
+
+	priv, err := parseKey(f.algorithm, f.public, private)
+	if err != nil {
+		return nil, err
+	}
+	// 公钥部分必须与私钥一致
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("ppk: %w", err)
+	}
+	if !bytes.Equal(signer.PublicKey().Marshal(), f.public) {
+		return nil, errors.New("ppk: public key does not match private key")
+	}
+	return priv, nil
+}
+
+// parseKey 按 PuTTY 的编码解析公钥和私钥部分
+func parseKey(algorithm string, public, private []byte) (any, error) {
+	pub, priv := &reader{data: public}, &reader{data: private}
+	if name := pub.string(); string(name) != algorithm {
+		return nil, fmt.Errorf("ppk: key type %q does not match header %q", name, algorithm)
+	}
+	switch algorithm {
+	case ssh.KeyAlgoRSA:
+		e, n := pub.mpint(), pub.mpint()
+		d, p, q, _ := priv.mpint(), priv.mpint(), priv.mpint(), priv.mpint() // 最后为 iqmp，由 Precompute 重新计算
+		if err := firstError(pub, priv); err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("ppk: invalid RSA exponent")
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("ppk: %w", err)
+		}
+		key.Precompute()
+		return key, nil
+
+	case ssh.KeyAlgoDSA:
+		p, q, g, y := pub.mpint(), pub.mpint(), pub.mpint(), pub.mpint()
+		x := priv.mpint()
+		if err := firstError(pub, priv); err != nil {
+			return nil, err
+		}
+		return &dsa.PrivateKey{PublicKey: dsa.PublicKey{Parameters: dsa.Parameters{P: p, Q: q, G: g}, Y: y}, X: x}, nil
+
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		curveName, point := pub.string(), pub.string()
+		k := priv.mpint()
+		if err := firstError(pub, priv); err != nil {
+			return nil, err
+		}
+		var curve elliptic.Curve
+		switch string(curveName) {
+		case "nistp256":
+			curve = elliptic.P256()
+		case "nistp384":
+			curve = elliptic.P384()
+		case "nistp521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("ppk: unsupported curve %q", curveName)
+		}
+		x, y := elliptic.Unmarshal(curve, point)
+		if x == nil {
+			return nil, errors.New("ppk: invalid ECDSA public key")
+		}
+		return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: k}, nil
+
+	case ssh.KeyAlgoED25519:
+		// 私钥为 32 字节的种子（PuTTY 按小端整数写出，即原样的字节）
+		pub.string()
+		seed := priv.string()
+		if err := firstError(pub, priv); err != nil {
+			return nil, err
+		}
+		if len(seed) > ed25519.SeedSize {
+			return nil, errors.New("ppk: invalid Ed25519 private key")
+		}
+		seed = append(seed, make([]byte, ed25519.SeedSize-len(seed))...)
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	return nil, fmt.Errorf("ppk: unsupported key type %q", algorithm)
+}
+
+// reader 读取 SSH 线上格式的 string 和 mpint，出错后后续读取都返回零值
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) string() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < 4 {
+		r.err = errors.New("ppk: truncated key data")
+		return nil
+	}
+	n := binary.BigEndian.Uint32(r.data)
+	if uint64(n) > uint64(len(r.data)-4) {
+		r.err = errors.New("ppk: truncated key data")
+		return nil
+	}
+	s := r.data[4 : 4+n]
+	r.data = r.data[4+n:]
+	return bytes.Clone(s)
+}
+
+func (r *reader) mpint() *big.Int {
+	b := r.string()
+	if r.err == nil && len(b) > 0 && b[0]&0x80 != 0 {
+		r.err = errors.New("ppk: negative integer in key data")
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+func firstError(readers ...*reader) error {
+	for _, r := range readers {
+		if r.err != nil {
+			return r.err
+		}
+	}
+	return nil
+}
+
+func writeString(w hash.Hash, b []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(b)))
+	w.Write(n[:])
+	w.Write(b)
+}
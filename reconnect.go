@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+)
+
+// noReconnect 由 --no-reconnect 开启：连接断开后不自动重连，直接退出（退出码 6）
+var noReconnect bool
+
+// redialer 返回按首次连接的方式（ControlMaster、ProxyJump 或直连）重新建立 SSH 连接的函数，
+// 认证方式和主机密钥校验与首次连接相同
+func redialer(destination string, sshConfig *config.SSHConfig, addr string, sshClientConfig *ssh.ClientConfig) client.Dialer {
+	return func() (*ssh.Client, error) {
+		var conn net.Conn
+		var err error
+		socket := config.ControlSocket(profileAlias(destination), sshConfig)
+		if socket != "" {
+			conn, err = client.DialControlMaster(socket, sshConfig.User, sshConfig.Host, sshConfig.Port)
+		}
+		switch {
+		case conn != nil:
+		case sshConfig.ProxyJump != "":
+			conn, err = client.DialProxyJump(sshConfig.ProxyJump, sshConfig.Host, sshConfig.Port)
+		default:
+			return client.Dial(addr, sshClientConfig)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return client.Handshake(conn, addr, sshClientConfig)
+	}
+}
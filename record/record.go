@@ -0,0 +1,259 @@
+// Package record 写入和回放 asciinema v2 (.cast) 会话录制。
+//
+// 只录制终端输出。密码提示关闭了回显，输入的密码不会进入录制文件。
+// 录制期间 os.Stdout 是管道，终端检查（IsTerminal、GetSize）须使用 Terminal 返回的原始文件
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// header 是 asciinema v2 文件首行
+type header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder 将输出事件写入 .cast 文件
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.WriteCloser
+	start   time.Time
+	pending []byte // 尚未组成完整 UTF-8 字符的尾部字节
+	err     error
+}
+
+// Create 创建录制文件并写入文件头
+func Create(path, title string, width, height int) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+	rec, err := newRecorder(f, title, width, height, time.Now())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return rec, nil
+}
+
+// newRecorder 向 w 写入文件头，start 为录制开始时间
+func newRecorder(w io.WriteCloser, title string, width, height int, start time.Time) (*Recorder, error) {
+	h := header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: start.Unix(),
+		Title:     title,
+		Env: map[string]string{
+			"SHELL": os.Getenv("SHELL"),
+			"TERM":  os.Getenv("TERM"),
+		},
+	}
+	line, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("write recording header: %w", err)
+	}
+	return &Recorder{w: w, start: start}, nil
+}
+
+// Write 记录一段输出（实现 io.Writer，不会返回错误以免影响终端输出）
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data := append(r.pending, p...)
+	complete, rest := splitCompleteUTF8(data)
+	r.pending = append([]byte(nil), rest...)
+	if len(complete) > 0 {
+		r.writeEvent(time.Since(r.start), complete)
+	}
+	return len(p), nil
+}
+
+// writeEvent 写入一条输出事件；出错后不再写入，错误由 Close 返回
+func (r *Recorder) writeEvent(elapsed time.Duration, data []byte) {
+	if r.err != nil {
+		return
+	}
+	event, err := json.Marshal([]interface{}{elapsed.Seconds(), "o", string(data)})
+	if err != nil {
+		r.err = err
+		return
+	}
+	_, r.err = r.w.Write(append(event, '\n'))
+}
+
+// Close 写出剩余字节并关闭文件，返回录制过程中的第一个写入错误
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) > 0 {
+		r.writeEvent(time.Since(r.start), r.pending)
+		r.pending = nil
+	}
+	closeErr := r.w.Close()
+	if r.err != nil {
+		return r.err
+	}
+	return closeErr
+}
+
+// splitCompleteUTF8 把 b 分成以完整字符结尾的前缀和不完整的尾部
+func splitCompleteUTF8(b []byte) ([]byte, []byte) {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(b[i]) {
+			continue
+		}
+		if utf8.FullRune(b[i:]) {
+			return b, nil
+		}
+		return b[:i], b[i:]
+	}
+	return b, nil
+}
+
+var (
+	terminalMu sync.Mutex
+	terminal   *os.File // 录制期间被替换前的 os.Stdout
+)
+
+// Terminal 返回真正的标准输出：录制期间是被替换前的 os.Stdout，否则就是 os.Stdout
+func Terminal() *os.File {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	if terminal != nil {
+		return terminal
+	}
+	return os.Stdout
+}
+
+// CaptureStdout 将 os.Stdout / os.Stderr 重定向到管道，输出同时写到原终端和录制文件
+// 返回的 stop 函数恢复原始输出并等待管道数据写完
+func (r *Recorder) CaptureStdout() (stop func(), err error) {
+	realStdout, realStderr := os.Stdout, os.Stderr
+
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	pump := func(src io.Reader, dst io.Writer) {
+		defer wg.Done()
+		io.Copy(io.MultiWriter(dst, r), src)
+	}
+	wg.Add(2)
+	go pump(outR, realStdout)
+	go pump(errR, realStderr)
+
+	terminalMu.Lock()
+	prevTerminal := terminal
+	if terminal == nil {
+		terminal = realStdout
+	}
+	terminalMu.Unlock()
+	os.Stdout, os.Stderr = outW, errW
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			os.Stdout, os.Stderr = realStdout, realStderr
+			terminalMu.Lock()
+			terminal = prevTerminal
+			terminalMu.Unlock()
+			outW.Close()
+			errW.Close()
+			wg.Wait()
+			outR.Close()
+			errR.Close()
+		})
+	}, nil
+}
+
+// ReplayOptions 回放选项
+type ReplayOptions struct {
+	Speed   float64       // 播放倍速，<=0 视为 1
+	MaxIdle time.Duration // 事件间最长等待时间，0 表示不限制
+}
+
+// Replay 按录制时的节奏把 .cast 文件输出到 out
+func Replay(r io.Reader, out io.Writer, opts ReplayOptions) error {
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("empty recording")
+	}
+	var h header
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil {
+		return fmt.Errorf("parse recording header: %w", err)
+	}
+	if h.Version != 2 {
+		return fmt.Errorf("unsupported recording version: %d", h.Version)
+	}
+
+	last := 0.0
+	lineNo := 1
+	for scanner.Scan() {
+		lineNo++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || len(event) != 3 {
+			return fmt.Errorf("parse recording line %d: invalid event", lineNo)
+		}
+		at, ok1 := event[0].(float64)
+		kind, ok2 := event[1].(string)
+		data, ok3 := event[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return fmt.Errorf("parse recording line %d: invalid event", lineNo)
+		}
+		if kind != "o" {
+			continue
+		}
+
+		wait := time.Duration((at - last) / speed * float64(time.Second))
+		if opts.MaxIdle > 0 && wait > opts.MaxIdle {
+			wait = opts.MaxIdle
+		}
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+		last = at
+
+		if _, err := io.WriteString(out, data); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
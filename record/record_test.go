@@ -0,0 +1,113 @@
+package record
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestRecorderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	rec, err := newRecorder(nopCloser{&buf}, "demo", 80, 24, time.Now())
+	if err != nil {
+		t.Fatalf("newRecorder() error = %v", err)
+	}
+
+	rocket := []byte("🚀 done\n")
+	rec.Write([]byte("\033[32m/home\033[0m > "))
+	// 多字节字符被拆成两次写入，不能产生非法 UTF-8
+	rec.Write(rocket[:2])
+	rec.Write(rocket[2:])
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("recording has %d lines:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], `{"version":2,"width":80,"height":24,`) {
+		t.Fatalf("header = %s", lines[0])
+	}
+	if strings.Contains(buf.String(), "�") {
+		t.Fatalf("recording contains replacement characters:\n%s", buf.String())
+	}
+
+	var out bytes.Buffer
+	if err := Replay(&buf, &out, ReplayOptions{Speed: 1000}); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if want := "\033[32m/home\033[0m > 🚀 done\n"; out.String() != want {
+		t.Fatalf("Replay() output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestCaptureStdoutKeepsTerminal(t *testing.T) {
+	// 用普通文件代替终端：录制期间 Terminal 必须返回它，而不是管道
+	real, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer real.Close()
+	origStdout := os.Stdout
+	os.Stdout = real
+	defer func() { os.Stdout = origStdout }()
+
+	var buf bytes.Buffer
+	rec, err := newRecorder(nopCloser{&buf}, "demo", 80, 24, time.Now())
+	if err != nil {
+		t.Fatalf("newRecorder() error = %v", err)
+	}
+	stop, err := rec.CaptureStdout()
+	if err != nil {
+		t.Fatalf("CaptureStdout() error = %v", err)
+	}
+	if os.Stdout == real {
+		t.Fatal("os.Stdout was not replaced")
+	}
+	if Terminal() != real {
+		t.Errorf("Terminal() during capture = %v, want the original stdout", Terminal().Name())
+	}
+	fmt.Fprint(os.Stdout, "hello\n")
+	stop()
+
+	if os.Stdout != real || Terminal() != real {
+		t.Error("stop() did not restore os.Stdout")
+	}
+	if data, _ := os.ReadFile(real.Name()); string(data) != "hello\n" {
+		t.Errorf("terminal output = %q", data)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"o","hello\n"`) {
+		t.Errorf("recording = %s", buf.String())
+	}
+}
+
+func TestSplitCompleteUTF8(t *testing.T) {
+	full := []byte("ab报")
+	complete, rest := splitCompleteUTF8(full[:len(full)-1])
+	if string(complete) != "ab" || len(rest) != 2 {
+		t.Fatalf("splitCompleteUTF8() = %q, %q", complete, rest)
+	}
+	complete, rest = splitCompleteUTF8(full)
+	if string(complete) != "ab报" || len(rest) != 0 {
+		t.Fatalf("splitCompleteUTF8() = %q, %q", complete, rest)
+	}
+}
+
+func TestReplayRejectsUnknownVersion(t *testing.T) {
+	err := Replay(strings.NewReader(`{"version":1}`+"\n"), &bytes.Buffer{}, ReplayOptions{})
+	if err == nil {
+		t.Fatal("expected error for version 1 recording")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	terminal "golang.org/x/term"
+
+	"github.com/frostime/my-sftp/record"
+)
+
+// startRecording 开始录制会话，返回停止录制的函数
+func startRecording(path, destination string) (func(), error) {
+	width, height, err := terminal.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	rec, err := record.Create(path, "my-sftp "+destination, width, height)
+	if err != nil {
+		return nil, err
+	}
+	stopCapture, err := rec.CaptureStdout()
+	if err != nil {
+		rec.Close()
+		return nil, err
+	}
+
+	fmt.Printf("● Recording session to %s\n", path)
+	return func() {
+		stopCapture()
+		if err := rec.Close(); err != nil {
+			fmt.Printf("Recording error: %v\n", err)
+			return
+		}
+		fmt.Printf("● Recording saved to %s\n", path)
+	}, nil
+}
+
+// runReplay 实现 my-sftp replay 子命令
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	speed := fs.Float64("speed", 1, "Playback speed multiplier")
+	idle := fs.Duration("idle", 2*time.Second, "Cap pauses between events to this `duration` (0 = no cap)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: my-sftp replay [--speed N] [--idle D] <file.cast>")
+		return exitUsage
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Replay error: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	if err := record.Replay(f, os.Stdout, record.ReplayOptions{Speed: *speed, MaxIdle: *idle}); err != nil {
+		fmt.Printf("\nReplay error: %v\n", err)
+		return 1
+	}
+	return 0
+}
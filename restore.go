@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+	"github.com/frostime/my-sftp/shell"
+)
+
+// runRestore 实现 my-sftp restore 子命令
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	at := fs.String("at", "", "Restore the snapshot `NAME`, or the newest one at or before a date (YYYY-MM-DD[ HH:MM[:SS]])")
+	to := fs.String("to", ".", "Local `dir` to restore into")
+	listOnly := fs.Bool("list", false, "List available snapshots and exit")
+	flatten := fs.Bool("flatten", false, "Flatten restored files into the target root")
+	positional, err := parseSubcommandArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) < 1 {
+		fmt.Println("Usage: my-sftp restore [--list] [--at DATE|NAME] [--to DIR] [--flatten] <destination>:<backup-dir> [path|pattern...]")
+		return exitUsage
+	}
+
+	destination, remoteBase, ok := config.SplitRemoteSpec(positional[0])
+	if !ok {
+		fmt.Printf("Error: backup location must be <destination>:<backup-dir>, got %s\n", positional[0])
+		return exitUsage
+	}
+
+	c, err := connect(destination, client.StartEager)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return connectExitCode(err)
+	}
+	defer c.Close()
+	c.Subscribe(shell.TransferEventPrinter(c))
+
+	snapshots, err := c.ListSnapshots(remoteBase)
+	if err != nil {
+		fmt.Printf("Error: list snapshots: %v\n", err)
+		return 1
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No snapshots found in %s\n", c.ResolveRemotePath(remoteBase))
+		return 1
+	}
+	if *listOnly {
+		printSnapshots(snapshots)
+		return 0
+	}
+
+	var snapshot client.Snapshot
+	if *at != "" {
+		snapshot, err = client.SelectSnapshot(snapshots, *at)
+	} else {
+		snapshot, err = pickSnapshot(snapshots)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	// 在快照目录内解析 source，使恢复路径相对于备份根
+	if err := c.Chdir(snapshot.Path); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	sources := positional[1:]
+	if len(sources) == 0 {
+		sources = []string{"."}
+	}
+
+	fmt.Printf("Restoring snapshot %s to %s\n", snapshot.Name, c.ResolveLocalPath(*to))
+	startTime := time.Now()
+	count, err := c.DownloadSources(sources, *to, &client.DownloadOptions{
+		Recursive:    true,
+		ShowProgress: true,
+		Concurrency:  c.Concurrency(),
+		Flatten:      *flatten,
+		MaxDepth:     -1,
+	})
+	if err != nil {
+		fmt.Printf("Restore failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✓ Restored %d file(s) from %s in %s\n", count, snapshot.Name, time.Since(startTime).Round(time.Millisecond))
+	return 0
+}
+
+func printSnapshots(snapshots []client.Snapshot) {
+	for i, snapshot := range snapshots {
+		fmt.Printf("  %2d) %s  (%s)\n", i+1, snapshot.Name, snapshot.Time.Format("Mon 2006-01-02 15:04:05"))
+	}
+}
+
+// pickSnapshot 列出快照并让用户选择，直接回车选择最新的
+func pickSnapshot(snapshots []client.Snapshot) (client.Snapshot, error) {
+	fmt.Println("Available snapshots:")
+	printSnapshots(snapshots)
+	fmt.Printf("Select snapshot [1-%d, default %d]: ", len(snapshots), len(snapshots))
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && line == "" {
+		return client.Snapshot{}, fmt.Errorf("no snapshot selected")
+	}
+	if line == "" {
+		return snapshots[len(snapshots)-1], nil
+	}
+	n, err := strconv.Atoi(line)
+	if err != nil || n < 1 || n > len(snapshots) {
+		return client.Snapshot{}, fmt.Errorf("invalid selection: %s", line)
+	}
+	return snapshots[n-1], nil
+}
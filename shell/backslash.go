@@ -0,0 +1,95 @@
+package shell
+
+import (
+	"slices"
+	"strings"
+)
+
+// remoteArgSpec 命令的哪些参数是远程路径（set backslash convert 时转换其中的反斜杠）
+type remoteArgSpec struct {
+	skip       int      // 开头不是远程路径的非选项参数个数（chmod 的模式、sync 的本地目录）
+	local      bool     // 非选项参数都是本地路径（put）
+	valueOpts  []string // 带值的选项，值不是远程路径
+	remoteOpts []string // 带值的选项，值是远程路径（put -d）
+}
+
+// transferValueOpts get/put 带值的选项（见 parseTransferCLIArgs）
+var transferValueOpts = []string{"--name", "--hash", "--spot-check", "--chunked", "--parallel",
+	"--include", "--exclude", "--overwrite", "--order", "--parallel-min", "--on-denied"}
+
+// remoteArgSpecs 参数中包含远程路径的命令；未列出的命令（本地命令、文本管道、set 等）不转换
+var remoteArgSpecs = map[string]remoteArgSpec{
+	"cd": {}, "rm": {}, "del": {}, "delete": {}, "mkdir": {}, "md": {}, "rmdir": {}, "rd": {},
+	"rename": {}, "mv": {}, "cp": {}, "stat": {}, "info": {}, "ln": {}, "readlink": {}, "df": {}, "wc": {},
+	"ls": {valueOpts: []string{"--export", "--format"}},
+	"ll": {valueOpts: []string{"--export", "--format"}}, "dir": {valueOpts: []string{"--export", "--format"}},
+	"chmod": {skip: 1}, "chown": {skip: 1}, "chgrp": {skip: 1},
+	"find":     {valueOpts: []string{"-name", "-type", "-mtime", "-size", "--export", "--format", "-d", "--dir"}},
+	"rwatch":   {valueOpts: []string{"-i", "--interval", "-n", "--count", "-d", "--dir"}},
+	"wait-for": {valueOpts: []string{"-t", "--timeout", "-i", "--interval"}},
+	"sync":     {skip: 1},
+	"get":      {valueOpts: append([]string{"-d", "--dir"}, transferValueOpts...)},
+	"download": {valueOpts: append([]string{"-d", "--dir"}, transferValueOpts...)},
+	"put":      {local: true, valueOpts: transferValueOpts, remoteOpts: []string{"-d", "--dir"}},
+	"upload":   {local: true, valueOpts: transferValueOpts, remoteOpts: []string{"-d", "--dir"}},
+}
+
+// SetConvertBackslashes 设置是否把远程路径参数中的 \ 当作 /（见 set backslash）
+func (s *Shell) SetConvertBackslashes(on bool) {
+	s.backslashes = on
+}
+
+// convertBackslashes 把 cmd 的远程路径参数中的 \ 换成 /，\\ 表示文件名中真正的反斜杠
+func convertBackslashes(cmd string, args []string) []string {
+	spec, ok := remoteArgSpecs[cmd]
+	if !ok {
+		return args
+	}
+	out := slices.Clone(args)
+	positional, optionsEnded := 0, false
+	for i := 0; i < len(out); i++ {
+		arg := out[i]
+		switch {
+		case optionsEnded:
+		case arg == "--":
+			optionsEnded = true
+			continue
+		case slices.Contains(spec.remoteOpts, arg):
+			if i+1 < len(out) {
+				i++
+				out[i] = toForwardSlashes(out[i])
+			}
+			continue
+		case slices.Contains(spec.valueOpts, arg):
+			i++
+			continue
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			continue
+		}
+		positional++
+		if !spec.local && positional > spec.skip {
+			out[i] = toForwardSlashes(arg)
+		}
+	}
+	return out
+}
+
+// toForwardSlashes 把 \ 换成 /，\\ 换成单个 \
+func toForwardSlashes(p string) string {
+	if !strings.Contains(p, `\`) {
+		return p
+	}
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		switch {
+		case p[i] != '\\':
+			b.WriteByte(p[i])
+		case i+1 < len(p) && p[i+1] == '\\':
+			b.WriteByte('\\')
+			i++
+		default:
+			b.WriteByte('/')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package shell
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestToForwardSlashes(t *testing.T) {
+	tests := map[string]string{
+		`logs\2024`:           "logs/2024",
+		`\srv\www\`:           "/srv/www/",
+		`report\\draft.txt`:   `report\draft.txt`,
+		`a\\\b`:               `a\/b`,
+		"/already/unix/style": "/already/unix/style",
+	}
+	for in, want := range tests {
+		if got := toForwardSlashes(in); got != want {
+			t.Errorf("toForwardSlashes(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestConvertBackslashes(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		args []string
+		want []string
+	}{
+		{"cd", []string{`logs\2024`}, []string{"logs/2024"}},
+		{"mv", []string{`a\b`, `c\d`}, []string{"a/b", "c/d"}},
+		{"chmod", []string{"-R", "755", `www\site`}, []string{"-R", "755", "www/site"}},
+		{"ls", []string{"--format", `{name}\t`, `var\log`}, []string{"--format", `{name}\t`, "var/log"}},
+		{"get", []string{"-d", `C:\tmp`, `logs\a.txt`}, []string{"-d", `C:\tmp`, "logs/a.txt"}},
+		{"put", []string{`C:\tmp\a.txt`, "-d", `www\up`}, []string{`C:\tmp\a.txt`, "-d", "www/up"}},
+		{"sync", []string{`C:\site`, `www\site`}, []string{`C:\site`, "www/site"}},
+		{"rm", []string{"--", `-odd\name`}, []string{"--", "-odd/name"}},
+		{"lcd", []string{`C:\Users`}, []string{`C:\Users`}},
+	}
+	for _, tt := range tests {
+		if got := convertBackslashes(tt.cmd, tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("convertBackslashes(%s, %q) = %q, want %q", tt.cmd, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestSetBackslash(t *testing.T) {
+	s := &Shell{}
+	if err := s.cmdSet([]string{"backslash", "convert"}); err != nil || !s.backslashes {
+		t.Fatalf("set backslash convert: %v, backslashes = %v", err, s.backslashes)
+	}
+	if err := s.cmdSet([]string{"backslash", "keep"}); err != nil || s.backslashes {
+		t.Fatalf("set backslash keep: %v, backslashes = %v", err, s.backslashes)
+	}
+	if err := s.cmdSet([]string{"backslash", "maybe"}); err == nil {
+		t.Fatal("set backslash maybe expected error")
+	}
+}
@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/frostime/my-sftp/lexer"
+)
+
+// RunBatch 逐行执行 r 中的命令（同 sftp -b）：空行和 # 开头的行被忽略，每条命令执行前回显。
+// 批处理模式下从不读取终端：需要询问的命令（--overwrite ask、cp -i）失败，! 和 !! 命令的标准输入为空。
+// 命令失败时停止并返回错误；continueOnError 时继续执行，最后返回失败的命令数。
+// 以 - 开头的命令失败时总是继续（同 sftp）。结束前等待后台任务完成
+func (s *Shell) RunBatch(r io.Reader, continueOnError bool) error {
+	s.batch = true
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read batch file: %w", err)
+	}
+	return s.runCommands(lines, "line", continueOnError)
+}
+
+// RunInline 执行 -e 给出的以 ; 分隔的命令（引号内的 ; 不分隔），规则同 RunBatch，
+// 但需要询问时仍然读取终端
+func (s *Shell) RunInline(line string, continueOnError bool) error {
+	return s.runCommands(lexer.SplitCommands(line), "command", continueOnError)
+}
+
+// runCommands 依次执行 commands，出错时用 unit（line/command）和序号指出失败的命令
+func (s *Shell) runCommands(commands []string, unit string, continueOnError bool) error {
+	defer s.rl.Close()
+	defer s.trackFailures()()
+
+	failed := 0
+	var runErr error
+	for i, line := range commands {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ignoreError := false
+		if rest, ok := strings.CutPrefix(line, "-"); ok {
+			line, ignoreError = strings.TrimSpace(rest), true
+		}
+		fmt.Fprintf(s.statusOutput(), "%s> %s\n", s.client.Getwd(), line)
+
+		err := s.timeCommand(line)
+		if errors.Is(err, errExit) {
+			break
+		}
+		if err == nil {
+			continue
+		}
+		fmt.Fprintf(s.statusOutput(), "Error: %v\n", err)
+		s.failures.addCommand(CommandFailure{Index: i + 1, Command: line, Error: err.Error(), Ignored: ignoreError})
+		if errors.Is(err, os.ErrNotExist) {
+			s.checkWorkDir()
+		}
+		if ignoreError {
+			continue
+		}
+		failed++
+		if !continueOnError {
+			runErr = fmt.Errorf("%s %d: %s: %w", unit, i+1, line, err)
+			break
+		}
+	}
+
+	// 后台任务（cmd &）随命令一起结束
+	s.client.WaitTransfers()
+	if runErr == nil && failed > 0 {
+		runErr = fmt.Errorf("%d command(s) failed", failed)
+	}
+	return runErr
+}
@@ -0,0 +1,151 @@
+package shell
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/lexer"
+)
+
+// scriptedEditor 从固定输入逐行读取的 lineEditor，代替终端
+type scriptedEditor struct {
+	in      *bufio.Reader
+	prompts []string
+	closed  bool
+}
+
+func (e *scriptedEditor) Readline() (string, error) {
+	line, err := e.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+func (e *scriptedEditor) SetPrompt(prompt string) { e.prompts = append(e.prompts, prompt) }
+func (e *scriptedEditor) Refresh()                {}
+func (e *scriptedEditor) HistoryDisable()         {}
+func (e *scriptedEditor) HistoryEnable()          {}
+func (e *scriptedEditor) SetHistoryPath(string)   {}
+func (e *scriptedEditor) Stdout() io.Writer       { return io.Discard }
+func (e *scriptedEditor) Close() error            { e.closed = true; return nil }
+
+// newTestShell 返回未连接服务器的 Shell，从 input 读取用户输入，只能执行本地命令
+func newTestShell(t *testing.T, input string) *Shell {
+	t.Helper()
+	return &Shell{
+		client:   &client.Client{},
+		rl:       &scriptedEditor{in: bufio.NewReader(strings.NewReader(input))},
+		jobs:     &jobManager{},
+		timings:  &timingLog{},
+		failures: &failureLog{},
+		atPrompt: &promptState{},
+	}
+}
+
+// batchScript 在 dir 中依次创建 a、再次创建 a（失败）、创建 b；fail 是失败那一行的前缀
+func batchScript(dir, fail string) string {
+	return "# create dirs\n" +
+		"lcd " + lexer.Quote(dir) + "\n" +
+		"\n" +
+		"lmkdir a\n" +
+		fail + "lmkdir a\n" +
+		"   # indented comment\n" +
+		"lmkdir b\n"
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestRunBatchStopsAtFirstError(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestShell(t, "")
+	err := s.RunBatch(strings.NewReader(batchScript(dir, "")), false)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 5: lmkdir a: ") {
+		t.Fatalf("RunBatch() error = %v, want line 5 to fail", err)
+	}
+	if !exists(filepath.Join(dir, "a")) || exists(filepath.Join(dir, "b")) {
+		t.Fatal("RunBatch() did not stop after the failing line")
+	}
+	if !s.batch {
+		t.Error("RunBatch() did not switch to batch mode")
+	}
+	if !s.rl.(*scriptedEditor).closed {
+		t.Error("RunBatch() did not close the line editor")
+	}
+	if len(s.failures.commands) != 1 || s.failures.commands[0].Index != 5 || s.failures.commands[0].Ignored {
+		t.Errorf("failures = %+v", s.failures.commands)
+	}
+}
+
+func TestRunBatchContinueOnError(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestShell(t, "")
+	err := s.RunBatch(strings.NewReader(batchScript(dir, "")), true)
+	if err == nil || err.Error() != "1 command(s) failed" {
+		t.Fatalf("RunBatch() error = %v, want 1 command(s) failed", err)
+	}
+	if !exists(filepath.Join(dir, "b")) {
+		t.Fatal("RunBatch() stopped at the failing line")
+	}
+}
+
+func TestRunBatchIgnoresDashPrefixedErrors(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestShell(t, "")
+	if err := s.RunBatch(strings.NewReader(batchScript(dir, "-")), false); err != nil {
+		t.Fatalf("RunBatch() error = %v", err)
+	}
+	if !exists(filepath.Join(dir, "b")) {
+		t.Fatal("RunBatch() stopped at an ignored error")
+	}
+	if len(s.failures.commands) != 1 || s.failures.commands[0].Command != "lmkdir a" || !s.failures.commands[0].Ignored {
+		t.Errorf("failures = %+v, want the ignored lmkdir a", s.failures.commands)
+	}
+}
+
+func TestRunBatchStopsAtExit(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestShell(t, "")
+	script := "lcd " + lexer.Quote(dir) + "\nexit\nlmkdir a\n"
+	if err := s.RunBatch(strings.NewReader(script), false); err != nil {
+		t.Fatalf("RunBatch() error = %v", err)
+	}
+	if exists(filepath.Join(dir, "a")) {
+		t.Fatal("RunBatch() ran commands after exit")
+	}
+}
+
+func TestRunInline(t *testing.T) {
+	// 引号内的 ; 不分隔命令，空命令被跳过
+	inline := func(dir string) string {
+		return "lcd " + lexer.Quote(dir) + "; lmkdir a;lmkdir 'x;y' ; ; lmkdir a; lmkdir b"
+	}
+	dir := t.TempDir()
+	s := newTestShell(t, "")
+	err := s.RunInline(inline(dir), false)
+	if err == nil || !strings.HasPrefix(err.Error(), "command 4: lmkdir a: ") {
+		t.Fatalf("RunInline() error = %v, want command 4 to fail", err)
+	}
+	if !exists(filepath.Join(dir, "x;y")) || exists(filepath.Join(dir, "b")) {
+		t.Fatal("RunInline() split the commands incorrectly")
+	}
+	if s.batch {
+		t.Error("RunInline() switched to batch mode")
+	}
+
+	dir = t.TempDir()
+	if err := newTestShell(t, "").RunInline(inline(dir), true); err == nil || err.Error() != "1 command(s) failed" {
+		t.Fatalf("RunInline(continueOnError) error = %v, want 1 command(s) failed", err)
+	}
+	if !exists(filepath.Join(dir, "b")) {
+		t.Fatal("RunInline(continueOnError) stopped at the failing command")
+	}
+}
@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// confirmableCommands 可以在 profile 的 Confirm 中要求确认的命令（"!" 为远程命令，"!!" 为本地命令）
+var confirmableCommands = []string{
+	"rm", "rmdir", "rename", "put", "get", "sync", "mkdir", "cp", "ln", "chmod", "chown", "chgrp",
+	"stage", "!", "!!", "shell",
+}
+
+// commandAliases 命令别名到 Confirm 中使用的名字
+var commandAliases = map[string]string{
+	"del": "rm", "delete": "rm", "rd": "rmdir", "mv": "rename", "md": "mkdir",
+	"upload": "put", "download": "get", "exec": "!",
+}
+
+// SetConfirmCommands 设置执行前需要用户确认（y/N）的命令，如生产环境的 rm、rename、put；
+// 别名同样需要确认。不在 confirmableCommands 中的名字被忽略（其余仍然生效）并返回错误
+func (s *Shell) SetConfirmCommands(commands []string) error {
+	var confirm []string
+	var err error
+	for _, cmd := range commands {
+		name := canonicalCommand(strings.ToLower(cmd))
+		if !slices.Contains(confirmableCommands, name) {
+			err = fmt.Errorf("cannot require confirmation for %q (supported: %s)", cmd, strings.Join(confirmableCommands, ", "))
+			continue
+		}
+		confirm = append(confirm, name)
+	}
+	s.confirm = confirm
+	return err
+}
+
+// ConfirmCommands 返回执行前需要确认的命令
+func (s *Shell) ConfirmCommands() []string {
+	return s.confirm
+}
+
+func canonicalCommand(cmd string) string {
+	if name, ok := commandAliases[cmd]; ok {
+		return name
+	}
+	return cmd
+}
+
+// commandName 返回命令行对应的命令名（别名已换成 Confirm 中的名字），用于确认检查
+func commandName(line string) string {
+	if strings.HasPrefix(line, "!!") {
+		return "!!"
+	}
+	if _, ok := cutRemoteCommand(line); ok {
+		return "!"
+	}
+	if cmdLine, ok := cutBackground(line); ok {
+		line = cmdLine
+	}
+	fields := parseCommandLine(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return canonicalCommand(fields[0])
+}
+
+// confirmCommand 命令在 Confirm 列表中时询问是否执行；拒绝（或批处理模式下无法询问）时返回错误
+func (s *Shell) confirmCommand(line string) error {
+	name := commandName(line)
+	if name == "" || !slices.Contains(s.confirm, name) {
+		return nil
+	}
+	answer, err := s.readAnswer(fmt.Sprintf("⚠ Confirm on this host: %s [y/N] ", line))
+	if err != nil || (answer != "y" && answer != "yes") {
+		return fmt.Errorf("%s: not confirmed", name)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package shell
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandName(t *testing.T) {
+	tests := map[string]string{
+		"rm a.txt":             "rm",
+		"del a.txt":            "rm",
+		"mv a b":               "rename",
+		"upload x.tar &":       "put",
+		"! rm -rf /tmp/x":      "!",
+		"exec ls":              "!",
+		"!! ls":                "!!",
+		`"rm" a`:               "rm",
+		"":                     "",
+		"cat log | grep ERROR": "cat",
+	}
+	for line, want := range tests {
+		if got := commandName(line); got != want {
+			t.Errorf("commandName(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestSetConfirmCommands(t *testing.T) {
+	s := &Shell{}
+	err := s.SetConfirmCommands([]string{"RM", "mv", "ls", "put"})
+	if err == nil {
+		t.Fatal("SetConfirmCommands with ls expected error")
+	}
+	if want := []string{"rm", "rename", "put"}; !slices.Equal(s.confirm, want) {
+		t.Fatalf("confirm = %q, want %q", s.confirm, want)
+	}
+}
+
+func TestConfirmCommandInBatchMode(t *testing.T) {
+	s := &Shell{batch: true, confirm: []string{"rm"}}
+	if err := s.confirmCommand("delete old.log"); err == nil || err.Error() != "rm: not confirmed" {
+		t.Fatalf("confirmCommand(delete) in batch mode = %v, want rm: not confirmed", err)
+	}
+	if err := s.confirmCommand("ls"); err != nil {
+		t.Fatalf("confirmCommand(ls) = %v", err)
+	}
+}
+
+func TestReadOnlyCommands(t *testing.T) {
+	tests := map[string]bool{
+		"ls -l":                true,
+		"cat log | grep ERROR": true,
+		"find . -name '*.go'":  true,
+		"mv a b":               false,
+		"rm a.txt":             false,
+		"cp a b":               false,
+		"stage push":           false,
+		"! ls":                 false,
+		"shell":                false,
+		"get big.iso &":        false,
+	}
+	for line, want := range tests {
+		if got := readOnlyCommands[commandName(line)]; got != want {
+			t.Errorf("read-only(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// notifyConnectionLost 连接断开时立即在提示符处提示，而不是等到下一个命令卡住或报错
+func (s *Shell) notifyConnectionLost(err error) {
+	if s.batch {
+		fmt.Printf("✗ Connection lost: %v\n", err)
+		return
+	}
+	var out io.Writer = os.Stdout
+	if s.rl != nil {
+		out = s.rl.Stdout()
+	}
+	next := "exit"
+	if s.client.CanReconnect() {
+		next = "reconnect"
+	}
+	fmt.Fprintf(out, "\r\033[K✗ Connection lost: %v. Press Enter to %s.\n", err, next)
+}
+
+// ensureConnected 连接已断开时重连；不能重连或重连失败时说明原因并返回 false，交互式循环随之退出（退出码 6）
+func (s *Shell) ensureConnected(line string) bool {
+	err := s.client.ConnectionLostErr()
+	if err == nil {
+		return true
+	}
+	if s.client.CanReconnect() {
+		return s.reconnect()
+	}
+	if line != "" {
+		fmt.Printf("Not running %q: connection lost (%v)\n", line, err)
+	} else {
+		fmt.Printf("Connection lost: %v\n", err)
+	}
+	return false
+}
+
+// readOnlyCommands 断线重连后自动重新执行的命令（名字同 commandName）：只读取状态，重复执行没有副作用。
+// 其余命令断线前可能已部分执行（如 mv、rm、stage push、! 命令），重新执行前先询问
+var readOnlyCommands = map[string]bool{
+	"pwd": true, "cd": true, "ls": true, "ll": true, "dir": true, "stat": true, "info": true, "df": true,
+	"find": true, "readlink": true, "wc": true, "cat": true, "grep": true, "head": true, "tail": true,
+	"sort": true, "uniq": true, "lpwd": true, "lls": true, "ldir": true, "help": true, "?": true,
+}
+
+// retryAfterReconnect 命令失败后检查连接：由断线引起时重连并重新执行一次该命令，返回重试的结果。
+// 不是断线或不能重连时原样返回 err；不是只读命令时先询问，不重新执行时返回 nil（错误已显示）
+func (s *Shell) retryAfterReconnect(line string, err error) error {
+	if err == nil || errors.Is(err, errExit) || !s.client.CanReconnect() || s.client.Alive() {
+		return err
+	}
+	fmt.Printf("Error: %v\n", err)
+	if !s.reconnect() {
+		return errExit
+	}
+	if !readOnlyCommands[commandName(line)] {
+		answer, err := s.readAnswer(fmt.Sprintf("⚠ %s may have partly run before the connection was lost. Run it again? [y/N] ", line))
+		if err != nil || (answer != "y" && answer != "yes") {
+			fmt.Printf("Not retried: %s\n", line)
+			return nil
+		}
+	}
+	fmt.Printf("↻ Retrying: %s\n", line)
+	return s.timeCommand(line)
+}
+
+// reconnect 等待断线时进行中的传输结束（它们已经失败），然后重连并恢复工作目录
+func (s *Shell) reconnect() bool {
+	s.client.WaitTransfers()
+	err := s.client.Reconnect(func(n int) {
+		if n == 1 {
+			fmt.Println("↻ Connection lost, reconnecting...")
+		} else {
+			fmt.Printf("↻ Reconnect attempt %d...\n", n)
+		}
+	})
+	if err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return false
+	}
+	fmt.Printf("✓ Reconnected (remote: %s, local: %s)\n", s.client.Getwd(), s.client.GetLocalwd())
+	return true
+}
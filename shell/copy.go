@@ -0,0 +1,82 @@
+package shell
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+type cpCLIOptions struct {
+	interactive bool
+	preserve    bool
+	sources     []string
+	dest        string
+}
+
+// parseCpCLIArgs 解析 cp 参数：cp [-i|-f] [-p] <source>... <dest>
+func parseCpCLIArgs(args []string) (*cpCLIOptions, error) {
+	opts := &cpCLIOptions{}
+	var positional []string
+	for _, tok := range args {
+		switch {
+		case tok == "-i" || tok == "--interactive":
+			opts.interactive = true
+		case tok == "-f" || tok == "--force":
+			opts.interactive = false
+		case tok == "-p" || tok == "--preserve":
+			opts.preserve = true
+		case strings.HasPrefix(tok, "-") && tok != "-":
+			return nil, fmt.Errorf("unknown option: %s", tok)
+		default:
+			positional = append(positional, tok)
+		}
+	}
+	if len(positional) < 2 {
+		return nil, fmt.Errorf("usage: cp [-i] [-p] <source>... <dest>")
+	}
+	opts.sources, opts.dest = positional[:len(positional)-1], positional[len(positional)-1]
+	return opts, nil
+}
+
+// cmdCp 在服务器上复制文件：目标是已存在的目录时复制到其中，是已存在的文件时覆盖（-i 先询问）
+func (s *Shell) cmdCp(args []string) error {
+	opts, err := parseCpCLIArgs(args)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.client.Stat(opts.dest)
+	destIsDir := err == nil && info.IsDir()
+	if len(opts.sources) > 1 && !destIsDir {
+		return fmt.Errorf("target '%s' is not a directory", opts.dest)
+	}
+
+	for _, src := range opts.sources {
+		target := opts.dest
+		if destIsDir {
+			target = path.Join(opts.dest, path.Base(s.client.ResolveRemotePath(src)))
+		}
+		if existing, err := s.client.Stat(target); err == nil {
+			if existing.IsDir() {
+				return fmt.Errorf("cannot overwrite directory '%s' with '%s'", target, src)
+			}
+			if opts.interactive {
+				answer, err := s.readAnswer(fmt.Sprintf("Overwrite '%s'? [y/N] ", target))
+				if err != nil {
+					return err
+				}
+				if answer != "y" && answer != "yes" {
+					fmt.Printf("Skipped: %s\n", src)
+					continue
+				}
+			}
+		}
+
+		method, err := s.client.CopyRemote(src, target, opts.preserve)
+		if err != nil {
+			return fmt.Errorf("cp %s: %w", src, err)
+		}
+		fmt.Printf("Copied: %s -> %s (%s)\n", src, target, method)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+// ErrorReport -b/-e/cp 结束后写入 --error-report 文件的 JSON
+type ErrorReport struct {
+	ExitCode int              `json:"exit_code"`
+	Commands []CommandFailure `json:"commands"` // 失败的命令，按执行顺序
+	Files    []FileFailure    `json:"files"`    // 传输失败的文件，按失败顺序
+}
+
+// CommandFailure 一条失败的命令
+type CommandFailure struct {
+	Index   int    `json:"index"` // 在批处理文件或 -e 中的序号，从 1 开始
+	Command string `json:"command"`
+	Error   string `json:"error"`
+	Ignored bool   `json:"ignored,omitempty"` // 以 - 开头，失败不影响退出码
+}
+
+// FileFailure 一个传输失败的文件
+type FileFailure struct {
+	Upload bool   `json:"upload"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Error  string `json:"error"`
+}
+
+// failureLog 收集批处理期间失败的命令和文件
+type failureLog struct {
+	mu       sync.Mutex
+	commands []CommandFailure
+	files    []FileFailure
+}
+
+func (l *failureLog) addCommand(f CommandFailure) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.commands = append(l.commands, f)
+}
+
+// recordEvent 是事件订阅者：记录 EventError
+func (l *failureLog) recordEvent(ev client.Event) {
+	if ev.Type != client.EventError {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.files = append(l.files, FileFailure{Upload: ev.IsUpload, Source: ev.Source, Target: ev.Target, Error: fmt.Sprint(ev.Err)})
+}
+
+// trackFailures 开始记录传输失败的文件，返回停止函数
+func (s *Shell) trackFailures() func() {
+	return s.client.Subscribe(s.failures.recordEvent)
+}
+
+// FailedFiles 返回 RunBatch、RunInline 或 RunCopy 期间传输失败的文件数
+func (s *Shell) FailedFiles() int {
+	s.failures.mu.Lock()
+	defer s.failures.mu.Unlock()
+	return len(s.failures.files)
+}
+
+// WriteErrorReport 把失败的命令和文件连同 exitCode 以 JSON 写入 file
+func (s *Shell) WriteErrorReport(file string, exitCode int) error {
+	s.failures.mu.Lock()
+	report := ErrorReport{
+		ExitCode: exitCode,
+		Commands: append([]CommandFailure{}, s.failures.commands...),
+		Files:    append([]FileFailure{}, s.failures.files...),
+	}
+	s.failures.mu.Unlock()
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write error report: %w", err)
+	}
+	return nil
+}
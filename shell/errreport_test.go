@@ -0,0 +1,40 @@
+package shell
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+func TestWriteErrorReport(t *testing.T) {
+	s := &Shell{failures: &failureLog{}}
+	s.failures.addCommand(CommandFailure{Index: 2, Command: "get -r logs", Error: "1 file failed"})
+	s.failures.recordEvent(client.Event{Type: client.EventCompleted, Source: "/srv/ok.log"})
+	s.failures.recordEvent(client.Event{Type: client.EventError, Source: "/srv/bad.log", Target: "logs/bad.log", Err: errors.New("permission denied")})
+	if got := s.FailedFiles(); got != 1 {
+		t.Fatalf("FailedFiles() = %d, want 1", got)
+	}
+
+	file := filepath.Join(t.TempDir(), "report.json")
+	if err := s.WriteErrorReport(file, 3); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report ErrorReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.ExitCode != 3 || len(report.Commands) != 1 || report.Commands[0].Index != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Files) != 1 || report.Files[0].Source != "/srv/bad.log" || report.Files[0].Error != "permission denied" {
+		t.Fatalf("report.Files = %+v", report.Files)
+	}
+}
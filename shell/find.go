@@ -0,0 +1,176 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+const findUsage = "usage: find [path] [-name PAT] [-type f|d|l] [-mtime [+|-]N] [-size [+|-]N[K|M|G]] [--export file [--format csv|json]] [--get [-d dir]]"
+
+type findCLIOptions struct {
+	root       string
+	filter     client.FindOptions
+	exportFile string
+	format     client.ExportFormat
+	download   bool
+	localDir   string
+}
+
+// findTypes -type 的值到 ExportEntry.Type 的映射
+var findTypes = map[string]string{"f": "file", "d": "dir", "l": "symlink"}
+
+// parseFindCLIArgs 解析 find 参数；与 find 一样，-mtime/-size 的 -N 是条件值而不是选项
+func parseFindCLIArgs(args []string) (*findCLIOptions, error) {
+	opts := &findCLIOptions{}
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		switch tok {
+		case "-name", "-type", "-mtime", "-size", "--export", "--format", "-d", "--dir":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", tok)
+			}
+			value := args[i]
+			var err error
+			switch tok {
+			case "-name":
+				opts.filter.Name = value
+			case "-type":
+				if opts.filter.Type = findTypes[value]; opts.filter.Type == "" {
+					return nil, fmt.Errorf("invalid -type: %s (want f, d or l)", value)
+				}
+			case "-mtime":
+				if opts.filter.Mtime, err = client.ParseFindDays(value); err != nil {
+					return nil, fmt.Errorf("-mtime: %w", err)
+				}
+			case "-size":
+				if opts.filter.Size, err = client.ParseFindSize(value); err != nil {
+					return nil, fmt.Errorf("-size: %w", err)
+				}
+			case "--export":
+				opts.exportFile = value
+			case "--format":
+				if opts.format, err = client.ParseExportFormat(value); err != nil {
+					return nil, err
+				}
+			default:
+				opts.localDir = value
+			}
+		case "--get":
+			opts.download = true
+		default:
+			if strings.HasPrefix(tok, "-") {
+				return nil, fmt.Errorf("unknown option: %s", tok)
+			}
+			if opts.root != "" {
+				return nil, fmt.Errorf("only one starting path is supported")
+			}
+			opts.root = tok
+		}
+	}
+	if opts.root == "" {
+		opts.root = "."
+	}
+	switch {
+	case opts.download && opts.exportFile != "":
+		return nil, fmt.Errorf("--get and --export cannot be combined")
+	case opts.localDir != "" && !opts.download:
+		return nil, fmt.Errorf("-d requires --get")
+	case opts.format != "" && opts.exportFile == "":
+		return nil, fmt.Errorf("--format requires --export")
+	}
+	return opts, nil
+}
+
+// displayFindPath 按用户给出的起始路径显示结果（与 find 相同：find logs 输出 logs/...）
+func displayFindPath(root, resolvedRoot, match string) string {
+	if match == resolvedRoot {
+		return root
+	}
+	rel := strings.TrimPrefix(match, strings.TrimSuffix(resolvedRoot, "/")+"/")
+	if root == "." {
+		return "./" + rel
+	}
+	return path.Join(root, rel)
+}
+
+// cmdFind 按条件查找远程文件，打印路径、导出元数据，或把找到的文件交给 get 下载
+func (s *Shell) cmdFind(args []string) error {
+	opts, err := parseFindCLIArgs(args)
+	if err != nil {
+		return fmt.Errorf("find: %w\n%s", err, findUsage)
+	}
+	opts.filter.OnError = func(p string, err error) error {
+		fmt.Fprintf(os.Stderr, "find: %s: %v\n", p, err)
+		return nil
+	}
+
+	if opts.exportFile != "" {
+		return s.exportFind(opts)
+	}
+
+	resolvedRoot := s.client.ResolveRemotePath(opts.root)
+	var files []string
+	count := 0
+	err = s.client.Find(opts.root, &opts.filter, func(entry client.ExportEntry) error {
+		count++
+		display := displayFindPath(opts.root, resolvedRoot, entry.Path)
+		if !opts.download {
+			fmt.Println(display)
+		} else if entry.Type == "file" {
+			files = append(files, display)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !opts.download {
+		return nil
+	}
+
+	if len(files) == 0 {
+		fmt.Printf("No files to download (%d match(es), none are regular files)\n", count)
+		return nil
+	}
+	// 交给 get：多个来源时保留相对目录结构
+	getArgs := []string{}
+	if opts.localDir != "" {
+		getArgs = append(getArgs, "-d", opts.localDir)
+	}
+	getOpts, err := parseTransferCLIArgs(append(append(getArgs, "--"), files...))
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	// 找到的是实际的文件名，其中的 * ? [ 不能再作为 glob 展开
+	getOpts.literal = true
+	return s.getParsed(getOpts)
+}
+
+func (s *Shell) exportFind(opts *findCLIOptions) error {
+	format := opts.format
+	if format == "" {
+		format = client.ExportFormatForFile(opts.exportFile)
+	}
+	target := s.client.ResolveLocalPath(opts.exportFile)
+	f, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+
+	startTime := time.Now()
+	count, err := s.client.ExportFind(opts.root, &opts.filter, format, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("export find results: %w", err)
+	}
+	fmt.Printf("✓ Exported %d entries (%s) to %s in %s\n", count, format, target, time.Since(startTime).Round(time.Millisecond))
+	return nil
+}
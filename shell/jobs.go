@@ -0,0 +1,366 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/ttystate"
+	"github.com/frostime/my-sftp/units"
+)
+
+// jobRefreshInterval fg 刷新进度行的间隔
+const jobRefreshInterval = 500 * time.Millisecond
+
+// job 以 & 结尾、在后台运行的 get/put/sync 命令
+type job struct {
+	id      int
+	line    string // 命令行（不含 &）
+	batch   *client.Batch
+	started time.Time
+	done    chan struct{} // 命令结束后关闭
+
+	logMu sync.Mutex
+	log   []byte // 运行中的说明信息（如同步计划、冲突），status 时显示
+
+	// 以下字段在 done 关闭后才能读取
+	ended   time.Time
+	summary string // 命令的完成信息，如 "✓ Uploaded 3 file(s) in 1s"
+	err     error
+}
+
+// Write 记录任务的说明信息，不打断前台的输入；任务与 status 可能并发访问
+func (j *job) Write(p []byte) (int, error) {
+	j.logMu.Lock()
+	defer j.logMu.Unlock()
+	j.log = append(j.log, p...)
+	return len(p), nil
+}
+
+// logLines 返回已记录的说明信息，每行一项
+func (j *job) logLines() []string {
+	j.logMu.Lock()
+	defer j.logMu.Unlock()
+	if len(j.log) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(j.log), "\n"), "\n")
+}
+
+// finished 报告命令是否已结束
+func (j *job) finished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// state 返回 running / cancelling / done / failed / cancelled
+func (j *job) state() string {
+	if !j.finished() {
+		if j.batch.Cancelled() {
+			return "cancelling"
+		}
+		return "running"
+	}
+	switch {
+	case j.batch.Cancelled():
+		return "cancelled"
+	case j.err != nil:
+		return "failed"
+	}
+	return "done"
+}
+
+// elapsed 返回已运行时间（结束后为总耗时）
+func (j *job) elapsed() time.Duration {
+	if j.finished() {
+		return j.ended.Sub(j.started)
+	}
+	return time.Since(j.started)
+}
+
+// progressLine 返回一行进度摘要，如 "12/340 files, 3.1 MB/120 MB (2%), 45s"
+func (j *job) progressLine() string {
+	p := j.batch.Progress()
+	line := fmt.Sprintf("%d/%d files, %s/%s", p.DoneFiles, p.Files, units.FormatSize(p.DoneBytes), units.FormatSize(p.Bytes))
+	if p.Bytes > 0 {
+		line += fmt.Sprintf(" (%d%%)", p.DoneBytes*100/p.Bytes)
+	}
+	if p.FailedFiles > 0 {
+		line += fmt.Sprintf(", %d failed", p.FailedFiles)
+	}
+	return line + ", " + j.elapsed().Round(time.Second).String()
+}
+
+// jobManager 管理后台任务；运行任务的 Shell 副本共享同一个 jobManager
+type jobManager struct {
+	mu     sync.Mutex
+	jobs   []*job
+	nextID int
+}
+
+func (m *jobManager) add(line string) *job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	j := &job{
+		id:      m.nextID,
+		line:    line,
+		batch:   client.NewBatch(),
+		started: time.Now(),
+		done:    make(chan struct{}),
+	}
+	m.jobs = append(m.jobs, j)
+	return j
+}
+
+func (m *jobManager) list() []*job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*job(nil), m.jobs...)
+}
+
+// lookup 按 "1"、"%1" 或 "[1]" 形式的编号查找任务
+func (m *jobManager) lookup(arg string) (*job, error) {
+	id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(arg, "%"), "[]"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid job id: %s", arg)
+	}
+	for _, j := range m.list() {
+		if j.id == id {
+			return j, nil
+		}
+	}
+	return nil, fmt.Errorf("no such job: %d", id)
+}
+
+// latestRunning 返回最近启动且仍在运行的任务
+func (m *jobManager) latestRunning() *job {
+	jobs := m.list()
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if !jobs[i].finished() {
+			return jobs[i]
+		}
+	}
+	return nil
+}
+
+// cutBackground 去掉命令行末尾未转义的 &，报告命令是否要求在后台运行
+func cutBackground(line string) (string, bool) {
+	if !strings.HasSuffix(line, "&") || strings.HasSuffix(line, `\&`) || strings.HasSuffix(line, "&&") {
+		return line, false
+	}
+	return strings.TrimSpace(strings.TrimSuffix(line, "&")), true
+}
+
+// checkBackground 检查命令能否在后台运行：只支持 get/put/sync，且不能需要交互或只输出计划
+func (s *Shell) checkBackground(cmd string, args []string) error {
+	switch cmd {
+	case "get", "download", "put", "upload":
+		opts, err := parseTransferCLIArgs(args)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd, err)
+		}
+		switch {
+		case opts.rename != "":
+			return fmt.Errorf("--name cannot be used in a background job")
+		case opts.listOnly || opts.dryRun:
+			return fmt.Errorf("--list-only and --dry-run cannot be used in a background job")
+		case opts.overwrite == client.OverwriteAsk || (opts.overwrite == "" && s.overwrite == client.OverwriteAsk):
+			return fmt.Errorf("--overwrite ask cannot be used in a background job")
+		}
+	case "sync":
+		opts, err := parseSyncCLIArgs(args)
+		if err != nil {
+			return err
+		}
+		if opts.dryRun {
+			return fmt.Errorf("--dry-run cannot be used in a background job")
+		}
+	default:
+		return fmt.Errorf("only get, put and sync can run in the background: %s", cmd)
+	}
+	return nil
+}
+
+// startJob 在后台运行 line（已去掉 &）
+func (s *Shell) startJob(line string) error {
+	fields := parseCommandLine(line)
+	if len(fields) == 0 {
+		return fmt.Errorf("usage: <get|put|sync> ... &")
+	}
+	if err := s.checkBackground(fields[0], fields[1:]); err != nil {
+		return err
+	}
+
+	j := s.jobs.add(line)
+	// 命令在 Shell 的副本上运行：共享客户端，使用启动时的设置，并关闭进度条
+	bg := *s
+	bg.job = j
+	go func() {
+		defer ttystate.Guard()
+		err := bg.executeCommand(line)
+		j.ended, j.err = time.Now(), err
+		close(j.done)
+		s.notifyJob(j)
+	}()
+
+	fmt.Printf("[%d] %s\n", j.id, line)
+	return nil
+}
+
+// report 打印命令的完成信息；在后台任务中则记录下来，任务结束时显示
+func (s *Shell) report(format string, a ...any) {
+	msg := fmt.Sprintf(format, a...)
+	if s.job != nil {
+		s.job.summary = msg
+		return
+	}
+	fmt.Println(msg)
+}
+
+// notifyJob 任务结束时打印一行通知，不打断正在输入的命令行
+func (s *Shell) notifyJob(j *job) {
+	var out io.Writer = os.Stdout
+	if s.rl != nil {
+		out = s.rl.Stdout()
+	}
+
+	msg := fmt.Sprintf("[%d] %-9s %s", j.id, j.state(), j.line)
+	switch {
+	case j.batch.Cancelled():
+	case j.err != nil:
+		first, _, multi := strings.Cut(j.err.Error(), "\n")
+		msg += ": " + first
+		if multi {
+			msg += fmt.Sprintf(" (see 'status %d')", j.id)
+		}
+	case j.summary != "":
+		msg += ": " + j.summary
+	}
+	fmt.Fprintf(out, "\r\033[K%s\n", msg)
+}
+
+// cmdJobs 列出后台任务
+func (s *Shell) cmdJobs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: jobs")
+	}
+	jobs := s.jobs.list()
+	if len(jobs) == 0 {
+		fmt.Println("No background jobs")
+		return nil
+	}
+	for _, j := range jobs {
+		fmt.Printf("[%d] %-10s %s  %s\n", j.id, j.state(), j.line, j.progressLine())
+	}
+	return nil
+}
+
+// cmdJobStatus 显示单个后台任务的详细状态
+func (s *Shell) cmdJobStatus(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: status <job-id>")
+	}
+	j, err := s.jobs.lookup(args[0])
+	if err != nil {
+		return err
+	}
+
+	p := j.batch.Progress()
+	fmt.Printf("Job [%d]: %s\n", j.id, j.line)
+	fmt.Printf("  State:    %s\n", j.state())
+	fmt.Printf("  Started:  %s (%s)\n", j.started.Format("15:04:05"), j.elapsed().Round(time.Second))
+	fmt.Printf("  Files:    %d/%d done, %d failed\n", p.DoneFiles, p.Files, p.FailedFiles)
+	fmt.Printf("  Bytes:    %s/%s", units.FormatSize(p.DoneBytes), units.FormatSize(p.Bytes))
+	if seconds := j.elapsed().Seconds(); seconds > 0 {
+		fmt.Printf(", %s/s", units.FormatSize(int64(float64(p.DoneBytes)/seconds)))
+	}
+	fmt.Println()
+	for i, line := range j.logLines() {
+		label := ""
+		if i == 0 {
+			label = "Log:"
+		}
+		fmt.Printf("  %-9s %s\n", label, line)
+	}
+	if j.finished() {
+		if j.summary != "" {
+			fmt.Printf("  Result:   %s\n", j.summary)
+		}
+		if j.err != nil {
+			fmt.Printf("  Error:    %v\n", j.err)
+		}
+	}
+	return nil
+}
+
+// cmdJobCancel 取消后台任务
+func (s *Shell) cmdJobCancel(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cancel <job-id>...")
+	}
+	for _, arg := range args {
+		j, err := s.jobs.lookup(arg)
+		if err != nil {
+			return err
+		}
+		if j.finished() {
+			return fmt.Errorf("job [%d] has already finished", j.id)
+		}
+		j.batch.Cancel()
+		fmt.Printf("Cancelling job [%d] %s\n", j.id, j.line)
+	}
+	return nil
+}
+
+// cmdFg 等待后台任务结束并显示进度；Ctrl-C 停止等待，任务继续在后台运行
+func (s *Shell) cmdFg(args []string) error {
+	var j *job
+	switch len(args) {
+	case 0:
+		if j = s.jobs.latestRunning(); j == nil {
+			return fmt.Errorf("fg: no running jobs")
+		}
+	case 1:
+		var err error
+		if j, err = s.jobs.lookup(args[0]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("usage: fg [job-id]")
+	}
+	if j.finished() {
+		return fmt.Errorf("job [%d] has already finished", j.id)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("[%d] %s (Ctrl-C to return to the prompt)\n", j.id, j.line)
+	ticker := time.NewTicker(jobRefreshInterval)
+	defer ticker.Stop()
+	for {
+		fmt.Printf("\r\033[K%s", j.progressLine())
+		select {
+		case <-j.done:
+			// 结果由 notifyJob 显示
+			fmt.Print("\r\033[K")
+			return nil
+		case <-ctx.Done():
+			fmt.Printf("\n[%d] still running in the background\n", j.id)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+func TestCutBackground(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		bg   bool
+	}{
+		{"put -r dist -d /srv &", "put -r dist -d /srv", true},
+		{"get big.iso&", "get big.iso", true},
+		{"put a\\&", "put a\\&", false},
+		{"put a && b", "put a && b", false},
+		{"put 'a & b'", "put 'a & b'", false},
+	}
+	for _, tt := range tests {
+		got, bg := cutBackground(tt.line)
+		if got != tt.want || bg != tt.bg {
+			t.Errorf("cutBackground(%q) = %q, %v, want %q, %v", tt.line, got, bg, tt.want, tt.bg)
+		}
+	}
+}
+
+func TestCheckBackground(t *testing.T) {
+	s := &Shell{overwrite: client.OverwriteAlways}
+	for _, fields := range [][]string{
+		{"put", "-r", "dist", "-d", "/srv"},
+		{"get", "--overwrite", "never", "logs/*.log"},
+		{"sync", "site", "/srv/site"},
+	} {
+		if err := s.checkBackground(fields[0], fields[1:]); err != nil {
+			t.Errorf("checkBackground(%v) error = %v", fields, err)
+		}
+	}
+
+	for _, fields := range [][]string{
+		{"ls", "-R"},
+		{"put", "a.txt", "--name", "b.txt"},
+		{"get", "-r", "logs", "--dry-run"},
+		{"put", "a.txt", "--overwrite", "ask"},
+		{"sync", "--dry-run", "site", "/srv/site"},
+	} {
+		if err := s.checkBackground(fields[0], fields[1:]); err == nil {
+			t.Errorf("checkBackground(%v) expected error", fields)
+		}
+	}
+
+	s.overwrite = client.OverwriteAsk
+	if err := s.checkBackground("put", []string{"a.txt"}); err == nil {
+		t.Error("checkBackground() should reject the session's ask policy")
+	}
+}
+
+func TestJobManagerLookup(t *testing.T) {
+	m := &jobManager{}
+	first := m.add("put a")
+	second := m.add("get b")
+	close(second.done)
+
+	for _, arg := range []string{"1", "%1", "[1]"} {
+		if j, err := m.lookup(arg); err != nil || j != first {
+			t.Errorf("lookup(%q) = %v, %v, want job 1", arg, j, err)
+		}
+	}
+	if _, err := m.lookup("3"); err == nil {
+		t.Error("lookup() of an unknown job should fail")
+	}
+	if j := m.latestRunning(); j != first {
+		t.Errorf("latestRunning() = %v, want job 1", j)
+	}
+	if second.state() != "done" {
+		t.Errorf("state() = %q, want done", second.state())
+	}
+	first.batch.Cancel()
+	if first.state() != "cancelling" {
+		t.Errorf("state() = %q, want cancelling", first.state())
+	}
+}
+
+func TestJobLog(t *testing.T) {
+	j := (&jobManager{}).add("sync a /srv/a")
+	s := &Shell{job: j}
+	if s.infoOutput(false) != io.Writer(j) {
+		t.Fatal("infoOutput() in a job should be the job log")
+	}
+	fmt.Fprintf(s.infoOutput(false), "Syncing %d new\n", 2)
+	fmt.Fprintln(s.infoOutput(false), "Not synced: x")
+	if got := j.logLines(); !slices.Equal(got, []string{"Syncing 2 new", "Not synced: x"}) {
+		t.Fatalf("logLines() = %q", got)
+	}
+	if (&Shell{}).infoOutput(true) != io.Writer(os.Stderr) {
+		t.Fatal("infoOutput(json) should be stderr")
+	}
+}
@@ -0,0 +1,31 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+// lockRemoteDir 写入远程目录前获取咨询锁（见 client.LockRemoteDir），返回释放函数。
+// 目录被他人锁定时返回错误；无法创建锁文件（如没有写权限）时只给出警告，不加锁继续
+func (s *Shell) lockRemoteDir(dir, command string) (func(), error) {
+	lock, err := s.client.LockRemoteDir(dir, command)
+	if err != nil {
+		var locked *client.LockedError
+		if errors.As(err, &locked) {
+			return nil, err
+		}
+		fmt.Printf("⚠ Could not lock %s, continuing without a lock: %v\n", s.client.ResolveRemotePath(dir), err)
+		return func() {}, nil
+	}
+	if stale := lock.TakenOver; stale != nil {
+		fmt.Printf("⚠ Took over an expired lock on %s held by %s (%s, expired %s)\n",
+			s.client.ResolveRemotePath(dir), stale.Owner, stale.Command, stale.Expires.Local().Format("2006-01-02 15:04"))
+	}
+	return func() {
+		if err := lock.Release(); err != nil {
+			fmt.Printf("⚠ %v\n", err)
+		}
+	}, nil
+}
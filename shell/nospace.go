@@ -0,0 +1,39 @@
+package shell
+
+import (
+	"fmt"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/units"
+)
+
+// askNoSpace 是上传时远程空间不足的询问回调：显示缺少多少空间，等待用户释放空间后继续或中止
+func (s *Shell) askNoSpace(info client.NoSpaceInfo) bool {
+	reason := "Remote disk full"
+	if info.Quota {
+		reason = "Remote quota exceeded"
+	}
+	fmt.Printf("%s✗ %s in %s: %v\n", s.client.ClearLine(), reason, info.Dir, info.Err)
+	fmt.Printf("  Still to upload: %s in %d file(s)\n", units.FormatSize(info.Remaining), info.Files)
+	switch {
+	case info.Usage == nil:
+		fmt.Println("  Available:       unknown (server does not support statvfs)")
+	case info.Missing() > 0:
+		fmt.Printf("  Available:       %s, free at least %s more\n",
+			units.FormatSize(int64(info.Usage.Available)), units.FormatSize(int64(info.Missing())))
+	default:
+		fmt.Printf("  Available:       %s\n", units.FormatSize(int64(info.Usage.Available)))
+	}
+	for {
+		answer, err := s.readAnswer("Upload paused: free up space on the server, then [r]esume or [a]bort? ")
+		if err != nil {
+			return false
+		}
+		switch answer {
+		case "r", "resume":
+			return true
+		case "a", "abort":
+			return false
+		}
+	}
+}
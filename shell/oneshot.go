@@ -0,0 +1,184 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+	"github.com/frostime/my-sftp/units"
+)
+
+// CopyCommand 一次性 cp 命令（my-sftp cp）解析后的参数：source 全在本地时上传，全在同一远程主机时下载；
+// source 和目标都是远程路径时在两台服务器之间传输（Source 为源主机）
+type CopyCommand struct {
+	Destination string // 远程主机：SSH config 别名或 user@host[:port]
+	Source      string // 服务器之间传输时的源主机，空表示一侧在本地
+	Upload      bool
+	opts        *transferCLIOptions
+	target      string // 目标路径，上传时为远程路径，下载时为本地路径
+	line        string // 原始参数，用于错误报告
+}
+
+// ParseCopyCommand 解析 cp 的参数：get/put 的选项 + source... + 目标，远程路径写作 destination:path。
+// 目标由最后一个参数给出，所以不接受 -d 和 --name
+func ParseCopyCommand(args []string) (*CopyCommand, error) {
+	opts, err := parseTransferCLIArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if opts.targetDir != "" || opts.rename != "" {
+		return nil, fmt.Errorf("cp takes the destination as its last argument; -d and --name are not accepted")
+	}
+	if len(opts.sources) < 2 {
+		return nil, fmt.Errorf("cp needs at least one source and a destination")
+	}
+
+	sources, target := opts.sources[:len(opts.sources)-1], opts.sources[len(opts.sources)-1]
+	cmd := &CopyCommand{opts: opts, line: "cp " + strings.Join(args, " ")}
+	if host, remotePath, ok := config.SplitRemoteSpec(target); ok {
+		cmd.Destination, cmd.Upload, cmd.target = host, true, remotePath
+		if _, _, remote := config.SplitRemoteSpec(sources[0]); remote {
+			return parseRelayCommand(cmd, sources)
+		}
+		for _, source := range sources {
+			if _, _, remote := config.SplitRemoteSpec(source); remote {
+				return nil, fmt.Errorf("cp cannot mix local and remote sources (%s, %s)", sources[0], source)
+			}
+		}
+		opts.sources = sources
+		return cmd, nil
+	}
+
+	cmd.target = target
+	opts.sources = make([]string, len(sources))
+	for i, source := range sources {
+		host, remotePath, ok := config.SplitRemoteSpec(source)
+		if !ok {
+			return nil, fmt.Errorf("cp copies between local and remote; %s and %s are both local", source, target)
+		}
+		if cmd.Destination != "" && host != cmd.Destination {
+			return nil, fmt.Errorf("all remote sources must be on the same host (%s, %s)", cmd.Destination, host)
+		}
+		if remotePath == "" {
+			return nil, fmt.Errorf("missing remote path in %s", source)
+		}
+		cmd.Destination = host
+		opts.sources[i] = remotePath
+	}
+	return cmd, nil
+}
+
+// parseRelayCommand 解析服务器之间的 cp：source 必须都在同一台主机上，只支持 -r、-p 和 --fail-fast
+func parseRelayCommand(cmd *CopyCommand, sources []string) (*CopyCommand, error) {
+	opts := cmd.opts
+	if opts.flatten || opts.listOnly || opts.dryRun || opts.spotCheck > 0 || opts.chunkSize > 0 || opts.specials ||
+		opts.verify || opts.checksum || opts.hash != "" || opts.streams > 0 || len(opts.include)+len(opts.exclude) > 0 ||
+		opts.overwrite != "" || opts.order != "" || opts.denied != "" || opts.json {
+		return nil, fmt.Errorf("cp between two servers supports only -r, -p and --fail-fast")
+	}
+	opts.sources = make([]string, len(sources))
+	for i, source := range sources {
+		host, remotePath, ok := config.SplitRemoteSpec(source)
+		if !ok {
+			return nil, fmt.Errorf("cp cannot mix local and remote sources (%s, %s)", sources[0], source)
+		}
+		if cmd.Source != "" && host != cmd.Source {
+			return nil, fmt.Errorf("all remote sources must be on the same host (%s, %s)", cmd.Source, host)
+		}
+		if remotePath == "" {
+			return nil, fmt.Errorf("missing remote path in %s", source)
+		}
+		cmd.Source = host
+		opts.sources[i] = remotePath
+	}
+	return cmd, nil
+}
+
+// RunCopy 执行 cp：目标是已存在的目录、以 / 结尾或有多个 source 时传输到该目录下，
+// 否则单个文件 source 被复制为目标路径本身（同 --name）
+func (s *Shell) RunCopy(cmd *CopyCommand) error {
+	defer s.trackFailures()()
+	err := s.runCopy(cmd)
+	if err != nil {
+		s.failures.addCommand(CommandFailure{Index: 1, Command: cmd.line, Error: err.Error()})
+	}
+	return err
+}
+
+func (s *Shell) runCopy(cmd *CopyCommand) error {
+	opts := *cmd.opts
+	target := cmd.target
+	if target == "" {
+		target = "."
+	}
+
+	if s.copyTargetIsDir(&opts, target, cmd.Upload) {
+		opts.targetDir = target
+		// 同 scp：多个显式文件并排放在目标目录下，而不是保留各自的路径
+		if len(opts.sources) > 1 && !opts.recursive && !slices.ContainsFunc(opts.sources, isGlob) {
+			opts.flatten = true
+		}
+	} else if cmd.Upload {
+		opts.targetDir, opts.rename = path.Split(target)
+	} else {
+		opts.targetDir, opts.rename = filepath.Split(target)
+	}
+	if opts.targetDir == "" {
+		opts.targetDir = "."
+	}
+
+	if cmd.Upload {
+		return s.put(&opts)
+	}
+	return s.get(&opts)
+}
+
+// copyTargetIsDir 判断 cp 的目标是否作为目录使用
+func (s *Shell) copyTargetIsDir(opts *transferCLIOptions, target string, upload bool) bool {
+	if len(opts.sources) != 1 || opts.recursive || isGlob(opts.sources[0]) {
+		return true
+	}
+	if target == "." || target == ".." || strings.HasSuffix(target, "/") || (!upload && strings.HasSuffix(target, string(filepath.Separator))) {
+		return true
+	}
+	if upload {
+		info, err := s.client.Stat(target)
+		return err == nil && info.IsDir()
+	}
+	info, err := os.Stat(s.client.ResolveLocalPath(target))
+	return err == nil && info.IsDir()
+}
+
+func isGlob(source string) bool {
+	return strings.ContainsAny(source, "*?[]")
+}
+
+// RunRelay 执行服务器之间的 cp：文件从 from（cmd.Source）经本机流式传输到本 shell 的服务器（cmd.Destination）
+func (s *Shell) RunRelay(from *client.Client, cmd *CopyCommand) error {
+	defer s.trackFailures()()
+	startTime := time.Now()
+	result, err := s.client.Relay(from, cmd.opts.sources, cmd.target, &client.RelayOptions{
+		Recursive:    cmd.opts.recursive,
+		ShowProgress: true,
+		Concurrency:  s.client.Concurrency(),
+		Preserve:     cmd.opts.preserve,
+		FailFast:     cmd.opts.failFast,
+	})
+	if result != nil {
+		for _, skipped := range result.Skipped {
+			fmt.Printf("Skipped (not a regular file): %s:%s\n", cmd.Source, skipped)
+		}
+		s.report("✓ Copied %d file(s) (%s) from %s to %s in %s", result.Files, units.FormatSize(result.Bytes),
+			cmd.Source, cmd.Destination, time.Since(startTime).Round(time.Millisecond))
+	}
+	if err != nil {
+		s.failures.addCommand(CommandFailure{Index: 1, Command: cmd.line, Error: err.Error()})
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseCopyCommand(t *testing.T) {
+	cmd, err := ParseCopyCommand([]string{"-r", "a.txt", "dir", "myserver:/srv/app/"})
+	if err != nil {
+		t.Fatalf("ParseCopyCommand(upload) error = %v", err)
+	}
+	if !cmd.Upload || cmd.Destination != "myserver" || cmd.target != "/srv/app/" ||
+		!cmd.opts.recursive || !slices.Equal(cmd.opts.sources, []string{"a.txt", "dir"}) {
+		t.Fatalf("ParseCopyCommand(upload) = %+v, opts %+v", cmd, cmd.opts)
+	}
+
+	cmd, err = ParseCopyCommand([]string{"user@host:2222:logs/a.log", "user@host:2222:/var/b.log", "--overwrite", "never", "out"})
+	if err != nil {
+		t.Fatalf("ParseCopyCommand(download) error = %v", err)
+	}
+	if cmd.Upload || cmd.Destination != "user@host:2222" || cmd.target != "out" ||
+		!slices.Equal(cmd.opts.sources, []string{"logs/a.log", "/var/b.log"}) {
+		t.Fatalf("ParseCopyCommand(download) = %+v, opts %+v", cmd, cmd.opts)
+	}
+
+	cmd, err = ParseCopyCommand([]string{"-r", "-p", "web1:/srv/site", "web2:/srv/"})
+	if err != nil {
+		t.Fatalf("ParseCopyCommand(relay) error = %v", err)
+	}
+	if cmd.Source != "web1" || cmd.Destination != "web2" || cmd.target != "/srv/" ||
+		!cmd.opts.recursive || !cmd.opts.preserve || !slices.Equal(cmd.opts.sources, []string{"/srv/site"}) {
+		t.Fatalf("ParseCopyCommand(relay) = %+v, opts %+v", cmd, cmd.opts)
+	}
+
+	for _, args := range [][]string{
+		{"a.txt"},
+		{"a.txt", "b.txt"},
+		{"host:a.txt", "web1:b.txt", "other:out"},
+		{"host:a.txt", "local.txt", "other:out"},
+		{"--chunked", "1M", "host:a.txt", "other:out"},
+		{"host:a.txt", "other:b.txt", "out"},
+		{"host:a.txt", "local.txt", "out"},
+		{"host:", "out"},
+		{"a.txt", "-d", "dir", "host:/srv"},
+		{"a.txt", "--name", "b.txt", "host:/srv"},
+	} {
+		if _, err := ParseCopyCommand(args); err == nil {
+			t.Fatalf("ParseCopyCommand(%q) expected error", args)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/units"
+)
+
+// SetJSONOutput 设置会话的输出格式：开启后 ls、stat、df 和传输摘要输出 JSON（同 set output json）
+func (s *Shell) SetJSONOutput(on bool) {
+	s.jsonOutput = on
+}
+
+// cmdSet 查看或修改会话设置：set [output json|text] / set [hash ALGO] / set [backslash convert|keep]
+func (s *Shell) cmdSet(args []string) error {
+	if len(args) == 0 {
+		output := "text"
+		if s.jsonOutput {
+			output = "json"
+		}
+		hash := s.hash
+		if hash == "" {
+			hash = client.DefaultHashAlgorithm
+		}
+		backslash := "keep"
+		if s.backslashes {
+			backslash = "convert"
+		}
+		fmt.Printf("output %s\nhash %s\nbackslash %s\nunits %s\nbuffer %s\n", output, hash, backslash,
+			units.CurrentStyle(), units.FormatSize(int64(s.client.BufferSize())))
+		return nil
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("usage: set [output json|text] | set [hash %s] | set [backslash convert|keep] | set [units %s] | set [buffer SIZE]",
+			strings.Join(client.HashAlgorithmNames(), "|"), strings.Join(units.StyleNames, "|"))
+	}
+	switch args[0] {
+	case "output":
+		switch args[1] {
+		case "json":
+			s.jsonOutput = true
+		case "text":
+			s.jsonOutput = false
+		default:
+			return fmt.Errorf("unknown output format: %s (want json or text)", args[1])
+		}
+	case "hash":
+		alg, err := client.LookupHashAlgorithm(args[1])
+		if err != nil {
+			return err
+		}
+		s.hash = alg.Name
+	case "backslash":
+		switch args[1] {
+		case "convert":
+			s.backslashes = true
+		case "keep":
+			s.backslashes = false
+		default:
+			return fmt.Errorf("unknown backslash mode: %s (want convert or keep)", args[1])
+		}
+	case "units":
+		style, err := units.ParseStyle(args[1])
+		if err != nil {
+			return err
+		}
+		units.SetStyle(style)
+	case "buffer":
+		size, err := units.ParseSize(args[1])
+		if err != nil || size <= 0 || size > client.MaxBufferSize {
+			return fmt.Errorf("invalid buffer size: %s (e.g. 512K or 1M, at most 64M)", args[1])
+		}
+		s.client.SetBufferSize(int(size))
+	default:
+		return fmt.Errorf("unknown setting: %s (want output, hash, backslash, units or buffer)", args[0])
+	}
+	return nil
+}
+
+// jsonFlag 去掉 args 中的 --json，返回剩余参数以及本次命令是否输出 JSON（--json 或 set output json）
+func (s *Shell) jsonFlag(args []string) ([]string, bool) {
+	rest := slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == "--json" })
+	return rest, s.jsonOutput || len(rest) != len(args)
+}
+
+// statusOutput 批处理的命令回显和错误信息的输出位置：输出 JSON 时为 stderr，stdout 只有 JSON
+func (s *Shell) statusOutput() io.Writer {
+	if s.jsonOutput {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// infoOutput 命令说明信息（如同步计划、冲突）的输出位置：后台任务写入任务日志（status 时显示），
+// 输出 JSON 时为 stderr，stdout 只有 JSON
+func (s *Shell) infoOutput(jsonOut bool) io.Writer {
+	switch {
+	case s.job != nil:
+		return s.job
+	case jsonOut:
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// muteTransferEvents 在前台传输期间不逐个显示完成的文件，返回恢复函数
+func (s *Shell) muteTransferEvents() func() {
+	s.quietEvents = true
+	return func() { s.quietEvents = false }
+}
+
+// writeJSON 把 v 作为一行 JSON 输出，多条命令的结果可以逐行解析
+func writeJSON(v any) error {
+	return json.NewEncoder(os.Stdout).Encode(v)
+}
+
+// lsEntryJSON ls/stat 的 JSON 输出：导出条目加上名称和符号链接目标
+type lsEntryJSON struct {
+	Name string `json:"name"`
+	client.ExportEntry
+	Target string `json:"target,omitempty"`
+}
+
+// lsJSON ls --json 的输出
+type lsJSON struct {
+	Dir     string        `json:"dir"`
+	Entries []lsEntryJSON `json:"entries"`
+}
+
+// lsEntry 构造 p（已解析的远程路径）的 JSON 条目
+func (s *Shell) lsEntry(p string, info os.FileInfo) (lsEntryJSON, error) {
+	entry := lsEntryJSON{Name: path.Base(p), ExportEntry: client.NewExportEntry(p, info)}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := s.client.ReadLink(p)
+		if err != nil {
+			return entry, err
+		}
+		entry.Target = target
+	}
+	return entry, nil
+}
+
+// writeLsJSON 输出 ls --json；读取不到目标的符号链接省略 target
+func (s *Shell) writeLsJSON(dir string, files []os.FileInfo) error {
+	out := lsJSON{Dir: s.client.ResolveRemotePath(dir), Entries: make([]lsEntryJSON, 0, len(files))}
+	for _, file := range files {
+		entry, _ := s.lsEntry(path.Join(out.Dir, file.Name()), file)
+		out.Entries = append(out.Entries, entry)
+	}
+	return writeJSON(out)
+}
+
+// dfJSON df --json 的一条输出；文件系统不报告 inode 时 inodes_use_percent 为 null
+type dfJSON struct {
+	Path             string `json:"path"`
+	Total            uint64 `json:"total"`
+	Used             uint64 `json:"used"`
+	Available        uint64 `json:"available"`
+	UsePercent       int    `json:"use_percent"`
+	Inodes           uint64 `json:"inodes"`
+	InodesUsed       uint64 `json:"inodes_used"`
+	InodesFree       uint64 `json:"inodes_free"`
+	InodesUsePercent *int   `json:"inodes_use_percent"`
+}
+
+func newDfJSON(usage *client.DiskUsage) dfJSON {
+	out := dfJSON{
+		Path:       usage.Path,
+		Total:      usage.Total,
+		Used:       usage.Used,
+		Available:  usage.Available,
+		UsePercent: usage.UsePercent(),
+		Inodes:     usage.Inodes,
+		InodesUsed: usage.InodesUsed(),
+		InodesFree: usage.InodesFree,
+	}
+	if percent := usage.InodesUsePercent(); percent >= 0 {
+		out.InodesUsePercent = &percent
+	}
+	return out
+}
+
+// transferJSON get/put/sync 完成后的 JSON 摘要
+type transferJSON struct {
+	Command    string   `json:"command"`
+	Files      int      `json:"files"`
+	Target     string   `json:"target,omitempty"`
+	New        *int     `json:"new,omitempty"` // 以下仅 sync
+	Updated    *int     `json:"updated,omitempty"`
+	Unchanged  *int     `json:"unchanged,omitempty"`
+	Conflicts  []string `json:"conflicts,omitempty"`
+	Skipped    []string `json:"skipped,omitempty"` // get/put --on-denied skip 跳过的路径
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// bisyncJSON sync --bidirectional 完成后的 JSON 摘要
+type bisyncJSON struct {
+	Command       string   `json:"command"`
+	Mode          string   `json:"mode"`
+	Uploaded      int      `json:"uploaded"`
+	Downloaded    int      `json:"downloaded"`
+	DeletedRemote int      `json:"deleted_remote"`
+	DeletedLocal  int      `json:"deleted_local"`
+	Unchanged     int      `json:"unchanged"`
+	Conflicts     []string `json:"conflicts"`
+	Skipped       []string `json:"skipped,omitempty"`
+	DurationMs    int64    `json:"duration_ms"`
+}
+
+// reportJSON 输出传输摘要；在后台任务中记录下来，任务结束时显示
+func (s *Shell) reportJSON(summary any) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	s.report("%s", data)
+	return nil
+}
@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+func TestJSONFlag(t *testing.T) {
+	s := &Shell{}
+	args, on := s.jsonFlag([]string{"--json", "/srv", "/var"})
+	if !on || !slices.Equal(args, []string{"/srv", "/var"}) {
+		t.Fatalf("jsonFlag(--json) = %q, %v", args, on)
+	}
+	if _, on := s.jsonFlag([]string{"/srv"}); on {
+		t.Fatalf("jsonFlag without --json should be off")
+	}
+	if err := s.cmdSet([]string{"output", "json"}); err != nil {
+		t.Fatalf("set output json: %v", err)
+	}
+	if _, on := s.jsonFlag([]string{"/srv"}); !on {
+		t.Fatalf("jsonFlag should follow set output json")
+	}
+	if err := s.cmdSet([]string{"output", "yaml"}); err == nil {
+		t.Fatalf("set output yaml expected error")
+	}
+}
+
+func TestDfJSONWithoutInodes(t *testing.T) {
+	data, err := json.Marshal(newDfJSON(&client.DiskUsage{Path: "/srv", Total: 100, Used: 30, Available: 70}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{`"use_percent":30`, `"inodes_use_percent":null`} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("df JSON %s missing %s", got, want)
+		}
+	}
+}
+
+func TestParseLsCLIArgsJSON(t *testing.T) {
+	opts, err := parseLsCLIArgs([]string{"--json", "/srv"})
+	if err != nil || !opts.json || opts.dir != "/srv" {
+		t.Fatalf("parseLsCLIArgs(--json) = %+v, %v", opts, err)
+	}
+	if _, err := parseLsCLIArgs([]string{"--json", "--export", "out.json"}); err == nil {
+		t.Fatalf("--json with --export expected error")
+	}
+}
@@ -0,0 +1,72 @@
+package shell
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/units"
+)
+
+// cmdOverwrite 显示或设置 get/put 默认的覆盖策略
+func (s *Shell) cmdOverwrite(args []string) error {
+	if len(args) == 0 {
+		fmt.Printf("overwrite: %s\n", s.overwrite)
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: overwrite [always|never|if-newer|if-different-size|ask]")
+	}
+	policy, err := client.ParseOverwritePolicy(args[0])
+	if err != nil {
+		return err
+	}
+	s.overwrite = policy
+	fmt.Printf("✓ overwrite: %s\n", policy)
+	return nil
+}
+
+// askOverwrite 是 ask 策略的询问回调：显示两端的大小和修改时间，读取用户的选择
+func (s *Shell) askOverwrite(conflict client.OverwriteConflict) client.OverwriteAnswer {
+	srcSide, destSide := "remote", "local"
+	if conflict.IsUpload {
+		srcSide, destSide = "local", "remote"
+	}
+	fmt.Printf("%s already exists\n", conflict.Destination)
+	fmt.Printf("  %-7s %10s  %s\n", srcSide+":", units.FormatSize(conflict.SourceSize), formatConflictTime(conflict.SourceMod))
+	fmt.Printf("  %-7s %10s  %s\n", destSide+":", units.FormatSize(conflict.DestSize), formatConflictTime(conflict.DestMod))
+
+	for {
+		answer, err := s.readAnswer("Overwrite? [y]es, [n]o, [a]ll, none, [q]uit: ")
+		if err != nil {
+			return client.OverwriteQuit
+		}
+		if result, ok := parseOverwriteAnswer(answer); ok {
+			return result
+		}
+	}
+}
+
+// parseOverwriteAnswer 解析覆盖询问的回答（已转为小写）
+func parseOverwriteAnswer(answer string) (client.OverwriteAnswer, bool) {
+	switch answer {
+	case "y", "yes":
+		return client.OverwriteYes, true
+	case "n", "no":
+		return client.OverwriteNo, true
+	case "a", "all":
+		return client.OverwriteAll, true
+	case "none":
+		return client.OverwriteNone, true
+	case "q", "quit":
+		return client.OverwriteQuit, true
+	}
+	return 0, false
+}
+
+func formatConflictTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
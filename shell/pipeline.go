@@ -0,0 +1,443 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/frostime/my-sftp/lexer"
+)
+
+// maxPipelineLine 单行最大长度，超长的行会导致读取失败
+const maxPipelineLine = 4 * 1024 * 1024
+
+// textCommands 可以出现在管道中的内置文本命令
+var textCommands = map[string]bool{
+	"cat": true, "grep": true, "head": true, "tail": true, "sort": true, "uniq": true,
+}
+
+// lineFilter 管道中的一个处理阶段
+type lineFilter func(iter.Seq[string]) iter.Seq[string]
+
+// pipelineStage 一个已解析的管道阶段；files 仅在第一个阶段有效
+type pipelineStage struct {
+	name   string
+	filter lineFilter
+	files  []string
+}
+
+// splitPipeline 按未加引号的独立 "|" 切分命令行；没有管道时返回 nil
+func splitPipeline(line string) [][]string {
+	tokens, _ := lexer.Lex(line)
+	var stages [][]string
+	var current []string
+	hasPipe := false
+	for _, tok := range tokens {
+		if tok.Raw(line) == "|" {
+			hasPipe = true
+			stages = append(stages, current)
+			current = nil
+			continue
+		}
+		current = append(current, tok.Value)
+	}
+	if !hasPipe {
+		return nil
+	}
+	return append(stages, current)
+}
+
+// parsePipeline 解析所有阶段：第一个阶段从远程文件读取，其余阶段只处理上游输出
+func parsePipeline(stages [][]string) ([]pipelineStage, error) {
+	parsed := make([]pipelineStage, 0, len(stages))
+	for i, fields := range stages {
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("empty command in pipeline")
+		}
+		if !textCommands[fields[0]] {
+			return nil, fmt.Errorf("%s cannot be used in a pipeline (supported: cat, grep, head, tail, sort, uniq)", fields[0])
+		}
+		stage, err := parseTextCommand(fields[0], fields[1:])
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 && len(stage.files) == 0 {
+			return nil, fmt.Errorf("%s: missing remote file", stage.name)
+		}
+		if i > 0 && len(stage.files) > 0 {
+			return nil, fmt.Errorf("%s: file arguments are only allowed in the first command of a pipeline", stage.name)
+		}
+		parsed = append(parsed, stage)
+	}
+	return parsed, nil
+}
+
+// parseTextCommand 解析单个内置文本命令
+func parseTextCommand(name string, args []string) (pipelineStage, error) {
+	stage := pipelineStage{name: name}
+	switch name {
+	case "cat":
+		stage.filter = func(in iter.Seq[string]) iter.Seq[string] { return in }
+		stage.files = args
+
+	case "grep":
+		var ignoreCase, invert, number bool
+		var rest []string
+		for _, arg := range args {
+			flags, ok := shortFlags(arg, "ivn")
+			if len(rest) > 0 || !strings.HasPrefix(arg, "-") {
+				rest = append(rest, arg)
+				continue
+			}
+			if !ok {
+				return stage, fmt.Errorf("grep: unknown option: %s", arg)
+			}
+			ignoreCase = ignoreCase || strings.Contains(flags, "i")
+			invert = invert || strings.Contains(flags, "v")
+			number = number || strings.Contains(flags, "n")
+		}
+		if len(rest) == 0 {
+			return stage, fmt.Errorf("usage: grep [-i] [-v] [-n] <regexp> [file|glob...]")
+		}
+		expr := rest[0]
+		if ignoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return stage, fmt.Errorf("grep: %w", err)
+		}
+		stage.filter = grepFilter(re, invert, number)
+		stage.files = rest[1:]
+
+	case "head", "tail":
+		n, files, err := parseLineCount(name, args)
+		if err != nil {
+			return stage, err
+		}
+		stage.files = files
+		if name == "head" {
+			stage.filter = headFilter(n)
+		} else {
+			stage.filter = tailFilter(n)
+		}
+
+	case "sort":
+		var reverse, numeric, unique bool
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				stage.files = append(stage.files, arg)
+				continue
+			}
+			flags, ok := shortFlags(arg, "rnu")
+			if !ok {
+				return stage, fmt.Errorf("sort: unknown option: %s", arg)
+			}
+			reverse = reverse || strings.Contains(flags, "r")
+			numeric = numeric || strings.Contains(flags, "n")
+			unique = unique || strings.Contains(flags, "u")
+		}
+		stage.filter = sortFilter(reverse, numeric, unique)
+
+	case "uniq":
+		count := false
+		for _, arg := range args {
+			switch {
+			case arg == "-c":
+				count = true
+			case strings.HasPrefix(arg, "-"):
+				return stage, fmt.Errorf("uniq: unknown option: %s", arg)
+			default:
+				stage.files = append(stage.files, arg)
+			}
+		}
+		stage.filter = uniqFilter(count)
+	}
+	return stage, nil
+}
+
+// shortFlags 解析组合短选项（如 -rn），所有字母都在 allowed 中时返回这些字母
+func shortFlags(arg, allowed string) (string, bool) {
+	if len(arg) < 2 || arg[0] != '-' {
+		return "", false
+	}
+	for _, r := range arg[1:] {
+		if !strings.ContainsRune(allowed, r) {
+			return "", false
+		}
+	}
+	return arg[1:], true
+}
+
+// parseLineCount 解析 head/tail 的行数：N、-N 或 -n N，默认 10
+func parseLineCount(name string, args []string) (int, []string, error) {
+	n := 10
+	var files []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		switch {
+		case arg == "-n":
+			i++
+			if i >= len(args) {
+				return 0, nil, fmt.Errorf("%s: missing value for -n", name)
+			}
+			value = args[i]
+		case strings.HasPrefix(arg, "-"):
+			value = arg[1:]
+		case len(files) == 0 && isDigits(arg):
+			value = arg
+		default:
+			files = append(files, arg)
+			continue
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return 0, nil, fmt.Errorf("%s: invalid line count: %s", name, arg)
+		}
+		n = count
+	}
+	return n, files, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func grepFilter(re *regexp.Regexp, invert, number bool) lineFilter {
+	return func(in iter.Seq[string]) iter.Seq[string] {
+		return func(yield func(string) bool) {
+			lineNo := 0
+			for line := range in {
+				lineNo++
+				if re.MatchString(line) == invert {
+					continue
+				}
+				if number {
+					line = strconv.Itoa(lineNo) + ":" + line
+				}
+				if !yield(line) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func headFilter(n int) lineFilter {
+	return func(in iter.Seq[string]) iter.Seq[string] {
+		return func(yield func(string) bool) {
+			if n == 0 {
+				return
+			}
+			taken := 0
+			for line := range in {
+				if !yield(line) {
+					return
+				}
+				if taken++; taken >= n {
+					return
+				}
+			}
+		}
+	}
+}
+
+func tailFilter(n int) lineFilter {
+	return func(in iter.Seq[string]) iter.Seq[string] {
+		return func(yield func(string) bool) {
+			if n == 0 {
+				return
+			}
+			// 环形缓冲区只保留最后 n 行
+			ring := make([]string, 0, n)
+			next := 0
+			for line := range in {
+				if len(ring) < n {
+					ring = append(ring, line)
+					continue
+				}
+				ring[next] = line
+				next = (next + 1) % n
+			}
+			for i := range ring {
+				if !yield(ring[(next+i)%len(ring)]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func sortFilter(reverse, numeric, unique bool) lineFilter {
+	return func(in iter.Seq[string]) iter.Seq[string] {
+		return func(yield func(string) bool) {
+			var lines []string
+			for line := range in {
+				lines = append(lines, line)
+			}
+			less := func(a, b string) bool { return a < b }
+			if numeric {
+				// 与 sort -n 一致：按行首数字比较，无法解析的视为 0
+				less = func(a, b string) bool {
+					na, nb := leadingNumber(a), leadingNumber(b)
+					if na != nb {
+						return na < nb
+					}
+					return a < b
+				}
+			}
+			sort.SliceStable(lines, func(i, j int) bool {
+				if reverse {
+					return less(lines[j], lines[i])
+				}
+				return less(lines[i], lines[j])
+			})
+			for i, line := range lines {
+				if unique && i > 0 && line == lines[i-1] {
+					continue
+				}
+				if !yield(line) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func leadingNumber(s string) float64 {
+	s = strings.TrimLeft(s, " \t")
+	end := 0
+	for end < len(s) && (s[end] == '-' && end == 0 || s[end] == '.' || s[end] >= '0' && s[end] <= '9') {
+		end++
+	}
+	n, _ := strconv.ParseFloat(s[:end], 64)
+	return n
+}
+
+func uniqFilter(count bool) lineFilter {
+	return func(in iter.Seq[string]) iter.Seq[string] {
+		return func(yield func(string) bool) {
+			prev, n := "", 0
+			emit := func() bool {
+				if n == 0 {
+					return true
+				}
+				if count {
+					return yield(fmt.Sprintf("%7d %s", n, prev))
+				}
+				return yield(prev)
+			}
+			for line := range in {
+				if n > 0 && line == prev {
+					n++
+					continue
+				}
+				if !emit() {
+					return
+				}
+				prev, n = line, 1
+			}
+			emit()
+		}
+	}
+}
+
+// readLines 逐行读取；读取错误保存在 *errp 中
+func readLines(r io.Reader, errp *error) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxPipelineLine)
+		for scanner.Scan() {
+			if !yield(strings.TrimSuffix(scanner.Text(), "\r")) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && *errp == nil {
+			*errp = err
+		}
+	}
+}
+
+// remoteLines 依次读取多个远程文件的行；prefix 为 true 时每行加 "path:" 前缀（多文件 grep）
+func (s *Shell) remoteLines(files []string, filter lineFilter, prefix bool, errp *error) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, file := range files {
+			if *errp != nil {
+				return
+			}
+			f, err := s.client.OpenRemoteFile(file)
+			if err != nil {
+				*errp = fmt.Errorf("%s: %w", file, err)
+				return
+			}
+			lines := readLines(f, errp)
+			if filter != nil {
+				lines = filter(lines)
+			}
+			stopped := false
+			for line := range lines {
+				if prefix {
+					line = file + ":" + line
+				}
+				if !yield(line) {
+					stopped = true
+					break
+				}
+			}
+			f.Close()
+			if stopped {
+				return
+			}
+		}
+	}
+}
+
+// runPipeline 在本地执行内置文本命令管道，数据通过 SFTP 流式读取，不依赖远程 exec
+func (s *Shell) runPipeline(stages [][]string, out io.Writer) error {
+	parsed, err := parsePipeline(stages)
+	if err != nil {
+		return err
+	}
+
+	var files []string
+	for _, pattern := range parsed[0].files {
+		matches, err := s.client.ExpandRemoteFiles(pattern)
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+
+	var readErr error
+	var lines iter.Seq[string]
+	if parsed[0].name == "grep" && len(files) > 1 {
+		// 与 grep 一致：多文件时逐文件匹配并加文件名前缀
+		lines = s.remoteLines(files, parsed[0].filter, true, &readErr)
+	} else {
+		lines = parsed[0].filter(s.remoteLines(files, nil, false, &readErr))
+	}
+	for _, stage := range parsed[1:] {
+		lines = stage.filter(lines)
+	}
+
+	w := bufio.NewWriter(out)
+	for line := range lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return readErr
+}
@@ -0,0 +1,84 @@
+package shell
+
+import (
+	"iter"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSplitPipeline(t *testing.T) {
+	got := splitPipeline(`grep "a | b" app.log | sort | uniq -c`)
+	want := [][]string{{"grep", "a | b", "app.log"}, {"sort"}, {"uniq", "-c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitPipeline() = %q, want %q", got, want)
+	}
+	if got := splitPipeline(`grep '|' app.log`); got != nil {
+		t.Fatalf("quoted pipe split: %q", got)
+	}
+	if got := splitPipeline(`! ps aux | grep sshd`); got == nil {
+		t.Fatal("expected stages for unquoted pipe")
+	}
+}
+
+func TestParsePipelineErrors(t *testing.T) {
+	for _, stages := range [][][]string{
+		{{"sort"}},
+		{{"cat", "a.log"}, {"ls"}},
+		{{"cat", "a.log"}, {"sort", "b.log"}},
+		{{"cat", "a.log"}, {}},
+		{{"grep", "(", "a.log"}},
+		{{"head", "-n", "x", "a.log"}},
+	} {
+		if _, err := parsePipeline(stages); err == nil {
+			t.Fatalf("parsePipeline(%q) expected error", stages)
+		}
+	}
+}
+
+func runFilters(t *testing.T, input []string, stages ...[]string) []string {
+	t.Helper()
+	var lines iter.Seq[string] = slices.Values(input)
+	for _, fields := range stages {
+		stage, err := parseTextCommand(fields[0], fields[1:])
+		if err != nil {
+			t.Fatalf("parseTextCommand(%q) error = %v", fields, err)
+		}
+		lines = stage.filter(lines)
+	}
+	return slices.Collect(lines)
+}
+
+func TestTextFilters(t *testing.T) {
+	log := []string{"ERROR disk", "INFO ok", "ERROR net", "error disk", "ERROR disk", "WARN x"}
+
+	tests := []struct {
+		stages [][]string
+		want   []string
+	}{
+		{[][]string{{"grep", "ERROR"}}, []string{"ERROR disk", "ERROR net", "ERROR disk"}},
+		{[][]string{{"grep", "-i", "-n", "error d"}}, []string{"1:ERROR disk", "4:error disk", "5:ERROR disk"}},
+		{[][]string{{"grep", "-v", "ERROR"}}, []string{"INFO ok", "error disk", "WARN x"}},
+		{[][]string{{"grep", "ERROR"}, {"sort"}, {"uniq", "-c"}}, []string{"      2 ERROR disk", "      1 ERROR net"}},
+		{[][]string{{"grep", "ERROR"}, {"sort"}, {"uniq", "-c"}, {"sort", "-rn"}, {"head", "1"}}, []string{"      2 ERROR disk"}},
+		{[][]string{{"sort", "-u"}}, []string{"ERROR disk", "ERROR net", "INFO ok", "WARN x", "error disk"}},
+		{[][]string{{"head", "-n", "2"}}, []string{"ERROR disk", "INFO ok"}},
+		{[][]string{{"tail", "-2"}}, []string{"ERROR disk", "WARN x"}},
+		{[][]string{{"tail", "0"}}, nil},
+		{[][]string{{"uniq"}}, log},
+	}
+	for _, tt := range tests {
+		got := runFilters(t, log, tt.stages...)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%q = %q, want %q", tt.stages, got, tt.want)
+		}
+	}
+}
+
+func TestSortNumeric(t *testing.T) {
+	got := runFilters(t, []string{"10 a", "9 b", "-1 c", "x"}, []string{"sort", "-n"})
+	want := []string{"-1 c", "x", "9 b", "10 a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sort -n = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package shell
+
+import "fmt"
+
+const projectUsage = "usage: project [show] | project link <local-dir> <remote-dir> | project unlink"
+
+// SetProject 设置本地与远程目录的映射（来自主机 profile 的 ProjectLink）
+func (s *Shell) SetProject(localDir, remoteDir string) error {
+	link, err := s.client.LinkProject(localDir, remoteDir)
+	if err != nil {
+		return err
+	}
+	s.project = link
+	return nil
+}
+
+// cmdProject 查看或修改 project 映射
+func (s *Shell) cmdProject(args []string) error {
+	if len(args) == 0 {
+		args = []string{"show"}
+	}
+	switch args[0] {
+	case "show":
+		if len(args) != 1 {
+			return fmt.Errorf(projectUsage)
+		}
+		if s.project == nil {
+			fmt.Println("No project linked (project link <local-dir> <remote-dir>)")
+			return nil
+		}
+		fmt.Printf("Project: %s <-> %s\n", s.project.Local, s.project.Remote)
+	case "link":
+		if len(args) != 3 {
+			return fmt.Errorf(projectUsage)
+		}
+		if err := s.SetProject(args[1], args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Linked %s <-> %s\n", s.project.Local, s.project.Remote)
+		fmt.Println("  get/put without -d keep paths relative to the link; sync without arguments syncs the whole project")
+	case "unlink":
+		if len(args) != 1 {
+			return fmt.Errorf(projectUsage)
+		}
+		if s.project == nil {
+			return fmt.Errorf("no project linked")
+		}
+		fmt.Printf("✓ Unlinked %s <-> %s\n", s.project.Local, s.project.Remote)
+		s.project = nil
+	default:
+		return fmt.Errorf(projectUsage)
+	}
+	return nil
+}
+
+// projectTargets 为没有 -d/--name 的 get/put 计算每个 source 在 project 映射下的目标目录。
+// 没有映射或 source 都在映射之外时返回 nil（按普通规则传输）；只有部分 source 在映射内时报错
+func (s *Shell) projectTargets(opts *transferCLIOptions, upload bool) ([]string, error) {
+	if s.project == nil || opts.targetDir != "" || opts.rename != "" {
+		return nil, nil
+	}
+	targets := make([]string, len(opts.sources))
+	inside := 0
+	var outside string
+	for i, source := range opts.sources {
+		var ok bool
+		if upload {
+			targets[i], ok = s.client.ProjectUploadTarget(s.project, source)
+		} else {
+			targets[i], ok = s.client.ProjectDownloadTarget(s.project, source)
+		}
+		if ok {
+			inside++
+		} else {
+			outside = source
+		}
+	}
+	switch {
+	case inside == 0:
+		return nil, nil
+	case outside != "":
+		return nil, fmt.Errorf("%s is outside the linked project; use -d or transfer it separately", outside)
+	}
+	return targets, nil
+}
+
+// eachProjectSource 逐个传输 source 到 project 映射下的目标目录。
+// 多个 source 一次传输时会在目标下保留各自的相对路径，所以分开执行
+func (s *Shell) eachProjectSource(opts *transferCLIOptions, targets []string, run func(*transferCLIOptions) error) error {
+	for i, source := range opts.sources {
+		single := *opts
+		single.sources = []string{source}
+		single.targetDir = targets[i]
+		if !opts.listOnly || opts.listFile != "" {
+			fmt.Printf("ℹ Project: %s -> %s\n", source, targets[i])
+		}
+		if err := run(&single); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectSyncDirs 返回不带参数的 sync 使用的目录对
+func (s *Shell) projectSyncDirs() (local, remote string, err error) {
+	if s.project == nil {
+		return "", "", fmt.Errorf("usage: sync [--reverse] [--dry-run] <local-dir> <remote-dir> (directories may be omitted after project link)")
+	}
+	return s.project.Local, s.project.Remote, nil
+}
@@ -0,0 +1,298 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frostime/my-sftp/ttystate"
+)
+
+// promptRefreshInterval 提示符指示器的刷新间隔
+const promptRefreshInterval = 500 * time.Millisecond
+
+// PromptStyle 提示符中路径的显示方式（来自主机 profile，可用 prompt 命令在会话中修改）
+type PromptStyle struct {
+	AbbrevHome bool   // 主目录显示为 ~
+	Depth      int    // 大于 0 时只显示路径的最后 Depth 级
+	ShowLocal  bool   // 在提示符上方单独一行显示本地工作目录
+	Color      string // 路径的颜色（promptColors 中的名字），空为默认的绿色
+}
+
+// promptColors 提示符可用的颜色名和对应的 ANSI 代码，用于区分生产环境等会话
+var promptColors = map[string]string{
+	"red": "31", "green": "32", "yellow": "33", "blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+}
+
+// ParsePromptColor 校验提示符颜色名（不区分大小写），返回小写的名字；default 和空串表示默认颜色
+func ParsePromptColor(name string) (string, error) {
+	name = strings.ToLower(name)
+	if name == "" || name == "default" {
+		return "", nil
+	}
+	if _, ok := promptColors[name]; !ok {
+		return "", fmt.Errorf("unknown color: %s (want red, green, yellow, blue, magenta, cyan, white or default)", name)
+	}
+	return name, nil
+}
+
+// SetPromptStyle 设置提示符中路径的显示方式
+func (s *Shell) SetPromptStyle(style PromptStyle) {
+	s.promptStyle = style
+}
+
+// SetColor 设置提示符是否使用 ANSI 颜色
+func (s *Shell) SetColor(on bool) {
+	s.noColor = !on
+}
+
+// SetBanner 设置进入交互式 Shell 时显示的醒目横幅（如 PRODUCTION），空串表示不显示
+func (s *Shell) SetBanner(text string) {
+	s.banner = text
+}
+
+// bannerLine 横幅行：用提示符颜色反色显示；关闭颜色时用 === 包围
+func (s *Shell) bannerLine() string {
+	if s.banner == "" {
+		return ""
+	}
+	if s.noColor {
+		return "=== " + s.banner + " ==="
+	}
+	return s.paint("1;7;"+s.promptColorCode(), "  "+s.banner+"  ")
+}
+
+// promptColorCode 提示符路径的 ANSI 颜色代码
+func (s *Shell) promptColorCode() string {
+	if code, ok := promptColors[s.promptStyle.Color]; ok {
+		return code
+	}
+	return "32"
+}
+
+// paint 用 ANSI 颜色 code 包裹 text；关闭颜色时原样返回
+func (s *Shell) paint(code, text string) string {
+	if s.noColor {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+// prompt 构造当前提示符：[传输指示器] 远程工作目录 >
+func (s *Shell) prompt() string {
+	indicator := transferIndicator(s.client.ActiveTransfers())
+	if indicator != "" {
+		indicator = s.paint("33", indicator) + " "
+	}
+	// SFTP 仍在后台启动时还不知道工作目录，而初始目录就是主目录
+	cwd := "~"
+	if s.client.Ready() {
+		cwd = s.displayRemotePath(s.client.Getwd())
+	}
+	return fmt.Sprintf("%s%s > ", indicator, s.paint(s.promptColorCode(), cwd))
+}
+
+// localPromptLine 两行提示符的第一行（本地工作目录）；未启用时返回空串。
+// readline 只支持单行提示符，所以这一行在读取输入前单独打印
+func (s *Shell) localPromptLine() string {
+	if !s.promptStyle.ShowLocal {
+		return ""
+	}
+	return s.paint("36", "local: "+s.displayLocalPath(s.client.GetLocalwd()))
+}
+
+func (s *Shell) displayRemotePath(p string) string {
+	home := ""
+	if s.promptStyle.AbbrevHome {
+		home = s.client.HomeDir()
+	}
+	return shortenPath(p, home, s.promptStyle.Depth)
+}
+
+func (s *Shell) displayLocalPath(p string) string {
+	home := ""
+	if s.promptStyle.AbbrevHome {
+		if dir, err := os.UserHomeDir(); err == nil {
+			home = filepath.ToSlash(dir)
+		}
+	}
+	return filepath.FromSlash(shortenPath(filepath.ToSlash(p), home, s.promptStyle.Depth))
+}
+
+// shortenPath 按显示设置缩短以 / 分隔的路径：home 非空时把主目录替换为 ~，
+// depth 大于 0 时只保留最后 depth 级，省略的部分显示为 …
+func shortenPath(p, home string, depth int) string {
+	root := ""
+	rest := p
+	switch {
+	case home != "" && home != "/" && (p == home || strings.HasPrefix(p, strings.TrimSuffix(home, "/")+"/")):
+		root, rest = "~", strings.TrimPrefix(p[len(strings.TrimSuffix(home, "/")):], "/")
+	case strings.HasPrefix(p, "/"):
+		root, rest = "/", strings.TrimPrefix(p, "/")
+	}
+	if depth <= 0 || rest == "" {
+		return joinDisplayPath(root, rest)
+	}
+
+	parts := strings.Split(rest, "/")
+	if len(parts) <= depth {
+		return joinDisplayPath(root, rest)
+	}
+	return "…/" + strings.Join(parts[len(parts)-depth:], "/")
+}
+
+func joinDisplayPath(root, rest string) string {
+	switch {
+	case rest == "":
+		return root
+	case root == "" || root == "/":
+		return root + rest
+	}
+	return root + "/" + rest
+}
+
+// cmdPrompt 显示或修改提示符的显示方式：prompt [home on|off] [depth N] [local on|off] [color NAME]
+func (s *Shell) cmdPrompt(args []string) error {
+	const usage = "usage: prompt [home on|off] [depth N] [local on|off] [color NAME]"
+	if len(args)%2 != 0 {
+		return fmt.Errorf(usage)
+	}
+	style := s.promptStyle
+	for i := 0; i < len(args); i += 2 {
+		key, value := args[i], args[i+1]
+		switch key {
+		case "home", "local":
+			var on bool
+			switch value {
+			case "on", "yes":
+				on = true
+			case "off", "no":
+			default:
+				return fmt.Errorf("invalid value for %s: %s (want on or off)", key, value)
+			}
+			if key == "home" {
+				style.AbbrevHome = on
+			} else {
+				style.ShowLocal = on
+			}
+		case "depth":
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth < 0 {
+				return fmt.Errorf("invalid depth: %s (want a number of path components, 0 for the full path)", value)
+			}
+			style.Depth = depth
+		case "color":
+			color, err := ParsePromptColor(value)
+			if err != nil {
+				return err
+			}
+			style.Color = color
+		default:
+			return fmt.Errorf("unknown prompt setting: %s\n%s", key, usage)
+		}
+	}
+	s.promptStyle = style
+
+	depth := "full path"
+	if style.Depth > 0 {
+		depth = fmt.Sprintf("last %d component(s)", style.Depth)
+	}
+	color := style.Color
+	if color == "" {
+		color = "default"
+	}
+	fmt.Printf("Prompt: home as ~ %s, %s, local cwd line %s, color %s\n", onOff(style.AbbrevHome), depth, onOff(style.ShowLocal), color)
+	return nil
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// transferIndicator 返回紧凑的传输指示器，如 "[2↑ 1↓]"；没有进行中的传输时返回空串
+func transferIndicator(uploads, downloads int) string {
+	var parts []string
+	if uploads > 0 {
+		parts = append(parts, fmt.Sprintf("%d↑", uploads))
+	}
+	if downloads > 0 {
+		parts = append(parts, fmt.Sprintf("%d↓", downloads))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// promptState 是否正在读取命令行（而不是 readAnswer 等嵌套的提示）。
+// Shell 会复制给后台任务，所以通过指针共享
+type promptState struct {
+	mu     sync.Mutex
+	active bool
+}
+
+// set 设置状态并返回之前的状态；update 非 nil 时在持有锁时调用（设置提示符），不会与刷新交错
+func (p *promptState) set(active bool, update func()) bool {
+	if p == nil {
+		if update != nil {
+			update()
+		}
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if update != nil {
+		update()
+	}
+	prev := p.active
+	p.active = active
+	return prev
+}
+
+// refresh 正在读取命令行时调用 update
+func (p *promptState) refresh(update func()) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active {
+		update()
+	}
+}
+
+// startPromptRefresher 在等待输入命令时定期刷新提示符，使指示器随传输完成而更新
+// 返回的函数用于停止刷新
+func (s *Shell) startPromptRefresher() func() {
+	stop := make(chan struct{})
+	go func() {
+		defer ttystate.Guard()
+		ticker := time.NewTicker(promptRefreshInterval)
+		defer ticker.Stop()
+
+		last := s.prompt()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				// readAnswer 等嵌套的提示期间不刷新，否则会把问题换成命令提示符
+				s.atPrompt.refresh(func() {
+					if current := s.prompt(); current != last {
+						last = current
+						s.rl.SetPrompt(current)
+						s.rl.Refresh()
+					}
+				})
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
@@ -0,0 +1,73 @@
+package shell
+
+import "testing"
+
+func TestShortenPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		home  string
+		depth int
+		want  string
+	}{
+		{"/home/alice/projects/site", "", 0, "/home/alice/projects/site"},
+		{"/home/alice/projects/site", "/home/alice", 0, "~/projects/site"},
+		{"/home/alice", "/home/alice", 0, "~"},
+		{"/home/alicia/x", "/home/alice", 0, "/home/alicia/x"},
+		{"/srv/www/app/releases/42", "/home/alice", 2, "…/releases/42"},
+		{"/home/alice/projects/site/src", "/home/alice", 2, "…/site/src"},
+		{"/home/alice/projects", "/home/alice", 2, "~/projects"},
+		{"/srv/www", "", 2, "/srv/www"},
+		{"/", "/", 1, "/"},
+		{"C:/Users/alice/work", "C:/Users/alice", 0, "~/work"},
+	}
+	for _, tt := range tests {
+		if got := shortenPath(tt.path, tt.home, tt.depth); got != tt.want {
+			t.Errorf("shortenPath(%q, %q, %d) = %q, want %q", tt.path, tt.home, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestCmdPromptUpdatesStyle(t *testing.T) {
+	s := &Shell{}
+	if err := s.cmdPrompt([]string{"home", "on", "depth", "3"}); err != nil {
+		t.Fatalf("cmdPrompt() error = %v", err)
+	}
+	if want := (PromptStyle{AbbrevHome: true, Depth: 3}); s.promptStyle != want {
+		t.Fatalf("promptStyle = %+v, want %+v", s.promptStyle, want)
+	}
+	for _, args := range [][]string{{"home"}, {"depth", "-1"}, {"local", "maybe"}, {"color", "on"}} {
+		if err := s.cmdPrompt(args); err == nil {
+			t.Fatalf("cmdPrompt(%q) expected error", args)
+		}
+	}
+	if !s.promptStyle.AbbrevHome || s.promptStyle.Depth != 3 {
+		t.Fatalf("failed cmdPrompt changed the style: %+v", s.promptStyle)
+	}
+}
+
+func TestPromptColorAndBanner(t *testing.T) {
+	s := &Shell{}
+	if err := s.cmdPrompt([]string{"color", "Red"}); err != nil {
+		t.Fatalf("cmdPrompt(color Red) error = %v", err)
+	}
+	if s.promptColorCode() != "31" {
+		t.Fatalf("promptColorCode() = %q, want 31", s.promptColorCode())
+	}
+	if err := s.cmdPrompt([]string{"color", "pink"}); err == nil {
+		t.Fatal("cmdPrompt(color pink) expected error")
+	}
+	if s.bannerLine() != "" {
+		t.Fatalf("bannerLine() without banner = %q", s.bannerLine())
+	}
+	s.SetBanner("PRODUCTION")
+	if want := "\033[1;7;31m  PRODUCTION  \033[0m"; s.bannerLine() != want {
+		t.Errorf("bannerLine() = %q, want %q", s.bannerLine(), want)
+	}
+	s.SetColor(false)
+	if want := "=== PRODUCTION ==="; s.bannerLine() != want {
+		t.Errorf("bannerLine() without color = %q, want %q", s.bannerLine(), want)
+	}
+	if err := s.cmdPrompt([]string{"color", "default"}); err != nil || s.promptColorCode() != "32" {
+		t.Fatalf("cmdPrompt(color default) = %v, code %q", err, s.promptColorCode())
+	}
+}
@@ -0,0 +1,26 @@
+package shell
+
+import (
+	"fmt"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+)
+
+// projectRouter 读取本地工作目录（或其上级）中 .sftp-settings 的 route 规则；没有规则时返回 nil
+func (s *Shell) projectRouter() (*client.Router, error) {
+	settings, err := config.LoadProjectSettings(s.client.GetLocalwd())
+	if err != nil || settings == nil || len(settings.Routes) == 0 {
+		return nil, err
+	}
+	rules := make([]client.RouteRule, len(settings.Routes))
+	for i, route := range settings.Routes {
+		rules[i] = client.RouteRule{Match: route.Match, Dir: route.Dir}
+	}
+	router, err := client.NewRouter(rules)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", settings.Path, err)
+	}
+	fmt.Printf("ℹ Routing uploads by %s (%d rule(s))\n", settings.Path, len(rules))
+	return router, nil
+}
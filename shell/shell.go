@@ -1,6 +1,7 @@
 package shell
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,8 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,85 +18,320 @@ import (
 
 	"github.com/frostime/my-sftp/client"
 	"github.com/frostime/my-sftp/completer"
+	"github.com/frostime/my-sftp/lexer"
+	"github.com/frostime/my-sftp/record"
+	"github.com/frostime/my-sftp/units"
 )
 
 const legacyPositionalTargetCompatibility = true
 
+// maxParallelStreams --parallel 允许的最大流数（OpenSSH 默认 MaxSessions 为 10）
+const maxParallelStreams = 16
+
+// errExit 由 exit/quit 命令返回，交由 Run 处理退出流程
+var errExit = errors.New("exit requested")
+
 type transferCLIOptions struct {
-	recursive bool
-	flatten   bool
-	targetDir string
-	rename    string
-	sources   []string
+	recursive  bool
+	preserve   bool // -p
+	flatten    bool
+	targetDir  string
+	rename     string
+	listOnly   bool
+	listFile   string
+	dryRun     bool
+	spotCheck  float64
+	chunkSize  int64
+	specials   bool
+	verify     bool
+	verifyFile string
+	checksum   bool   // --checksum（仅 get）
+	hash       string // --hash ALGO：--checksum / --spot-check 使用的算法，空则使用 shell 的 hash 设置
+	streams    int    // --parallel N
+	streamMin  int64  // --parallel-min SIZE
+	failFast   bool   // --fail-fast
+	include    []string
+	exclude    []string
+	filter     *client.PathFilter     // 由 include/exclude 构造
+	overwrite  client.OverwritePolicy // --overwrite，空则使用 shell 的 overwrite 设置
+	order      client.TransferOrder   // --order
+	denied     client.DeniedPolicy    // --on-denied
+	json       bool                   // --json：完成后输出 JSON 摘要
+	literal    bool                   // sources 不作为 glob 展开（find --get 找到的路径）
+	sources    []string
+}
+
+// lineEditor Shell 使用的 readline 功能（*readline.Instance），测试中替换为读取固定输入的实现
+type lineEditor interface {
+	Readline() (string, error)
+	SetPrompt(prompt string)
+	Refresh()
+	HistoryDisable()
+	HistoryEnable()
+	SetHistoryPath(path string)
+	Stdout() io.Writer
+	Close() error
 }
 
 // Shell 交互式 Shell
 type Shell struct {
 	client    *client.Client
-	rl        *readline.Instance
+	rl        lineEditor
 	completer *completer.Completer
+
+	downloadDir string // 不带 -d 的 get 的本地目标目录（空则为当前目录）
+	uploadDir   string // 不带 -d 的 put 的远程目标目录（空则为当前目录）
+
+	project *client.ProjectLink // project 映射，nil 表示未设置；优先于 downloadDir/uploadDir
+	stage   *stageSet           // stage add 收集的待推送文件，nil 表示为空
+
+	overwrite client.OverwritePolicy // 不带 --overwrite 的 get/put 使用的覆盖策略
+	scanner   *client.ContentScanner // 上传前/下载后的内容扫描，nil 表示不扫描
+	autoLs    int                    // cd 后自动显示的条目数，0 表示不显示
+
+	promptStyle PromptStyle // 提示符中路径的显示方式
+	banner      string      // 进入交互式 Shell 时显示的横幅，见 SetBanner
+	noColor     bool        // 提示符不使用 ANSI 颜色（Color no、--no-color、NO_COLOR）
+	confirm     []string    // 执行前需要确认的命令，见 SetConfirmCommands
+
+	jobs *jobManager // 后台任务
+	job  *job        // 非 nil 表示这是运行该后台任务的 Shell 副本
+
+	timings  *timingLog  // 最近命令的耗时，见 timing 命令
+	failures *failureLog // -b/-e/cp 期间失败的命令和文件，见 WriteErrorReport
+
+	batch bool // 批处理模式（-b）：不读取终端
+
+	atPrompt *promptState // 正在读取命令行时才定期刷新提示符，见 startPromptRefresher
+
+	jsonOutput  bool   // set output json：ls、stat、df 和传输摘要输出 JSON
+	quietEvents bool   // 前台传输输出 JSON 时不逐个显示完成的文件
+	hash        string // set hash ALGO：--checksum / --spot-check 默认的校验算法，空为 sha256
+	backslashes bool   // set backslash convert：远程路径参数中的 \ 当作 /
 }
 
 // NewShell 创建 Shell
 func NewShell(c *client.Client) *Shell {
-	comp := completer.NewCompleter(c)
+	cc := &completionClient{Client: c}
+	comp := completer.NewCompleter(cc)
 
 	rl, err := readline.NewEx(&readline.Config{
-		Prompt:          c.Getwd() + " > ",
+		Prompt:          "> ", // Run 在每次读取前设置实际提示符
 		HistoryFile:     filepath.Join(os.TempDir(), "my-sftp-history"),
 		AutoComplete:    comp,
 		InterruptPrompt: "^C",
 		EOFPrompt:       "exit",
+		// 显式使用当前 os.Stdout/os.Stderr，会话录制时它们会被重定向
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
 	})
 	if err != nil {
 		panic(err)
 	}
+	cc.out = rl.Stdout()
+	comp.SetOutput(rl.Stdout())
 
-	return &Shell{
+	s := &Shell{
 		client:    c,
 		rl:        rl,
 		completer: comp,
+		overwrite: client.OverwriteAlways,
+		jobs:      &jobManager{},
+		timings:   &timingLog{},
+		failures:  &failureLog{},
+		atPrompt:  &promptState{},
+	}
+	c.SetOverwritePrompt(s.askOverwrite)
+	c.SetNoSpacePrompt(s.askNoSpace)
+	c.SetConnectionLostHandler(s.notifyConnectionLost)
+	printEvent := TransferEventPrinter(c)
+	c.Subscribe(func(ev client.Event) {
+		if ev.Batch == nil && s.quietEvents {
+			return
+		}
+		printEvent(ev)
+	})
+	return s
+}
+
+// TransferEventPrinter 返回将客户端事件显示到终端的订阅函数：每个完成的文件打印一行确认信息。
+// 后台任务（属于某个 Batch）的文件不逐个显示，单文件 Download/Upload（Count 为 0）由调用方显示结果
+func TransferEventPrinter(c *client.Client) func(client.Event) {
+	return func(ev client.Event) {
+		if ev.Type != client.EventCompleted || ev.Batch != nil || ev.Count == 0 {
+			return
+		}
+		name := filepath.Base(ev.Source)
+		if !ev.IsUpload {
+			name = path.Base(ev.Source)
+		}
+		// 先清除进度条所在行
+		fmt.Printf("%s✓ %s (%s)\n", c.ClearLine(), name, units.FormatSize(ev.Bytes))
 	}
 }
 
 // Run 运行交互式循环
 func (s *Shell) Run() error {
 	defer s.rl.Close()
+	stopRefresher := s.startPromptRefresher()
+	defer stopRefresher()
+	if line := s.bannerLine(); line != "" {
+		fmt.Println(line)
+		fmt.Println()
+	}
 
 	for {
-		s.rl.SetPrompt(fmt.Sprintf("\033[32m%s\033[0m > ", s.client.Getwd()))
-
+		if line := s.localPromptLine(); line != "" {
+			fmt.Println(line)
+		}
+		s.atPrompt.set(true, func() { s.rl.SetPrompt(s.prompt()) })
 		line, err := s.rl.Readline()
+		s.atPrompt.set(false, nil)
 		if err != nil {
 			if err == readline.ErrInterrupt {
-				if len(line) == 0 {
+				if len(line) == 0 && s.confirmExit() {
 					break
 				}
 				continue
 			}
 			if err == io.EOF {
-				break
+				if s.confirmExit() {
+					break
+				}
+				continue
 			}
 			return err
 		}
 
 		line = strings.TrimSpace(line)
+		if !s.ensureConnected(line) {
+			return nil
+		}
 		if line == "" {
 			continue
 		}
 
-		if err := s.executeCommand(line); err != nil {
+		if err := s.retryAfterReconnect(line, s.timeCommand(line)); err != nil {
+			if errors.Is(err, errExit) {
+				if s.confirmExit() {
+					break
+				}
+				continue
+			}
 			fmt.Printf("Error: %v\n", err)
+			if errors.Is(err, os.ErrNotExist) {
+				s.checkWorkDir()
+			}
 		}
 	}
 
+	fmt.Println("Goodbye!")
 	return nil
 }
 
+// checkWorkDir 在命令报告路径不存在后检查远程工作目录：目录已在服务器端被删除或重命名时
+// 切换到主目录并说明原因，避免之后每个相对路径都报 no such file
+func (s *Shell) checkWorkDir() {
+	if !s.client.Ready() {
+		return
+	}
+	lost, err := s.client.EnsureWorkDir()
+	if err != nil || lost == "" {
+		return
+	}
+	fmt.Printf("⚠ Remote working directory %s no longer exists; switched to %s\n", lost, s.client.Getwd())
+}
+
+// confirmExit 退出前检查进行中的传输，询问等待/取消/直接退出
+// 返回 false 表示用户选择留在 shell 中
+func (s *Shell) confirmExit() bool {
+	uploads, downloads := s.client.ActiveTransfers()
+	if uploads == 0 && downloads == 0 {
+		return true
+	}
+	return s.askExit(uploads, downloads)
+}
+
+// askExit 有传输进行中时询问如何退出，见 confirmExit
+func (s *Shell) askExit(uploads, downloads int) bool {
+	fmt.Printf("%s %d transfer(s) still running.\n", transferIndicator(uploads, downloads), uploads+downloads)
+	answer, err := s.readAnswer("[w]ait, [c]ancel, [d]etach (exit now, abandon transfers), or stay? ")
+	if err != nil {
+		return false
+	}
+
+	switch answer {
+	case "w", "wait":
+		fmt.Println("Waiting for transfers to finish...")
+		s.client.WaitTransfers()
+		return true
+	case "c", "cancel":
+		fmt.Println("Cancelling transfers...")
+		s.client.CancelTransfers()
+		s.client.WaitTransfers()
+		return true
+	case "d", "detach":
+		fmt.Println("Warning: exiting with transfers in flight; partially written files may remain.")
+		return true
+	default:
+		return false
+	}
+}
+
+// errBatchPrompt 批处理模式下命令需要询问用户
+var errBatchPrompt = errors.New("cannot prompt for input in batch mode")
+
+// readAnswer 使用临时提示符读取一行用户输入（小写、去空白）；批处理模式下返回 errBatchPrompt
+func (s *Shell) readAnswer(prompt string) (string, error) {
+	if s.batch {
+		fmt.Printf("%s(no answer in batch mode)\n", prompt)
+		return "", errBatchPrompt
+	}
+	// 后台任务也会提问，此时主循环可能正在读取命令行：暂停提示符刷新，结束后恢复
+	wasAtPrompt := s.atPrompt.set(false, func() { s.rl.SetPrompt(prompt) })
+	s.rl.HistoryDisable()
+	defer func() {
+		s.rl.HistoryEnable()
+		s.atPrompt.set(wasAtPrompt, func() { s.rl.SetPrompt(s.prompt()) })
+	}()
+	line, err := s.rl.Readline()
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(strings.TrimSpace(line)), nil
+}
+
 // ==================== Internal ====================
 
+// localCommands 不通过 SFTP 访问远程文件的命令：SFTP 在后台启动期间无需等待，exec-only 模式下也可用
+var localCommands = map[string]bool{
+	"help": true, "?": true, "exit": true, "quit": true, "q": true, "shell": true,
+	"overwrite": true, "jobs": true, "status": true, "cancel": true, "fg": true,
+	"timing": true, "prompt": true, "set": true,
+	"lpwd": true, "lcd": true, "lls": true, "ldir": true, "lmkdir": true,
+}
+
+// requireSFTP 等待 SFTP 就绪；exec-only 模式或启动失败时返回错误
+func (s *Shell) requireSFTP() error {
+	err := s.client.WaitReady()
+	if errors.Is(err, client.ErrExecOnly) {
+		return fmt.Errorf("%w: only ! <command> and local commands are available", err)
+	}
+	if err != nil {
+		return fmt.Errorf("SFTP unavailable: %w", err)
+	}
+	return nil
+}
+
 // executeCommand 执行命令
 func (s *Shell) executeCommand(line string) error {
+	// 后台任务在启动前已确认过
+	if s.job == nil {
+		if err := s.confirmCommand(line); err != nil {
+			return err
+		}
+	}
+
 	// 检查 !! 前缀（本地命令）- 必须先检查 !! 再检查 !
 	if strings.HasPrefix(line, "!!") {
 		cmdStr := strings.TrimSpace(strings.TrimPrefix(line, "!!"))
@@ -103,15 +341,32 @@ func (s *Shell) executeCommand(line string) error {
 		return s.cmdExecLocal(cmdStr)
 	}
 
-	// 检查 ! 前缀（远程命令）
-	if strings.HasPrefix(line, "!") {
-		cmdStr := strings.TrimSpace(strings.TrimPrefix(line, "!"))
+	// 检查 ! 前缀或 exec 命令（远程命令）：其余部分原样交给远程 shell，不做管道和 & 处理
+	if cmdStr, ok := cutRemoteCommand(line); ok {
 		if cmdStr == "" {
-			return fmt.Errorf("usage: ! <remote_command>")
+			return fmt.Errorf("usage: ! <remote_command> (or exec <remote_command>)")
 		}
+		// 等待 SFTP 确定远程工作目录；SFTP 不可用时在登录目录执行
+		s.client.WaitReady()
 		return s.cmdExecRemote(cmdStr)
 	}
 
+	// 以 & 结尾的 get/put/sync 在后台运行
+	if cmdLine, ok := cutBackground(line); ok {
+		if err := s.requireSFTP(); err != nil {
+			return err
+		}
+		return s.startJob(cmdLine)
+	}
+
+	// 内置文本命令管道（cat/grep ... | sort | uniq），在本地处理
+	if stages := splitPipeline(line); stages != nil {
+		if err := s.requireSFTP(); err != nil {
+			return err
+		}
+		return s.runPipeline(stages, os.Stdout)
+	}
+
 	fields := parseCommandLine(line)
 	if len(fields) == 0 {
 		return nil
@@ -119,21 +374,38 @@ func (s *Shell) executeCommand(line string) error {
 
 	cmd := fields[0]
 	args := fields[1:]
+	if s.backslashes {
+		args = convertBackslashes(cmd, args)
+	}
+
+	if !localCommands[cmd] {
+		if err := s.requireSFTP(); err != nil {
+			return err
+		}
+	}
 
 	switch cmd {
 	case "help", "?":
 		s.showHelp()
 	case "exit", "quit", "q":
-		fmt.Println("Goodbye!")
-		os.Exit(0)
+		return errExit
 	case "pwd":
 		fmt.Println(s.client.Getwd())
+	case "shell":
+		if len(args) != 0 {
+			return fmt.Errorf("usage: shell")
+		}
+		return s.cmdShell()
 	case "cd":
 		return s.cmdCd(args)
 	case "ls", "ll", "dir":
 		return s.cmdLs(args)
 	case "get", "download":
 		return s.cmdGet(args)
+	case "sync":
+		return s.cmdSync(args)
+	case "overwrite":
+		return s.cmdOverwrite(args)
 	case "put", "upload":
 		return s.cmdPut(args)
 	case "rm", "del", "delete":
@@ -144,8 +416,55 @@ func (s *Shell) executeCommand(line string) error {
 		return s.cmdRmdir(args)
 	case "rename", "mv":
 		return s.cmdRename(args)
-	case "stat", "info":
+	case "cp":
+		return s.cmdCp(args)
+	case "stat":
+		return s.cmdStat(args)
+	case "info":
+		if len(args) == 0 {
+			return s.cmdServerInfo()
+		}
 		return s.cmdStat(args)
+	case "ln":
+		return s.cmdLn(args)
+	case "df":
+		return s.cmdDf(args)
+	case "find":
+		return s.cmdFind(args)
+	case "readlink":
+		return s.cmdReadlink(args)
+	case "chown", "chgrp":
+		return s.cmdChown(cmd, args)
+	case "chmod":
+		return s.cmdChmod(args)
+	case "wc":
+		return s.cmdWc(args)
+	case "cat", "grep", "head", "tail", "sort", "uniq":
+		return s.runPipeline([][]string{fields}, os.Stdout)
+	case "rwatch":
+		return s.cmdRwatch(args)
+	case "wait-for":
+		return s.cmdWaitFor(args)
+	case "snapshot":
+		return s.cmdSnapshot(args)
+	case "jobs":
+		return s.cmdJobs(args)
+	case "status":
+		return s.cmdJobStatus(args)
+	case "cancel":
+		return s.cmdJobCancel(args)
+	case "fg":
+		return s.cmdFg(args)
+	case "timing":
+		return s.cmdTiming(args)
+	case "project":
+		return s.cmdProject(args)
+	case "stage":
+		return s.cmdStage(args)
+	case "prompt":
+		return s.cmdPrompt(args)
+	case "set":
+		return s.cmdSet(args)
 	// 本地命令
 	case "lpwd":
 		fmt.Println(s.client.GetLocalwd())
@@ -163,86 +482,11 @@ func (s *Shell) executeCommand(line string) error {
 }
 
 // parseCommandLine 解析命令行，支持引号包裹的参数
+// 引号与反斜杠规则见 lexer 包文档
 func parseCommandLine(line string) []string {
-	var fields []string
-	var current strings.Builder
-	inQuote := false
-	quoteChar := rune(0)
-	escaped := false
-
-	for _, r := range line {
-		if escaped {
-			// Inside double quotes after backslash: only \" -> ", \\ -> \<space> -> space consume the backslash
-			if r == '"' || r == '\\' || r == ' ' || r == '\t' {
-				current.WriteRune(r)
-			} else {
-				current.WriteRune('\\')
-				current.WriteRune(r)
-			}
-			escaped = false
-			continue
-		}
-
-		switch r {
-		case '\\':
-			if inQuote {
-				if quoteChar == '\'' {
-					// Inside single quotes: backslash is always literal
-					current.WriteRune(r)
-				} else {
-					// Inside double quotes: backslash is an escape prefix
-					escaped = true
-				}
-			} else {
-				// Outside quotes: backslash is a literal character
-				current.WriteRune(r)
-			}
-
-		case '"', '\'':
-			if inQuote {
-				if r == quoteChar {
-					// 结束当前引号
-					inQuote = false
-					quoteChar = 0
-				} else {
-					// 引号内的另一种引号，直接写入
-					current.WriteRune(r)
-				}
-			} else {
-				// 开始新的引号
-				inQuote = true
-				quoteChar = r
-			}
-
-		case ' ', '\t':
-			if inQuote {
-				current.WriteRune(r)
-			} else if current.Len() > 0 {
-				fields = append(fields, current.String())
-				current.Reset()
-			}
-
-		default:
-			current.WriteRune(r)
-		}
-	}
-
-	// 末尾还有内容就收尾
-	if escaped {
-		// 行尾单独一个反斜杠，就把它当普通字符
-		current.WriteRune('\\')
-	}
-	if current.Len() > 0 {
-		fields = append(fields, current.String())
-	}
-
-	return fields
+	return lexer.Split(line)
 }
 
-// escapedWriteRune writes a rune after backslash escape in double quotes.
-// Only \" -> ", \\ -> \, and \<space> -> space trigger escape (consume backslash).
-// All other \x writes both backslash and x literally.
-
 // showHelp 显示帮助
 func (s *Shell) showHelp() {
 	help := `
@@ -250,7 +494,9 @@ Available commands:
   Remote Navigation:
     pwd                    Print remote working directory
     cd <dir>              Change remote directory
-    ls [dir]              List remote directory contents
+    ls [--json] [dir]     List remote directory contents
+    ls [-R] [dir] --export <file> [--format csv|json]
+                          Export path/type/size/mtime/mode/uid/gid metadata for offline analysis
     ll [dir]              List with details (alias of ls)
 
   Local Navigation:
@@ -260,14 +506,54 @@ Available commands:
     lmkdir <dir>          Create local directory
 
   File Transfer:
-	get [-r] [--flatten] [-d dir] [--name name] [--] <remote|pattern>...  Download file(s) or directory from server
-	put [-r] [--flatten] [-d dir] [--name name] [--] <local|pattern>...   Upload file(s) or directory to server
+	get [-r] [-p] [--flatten] [-d dir] [--name name] [--list-only[=file]] [--dry-run] [--include PAT] [--exclude PAT] [--specials] [--verify[=report]] [--checksum] [--parallel N] [--] <remote|pattern>...  Download file(s) or directory from server
+	put [-r] [-p] [--flatten] [-d dir] [--name name] [--list-only[=file]] [--dry-run] [--include PAT] [--exclude PAT] [--specials] [--verify[=report]] [--spot-check N%] [--chunked SIZE] [--parallel N] [--] <local|pattern>...   Upload file(s) or directory to server
+
+	sync [--reverse] [--dry-run] [--json] <local-dir> <remote-dir>   Transfer only new/changed files (size+mtime); --reverse pulls remote -> local
+	                                                       (without directories: sync the linked project)
+	sync --bidirectional [--dry-run] [--json] <local-dir> <remote-dir>   (experimental) Two-way sync: files changed on one side since
+	                                                       the last run (including deletions) go to the other; changed on both = conflict
+	project link <local-dir> <remote-dir>   Map a local project tree to a remote one: get/put without -d keep
+	                                        paths relative to the link (put src/app.py -> <remote-dir>/src/)
+	project [show] | project unlink         Show or remove the link
+	stage add <local|pattern>...   Collect files into a pending changeset (directories are added recursively)
+	stage status | stage rm <path>... | stage clear   Show, shrink or drop the changeset
+	stage push [remote-dir]   Upload the changeset to a staging dir next to the targets, then swap all files in;
+	                          nothing changes if an upload fails (remote-dir defaults to the linked project)
+	overwrite [POLICY]   Show or set the session's default --overwrite policy (initially always)
 
     Options:
 	  -r                   Recursive mode for directories
-	  -d, --dir            Destination directory (local for get, remote for put)
+	  -p                   Preserve modification times and permission bits of the source files
+	  -d, --dir            Destination directory (local for get, remote for put); defaults to the
+	                       host profile's DownloadDir/UploadDir, else the current directory
 	  --name               Rename a single-file destination (filename only)
 	  --flatten            Flatten multi-source structure into target root
+	  --list-only[=file]   Print the sorted source -> destination manifest (to stdout or file) without transferring
+	  --dry-run            Show direction, paths, sizes and total bytes of what would be transferred, touching nothing
+	  --include PATTERN    Only transfer files matching PATTERN (repeatable; doublestar syntax)
+	  --exclude PATTERN    Skip files/directories matching PATTERN (repeatable, wins over --include);
+	                       patterns without / match a name at any depth, e.g. "*.log" or "node_modules"
+	  --specials           Recreate symlinks and FIFOs instead of following/skipping them
+	                       (sockets and devices are always skipped with a notice)
+	  --verify[=report]    After transfer, re-stat source and destination and report files whose size differs
+	  --checksum           (get) Hash each file while downloading and compare with the server's sha256sum,
+	                       so huge files are never read twice (needs remote exec; not with --parallel)
+	  --spot-check N%      (put) Re-download a random N% sample after upload and compare SHA-256 checksums
+	  --hash ALGO          Hash for --checksum/--spot-check: sha256 (default), xxh3 or blake3; --checksum
+	                       falls back to sha256 when the server lacks xxhsum/b3sum
+	  --chunked SIZE       (put) Upload files larger than SIZE (e.g. 64M) as separate chunks, then join them on the server
+	  --parallel N         Split files of 64M or more into N byte ranges sent over separate SFTP channels
+	  --parallel-min SIZE  Lower the size threshold for --parallel (e.g. 16M)
+	  --fail-fast          Stop at the first failed file: abort transfers in flight and skip the rest
+	                       (default: transfer everything and report all errors at the end)
+	  --overwrite POLICY   What to do when the destination file exists (default: the 'overwrite' setting):
+	                       always, never, if-newer, if-different-size, or ask (y/n/all/none/quit per file)
+	  --order ORDER        Transfer order: walk (default), dir (group by destination directory),
+	                       size (largest first), or shuffle
+	  --on-denied POLICY   Unreadable directories and files: abort (default) stops the transfer,
+	                       skip transfers everything else and lists the skipped paths at the end
+	  --json               Print the summary as one JSON line instead of progress and per-file lines
 	  --                   End option parsing for source names beginning with -
 
     Examples:
@@ -285,17 +571,66 @@ Available commands:
 	  put **/*.go -d /srv/code --flatten     Upload recursively and flatten output
 	  put -d /srv/out -- -report.txt         Upload a source whose name begins with -
 	  put -r mydir -d /srv/remotedir         Upload entire directory recursively
+	  put -r dist -d /srv/www --list-only=deploy.txt  Write the deploy manifest for review
+	  put -r photos -d /backup --spot-check 2%        Upload and verify a 2% random sample
+	  put -r project -d /srv/app --exclude "node_modules/**" --exclude "*.log"
+	  get -r logs -d ./logs --overwrite if-newer      Only replace local files that are older
+	  get images/disk.img --checksum                  Download and verify a multi-GB image in one pass
+
+  Background Jobs:
+    <get|put|sync> ... &  Run the transfer in the background and return to the prompt
+    jobs                  List background jobs with their progress
+    status <id>           Show details of a job (files, bytes, rate, errors)
+    cancel <id>...        Cancel running jobs
+    fg [id]               Follow a job's progress until it finishes (Ctrl-C returns to the prompt)
 
   Remote File Operations:
     rm <path>             Remove file or directory
     mkdir <dir>           Create directory
     rmdir <dir>           Remove empty directory
-    rename <old> <new>    Rename file or directory
-    stat <path>           Show file information
+    mv [-i] <src>... <dst>
+                          Rename, or move into a directory (-i asks before overwriting a file)
+    cp [-i] [-p] <src>... <dst>
+                          Copy files on the server (server-side when supported; -p keeps mtime)
+    stat [--json] <path>  Show file information (does not follow symlinks)
+    info                  Show the server's limits (packet size, open handles) and the values in use
+    ln -s <target> <link> Create a symbolic link (target is stored as given)
+    readlink <link>...    Print the target of symbolic links
+    find [path] [-name PAT] [-type f|d|l] [-mtime [+|-]N] [-size [+|-]N[K|M|G]]
+                          Walk the remote tree and print matching paths; --export FILE writes
+                          CSV/JSON metadata, --get [-d dir] downloads the matching files
+    df [--json] [path]... Show size, used/available space and inodes of the remote filesystem
+    wc [-l|-c] <path|glob>...
+                          Count lines and/or bytes of remote files without downloading
+    chown [-R] <user>[:<group>] <path>...
+                          Change owner (names resolved on the server, Tab completes them)
+    chgrp [-R] <group> <path>...
+                          Change group
+    chmod [-R] <mode> <path>...
+                          Change permissions (octal, e.g. 755 or 0644)
+    rwatch <path> [-i 2s] [-n N] [--get [-d dir]]
+                          Poll a remote file/dir and print changes (optionally download them)
+    wait-for <path> [--timeout 10m] [--interval 1s] [--gone]
+                          Block until a remote path exists (or disappears with --gone)
+    snapshot save <name> [dir]
+                          Record the metadata of a remote tree (default: current dir)
+    snapshot diff <name> [dir]
+                          Show files added, removed or modified since the snapshot
+    snapshot list         List saved snapshots
+
+  Text Commands (read over SFTP, no remote exec needed):
+    cat <path|glob>...                        Print remote files
+    grep [-i] [-v] [-n] <regexp> <path|glob>... Print matching lines
+    head|tail [-n N] <path|glob>...           First/last N lines (default 10)
+    sort [-r] [-n] [-u], uniq [-c]            Sort / collapse repeated lines
+    Chain them with " | ", e.g.  grep ERROR app.log | sort | uniq -c | sort -rn | head 20
 
   Shell Commands:
-    ! <command>           Execute command on remote server
+    ! <command>           Execute command on remote server (in the remote working directory)
+    exec <command>        Same as ! <command>
     !! <command>          Execute command on local machine
+    shell                 Open an interactive remote shell (PTY) in the remote working directory;
+                          exit it to return to the sftp prompt
 
     Examples:
       ! tree -L 2              List remote directory tree
@@ -305,6 +640,21 @@ Available commands:
       !! ls -la                List local directory (Linux/Mac)
 
   Other:
+    prompt [home on|off] [depth N] [local on|off] [color NAME]
+                          Show ~ for home, keep only the last N path components, add a line
+                          with the local cwd above the prompt, or color the prompt (red, green,
+                          yellow, blue, magenta, cyan, white; defaults from the host profile)
+    set [output json|text]
+                          Print ls, stat, df and transfer summaries as JSON (one object per line)
+    set [hash sha256|xxh3|blake3]
+                          Default hash for --checksum and --spot-check
+    set [backslash convert|keep]
+                          Treat \ in remote paths as / (cd logs\2024); \\ stays a literal backslash
+    set [units binary|decimal]
+                          Show sizes in KB/MB of 1024 (default) or kB/MB of 1000
+    set [buffer SIZE]     Transfer buffer size for later transfers, e.g. 1M
+    timing                Measure server latency and list recent commands with their run times
+                          (commands slower than 2s print their time when they finish)
     help                  Show this help
     exit/quit/q           Exit program
 
@@ -332,42 +682,190 @@ func (s *Shell) cmdCd(args []string) error {
 	if len(args) > 0 {
 		dir = args[0]
 	}
-	return s.client.Chdir(dir)
+	if err := s.client.Chdir(dir); err != nil {
+		return err
+	}
+	if s.autoLs > 0 {
+		// 列出的结果会留在目录缓存中，之后的 Tab 补全不需要再请求服务器
+		files, err := s.client.List("")
+		if err != nil {
+			return fmt.Errorf("list %s: %w", s.client.Getwd(), err)
+		}
+		fmt.Print(formatShortListing(files, s.autoLs))
+	}
+	return nil
+}
+
+// formatShortListing cd 后的简短列表：统计一行，目录在前按名称排序的前 limit 个条目，每行若干个
+func formatShortListing(files []os.FileInfo, limit int) string {
+	sorted := append([]os.FileInfo(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].IsDir() != sorted[j].IsDir() {
+			return sorted[i].IsDir()
+		}
+		return sorted[i].Name() < sorted[j].Name()
+	})
+
+	dirs := 0
+	for _, file := range files {
+		if file.IsDir() {
+			dirs++
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d dir(s), %d file(s)\n", dirs, len(files)-dirs)
+
+	const width = 80
+	line := ""
+	for i, file := range sorted {
+		if i == limit {
+			break
+		}
+		name := file.Name()
+		if file.IsDir() {
+			name += "/"
+		}
+		if line != "" && len(line)+2+len(name) > width {
+			b.WriteString(line + "\n")
+			line = ""
+		}
+		if line == "" {
+			line = "  " + name
+		} else {
+			line += "  " + name
+		}
+	}
+	if line != "" {
+		b.WriteString(line + "\n")
+	}
+	if len(sorted) > limit {
+		fmt.Fprintf(&b, "  ... and %d more (ls to see all)\n", len(sorted)-limit)
+	}
+	return b.String()
 }
 
 // cmdLs 列出目录
 func (s *Shell) cmdLs(args []string) error {
-	dir := ""
-	if len(args) > 0 {
-		dir = args[0]
+	opts, err := parseLsCLIArgs(args)
+	if err != nil {
+		return fmt.Errorf("ls: %w", err)
 	}
+	dir := opts.dir
 
 	// 用户主动执行 ls 时，清除缓存以获取最新内容
 	s.client.ClearDirCache()
 
+	if opts.exportFile != "" {
+		return s.exportListing(opts)
+	}
+
 	files, err := s.client.List(dir)
 	if err != nil {
 		return err
 	}
+	if opts.json || s.jsonOutput {
+		return s.writeLsJSON(dir, files)
+	}
 
 	fmt.Printf("Total: %d items\n", len(files))
 	for _, file := range files {
 		typeChar := "-"
-		if file.IsDir() {
+		name := file.Name()
+		switch {
+		case file.IsDir():
 			typeChar = "d"
+		case file.Mode()&os.ModeSymlink != 0:
+			typeChar = "l"
+			if target, err := s.client.ReadLink(path.Join(dir, name)); err == nil {
+				name += " -> " + target
+			}
 		}
 
 		fmt.Printf("%s %10s  %s  %s\n",
 			typeChar,
-			client.FormatSize(file.Size()),
+			units.FormatSize(file.Size()),
 			file.ModTime().Format("2006-01-02 15:04:05"),
-			file.Name(),
+			name,
 		)
 	}
 
 	return nil
 }
 
+type lsCLIOptions struct {
+	dir        string
+	recursive  bool
+	json       bool
+	exportFile string
+	format     client.ExportFormat
+}
+
+func parseLsCLIArgs(args []string) (*lsCLIOptions, error) {
+	opts := &lsCLIOptions{}
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		switch tok {
+		case "-R", "--recursive":
+			opts.recursive = true
+		case "--json":
+			opts.json = true
+		case "--export", "--format":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", tok)
+			}
+			if tok == "--export" {
+				opts.exportFile = args[i]
+				continue
+			}
+			format, err := client.ParseExportFormat(args[i])
+			if err != nil {
+				return nil, err
+			}
+			opts.format = format
+		default:
+			if strings.HasPrefix(tok, "-") {
+				return nil, fmt.Errorf("unknown option: %s", tok)
+			}
+			if opts.dir != "" {
+				return nil, fmt.Errorf("only one directory can be listed")
+			}
+			opts.dir = tok
+		}
+	}
+
+	if opts.exportFile == "" && (opts.recursive || opts.format != "") {
+		return nil, fmt.Errorf("-R and --format require --export <file>")
+	}
+	if opts.exportFile != "" && opts.json {
+		return nil, fmt.Errorf("--json cannot be used with --export (use --format json)")
+	}
+	if opts.exportFile != "" && opts.format == "" {
+		opts.format = client.ExportFormatForFile(opts.exportFile)
+	}
+	return opts, nil
+}
+
+// exportListing 将远程目录元数据导出到本地 CSV/JSON 文件
+func (s *Shell) exportListing(opts *lsCLIOptions) error {
+	target := s.client.ResolveLocalPath(opts.exportFile)
+	f, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+
+	startTime := time.Now()
+	count, err := s.client.ExportListing(opts.dir, opts.recursive, opts.format, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("export listing: %w", err)
+	}
+	fmt.Printf("✓ Exported %d entries (%s) to %s in %s\n", count, opts.format, target, time.Since(startTime).Round(time.Millisecond))
+	return nil
+}
+
 func parseTransferCLIArgs(args []string) (*transferCLIOptions, error) {
 	opts := &transferCLIOptions{}
 	stopOptions := false
@@ -384,6 +882,8 @@ func parseTransferCLIArgs(args []string) (*transferCLIOptions, error) {
 			stopOptions = true
 		case "-r":
 			opts.recursive = true
+		case "-p":
+			opts.preserve = true
 		case "--flatten":
 			opts.flatten = true
 		case "-d", "--dir":
@@ -398,7 +898,163 @@ func parseTransferCLIArgs(args []string) (*transferCLIOptions, error) {
 				return nil, fmt.Errorf("missing value for --name")
 			}
 			opts.rename = args[i]
+		case "--list-only":
+			opts.listOnly = true
+		case "--dry-run":
+			opts.dryRun = true
+		case "--specials":
+			opts.specials = true
+		case "--checksum":
+			opts.checksum = true
+		case "--fail-fast":
+			opts.failFast = true
+		case "--json":
+			opts.json = true
+		case "--hash":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --hash")
+			}
+			alg, err := client.LookupHashAlgorithm(args[i])
+			if err != nil {
+				return nil, err
+			}
+			opts.hash = alg.Name
+		case "--spot-check":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --spot-check")
+			}
+			percent, err := parseSpotCheckPercent(args[i])
+			if err != nil {
+				return nil, err
+			}
+			opts.spotCheck = percent
+		case "--chunked":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --chunked")
+			}
+			size, err := units.ParseSize(args[i])
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("invalid --chunked size: %s", args[i])
+			}
+			opts.chunkSize = size
+		case "--parallel":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --parallel")
+			}
+			streams, err := strconv.Atoi(args[i])
+			if err != nil || streams < 2 || streams > maxParallelStreams {
+				return nil, fmt.Errorf("invalid --parallel value: %s (want 2-%d)", args[i], maxParallelStreams)
+			}
+			opts.streams = streams
+		case "--include", "--exclude":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing pattern for %s", tok)
+			}
+			if tok == "--include" {
+				opts.include = append(opts.include, args[i])
+			} else {
+				opts.exclude = append(opts.exclude, args[i])
+			}
+		case "--overwrite":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --overwrite")
+			}
+			policy, err := client.ParseOverwritePolicy(args[i])
+			if err != nil {
+				return nil, err
+			}
+			opts.overwrite = policy
+		case "--order":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --order")
+			}
+			order, err := client.ParseTransferOrder(args[i])
+			if err != nil {
+				return nil, err
+			}
+			opts.order = order
+		case "--on-denied":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --on-denied")
+			}
+			policy, err := client.ParseDeniedPolicy(args[i])
+			if err != nil {
+				return nil, err
+			}
+			opts.denied = policy
+		case "--parallel-min":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for --parallel-min")
+			}
+			size, err := units.ParseSize(args[i])
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("invalid --parallel-min size: %s", args[i])
+			}
+			opts.streamMin = size
 		default:
+			if tok == "--verify" || strings.HasPrefix(tok, "--verify=") {
+				opts.verify = true
+				if strings.HasPrefix(tok, "--verify=") {
+					opts.verifyFile = strings.TrimPrefix(tok, "--verify=")
+					if opts.verifyFile == "" {
+						return nil, fmt.Errorf("missing file for --verify=")
+					}
+				}
+				continue
+			}
+			if name, pattern, ok := strings.Cut(tok, "="); ok && (name == "--include" || name == "--exclude") {
+				if name == "--include" {
+					opts.include = append(opts.include, pattern)
+				} else {
+					opts.exclude = append(opts.exclude, pattern)
+				}
+				continue
+			}
+			if strings.HasPrefix(tok, "--overwrite=") {
+				value := strings.TrimPrefix(tok, "--overwrite=")
+				if value == "" {
+					return nil, fmt.Errorf("missing value for --overwrite=")
+				}
+				policy, err := client.ParseOverwritePolicy(value)
+				if err != nil {
+					return nil, err
+				}
+				opts.overwrite = policy
+				continue
+			}
+			if value, ok := strings.CutPrefix(tok, "--on-denied="); ok {
+				policy, err := client.ParseDeniedPolicy(value)
+				if err != nil {
+					return nil, err
+				}
+				opts.denied = policy
+				continue
+			}
+			if value, ok := strings.CutPrefix(tok, "--order="); ok {
+				order, err := client.ParseTransferOrder(value)
+				if err != nil {
+					return nil, err
+				}
+				opts.order = order
+				continue
+			}
+			if strings.HasPrefix(tok, "--list-only=") {
+				opts.listOnly = true
+				opts.listFile = strings.TrimPrefix(tok, "--list-only=")
+				if opts.listFile == "" {
+					return nil, fmt.Errorf("missing file for --list-only=")
+				}
+				continue
+			}
 			if strings.HasPrefix(tok, "-") {
 				return nil, fmt.Errorf("unknown option: %s", tok)
 			}
@@ -409,10 +1065,36 @@ func parseTransferCLIArgs(args []string) (*transferCLIOptions, error) {
 	if len(opts.sources) == 0 {
 		return nil, fmt.Errorf("missing source path")
 	}
+	if opts.streamMin > 0 && opts.streams == 0 {
+		return nil, fmt.Errorf("--parallel-min requires --parallel N")
+	}
+	if opts.dryRun && opts.listOnly {
+		return nil, fmt.Errorf("--dry-run cannot be used with --list-only")
+	}
+	if opts.hash != "" && !opts.checksum && opts.spotCheck == 0 {
+		return nil, fmt.Errorf("--hash requires --checksum or --spot-check")
+	}
+	filter, err := client.NewPathFilter(opts.include, opts.exclude)
+	if err != nil {
+		return nil, err
+	}
+	opts.filter = filter
+	if opts.filter != nil && opts.rename != "" {
+		return nil, fmt.Errorf("--include/--exclude cannot be used with --name")
+	}
 
 	return opts, nil
 }
 
+// parseSpotCheckPercent 解析 --spot-check 的值，如 "5%" 或 "5"
+func parseSpotCheckPercent(value string) (float64, error) {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid --spot-check value: %s (want a percentage in (0, 100])", value)
+	}
+	return percent, nil
+}
+
 func validateTransferRename(name string) error {
 	if name == "" {
 		return nil
@@ -426,23 +1108,57 @@ func validateTransferRename(name string) error {
 	return nil
 }
 
+// deniedSkips --on-denied skip 时返回收集跳过路径的 DeniedSkips，否则为 nil（中止）
+func (parsed *transferCLIOptions) deniedSkips() *client.DeniedSkips {
+	if parsed.denied != client.DeniedSkip {
+		return nil
+	}
+	return &client.DeniedSkips{}
+}
+
 func buildDownloadCommandOptions(parsed *transferCLIOptions) *client.DownloadOptions {
 	return &client.DownloadOptions{
-		Recursive:    parsed.recursive,
-		ShowProgress: true,
-		Concurrency:  client.MaxConcurrentTransfers,
-		Flatten:      parsed.flatten,
-		MaxDepth:     -1,
+		Recursive:       parsed.recursive,
+		ShowProgress:    true,
+		Flatten:         parsed.flatten,
+		MaxDepth:        -1,
+		RecreateSpecial: parsed.specials,
+		Verify:          parsed.verify,
+		Checksum:        parsed.checksum,
+		Hash:            parsed.hash,
+		FailFast:        parsed.failFast,
+
+		ParallelStreams:   parsed.streams,
+		ParallelThreshold: parsed.streamMin,
+		Filter:            parsed.filter,
+		Overwrite:         parsed.overwrite,
+		Preserve:          parsed.preserve,
+		Order:             parsed.order,
+		Denied:            parsed.deniedSkips(),
+		Literal:           parsed.literal,
 	}
 }
 
 func buildUploadCommandOptions(parsed *transferCLIOptions) *client.UploadOptions {
 	return &client.UploadOptions{
-		Recursive:    parsed.recursive,
-		ShowProgress: true,
-		Concurrency:  client.MaxConcurrentTransfers,
-		Flatten:      parsed.flatten,
-		MaxDepth:     -1,
+		Recursive:        parsed.recursive,
+		ShowProgress:     true,
+		Flatten:          parsed.flatten,
+		MaxDepth:         -1,
+		SpotCheckPercent: parsed.spotCheck,
+		SpotCheckHash:    parsed.hash,
+		ChunkSize:        parsed.chunkSize,
+		RecreateSpecial:  parsed.specials,
+		Verify:           parsed.verify,
+		FailFast:         parsed.failFast,
+
+		ParallelStreams:   parsed.streams,
+		ParallelThreshold: parsed.streamMin,
+		Filter:            parsed.filter,
+		Overwrite:         parsed.overwrite,
+		Preserve:          parsed.preserve,
+		Order:             parsed.order,
+		Denied:            parsed.deniedSkips(),
 	}
 }
 
@@ -474,16 +1190,80 @@ func (s *Shell) inferLegacyPutTarget(localPaths []string) ([]string, string, boo
 	return localPaths, "", false
 }
 
-// cmdGet 下载文件
-func (s *Shell) cmdGet(args []string) error {
-	if len(args) < 1 {
-		return fmt.Errorf("usage: get [-r] [--flatten] [-d <local_dir>] [--name <filename>] [--] <remote_src>...")
-	}
+// SetLandingDirs 设置不带 -d 时 get/put 的默认目标目录（来自主机 profile）
+func (s *Shell) SetLandingDirs(downloadDir, uploadDir string) {
+	s.downloadDir = downloadDir
+	s.uploadDir = uploadDir
+}
 
-	opts, err := parseTransferCLIArgs(args)
-	if err != nil {
-		return fmt.Errorf("get: %w", err)
-	}
+// SetAutoLs 设置 cd 成功后自动显示的条目数，0 表示不显示（来自主机 profile 的 AutoLsOnCd）
+func (s *Shell) SetAutoLs(entries int) {
+	s.autoLs = entries
+}
+
+// SetScanner 设置上传前/下载后的内容扫描（来自主机 profile）
+func (s *Shell) SetScanner(scanner *client.ContentScanner) {
+	s.scanner = scanner
+}
+
+// SetOverwrite 设置 get/put 默认的覆盖策略（来自主机 profile 的 Overwrite，同 overwrite 命令）
+func (s *Shell) SetOverwrite(policy client.OverwritePolicy) {
+	s.overwrite = policy
+}
+
+// SetHistoryFile 设置交互式命令历史文件，空串表示不保存历史
+func (s *Shell) SetHistoryFile(path string) error {
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("history file: %w", err)
+		}
+	}
+	s.rl.SetHistoryPath(path)
+	return nil
+}
+
+// cmdGet 下载文件
+func (s *Shell) cmdGet(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: get [-r] [-p] [--flatten] [-d <local_dir>] [--name <filename>] [--] <remote_src>...")
+	}
+
+	opts, err := parseTransferCLIArgs(args)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	return s.getParsed(opts)
+}
+
+// getParsed 执行解析后的 get 命令：设置了 project 映射时按映射确定目标目录
+func (s *Shell) getParsed(opts *transferCLIOptions) error {
+	targets, err := s.projectTargets(opts, false)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	if targets != nil {
+		return s.eachProjectSource(opts, targets, s.get)
+	}
+	return s.get(opts)
+}
+
+// get 执行解析后的 get 命令
+func (s *Shell) get(opts *transferCLIOptions) error {
+	if opts.overwrite == "" {
+		opts.overwrite = s.overwrite
+	}
+	if opts.hash == "" {
+		opts.hash = s.hash
+	}
+	if opts.spotCheck > 0 || opts.chunkSize > 0 {
+		return fmt.Errorf("get: --spot-check and --chunked are only supported for put")
+	}
+	if opts.rename != "" && opts.streams > 0 {
+		return fmt.Errorf("--parallel cannot be used with --name")
+	}
+	if opts.checksum && opts.streams > 0 {
+		return fmt.Errorf("--checksum cannot be used with --parallel")
+	}
 	if err := validateTransferRename(opts.rename); err != nil {
 		return fmt.Errorf("get: %w", err)
 	}
@@ -491,17 +1271,25 @@ func (s *Shell) cmdGet(args []string) error {
 	remotePaths := opts.sources
 	localDir := opts.targetDir
 	if localDir == "" && len(remotePaths) > 1 {
-		if legacyPositionalTargetCompatibility {
+		if legacyPositionalTargetCompatibility && !opts.literal {
 			var usedLegacy bool
 			remotePaths, localDir, usedLegacy = s.inferLegacyGetTarget(remotePaths)
 			if usedLegacy {
 				fmt.Println("Warning: legacy positional target syntax is deprecated; use -d <local_dir>")
 			}
 		}
-		if localDir == "" {
+		if localDir == "" && s.downloadDir == "" {
 			return fmt.Errorf("multiple get sources require destination: use -d <local_dir>")
 		}
 	}
+	if localDir == "" && s.downloadDir != "" {
+		localDir = s.downloadDir
+		if !opts.listOnly && !opts.dryRun {
+			if err := os.MkdirAll(s.client.ResolveLocalPath(localDir), 0755); err != nil {
+				return fmt.Errorf("create download dir: %w", err)
+			}
+		}
+	}
 	if localDir == "" {
 		localDir = "."
 	}
@@ -509,10 +1297,15 @@ func (s *Shell) cmdGet(args []string) error {
 	if opts.rename != "" && len(remotePaths) != 1 {
 		return fmt.Errorf("--name is only valid with exactly one source file")
 	}
+	jsonOut := opts.json || s.jsonOutput
+	if jsonOut {
+		defer s.muteTransferEvents()()
+	}
 
 	// 开始计时
 	startTime := time.Now()
 	totalCount := 0
+	var denied *client.DeniedSkips
 
 	if opts.rename != "" {
 		remotePath := remotePaths[0]
@@ -526,37 +1319,112 @@ func (s *Shell) cmdGet(args []string) error {
 		if stat.IsDir() {
 			return fmt.Errorf("--name cannot be used with directory source: %s", remotePath)
 		}
+		if !stat.Mode().IsRegular() {
+			return fmt.Errorf("--name requires a regular file: %s", remotePath)
+		}
 		targetPath := filepath.Join(localDir, opts.rename)
-		if err := s.client.Download(remotePath, targetPath); err != nil {
+		if opts.listOnly || opts.dryRun {
+			return s.writeTransferPlan([]client.ManifestEntry{{
+				Source:      s.client.ResolveRemotePath(remotePath),
+				Destination: s.client.ResolveLocalPath(targetPath),
+				Size:        stat.Size(),
+			}}, false, opts, nil)
+		}
+		ok, err := s.client.AllowOverwrite(targetPath, remotePath, false, opts.overwrite)
+		if err != nil || !ok {
+			return err
+		}
+		download := s.client.Download
+		if opts.checksum {
+			download = func(remotePath, localPath string) error {
+				return s.client.DownloadChecksum(remotePath, localPath, opts.hash)
+			}
+		} else if jsonOut {
+			download = func(remotePath, localPath string) error {
+				return s.client.DownloadWithProgress(remotePath, localPath, nil)
+			}
+		}
+		if err := download(remotePath, targetPath); err != nil {
 			return err
 		}
+		if err := s.scanner.CheckDownload(s.client.ResolveLocalPath(targetPath)); err != nil {
+			return err
+		}
+		if opts.preserve {
+			if err := s.client.PreserveAttributes(targetPath, remotePath, false); err != nil {
+				return fmt.Errorf("preserve attributes: %w", err)
+			}
+		}
+		if opts.verify {
+			if err := s.client.VerifyFile(targetPath, remotePath, false); err != nil {
+				return s.reportVerifyError(err, opts.verifyFile)
+			}
+		}
 		totalCount = 1
-	} else {
-		count, err := s.client.DownloadSources(remotePaths, localDir, buildDownloadCommandOptions(opts))
+	} else if opts.listOnly || opts.dryRun {
+		downloadOpts := buildDownloadCommandOptions(opts)
+		entries, err := s.client.DownloadManifest(remotePaths, localDir, downloadOpts)
 		if err != nil {
 			return err
 		}
+		return s.writeTransferPlan(entries, false, opts, downloadOpts.Denied)
+	} else {
+		downloadOpts := buildDownloadCommandOptions(opts)
+		denied = downloadOpts.Denied
+		downloadOpts.Scanner = s.scanner
+		downloadOpts.ShowProgress = !jsonOut
+		if s.job != nil {
+			downloadOpts.ShowProgress, downloadOpts.Batch = false, s.job.batch
+		}
+		count, err := s.client.DownloadSources(remotePaths, localDir, downloadOpts)
+		if err != nil {
+			return s.reportVerifyError(err, opts.verifyFile)
+		}
 		totalCount = count
 	}
 
 	duration := time.Since(startTime)
-	fmt.Printf("✓ Downloaded %d file(s) in %s\n", totalCount, duration.Round(time.Millisecond))
+	if jsonOut {
+		return s.reportJSON(transferJSON{Command: "get", Files: totalCount, Target: s.client.ResolveLocalPath(localDir), Skipped: denied.Paths(), DurationMs: duration.Milliseconds()})
+	}
+	s.report("✓ Downloaded %d file(s) in %s%s", totalCount, duration.Round(time.Millisecond), deniedSummary(denied))
 	return nil
 }
 
 // cmdPut 上传文件
 func (s *Shell) cmdPut(args []string) error {
 	if len(args) < 1 {
-		return fmt.Errorf("usage: put [-r] [--flatten] [-d <remote_dir>] [--name <filename>] [--] <local_src>...")
+		return fmt.Errorf("usage: put [-r] [-p] [--flatten] [-d <remote_dir>] [--name <filename>] [--] <local_src>...")
 	}
 
 	opts, err := parseTransferCLIArgs(args)
 	if err != nil {
 		return fmt.Errorf("put: %w", err)
 	}
+	targets, err := s.projectTargets(opts, true)
+	if err != nil {
+		return fmt.Errorf("put: %w", err)
+	}
+	if targets != nil {
+		return s.eachProjectSource(opts, targets, s.put)
+	}
+	return s.put(opts)
+}
+
+// put 执行解析后的 put 命令
+func (s *Shell) put(opts *transferCLIOptions) error {
+	if opts.overwrite == "" {
+		opts.overwrite = s.overwrite
+	}
+	if opts.hash == "" {
+		opts.hash = s.hash
+	}
 	if err := validateTransferRename(opts.rename); err != nil {
 		return fmt.Errorf("put: %w", err)
 	}
+	if opts.checksum {
+		return fmt.Errorf("put: --checksum is only supported for get (use --spot-check for uploads)")
+	}
 
 	localPaths := opts.sources
 	remoteDir := opts.targetDir
@@ -568,10 +1436,13 @@ func (s *Shell) cmdPut(args []string) error {
 				fmt.Println("Warning: legacy positional target syntax is deprecated; use -d <remote_dir>")
 			}
 		}
-		if remoteDir == "" {
+		if remoteDir == "" && s.uploadDir == "" {
 			return fmt.Errorf("multiple put sources require destination: use -d <remote_dir>")
 		}
 	}
+	if remoteDir == "" {
+		remoteDir = s.uploadDir
+	}
 	if remoteDir == "" {
 		remoteDir = "."
 	}
@@ -579,10 +1450,21 @@ func (s *Shell) cmdPut(args []string) error {
 	if opts.rename != "" && len(localPaths) != 1 {
 		return fmt.Errorf("--name is only valid with exactly one source file")
 	}
+	if opts.rename != "" && opts.spotCheck > 0 {
+		return fmt.Errorf("--spot-check cannot be used with --name")
+	}
+	if opts.rename != "" && opts.streams > 0 {
+		return fmt.Errorf("--parallel cannot be used with --name")
+	}
+	jsonOut := opts.json || s.jsonOutput
+	if jsonOut {
+		defer s.muteTransferEvents()()
+	}
 
 	// 开始计时
 	startTime := time.Now()
 	totalCount := 0
+	var denied *client.DeniedSkips
 
 	if opts.rename != "" {
 		localPath := localPaths[0]
@@ -597,21 +1479,154 @@ func (s *Shell) cmdPut(args []string) error {
 		if stat.IsDir() {
 			return fmt.Errorf("--name cannot be used with directory source: %s", localPath)
 		}
+		if !stat.Mode().IsRegular() {
+			return fmt.Errorf("--name requires a regular file: %s", localPath)
+		}
 		targetPath := path.Join(remoteDir, opts.rename)
-		if err := s.client.Upload(localPath, targetPath); err != nil {
+		if opts.listOnly || opts.dryRun {
+			return s.writeTransferPlan([]client.ManifestEntry{{
+				Upload:      true,
+				Source:      resolvedPath,
+				Destination: s.client.ResolveRemotePath(targetPath),
+				Size:        stat.Size(),
+			}}, true, opts, nil)
+		}
+		upload := s.client.Upload
+		if jsonOut {
+			upload = func(localPath, remotePath string) error {
+				return s.client.UploadWithProgress(localPath, remotePath, nil)
+			}
+		}
+		if opts.chunkSize > 0 && stat.Size() > opts.chunkSize {
+			upload = func(localPath, remotePath string) error {
+				return s.client.UploadChunked(localPath, remotePath, opts.chunkSize)
+			}
+		}
+		ok, err := s.client.AllowOverwrite(localPath, targetPath, true, opts.overwrite)
+		if err != nil || !ok {
+			return err
+		}
+		if err := s.scanner.CheckUpload(resolvedPath); err != nil {
+			return err
+		}
+		if err := upload(localPath, targetPath); err != nil {
 			return err
 		}
+		if opts.preserve {
+			if err := s.client.PreserveAttributes(localPath, targetPath, true); err != nil {
+				return fmt.Errorf("preserve attributes: %w", err)
+			}
+		}
+		if opts.verify {
+			if err := s.client.VerifyFile(localPath, targetPath, true); err != nil {
+				return s.reportVerifyError(err, opts.verifyFile)
+			}
+		}
 		totalCount = 1
 	} else {
-		count, err := s.client.UploadSources(localPaths, remoteDir, buildUploadCommandOptions(opts))
+		router, err := s.projectRouter()
 		if err != nil {
 			return err
 		}
+		uploadOpts := buildUploadCommandOptions(opts)
+		uploadOpts.Router = router
+		if opts.listOnly || opts.dryRun {
+			entries, err := s.client.UploadManifest(localPaths, remoteDir, uploadOpts)
+			if err != nil {
+				return err
+			}
+			return s.writeTransferPlan(entries, true, opts, uploadOpts.Denied)
+		}
+		denied = uploadOpts.Denied
+		uploadOpts.Scanner = s.scanner
+		uploadOpts.ShowProgress = !jsonOut
+		if s.job != nil {
+			uploadOpts.ShowProgress, uploadOpts.Batch = false, s.job.batch
+		}
+		count, err := s.client.UploadSources(localPaths, remoteDir, uploadOpts)
+		if err != nil {
+			return s.reportVerifyError(err, opts.verifyFile)
+		}
 		totalCount = count
 	}
 
 	duration := time.Since(startTime)
-	fmt.Printf("✓ Uploaded %d file(s) in %s\n", totalCount, duration.Round(time.Millisecond))
+	if jsonOut {
+		return s.reportJSON(transferJSON{Command: "put", Files: totalCount, Target: s.client.ResolveRemotePath(remoteDir), Skipped: denied.Paths(), DurationMs: duration.Milliseconds()})
+	}
+	s.report("✓ Uploaded %d file(s) in %s%s", totalCount, duration.Round(time.Millisecond), deniedSummary(denied))
+	return nil
+}
+
+// reportVerifyError 遇到 *client.VerifyError 时输出校验报告（未指定文件时写到 stdout），并原样返回 err
+func (s *Shell) reportVerifyError(err error, file string) error {
+	var verr *client.VerifyError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	if file == "" {
+		client.WriteVerifyReport(os.Stdout, verr)
+		return err
+	}
+
+	target := s.client.ResolveLocalPath(file)
+	f, createErr := os.Create(target)
+	if createErr != nil {
+		return fmt.Errorf("%w (write report: %v)", err, createErr)
+	}
+	defer f.Close()
+	if writeErr := client.WriteVerifyReport(f, verr); writeErr != nil {
+		return fmt.Errorf("%w (write report: %v)", err, writeErr)
+	}
+	return fmt.Errorf("%w (report written to %s)", err, target)
+}
+
+// writeTransferPlan 输出 --dry-run 计划或 --list-only 清单，upload 为传输方向
+func (s *Shell) writeTransferPlan(entries []client.ManifestEntry, upload bool, opts *transferCLIOptions, denied *client.DeniedSkips) error {
+	var err error
+	if opts.dryRun {
+		err = client.WriteDryRun(os.Stdout, entries, upload)
+	} else {
+		err = s.writeTransferManifest(entries, upload, opts.listFile)
+	}
+	if summary := denied.Summary(); summary != "" && err == nil {
+		// 清单写到 stdout 时跳过的路径写到 stderr，保持清单可解析
+		if opts.listOnly && opts.listFile == "" {
+			fmt.Fprintln(os.Stderr, summary)
+		} else {
+			fmt.Println(summary)
+		}
+	}
+	return err
+}
+
+// deniedSummary --on-denied skip 跳过的路径，接在传输摘要之后；没有跳过时为 ""
+func deniedSummary(denied *client.DeniedSkips) string {
+	if summary := denied.Summary(); summary != "" {
+		return "\n" + summary
+	}
+	return ""
+}
+
+// writeTransferManifest 输出 --list-only 清单：未指定文件时写到 stdout
+func (s *Shell) writeTransferManifest(entries []client.ManifestEntry, upload bool, file string) error {
+	if file == "" {
+		return client.WriteManifest(os.Stdout, entries, upload)
+	}
+
+	target := s.client.ResolveLocalPath(file)
+	f, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("create manifest: %w", err)
+	}
+	if err := client.WriteManifest(f, entries, upload); err != nil {
+		f.Close()
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	fmt.Printf("✓ Wrote manifest of %d file(s) to %s\n", len(entries), target)
 	return nil
 }
 
@@ -662,44 +1677,411 @@ func (s *Shell) cmdRmdir(args []string) error {
 	return nil
 }
 
-// cmdRename 重命名
+type mvCLIOptions struct {
+	interactive bool
+	sources     []string
+	dest        string
+}
+
+// parseMvCLIArgs 解析 mv 参数：mv [-i|-f] <source>... <dest>
+func parseMvCLIArgs(args []string) (*mvCLIOptions, error) {
+	opts := &mvCLIOptions{}
+	var positional []string
+	for _, tok := range args {
+		switch {
+		case tok == "-i" || tok == "--interactive":
+			opts.interactive = true
+		case tok == "-f" || tok == "--force":
+			opts.interactive = false
+		case strings.HasPrefix(tok, "-") && tok != "-":
+			return nil, fmt.Errorf("unknown option: %s", tok)
+		default:
+			positional = append(positional, tok)
+		}
+	}
+	if len(positional) < 2 {
+		return nil, fmt.Errorf("usage: mv [-i] <source>... <dest>")
+	}
+	opts.sources, opts.dest = positional[:len(positional)-1], positional[len(positional)-1]
+	return opts, nil
+}
+
+// cmdRename 重命名或移动：目标是已存在的目录时移入其中，是已存在的文件时覆盖（-i 先询问）
 func (s *Shell) cmdRename(args []string) error {
-	if len(args) < 2 {
-		return fmt.Errorf("usage: rename <old_path> <new_path>")
+	opts, err := parseMvCLIArgs(args)
+	if err != nil {
+		return err
 	}
 
-	if err := s.client.Rename(args[0], args[1]); err != nil {
+	info, err := s.client.Stat(opts.dest)
+	destIsDir := err == nil && info.IsDir()
+	if len(opts.sources) > 1 && !destIsDir {
+		return fmt.Errorf("target '%s' is not a directory", opts.dest)
+	}
+
+	for _, src := range opts.sources {
+		target := opts.dest
+		if destIsDir {
+			target = path.Join(opts.dest, path.Base(s.client.ResolveRemotePath(src)))
+		}
+		if existing, err := s.client.Stat(target); err == nil {
+			if existing.IsDir() {
+				return fmt.Errorf("cannot overwrite directory '%s' with '%s'", target, src)
+			}
+			if opts.interactive {
+				answer, err := s.readAnswer(fmt.Sprintf("Overwrite '%s'? [y/N] ", target))
+				if err != nil {
+					return err
+				}
+				if answer != "y" && answer != "yes" {
+					fmt.Printf("Skipped: %s\n", src)
+					continue
+				}
+			}
+		}
+
+		if err := s.client.Move(src, target); err != nil {
+			return fmt.Errorf("mv %s: %w", src, err)
+		}
+		fmt.Printf("Renamed: %s -> %s\n", src, target)
+	}
+	return nil
+}
+
+type chownCLIOptions struct {
+	recursive bool
+	owner     string
+	group     string
+	paths     []string
+}
+
+// parseChownCLIArgs 解析 chown/chgrp 参数；chown 的第一个参数为 user[:group]，chgrp 为 group
+func parseChownCLIArgs(cmd string, args []string) (*chownCLIOptions, error) {
+	usage := fmt.Errorf("usage: chown [-R] <user>[:<group>] <path>...")
+	if cmd == "chgrp" {
+		usage = fmt.Errorf("usage: chgrp [-R] <group> <path>...")
+	}
+
+	opts := &chownCLIOptions{}
+	var positional []string
+	for _, tok := range args {
+		switch {
+		case tok == "-R" || tok == "--recursive":
+			opts.recursive = true
+		case strings.HasPrefix(tok, "-") && len(positional) == 0:
+			return nil, fmt.Errorf("unknown option: %s", tok)
+		default:
+			positional = append(positional, tok)
+		}
+	}
+	if len(positional) < 2 {
+		return nil, usage
+	}
+
+	spec := positional[0]
+	if cmd == "chgrp" {
+		opts.group = spec
+	} else if owner, group, ok := strings.Cut(spec, ":"); ok {
+		opts.owner, opts.group = owner, group
+	} else {
+		opts.owner = spec
+	}
+	if opts.owner == "" && opts.group == "" {
+		return nil, usage
+	}
+	opts.paths = positional[1:]
+	return opts, nil
+}
+
+// cmdChown 修改远程文件所有者/组
+func (s *Shell) cmdChown(cmd string, args []string) error {
+	opts, err := parseChownCLIArgs(cmd, args)
+	if err != nil {
 		return err
 	}
+	for _, p := range opts.paths {
+		if err := s.client.Chown(p, opts.owner, opts.group, opts.recursive); err != nil {
+			return err
+		}
+		fmt.Printf("Changed ownership: %s\n", p)
+	}
+	return nil
+}
+
+type chmodCLIOptions struct {
+	recursive bool
+	mode      os.FileMode
+	paths     []string
+}
 
-	fmt.Printf("Renamed: %s -> %s\n", args[0], args[1])
+func parseChmodCLIArgs(args []string) (*chmodCLIOptions, error) {
+	opts := &chmodCLIOptions{}
+	var positional []string
+	for _, tok := range args {
+		switch {
+		case tok == "-R" || tok == "--recursive":
+			opts.recursive = true
+		case strings.HasPrefix(tok, "-") && len(positional) == 0:
+			return nil, fmt.Errorf("unknown option: %s", tok)
+		default:
+			positional = append(positional, tok)
+		}
+	}
+	if len(positional) < 2 {
+		return nil, fmt.Errorf("usage: chmod [-R] <mode> <path>...")
+	}
+	mode, err := client.ParseFileMode(positional[0])
+	if err != nil {
+		return nil, err
+	}
+	opts.mode = mode
+	opts.paths = positional[1:]
+	return opts, nil
+}
+
+func (s *Shell) cmdChmod(args []string) error {
+	opts, err := parseChmodCLIArgs(args)
+	if err != nil {
+		return err
+	}
+	for _, p := range opts.paths {
+		if err := s.client.Chmod(p, opts.mode, opts.recursive); err != nil {
+			return err
+		}
+		fmt.Printf("Changed mode: %s (%s)\n", p, opts.mode)
+	}
 	return nil
 }
 
+type wcCLIOptions struct {
+	lines   bool
+	bytes   bool
+	targets []string
+}
+
+// parseWcCLIArgs 解析 wc 参数；未指定 -l/-c 时两者都统计
+func parseWcCLIArgs(args []string) (*wcCLIOptions, error) {
+	opts := &wcCLIOptions{}
+	for _, tok := range args {
+		switch tok {
+		case "-l", "--lines":
+			opts.lines = true
+		case "-c", "--bytes":
+			opts.bytes = true
+		case "-lc", "-cl":
+			opts.lines, opts.bytes = true, true
+		default:
+			if strings.HasPrefix(tok, "-") {
+				return nil, fmt.Errorf("unknown option: %s", tok)
+			}
+			opts.targets = append(opts.targets, tok)
+		}
+	}
+	if len(opts.targets) == 0 {
+		return nil, fmt.Errorf("usage: wc [-l|-c] <path|glob>...")
+	}
+	if !opts.lines && !opts.bytes {
+		opts.lines, opts.bytes = true, true
+	}
+	return opts, nil
+}
+
+// cmdWc 统计远程文件行数/字节数
+func (s *Shell) cmdWc(args []string) error {
+	opts, err := parseWcCLIArgs(args)
+	if err != nil {
+		return err
+	}
+	results, err := s.client.Wc(opts.targets, opts.lines)
+	printRow := func(lines, size int64, name string) {
+		switch {
+		case opts.lines && opts.bytes:
+			fmt.Printf("%8d %10d %s\n", lines, size, name)
+		case opts.lines:
+			fmt.Printf("%8d %s\n", lines, name)
+		default:
+			fmt.Printf("%10d %s\n", size, name)
+		}
+	}
+
+	var totalLines, totalBytes int64
+	for _, r := range results {
+		printRow(r.Lines, r.Bytes, r.Path)
+		totalLines += r.Lines
+		totalBytes += r.Bytes
+	}
+	if len(results) > 1 {
+		printRow(totalLines, totalBytes, "total")
+	}
+	return err
+}
+
 // cmdStat 查看文件信息
 func (s *Shell) cmdStat(args []string) error {
+	args, jsonOut := s.jsonFlag(args)
 	if len(args) < 1 {
-		return fmt.Errorf("usage: stat <path>")
+		return fmt.Errorf("usage: stat [--json] <path>")
 	}
 
-	stat, err := s.client.Stat(args[0])
+	stat, err := s.client.Lstat(args[0])
 	if err != nil {
 		return err
 	}
+	if jsonOut {
+		entry, err := s.lsEntry(s.client.ResolveRemotePath(args[0]), stat)
+		if err != nil {
+			return err
+		}
+		return writeJSON(entry)
+	}
 
 	fmt.Printf("Path:     %s\n", args[0])
 	fmt.Printf("Type:     %s\n", s.fileType(stat))
-	fmt.Printf("Size:     %s (%d bytes)\n", client.FormatSize(stat.Size()), stat.Size())
+	if stat.Mode()&os.ModeSymlink != 0 {
+		target, err := s.client.ReadLink(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Target:   %s\n", target)
+	}
+	fmt.Printf("Size:     %s (%d bytes)\n", units.FormatSize(stat.Size()), stat.Size())
 	fmt.Printf("Modified: %s\n", stat.ModTime().Format("2006-01-02 15:04:05"))
 	fmt.Printf("Mode:     %s\n", stat.Mode())
 
 	return nil
 }
 
+// cmdServerInfo 显示服务器的限制（limits@openssh.com）和本会话实际使用的值
+func (s *Shell) cmdServerInfo() error {
+	limits, err := s.client.ServerLimits()
+	if err != nil {
+		fmt.Printf("Warning: query server limits: %v\n", err)
+	}
+	unknown := func(v uint64, format func(uint64) string) string {
+		if v == 0 {
+			return "unknown"
+		}
+		return format(v)
+	}
+	size := func(v uint64) string { return fmt.Sprintf("%s (%d bytes)", units.FormatSize(int64(v)), v) }
+	count := func(v uint64) string { return fmt.Sprint(v) }
+
+	if limits.Reported {
+		fmt.Println("Server limits (limits@openssh.com):")
+	} else {
+		fmt.Println("Server limits: not reported (the server does not support limits@openssh.com)")
+	}
+	fmt.Printf("  Max packet:       %s\n", unknown(limits.MaxPacket, size))
+	fmt.Printf("  Max read:         %s\n", unknown(limits.MaxRead, size))
+	fmt.Printf("  Max write:        %s\n", unknown(limits.MaxWrite, size))
+	fmt.Printf("  Max open handles: %s\n", unknown(limits.MaxHandles, count))
+	fmt.Println("This session:")
+	fmt.Printf("  Packet size:      %s (%d bytes) per read/write request\n", units.FormatSize(int64(limits.PacketSize)), limits.PacketSize)
+	fmt.Printf("  Request window:   %d requests in flight per file\n", limits.RequestsPerFile)
+	fmt.Printf("  Concurrency:      %d files at a time\n", limits.Concurrency)
+	return nil
+}
+
+// cmdDf 显示远程文件系统的容量和 inode 使用情况（默认为当前目录）
+func (s *Shell) cmdDf(args []string) error {
+	args, jsonOut := s.jsonFlag(args)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("usage: df [--json] [path]...")
+		}
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	if jsonOut {
+		var out []dfJSON
+		for _, arg := range args {
+			usage, err := s.client.DiskUsage(arg)
+			if err != nil {
+				return err
+			}
+			out = append(out, newDfJSON(usage))
+		}
+		return writeJSON(out)
+	}
+
+	fmt.Printf("%-24s %10s %10s %10s %5s %12s %12s %12s %5s\n",
+		"Path", "Size", "Used", "Avail", "Use%", "Inodes", "IUsed", "IFree", "IUse%")
+	for _, arg := range args {
+		usage, err := s.client.DiskUsage(arg)
+		if err != nil {
+			return err
+		}
+		inodePercent := "-"
+		if percent := usage.InodesUsePercent(); percent >= 0 {
+			inodePercent = fmt.Sprintf("%d%%", percent)
+		}
+		fmt.Printf("%-24s %10s %10s %10s %5s %12d %12d %12d %5s\n", usage.Path,
+			units.FormatSize(int64(usage.Total)), units.FormatSize(int64(usage.Used)),
+			units.FormatSize(int64(usage.Available)), fmt.Sprintf("%d%%", usage.UsePercent()),
+			usage.Inodes, usage.InodesUsed(), usage.InodesFree, inodePercent)
+	}
+	return nil
+}
+
+// parseLnCLIArgs 解析 ln 参数：ln -s <target> <link>
+func parseLnCLIArgs(args []string) (target, link string, err error) {
+	symbolic := false
+	var positional []string
+	for _, tok := range args {
+		switch {
+		case tok == "-s" || tok == "--symbolic":
+			symbolic = true
+		case strings.HasPrefix(tok, "-"):
+			return "", "", fmt.Errorf("unknown option: %s", tok)
+		default:
+			positional = append(positional, tok)
+		}
+	}
+	if !symbolic || len(positional) != 2 {
+		return "", "", fmt.Errorf("usage: ln -s <target> <link> (only symbolic links are supported)")
+	}
+	return positional[0], positional[1], nil
+}
+
+// cmdLn 创建符号链接；link 是已存在的目录时在其中创建同名链接
+func (s *Shell) cmdLn(args []string) error {
+	target, link, err := parseLnCLIArgs(args)
+	if err != nil {
+		return err
+	}
+	if info, err := s.client.Stat(link); err == nil && info.IsDir() {
+		link = path.Join(link, path.Base(target))
+	}
+	if err := s.client.Symlink(target, link); err != nil {
+		return fmt.Errorf("ln %s: %w", link, err)
+	}
+	fmt.Printf("Linked: %s -> %s\n", link, target)
+	return nil
+}
+
+// cmdReadlink 显示符号链接的目标
+func (s *Shell) cmdReadlink(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: readlink <link>...")
+	}
+	for _, link := range args {
+		target, err := s.client.ReadLink(link)
+		if err != nil {
+			return fmt.Errorf("readlink %s: %w", link, err)
+		}
+		fmt.Println(target)
+	}
+	return nil
+}
+
 // fileType 获取文件类型描述
 func (s *Shell) fileType(info os.FileInfo) string {
-	if info.IsDir() {
+	switch {
+	case info.IsDir():
 		return "Directory"
+	case info.Mode()&os.ModeSymlink != 0:
+		return "Symbolic Link"
 	}
 	return "Regular File"
 }
@@ -736,7 +2118,7 @@ func (s *Shell) cmdLls(args []string) error {
 
 		fmt.Printf("%s %10s  %s  %s\n",
 			typeChar,
-			client.FormatSize(file.Size()),
+			units.FormatSize(file.Size()),
 			file.ModTime().Format("2006-01-02 15:04:05"),
 			file.Name(),
 		)
@@ -763,16 +2145,60 @@ func (s *Shell) cmdLmkdir(args []string) error {
 
 // ==================== Shell 命令执行 ====================
 
-// cmdExecRemote 在远程服务器执行命令
+// cutRemoteCommand 识别 "! <command>" 和 "exec <command>"，返回要在远程执行的命令
+func cutRemoteCommand(line string) (string, bool) {
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		return strings.TrimSpace(rest), true
+	}
+	if rest, ok := strings.CutPrefix(line, "exec"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+		return strings.TrimSpace(rest), true
+	}
+	return "", false
+}
+
+// cmdExecRemote 在远程服务器的当前工作目录执行命令，输出直接写到终端
 func (s *Shell) cmdExecRemote(cmdStr string) error {
 	fmt.Printf("[Remote] Executing: %s\n", cmdStr)
-	// 直接绑定终端的 stdin/stdout/stderr，支持交互式命令
-	if err := s.client.ExecuteRemote(cmdStr, os.Stdin, os.Stdout, os.Stderr); err != nil {
+	// 直接绑定终端的 stdin/stdout/stderr，支持交互式命令；批处理模式下 stdin 可能就是命令文件，不传给命令
+	var stdin io.Reader = os.Stdin
+	if s.batch {
+		stdin = nil
+	}
+	err := s.client.ExecuteRemote(cmdStr, stdin, os.Stdout, os.Stderr)
+	var exitErr *client.RemoteExitError
+	if errors.As(err, &exitErr) {
+		return exitErr
+	}
+	if err != nil {
 		return fmt.Errorf("remote command failed: %w", err)
 	}
 	return nil
 }
 
+// cmdShell 在当前连接上打开交互式远程 shell，退出后回到 sftp 提示符
+func (s *Shell) cmdShell() error {
+	if s.batch {
+		return fmt.Errorf("shell is not available in batch mode")
+	}
+	// 等待 SFTP 确定远程工作目录；SFTP 不可用时在登录目录启动
+	s.client.WaitReady()
+	fmt.Println("[Remote] Interactive shell (exit or Ctrl-D to return)")
+	err := s.client.InteractiveShell(os.Stdin, record.Terminal(), os.Stdout)
+	var exitErr *client.RemoteExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Signal != "" {
+			return exitErr
+		}
+		// 交互式 shell 的退出状态来自最后一条命令，只作提示
+		fmt.Printf("[Remote] Shell exited with status %d\n", exitErr.Status)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("shell: %w", err)
+	}
+	return nil
+}
+
 // cmdExecLocal 在本地执行命令
 func (s *Shell) cmdExecLocal(cmdStr string) error {
 	fmt.Printf("[Local] Executing: %s\n", cmdStr)
@@ -788,9 +2214,16 @@ func (s *Shell) cmdExecLocal(cmdStr string) error {
 	cmd.Dir = s.client.GetLocalwd()
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+	if !s.batch {
+		cmd.Stdin = os.Stdin
+	}
 
-	if err := cmd.Run(); err != nil {
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return fmt.Errorf("local command exited with status %d", exitErr.ExitCode())
+	}
+	if err != nil {
 		return fmt.Errorf("local command failed: %w", err)
 	}
 	return nil
@@ -1,7 +1,10 @@
 package shell
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/frostime/my-sftp/client"
 )
@@ -68,14 +71,17 @@ func TestValidateTransferRename(t *testing.T) {
 }
 
 func TestBuildDownloadCommandOptions(t *testing.T) {
-	parsed := &transferCLIOptions{recursive: true, flatten: true}
+	parsed, err := parseTransferCLIArgs([]string{"-r", "-p", "--flatten", "logs"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
 	got := buildDownloadCommandOptions(parsed)
 	want := &client.DownloadOptions{
 		Recursive:    true,
 		ShowProgress: true,
-		Concurrency:  client.MaxConcurrentTransfers,
 		Flatten:      true,
 		MaxDepth:     -1,
+		Preserve:     true,
 	}
 	if *got != *want {
 		t.Fatalf("buildDownloadCommandOptions() = %#v, want %#v", *got, *want)
@@ -88,7 +94,6 @@ func TestBuildUploadCommandOptions(t *testing.T) {
 	want := &client.UploadOptions{
 		Recursive:    true,
 		ShowProgress: true,
-		Concurrency:  client.MaxConcurrentTransfers,
 		Flatten:      true,
 		MaxDepth:     -1,
 	}
@@ -111,11 +116,10 @@ func TestParseCommandLineBackslashOutsideQuotes(t *testing.T) {
 }
 
 func TestParseCommandLineBackslashInsideDoubleQuotes(t *testing.T) {
-	// \P -> \P, \\" -> " (escape consumed, but quote stays open since last char was escaped quote)
+	// A trailing \" that would leave the quote open is read as a literal
+	// backslash followed by the closing quote.
 	got := parseCommandLine(`put "C:\Program Files\"`)
-	// After escaped \" the parser ends with open quote; trailing code writes it
-	// Result: C:\Program Files"
-	want := []string{"put", "C:\\Program Files\""}
+	want := []string{"put", `C:\Program Files\`}
 	if len(got) != len(want) {
 		t.Fatalf("parseCommandLine() = %#v, want %#v", got, want)
 	}
@@ -178,3 +182,608 @@ func TestParseCommandLineTrailingBackslashOutsideQuotes(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTransferCLIArgsListOnly(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"-r", "dist", "--list-only"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if !opts.listOnly || opts.listFile != "" {
+		t.Fatalf("listOnly = %v, listFile = %q", opts.listOnly, opts.listFile)
+	}
+
+	opts, err = parseTransferCLIArgs([]string{"dist", "--list-only=deploy.txt"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if !opts.listOnly || opts.listFile != "deploy.txt" {
+		t.Fatalf("listOnly = %v, listFile = %q", opts.listOnly, opts.listFile)
+	}
+
+	if _, err := parseTransferCLIArgs([]string{"dist", "--list-only="}); err == nil {
+		t.Fatal("expected error for empty --list-only file")
+	}
+}
+
+func TestTransferIndicator(t *testing.T) {
+	tests := []struct {
+		uploads, downloads int
+		want               string
+	}{
+		{0, 0, ""},
+		{2, 0, "[2↑]"},
+		{0, 1, "[1↓]"},
+		{2, 1, "[2↑ 1↓]"},
+	}
+	for _, tt := range tests {
+		if got := transferIndicator(tt.uploads, tt.downloads); got != tt.want {
+			t.Fatalf("transferIndicator(%d, %d) = %q, want %q", tt.uploads, tt.downloads, got, tt.want)
+		}
+	}
+}
+
+func TestParseWatchCLIArgs(t *testing.T) {
+	opts, err := parseWatchCLIArgs([]string{"out/result.csv", "-i", "500ms", "--get", "-d", "downloads", "-n", "1"})
+	if err != nil {
+		t.Fatalf("parseWatchCLIArgs() error = %v", err)
+	}
+	if opts.target != "out/result.csv" || opts.interval != 500*time.Millisecond || !opts.download || opts.localDir != "downloads" || opts.maxEvents != 1 {
+		t.Fatalf("parseWatchCLIArgs() = %#v", opts)
+	}
+
+	for _, args := range [][]string{{}, {"a", "b"}, {"a", "-i", "0s"}, {"a", "-n", "x"}, {"a", "--bogus"}} {
+		if _, err := parseWatchCLIArgs(args); err == nil {
+			t.Fatalf("parseWatchCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseWaitForCLIArgs(t *testing.T) {
+	opts, err := parseWaitForCLIArgs([]string{"--gone", "jobs/run.lock", "--timeout", "10m"})
+	if err != nil {
+		t.Fatalf("parseWaitForCLIArgs() error = %v", err)
+	}
+	if opts.target != "jobs/run.lock" || !opts.gone || opts.timeout != 10*time.Minute || opts.interval != time.Second {
+		t.Fatalf("parseWaitForCLIArgs() = %#v", opts)
+	}
+
+	for _, args := range [][]string{{}, {"a", "b"}, {"a", "--timeout"}, {"a", "--timeout", "soon"}, {"a", "-i", "0s"}, {"a", "--bogus"}} {
+		if _, err := parseWaitForCLIArgs(args); err == nil {
+			t.Fatalf("parseWaitForCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsSpotCheck(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"-r", "photos", "--spot-check", "2.5%"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if opts.spotCheck != 2.5 {
+		t.Fatalf("spotCheck = %v, want 2.5", opts.spotCheck)
+	}
+	if got := buildUploadCommandOptions(opts).SpotCheckPercent; got != 2.5 {
+		t.Fatalf("SpotCheckPercent = %v, want 2.5", got)
+	}
+
+	for _, value := range []string{"0%", "101", "-5%", "many"} {
+		if _, err := parseTransferCLIArgs([]string{"photos", "--spot-check", value}); err == nil {
+			t.Fatalf("--spot-check %q expected error", value)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsHash(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"disk.img", "--checksum", "--hash", "XXH3"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if got := buildDownloadCommandOptions(opts).Hash; got != "xxh3" {
+		t.Fatalf("Hash = %q, want xxh3", got)
+	}
+
+	for _, args := range [][]string{
+		{"disk.img", "--checksum", "--hash", "md5"},
+		{"disk.img", "--hash", "blake3"},
+		{"disk.img", "--checksum", "--hash"},
+	} {
+		if _, err := parseTransferCLIArgs(args); err == nil {
+			t.Fatalf("parseTransferCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsChunked(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"disk.img", "--chunked", "64M"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if got := buildUploadCommandOptions(opts).ChunkSize; got != 64<<20 {
+		t.Fatalf("ChunkSize = %d, want %d", got, 64<<20)
+	}
+
+	for _, value := range []string{"0", "lots"} {
+		if _, err := parseTransferCLIArgs([]string{"disk.img", "--chunked", value}); err == nil {
+			t.Fatalf("--chunked %q expected error", value)
+		}
+	}
+}
+
+func TestParseLsCLIArgs(t *testing.T) {
+	opts, err := parseLsCLIArgs([]string{"-R", "/srv", "--export", "listing.json"})
+	if err != nil {
+		t.Fatalf("parseLsCLIArgs() error = %v", err)
+	}
+	if opts.dir != "/srv" || !opts.recursive || opts.exportFile != "listing.json" || opts.format != client.ExportJSON {
+		t.Fatalf("parseLsCLIArgs() = %#v", opts)
+	}
+
+	opts, err = parseLsCLIArgs([]string{"--export", "out.txt", "--format", "json"})
+	if err != nil || opts.format != client.ExportJSON {
+		t.Fatalf("explicit --format: %#v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{{"-R"}, {"--format", "csv"}, {"a", "b"}, {"--export"}, {"--export", "x", "--format", "xml"}, {"-l"}} {
+		if _, err := parseLsCLIArgs(args); err == nil {
+			t.Fatalf("parseLsCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsVerify(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"-r", "logs", "--verify"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if !opts.verify || opts.verifyFile != "" || !buildDownloadCommandOptions(opts).Verify {
+		t.Fatalf("--verify parsed as %#v", opts)
+	}
+
+	opts, err = parseTransferCLIArgs([]string{"logs", "--verify=report.tsv"})
+	if err != nil || !opts.verify || opts.verifyFile != "report.tsv" || !buildUploadCommandOptions(opts).Verify {
+		t.Fatalf("--verify=report.tsv parsed as %#v, %v", opts, err)
+	}
+
+	if _, err := parseTransferCLIArgs([]string{"logs", "--verify="}); err == nil {
+		t.Fatal("--verify= expected error")
+	}
+}
+
+func TestParseTransferCLIArgsFailFast(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"-r", "logs", "--fail-fast"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if !buildDownloadCommandOptions(opts).FailFast || !buildUploadCommandOptions(opts).FailFast {
+		t.Fatalf("--fail-fast parsed as %#v", opts)
+	}
+
+	opts, _ = parseTransferCLIArgs([]string{"logs"})
+	if buildDownloadCommandOptions(opts).FailFast {
+		t.Fatal("FailFast should default to false")
+	}
+}
+
+func TestParseChownCLIArgs(t *testing.T) {
+	tests := []struct {
+		cmd       string
+		args      []string
+		owner     string
+		group     string
+		recursive bool
+		paths     int
+	}{
+		{cmd: "chown", args: []string{"www-data", "a.txt"}, owner: "www-data", paths: 1},
+		{cmd: "chown", args: []string{"-R", "app:staff", "srv", "logs"}, owner: "app", group: "staff", recursive: true, paths: 2},
+		{cmd: "chown", args: []string{":staff", "a.txt"}, group: "staff", paths: 1},
+		{cmd: "chgrp", args: []string{"staff", "a.txt", "-R"}, group: "staff", recursive: true, paths: 1},
+	}
+	for _, tt := range tests {
+		opts, err := parseChownCLIArgs(tt.cmd, tt.args)
+		if err != nil {
+			t.Fatalf("parseChownCLIArgs(%q, %q) error = %v", tt.cmd, tt.args, err)
+		}
+		if opts.owner != tt.owner || opts.group != tt.group || opts.recursive != tt.recursive || len(opts.paths) != tt.paths {
+			t.Fatalf("parseChownCLIArgs(%q, %q) = %#v", tt.cmd, tt.args, opts)
+		}
+	}
+
+	for _, args := range [][]string{{"root"}, {":", "a.txt"}, {"-x", "root", "a.txt"}} {
+		if _, err := parseChownCLIArgs("chown", args); err == nil {
+			t.Fatalf("parseChownCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseMvCLIArgs(t *testing.T) {
+	opts, err := parseMvCLIArgs([]string{"-i", "a.txt", "b.txt", "archive"})
+	if err != nil {
+		t.Fatalf("parseMvCLIArgs() error = %v", err)
+	}
+	if !opts.interactive || len(opts.sources) != 2 || opts.sources[1] != "b.txt" || opts.dest != "archive" {
+		t.Fatalf("parseMvCLIArgs() = %#v", opts)
+	}
+	if opts, _ := parseMvCLIArgs([]string{"-i", "-f", "a", "b"}); opts.interactive {
+		t.Fatal("-f should override -i")
+	}
+	for _, args := range [][]string{{"a.txt"}, {"-n", "a", "b"}} {
+		if _, err := parseMvCLIArgs(args); err == nil {
+			t.Fatalf("parseMvCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestCutRemoteCommand(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{line: "! ls -la | grep x &", want: "ls -la | grep x &", ok: true},
+		{line: "!uptime", want: "uptime", ok: true},
+		{line: "exec  make test", want: "make test", ok: true},
+		{line: "exec\tdf -h", want: "df -h", ok: true},
+		{line: "exec", want: "", ok: true},
+		{line: "executable", ok: false},
+		{line: "ls", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := cutRemoteCommand(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("cutRemoteCommand(%q) = %q, %v; want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseCpCLIArgs(t *testing.T) {
+	opts, err := parseCpCLIArgs([]string{"-p", "a.txt", "b.txt", "backup"})
+	if err != nil {
+		t.Fatalf("parseCpCLIArgs() error = %v", err)
+	}
+	if !opts.preserve || opts.interactive || len(opts.sources) != 2 || opts.dest != "backup" {
+		t.Fatalf("parseCpCLIArgs() = %#v", opts)
+	}
+	for _, args := range [][]string{{"a.txt"}, {"-r", "a", "b"}} {
+		if _, err := parseCpCLIArgs(args); err == nil {
+			t.Fatalf("parseCpCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseLnCLIArgs(t *testing.T) {
+	target, link, err := parseLnCLIArgs([]string{"-s", "../shared/config.yml", "config.yml"})
+	if err != nil || target != "../shared/config.yml" || link != "config.yml" {
+		t.Fatalf("parseLnCLIArgs() = %q, %q, %v", target, link, err)
+	}
+	for _, args := range [][]string{{"a", "b"}, {"-s", "a"}, {"-sf", "a", "b"}} {
+		if _, _, err := parseLnCLIArgs(args); err == nil {
+			t.Fatalf("parseLnCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseFindCLIArgs(t *testing.T) {
+	opts, err := parseFindCLIArgs([]string{"logs", "-name", "*.gz", "-type", "f", "-mtime", "-7", "-size", "+1M", "--get", "-d", "backup"})
+	if err != nil {
+		t.Fatalf("parseFindCLIArgs() error = %v", err)
+	}
+	if opts.root != "logs" || opts.filter.Name != "*.gz" || opts.filter.Type != "file" || !opts.download || opts.localDir != "backup" {
+		t.Fatalf("parseFindCLIArgs() = %#v", opts)
+	}
+	if opts.filter.Mtime.Cmp != -1 || opts.filter.Mtime.Value != 7 || opts.filter.Size.Cmp != 1 {
+		t.Fatalf("bounds = %+v, %+v", *opts.filter.Mtime, *opts.filter.Size)
+	}
+
+	if opts, _ := parseFindCLIArgs(nil); opts.root != "." {
+		t.Fatalf("default root = %q, want .", opts.root)
+	}
+	for _, args := range [][]string{{"-type", "x"}, {"-name"}, {"a", "b"}, {"-d", "out"}, {"--get", "--export", "x.csv"}, {"-print"}} {
+		if _, err := parseFindCLIArgs(args); err == nil {
+			t.Fatalf("parseFindCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestDisplayFindPath(t *testing.T) {
+	tests := []struct{ root, resolved, match, want string }{
+		{".", "/home/u", "/home/u", "."},
+		{".", "/home/u", "/home/u/a/b.txt", "./a/b.txt"},
+		{"logs", "/home/u/logs", "/home/u/logs/x.gz", "logs/x.gz"},
+		{"/", "/", "/etc/hosts", "/etc/hosts"},
+	}
+	for _, tt := range tests {
+		if got := displayFindPath(tt.root, tt.resolved, tt.match); got != tt.want {
+			t.Errorf("displayFindPath(%q, %q, %q) = %q, want %q", tt.root, tt.resolved, tt.match, got, tt.want)
+		}
+	}
+}
+
+func TestParseChmodCLIArgs(t *testing.T) {
+	opts, err := parseChmodCLIArgs([]string{"-R", "0750", "bin", "scripts"})
+	if err != nil {
+		t.Fatalf("parseChmodCLIArgs() error = %v", err)
+	}
+	if opts.mode != 0750 || !opts.recursive || len(opts.paths) != 2 {
+		t.Fatalf("parseChmodCLIArgs() = %#v", opts)
+	}
+
+	for _, args := range [][]string{{"755"}, {"u+x", "run.sh"}, {"-x", "755", "run.sh"}} {
+		if _, err := parseChmodCLIArgs(args); err == nil {
+			t.Fatalf("parseChmodCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseWcCLIArgs(t *testing.T) {
+	opts, err := parseWcCLIArgs([]string{"logs/*.log"})
+	if err != nil || !opts.lines || !opts.bytes || len(opts.targets) != 1 {
+		t.Fatalf("default: %#v, %v", opts, err)
+	}
+	opts, err = parseWcCLIArgs([]string{"-l", "a.log", "b.log"})
+	if err != nil || !opts.lines || opts.bytes || len(opts.targets) != 2 {
+		t.Fatalf("-l: %#v, %v", opts, err)
+	}
+	opts, err = parseWcCLIArgs([]string{"a.log", "-c"})
+	if err != nil || opts.lines || !opts.bytes {
+		t.Fatalf("-c: %#v, %v", opts, err)
+	}
+	for _, args := range [][]string{{}, {"-l"}, {"-w", "a.log"}} {
+		if _, err := parseWcCLIArgs(args); err == nil {
+			t.Fatalf("parseWcCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsParallel(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"big.iso", "--parallel", "4", "--parallel-min", "16M"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	got := buildDownloadCommandOptions(opts)
+	if got.ParallelStreams != 4 || got.ParallelThreshold != 16*1024*1024 {
+		t.Fatalf("download options = %#v", got)
+	}
+
+	for _, args := range [][]string{
+		{"big.iso", "--parallel"},
+		{"big.iso", "--parallel", "1"},
+		{"big.iso", "--parallel", "x"},
+		{"big.iso", "--parallel", "64"},
+		{"big.iso", "--parallel-min", "16M"},
+	} {
+		if _, err := parseTransferCLIArgs(args); err == nil {
+			t.Fatalf("parseTransferCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseSyncCLIArgs(t *testing.T) {
+	opts, err := parseSyncCLIArgs([]string{"site", "--reverse", "--dry-run", "/var/www"})
+	if err != nil {
+		t.Fatalf("parseSyncCLIArgs() error = %v", err)
+	}
+	if want := (syncCLIOptions{reverse: true, dryRun: true, local: "site", remote: "/var/www"}); *opts != want {
+		t.Fatalf("parseSyncCLIArgs() = %#v, want %#v", *opts, want)
+	}
+	for _, args := range [][]string{{"site"}, {"a", "b", "c"}, {"-r", "a", "b"}} {
+		if _, err := parseSyncCLIArgs(args); err == nil {
+			t.Fatalf("parseSyncCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsDryRun(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"--dry-run", "-r", "dist"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if !opts.dryRun || !opts.recursive {
+		t.Fatalf("parseTransferCLIArgs() = %+v, want dryRun and recursive", opts)
+	}
+	if _, err := parseTransferCLIArgs([]string{"--dry-run", "--list-only", "dist"}); err == nil {
+		t.Fatal("parseTransferCLIArgs() expected error for --dry-run with --list-only")
+	}
+}
+
+func TestParseTransferCLIArgsFilters(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"-r", "project", "--exclude", "node_modules/**", "--exclude=*.log", "--include", "*.go"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if len(opts.exclude) != 2 || len(opts.include) != 1 || opts.filter == nil {
+		t.Fatalf("parseTransferCLIArgs() = %+v, want 2 excludes, 1 include and a filter", opts)
+	}
+	if opts.filter.AllowFile("debug.log") || !opts.filter.AllowFile("main.go") {
+		t.Fatal("parsed filter does not apply the patterns")
+	}
+	if got := buildUploadCommandOptions(opts); got.Filter != opts.filter {
+		t.Fatal("buildUploadCommandOptions() must pass the filter through")
+	}
+	for _, args := range [][]string{
+		{"-r", "project", "--exclude"},
+		{"-r", "project", "--exclude", "[bad"},
+		{"a.txt", "--name", "b.txt", "--exclude", "*.log"},
+	} {
+		if _, err := parseTransferCLIArgs(args); err == nil {
+			t.Fatalf("parseTransferCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsOverwrite(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"a.txt", "--overwrite", "if-newer"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if got := buildDownloadCommandOptions(opts).Overwrite; got != client.OverwriteIfNewer {
+		t.Fatalf("Overwrite = %q, want if-newer", got)
+	}
+	opts, err = parseTransferCLIArgs([]string{"--overwrite=never", "a.txt"})
+	if err != nil || opts.overwrite != client.OverwriteNever {
+		t.Fatalf("parseTransferCLIArgs(--overwrite=never) = %+v, %v", opts, err)
+	}
+	for _, args := range [][]string{
+		{"a.txt", "--overwrite"},
+		{"a.txt", "--overwrite=", "b"},
+		{"a.txt", "--overwrite", "sometimes"},
+	} {
+		if _, err := parseTransferCLIArgs(args); err == nil {
+			t.Fatalf("parseTransferCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsOrder(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"-r", "dir", "--order", "size"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if got := buildUploadCommandOptions(opts).Order; got != client.OrderBySize {
+		t.Fatalf("Order = %q, want size", got)
+	}
+	opts, err = parseTransferCLIArgs([]string{"--order=dir", "a.txt"})
+	if err != nil || buildDownloadCommandOptions(opts).Order != client.OrderByDir {
+		t.Fatalf("parseTransferCLIArgs(--order=dir) = %+v, %v", opts, err)
+	}
+	for _, args := range [][]string{
+		{"a.txt", "--order"},
+		{"a.txt", "--order", "alphabetical"},
+	} {
+		if _, err := parseTransferCLIArgs(args); err == nil {
+			t.Fatalf("parseTransferCLIArgs(%q) expected error", args)
+		}
+	}
+}
+
+func TestParseTransferCLIArgsOnDenied(t *testing.T) {
+	opts, err := parseTransferCLIArgs([]string{"-r", "dir", "--on-denied", "skip"})
+	if err != nil {
+		t.Fatalf("parseTransferCLIArgs() error = %v", err)
+	}
+	if buildDownloadCommandOptions(opts).Denied == nil || buildUploadCommandOptions(opts).Denied == nil {
+		t.Fatal("--on-denied skip should collect skipped paths")
+	}
+	opts, err = parseTransferCLIArgs([]string{"--on-denied=abort", "-r", "dir"})
+	if err != nil || buildDownloadCommandOptions(opts).Denied != nil {
+		t.Fatalf("parseTransferCLIArgs(--on-denied=abort) = %+v, %v", opts, err)
+	}
+	if _, err := parseTransferCLIArgs([]string{"-r", "dir", "--on-denied", "ignore"}); err == nil {
+		t.Fatal("--on-denied ignore expected error")
+	}
+}
+
+func TestParseOverwriteAnswer(t *testing.T) {
+	tests := map[string]client.OverwriteAnswer{
+		"y": client.OverwriteYes, "no": client.OverwriteNo,
+		"a": client.OverwriteAll, "all": client.OverwriteAll,
+		"none": client.OverwriteNone, "q": client.OverwriteQuit,
+	}
+	for answer, want := range tests {
+		if got, ok := parseOverwriteAnswer(answer); !ok || got != want {
+			t.Errorf("parseOverwriteAnswer(%q) = %v, %v, want %v", answer, got, ok, want)
+		}
+	}
+	if _, ok := parseOverwriteAnswer("maybe"); ok {
+		t.Error("parseOverwriteAnswer(\"maybe\") should be rejected")
+	}
+}
+
+// FuzzParseCommandLine 检查命令行解析和各命令的参数解析不会 panic
+func FuzzParseCommandLine(f *testing.F) {
+	for _, seed := range []string{
+		`put -r "my dir" -d /srv --exclude "*.log"`,
+		`get --parallel 4 --parallel-min 1M big.iso`,
+		`grep -in "err|warn" logs/*.log | sort -rn | head -5`,
+		`chown -R www:www /var/www`,
+		`wc -l 'a b.txt'`,
+		`sync --reverse "unterminated`,
+		`put -- -x |`,
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		args := parseCommandLine(line)
+		if len(args) > 0 {
+			args = args[1:]
+		}
+		parseTransferCLIArgs(args)
+		parseChownCLIArgs("chown", args)
+		parseWcCLIArgs(args)
+		parseSyncCLIArgs(args)
+		if stages := splitPipeline(line); stages != nil {
+			parsePipeline(stages)
+		}
+	})
+}
+
+func TestFormatShortListing(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src", "docs"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"go.mod", "README.md", "main.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []os.FileInfo
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, info)
+	}
+
+	want := "2 dir(s), 3 file(s)\n  docs/  src/  README.md  go.mod\n  ... and 1 more (ls to see all)\n"
+	if got := formatShortListing(files, 4); got != want {
+		t.Fatalf("formatShortListing() = %q, want %q", got, want)
+	}
+}
+
+func TestConfirmExitWithoutTransfers(t *testing.T) {
+	// 没有进行中的传输时直接退出，不读取输入
+	s := newTestShell(t, "n\n")
+	if !s.confirmExit() {
+		t.Fatal("confirmExit() without transfers = false, want true")
+	}
+	if line, err := s.rl.Readline(); err != nil || line != "n" {
+		t.Fatalf("confirmExit() consumed the input: %q, %v", line, err)
+	}
+}
+
+func TestAskExit(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"w\n", true},
+		{"wait\n", true},
+		{"C\n", true},
+		{" d \n", true},
+		{"detach\n", true},
+		{"n\n", false},
+		{"y\n", false},
+		{"\n", false},
+		{"", false}, // EOF
+	}
+	for _, tt := range tests {
+		s := newTestShell(t, tt.input)
+		if got := s.askExit(1, 1); got != tt.want {
+			t.Errorf("askExit() with input %q = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	// 批处理模式下不读取输入，留在 shell 中
+	s := newTestShell(t, "w\n")
+	s.batch = true
+	if s.askExit(1, 0) {
+		t.Error("askExit() in batch mode = true, want false")
+	}
+}
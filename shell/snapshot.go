@@ -0,0 +1,150 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+	"github.com/frostime/my-sftp/units"
+)
+
+const snapshotUsage = "usage: snapshot save <name> [remote-dir] | snapshot diff <name> [remote-dir] | snapshot list"
+
+// cmdSnapshot 保存远程目录树的元数据快照，或比较当前状态与已保存的快照
+func (s *Shell) cmdSnapshot(args []string) error {
+	if len(args) == 0 {
+		return errors.New(snapshotUsage)
+	}
+	switch args[0] {
+	case "save":
+		if len(args) < 2 || len(args) > 3 {
+			return errors.New(snapshotUsage)
+		}
+		dir := "."
+		if len(args) == 3 {
+			dir = args[2]
+		}
+		return s.snapshotSave(args[1], dir)
+	case "diff":
+		if len(args) < 2 || len(args) > 3 {
+			return errors.New(snapshotUsage)
+		}
+		dir := ""
+		if len(args) == 3 {
+			dir = args[2]
+		}
+		return s.snapshotDiff(args[1], dir)
+	case "list", "ls":
+		if len(args) != 1 {
+			return errors.New(snapshotUsage)
+		}
+		return snapshotList()
+	}
+	return fmt.Errorf("unknown snapshot command: %s\n%s", args[0], snapshotUsage)
+}
+
+func (s *Shell) snapshotSave(name, dir string) error {
+	file, err := config.SnapshotPath(name)
+	if err != nil {
+		return err
+	}
+	snap, err := s.client.TakeTreeSnapshot(dir)
+	if err != nil {
+		return fmt.Errorf("snapshot %s: %w", dir, err)
+	}
+	_, statErr := os.Stat(file)
+	if err := snap.Save(file); err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+	replaced := ""
+	if statErr == nil {
+		replaced = ", replacing the previous one"
+	}
+	fmt.Printf("✓ Snapshot '%s': %d entries under %s%s\n", name, len(snap.Entries), snap.Root, replaced)
+	return nil
+}
+
+// snapshotDiff 比较 dir（默认为快照记录的目录）的当前状态与快照
+func (s *Shell) snapshotDiff(name, dir string) error {
+	file, err := config.SnapshotPath(name)
+	if err != nil {
+		return err
+	}
+	before, err := client.LoadTreeSnapshot(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no snapshot named '%s' (see 'snapshot list')", name)
+	}
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = before.Root
+	}
+	after, err := s.client.TakeTreeSnapshot(dir)
+	if err != nil {
+		return fmt.Errorf("snapshot %s: %w", dir, err)
+	}
+	if before.Host != after.Host {
+		fmt.Printf("ℹ Snapshot '%s' was taken on %s; now connected to %s\n", name, before.Host, after.Host)
+	}
+
+	changes := client.DiffTreeSnapshots(before, after)
+	since := fmt.Sprintf("since snapshot '%s' (%s)", name, before.Created.Local().Format("2006-01-02 15:04:05"))
+	if len(changes) == 0 {
+		fmt.Printf("✓ No changes in %s %s\n", after.Root, since)
+		return nil
+	}
+
+	fmt.Printf("Changes in %s %s:\n", after.Root, since)
+	counts := map[client.ChangeKind]int{}
+	for _, change := range changes {
+		counts[change.Kind]++
+		switch change.Kind {
+		case client.ChangeAdded:
+			fmt.Printf("  + %s%s\n", change.Path, entrySuffix(change.New))
+		case client.ChangeRemoved:
+			fmt.Printf("  - %s%s\n", change.Path, entrySuffix(change.Old))
+		case client.ChangeModified:
+			fmt.Printf("  ~ %s  %s\n", change.Path, strings.Join(change.Details, ", "))
+		}
+	}
+	fmt.Printf("%d added, %d removed, %d modified\n",
+		counts[client.ChangeAdded], counts[client.ChangeRemoved], counts[client.ChangeModified])
+	return nil
+}
+
+// entrySuffix 目录显示为 "/"，文件显示大小
+func entrySuffix(entry *client.ExportEntry) string {
+	if entry.Type == "dir" {
+		return "/"
+	}
+	if entry.Type != "file" {
+		return fmt.Sprintf(" (%s)", entry.Type)
+	}
+	return fmt.Sprintf(" (%s)", units.FormatSize(entry.Size))
+}
+
+func snapshotList() error {
+	names, err := config.SnapshotNames()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("No snapshots saved (use 'snapshot save <name> [remote-dir]')")
+		return nil
+	}
+	for _, name := range names {
+		file, _ := config.SnapshotPath(name)
+		snap, err := client.LoadTreeSnapshot(file)
+		if err != nil {
+			fmt.Printf("  %-20s (unreadable: %v)\n", name, err)
+			continue
+		}
+		fmt.Printf("  %-20s %s  %s:%s  %d entries\n", name,
+			snap.Created.Local().Format("2006-01-02 15:04:05"), snap.Host, snap.Root, len(snap.Entries))
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/units"
+)
+
+const stageUsage = "usage: stage add <local-path|glob>... | stage status | stage rm <path>... | stage clear | stage push [remote-dir]"
+
+// stageSet 本地暂存区：stage add 收集的文件，stage push 一次性推送
+type stageSet struct {
+	base  string              // 暂存文件的本地根目录，推送时的相对路径以它为基准
+	files []client.StagedFile // 按 Rel 排序
+}
+
+// cmdStage 管理暂存区
+func (s *Shell) cmdStage(args []string) error {
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			return errors.New(stageUsage)
+		}
+		return s.stageAdd(args[1:])
+	case "status", "st":
+		if len(args) != 1 {
+			return errors.New(stageUsage)
+		}
+		s.stageStatus()
+		return nil
+	case "rm", "remove":
+		if len(args) < 2 {
+			return errors.New(stageUsage)
+		}
+		return s.stageRemove(args[1:])
+	case "clear":
+		if len(args) != 1 {
+			return errors.New(stageUsage)
+		}
+		if s.stage != nil {
+			fmt.Printf("✓ Unstaged %d file(s)\n", len(s.stage.files))
+		}
+		s.stage = nil
+		return nil
+	case "push":
+		if len(args) > 2 {
+			return errors.New(stageUsage)
+		}
+		remoteDir := ""
+		if len(args) == 2 {
+			remoteDir = args[1]
+		}
+		return s.stagePush(remoteDir)
+	}
+	return fmt.Errorf("unknown stage command: %s\n%s", args[0], stageUsage)
+}
+
+// stageAdd 把文件（目录递归展开）加入暂存区。第一次添加时确定根目录：
+// 已链接 project 且文件在其中时为 project 的本地根目录，否则为当前本地工作目录
+func (s *Shell) stageAdd(patterns []string) error {
+	var paths []string
+	for _, pattern := range patterns {
+		resolved := s.client.ResolveLocalPath(pattern)
+		if !isGlob(pattern) {
+			paths = append(paths, resolved)
+			continue
+		}
+		matches, err := filepath.Glob(resolved)
+		if err != nil {
+			return fmt.Errorf("bad pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no match: %s", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+
+	// 先收集并检查全部文件，任何一个出错时暂存区保持不变
+	stage := s.stage
+	if stage == nil {
+		base := s.client.GetLocalwd()
+		if s.project != nil {
+			if _, ok := relPath(s.project.Local, paths[0]); ok {
+				base = s.project.Local
+			}
+		}
+		stage = &stageSet{base: base}
+	}
+
+	var files []string
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				files = append(files, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	entries := make([]client.StagedFile, 0, len(files))
+	for _, file := range files {
+		rel, ok := relPath(stage.base, file)
+		if !ok {
+			return fmt.Errorf("%s is outside the staging root %s (stage clear to start over elsewhere)", file, stage.base)
+		}
+		entries = append(entries, client.StagedFile{Local: file, Rel: rel})
+	}
+
+	staged := slices.Clone(stage.files)
+	added := 0
+	for _, entry := range entries {
+		i, found := slices.BinarySearchFunc(staged, entry.Rel, func(f client.StagedFile, rel string) int {
+			return strings.Compare(f.Rel, rel)
+		})
+		if !found {
+			staged = slices.Insert(staged, i, entry)
+			added++
+		}
+	}
+	stage.files = staged
+	s.stage = stage
+	fmt.Printf("✓ Staged %d new file(s), %d in total (root %s)\n", added, len(stage.files), stage.base)
+	return nil
+}
+
+// stageStatus 列出暂存的文件
+func (s *Shell) stageStatus() {
+	if s.stage == nil || len(s.stage.files) == 0 {
+		fmt.Println("Nothing staged (stage add <local-path>...)")
+		return
+	}
+	fmt.Printf("Staged under %s:\n", s.stage.base)
+	var total int64
+	for _, f := range s.stage.files {
+		info, err := os.Stat(f.Local)
+		if err != nil {
+			fmt.Printf("  %10s  %s (missing)\n", "-", f.Rel)
+			continue
+		}
+		total += info.Size()
+		fmt.Printf("  %10s  %s\n", units.FormatSize(info.Size()), f.Rel)
+	}
+	fmt.Printf("%d file(s), %s\n", len(s.stage.files), units.FormatSize(total))
+}
+
+// stageRemove 从暂存区移除文件；参数可以是暂存路径（status 中显示的）或本地路径，目录移除其下所有文件
+func (s *Shell) stageRemove(names []string) error {
+	if s.stage == nil {
+		return errors.New("nothing staged")
+	}
+	removed := 0
+	for _, name := range names {
+		rel := filepath.ToSlash(filepath.Clean(name))
+		if r, ok := relPath(s.stage.base, s.client.ResolveLocalPath(name)); ok {
+			rel = r
+		}
+		before := len(s.stage.files)
+		s.stage.files = slices.DeleteFunc(s.stage.files, func(f client.StagedFile) bool {
+			return f.Rel == rel || rel == "." || strings.HasPrefix(f.Rel, rel+"/")
+		})
+		if len(s.stage.files) == before {
+			return fmt.Errorf("not staged: %s", name)
+		}
+		removed += before - len(s.stage.files)
+	}
+	fmt.Printf("✓ Unstaged %d file(s), %d left\n", removed, len(s.stage.files))
+	return nil
+}
+
+// stagePush 推送暂存区到 remoteDir（默认为 project 的远程目录），成功后清空暂存区
+func (s *Shell) stagePush(remoteDir string) error {
+	if s.stage == nil || len(s.stage.files) == 0 {
+		return errors.New("nothing staged")
+	}
+	if remoteDir == "" {
+		if s.project == nil || s.stage.base != s.project.Local {
+			return errors.New("usage: stage push <remote-dir> (the remote dir may be omitted when staging inside a linked project)")
+		}
+		remoteDir = s.project.Remote
+	}
+	unlock, err := s.lockRemoteDir(remoteDir, "stage push")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	result, err := s.client.PushStaged(s.stage.files, remoteDir, true)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Pushed %d file(s) to %s (%d replaced, %d new)\n",
+		result.Replaced+result.Created, s.client.ResolveRemotePath(remoteDir), result.Replaced, result.Created)
+	s.stage = nil
+	return nil
+}
+
+// relPath 返回 p 相对于 root 的路径（/ 分隔），p 不在 root 之下时 ok 为 false
+func relPath(root, p string) (string, bool) {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
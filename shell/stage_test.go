@@ -0,0 +1,27 @@
+package shell
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRelPath(t *testing.T) {
+	root := filepath.FromSlash("/home/me/proj")
+	tests := []struct {
+		path string
+		rel  string
+		ok   bool
+	}{
+		{"/home/me/proj/src/app.go", "src/app.go", true},
+		{"/home/me/proj", ".", true},
+		{"/home/me/proj/..hidden", "..hidden", true},
+		{"/home/me/project/a.go", "", false},
+		{"/home/me/other.go", "", false},
+	}
+	for _, tt := range tests {
+		rel, ok := relPath(root, filepath.FromSlash(tt.path))
+		if rel != tt.rel || ok != tt.ok {
+			t.Fatalf("relPath(%q) = %q, %v, want %q, %v", tt.path, rel, ok, tt.rel, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/config"
+)
+
+type syncCLIOptions struct {
+	reverse       bool
+	bidirectional bool
+	dryRun        bool
+	json          bool
+	local         string
+	remote        string
+}
+
+// parseSyncCLIArgs 解析 sync 参数：sync [--reverse|--bidirectional] [--dry-run] [--json] [<local-dir> <remote-dir>]
+// 省略目录时 local/remote 为空，由 cmdSync 使用 project 映射
+func parseSyncCLIArgs(args []string) (*syncCLIOptions, error) {
+	opts := &syncCLIOptions{}
+	var positional []string
+	for _, tok := range args {
+		switch {
+		case tok == "--reverse":
+			opts.reverse = true
+		case tok == "--bidirectional":
+			opts.bidirectional = true
+		case tok == "--dry-run":
+			opts.dryRun = true
+		case tok == "--json":
+			opts.json = true
+		case strings.HasPrefix(tok, "-"):
+			return nil, fmt.Errorf("unknown option: %s", tok)
+		default:
+			positional = append(positional, tok)
+		}
+	}
+	if opts.reverse && opts.bidirectional {
+		return nil, fmt.Errorf("--reverse and --bidirectional cannot be used together")
+	}
+	switch len(positional) {
+	case 0:
+	case 2:
+		opts.local, opts.remote = positional[0], positional[1]
+	default:
+		return nil, fmt.Errorf("usage: sync [--reverse|--bidirectional] [--dry-run] [--json] [<local-dir> <remote-dir>]")
+	}
+	return opts, nil
+}
+
+// cmdSync 增量同步本地与远程目录
+func (s *Shell) cmdSync(args []string) error {
+	opts, err := parseSyncCLIArgs(args)
+	if err != nil {
+		return err
+	}
+	jsonOut := (opts.json || s.jsonOutput) && !opts.dryRun
+	if opts.local == "" {
+		if opts.local, opts.remote, err = s.projectSyncDirs(); err != nil {
+			return err
+		}
+		if !jsonOut {
+			fmt.Fprintf(s.infoOutput(jsonOut), "ℹ Project: %s <-> %s\n", opts.local, opts.remote)
+		}
+	}
+
+	// 写入远程目录时加锁，避免多人同时部署到同一目录
+	if !opts.dryRun && !opts.reverse {
+		unlock, err := s.lockRemoteDir(opts.remote, "sync")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	if opts.bidirectional {
+		return s.biSync(opts, jsonOut)
+	}
+
+	var router *client.Router
+	if !opts.reverse {
+		if router, err = s.projectRouter(); err != nil {
+			return err
+		}
+	}
+
+	if opts.dryRun {
+		entries, result, err := s.client.SyncManifest(opts.local, opts.remote, opts.reverse, router)
+		if err != nil {
+			return err
+		}
+		printSyncConflicts(os.Stdout, result)
+		fmt.Printf("[dry-run] %d new, %d updated, %d unchanged\n", result.New, result.Updated, result.Unchanged)
+		return client.WriteDryRun(os.Stdout, entries, !opts.reverse)
+	}
+
+	syncOpts := &client.SyncOptions{
+		Reverse:      opts.reverse,
+		ShowProgress: true,
+		Concurrency:  s.client.Concurrency(),
+		Scanner:      s.scanner,
+		Router:       router,
+		Log:          s.infoOutput(jsonOut),
+	}
+	if jsonOut {
+		syncOpts.ShowProgress = false
+		defer s.muteTransferEvents()()
+	}
+	if s.job != nil {
+		syncOpts.ShowProgress, syncOpts.Batch = false, s.job.batch
+	}
+	startTime := time.Now()
+	result, err := s.client.Sync(opts.local, opts.remote, syncOpts)
+	if result != nil && jsonOut {
+		target := s.client.ResolveRemotePath(opts.remote)
+		if opts.reverse {
+			target = s.client.ResolveLocalPath(opts.local)
+		}
+		if jsonErr := s.reportJSON(transferJSON{
+			Command:    "sync",
+			Files:      result.New + result.Updated,
+			Target:     target,
+			New:        &result.New,
+			Updated:    &result.Updated,
+			Unchanged:  &result.Unchanged,
+			Conflicts:  result.Conflicts,
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}); err == nil {
+			err = jsonErr
+		}
+	} else if result != nil {
+		printSyncConflicts(s.infoOutput(jsonOut), result)
+		s.report("✓ Sync: %d new, %d updated, %d skipped (unchanged) in %s",
+			result.New, result.Updated, result.Unchanged, time.Since(startTime).Round(time.Millisecond))
+	}
+	return err
+}
+
+func printSyncConflicts(w io.Writer, result *client.SyncResult) {
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(w, "Not synced (type conflict or special file): %s\n", conflict)
+	}
+}
+
+// biSync 双向同步：按状态数据库判断每个文件哪一端变化，两端都变化的文件只报告不处理
+func (s *Shell) biSync(opts *syncCLIOptions, jsonOut bool) error {
+	plan, err := s.client.PlanBiSync(opts.local, opts.remote, config.SyncStateDir())
+	if err != nil {
+		return err
+	}
+	if plan.FirstRun && !jsonOut {
+		fmt.Fprintln(s.infoOutput(jsonOut), "ℹ First two-way sync of these directories: files that differ on both sides are reported as conflicts")
+	}
+	if opts.dryRun {
+		printBiSyncPlan(plan)
+		return nil
+	}
+
+	syncOpts := &client.SyncOptions{
+		ShowProgress: !jsonOut,
+		Concurrency:  s.client.Concurrency(),
+		Scanner:      s.scanner,
+		Log:          s.infoOutput(jsonOut),
+	}
+	if jsonOut {
+		defer s.muteTransferEvents()()
+	}
+	if s.job != nil {
+		syncOpts.ShowProgress, syncOpts.Batch = false, s.job.batch
+	}
+	startTime := time.Now()
+	result, err := s.client.BiSync(plan, syncOpts)
+	if jsonOut {
+		if jsonErr := s.reportJSON(bisyncJSON{
+			Command:       "sync",
+			Mode:          "bidirectional",
+			Uploaded:      result.Uploaded,
+			Downloaded:    result.Downloaded,
+			DeletedRemote: result.DeletedRemote,
+			DeletedLocal:  result.DeletedLocal,
+			Unchanged:     result.Unchanged,
+			Conflicts:     append([]string{}, result.Conflicts...),
+			Skipped:       result.Skipped,
+			DurationMs:    time.Since(startTime).Milliseconds(),
+		}); err == nil {
+			err = jsonErr
+		}
+		return err
+	}
+	out := s.infoOutput(jsonOut)
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(out, "Not synced (type conflict or special file): %s\n", skipped)
+	}
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(out, "Conflict (changed on both sides, left untouched): %s\n", conflict)
+	}
+	s.report("✓ Sync (both ways): %d up, %d down, %d deleted remotely, %d deleted locally, %d unchanged, %d conflict(s) in %s",
+		result.Uploaded, result.Downloaded, result.DeletedRemote, result.DeletedLocal, result.Unchanged,
+		len(result.Conflicts), time.Since(startTime).Round(time.Millisecond))
+	return err
+}
+
+// printBiSyncPlan 输出 sync --bidirectional --dry-run 的计划
+func printBiSyncPlan(plan *client.BiSyncPlan) {
+	fmt.Printf("[dry-run] %d up, %d down, %d deletion(s), %d unchanged, %d conflict(s)\n",
+		len(plan.Upload), len(plan.Download), len(plan.DeleteRemote)+len(plan.DeleteLocal), plan.Unchanged, len(plan.Conflicts))
+	for _, group := range []struct {
+		label string
+		paths []string
+	}{
+		{"upload", plan.Upload},
+		{"download", plan.Download},
+		{"delete remote", plan.DeleteRemote},
+		{"delete local", plan.DeleteLocal},
+		{"conflict", plan.Conflicts},
+		{"skip", plan.Skipped},
+	} {
+		for _, rel := range group.paths {
+			fmt.Printf("  %-14s %s\n", group.label, rel)
+		}
+	}
+}
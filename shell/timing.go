@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+)
+
+const (
+	// slowCommandThreshold 耗时超过该值的命令在结束后显示耗时
+	slowCommandThreshold = 2 * time.Second
+	// slowRTTThreshold 服务器 RTT 超过该值时，需要访问服务器的 Tab 补全会先提示
+	slowRTTThreshold = 300 * time.Millisecond
+	// timingHistorySize timing 命令显示的最近命令数
+	timingHistorySize = 20
+)
+
+// commandTiming 一条命令的耗时记录
+type commandTiming struct {
+	line     string
+	started  time.Time
+	duration time.Duration
+}
+
+// timingLog 最近执行的命令及耗时
+type timingLog struct {
+	mu      sync.Mutex
+	entries []commandTiming
+}
+
+func (l *timingLog) add(entry commandTiming) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > timingHistorySize {
+		l.entries = l.entries[len(l.entries)-timingHistorySize:]
+	}
+}
+
+func (l *timingLog) list() []commandTiming {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]commandTiming(nil), l.entries...)
+}
+
+// reportsOwnDuration 报告命令是否自己显示耗时或本来就长时间运行（传输、监视、等待、远程/本地命令），这类命令不提示慢
+func reportsOwnDuration(cmd string) bool {
+	switch cmd {
+	case "get", "download", "put", "upload", "sync", "rwatch", "wait-for", "fg", "exec", "shell":
+		return true
+	}
+	return false
+}
+
+// timeCommand 执行命令并记录耗时；耗时超过阈值时显示一行提示，如 "⏱ ls took 4.2s — server latency ~400ms"
+func (s *Shell) timeCommand(line string) error {
+	start := time.Now()
+	err := s.executeCommand(line)
+	elapsed := time.Since(start)
+	s.timings.add(commandTiming{line: line, started: start, duration: elapsed})
+
+	fields := parseCommandLine(line)
+	if elapsed < slowCommandThreshold || len(fields) == 0 || reportsOwnDuration(fields[0]) ||
+		line[0] == '!' || line[len(line)-1] == '&' {
+		return err
+	}
+	msg := fmt.Sprintf("⏱ %s took %s", fields[0], elapsed.Round(100*time.Millisecond))
+	if rtt, samples := s.client.RTT(); samples > 0 {
+		msg += fmt.Sprintf(" — server latency ~%s", roundDuration(rtt))
+	}
+	fmt.Println(msg)
+	return err
+}
+
+// cmdTiming 测量当前服务器延迟并列出最近命令的耗时
+func (s *Shell) cmdTiming(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: timing")
+	}
+	if rtt, err := s.client.MeasureRTT(); err != nil {
+		fmt.Printf("Server latency: unavailable (%v)\n", err)
+	} else {
+		avg, samples := s.client.RTT()
+		fmt.Printf("Server latency: %s now, ~%s average over %d request(s)\n",
+			roundDuration(rtt), roundDuration(avg), samples)
+	}
+
+	entries := s.timings.list()
+	if len(entries) == 0 {
+		return nil
+	}
+	fmt.Println("Recent commands:")
+	for _, entry := range entries {
+		fmt.Printf("  %s  %8s  %s\n", entry.started.Format("15:04:05"),
+			roundDuration(entry.duration), entry.line)
+	}
+	return nil
+}
+
+// roundDuration 保留三位左右有效数字：低于 10ms 的时间（如本机网络的 RTT）精确到微秒
+func roundDuration(d time.Duration) time.Duration {
+	if d < 10*time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// completionClient 包装客户端：远程 Tab 补全需要访问服务器且延迟较高时，先提示一次可能较慢
+type completionClient struct {
+	*client.Client
+	out    io.Writer // 提示输出位置（readline 的 Stdout，不打断输入行）
+	warned bool
+}
+
+func (c *completionClient) ListCompletion(prefix string) []string {
+	// SFTP 未就绪（仍在启动或 exec-only）时不补全远程路径，避免阻塞输入
+	if !c.Ready() {
+		return nil
+	}
+	if !c.warned && c.out != nil && !c.CompletionCached(prefix) {
+		if rtt, samples := c.RTT(); samples > 0 && rtt >= slowRTTThreshold {
+			c.warned = true
+			fmt.Fprintf(c.out, "ℹ Server latency is ~%s: remote Tab completion waits for a directory listing "+
+				"(listings are then cached for %s)\n", rtt.Round(time.Millisecond), client.DirCacheTimeout)
+		}
+	}
+	return c.Client.ListCompletion(prefix)
+}
+
+func (c *completionClient) RemoteUsers() []string {
+	if !c.Ready() {
+		return nil
+	}
+	return c.Client.RemoteUsers()
+}
+
+func (c *completionClient) RemoteGroups() []string {
+	if !c.Ready() {
+		return nil
+	}
+	return c.Client.RemoteGroups()
+}
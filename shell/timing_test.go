@@ -0,0 +1,33 @@
+package shell
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTimingLogKeepsRecent(t *testing.T) {
+	var log timingLog
+	for i := 0; i < timingHistorySize+5; i++ {
+		log.add(commandTiming{line: fmt.Sprintf("ls %d", i)})
+	}
+	entries := log.list()
+	if len(entries) != timingHistorySize {
+		t.Fatalf("len = %d, want %d", len(entries), timingHistorySize)
+	}
+	if entries[0].line != "ls 5" || entries[len(entries)-1].line != fmt.Sprintf("ls %d", timingHistorySize+4) {
+		t.Fatalf("entries = %q ... %q", entries[0].line, entries[len(entries)-1].line)
+	}
+}
+
+func TestReportsOwnDuration(t *testing.T) {
+	for _, cmd := range []string{"get", "put", "sync", "rwatch", "fg"} {
+		if !reportsOwnDuration(cmd) {
+			t.Errorf("reportsOwnDuration(%q) = false", cmd)
+		}
+	}
+	for _, cmd := range []string{"ls", "cd", "grep", "snapshot"} {
+		if reportsOwnDuration(cmd) {
+			t.Errorf("reportsOwnDuration(%q) = true", cmd)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/frostime/my-sftp/client"
+	"github.com/frostime/my-sftp/units"
+)
+
+type watchCLIOptions struct {
+	interval  time.Duration
+	download  bool
+	localDir  string
+	maxEvents int
+	target    string
+}
+
+func parseWatchCLIArgs(args []string) (*watchCLIOptions, error) {
+	opts := &watchCLIOptions{interval: 2 * time.Second, localDir: "."}
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		switch tok {
+		case "-i", "--interval":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", tok)
+			}
+			d, err := units.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid interval: %s", args[i])
+			}
+			opts.interval = d
+		case "--get":
+			opts.download = true
+		case "-d", "--dir":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", tok)
+			}
+			opts.localDir = args[i]
+		case "-n", "--count":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", tok)
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid count: %s", args[i])
+			}
+			opts.maxEvents = n
+		default:
+			if len(tok) > 1 && tok[0] == '-' {
+				return nil, fmt.Errorf("unknown option: %s", tok)
+			}
+			if opts.target != "" {
+				return nil, fmt.Errorf("only one path can be watched")
+			}
+			opts.target = tok
+		}
+	}
+	if opts.target == "" {
+		return nil, fmt.Errorf("missing remote path")
+	}
+	return opts, nil
+}
+
+// cmdRwatch 轮询远程文件/目录并打印变化事件，可选自动下载变化的文件
+func (s *Shell) cmdRwatch(args []string) error {
+	opts, err := parseWatchCLIArgs(args)
+	if err != nil {
+		return fmt.Errorf("rwatch: %w\nusage: rwatch <remote-path> [-i interval] [-n count] [--get [-d local_dir]]", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fmt.Printf("Watching %s every %s (Ctrl-C to stop)\n", s.client.ResolveRemotePath(opts.target), opts.interval)
+
+	seen := 0
+	err = s.client.WatchRemote(ctx, opts.target, opts.interval, func(event client.WatchEvent) {
+		line := fmt.Sprintf("[%s] %-8s %s", time.Now().Format("15:04:05"), event.Kind, event.Path)
+		if event.Info != nil && !event.Info.IsDir() {
+			line += fmt.Sprintf(" (%s)", units.FormatSize(event.Info.Size()))
+		}
+		fmt.Println(line)
+
+		if opts.download && event.Info != nil && !event.Info.IsDir() {
+			localPath := s.client.ResolveLocalPath(filepath.Join(opts.localDir, path.Base(event.Path)))
+			if err := s.client.Download(event.Path, localPath); err != nil {
+				fmt.Printf("  ✗ get %s: %v\n", event.Path, err)
+			} else {
+				fmt.Printf("  ✓ saved to %s\n", localPath)
+			}
+		}
+
+		seen++
+		if opts.maxEvents > 0 && seen >= opts.maxEvents {
+			cancel()
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("rwatch: %w", err)
+	}
+	fmt.Println("Stopped watching.")
+	return nil
+}
+
+type waitForCLIOptions struct {
+	timeout  time.Duration
+	interval time.Duration
+	gone     bool
+	target   string
+}
+
+func parseWaitForCLIArgs(args []string) (*waitForCLIOptions, error) {
+	opts := &waitForCLIOptions{interval: time.Second}
+	for i := 0; i < len(args); i++ {
+		tok := args[i]
+		switch tok {
+		case "-t", "--timeout", "-i", "--interval":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", tok)
+			}
+			d, err := units.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid duration for %s: %s", tok, args[i])
+			}
+			if tok == "-t" || tok == "--timeout" {
+				opts.timeout = d
+			} else {
+				opts.interval = d
+			}
+		case "--gone":
+			opts.gone = true
+		default:
+			if len(tok) > 1 && tok[0] == '-' {
+				return nil, fmt.Errorf("unknown option: %s", tok)
+			}
+			if opts.target != "" {
+				return nil, fmt.Errorf("only one path can be waited for")
+			}
+			opts.target = tok
+		}
+	}
+	if opts.target == "" {
+		return nil, fmt.Errorf("missing remote path")
+	}
+	return opts, nil
+}
+
+// cmdWaitFor 阻塞直到远程路径出现（或 --gone 时消失），超时或 Ctrl-C 返回错误
+func (s *Shell) cmdWaitFor(args []string) error {
+	opts, err := parseWaitForCLIArgs(args)
+	if err != nil {
+		return fmt.Errorf("wait-for: %w\nusage: wait-for <remote-path> [--timeout 10m] [--interval 1s] [--gone]", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	target := s.client.ResolveRemotePath(opts.target)
+	state, reached := "exist", "exists"
+	if opts.gone {
+		state, reached = "disappear", "is gone"
+	}
+	fmt.Printf("Waiting for %s to %s...\n", target, state)
+
+	startTime := time.Now()
+	if err := s.client.WaitForPath(ctx, target, opts.gone, opts.interval); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("wait-for: timed out after %s waiting for %s to %s", opts.timeout, target, state)
+		}
+		if errors.Is(err, context.Canceled) {
+			return fmt.Errorf("wait-for: interrupted")
+		}
+		return fmt.Errorf("wait-for: %w", err)
+	}
+	fmt.Printf("✓ %s %s (waited %s)\n", target, reached, time.Since(startTime).Round(time.Millisecond))
+	return nil
+}
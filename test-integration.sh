@@ -0,0 +1,21 @@
+#!/bin/bash
+set -euo pipefail  # 错误时退出，未定义变量报错，管道任一失败则失败
+
+# 启动 OpenSSH 容器，运行带 integration 构建标签的测试，结束后清理容器
+COMPOSE_FILE=testdata/integration/docker-compose.yml
+
+docker compose -f "${COMPOSE_FILE}" up -d --wait
+trap 'docker compose -f "${COMPOSE_FILE}" down' EXIT
+
+# 等待 sshd 接受连接
+for _ in $(seq 1 30); do
+    if (exec 3<>/dev/tcp/127.0.0.1/2223) 2>/dev/null; then
+        break
+    fi
+    sleep 1
+done
+
+MY_SFTP_IT_ADDR=127.0.0.1:2223 MY_SFTP_IT_USER=tester MY_SFTP_IT_PASSWORD=tester \
+    go test -tags integration -count=1 "$@" ./client/...
+
+echo "✓ Integration tests passed"
@@ -0,0 +1,116 @@
+// Package ttystate 在进程于 raw 模式或关闭回显时退出的情况下恢复终端。
+//
+// Save 在启动时记录标准输入的终端状态；Restore 恢复该状态，收到 SIGTERM、SIGHUP 时执行，
+// panic 时由 Guard 执行。recover 只对所在的 goroutine 有效，所以 Guard 需要在 main
+// 以及每个可能在终端处于 raw 模式或关闭回显时 panic 的 goroutine（传输 worker、后台任务、
+// 提示符刷新等）中 defer 调用；第三方库自己启动的 goroutine（如 readline 内部的）发生 panic 时
+// 无法恢复终端，SIGKILL 同样无法处理。
+package ttystate
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	terminal "golang.org/x/term"
+)
+
+var (
+	mu    sync.Mutex
+	fd    = -1
+	state *terminal.State // 启动时标准输入的终端状态
+)
+
+// exit 收到终止信号时退出进程，测试中替换
+var exit = os.Exit
+
+// Save 记录标准输入的初始终端状态并开始处理终止信号；标准输入不是终端时只处理信号。
+// 返回的函数停止处理信号
+func Save() (stop func()) {
+	mu.Lock()
+	fd, state = -1, nil
+	if stdin := int(os.Stdin.Fd()); terminal.IsTerminal(stdin) {
+		if s, err := terminal.GetState(stdin); err == nil {
+			fd, state = stdin, s
+		}
+	}
+	mu.Unlock()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case s := <-sig:
+			exitOnSignal(s)
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sig)
+			close(done)
+		})
+	}
+}
+
+// Restore 把终端恢复为 Save 时的状态，返回是否恢复；未记录时什么也不做
+func Restore() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if state == nil {
+		return false
+	}
+	terminal.Restore(fd, state)
+	return true
+}
+
+// Guard 在 main 和 goroutine 中 defer 调用：panic 时先恢复终端再继续 panic
+func Guard() {
+	if r := recover(); r != nil {
+		Restore()
+		panic(r)
+	}
+}
+
+// MakeRaw 把 fd 切换到 raw 模式，返回恢复到切换前状态的函数
+func MakeRaw(fd int) (restore func(), err error) {
+	old, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	return func() { terminal.Restore(fd, old) }, nil
+}
+
+// ReadPassword 关闭回显读取一行。提示期间按 Ctrl-C 时恢复回显后退出（状态 130），
+// 而不是让默认的 SIGINT 处理在回显关闭的情况下结束进程
+func ReadPassword(fd int) ([]byte, error) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case s := <-sig:
+			exitOnSignal(s)
+		case <-done:
+		}
+	}()
+	return terminal.ReadPassword(fd)
+}
+
+// exitOnSignal 恢复终端后以 128+信号值退出（同 shell 的约定）
+func exitOnSignal(s os.Signal) {
+	if Restore() {
+		fmt.Println() // 光标可能停在提示或远程输出的行中
+	}
+	code := 1
+	if n, ok := s.(syscall.Signal); ok {
+		code = 128 + int(n)
+	}
+	exit(code)
+}
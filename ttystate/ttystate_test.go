@@ -0,0 +1,55 @@
+package ttystate
+
+import (
+	"os"
+	"testing"
+)
+
+// withPipeStdin 把标准输入换成管道（不是终端）
+func withPipeStdin(t *testing.T) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = old
+		r.Close()
+		w.Close()
+	})
+}
+
+func TestSaveRestoreWithoutTerminal(t *testing.T) {
+	withPipeStdin(t)
+	stop := Save()
+	defer stop()
+	if Restore() {
+		t.Error("Restore() = true for a non-terminal stdin, want false")
+	}
+	// 停止处理信号可以重复调用
+	stop()
+	stop()
+}
+
+func TestGuardRepanics(t *testing.T) {
+	withPipeStdin(t)
+	defer Save()()
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recover() = %v, want the original panic value", r)
+		}
+	}()
+	func() {
+		defer Guard()
+		panic("boom")
+	}()
+}
+
+func TestGuardWithoutPanic(t *testing.T) {
+	func() {
+		defer Guard()
+	}()
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package ttystate
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSaveHandlesSignalsUntilStopped(t *testing.T) {
+	withPipeStdin(t)
+	codes := make(chan int, 1)
+	exit = func(code int) { codes <- code }
+	defer func() { exit = os.Exit }()
+
+	stop := Save()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case code := <-codes:
+		if code != 128+int(syscall.SIGHUP) {
+			t.Errorf("exit code = %d, want %d", code, 128+int(syscall.SIGHUP))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGHUP was not handled")
+	}
+	stop()
+
+	// 停止后不再处理：信号只送到测试自己注册的通道
+	Save()()
+	hold := make(chan os.Signal, 1)
+	signal.Notify(hold, syscall.SIGHUP)
+	defer signal.Stop(hold)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	<-hold
+	select {
+	case code := <-codes:
+		t.Fatalf("exit(%d) called after the handler was stopped", code)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
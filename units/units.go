@@ -0,0 +1,120 @@
+// Package units 解析和格式化命令行、profile 和 Shell 命令中的大小与时长，
+// 使各处接受相同的写法（"10M"、"1.5g"、"2m30s"、"90"），输出使用相同的单位
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Style FormatSize 显示大小时使用的单位
+type Style int32
+
+const (
+	// Binary 以 1024 为进制，标为 KB、MB、GB（默认）
+	Binary Style = iota
+	// Decimal 以 1000 为进制，标为 kB、MB、GB，与硬盘厂商和多数文件管理器相同
+	Decimal
+)
+
+// StyleNames ParseStyle 接受的名称
+var StyleNames = []string{"binary", "decimal"}
+
+func (s Style) String() string {
+	if s == Decimal {
+		return "decimal"
+	}
+	return "binary"
+}
+
+// ParseStyle 解析 "binary"（或 "iec"、"1024"）和 "decimal"（或 "si"、"1000"）
+func ParseStyle(s string) (Style, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "binary", "iec", "1024":
+		return Binary, nil
+	case "decimal", "si", "1000":
+		return Decimal, nil
+	}
+	return Binary, fmt.Errorf("unknown size units %q (want binary or decimal)", s)
+}
+
+var style atomic.Int32
+
+// SetStyle 设置整个进程中 FormatSize 使用的单位
+func SetStyle(s Style) {
+	style.Store(int32(s))
+}
+
+// CurrentStyle 返回 FormatSize 当前使用的单位
+func CurrentStyle() Style {
+	return Style(style.Load())
+}
+
+// FormatSize 按当前的 Style 把字节数格式化为易读的形式，如 "2.9 MB"
+func FormatSize(bytes int64) string {
+	unit, prefixes := int64(1024), "KMGTPE"
+	if CurrentStyle() == Decimal {
+		unit, prefixes = 1000, "kMGTPE"
+	}
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := unit, 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), prefixes[exp])
+}
+
+// ParseSize 解析字节数，如 "512"、"64K"、"64kb"、"10 MiB" 或 "1.5G"。
+// 后缀不区分大小写，且与显示用的 Style 无关，总是以 1024 为进制（1K = 1024），已有的脚本和 profile 含义不变
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "IB"), "B")
+	multiplier := int64(1)
+	if n := len(value); n > 0 {
+		if exp := strings.IndexByte("KMGTPE", value[n-1]); exp >= 0 {
+			multiplier = int64(1) << (10 * (exp + 1))
+			value = value[:n-1]
+		}
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 || math.IsNaN(number) || number*float64(multiplier) >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// ParseDuration 解析时长，如 "90"（单独的数字为秒）、"2m30s"、"1.5h" 或 "1d12h"（d 为 24 小时），
+// 不接受负的时长
+func ParseDuration(s string) (time.Duration, error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	invalid := fmt.Errorf("invalid duration: %s", s)
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds < 0 || math.IsNaN(seconds) || seconds >= float64(math.MaxInt64/int64(time.Second)) {
+			return 0, invalid
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	var total time.Duration
+	if days, rest, ok := strings.Cut(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 || math.IsNaN(n) || n >= float64(math.MaxInt64/int64(24*time.Hour)) {
+			return 0, invalid
+		}
+		total, value = time.Duration(n*float64(24*time.Hour)), rest
+		if value == "" {
+			return total, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 || total+d < total {
+		return 0, invalid
+	}
+	return total + d, nil
+}
@@ -0,0 +1,84 @@
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"64K", 64 << 10},
+		{"64kb", 64 << 10},
+		{"64KiB", 64 << 10},
+		{"1.5G", 3 << 29},
+		{" 2M ", 2 << 20},
+		{"1m", 1 << 20},
+		{"10 MiB", 10 << 20},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "M", "-1K", "12X", "ten", "NaN", "9E"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) expected error", in)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"90", 90 * time.Second},
+		{"0.5", 500 * time.Millisecond},
+		{"2m30s", 150 * time.Second},
+		{"1.5h", 90 * time.Minute},
+		{"1D", 24 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{" 0 ", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "soon", "-5s", "-1", "1d-1h", "2x", "d", "inf"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) expected error", in)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	defer SetStyle(CurrentStyle())
+	tests := []struct {
+		style Style
+		in    int64
+		want  string
+	}{
+		{Binary, 999, "999 B"},
+		{Binary, 1536, "1.5 KB"},
+		{Binary, 3 << 20, "3.0 MB"},
+		{Decimal, 999, "999 B"},
+		{Decimal, 1536, "1.5 kB"},
+		{Decimal, 2_500_000_000, "2.5 GB"},
+	}
+	for _, tt := range tests {
+		SetStyle(tt.style)
+		if got := FormatSize(tt.in); got != tt.want {
+			t.Errorf("FormatSize(%d) in %s = %q, want %q", tt.in, tt.style, got, tt.want)
+		}
+	}
+}